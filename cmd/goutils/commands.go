@@ -0,0 +1,306 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/housekeeper"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/s3helper"
+	"github.com/romisugianto/go-utils/utils/splitter"
+	"github.com/romisugianto/go-utils/utils/textnorm"
+)
+
+// runSplit implements "goutils split"
+func runSplit(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("split", "<file>", stderr)
+	lines := fs.Int("lines", 10000, "lines per output file")
+	outputDir := fs.String("output", "output", "directory for the split files")
+	processedDir := fs.String("processed", "processed", "directory the source file is moved to")
+	normalize := fs.Bool("normalize", false, "convert the input to UTF-8 with LF line endings while splitting")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+
+	s, err := splitter.NewSplitter(log)
+	if err != nil {
+		return err
+	}
+	if *normalize {
+		s.Preprocess = textnorm.Preprocessor(&textnorm.Options{LineEnding: textnorm.LF})
+	}
+	return s.SplitFileByLines(fs.Arg(0), *lines, *outputDir, *processedDir)
+}
+
+// runNormalize implements "goutils normalize". Without -output the file is
+// rewritten in place; with -detect it is only inspected.
+func runNormalize(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("normalize", "<file>", stderr)
+	encodingName := fs.String("encoding", "auto", "source encoding: auto, utf-8, utf-16le, utf-16be, latin1 or windows-1252")
+	eolName := fs.String("eol", "lf", "line endings to write: lf, crlf or keep")
+	output := fs.String("output", "", "file to write instead of rewriting the input")
+	finalNewline := fs.Bool("final-newline", false, "add a line ending after the last line if missing")
+	detect := fs.Bool("detect", false, "only report the encoding and line endings")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	encoding, err := textnorm.ParseEncoding(*encodingName)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid -encoding %q\n", *encodingName)
+		fs.Usage()
+		return errUsage
+	}
+	eol, err := textnorm.ParseLineEnding(*eolName)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid -eol %q\n", *eolName)
+		fs.Usage()
+		return errUsage
+	}
+
+	path := fs.Arg(0)
+	var info *textnorm.Info
+	if *detect {
+		info, err = textnorm.DetectFile(path)
+	} else {
+		info, err = textnorm.NormalizeFile(path, *output, &textnorm.Options{Encoding: encoding, LineEnding: eol, FinalNewline: *finalNewline})
+	}
+	if err != nil {
+		return err
+	}
+	log.Summary("%s: %s, BOM: %t, line endings: %d CRLF, %d LF, %d CR", path, info.Encoding, info.BOM, info.CRLF, info.LF, info.CR)
+	return nil
+}
+
+// runHousekeep implements "goutils housekeep"
+func runHousekeep(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("housekeep", "<dir>", stderr)
+	maxAge := fs.Int("max-age", -1, "remove files older than this many days")
+	maxFiles := fs.Int("max-files", -1, "keep only the newest N files")
+	recursive := fs.Bool("recursive", false, "include subdirectories with -max-age")
+	if err := parseFlags(fs, args, 1); err != nil {
+		return err
+	}
+	if *maxAge < 0 && *maxFiles < 0 {
+		fmt.Fprintln(stderr, "one of -max-age or -max-files is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	h, err := housekeeper.NewHousekeeper(log)
+	if err != nil {
+		return err
+	}
+	if *maxAge >= 0 {
+		if err := h.HousekeepFilesByAge(fs.Arg(0), *maxAge, *recursive); err != nil {
+			return err
+		}
+	}
+	if *maxFiles >= 0 {
+		return h.HousekeepFilesByCount(fs.Arg(0), *maxFiles)
+	}
+	return nil
+}
+
+// runS3 implements the "goutils s3" subcommands
+func runS3(log *logger.Logger, args []string, stderr io.Writer) error {
+	return subcommand(log, "s3", map[string]command{
+		"upload":   runS3Upload,
+		"download": runS3Download,
+		"sync":     runS3Sync,
+	}, args, stderr)
+}
+
+// s3Flags registers the connection flags shared by the s3 subcommands
+func s3Flags(fs *flag.FlagSet, prefix string) func() *s3helper.S3Helper {
+	profile := fs.String(prefix+"profile", "default", "AWS profile name")
+	bucket := fs.String(prefix+"bucket", "", "bucket name")
+	region := fs.String(prefix+"region", "", "AWS region")
+	endpoint := fs.String(prefix+"endpoint", "", "custom endpoint URL, e.g. for MinIO")
+	pathStyle := fs.Bool(prefix+"path-style", false, "use path-style addressing")
+	return func() *s3helper.S3Helper {
+		return &s3helper.S3Helper{
+			ProfileName:    *profile,
+			BucketName:     *bucket,
+			Region:         *region,
+			EndpointURL:    *endpoint,
+			ForcePathStyle: *pathStyle,
+		}
+	}
+}
+
+// requireBucket reports a usage error when the bucket flag is missing
+func requireBucket(fs *flag.FlagSet, helper *s3helper.S3Helper, flagName string, stderr io.Writer) error {
+	if helper.BucketName == "" {
+		fmt.Fprintf(stderr, "-%s is required\n", flagName)
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// runS3Upload implements "goutils s3 upload". A directory is uploaded
+// recursively under the given prefix.
+func runS3Upload(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("s3 upload", "<local-path> <s3-path>", stderr)
+	helperFor := s3Flags(fs, "")
+	exclude := fs.String("exclude", "", "comma-separated glob patterns to skip when uploading a directory")
+	if err := parseFlags(fs, args, 2); err != nil {
+		return err
+	}
+	helper := helperFor()
+	if err := requireBucket(fs, helper, "bucket", stderr); err != nil {
+		return err
+	}
+
+	local, s3Path := fs.Arg(0), fs.Arg(1)
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if err := helper.UploadFile(local, s3Path); err != nil {
+			return err
+		}
+		log.Info("Uploaded %s to s3://%s/%s", local, helper.BucketName, s3Path)
+		return nil
+	}
+
+	filter := &s3helper.FileFilter{}
+	if *exclude != "" {
+		filter.Exclude = strings.Split(*exclude, ",")
+	}
+	keys, err := helper.UploadDirectory(local, s3Path, filter)
+	if err != nil {
+		return err
+	}
+	log.Summary("Uploaded %d file(s) from %s to s3://%s/%s", len(keys), local, helper.BucketName, s3Path)
+	return nil
+}
+
+// runS3Download implements "goutils s3 download"
+func runS3Download(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("s3 download", "<s3-path> <local-path>", stderr)
+	helperFor := s3Flags(fs, "")
+	if err := parseFlags(fs, args, 2); err != nil {
+		return err
+	}
+	helper := helperFor()
+	if err := requireBucket(fs, helper, "bucket", stderr); err != nil {
+		return err
+	}
+
+	if err := helper.DownloadFile(fs.Arg(0), fs.Arg(1)); err != nil {
+		return err
+	}
+	log.Info("Downloaded s3://%s/%s to %s", helper.BucketName, fs.Arg(0), fs.Arg(1))
+	return nil
+}
+
+// runS3Sync implements "goutils s3 sync". The destination connection flags
+// default to the source ones, so only -dst-bucket is needed within an account.
+func runS3Sync(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("s3 sync", "<src-prefix> <dst-prefix>", stderr)
+	srcFor := s3Flags(fs, "")
+	dstFor := s3Flags(fs, "dst-")
+	deleteOrphans := fs.Bool("delete", false, "delete destination objects missing from the source")
+	if err := parseFlags(fs, args, 2); err != nil {
+		return err
+	}
+	src, dst := srcFor(), dstFor()
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["dst-profile"] {
+		dst.ProfileName = src.ProfileName
+	}
+	if !set["dst-region"] {
+		dst.Region = src.Region
+	}
+	if !set["dst-endpoint"] {
+		dst.EndpointURL = src.EndpointURL
+	}
+	if !set["dst-path-style"] {
+		dst.ForcePathStyle = src.ForcePathStyle
+	}
+	if dst.BucketName == "" {
+		dst.BucketName = src.BucketName
+	}
+	if err := requireBucket(fs, src, "bucket", stderr); err != nil {
+		return err
+	}
+
+	result, err := src.SyncRemote(fs.Arg(0), dst, fs.Arg(1), *deleteOrphans)
+	if err != nil {
+		return err
+	}
+	log.Summary("Synced s3://%s/%s to s3://%s/%s", src.BucketName, fs.Arg(0), dst.BucketName, fs.Arg(1))
+	log.Summary("  - Copied: %d, skipped: %d, deleted: %d", len(result.Copied), len(result.Skipped), len(result.Deleted))
+	return nil
+}
+
+// runLogs implements the "goutils logs" subcommands
+func runLogs(log *logger.Logger, args []string, stderr io.Writer) error {
+	return subcommand(log, "logs", map[string]command{
+		"rotate": runLogsRotate,
+	}, args, stderr)
+}
+
+// runLogsRotate implements "goutils logs rotate". Log files last written
+// before today are compressed, keeping their modification time, and archives
+// older than -keep-days are removed.
+func runLogsRotate(log *logger.Logger, args []string, stderr io.Writer) error {
+	fs := newFlagSet("logs rotate", "", stderr)
+	dir := fs.String("dir", "logs", "log directory")
+	formatName := fs.String("format", "gzip", "compression format: gzip, zstd or bzip2")
+	keepDays := fs.Int("keep-days", 30, "remove archives older than this many days (0 keeps all)")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	format, err := compress.ParseFormat(*formatName)
+	if err != nil || format == compress.None {
+		fmt.Fprintf(stderr, "invalid -format %q\n", *formatName)
+		fs.Usage()
+		return errUsage
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	matches, err := filepath.Glob(filepath.Join(*dir, "*.log"))
+	if err != nil {
+		return err
+	}
+
+	var rotated int
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(today) {
+			continue
+		}
+		archive, err := compress.CompressFile(path, "", format, compress.BestCompression)
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(archive, info.ModTime(), info.ModTime()); err != nil {
+			return fmt.Errorf("failed to set time on %s: %w", archive, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		rotated++
+	}
+	log.Summary("Rotated %d log file(s) in %s", rotated, *dir)
+
+	if *keepDays <= 0 {
+		return nil
+	}
+	h, err := housekeeper.NewHousekeeper(log)
+	if err != nil {
+		return err
+	}
+	return h.HousekeepFilesByAge(*dir, *keepDays)
+}
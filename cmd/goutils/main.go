@@ -0,0 +1,155 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/romisugianto/go-utils/utils/buildinfo"
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+const usage = `Usage: goutils <command> [flags] [args]
+
+Commands:
+  split       Split a file into parts by line count
+  normalize   Convert a text file to UTF-8 with uniform line endings
+  housekeep   Remove files from a directory by age or count
+  s3 upload   Upload a file or directory to S3
+  s3 download Download an object from S3
+  s3 sync     Mirror objects between S3 prefixes or buckets
+  logs rotate Compress old log files and remove expired archives
+  version     Print the version, commit and build date
+
+Run "goutils <command> -h" for the flags of a command.
+`
+
+// stdout receives command output such as the version
+var stdout io.Writer = os.Stdout
+
+// errUsage reports invalid arguments; the usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+// command runs a subcommand with its arguments
+type command func(log *logger.Logger, args []string, stderr io.Writer) error
+
+var commands = map[string]command{
+	"split":     runSplit,
+	"normalize": runNormalize,
+	"housekeep": runHousekeep,
+	"s3":        runS3,
+	"logs":      runLogs,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run executes the command in args and returns the process exit code:
+// 0 on success, 1 when the command fails and 2 for invalid usage
+func run(args []string, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	// version does not need a logger, so it runs without creating a log file
+	if args[0] == "version" || args[0] == "--version" {
+		return runVersion(args[1:], stderr)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	log, err := logger.NewLogger("goutils")
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to create logger: %v\n", err)
+		return 1
+	}
+	defer log.Close()
+
+	if err := cmd(log, args[1:], stderr); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		log.Error("%s failed: %v", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// newFlagSet creates a flag set that reports errors to stderr instead of exiting
+func newFlagSet(name, argsUsage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: goutils %s [flags] %s\n\nFlags:\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and checks the number of positional arguments
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != nargs {
+		fmt.Fprintf(fs.Output(), "expected %d argument(s), got %d\n", nargs, fs.NArg())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// subcommand dispatches to one of the named subcommands, e.g. "s3 upload"
+func subcommand(log *logger.Logger, group string, subs map[string]command, args []string, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, "missing %s subcommand\n\n%s", group, usage)
+		return errUsage
+	}
+	sub, ok := subs[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown %s subcommand %q\n\n%s", group, args[0], usage)
+		return errUsage
+	}
+	return sub(log, args[1:], stderr)
+}
+
+// runVersion prints the build information, as JSON with -json
+func runVersion(args []string, stderr io.Writer) int {
+	fs := newFlagSet("version", "", stderr)
+	asJSON := fs.Bool("json", false, "print the build information as JSON")
+	if err := parseFlags(fs, args, 0); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	info := buildinfo.Get()
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(info)
+		return 0
+	}
+	fmt.Fprintf(stdout, "goutils %s\n", info)
+	return 0
+}
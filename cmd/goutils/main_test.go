@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/buildinfo"
+)
+
+func TestRunUsage(t *testing.T) {
+	cases := []struct {
+		args []string
+		code int
+	}{
+		{nil, 2},
+		{[]string{"help"}, 0},
+		{[]string{"unknown"}, 2},
+		{[]string{"s3"}, 2},
+		{[]string{"s3", "rename"}, 2},
+		{[]string{"split"}, 2},
+		{[]string{"split", "-h"}, 0},
+		{[]string{"housekeep", t.TempDir()}, 2},
+		{[]string{"s3", "upload", "a.csv", "data/a.csv"}, 2},
+		{[]string{"logs", "rotate", "-format", "lz4"}, 2},
+		{[]string{"normalize", "-eol", "cr", "a.csv"}, 2},
+	}
+	for _, c := range cases {
+		var stderr bytes.Buffer
+		if code := run(c.args, &stderr); code != c.code {
+			t.Errorf("run(%v) = %d, expected %d; output:\n%s", c.args, code, c.code, stderr.String())
+		}
+	}
+}
+
+func TestRunVersion(t *testing.T) {
+	var out, stderr bytes.Buffer
+	stdout = &out
+	t.Cleanup(func() { stdout = os.Stdout })
+
+	if code := run([]string{"version"}, &stderr); code != 0 {
+		t.Fatalf("version exited with %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(out.String(), "goutils "+buildinfo.Get().Version+" (") {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+
+	out.Reset()
+	if code := run([]string{"version", "-json"}, &stderr); code != 0 {
+		t.Fatalf("version -json exited with %d: %s", code, stderr.String())
+	}
+	var info buildinfo.Info
+	if err := json.Unmarshal(out.Bytes(), &info); err != nil || info.GoVersion == "" {
+		t.Errorf("unexpected JSON output %q: %v", out.String(), err)
+	}
+
+	if code := run([]string{"version", "extra"}, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for extra arguments, got %d", code)
+	}
+}
+
+func TestRunSplitAndHousekeep(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "orders.csv")
+	os.WriteFile(input, []byte("1\n2\n3\n4\n5\n"), 0644)
+	output := filepath.Join(dir, "out")
+
+	var stderr bytes.Buffer
+	args := []string{"split", "-lines", "2", "-output", output, "-processed", filepath.Join(dir, "done"), input}
+	if code := run(args, &stderr); code != 0 {
+		t.Fatalf("split exited with %d: %s", code, stderr.String())
+	}
+	parts, _ := os.ReadDir(output)
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+
+	if code := run([]string{"housekeep", "-max-files", "1", output}, &stderr); code != 0 {
+		t.Fatalf("housekeep exited with %d: %s", code, stderr.String())
+	}
+	if parts, _ := os.ReadDir(output); len(parts) != 1 {
+		t.Errorf("expected 1 file after housekeeping, got %d", len(parts))
+	}
+
+	if code := run([]string{"housekeep", "-max-age", "1", filepath.Join(dir, "missing")}, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for a missing directory, got %d", code)
+	}
+}
+
+func TestRunLogsRotate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(strings.Repeat("log line\n", 100)), 0644)
+		modTime := time.Now().Add(-age)
+		os.Chtimes(path, modTime, modTime)
+		return path
+	}
+	current := write("app_today.log", 0)
+	yesterday := write("app_yesterday.log", 48*time.Hour)
+	expired := write("app_old.log.gz", 40*24*time.Hour)
+
+	var stderr bytes.Buffer
+	if code := run([]string{"logs", "rotate", "-dir", dir, "-keep-days", "30"}, &stderr); code != 0 {
+		t.Fatalf("logs rotate exited with %d: %s", code, stderr.String())
+	}
+
+	if _, err := os.Stat(current); err != nil {
+		t.Errorf("expected today's log to be kept: %v", err)
+	}
+	if _, err := os.Stat(yesterday); !os.IsNotExist(err) {
+		t.Error("expected yesterday's log to be replaced by an archive")
+	}
+	if _, err := os.Stat(yesterday + ".gz"); err != nil {
+		t.Errorf("expected archive for yesterday's log: %v", err)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Error("expected expired archive to be removed")
+	}
+}
+
+func TestRunNormalize(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "export.csv")
+	os.WriteFile(input, []byte("id,name\r\n1,Caf\xe9\r\n"), 0644)
+	output := filepath.Join(dir, "out", "export.csv")
+
+	var stderr bytes.Buffer
+	if code := run([]string{"normalize", "-detect", input}, &stderr); code != 0 {
+		t.Fatalf("normalize -detect exited with %d: %s", code, stderr.String())
+	}
+	if code := run([]string{"normalize", "-output", output, input}, &stderr); code != 0 {
+		t.Fatalf("normalize exited with %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(output); string(data) != "id,name\n1,Café\n" {
+		t.Errorf("normalized content = %q", data)
+	}
+
+	// Split normalizes while reading the input
+	args := []string{"split", "-normalize", "-lines", "1", "-output", filepath.Join(dir, "parts"), "-processed", filepath.Join(dir, "done"), input}
+	if code := run(args, &stderr); code != 0 {
+		t.Fatalf("split -normalize exited with %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "parts", "export_part2.csv")); string(data) != "1,Café\n" {
+		t.Errorf("split part = %q", data)
+	}
+}
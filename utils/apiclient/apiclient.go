@@ -0,0 +1,322 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/httpclient"
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// maxErrorBody bounds the response body kept in an APIError
+const maxErrorBody = 4 << 10
+
+// Auth adds credentials to a request
+type Auth interface {
+	Apply(req *http.Request) error
+}
+
+// AuthFunc adapts a function to Auth, e.g. for signed requests or tokens
+// that are refreshed before they expire
+type AuthFunc func(req *http.Request) error
+
+// Apply calls f
+func (f AuthFunc) Apply(req *http.Request) error {
+	return f(req)
+}
+
+// Bearer sends token in an "Authorization: Bearer" header
+func Bearer(token string) Auth {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// Basic sends HTTP basic authentication credentials
+func Basic(username, password string) Auth {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Basic "+credentials)
+		return nil
+	})
+}
+
+// APIKey sends key in the named header, e.g. "X-API-Key". Keys are never
+// sent as query parameters, which would end up in logs.
+func APIKey(header, key string) Auth {
+	return AuthFunc(func(req *http.Request) error {
+		req.Header.Set(header, key)
+		return nil
+	})
+}
+
+// Options configures a Client
+type Options struct {
+	// Auth adds credentials to every request; nil sends none
+	Auth Auth
+	// HTTP configures the underlying client: timeout, retries on connection
+	// errors and 5xx responses, default headers and TLS transport
+	HTTP httpclient.Options
+}
+
+// Client sends JSON requests to a REST API below a base URL
+type Client struct {
+	base *url.URL
+	http *httpclient.Client
+	auth Auth
+}
+
+// APIError is returned for non-2xx responses. Code and Message are taken
+// from common JSON error bodies such as {"error": {"code": ..., "message": ...}},
+// {"code": ..., "message": ...} or RFC 7807 problem details.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Code       string
+	Message    string
+	// RequestID is the X-Request-Id header, for reporting to the API owner
+	RequestID string
+	// RetryAfter is the delay requested by a 429 or 503 response
+	RetryAfter time.Duration
+	// Body is the start of the response body
+	Body string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+	switch {
+	case e.Code != "" && e.Message != "":
+		msg += fmt.Sprintf(": %s: %s", e.Code, e.Message)
+	case e.Message != "":
+		msg += ": " + e.Message
+	case e.Code != "":
+		msg += ": " + e.Code
+	case e.Body != "":
+		msg += ": " + e.Body
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID %s)", e.RequestID)
+	}
+	return msg
+}
+
+// StatusCode returns the HTTP status of an *APIError in err's chain, or 0
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is an *APIError with status 404
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// New creates a client for the API at baseURL, e.g.
+// "https://inventory.internal/api/v2". Request paths are resolved relative to
+// it. A nil opts uses the defaults.
+func New(log *logger.Logger, baseURL string, opts *Options) (*Client, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	// Keep the base path when resolving relative references
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	client, err := httpclient.NewClient(log, &o.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{base: base, http: client, auth: o.Auth}, nil
+}
+
+// Get requests path with the query parameters and decodes the response into
+// result
+func (c *Client) Get(ctx context.Context, path string, query url.Values, result any) error {
+	return c.Do(ctx, http.MethodGet, path, query, nil, result)
+}
+
+// Post sends body as JSON and decodes the response into result
+func (c *Client) Post(ctx context.Context, path string, body, result any) error {
+	return c.Do(ctx, http.MethodPost, path, nil, body, result)
+}
+
+// Put sends body as JSON and decodes the response into result
+func (c *Client) Put(ctx context.Context, path string, body, result any) error {
+	return c.Do(ctx, http.MethodPut, path, nil, body, result)
+}
+
+// Patch sends body as JSON and decodes the response into result
+func (c *Client) Patch(ctx context.Context, path string, body, result any) error {
+	return c.Do(ctx, http.MethodPatch, path, nil, body, result)
+}
+
+// Delete deletes the resource at path
+func (c *Client) Delete(ctx context.Context, path string) error {
+	return c.Do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+// Do sends a request to path below the base URL. A non-nil body is encoded
+// as JSON; a non-nil result is decoded from a JSON response, or left
+// unchanged by an empty one. Non-2xx responses return an *APIError.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, result any) error {
+	target, err := c.resolve(path, query)
+	if err != nil {
+		return err
+	}
+	resp, data, err := c.send(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode response from %s %s: %w", method, resp.Request.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// resolve returns the absolute URL of path with the query parameters
+// appended to any already in path
+func (c *Client) resolve(path string, query url.Values) (*url.URL, error) {
+	ref, err := url.Parse(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	if ref.IsAbs() || ref.Host != "" {
+		return nil, fmt.Errorf("request path %q must be relative to the base URL", path)
+	}
+	target := c.base.ResolveReference(ref)
+	if len(query) > 0 {
+		values := target.Query()
+		for key, vals := range query {
+			for _, v := range vals {
+				values.Add(key, v)
+			}
+		}
+		target.RawQuery = values.Encode()
+	}
+	return target, nil
+}
+
+// send performs the request and returns the response with its body read
+func (c *Client) send(ctx context.Context, method string, target *url.URL, body any) (*http.Response, []byte, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		if err := c.auth.Apply(req); err != nil {
+			return nil, nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp, nil, newAPIError(req, resp, data)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, fmt.Errorf("failed to read response from %s %s: %w", method, req.URL.Redacted(), err)
+	}
+	return resp, data, nil
+}
+
+// newAPIError builds an *APIError, extracting the code and message from
+// common JSON error bodies
+func newAPIError(req *http.Request, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Body:       string(bytes.TrimSpace(body)),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return apiErr
+	}
+	// {"error": {"code": ..., "message": ...}} as used by Google and Azure APIs
+	if nested, ok := fields["error"]; ok {
+		var inner map[string]json.RawMessage
+		if json.Unmarshal(nested, &inner) == nil {
+			fields = inner
+		}
+	}
+	apiErr.Code = firstString(fields, "code", "error", "type")
+	apiErr.Message = firstString(fields, "message", "error_description", "detail", "title")
+	if apiErr.Message == apiErr.Code {
+		apiErr.Code = ""
+	}
+	return apiErr
+}
+
+// firstString returns the first of keys holding a JSON string or number
+func firstString(fields map[string]json.RawMessage, keys ...string) string {
+	for _, key := range keys {
+		if s := scalar(fields[key]); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// scalar returns a JSON string or number as a string, or "" for other values
+func scalar(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) == nil {
+		return n.String()
+	}
+	return ""
+}
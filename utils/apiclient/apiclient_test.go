@@ -0,0 +1,262 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/httpclient"
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestClient(t *testing.T, baseURL string, auth Auth) *Client {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("apiclient_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	opts := &Options{Auth: auth, HTTP: httpclient.Options{}}
+	opts.HTTP.Retry.InitialDelay = time.Millisecond
+	client, err := New(testLogger, baseURL, opts)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return client
+}
+
+func TestNew(t *testing.T) {
+	testLogger, _ := logger.NewLogger("apiclient_test")
+	defer testLogger.Close()
+	for _, base := range []string{"", "inventory/api", "://bad"} {
+		if _, err := New(testLogger, base, nil); err == nil {
+			t.Errorf("expected error for base URL %q", base)
+		}
+	}
+	if _, err := New(nil, "https://api.example.com", nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+}
+
+func TestRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/items/7":
+			if r.URL.Query().Get("expand") != "owner" {
+				t.Errorf("missing query parameter: %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(item{ID: 7, Name: "widget"})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/items":
+			if r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+			}
+			var in item
+			json.NewDecoder(r.Body).Decode(&in)
+			in.ID = 8
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(in)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL+"/api/v2", nil)
+	ctx := context.Background()
+
+	var got item
+	if err := client.Get(ctx, "/items/7", url.Values{"expand": {"owner"}}, &got); err != nil || got.Name != "widget" {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+	var created item
+	if err := client.Post(ctx, "items", item{Name: "gadget"}, &created); err != nil || created.ID != 8 {
+		t.Errorf("Post = %+v, %v", created, err)
+	}
+	if err := client.Delete(ctx, "items/8"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if err := client.Get(ctx, "https://evil.example.com/items", nil, nil); err == nil {
+		t.Error("expected error for absolute request path")
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   Auth
+		header string
+		value  string
+	}{
+		{"bearer", Bearer("token123"), "Authorization", "Bearer token123"},
+		{"basic", Basic("user", "pass"), "Authorization", "Basic dXNlcjpwYXNz"},
+		{"api key", APIKey("X-API-Key", "secret"), "X-API-Key", "secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tt.header) != tt.value {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			if err := newTestClient(t, server.URL, tt.auth).Get(context.Background(), "ping", nil, nil); err != nil {
+				t.Errorf("request failed: %v", err)
+			}
+		})
+	}
+
+	failing := AuthFunc(func(req *http.Request) error { return errors.New("token expired") })
+	err := newTestClient(t, "https://api.example.com", failing).Get(context.Background(), "ping", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "token expired") {
+		t.Errorf("expected auth error, got %v", err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    string
+		message string
+	}{
+		{"nested", 400, `{"error": {"code": "invalid_sku", "message": "SKU must be 8 digits"}}`, "invalid_sku", "SKU must be 8 digits"},
+		{"flat", 409, `{"code": 4091, "message": "already exists"}`, "4091", "already exists"},
+		{"oauth", 401, `{"error": "invalid_token", "error_description": "expired"}`, "invalid_token", "expired"},
+		{"problem", 404, `{"type": "about:blank", "title": "Not Found", "detail": "no item 9"}`, "about:blank", "no item 9"},
+		{"plain text", 403, `forbidden`, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "req-42")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := newTestClient(t, server.URL, nil).Get(context.Background(), "items/9", nil, nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.code || apiErr.Message != tt.message || apiErr.RequestID != "req-42" {
+				t.Errorf("unexpected error %+v", apiErr)
+			}
+			if StatusCode(fmt.Errorf("wrapped: %w", err)) != tt.status {
+				t.Error("StatusCode did not unwrap the error")
+			}
+			if !strings.Contains(err.Error(), "req-42") {
+				t.Errorf("error message lacks request ID: %v", err)
+			}
+		})
+	}
+
+	if IsNotFound(errors.New("other")) || StatusCode(nil) != 0 {
+		t.Error("expected non-API errors to have no status")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	err := newTestClient(t, server.URL, nil).Get(context.Background(), "items", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	const total = 7
+	page := func(offset, size int) []item {
+		var items []item
+		for i := offset; i < min(offset+size, total); i++ {
+			items = append(items, item{ID: i})
+		}
+		return items
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/pages":
+			n, _ := strconv.Atoi(q.Get("page"))
+			json.NewEncoder(w).Encode(map[string]any{"data": page((n-1)*3, 3)})
+		case "/cursor":
+			offset, _ := strconv.Atoi(q.Get("after"))
+			next := ""
+			if offset+3 < total {
+				next = strconv.Itoa(offset + 3)
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"result": map[string]any{"items": page(offset, 3)},
+				"meta":   map[string]any{"next_cursor": next},
+			})
+		case "/links":
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			if offset+3 < total {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/links?offset=%d>; rel="next", <%s/links?offset=6>; rel="last"`, server.URL, offset+3, server.URL))
+			}
+			json.NewEncoder(w).Encode(page(offset, 3))
+		case "/evil":
+			w.Header().Set("Link", `<https://evil.example.com/steal>; rel="next"`)
+			json.NewEncoder(w).Encode(page(0, 3))
+		}
+	}))
+	defer server.Close()
+	client := newTestClient(t, server.URL, Bearer("secret"))
+	ctx := context.Background()
+
+	tests := []struct {
+		path string
+		p    Pagination
+	}{
+		{"pages", Pagination{ItemsField: "data", PageParam: "page"}},
+		{"cursor", Pagination{ItemsField: "result.items", CursorParam: "after", CursorField: "meta.next_cursor"}},
+		{"links", Pagination{}},
+	}
+	for _, tt := range tests {
+		items, err := ListAll[item](ctx, client, tt.path, nil, tt.p)
+		if err != nil || len(items) != total || items[total-1].ID != total-1 {
+			t.Errorf("%s: ListAll = %+v, %v", tt.path, items, err)
+		}
+	}
+
+	pages := 0
+	err := List(ctx, client, "pages", nil, Pagination{ItemsField: "data", PageParam: "page", MaxPages: 2}, func(items []item) error {
+		pages++
+		return nil
+	})
+	if err != nil || pages != 2 {
+		t.Errorf("MaxPages: %d pages, %v", pages, err)
+	}
+
+	if _, err := ListAll[item](ctx, client, "evil", nil, Pagination{}); err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("expected refusal to follow a link to another host, got %v", err)
+	}
+	if _, err := ListAll[item](ctx, client, "cursor", nil, Pagination{CursorParam: "after"}); err == nil {
+		t.Error("expected error for incomplete cursor pagination")
+	}
+}
@@ -0,0 +1,178 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pagination describes how a listing endpoint returns its pages. Set
+// PageParam for page numbers or CursorParam and CursorField for cursors;
+// with neither, the Link header's rel="next" URL is followed.
+type Pagination struct {
+	// ItemsField is the dotted path of the items array in the response, e.g.
+	// "data" or "result.items"; empty when the response is the array itself
+	ItemsField string
+
+	// PageParam is the page number parameter, e.g. "page". Pages are
+	// requested from FirstPage (default 1) until one is empty.
+	PageParam string
+	FirstPage int
+
+	// CursorParam is the parameter receiving the cursor of the next page,
+	// read from the dotted CursorField of each response, e.g. "meta.next_cursor".
+	// Listing stops when the cursor is missing or empty.
+	CursorParam string
+	CursorField string
+
+	// MaxPages stops after this many pages (0 for no limit)
+	MaxPages int
+}
+
+// linkNext matches the next URL in a Link header, e.g. <https://...>; rel="next"
+var linkNext = regexp.MustCompile(`<([^>]*)>\s*;[^,]*\brel="?next"?`)
+
+// List requests every page of a listing endpoint and calls fn with the items
+// of each page, stopping at the first error returned by fn
+func List[T any](ctx context.Context, c *Client, path string, query url.Values, p Pagination, fn func(items []T) error) error {
+	if (p.CursorParam == "") != (p.CursorField == "") {
+		return fmt.Errorf("cursor pagination needs both CursorParam and CursorField")
+	}
+	target, err := c.resolve(path, query)
+	if err != nil {
+		return err
+	}
+	page := p.FirstPage
+	if page == 0 {
+		page = 1
+	}
+
+	for count := 1; ; count++ {
+		if p.PageParam != "" {
+			setParam(target, p.PageParam, strconv.Itoa(page))
+		}
+		resp, data, err := c.send(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+
+		var fields map[string]json.RawMessage
+		items, err := decodeItems[T](data, p.ItemsField, &fields)
+		if err != nil {
+			return fmt.Errorf("failed to decode page %d of %s: %w", count, target.Redacted(), err)
+		}
+		if len(items) > 0 {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+		if p.MaxPages > 0 && count >= p.MaxPages {
+			return nil
+		}
+
+		switch {
+		case p.PageParam != "":
+			if len(items) == 0 {
+				return nil
+			}
+			page++
+		case p.CursorParam != "":
+			cursor := lookupString(fields, p.CursorField)
+			if cursor == "" {
+				return nil
+			}
+			setParam(target, p.CursorParam, cursor)
+		default:
+			next, err := c.nextLink(resp)
+			if next == nil || err != nil {
+				return err
+			}
+			target = next
+		}
+	}
+}
+
+// ListAll collects the items of every page
+func ListAll[T any](ctx context.Context, c *Client, path string, query url.Values, p Pagination) ([]T, error) {
+	var all []T
+	err := List(ctx, c, path, query, p, func(items []T) error {
+		all = append(all, items...)
+		return nil
+	})
+	return all, err
+}
+
+// nextLink returns the rel="next" URL of the Link header, or nil on the last
+// page. Links to another host are refused so credentials are not leaked.
+func (c *Client) nextLink(resp *http.Response) (*url.URL, error) {
+	match := linkNext.FindStringSubmatch(resp.Header.Get("Link"))
+	if match == nil {
+		return nil, nil
+	}
+	next, err := resp.Request.URL.Parse(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid next page link %q: %w", match[1], err)
+	}
+	if next.Scheme != c.base.Scheme || next.Host != c.base.Host {
+		return nil, fmt.Errorf("refusing to follow next page link to %s", next.Redacted())
+	}
+	return next, nil
+}
+
+// decodeItems decodes the items of a page at the dotted field path, keeping
+// the top-level fields for cursor lookups
+func decodeItems[T any](data []byte, field string, fields *map[string]json.RawMessage) ([]T, error) {
+	var items []T
+	if field == "" {
+		return items, json.Unmarshal(data, &items)
+	}
+	if err := json.Unmarshal(data, fields); err != nil {
+		return nil, err
+	}
+	raw, ok := lookup(*fields, field)
+	if !ok || string(raw) == "null" {
+		return nil, nil
+	}
+	return items, json.Unmarshal(raw, &items)
+}
+
+// lookup returns the value at a dotted path such as "meta.next_cursor"
+func lookup(fields map[string]json.RawMessage, path string) (json.RawMessage, bool) {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		raw, ok := fields[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return raw, true
+		}
+		var nested map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &nested); err != nil {
+			return nil, false
+		}
+		fields = nested
+	}
+	return nil, false
+}
+
+// lookupString returns the string or number at a dotted path, or ""
+func lookupString(fields map[string]json.RawMessage, path string) string {
+	raw, ok := lookup(fields, path)
+	if !ok {
+		return ""
+	}
+	return scalar(raw)
+}
+
+// setParam replaces a query parameter of u
+func setParam(u *url.URL, key, value string) {
+	values := u.Query()
+	values.Set(key, value)
+	u.RawQuery = values.Encode()
+}
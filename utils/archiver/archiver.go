@@ -0,0 +1,580 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	aeszip "github.com/yeka/zip"
+
+	"github.com/romisugianto/go-utils/utils/iocopy"
+	"github.com/romisugianto/go-utils/utils/pathutil"
+	"github.com/romisugianto/go-utils/utils/ratelimit"
+)
+
+// Format identifies an archive format
+type Format string
+
+// Supported archive formats
+const (
+	TarGz Format = "tar.gz"
+	Zip   Format = "zip"
+)
+
+// ErrUnsafePath is returned when an archive entry would be written outside the destination
+var ErrUnsafePath = errors.New("unsafe path in archive")
+
+// Errors returned when extracting password-protected zip archives
+var (
+	ErrPasswordRequired = errors.New("archive entry is encrypted and no password was given")
+	ErrInvalidPassword  = errors.New("invalid archive password")
+)
+
+// Progress describes the state of an archive operation after each file
+type Progress struct {
+	Name  string // slash-separated path of the file just processed
+	Files int    // files processed so far
+	Bytes int64  // uncompressed bytes processed so far
+}
+
+// Options controls which files are archived or extracted
+type Options struct {
+	// Include limits processing to files matching at least one glob pattern
+	Include []string
+	// Exclude skips matching files and directories
+	Exclude []string
+	// MaxBytes aborts extraction when more uncompressed bytes would be written (0 for no limit)
+	MaxBytes int64
+	// OnProgress is called after each file is written
+	OnProgress func(p Progress)
+	// Limiter throttles extraction writes in bytes per second, e.g. to spare
+	// disk I/O on a shared host; nil is unlimited
+	Limiter *ratelimit.Limiter
+	// Password encrypts zip entries with AES-256 on Create and decrypts them
+	// on Extract; tar.gz archives cannot be encrypted
+	Password string
+}
+
+// DetectFormat returns the archive format for a path based on its extension
+func DetectFormat(archivePath string) (Format, error) {
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGz, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return Zip, nil
+	default:
+		return "", fmt.Errorf("unsupported archive extension: %s", archivePath)
+	}
+}
+
+// Create archives source (a directory or a single file) into archivePath.
+// The format is chosen from the archive extension. Paths inside the archive are
+// relative to source for directories, or the file name for single files.
+func Create(archivePath, source string, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	format, err := DetectFormat(archivePath)
+	if err != nil {
+		return err
+	}
+	if err := validatePatterns(opts); err != nil {
+		return err
+	}
+	if opts.Password != "" && format != Zip {
+		return fmt.Errorf("password protection requires a zip archive: %s", archivePath)
+	}
+
+	baseDir, entries, err := collect(source, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create archive %s: %w", archivePath, err)
+	}
+
+	switch format {
+	case TarGz:
+		err = writeTarGz(out, baseDir, entries, opts)
+	case Zip:
+		if opts.Password != "" {
+			err = writeEncryptedZip(out, baseDir, entries, opts)
+		} else {
+			err = writeZip(out, baseDir, entries, opts)
+		}
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("failed to create archive %s: %w", archivePath, err)
+	}
+	return nil
+}
+
+// Extract unpacks archivePath into destDir. Entries with absolute paths or ".."
+// components that would escape destDir are rejected with ErrUnsafePath, and
+// symbolic or hard links are skipped.
+func Extract(archivePath, destDir string, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	format, err := DetectFormat(archivePath)
+	if err != nil {
+		return err
+	}
+	if err := validatePatterns(opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	switch format {
+	case TarGz:
+		err = extractTarGz(archivePath, destDir, opts)
+	case Zip:
+		err = extractZip(archivePath, destDir, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", archivePath, err)
+	}
+	return nil
+}
+
+// entry is a file or directory selected for archiving
+type entry struct {
+	relPath string // slash-separated path inside the archive
+	info    fs.FileInfo
+}
+
+// collect walks source and returns the base directory and entries to archive
+func collect(source string, opts *Options) (string, []entry, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to access %s: %w", source, err)
+	}
+	if !info.IsDir() {
+		return filepath.Dir(source), []entry{{relPath: info.Name(), info: info}}, nil
+	}
+
+	var entries []entry
+	err = filepath.WalkDir(source, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == source {
+			return nil
+		}
+		relPath, err := filepath.Rel(source, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if matchAny(relPath, opts.Exclude) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && (!d.Type().IsRegular() || !included(relPath, opts)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{relPath: relPath, info: info})
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("error walking directory %s: %w", source, err)
+	}
+	return source, entries, nil
+}
+
+// writeTarGz writes the entries as a gzip-compressed tar stream
+func writeTarGz(w io.Writer, baseDir string, entries []entry, opts *Options) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	progress := Progress{}
+
+	for _, e := range entries {
+		header, err := tar.FileInfoHeader(e.info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = e.relPath
+		if e.info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if e.info.IsDir() {
+			continue
+		}
+
+		n, err := copyFile(tw, filepath.Join(baseDir, filepath.FromSlash(e.relPath)))
+		if err != nil {
+			return err
+		}
+		progress.report(opts, e.relPath, n)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeZip writes the entries as a deflate-compressed zip archive
+func writeZip(w io.Writer, baseDir string, entries []entry, opts *Options) error {
+	zw := zip.NewWriter(w)
+	progress := Progress{}
+
+	for _, e := range entries {
+		header, err := zip.FileInfoHeader(e.info)
+		if err != nil {
+			return err
+		}
+		header.Name = e.relPath
+		if e.info.IsDir() {
+			header.Name += "/"
+			if _, err := zw.CreateHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		n, err := copyFile(fw, filepath.Join(baseDir, filepath.FromSlash(e.relPath)))
+		if err != nil {
+			return err
+		}
+		progress.report(opts, e.relPath, n)
+	}
+
+	return zw.Close()
+}
+
+// writeEncryptedZip writes the entries as a zip archive with each file
+// deflated and encrypted with WinZip AES-256
+func writeEncryptedZip(w io.Writer, baseDir string, entries []entry, opts *Options) error {
+	zw := aeszip.NewWriter(w)
+	progress := Progress{}
+
+	for _, e := range entries {
+		header, err := aeszip.FileInfoHeader(e.info)
+		if err != nil {
+			return err
+		}
+		header.Name = e.relPath
+		if e.info.IsDir() {
+			header.Name += "/"
+			if _, err := zw.CreateHeader(header); err != nil {
+				return err
+			}
+			continue
+		}
+		header.Method = aeszip.Deflate
+		header.SetPassword(opts.Password)
+		header.SetEncryptionMethod(aeszip.AES256Encryption)
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		n, err := copyFile(fw, filepath.Join(baseDir, filepath.FromSlash(e.relPath)))
+		if err != nil {
+			return err
+		}
+		progress.report(opts, e.relPath, n)
+	}
+
+	return zw.Close()
+}
+
+// extractTarGz unpacks a gzip-compressed tar archive
+func extractTarGz(archivePath, destDir string, opts *Options) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	progress := Progress{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeTarget(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(path.Clean(filepath.ToSlash(header.Name)), "/")
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if !matchAny(name, opts.Exclude) {
+				if err := os.MkdirAll(target, 0755); err != nil {
+					return err
+				}
+			}
+		case tar.TypeReg:
+			if !selected(name, opts) {
+				continue
+			}
+			if err := progress.checkLimit(opts, header.Size); err != nil {
+				return err
+			}
+			n, err := writeFile(target, tr, header.FileInfo().Mode(), opts, progress.Bytes)
+			if err != nil {
+				return err
+			}
+			os.Chtimes(target, header.ModTime, header.ModTime)
+			progress.report(opts, name, n)
+		}
+	}
+}
+
+// zipEntry is an entry of a plain or encrypted zip archive
+type zipEntry struct {
+	name     string
+	mode     fs.FileMode
+	size     uint64
+	modified time.Time
+	open     func() (io.ReadCloser, error)
+}
+
+// extractZip unpacks a zip archive, reading it with the AES-capable reader
+// when a password is given
+func extractZip(archivePath, destDir string, opts *Options) error {
+	if opts.Password != "" {
+		zr, err := aeszip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		entries := make([]zipEntry, len(zr.File))
+		for i, f := range zr.File {
+			if f.IsEncrypted() {
+				f.SetPassword(opts.Password)
+			}
+			entries[i] = zipEntry{name: f.Name, mode: f.Mode(), size: f.UncompressedSize64, modified: f.ModTime(), open: f.Open}
+		}
+		return extractZipEntries(entries, destDir, opts)
+	}
+
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	entries := make([]zipEntry, len(zr.File))
+	for i, f := range zr.File {
+		entries[i] = zipEntry{name: f.Name, mode: f.Mode(), size: f.UncompressedSize64, modified: f.Modified, open: f.Open}
+		if f.Flags&0x1 != 0 {
+			// Fail only if the encrypted entry is actually extracted
+			entries[i].open = func() (io.ReadCloser, error) { return nil, ErrPasswordRequired }
+		}
+	}
+	return extractZipEntries(entries, destDir, opts)
+}
+
+// extractZipEntries writes the selected zip entries below destDir
+func extractZipEntries(entries []zipEntry, destDir string, opts *Options) error {
+	progress := Progress{}
+	for _, f := range entries {
+		target, err := safeTarget(destDir, f.name)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(path.Clean(filepath.ToSlash(f.name)), "/")
+
+		if f.mode.IsDir() {
+			if !matchAny(name, opts.Exclude) {
+				if err := os.MkdirAll(target, 0755); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !f.mode.IsRegular() || !selected(name, opts) {
+			continue
+		}
+		if err := progress.checkLimit(opts, int64(f.size)); err != nil {
+			return err
+		}
+
+		rc, err := f.open()
+		if err != nil {
+			return zipEntryError(name, err)
+		}
+		n, err := writeFile(target, rc, f.mode, opts, progress.Bytes)
+		rc.Close()
+		if err != nil {
+			os.Remove(target)
+			return zipEntryError(name, err)
+		}
+		os.Chtimes(target, f.modified, f.modified)
+		progress.report(opts, name, n)
+	}
+	return nil
+}
+
+// zipEntryError maps decryption failures of an entry to the package errors
+func zipEntryError(name string, err error) error {
+	switch {
+	case errors.Is(err, ErrPasswordRequired):
+		return fmt.Errorf("%w: %s", ErrPasswordRequired, name)
+	case errors.Is(err, aeszip.ErrPassword):
+		return fmt.Errorf("%w: %s", ErrInvalidPassword, name)
+	}
+	return err
+}
+
+// safeTarget resolves an archive entry name inside destDir, rejecting traversal
+func safeTarget(destDir, name string) (string, error) {
+	target, err := pathutil.SafeJoin(destDir, name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+	}
+	return target, nil
+}
+
+// writeFile writes r to target, creating parent directories and enforcing MaxBytes
+func writeFile(target string, r io.Reader, mode fs.FileMode, opts *Options, written int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()|0200)
+	if err != nil {
+		return 0, err
+	}
+
+	if opts.MaxBytes > 0 {
+		// Read one byte past the limit so oversized entries are detected
+		r = io.LimitReader(r, opts.MaxBytes-written+1)
+	}
+	copied, err := iocopy.Copy(context.Background(), out, r, &iocopy.Options{Limiter: opts.Limiter})
+	n := copied.Bytes
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	if opts.MaxBytes > 0 && written+n > opts.MaxBytes {
+		os.Remove(target)
+		return n, fmt.Errorf("archive exceeds maximum size of %d bytes", opts.MaxBytes)
+	}
+	return n, nil
+}
+
+// copyFile streams a file into w and returns the bytes written
+func copyFile(w io.Writer, filePath string) (int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return io.Copy(w, file)
+}
+
+// report records a processed file and invokes the progress callback
+func (p *Progress) report(opts *Options, name string, n int64) {
+	p.Name = name
+	p.Files++
+	p.Bytes += n
+	if opts.OnProgress != nil {
+		opts.OnProgress(*p)
+	}
+}
+
+// checkLimit fails early when the declared entry size already exceeds MaxBytes
+func (p *Progress) checkLimit(opts *Options, size int64) error {
+	if opts.MaxBytes > 0 && p.Bytes+size > opts.MaxBytes {
+		return fmt.Errorf("archive exceeds maximum size of %d bytes", opts.MaxBytes)
+	}
+	return nil
+}
+
+// selected reports whether a file entry passes the include and exclude patterns,
+// also excluding files below an excluded directory
+func selected(name string, opts *Options) bool {
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		if matchAny(strings.Join(parts[:i], "/"), opts.Exclude) {
+			return false
+		}
+	}
+	return !matchAny(name, opts.Exclude) && included(name, opts)
+}
+
+// included reports whether name matches the include patterns (or none are set)
+func included(name string, opts *Options) bool {
+	return len(opts.Include) == 0 || matchAny(name, opts.Include)
+}
+
+// matchAny reports whether the relative path or its base name matches any pattern
+func matchAny(relPath string, patterns []string) bool {
+	base := path.Base(relPath)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatterns checks that all glob patterns are well-formed
+func validatePatterns(opts *Options) error {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
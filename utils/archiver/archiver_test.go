@@ -0,0 +1,263 @@
+package archiver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func createTree(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	return root
+}
+
+func listTree(t *testing.T, root string) []string {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to list %s: %v", root, err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestCreateAndExtract(t *testing.T) {
+	source := createTree(t, map[string]string{
+		"a.log":          "alpha",
+		"b.tmp":          "temp",
+		"sub/c.log":      "charlie",
+		"cache/skip.log": "cached",
+	})
+
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		t.Run(ext, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "logs"+ext)
+			var progress []Progress
+			opts := &Options{
+				Exclude:    []string{"*.tmp", "cache"},
+				OnProgress: func(p Progress) { progress = append(progress, p) },
+			}
+
+			if err := Create(archivePath, source, opts); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if len(progress) != 2 || progress[1].Files != 2 || progress[1].Bytes != int64(len("alpha")+len("charlie")) {
+				t.Errorf("unexpected progress: %+v", progress)
+			}
+
+			dest := filepath.Join(t.TempDir(), "out")
+			if err := Extract(archivePath, dest, nil); err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+			if got := strings.Join(listTree(t, dest), ","); got != "a.log,sub/c.log" {
+				t.Errorf("extracted files = %s", got)
+			}
+			content, _ := os.ReadFile(filepath.Join(dest, "sub", "c.log"))
+			if string(content) != "charlie" {
+				t.Errorf("unexpected content %q", content)
+			}
+		})
+	}
+}
+
+func TestExtractInclude(t *testing.T) {
+	source := createTree(t, map[string]string{"a.csv": "a", "b.log": "b", "sub/c.csv": "c"})
+	archivePath := filepath.Join(t.TempDir(), "data.zip")
+	if err := Create(archivePath, source, nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := Extract(archivePath, dest, &Options{Include: []string{"*.csv"}}); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if got := strings.Join(listTree(t, dest), ","); got != "a.csv,sub/c.csv" {
+		t.Errorf("extracted files = %s", got)
+	}
+}
+
+func TestCreateSingleFile(t *testing.T) {
+	source := createTree(t, map[string]string{"report.csv": "data"})
+	archivePath := filepath.Join(t.TempDir(), "report.tar.gz")
+	if err := Create(archivePath, filepath.Join(source, "report.csv"), nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	dest := t.TempDir()
+	if err := Extract(archivePath, dest, nil); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if got := strings.Join(listTree(t, dest), ","); got != "report.csv" {
+		t.Errorf("extracted files = %s", got)
+	}
+}
+
+func writeMaliciousTar(t *testing.T, path, name string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 4, Typeflag: tar.TypeReg})
+	tw.Write([]byte("evil"))
+	tw.Close()
+	gz.Close()
+}
+
+func writeMaliciousZip(t *testing.T, path, name string) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	w, _ := zw.Create(name)
+	w.Write([]byte("evil"))
+	zw.Close()
+}
+
+func TestExtractRejectsTraversal(t *testing.T) {
+	names := []string{"../evil.txt", "sub/../../evil.txt", "/etc/evil.txt"}
+
+	for _, name := range names {
+		for _, ext := range []string{".tar.gz", ".zip"} {
+			t.Run(name+ext, func(t *testing.T) {
+				dir := t.TempDir()
+				archivePath := filepath.Join(dir, "bad"+ext)
+				if ext == ".zip" {
+					writeMaliciousZip(t, archivePath, name)
+				} else {
+					writeMaliciousTar(t, archivePath, name)
+				}
+
+				dest := filepath.Join(dir, "dest")
+				err := Extract(archivePath, dest, nil)
+				if !errors.Is(err, ErrUnsafePath) {
+					t.Errorf("expected ErrUnsafePath, got %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+					t.Error("file was written outside the destination")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractMaxBytes(t *testing.T) {
+	source := createTree(t, map[string]string{"big.txt": strings.Repeat("x", 1024)})
+	archivePath := filepath.Join(t.TempDir(), "big.tar.gz")
+	if err := Create(archivePath, source, nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if err := Extract(archivePath, t.TempDir(), &Options{MaxBytes: 100}); err == nil {
+		t.Error("expected error when exceeding MaxBytes")
+	}
+	if err := Extract(archivePath, t.TempDir(), &Options{MaxBytes: 2048}); err != nil {
+		t.Errorf("unexpected error under MaxBytes: %v", err)
+	}
+}
+
+func TestPasswordZip(t *testing.T) {
+	source := createTree(t, map[string]string{
+		"orders.csv":       "id,amount\n1,100\n",
+		"sub/invoices.csv": strings.Repeat("invoice,", 1000),
+	})
+	archivePath := filepath.Join(t.TempDir(), "delivery.zip")
+	if err := Create(archivePath, source, &Options{Password: "s3cret"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Entries are encrypted, so the plain zip reader cannot read them
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, "/") && f.Flags&0x1 == 0 {
+			t.Errorf("entry %s is not encrypted", f.Name)
+		}
+	}
+	zr.Close()
+
+	dest := filepath.Join(t.TempDir(), "out")
+	if err := Extract(archivePath, dest, &Options{Password: "s3cret"}); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if got := strings.Join(listTree(t, dest), ","); got != "orders.csv,sub/invoices.csv" {
+		t.Errorf("extracted files = %s", got)
+	}
+	content, _ := os.ReadFile(filepath.Join(dest, "sub", "invoices.csv"))
+	if string(content) != strings.Repeat("invoice,", 1000) {
+		t.Errorf("unexpected content of length %d", len(content))
+	}
+
+	if err := Extract(archivePath, t.TempDir(), nil); !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("expected ErrPasswordRequired, got %v", err)
+	}
+	wrongDest := t.TempDir()
+	if err := Extract(archivePath, wrongDest, &Options{Password: "wrong"}); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected ErrInvalidPassword, got %v", err)
+	}
+	if files := listTree(t, wrongDest); len(files) != 0 {
+		t.Errorf("expected nothing extracted with a wrong password, got %v", files)
+	}
+
+	// Plain archives still extract when a password is given
+	plainPath := filepath.Join(t.TempDir(), "plain.zip")
+	if err := Create(plainPath, source, nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := Extract(plainPath, t.TempDir(), &Options{Password: "unused"}); err != nil {
+		t.Errorf("Extract of plain archive with password failed: %v", err)
+	}
+
+	if err := Create(filepath.Join(t.TempDir(), "a.tar.gz"), source, &Options{Password: "s3cret"}); err == nil {
+		t.Error("expected error for an encrypted tar.gz")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    Format
+		wantErr bool
+	}{
+		{"a.tar.gz", TarGz, false},
+		{"A.TGZ", TarGz, false},
+		{"a.zip", Zip, false},
+		{"a.rar", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, %v", tt.path, got, err)
+		}
+	}
+}
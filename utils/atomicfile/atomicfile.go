@@ -0,0 +1,117 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package atomicfile
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPerm is the permission of new files when none is given
+const DefaultPerm fs.FileMode = 0644
+
+// Writer writes a file through a temporary file in the same directory, so
+// readers see either the previous content or the complete new content but
+// never a partial file. Close publishes the file and Abort discards it.
+type Writer struct {
+	path string
+	perm fs.FileMode
+	tmp  *os.File
+	done bool
+}
+
+// Create starts writing path, creating its directory. The file gets perm
+// when given, otherwise the permissions of the file it replaces, or
+// DefaultPerm for a new file.
+func Create(path string, perm ...fs.FileMode) (*Writer, error) {
+	mode := DefaultPerm
+	if len(perm) > 0 {
+		mode = perm[0]
+	} else if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file for %s: %w", path, err)
+	}
+	return &Writer{path: path, perm: mode, tmp: tmp}, nil
+}
+
+// Write writes p to the temporary file
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.tmp.Write(p)
+}
+
+// Name returns the path the file is published to
+func (w *Writer) Name() string {
+	return w.path
+}
+
+// Close flushes the file to disk, sets its permissions and renames it into
+// place. The file is discarded if any step fails.
+func (w *Writer) Close() error {
+	if w.done {
+		return fmt.Errorf("file %s already closed", w.path)
+	}
+	w.done = true
+
+	err := w.tmp.Chmod(w.perm)
+	if err == nil {
+		err = w.tmp.Sync()
+	}
+	if closeErr := w.tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(w.tmp.Name(), w.path)
+	}
+	if err != nil {
+		os.Remove(w.tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", w.path, err)
+	}
+	syncDir(filepath.Dir(w.path))
+	return nil
+}
+
+// Abort discards the temporary file, leaving path untouched. It does nothing
+// after Close, so it can be deferred right after Create.
+func (w *Writer) Abort() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	w.tmp.Close()
+	if err := os.Remove(w.tmp.Name()); err != nil {
+		return fmt.Errorf("failed to remove temporary file for %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// WriteFile atomically replaces path with data, with the same permission
+// rules as Create
+func WriteFile(path string, data []byte, perm ...fs.FileMode) error {
+	w, err := Create(path, perm...)
+	if err != nil {
+		return err
+	}
+	defer w.Abort()
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return w.Close()
+}
+
+// syncDir flushes a directory so a rename survives a crash. Errors are
+// ignored because some platforms and file systems cannot sync directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,98 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf", "app.json")
+
+	if err := WriteFile(path, []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != DefaultPerm {
+		t.Errorf("mode = %v, expected %v", info.Mode().Perm(), DefaultPerm)
+	}
+
+	// Replacing keeps the existing permissions unless given
+	os.Chmod(path, 0600)
+	if err := WriteFile(path, []byte(`{"a":2}`)); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"a":2}` {
+		t.Errorf("content = %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, expected 0600 to be preserved", info.Mode().Perm())
+	}
+	if err := WriteFile(path, nil, 0640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 || info.Size() != 0 {
+		t.Errorf("unexpected file %v, size %d", info.Mode(), info.Size())
+	}
+
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.csv")
+	os.WriteFile(path, []byte("old\n"), 0644)
+
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	w.Write([]byte("new\n"))
+	if data, _ := os.ReadFile(path); string(data) != "old\n" {
+		t.Errorf("file replaced before Close: %q", data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("content = %q", data)
+	}
+	if err := w.Abort(); err != nil {
+		t.Errorf("Abort after Close should do nothing, got %v", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Error("expected error closing twice")
+	}
+
+	// An aborted writer leaves the file untouched
+	w, err = Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	w.Write([]byte("partial"))
+	if err := w.Abort(); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("content = %q after Abort", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	// A failed rename, here onto a directory, discards the temporary file
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "x"), nil, 0644)
+	if err := WriteFile(filepath.Join(dir, "sub"), []byte("x")); err == nil {
+		t.Error("expected error replacing a non-empty directory")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
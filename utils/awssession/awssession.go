@@ -0,0 +1,144 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package awssession
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Config holds the credential, endpoint and TLS settings shared by the AWS helpers
+type Config struct {
+	ProfileName string
+	Region      string
+	// EndpointURL overrides the service endpoint, e.g. for LocalStack or MinIO
+	EndpointURL string
+
+	// CABundlePath is an optional PEM file with root CAs trusted for the endpoint,
+	// in addition to the system pool (e.g. an internal CA for on-prem services)
+	CABundlePath string
+	// ClientCertPath and ClientKeyPath enable mutual TLS with the endpoint
+	ClientCertPath string
+	ClientKeyPath  string
+	// InsecureSkipVerify disables certificate verification for this client only
+	InsecureSkipVerify bool
+
+	// RoleARN assumes an IAM role through STS using the profile credentials as the source.
+	// Temporary credentials are refreshed automatically before they expire.
+	RoleARN string
+	// ExternalID is passed to AssumeRole when the role's trust policy requires it
+	ExternalID string
+	// RoleSessionName identifies the assumed-role session (defaults to an SDK-generated name)
+	RoleSessionName string
+	// RoleDuration is the lifetime of the assumed-role credentials (defaults to 15 minutes)
+	RoleDuration time.Duration
+}
+
+// NewSession creates an AWS session from the configuration. configure, when
+// not nil, adjusts the service settings (e.g. S3 addressing) before the
+// session is created.
+func (c Config) NewSession(configure func(config *aws.Config) error) (*session.Session, error) {
+	config := aws.Config{
+		Region:      aws.String(c.Region),
+		Credentials: credentials.NewSharedCredentials("", c.ProfileName),
+	}
+	if c.EndpointURL != "" {
+		config.Endpoint = aws.String(c.EndpointURL)
+	}
+	if configure != nil {
+		if err := configure(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient, err := c.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		config.HTTPClient = httpClient
+	}
+
+	if c.RoleARN != "" {
+		// STS must use its own endpoint, not the service endpoint
+		stsConfig := config
+		stsConfig.Endpoint = nil
+		stsSess, err := session.NewSessionWithOptions(session.Options{Config: stsConfig})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS session: %v", err)
+		}
+		config.Credentials = stscreds.NewCredentials(stsSess, c.RoleARN, c.AssumeRoleOptions)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{Config: config})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %v", err)
+	}
+	return sess, nil
+}
+
+// AssumeRoleOptions applies the role settings to an STS credential provider
+func (c Config) AssumeRoleOptions(p *stscreds.AssumeRoleProvider) {
+	if c.ExternalID != "" {
+		p.ExternalID = aws.String(c.ExternalID)
+	}
+	if c.RoleSessionName != "" {
+		p.RoleSessionName = c.RoleSessionName
+	}
+	if c.RoleDuration > 0 {
+		p.Duration = c.RoleDuration
+	}
+	// Refresh shortly before expiry so long transfers never see expired credentials
+	p.ExpiryWindow = time.Minute
+}
+
+// HTTPClient builds an HTTP client with the configured TLS settings.
+// It returns nil when no TLS customization is configured so the SDK default is used.
+func (c Config) HTTPClient() (*http.Client, error) {
+	if c.CABundlePath == "" && c.ClientCertPath == "" && c.ClientKeyPath == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CABundlePath != "" {
+		pemData, err := os.ReadFile(c.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %q: %v", c.CABundlePath, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no valid certificates found in CA bundle %q", c.CABundlePath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertPath != "" || c.ClientKeyPath != "" {
+		if c.ClientCertPath == "" || c.ClientKeyPath == "" {
+			return nil, fmt.Errorf("both ClientCertPath and ClientKeyPath must be set for client TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
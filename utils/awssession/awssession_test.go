@@ -0,0 +1,56 @@
+package awssession
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestNewSession(t *testing.T) {
+	config := Config{ProfileName: "default", Region: "eu-west-1", EndpointURL: "http://localhost:4566"}
+
+	sess, err := config.NewSession(nil)
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	if aws.StringValue(sess.Config.Region) != "eu-west-1" || aws.StringValue(sess.Config.Endpoint) != config.EndpointURL {
+		t.Errorf("unexpected session config: region %q, endpoint %q", aws.StringValue(sess.Config.Region), aws.StringValue(sess.Config.Endpoint))
+	}
+
+	// Without an endpoint the SDK resolves the regional service endpoint
+	sess, err = Config{Region: "eu-west-1"}.NewSession(nil)
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	if sess.Config.Endpoint != nil {
+		t.Errorf("expected no endpoint override, got %q", aws.StringValue(sess.Config.Endpoint))
+	}
+}
+
+func TestNewSessionConfigure(t *testing.T) {
+	config := Config{Region: "us-east-1", EndpointURL: "https://minio.internal:9000", RoleARN: "arn:aws:iam::123456789012:role/delivery"}
+
+	sess, err := config.NewSession(func(c *aws.Config) error {
+		c.S3ForcePathStyle = aws.Bool(true)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	if !aws.BoolValue(sess.Config.S3ForcePathStyle) {
+		t.Error("expected configure to be applied")
+	}
+	if sess.Config.Credentials == nil {
+		t.Error("expected assumed-role credentials to be configured")
+	}
+
+	wantErr := errors.New("invalid combination")
+	if _, err := config.NewSession(func(c *aws.Config) error { return wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("expected configure error, got %v", err)
+	}
+
+	if _, err := (Config{ClientKeyPath: "key.pem"}).NewSession(nil); err == nil {
+		t.Error("expected error for incomplete client TLS settings")
+	}
+}
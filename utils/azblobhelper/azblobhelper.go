@@ -0,0 +1,313 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package azblobhelper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+// AzBlobHelper holds the configuration for Azure Blob Storage operations.
+// Credentials are taken from ConnectionString, AccountKey or SASToken, in
+// that order.
+type AzBlobHelper struct {
+	AccountName   string
+	ContainerName string
+	// AccountKey authenticates with a shared key. It is required for PresignGetURL.
+	AccountKey string
+	// ConnectionString is an alternative to AccountName, AccountKey and EndpointURL
+	ConnectionString string
+	// SASToken authenticates with an existing account or container SAS,
+	// e.g. "sv=2022-11-02&ss=b&sig=..."
+	SASToken string
+	// EndpointURL overrides the service URL (defaults to
+	// https://<account>.blob.core.windows.net/), e.g.
+	// "http://127.0.0.1:10000/devstoreaccount1" for Azurite
+	EndpointURL string
+	// ContentTypes overrides the detected content type by file extension (e.g. ".dat": "text/csv").
+	// Keys are matched case-insensitively, with or without the leading dot.
+	ContentTypes map[string]string
+}
+
+// UploadOptions holds optional blob headers applied to an upload
+type UploadOptions struct {
+	ContentType        string            // explicit content type, overriding detection
+	CacheControl       string            // e.g. "public, max-age=86400"
+	ContentDisposition string            // e.g. `attachment; filename="report.csv"`
+	ContentLanguage    string            // e.g. "en-US"
+	Metadata           map[string]string // custom blob metadata
+}
+
+// ResponseOverrides sets the headers Azure returns when a SAS URL is fetched
+type ResponseOverrides struct {
+	ContentDisposition string // e.g. Attachment("report.csv") to force a download filename
+	ContentType        string // e.g. "text/csv"
+}
+
+// serviceURL returns the blob service URL of the account
+func (a *AzBlobHelper) serviceURL() string {
+	if a.EndpointURL != "" {
+		return strings.TrimSuffix(a.EndpointURL, "/") + "/"
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", a.AccountName)
+}
+
+// newClient creates a blob service client from the helper configuration
+func (a *AzBlobHelper) newClient() (*azblob.Client, error) {
+	if a.ContainerName == "" {
+		return nil, fmt.Errorf("ContainerName cannot be empty")
+	}
+
+	var client *azblob.Client
+	var err error
+	switch {
+	case a.ConnectionString != "":
+		client, err = azblob.NewClientFromConnectionString(a.ConnectionString, nil)
+	case a.AccountKey != "":
+		var cred *azblob.SharedKeyCredential
+		cred, err = a.sharedKey()
+		if err == nil {
+			client, err = azblob.NewClientWithSharedKeyCredential(a.serviceURL(), cred, nil)
+		}
+	case a.SASToken != "":
+		client, err = azblob.NewClientWithNoCredential(a.serviceURL()+"?"+strings.TrimPrefix(a.SASToken, "?"), nil)
+	default:
+		return nil, fmt.Errorf("one of ConnectionString, AccountKey or SASToken must be set")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob client: %v", err)
+	}
+	return client, nil
+}
+
+// sharedKey returns the account shared key credential
+func (a *AzBlobHelper) sharedKey() (*azblob.SharedKeyCredential, error) {
+	if a.AccountName == "" || a.AccountKey == "" {
+		return nil, fmt.Errorf("AccountName and AccountKey must be set")
+	}
+	return azblob.NewSharedKeyCredential(a.AccountName, a.AccountKey)
+}
+
+// UploadFile uploads a local file to the specified blob path
+func (a *AzBlobHelper) UploadFile(filePath, blobPath string) error {
+	return a.UploadFileWithOptions(filePath, blobPath, nil)
+}
+
+// UploadFileWithOptions uploads a local file to the specified blob path with
+// additional blob headers such as Cache-Control and Content-Disposition
+func (a *AzBlobHelper) UploadFileWithOptions(filePath, blobPath string, opts *UploadOptions) error {
+	client, err := a.newClient()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %v", filePath, err)
+	}
+	defer file.Close()
+
+	blobPath = cleanKey(blobPath)
+
+	headers := &blob.HTTPHeaders{BlobContentType: stringPtr(a.detectContentType(filePath))}
+	uploadOpts := &azblob.UploadFileOptions{HTTPHeaders: headers}
+	if opts != nil {
+		if opts.ContentType != "" {
+			headers.BlobContentType = stringPtr(opts.ContentType)
+		}
+		headers.BlobCacheControl = stringPtr(opts.CacheControl)
+		headers.BlobContentDisposition = stringPtr(opts.ContentDisposition)
+		headers.BlobContentLanguage = stringPtr(opts.ContentLanguage)
+		if len(opts.Metadata) > 0 {
+			uploadOpts.Metadata = make(map[string]*string, len(opts.Metadata))
+			for key, value := range opts.Metadata {
+				uploadOpts.Metadata[key] = stringPtr(value)
+			}
+		}
+	}
+
+	if _, err := client.UploadFile(context.Background(), a.ContainerName, blobPath, file, uploadOpts); err != nil {
+		return fmt.Errorf("failed to upload file to Azure Blob Storage: %v", err)
+	}
+
+	log.Printf("Successfully uploaded %q to azblob://%s/%s", filePath, a.ContainerName, blobPath)
+	return nil
+}
+
+// detectContentType determines the content type of a file from its extension,
+// preferring the helper's ContentTypes overrides over the system MIME table
+func (a *AzBlobHelper) detectContentType(filePath string) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	for key, contentType := range a.ContentTypes {
+		key = strings.ToLower(key)
+		if !strings.HasPrefix(key, ".") {
+			key = "." + key
+		}
+		if key == ext {
+			return contentType
+		}
+	}
+
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" {
+		contentType = "application/octet-stream" // Default content type
+	}
+	return contentType
+}
+
+// ListFiles lists all blobs in the specified path prefix
+func (a *AzBlobHelper) ListFiles(prefix string) ([]string, error) {
+	client, err := a.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	pager := client.NewListBlobsFlatPager(a.ContainerName, &azblob.ListBlobsFlatOptions{Prefix: stringPtr(prefix)})
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			files = append(files, *item.Name)
+		}
+	}
+
+	return files, nil
+}
+
+// DeleteFile deletes a blob
+func (a *AzBlobHelper) DeleteFile(blobPath string) error {
+	client, err := a.newClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.DeleteBlob(context.Background(), a.ContainerName, blobPath, nil); err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", blobPath, err)
+	}
+
+	log.Printf("Successfully deleted azblob://%s/%s", a.ContainerName, blobPath)
+	return nil
+}
+
+// DownloadFile downloads a blob to the local filesystem
+func (a *AzBlobHelper) DownloadFile(blobPath, localPath string) error {
+	client, err := a.newClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.DownloadStream(context.Background(), a.ContainerName, blobPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get blob %q from Azure Blob Storage: %v", blobPath, err)
+	}
+	defer result.Body.Close()
+
+	// Create the directory for the local file if it doesn't exist
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %v", dir, err)
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file %q: %v", localPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.ReadFrom(result.Body); err != nil {
+		return fmt.Errorf("failed to write to local file %q: %v", localPath, err)
+	}
+
+	log.Printf("Successfully downloaded azblob://%s/%s to %s", a.ContainerName, blobPath, localPath)
+	return nil
+}
+
+// CheckContainer verifies that the container exists and the credentials can
+// access it, e.g. for readiness checks
+func (a *AzBlobHelper) CheckContainer(ctx context.Context) error {
+	client, err := a.newClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.ServiceClient().NewContainerClient(a.ContainerName).GetProperties(ctx, nil); err != nil {
+		return fmt.Errorf("container %q is not accessible: %v", a.ContainerName, err)
+	}
+	return nil
+}
+
+// PresignGetURL returns a read-only SAS URL for the blob valid for the given
+// duration. Overrides, when non-nil, are signed into the URL. It requires
+// AccountName and AccountKey.
+func (a *AzBlobHelper) PresignGetURL(blobPath string, expires time.Duration, overrides *ResponseOverrides) (string, error) {
+	if expires <= 0 {
+		return "", fmt.Errorf("expires must be positive, got %v", expires)
+	}
+	cred, err := a.sharedKey()
+	if err != nil {
+		return "", err
+	}
+
+	blobPath = cleanKey(blobPath)
+	values := sas.BlobSignatureValues{
+		Protocol: sas.ProtocolHTTPSandHTTP,
+		// Allow for clock skew between this host and Azure
+		StartTime:     time.Now().UTC().Add(-5 * time.Minute),
+		ExpiryTime:    time.Now().UTC().Add(expires),
+		Permissions:   (&sas.BlobPermissions{Read: true}).String(),
+		ContainerName: a.ContainerName,
+		BlobName:      blobPath,
+	}
+	if overrides != nil {
+		values.ContentDisposition = overrides.ContentDisposition
+		values.ContentType = overrides.ContentType
+	}
+
+	params, err := values.SignWithSharedKey(cred)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign SAS for %q: %v", blobPath, err)
+	}
+	blobURL := a.serviceURL() + a.ContainerName + "/" + escapePath(blobPath)
+	return blobURL + "?" + params.Encode(), nil
+}
+
+// Attachment builds a Content-Disposition value that makes browsers download
+// the blob under the given filename, including non-ASCII names
+func Attachment(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+// escapePath URL-escapes each segment of a blob path
+func escapePath(blobPath string) string {
+	segments := strings.Split(blobPath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// cleanKey normalizes a blob path by removing leading and trailing slashes
+func cleanKey(blobPath string) string {
+	return strings.TrimPrefix(path.Clean(blobPath), "/")
+}
+
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
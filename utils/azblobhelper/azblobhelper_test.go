@@ -0,0 +1,392 @@
+package azblobhelper
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// Azurite's well-known development account
+const (
+	testAccount = "devstoreaccount1"
+	testKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// fakeBlob is a blob stored by fakeAzure
+type fakeBlob struct {
+	data   []byte
+	header http.Header
+}
+
+// fakeAzure implements the parts of the Blob service REST API used by AzBlobHelper
+type fakeAzure struct {
+	mu        sync.Mutex
+	container string
+	blobs     map[string]*fakeBlob
+	// staged holds uncommitted blocks by block ID
+	staged map[string][]byte
+	// auth records the authentication of the last request
+	auth string
+}
+
+func newFakeAzure(t *testing.T, container string) (*fakeAzure, *AzBlobHelper) {
+	t.Helper()
+	fake := &fakeAzure{container: container, blobs: map[string]*fakeBlob{}, staged: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, &AzBlobHelper{
+		AccountName:   testAccount,
+		AccountKey:    testKey,
+		ContainerName: container,
+		EndpointURL:   server.URL + "/" + testAccount,
+	}
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auth = r.Header.Get("Authorization")
+	if f.auth == "" && r.URL.Query().Get("sig") != "" {
+		f.auth = "sas"
+	}
+
+	containerPath := "/" + testAccount + "/" + f.container
+	query := r.URL.Query()
+	switch {
+	case r.URL.Path == containerPath && query.Get("comp") == "list":
+		f.list(w, r)
+	case r.URL.Path == containerPath && query.Get("restype") == "container":
+		w.Header().Set("ETag", `"0x1"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	case strings.HasPrefix(r.URL.Path, containerPath+"/"):
+		f.blob(w, r, strings.TrimPrefix(r.URL.Path, containerPath+"/"))
+	default:
+		notFound(w, "ContainerNotFound")
+	}
+}
+
+func notFound(w http.ResponseWriter, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><Error><Code>` + code + `</Code><Message>not found</Message></Error>`))
+}
+
+func (f *fakeAzure) blob(w http.ResponseWriter, r *http.Request, name string) {
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		switch r.URL.Query().Get("comp") {
+		case "block":
+			f.staged[r.URL.Query().Get("blockid")] = data
+		case "blocklist":
+			var list struct {
+				Latest []string `xml:"Latest"`
+			}
+			if err := xml.Unmarshal(data, &list); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var content []byte
+			for _, id := range list.Latest {
+				content = append(content, f.staged[id]...)
+			}
+			f.blobs[name] = &fakeBlob{data: content, header: r.Header.Clone()}
+		default:
+			f.blobs[name] = &fakeBlob{data: data, header: r.Header.Clone()}
+		}
+		w.Header().Set("ETag", `"0x2"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		b, ok := f.blobs[name]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.Header().Set("x-ms-error-code", "BlobNotFound")
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			notFound(w, "BlobNotFound")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(b.data)))
+		w.Header().Set("Content-Type", b.header.Get("x-ms-blob-content-type"))
+		w.Header().Set("ETag", `"0x2"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		if r.Method == http.MethodGet {
+			w.Write(b.data)
+		}
+	case http.MethodDelete:
+		if _, ok := f.blobs[name]; !ok {
+			notFound(w, "BlobNotFound")
+			return
+		}
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// list returns the blobs under the prefix, two per page
+func (f *fakeAzure) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var names []string
+	for name := range f.blobs {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if marker := r.URL.Query().Get("marker"); marker != "" {
+		start = sort.SearchStrings(names, marker)
+	}
+	end := min(start+2, len(names))
+
+	type blobItem struct {
+		Name          string `xml:"Name"`
+		ContentLength int    `xml:"Properties>Content-Length"`
+		LastModified  string `xml:"Properties>Last-Modified"`
+		ETag          string `xml:"Properties>Etag"`
+	}
+	result := struct {
+		XMLName    xml.Name   `xml:"EnumerationResults"`
+		Prefix     string     `xml:"Prefix"`
+		Blobs      []blobItem `xml:"Blobs>Blob"`
+		NextMarker string     `xml:"NextMarker"`
+	}{Prefix: prefix}
+	for _, name := range names[start:end] {
+		result.Blobs = append(result.Blobs, blobItem{
+			Name:          name,
+			ContentLength: len(f.blobs[name].data),
+			LastModified:  time.Now().UTC().Format(http.TimeFormat),
+			ETag:          `"0x2"`,
+		})
+	}
+	if end < len(names) {
+		result.NextMarker = names[end]
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(result)
+}
+
+func TestUploadDownload(t *testing.T) {
+	fake, helper := newFakeAzure(t, "deliveries")
+	helper.ContentTypes = map[string]string{".dat": "text/csv"}
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "export.dat")
+	os.WriteFile(src, []byte("id,name\n1,acme\n"), 0644)
+	if err := helper.UploadFile(src, "/acme/2024-06-01/export.dat"); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	stored := fake.blobs["acme/2024-06-01/export.dat"]
+	if stored == nil {
+		t.Fatalf("blob not stored, have %v", fake.blobs)
+	}
+	if stored.header.Get("x-ms-blob-content-type") != "text/csv" || stored.header.Get("x-ms-blob-type") != "BlockBlob" {
+		t.Errorf("unexpected upload headers %v", stored.header)
+	}
+	if !strings.HasPrefix(fake.auth, "SharedKey "+testAccount+":") {
+		t.Errorf("expected shared key authentication, got %q", fake.auth)
+	}
+
+	err := helper.UploadFileWithOptions(src, "acme/report.csv", &UploadOptions{
+		ContentType:        "application/x-custom",
+		CacheControl:       "no-cache",
+		ContentDisposition: Attachment("report.csv"),
+		Metadata:           map[string]string{"customer": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("UploadFileWithOptions failed: %v", err)
+	}
+	header := fake.blobs["acme/report.csv"].header
+	if header.Get("x-ms-blob-content-type") != "application/x-custom" || header.Get("x-ms-blob-cache-control") != "no-cache" ||
+		header.Get("x-ms-blob-content-disposition") != `attachment; filename=report.csv` || header.Get("x-ms-meta-customer") != "acme" {
+		t.Errorf("unexpected upload headers %v", header)
+	}
+
+	dst := filepath.Join(dir, "downloads", "nested", "export.dat")
+	if err := helper.DownloadFile("acme/2024-06-01/export.dat", dst); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "id,name\n1,acme\n" {
+		t.Errorf("downloaded content = %q", data)
+	}
+
+	if err := helper.DownloadFile("acme/missing.csv", filepath.Join(dir, "missing.csv")); err == nil {
+		t.Error("expected error for missing blob")
+	}
+}
+
+func TestListDelete(t *testing.T) {
+	fake, helper := newFakeAzure(t, "deliveries")
+	for _, name := range []string{"acme/a.csv", "acme/b.csv", "acme/c.csv", "other/d.csv"} {
+		fake.blobs[name] = &fakeBlob{data: []byte(name), header: http.Header{}}
+	}
+
+	files, err := helper.ListFiles("acme/")
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if strings.Join(files, ",") != "acme/a.csv,acme/b.csv,acme/c.csv" {
+		t.Errorf("ListFiles = %v", files)
+	}
+
+	if err := helper.DeleteFile("acme/b.csv"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if _, ok := fake.blobs["acme/b.csv"]; ok {
+		t.Error("expected blob to be deleted")
+	}
+	if err := helper.DeleteFile("acme/b.csv"); err == nil {
+		t.Error("expected error deleting a missing blob")
+	}
+}
+
+func TestBackend(t *testing.T) {
+	fake, helper := newFakeAzure(t, "deliveries")
+	ctx := context.Background()
+
+	if err := helper.Put(ctx, "/acme/export.csv", strings.NewReader("id,name\n1,acme\n")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	stored := fake.blobs["acme/export.csv"]
+	if stored == nil {
+		t.Fatalf("blob not stored, have %v", fake.blobs)
+	}
+	if stored.header.Get("x-ms-blob-content-type") != "text/csv; charset=utf-8" {
+		t.Errorf("unexpected upload headers %v", stored.header)
+	}
+	fake.blobs["acme/b.csv"] = &fakeBlob{data: []byte("b"), header: http.Header{}}
+	fake.blobs["other/c.csv"] = &fakeBlob{data: []byte("c"), header: http.Header{}}
+
+	r, err := helper.Get(ctx, "acme/export.csv")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "id,name\n1,acme\n" {
+		t.Errorf("Get content = %q", data)
+	}
+
+	objects, err := helper.List(ctx, "acme/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != "acme/b.csv" || objects[1].Key != "acme/export.csv" || objects[1].Size != 15 || objects[1].ETag != `"0x2"` {
+		t.Errorf("List = %+v", objects)
+	}
+
+	info, err := helper.Stat(ctx, "acme/export.csv")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != 15 || info.ETag != `"0x2"` || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v", info)
+	}
+
+	if err := helper.Delete(ctx, "acme/export.csv"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := helper.Delete(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Delete, got %v", err)
+	}
+	if _, err := helper.Stat(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Stat, got %v", err)
+	}
+	if _, err := helper.Get(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Get, got %v", err)
+	}
+}
+
+func TestSASTokenAuth(t *testing.T) {
+	fake, helper := newFakeAzure(t, "deliveries")
+	helper.AccountKey = ""
+	helper.SASToken = "?sv=2022-11-02&ss=b&srt=co&sp=rl&sig=c2lnbmF0dXJl"
+
+	if _, err := helper.ListFiles(""); err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if fake.auth != "sas" {
+		t.Errorf("expected SAS authentication, got %q", fake.auth)
+	}
+}
+
+func TestCheckContainer(t *testing.T) {
+	_, helper := newFakeAzure(t, "deliveries")
+	if err := helper.CheckContainer(context.Background()); err != nil {
+		t.Errorf("CheckContainer failed: %v", err)
+	}
+
+	helper.ContainerName = "missing"
+	if err := helper.CheckContainer(context.Background()); err == nil {
+		t.Error("expected error for missing container")
+	}
+
+	helper.ContainerName = ""
+	if _, err := helper.ListFiles(""); err == nil {
+		t.Error("expected error for empty ContainerName")
+	}
+	if _, err := (&AzBlobHelper{ContainerName: "deliveries"}).ListFiles(""); err == nil {
+		t.Error("expected error without credentials")
+	}
+}
+
+func TestPresignGetURL(t *testing.T) {
+	helper := &AzBlobHelper{AccountName: testAccount, AccountKey: testKey, ContainerName: "deliveries"}
+
+	presigned, err := helper.PresignGetURL("/reports/January report.csv", time.Hour, &ResponseOverrides{
+		ContentDisposition: Attachment("January report.csv"),
+		ContentType:        "text/csv",
+	})
+	if err != nil {
+		t.Fatalf("PresignGetURL failed: %v", err)
+	}
+	u, err := url.Parse(presigned)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", presigned, err)
+	}
+	if u.Host != testAccount+".blob.core.windows.net" || u.Path != "/deliveries/reports/January report.csv" {
+		t.Errorf("unexpected blob URL %q", presigned)
+	}
+	query := u.Query()
+	if query.Get("sp") != "r" || query.Get("sr") != "b" || query.Get("sig") == "" {
+		t.Errorf("unexpected SAS parameters %v", query)
+	}
+	if query.Get("rsct") != "text/csv" || query.Get("rscd") != `attachment; filename="January report.csv"` {
+		t.Errorf("expected response overrides, got %v", query)
+	}
+	expiry, err := time.Parse(time.RFC3339, query.Get("se"))
+	if err != nil || time.Until(expiry) > time.Hour || time.Until(expiry) < 59*time.Minute {
+		t.Errorf("unexpected expiry %q", query.Get("se"))
+	}
+
+	if _, err := helper.PresignGetURL("x", 0, nil); err == nil {
+		t.Error("expected error for non-positive expiry")
+	}
+	if _, err := (&AzBlobHelper{ContainerName: "deliveries", SASToken: "sig=x"}).PresignGetURL("x", time.Hour, nil); err == nil {
+		t.Error("expected error without an account key")
+	}
+}
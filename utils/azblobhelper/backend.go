@@ -0,0 +1,129 @@
+package azblobhelper
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+var _ storage.Backend = (*AzBlobHelper)(nil)
+
+// Put streams r to the blob at key. The content type is detected from the
+// key's extension.
+func (a *AzBlobHelper) Put(ctx context.Context, key string, r io.Reader) error {
+	client, err := a.newClient()
+	if err != nil {
+		return err
+	}
+
+	key = cleanKey(key)
+	_, err = client.UploadStream(ctx, a.ContainerName, key, r, &azblob.UploadStreamOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: stringPtr(a.detectContentType(key))},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %q to Azure Blob Storage: %v", key, err)
+	}
+	return nil
+}
+
+// Get opens the blob at key for reading
+func (a *AzBlobHelper) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	client, err := a.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	key = cleanKey(key)
+	result, err := client.DownloadStream(ctx, a.ContainerName, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, storage.NotFound(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob %q from Azure Blob Storage: %v", key, err)
+	}
+	return result.Body, nil
+}
+
+// List returns the blobs whose name starts with prefix
+func (a *AzBlobHelper) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	client, err := a.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Azure lists blobs in lexicographic order
+	var objects []storage.ObjectInfo
+	pager := client.NewListBlobsFlatPager(a.ContainerName, &azblob.ListBlobsFlatOptions{Prefix: stringPtr(prefix)})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			info := storage.ObjectInfo{Key: value(item.Name)}
+			if props := item.Properties; props != nil {
+				info.Size = value(props.ContentLength)
+				info.ModTime = value(props.LastModified)
+				info.ETag = string(value(props.ETag))
+			}
+			objects = append(objects, info)
+		}
+	}
+	return objects, nil
+}
+
+// Delete removes the blob at key
+func (a *AzBlobHelper) Delete(ctx context.Context, key string) error {
+	client, err := a.newClient()
+	if err != nil {
+		return err
+	}
+
+	key = cleanKey(key)
+	_, err = client.DeleteBlob(ctx, a.ContainerName, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return storage.NotFound(key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", key, err)
+	}
+	return nil
+}
+
+// Stat returns the size, modification time and ETag of the blob at key
+func (a *AzBlobHelper) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	client, err := a.newClient()
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+
+	key = cleanKey(key)
+	props, err := client.ServiceClient().NewContainerClient(a.ContainerName).NewBlobClient(key).GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return storage.ObjectInfo{}, storage.NotFound(key)
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("failed to stat blob %q: %v", key, err)
+	}
+	return storage.ObjectInfo{
+		Key:     key,
+		Size:    value(props.ContentLength),
+		ModTime: value(props.LastModified),
+		ETag:    string(value(props.ETag)),
+	}, nil
+}
+
+// value dereferences p, returning the zero value for nil
+func value[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
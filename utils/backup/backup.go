@@ -0,0 +1,501 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/dirwalk"
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/manifest"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// ManifestVersion is the version of the manifest format written by Run
+const ManifestVersion = 1
+
+// IDFormat is the time layout of backup IDs. IDs sort chronologically.
+const IDFormat = "20060102T150405.000Z"
+
+// manifestName is the object name of a backup's manifest. It is uploaded
+// last, so a backup without one is incomplete.
+const manifestName = "manifest.json"
+
+// Options configures a backup set
+type Options struct {
+	// Name identifies the backup set; each backup is stored under
+	// Prefix/Name/<ID>/
+	Name   string
+	Prefix string
+
+	// Compression of the archive stream (default zstd, compress.None for none)
+	Compression compress.Format
+	Level       compress.Level
+	// PartSize splits the compressed archive into parts of at most this many
+	// bytes, e.g. to stay below an upload limit (0 for a single part)
+	PartSize int64
+	// Algorithm checksums files and parts (default SHA-256)
+	Algorithm checksum.Algorithm
+
+	// Include, Exclude and SkipHidden select files as in dirwalk
+	Include    []string
+	Exclude    []string
+	SkipHidden bool
+
+	// Verify downloads each part after the upload and compares its checksum
+	Verify bool
+	// Keep retains the newest Keep backups and deletes older ones after a
+	// successful run (0 keeps all)
+	Keep int
+	// MaxAge deletes backups older than this after a successful run (0 for
+	// no limit). The newest backup is always kept.
+	MaxAge time.Duration
+
+	// TempDir holds each part while it is uploaded (default os.TempDir())
+	TempDir string
+}
+
+// File is a file stored in a backup
+type File struct {
+	// Path is slash-separated and relative to the backed up directory
+	Path     string      `json:"path"`
+	Size     int64       `json:"size"`
+	Mode     fs.FileMode `json:"mode"`
+	ModTime  time.Time   `json:"mod_time"`
+	Checksum string      `json:"checksum"`
+}
+
+// Part is one object of the split archive
+type Part struct {
+	Key      string `json:"key"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// Manifest describes a complete backup. The archive is a tar stream of
+// Files, compressed and split into Parts in order.
+type Manifest struct {
+	Version     int                `json:"version"`
+	Name        string             `json:"name"`
+	ID          string             `json:"id"`
+	Created     time.Time          `json:"created"`
+	Source      string             `json:"source"`
+	Compression compress.Format    `json:"compression"`
+	Algorithm   checksum.Algorithm `json:"algorithm"`
+	// Size is the total size of the files before compression
+	Size  int64  `json:"size"`
+	Files []File `json:"files"`
+	Parts []Part `json:"parts"`
+}
+
+// Backup writes backups of a directory or file to a storage backend
+type Backup struct {
+	backend storage.Backend
+	opts    Options
+	logger  *logger.Logger
+}
+
+// NewBackup creates a Backup storing the set opts.Name on backend, which
+// can be storage.Local, S3, SFTP or any other storage.Backend
+func NewBackup(log *logger.Logger, backend storage.Backend, opts Options) (*Backup, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	if opts.Name == "" || strings.Contains(opts.Name, "/") {
+		return nil, fmt.Errorf("invalid backup name %q", opts.Name)
+	}
+	if opts.Compression == "" {
+		opts.Compression = compress.Zstd
+	}
+	format, err := compress.ParseFormat(string(opts.Compression))
+	if err != nil {
+		return nil, err
+	}
+	opts.Compression = format
+	if opts.Level < compress.DefaultCompression || opts.Level > compress.BestCompression {
+		return nil, fmt.Errorf("compression level must be between 0 and 9, got %d", opts.Level)
+	}
+	if opts.Algorithm == "" {
+		opts.Algorithm = checksum.SHA256
+	}
+	if _, err := checksum.NewHash(opts.Algorithm); err != nil {
+		return nil, err
+	}
+	if opts.PartSize < 0 || opts.Keep < 0 || opts.MaxAge < 0 {
+		return nil, fmt.Errorf("PartSize, Keep and MaxAge cannot be negative")
+	}
+	return &Backup{backend: backend, opts: opts, logger: log}, nil
+}
+
+// Run backs up source, a directory or a single file: the selected files are
+// archived, compressed, split, checksummed and uploaded with a manifest,
+// then the retention rules are applied. A failed run removes the parts it
+// uploaded.
+func (b *Backup) Run(ctx context.Context, source string) (*Manifest, error) {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", source, err)
+	}
+	entries, err := b.collect(ctx, absSource)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	m := &Manifest{
+		Version:     ManifestVersion,
+		Name:        b.opts.Name,
+		ID:          start.UTC().Format(IDFormat),
+		Created:     start,
+		Source:      absSource,
+		Compression: b.opts.Compression,
+		Algorithm:   b.opts.Algorithm,
+	}
+	b.logger.Info("Backing up %s (%d files) to %s", absSource, len(entries), b.dir(m.ID))
+
+	parts := &partWriter{ctx: ctx, b: b, id: m.ID}
+	if err := b.archive(ctx, m, entries, parts); err != nil {
+		parts.discard()
+		b.removeParts(m.ID, parts.parts)
+		return nil, fmt.Errorf("backup of %s failed: %w", absSource, err)
+	}
+	m.Parts = parts.parts
+
+	if b.opts.Verify {
+		if err := b.verify(ctx, m); err != nil {
+			b.removeParts(m.ID, m.Parts)
+			return nil, fmt.Errorf("backup of %s failed: %w", absSource, err)
+		}
+	}
+	if err := b.writeManifest(ctx, m); err != nil {
+		b.removeParts(m.ID, m.Parts)
+		return nil, fmt.Errorf("backup of %s failed: %w", absSource, err)
+	}
+
+	var stored int64
+	for _, part := range m.Parts {
+		stored += part.Size
+	}
+	b.logger.Info("Backup %s/%s finished in %s: %d files, %s stored as %s in %d part(s)",
+		m.Name, m.ID, time.Since(start).Round(time.Millisecond), len(m.Files), humanize.Bytes(m.Size), humanize.Bytes(stored), len(m.Parts))
+
+	if b.opts.Keep > 0 || b.opts.MaxAge > 0 {
+		if _, err := b.Prune(ctx); err != nil {
+			b.logger.Warning("Failed to apply retention to backup set %s: %v", b.opts.Name, err)
+		}
+	}
+	return m, nil
+}
+
+// ManifestKey returns the key of the manifest of backup id
+func (b *Backup) ManifestKey(id string) string {
+	return path.Join(b.dir(id), manifestName)
+}
+
+// setPrefix returns the key prefix of the backup set
+func (b *Backup) setPrefix() string {
+	return path.Join(b.opts.Prefix, b.opts.Name)
+}
+
+// dir returns the key prefix of backup id
+func (b *Backup) dir(id string) string {
+	return path.Join(b.setPrefix(), id)
+}
+
+// collect returns the files to back up
+func (b *Backup) collect(ctx context.Context, source string) ([]dirwalk.Entry, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", source, err)
+	}
+	if !info.IsDir() {
+		return []dirwalk.Entry{{FileInfo: info, Path: source, RelPath: info.Name(), Depth: 1}}, nil
+	}
+
+	entries, err := dirwalk.Collect(ctx, source, &dirwalk.Options{
+		Include:    b.opts.Include,
+		Exclude:    b.opts.Exclude,
+		SkipHidden: b.opts.SkipHidden,
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := entries[:0]
+	for _, e := range entries {
+		if e.Mode().IsRegular() {
+			files = append(files, e)
+		}
+	}
+	return files, nil
+}
+
+// archive writes the entries as a compressed tar stream into parts and
+// records them in m
+func (b *Backup) archive(ctx context.Context, m *Manifest, entries []dirwalk.Entry, parts *partWriter) error {
+	zw, err := compress.NewWriter(parts, b.opts.Compression, b.opts.Level)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		file, err := b.addFile(tw, e)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, file)
+		m.Size += file.Size
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return parts.Close()
+}
+
+// addFile writes one file to the tar stream and returns its record
+func (b *Backup) addFile(tw *tar.Writer, e dirwalk.Entry) (File, error) {
+	src, err := os.Open(e.Path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to open %s: %w", e.Path, err)
+	}
+	defer src.Close()
+
+	header, err := tar.FileInfoHeader(e.FileInfo, "")
+	if err != nil {
+		return File{}, err
+	}
+	header.Name = e.RelPath
+	if err := tw.WriteHeader(header); err != nil {
+		return File{}, err
+	}
+
+	h, _ := checksum.NewHash(b.opts.Algorithm)
+	if _, err := io.Copy(io.MultiWriter(tw, h), src); err != nil {
+		return File{}, fmt.Errorf("failed to archive %s: %w", e.Path, err)
+	}
+	return File{
+		Path:     e.RelPath,
+		Size:     e.Size(),
+		Mode:     e.Mode().Perm(),
+		ModTime:  e.ModTime(),
+		Checksum: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// verify downloads every part and compares its checksum
+func (b *Backup) verify(ctx context.Context, m *Manifest) error {
+	for _, part := range m.Parts {
+		if err := VerifyPart(ctx, b.backend, part, m.Algorithm); err != nil {
+			return err
+		}
+	}
+	b.logger.Info("Verified %d part(s) of backup %s/%s", len(m.Parts), m.Name, m.ID)
+	return nil
+}
+
+// writeManifest uploads the manifest, completing the backup
+func (b *Backup) writeManifest(ctx context.Context, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := b.backend.Put(ctx, b.ManifestKey(m.ID), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
+
+// removeParts deletes the uploaded parts of a failed backup
+func (b *Backup) removeParts(id string, parts []Part) {
+	// The run's context may be canceled already
+	ctx := context.Background()
+	for _, part := range parts {
+		if err := b.backend.Delete(ctx, part.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			b.logger.Warning("Failed to remove part %s of incomplete backup %s: %v", part.Key, id, err)
+		}
+	}
+}
+
+// ReadManifest downloads and decodes the manifest at key
+func ReadManifest(ctx context.Context, backend storage.Backend, key string) (*Manifest, error) {
+	r, err := backend.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", key, err)
+	}
+	defer r.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", key, err)
+	}
+	if m.Version < 1 || m.Version > ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d in %s", m.Version, key)
+	}
+	return &m, nil
+}
+
+// Transfer returns the parts of the backup as a standard transfer manifest
+// with the backup's ID, e.g. for a reconciliation report. The backed up
+// files are summarized in the metadata.
+func (m *Manifest) Transfer() *manifest.Manifest {
+	t := manifest.New("backup")
+	t.ID, t.Created = m.ID, m.Created.UTC()
+	t.Metadata = map[string]string{
+		"name":        m.Name,
+		"source":      m.Source,
+		"compression": string(m.Compression),
+		"files":       strconv.Itoa(len(m.Files)),
+		"size":        strconv.FormatInt(m.Size, 10),
+	}
+	for _, part := range m.Parts {
+		t.Set(manifest.Entry{
+			Key:      part.Key,
+			Size:     part.Size,
+			Checksum: string(m.Algorithm) + ":" + part.Checksum,
+			Status:   manifest.StatusComplete,
+			Updated:  m.Created.UTC(),
+		})
+	}
+	return t
+}
+
+// VerifyPart downloads a part and compares its size and checksum with the
+// manifest. A mismatch returns an error wrapping checksum.ErrMismatch.
+func VerifyPart(ctx context.Context, backend storage.Backend, part Part, algo checksum.Algorithm) error {
+	r, err := backend.Get(ctx, part.Key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	h, err := checksum.NewHash(algo)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return fmt.Errorf("failed to read part %s: %w", part.Key, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); n != part.Size || sum != part.Checksum {
+		return fmt.Errorf("%w for part %s: expected %d bytes with %s %s, got %d bytes with %s",
+			checksum.ErrMismatch, part.Key, part.Size, algo, part.Checksum, n, sum)
+	}
+	return nil
+}
+
+// partWriter spools the archive stream into temporary files of at most
+// PartSize bytes and uploads each one when it is full
+type partWriter struct {
+	ctx   context.Context
+	b     *Backup
+	id    string
+	file  *os.File
+	hash  hash.Hash
+	size  int64
+	parts []Part
+}
+
+func (w *partWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.file == nil {
+			if err := w.open(); err != nil {
+				return written, err
+			}
+		}
+		n := len(p)
+		if limit := w.b.opts.PartSize; limit > 0 && w.size+int64(n) > limit {
+			n = int(limit - w.size)
+		}
+		if _, err := io.MultiWriter(w.file, w.hash).Write(p[:n]); err != nil {
+			return written, fmt.Errorf("failed to spool part: %w", err)
+		}
+		w.size += int64(n)
+		written += n
+		p = p[n:]
+		if w.b.opts.PartSize > 0 && w.size == w.b.opts.PartSize {
+			if err := w.upload(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close uploads the last part; an empty stream still gets one part
+func (w *partWriter) Close() error {
+	if w.file == nil && len(w.parts) > 0 {
+		return nil
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.upload()
+}
+
+// open starts a new temporary part file
+func (w *partWriter) open() error {
+	file, err := os.CreateTemp(w.b.opts.TempDir, "backup-part-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary part: %w", err)
+	}
+	w.file = file
+	w.hash, _ = checksum.NewHash(w.b.opts.Algorithm)
+	w.size = 0
+	return nil
+}
+
+// upload stores the current part and removes its temporary file
+func (w *partWriter) upload() error {
+	defer w.discard()
+
+	name := fmt.Sprintf("part-%05d.tar%s", len(w.parts)+1, compress.Ext(w.b.opts.Compression))
+	part := Part{Key: path.Join(w.b.dir(w.id), name), Size: w.size, Checksum: hex.EncodeToString(w.hash.Sum(nil))}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind part: %w", err)
+	}
+	if err := w.b.backend.Put(w.ctx, part.Key, w.file); err != nil {
+		return fmt.Errorf("failed to upload part %s: %w", part.Key, err)
+	}
+	w.parts = append(w.parts, part)
+	w.b.logger.Info("Uploaded %s (%s)", part.Key, humanize.Bytes(part.Size))
+	return nil
+}
+
+// discard closes and removes the current temporary part, if any
+func (w *partWriter) discard() {
+	if w.file != nil {
+		w.file.Close()
+		os.Remove(w.file.Name())
+		w.file = nil
+	}
+}
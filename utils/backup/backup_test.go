@@ -0,0 +1,286 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/manifest"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+func newLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger("backup_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return log
+}
+
+func createTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// readArchive joins the parts of a backup and returns the archived files
+func readArchive(t *testing.T, backend storage.Backend, m *Manifest) map[string]string {
+	t.Helper()
+	var joined bytes.Buffer
+	for _, part := range m.Parts {
+		r, err := backend.Get(context.Background(), part.Key)
+		if err != nil {
+			t.Fatalf("Get %s failed: %v", part.Key, err)
+		}
+		io.Copy(&joined, r)
+		r.Close()
+	}
+	zr, err := compress.NewReader(&joined, m.Compression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("failed to read archive: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+}
+
+func TestNewBackupValidation(t *testing.T) {
+	log := newLogger(t)
+	backend := &storage.Local{Root: t.TempDir()}
+
+	invalid := []Options{
+		{},
+		{Name: "a/b"},
+		{Name: "db", Compression: "rar"},
+		{Name: "db", Algorithm: "sha3"},
+		{Name: "db", Level: 12},
+		{Name: "db", Keep: -1},
+	}
+	for _, opts := range invalid {
+		if _, err := NewBackup(log, backend, opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+	if _, err := NewBackup(nil, backend, Options{Name: "db"}); err == nil {
+		t.Error("expected error for nil logger")
+	}
+	if _, err := NewBackup(log, nil, Options{Name: "db"}); err == nil {
+		t.Error("expected error for nil backend")
+	}
+}
+
+func TestRun(t *testing.T) {
+	files := map[string]string{
+		"orders.csv":         strings.Repeat("1,alpha,100\n", 500),
+		"sub/invoices.csv":   strings.Repeat("2,beta,200\n", 300),
+		"sub/empty.txt":      "",
+		"cache/skip.tmp":     "temporary",
+		".hidden/secret.txt": "hidden",
+	}
+	source := createTree(t, files)
+	backend := &storage.Local{Root: t.TempDir()}
+
+	for _, format := range []compress.Format{compress.Zstd, compress.Gzip, compress.None} {
+		t.Run(string(format), func(t *testing.T) {
+			b, err := NewBackup(newLogger(t), backend, Options{
+				Name:        "exports-" + string(format),
+				Prefix:      "backups",
+				Compression: format,
+				PartSize:    1000,
+				Exclude:     []string{"*.tmp"},
+				SkipHidden:  true,
+				Verify:      true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := b.Run(context.Background(), source)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+
+			if len(m.Files) != 3 || m.Files[0].Path != "orders.csv" || m.Size != int64(len(files["orders.csv"])+len(files["sub/invoices.csv"])) {
+				t.Errorf("unexpected files %+v (size %d)", m.Files, m.Size)
+			}
+			if sum, _ := checksum.Sum(strings.NewReader(files["orders.csv"]), checksum.SHA256); m.Files[0].Checksum != sum {
+				t.Errorf("unexpected checksum %s", m.Files[0].Checksum)
+			}
+			if format == compress.None && len(m.Parts) < 5 {
+				t.Errorf("expected the archive to be split, got %d parts", len(m.Parts))
+			}
+			for i, part := range m.Parts {
+				if part.Size > 1000 || (i < len(m.Parts)-1 && part.Size != 1000) {
+					t.Errorf("part %s has %d bytes", part.Key, part.Size)
+				}
+			}
+
+			archived := readArchive(t, backend, m)
+			if len(archived) != 3 || archived["sub/invoices.csv"] != files["sub/invoices.csv"] {
+				t.Errorf("unexpected archive content: %d files", len(archived))
+			}
+
+			stored, err := ReadManifest(context.Background(), backend, b.ManifestKey(m.ID))
+			if err != nil || stored.ID != m.ID || len(stored.Parts) != len(m.Parts) || stored.Compression != format {
+				t.Errorf("ReadManifest = %+v, %v", stored, err)
+			}
+			if latest, err := b.Latest(context.Background()); err != nil || latest.ID != m.ID {
+				t.Errorf("Latest = %+v, %v", latest, err)
+			}
+
+			transfer := m.Transfer()
+			if err := transfer.Validate(); err != nil || transfer.ID != m.ID || len(transfer.Entries) != len(m.Parts) {
+				t.Errorf("Transfer = %+v, %v", transfer, err)
+			}
+			if total := transfer.Totals(); total.ByStatus[manifest.StatusComplete] != len(m.Parts) {
+				t.Errorf("unexpected totals %+v", total)
+			}
+		})
+	}
+}
+
+func TestRunSingleFile(t *testing.T) {
+	source := createTree(t, map[string]string{"dump.sql": "CREATE TABLE orders;"})
+	backend := &storage.Local{Root: t.TempDir()}
+	b, _ := NewBackup(newLogger(t), backend, Options{Name: "db"})
+
+	m, err := b.Run(context.Background(), filepath.Join(source, "dump.sql"))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(m.Parts) != 1 || !strings.HasSuffix(m.Parts[0].Key, "part-00001.tar.zst") {
+		t.Errorf("unexpected parts %+v", m.Parts)
+	}
+	if archived := readArchive(t, backend, m); archived["dump.sql"] != "CREATE TABLE orders;" {
+		t.Errorf("unexpected archive %v", archived)
+	}
+
+	if _, err := b.Run(context.Background(), filepath.Join(source, "missing")); err == nil {
+		t.Error("expected error for a missing source")
+	}
+}
+
+func TestVerifyPart(t *testing.T) {
+	source := createTree(t, map[string]string{"a.txt": "alpha"})
+	root := t.TempDir()
+	backend := &storage.Local{Root: root}
+	b, _ := NewBackup(newLogger(t), backend, Options{Name: "db"})
+	m, err := b.Run(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyPart(context.Background(), backend, m.Parts[0], m.Algorithm); err != nil {
+		t.Errorf("VerifyPart failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(root, filepath.FromSlash(m.Parts[0].Key)), []byte("corrupt"), 0644)
+	if err := VerifyPart(context.Background(), backend, m.Parts[0], m.Algorithm); !errors.Is(err, checksum.ErrMismatch) {
+		t.Errorf("expected ErrMismatch, got %v", err)
+	}
+}
+
+// failingBackend fails uploads of keys containing fail
+type failingBackend struct {
+	storage.Backend
+	fail string
+}
+
+func (f *failingBackend) Put(ctx context.Context, key string, r io.Reader) error {
+	if strings.Contains(key, f.fail) {
+		return errors.New("quota exceeded")
+	}
+	return f.Backend.Put(ctx, key, r)
+}
+
+func TestRunFailureRemovesParts(t *testing.T) {
+	source := createTree(t, map[string]string{"a.txt": strings.Repeat("a", 5000)})
+	local := &storage.Local{Root: t.TempDir()}
+	backend := &failingBackend{Backend: local, fail: manifestName}
+	b, _ := NewBackup(newLogger(t), backend, Options{Name: "db", Compression: compress.None, PartSize: 1024})
+
+	if _, err := b.Run(context.Background(), source); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("expected upload error, got %v", err)
+	}
+	if objects, _ := local.List(context.Background(), ""); len(objects) != 0 {
+		t.Errorf("expected no objects after a failed backup, got %d", len(objects))
+	}
+
+	backend.fail = "part-00003"
+	if _, err := b.Run(context.Background(), source); err == nil {
+		t.Fatal("expected upload error")
+	}
+	if objects, _ := local.List(context.Background(), ""); len(objects) != 0 {
+		t.Errorf("expected no objects after a failed backup, got %d", len(objects))
+	}
+}
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	source := createTree(t, map[string]string{"a.txt": "alpha"})
+	backend := &storage.Local{Root: t.TempDir()}
+	b, _ := NewBackup(newLogger(t), backend, Options{Name: "db", Prefix: "nightly", Keep: 2})
+
+	// An old backup from a previous year and an incomplete one
+	old := time.Now().AddDate(-1, 0, 0).UTC().Format(IDFormat)
+	backend.Put(ctx, "nightly/db/"+old+"/"+manifestName, strings.NewReader(`{"version":1}`))
+	backend.Put(ctx, "nightly/db/"+old+"/part-00001.tar.zst", strings.NewReader("x"))
+	backend.Put(ctx, "nightly/db/20000101T000000.000Z/part-00001.tar.zst", strings.NewReader("x"))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		m, err := b.Run(ctx, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, m.ID)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	kept, err := b.List(ctx)
+	if err != nil || strings.Join(kept, ",") != strings.Join(ids[1:], ",") {
+		t.Errorf("List = %v, %v; expected %v", kept, err, ids[1:])
+	}
+	if _, err := backend.Stat(ctx, "nightly/db/"+old+"/part-00001.tar.zst"); !errors.Is(err, storage.ErrNotFound) {
+		t.Error("expected the parts of the pruned backup to be deleted")
+	}
+
+	// MaxAge never removes the newest backup
+	b.opts.Keep, b.opts.MaxAge = 0, time.Nanosecond
+	deleted, err := b.Prune(ctx)
+	if err != nil || len(deleted) != 1 || deleted[0] != ids[1] {
+		t.Errorf("Prune = %v, %v", deleted, err)
+	}
+	if kept, _ := b.List(ctx); len(kept) != 1 || kept[0] != ids[2] {
+		t.Errorf("expected only the newest backup, got %v", kept)
+	}
+}
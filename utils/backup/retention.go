@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// List returns the IDs of the complete backups of the set, oldest first
+func (b *Backup) List(ctx context.Context) ([]string, error) {
+	objects, err := b.backend.List(ctx, b.setPrefix()+"/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup set %s: %w", b.opts.Name, err)
+	}
+
+	var ids []string
+	for _, obj := range objects {
+		rel := strings.TrimPrefix(obj.Key, b.setPrefix()+"/")
+		if id, name, ok := strings.Cut(rel, "/"); ok && name == manifestName {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Latest returns the manifest of the newest complete backup, or an error
+// wrapping storage.ErrNotFound when the set is empty
+func (b *Backup) Latest(ctx context.Context) (*Manifest, error) {
+	ids, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, storage.NotFound(b.setPrefix())
+	}
+	return ReadManifest(ctx, b.backend, b.ManifestKey(ids[len(ids)-1]))
+}
+
+// Prune applies Keep and MaxAge to the set and returns the IDs of the
+// deleted backups. The newest backup is never deleted.
+func (b *Backup) Prune(ctx context.Context) ([]string, error) {
+	ids, err := b.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	for i, id := range ids[:max(0, len(ids)-1)] {
+		tooMany := b.opts.Keep > 0 && len(ids)-i > b.opts.Keep
+		tooOld := false
+		if created, err := time.Parse(IDFormat, id); err == nil && b.opts.MaxAge > 0 {
+			tooOld = time.Since(created) > b.opts.MaxAge
+		}
+		if tooMany || tooOld {
+			expired = append(expired, id)
+		}
+	}
+
+	var deleted []string
+	for _, id := range expired {
+		if err := b.Delete(ctx, id); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, nil
+}
+
+// Delete removes backup id. The manifest goes first, so an interrupted
+// delete leaves an incomplete backup rather than a broken one.
+func (b *Backup) Delete(ctx context.Context, id string) error {
+	if id == "" || strings.Contains(id, "/") {
+		return fmt.Errorf("invalid backup ID %q", id)
+	}
+	if err := b.backend.Delete(ctx, b.ManifestKey(id)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to delete backup %s: %w", id, err)
+	}
+	objects, err := b.backend.List(ctx, b.dir(id)+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list backup %s: %w", id, err)
+	}
+	for _, obj := range objects {
+		if err := b.backend.Delete(ctx, obj.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("failed to delete backup %s: %w", id, err)
+		}
+	}
+	b.logger.Info("Deleted backup %s/%s (%d objects)", b.opts.Name, id, len(objects)+1)
+	return nil
+}
@@ -0,0 +1,117 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version, Commit and Date are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/romisugianto/go-utils/utils/buildinfo.Version=1.4.0
+//	  -X github.com/romisugianto/go-utils/utils/buildinfo.Commit=$(git rev-parse HEAD)
+//	  -X github.com/romisugianto/go-utils/utils/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left empty are taken from the module and VCS information Go embeds
+// in the binary.
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// Info describes the running binary
+type Info struct {
+	// Version is the release version without a leading "v", or "dev" for
+	// untagged builds
+	Version string `json:"version"`
+	// Commit is the VCS revision the binary was built from
+	Commit string `json:"commit,omitempty"`
+	// Date is the build date, or the commit time when only VCS information
+	// is available
+	Date time.Time `json:"date"`
+	// Modified reports uncommitted changes in the build tree
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	// Module is the main module path
+	Module string `json:"module,omitempty"`
+}
+
+var cached = sync.OnceValue(func() Info {
+	build, _ := debug.ReadBuildInfo()
+	return resolve(build)
+})
+
+// Get returns the build information, combining the ldflags variables with
+// the information embedded by the Go toolchain
+func Get() Info {
+	return cached()
+}
+
+// resolve builds the Info from the ldflags variables and build, which may be nil
+func resolve(build *debug.BuildInfo) Info {
+	info := Info{Version: Version, Commit: Commit, GoVersion: runtime.Version()}
+	if Date != "" {
+		info.Date, _ = time.Parse(time.RFC3339, Date)
+	}
+
+	if build != nil {
+		info.Module = build.Main.Path
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date.IsZero() {
+					info.Date, _ = time.Parse(time.RFC3339, setting.Value)
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	// Strip the "v" of tags so banners can print "v" + Version
+	if len(info.Version) > 1 && info.Version[0] == 'v' && info.Version[1] >= '0' && info.Version[1] <= '9' {
+		info.Version = info.Version[1:]
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// ShortCommit returns the first 7 characters of the commit
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 7 {
+		return i.Commit[:7]
+	}
+	return i.Commit
+}
+
+// String formats the information on one line, e.g.
+// "1.4.0 (commit 3f2a9c1, built 2024-06-01T12:00:00Z, go1.24.5)"
+func (i Info) String() string {
+	var details []string
+	if i.Commit != "" {
+		commit := "commit " + i.ShortCommit()
+		if i.Modified {
+			commit += "-dirty"
+		}
+		details = append(details, commit)
+	}
+	if !i.Date.IsZero() {
+		details = append(details, "built "+i.Date.UTC().Format(time.RFC3339))
+	}
+	details = append(details, i.GoVersion)
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
@@ -0,0 +1,60 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestResolveFromBuildInfo(t *testing.T) {
+	build := &debug.BuildInfo{
+		Main: debug.Module{Path: "github.com/acme/delivery", Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "3f2a9c1d8e7b6a5f"},
+			{Key: "vcs.time", Value: "2024-06-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	info := resolve(build)
+	if info.Version != "1.4.0" || info.Commit != "3f2a9c1d8e7b6a5f" || !info.Modified || info.Module != "github.com/acme/delivery" {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if !info.Date.Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Date = %v", info.Date)
+	}
+	expected := "1.4.0 (commit 3f2a9c1-dirty, built 2024-06-01T12:00:00Z, " + runtime.Version() + ")"
+	if info.String() != expected {
+		t.Errorf("String() = %q, expected %q", info.String(), expected)
+	}
+}
+
+func TestResolveLdflagsTakePrecedence(t *testing.T) {
+	Version, Commit, Date = "2.0.0-rc1", "abc123", "2024-07-01T08:30:00Z"
+	t.Cleanup(func() { Version, Commit, Date = "", "", "" })
+
+	build := &debug.BuildInfo{
+		Main:     debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "3f2a9c1d8e7b6a5f"}, {Key: "vcs.time", Value: "2024-06-01T12:00:00Z"}},
+	}
+	info := resolve(build)
+	if info.Version != "2.0.0-rc1" || info.Commit != "abc123" || info.Date.Month() != time.July {
+		t.Errorf("expected ldflags values, got %+v", info)
+	}
+}
+
+func TestResolveDevBuild(t *testing.T) {
+	info := resolve(&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+	if info.Version != "dev" || info.Commit != "" || !info.Date.IsZero() {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.String() != "dev ("+runtime.Version()+")" {
+		t.Errorf("String() = %q", info.String())
+	}
+	if resolve(nil).Version != "dev" {
+		t.Error("expected dev version without build information")
+	}
+	if Get().GoVersion != runtime.Version() {
+		t.Errorf("Get().GoVersion = %q", Get().GoVersion)
+	}
+}
@@ -0,0 +1,247 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package checksum
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+)
+
+// Algorithm identifies a checksum algorithm
+type Algorithm string
+
+// Supported algorithms
+const (
+	MD5    Algorithm = "md5"
+	SHA1   Algorithm = "sha1"
+	SHA256 Algorithm = "sha256"
+	CRC32  Algorithm = "crc32"
+)
+
+// ErrMismatch is returned when a computed checksum differs from the expected value
+var ErrMismatch = errors.New("checksum mismatch")
+
+// NewHash returns a new hash.Hash for the algorithm
+func NewHash(algo Algorithm) (hash.Hash, error) {
+	switch algo {
+	case MD5:
+		return md5.New(), nil
+	case SHA1:
+		return sha1.New(), nil
+	case SHA256:
+		return sha256.New(), nil
+	case CRC32:
+		return crc32.NewIEEE(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+}
+
+// ParseAlgorithm converts a name such as "SHA-256" or "sha256" to an Algorithm
+func ParseAlgorithm(name string) (Algorithm, error) {
+	algo := Algorithm(strings.ToLower(strings.ReplaceAll(name, "-", "")))
+	if _, err := NewHash(algo); err != nil {
+		return "", err
+	}
+	return algo, nil
+}
+
+// Sum streams r and returns its hex-encoded checksum
+func Sum(r io.Reader, algo Algorithm) (string, error) {
+	sums, err := MultiSum(r, algo)
+	if err != nil {
+		return "", err
+	}
+	return sums[algo], nil
+}
+
+// MultiSum streams r once and returns the hex-encoded checksum for each algorithm
+func MultiSum(r io.Reader, algos ...Algorithm) (map[Algorithm]string, error) {
+	if len(algos) == 0 {
+		return nil, fmt.Errorf("at least one algorithm is required")
+	}
+
+	hashes := make(map[Algorithm]hash.Hash, len(algos))
+	writers := make([]io.Writer, 0, len(algos))
+	for _, algo := range algos {
+		h, err := NewHash(algo)
+		if err != nil {
+			return nil, err
+		}
+		hashes[algo] = h
+		writers = append(writers, h)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+
+	sums := make(map[Algorithm]string, len(hashes))
+	for algo, h := range hashes {
+		sums[algo] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
+
+// SumFile returns the hex-encoded checksum of the file at path
+func SumFile(path string, algo Algorithm) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	sum, err := Sum(file, algo)
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+	return sum, nil
+}
+
+// VerifyFile checks the file against an expected checksum. The expected value may be
+// prefixed with the algorithm ("sha256:ab12..."); otherwise the algorithm is inferred
+// from the digest length. A mismatch returns an error wrapping ErrMismatch.
+func VerifyFile(path, expected string) error {
+	algo, digest, err := splitExpected(expected)
+	if err != nil {
+		return err
+	}
+
+	actual, err := SumFile(path, algo)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, digest) {
+		return fmt.Errorf("%w for %s: expected %s %s, got %s", ErrMismatch, path, algo, digest, actual)
+	}
+	return nil
+}
+
+// ManifestEntry is one line of a checksum manifest
+type ManifestEntry struct {
+	Checksum string
+	Path     string // relative to the manifest's directory
+}
+
+// WriteManifest writes a sha256sum-compatible manifest ("<digest>  <path>") for files.
+// Paths are recorded relative to the manifest's directory so the manifest can be
+// verified with `sha256sum -c` (or md5sum/sha1sum) from that directory.
+func WriteManifest(manifestPath string, files []string, algo Algorithm) error {
+	baseDir, err := filepath.Abs(filepath.Dir(manifestPath))
+	if err != nil {
+		return fmt.Errorf("failed to resolve manifest directory: %w", err)
+	}
+
+	var b strings.Builder
+	for _, file := range files {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		relPath, err := filepath.Rel(baseDir, absPath)
+		if err != nil {
+			return fmt.Errorf("failed to make %s relative to manifest: %w", file, err)
+		}
+
+		sum, err := SumFile(absPath, algo)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, filepath.ToSlash(relPath))
+	}
+
+	if err := atomicfile.WriteFile(manifestPath, []byte(b.String())); err != nil {
+		return fmt.Errorf("failed to write manifest %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
+// ReadManifest parses a sha256sum-style manifest, accepting both text ("  ")
+// and binary (" *") separators and skipping blank and comment lines
+func ReadManifest(manifestPath string) ([]ManifestEntry, error) {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest %s: %w", manifestPath, err)
+	}
+	defer file.Close()
+
+	var entries []ManifestEntry
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		digest, rest, ok := strings.Cut(line, " ")
+		if !ok || len(rest) < 2 || (rest[0] != ' ' && rest[0] != '*') {
+			return nil, fmt.Errorf("invalid manifest line %d in %s", lineNum, manifestPath)
+		}
+		entries = append(entries, ManifestEntry{Checksum: digest, Path: rest[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", manifestPath, err)
+	}
+	return entries, nil
+}
+
+// VerifyManifest checks every file listed in the manifest and returns the paths
+// that are missing or do not match. The error aggregates all failures.
+func VerifyManifest(manifestPath string) ([]string, error) {
+	entries, err := ReadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := filepath.Dir(manifestPath)
+	var failed []string
+	var errs []error
+	for _, entry := range entries {
+		path := filepath.Join(baseDir, filepath.FromSlash(entry.Path))
+		if err := VerifyFile(path, entry.Checksum); err != nil {
+			failed = append(failed, entry.Path)
+			errs = append(errs, err)
+		}
+	}
+
+	return failed, errors.Join(errs...)
+}
+
+// splitExpected separates an optional "algo:" prefix from the digest
+func splitExpected(expected string) (Algorithm, string, error) {
+	expected = strings.TrimSpace(expected)
+	if name, digest, ok := strings.Cut(expected, ":"); ok {
+		algo, err := ParseAlgorithm(name)
+		if err != nil {
+			return "", "", err
+		}
+		return algo, digest, nil
+	}
+
+	switch len(expected) {
+	case 8:
+		return CRC32, expected, nil
+	case 32:
+		return MD5, expected, nil
+	case 40:
+		return SHA1, expected, nil
+	case 64:
+		return SHA256, expected, nil
+	default:
+		return "", "", fmt.Errorf("cannot infer checksum algorithm from %q", expected)
+	}
+}
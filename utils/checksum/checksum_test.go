@@ -0,0 +1,167 @@
+package checksum
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Known digests of "hello world"
+const (
+	helloMD5    = "5eb63bbbe01eeed093cb22bb8f5acdc3"
+	helloSHA1   = "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed"
+	helloSHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	helloCRC32  = "0d4a1185"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return path
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		algo     Algorithm
+		expected string
+	}{
+		{MD5, helloMD5},
+		{SHA1, helloSHA1},
+		{SHA256, helloSHA256},
+		{CRC32, helloCRC32},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.algo), func(t *testing.T) {
+			got, err := Sum(strings.NewReader("hello world"), tt.algo)
+			if err != nil {
+				t.Fatalf("Sum failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Sum(%s) = %s, expected %s", tt.algo, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := Sum(strings.NewReader(""), Algorithm("sha512")); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+func TestMultiSum(t *testing.T) {
+	sums, err := MultiSum(strings.NewReader("hello world"), MD5, SHA256)
+	if err != nil {
+		t.Fatalf("MultiSum failed: %v", err)
+	}
+	if sums[MD5] != helloMD5 || sums[SHA256] != helloSHA256 {
+		t.Errorf("unexpected sums: %v", sums)
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, name := range []string{"SHA-256", "sha256", "Sha256"} {
+		if algo, err := ParseAlgorithm(name); err != nil || algo != SHA256 {
+			t.Errorf("ParseAlgorithm(%q) = %q, %v", name, algo, err)
+		}
+	}
+	if _, err := ParseAlgorithm("whirlpool"); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "hello.txt", "hello world")
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"inferred sha256", helloSHA256, false},
+		{"inferred md5", helloMD5, false},
+		{"inferred crc32", helloCRC32, false},
+		{"prefixed sha1", "sha1:" + helloSHA1, false},
+		{"uppercase digest", strings.ToUpper(helloSHA256), false},
+		{"mismatch", strings.Repeat("0", 64), true},
+		{"unknown length", "abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyFile(path, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyFile(%q) error = %v, wantErr %v", tt.expected, err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := VerifyFile(path, strings.Repeat("0", 64)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("expected ErrMismatch, got %v", err)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeFile(t, dir, "part1.csv", "hello world"),
+		writeFile(t, dir, "sub/part2.csv", "second part"),
+	}
+	manifestPath := filepath.Join(dir, "SHA256SUMS")
+
+	if err := WriteManifest(manifestPath, files, SHA256); err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	if !strings.HasPrefix(string(content), helloSHA256+"  part1.csv\n") {
+		t.Errorf("unexpected manifest format:\n%s", content)
+	}
+
+	entries, err := ReadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Path != "sub/part2.csv" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	if failed, err := VerifyManifest(manifestPath); err != nil || len(failed) != 0 {
+		t.Errorf("VerifyManifest failed: %v %v", failed, err)
+	}
+
+	// Tampered and missing files are reported
+	writeFile(t, dir, "part1.csv", "tampered")
+	os.Remove(files[1])
+	failed, err := VerifyManifest(manifestPath)
+	if err == nil {
+		t.Fatal("expected verification error")
+	}
+	if strings.Join(failed, ",") != "part1.csv,sub/part2.csv" {
+		t.Errorf("failed = %v", failed)
+	}
+}
+
+func TestReadManifestBinaryMode(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "hello.txt", "hello world")
+	manifestPath := writeFile(t, dir, "MD5SUMS", "# generated\n"+helloMD5+" *hello.txt\n\n")
+
+	if failed, err := VerifyManifest(manifestPath); err != nil || len(failed) != 0 {
+		t.Errorf("VerifyManifest failed: %v %v", failed, err)
+	}
+
+	badPath := writeFile(t, dir, "BAD", "not a manifest line\n")
+	if _, err := ReadManifest(badPath); err == nil {
+		t.Error("expected error for malformed manifest")
+	}
+}
@@ -0,0 +1,254 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package chunker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Defaults for Options
+const (
+	DefaultChunkSize  = 8 << 20
+	DefaultBufferSize = 64 << 10
+)
+
+// ErrRecordTooLarge is returned when a record exceeds Options.MaxRecordSize
+var ErrRecordTooLarge = errors.New("record exceeds maximum size")
+
+// Options configures chunking. A nil *Options uses the defaults.
+type Options struct {
+	// ChunkSize is the maximum chunk size in bytes (default 8 MiB). A record
+	// longer than ChunkSize gets a chunk of its own.
+	ChunkSize int64
+	// Delimiter ends each record (default "\n"), e.g. "\x1e" for record
+	// separators
+	Delimiter []byte
+	// BufferSize is the size of each read from the source (default 64 KiB)
+	BufferSize int
+	// MaxRecordSize fails on longer records, e.g. a file without delimiters
+	// (0 for no limit)
+	MaxRecordSize int64
+}
+
+// withDefaults returns a copy of opts with defaults applied
+func withDefaults(opts *Options) Options {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if len(o.Delimiter) == 0 {
+		o.Delimiter = []byte("\n")
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultBufferSize
+	}
+	return o
+}
+
+// Chunk is a run of whole records read from the source
+type Chunk struct {
+	// Index is the zero-based position of the chunk in the source
+	Index int
+	// Offset is the position of the first byte in the source
+	Offset int64
+	// Data holds the records including their delimiters. It is owned by the
+	// caller and stays valid after the next call to Next.
+	Data []byte
+	// Records counts the records, including a last one without a delimiter
+	Records int
+}
+
+// Reader splits a stream into chunks of whole records, e.g. to hand lines
+// to parallel workers or to upload parts that never split a record
+type Reader struct {
+	src    io.Reader
+	opts   Options
+	buf    []byte
+	eof    bool
+	index  int
+	offset int64
+	err    error
+}
+
+// NewReader returns a Reader over src. A nil opts uses the defaults.
+func NewReader(src io.Reader, opts *Options) *Reader {
+	return &Reader{src: src, opts: withDefaults(opts)}
+}
+
+// Next returns the next chunk, or io.EOF after the last one. A chunk holds
+// as many whole records as fit in ChunkSize; only a single record longer
+// than ChunkSize makes a larger chunk.
+func (r *Reader) Next() (*Chunk, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	end, err := r.boundary()
+	if err != nil {
+		r.err = err
+		return nil, err
+	}
+	if end == 0 {
+		r.err = io.EOF
+		return nil, io.EOF
+	}
+
+	data := bytes.Clone(r.buf[:end])
+	records := bytes.Count(data, r.opts.Delimiter)
+	if !bytes.HasSuffix(data, r.opts.Delimiter) {
+		records++
+	}
+	chunk := &Chunk{Index: r.index, Offset: r.offset, Data: data, Records: records}
+	r.buf = append(r.buf[:0], r.buf[end:]...)
+	r.index++
+	r.offset += int64(end)
+	return chunk, nil
+}
+
+// boundary fills the buffer and returns the length of the next chunk
+func (r *Reader) boundary() (int, error) {
+	// One byte more than ChunkSize tells whether the rest fits in one chunk
+	if err := r.fill(r.opts.ChunkSize + 1); err != nil {
+		return 0, err
+	}
+	if int64(len(r.buf)) <= r.opts.ChunkSize {
+		return len(r.buf), nil
+	}
+
+	delim := r.opts.Delimiter
+	if i := bytes.LastIndex(r.buf[:r.opts.ChunkSize], delim); i >= 0 {
+		return i + len(delim), nil
+	}
+
+	// The first record is longer than ChunkSize: find its end
+	from := 0
+	for {
+		end := -1
+		if i := bytes.Index(r.buf[from:], delim); i >= 0 {
+			end = from + i + len(delim)
+		} else if r.eof {
+			end = len(r.buf)
+		}
+		if end >= 0 {
+			if r.opts.MaxRecordSize > 0 && int64(end) > r.opts.MaxRecordSize {
+				return 0, r.tooLarge()
+			}
+			return end, nil
+		}
+		if r.opts.MaxRecordSize > 0 && int64(len(r.buf)) > r.opts.MaxRecordSize {
+			return 0, r.tooLarge()
+		}
+		from = max(0, len(r.buf)-len(delim)+1)
+		if err := r.fill(int64(len(r.buf)) + int64(r.opts.BufferSize)); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// fill reads from the source until the buffer holds n bytes or the source
+// is exhausted
+func (r *Reader) fill(n int64) error {
+	for int64(len(r.buf)) < n && !r.eof {
+		if cap(r.buf)-len(r.buf) < r.opts.BufferSize {
+			grown := make([]byte, len(r.buf), 2*cap(r.buf)+r.opts.BufferSize)
+			copy(grown, r.buf)
+			r.buf = grown
+		}
+		m, err := r.src.Read(r.buf[len(r.buf) : len(r.buf)+r.opts.BufferSize])
+		r.buf = r.buf[:len(r.buf)+m]
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return fmt.Errorf("failed to read chunk %d: %w", r.index, err)
+		}
+	}
+	return nil
+}
+
+// tooLarge returns the error for an oversized record at the current offset
+func (r *Reader) tooLarge() error {
+	return fmt.Errorf("%w: record at offset %d is larger than %d bytes", ErrRecordTooLarge, r.offset, r.opts.MaxRecordSize)
+}
+
+// Range is a byte range of whole records within a file
+type Range struct {
+	Index  int
+	Offset int64
+	Length int64
+}
+
+// Reader returns a reader for the range of ra
+func (rg Range) Reader(ra io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(ra, rg.Offset, rg.Length)
+}
+
+// Ranges plans the chunks of a file of the given size without reading it
+// all: only the bytes around each boundary are read, so workers can then
+// process the ranges in parallel with Range.Reader. Ranges follow the same
+// rules as Reader chunks.
+func Ranges(ra io.ReaderAt, size int64, opts *Options) ([]Range, error) {
+	o := withDefaults(opts)
+	var ranges []Range
+	for start := int64(0); start < size; {
+		end := size
+		if size-start > o.ChunkSize {
+			var err error
+			end, err = lastBoundary(ra, start, start+o.ChunkSize, o)
+			if err != nil {
+				return nil, err
+			}
+			if end < 0 {
+				end, err = nextBoundary(ra, start+o.ChunkSize, size, o)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if o.MaxRecordSize > 0 && end-start > o.ChunkSize && end-start > o.MaxRecordSize {
+			return nil, fmt.Errorf("%w: record at offset %d is larger than %d bytes", ErrRecordTooLarge, start, o.MaxRecordSize)
+		}
+		ranges = append(ranges, Range{Index: len(ranges), Offset: start, Length: end - start})
+		start = end
+	}
+	return ranges, nil
+}
+
+// lastBoundary returns the position after the last delimiter within
+// [from, to), or -1 when there is none. Blocks are read backwards from to
+// and overlap so delimiters across block edges are found.
+func lastBoundary(ra io.ReaderAt, from, to int64, o Options) (int64, error) {
+	overlap := int64(len(o.Delimiter) - 1)
+	buf := make([]byte, int64(o.BufferSize)+overlap)
+	for end := to; end > from; end -= int64(o.BufferSize) {
+		start := max(from, end-int64(o.BufferSize))
+		block := buf[:min(to, end+overlap)-start]
+		if _, err := ra.ReadAt(block, start); err != nil && err != io.EOF {
+			return 0, fmt.Errorf("failed to read at offset %d: %w", start, err)
+		}
+		if i := bytes.LastIndex(block, o.Delimiter); i >= 0 {
+			return start + int64(i) + int64(len(o.Delimiter)), nil
+		}
+	}
+	return -1, nil
+}
+
+// nextBoundary returns the position after the first delimiter ending after
+// from, or size when there is none
+func nextBoundary(ra io.ReaderAt, from, size int64, o Options) (int64, error) {
+	overlap := int64(len(o.Delimiter) - 1)
+	buf := make([]byte, int64(o.BufferSize)+overlap)
+	for start := max(0, from-overlap); start < size; start += int64(o.BufferSize) {
+		block := buf[:min(size-start, int64(len(buf)))]
+		if _, err := ra.ReadAt(block, start); err != nil && err != io.EOF {
+			return 0, fmt.Errorf("failed to read at offset %d: %w", start, err)
+		}
+		if i := bytes.Index(block, o.Delimiter); i >= 0 {
+			return start + int64(i) + int64(len(o.Delimiter)), nil
+		}
+	}
+	return size, nil
+}
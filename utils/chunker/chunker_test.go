@@ -0,0 +1,136 @@
+package chunker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// readAll collects every chunk of data
+func readAll(t *testing.T, data []byte, opts *Options) []*Chunk {
+	t.Helper()
+	r := NewReader(bytes.NewReader(data), opts)
+	var chunks []*Chunk
+	for {
+		chunk, err := r.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestReader(t *testing.T) {
+	data := []byte("id,name\n1,alpha\n2,beta\n3,gamma\n4,delta")
+	chunks := readAll(t, data, &Options{ChunkSize: 16, BufferSize: 4})
+
+	expected := []string{"id,name\n1,alpha\n", "2,beta\n3,gamma\n", "4,delta"}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	offset := int64(0)
+	for i, chunk := range chunks {
+		if string(chunk.Data) != expected[i] || chunk.Index != i || chunk.Offset != offset {
+			t.Errorf("chunk %d = %+v", i, chunk)
+		}
+		offset += int64(len(chunk.Data))
+	}
+	if chunks[0].Records != 2 || chunks[2].Records != 1 {
+		t.Errorf("unexpected record counts %d, %d", chunks[0].Records, chunks[2].Records)
+	}
+
+	r := NewReader(strings.NewReader(""), nil)
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF for empty input, got %v", err)
+	}
+}
+
+func TestLongRecord(t *testing.T) {
+	data := []byte("short\n" + strings.Repeat("x", 50) + "\nend\n")
+	chunks := readAll(t, data, &Options{ChunkSize: 10, BufferSize: 8})
+	if len(chunks) != 3 || len(chunks[1].Data) != 51 || chunks[1].Records != 1 {
+		t.Errorf("expected the long record in its own chunk, got %d chunks", len(chunks))
+	}
+
+	r := NewReader(bytes.NewReader(data), &Options{ChunkSize: 10, BufferSize: 8, MaxRecordSize: 20})
+	r.Next()
+	if _, err := r.Next(); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("expected ErrRecordTooLarge, got %v", err)
+	}
+	if _, err := r.Next(); !errors.Is(err, ErrRecordTooLarge) {
+		t.Error("expected the error to persist")
+	}
+
+	noDelimiters := bytes.Repeat([]byte("x"), 100)
+	if _, err := Ranges(bytes.NewReader(noDelimiters), 100, &Options{ChunkSize: 10, MaxRecordSize: 50}); !errors.Is(err, ErrRecordTooLarge) {
+		t.Errorf("expected ErrRecordTooLarge from Ranges, got %v", err)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	failure := errors.New("disk failure")
+	r := NewReader(iotest.ErrReader(failure), nil)
+	if _, err := r.Next(); !errors.Is(err, failure) {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+// TestReaderAndRanges checks on random input that chunks cover the input,
+// end on record boundaries, respect ChunkSize and match the planned ranges
+func TestReaderAndRanges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, delim := range []string{"\n", "\r\n", "|~|"} {
+		for iter := 0; iter < 50; iter++ {
+			var b strings.Builder
+			for i := rng.Intn(60); i > 0; i-- {
+				b.WriteString(strings.Repeat("a", rng.Intn(30)))
+				b.WriteString(delim)
+			}
+			if rng.Intn(2) == 0 {
+				b.WriteString("tail")
+			}
+			data := []byte(b.String())
+			opts := &Options{ChunkSize: int64(1 + rng.Intn(64)), Delimiter: []byte(delim), BufferSize: 1 + rng.Intn(16)}
+			name := fmt.Sprintf("delim %q iter %d chunk %d buffer %d", delim, iter, opts.ChunkSize, opts.BufferSize)
+
+			chunks := readAll(t, data, opts)
+			ranges, err := Ranges(bytes.NewReader(data), int64(len(data)), opts)
+			if err != nil {
+				t.Fatalf("%s: Ranges failed: %v", name, err)
+			}
+			if len(ranges) != len(chunks) {
+				t.Fatalf("%s: %d ranges, %d chunks", name, len(ranges), len(chunks))
+			}
+
+			var joined []byte
+			for i, chunk := range chunks {
+				joined = append(joined, chunk.Data...)
+				last := i == len(chunks)-1
+				if !last && !bytes.HasSuffix(chunk.Data, opts.Delimiter) {
+					t.Fatalf("%s: chunk %d does not end on a record boundary: %q", name, i, chunk.Data)
+				}
+				if int64(len(chunk.Data)) > opts.ChunkSize && chunk.Records != 1 {
+					t.Fatalf("%s: oversized chunk %d holds %d records", name, i, chunk.Records)
+				}
+				if ranges[i].Offset != chunk.Offset || ranges[i].Length != int64(len(chunk.Data)) {
+					t.Fatalf("%s: range %d = %+v, chunk at %d with %d bytes", name, i, ranges[i], chunk.Offset, len(chunk.Data))
+				}
+				section, _ := io.ReadAll(ranges[i].Reader(bytes.NewReader(data)))
+				if !bytes.Equal(section, chunk.Data) {
+					t.Fatalf("%s: range %d reads %q", name, i, section)
+				}
+			}
+			if !bytes.Equal(joined, data) {
+				t.Fatalf("%s: chunks do not reassemble the input", name)
+			}
+		}
+	}
+}
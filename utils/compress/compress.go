@@ -0,0 +1,274 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format identifies a compression format
+type Format string
+
+// Supported formats; None passes data through unchanged
+const (
+	None  Format = "none"
+	Gzip  Format = "gzip"
+	Zstd  Format = "zstd"
+	Bzip2 Format = "bzip2"
+)
+
+// Level is a compression level from BestSpeed (1) to BestCompression (9).
+// Each format maps the level onto its own scale.
+type Level int
+
+// Common compression levels
+const (
+	DefaultCompression Level = 0
+	BestSpeed          Level = 1
+	BestCompression    Level = 9
+)
+
+// magic numbers used for format detection
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic = []byte("BZh")
+)
+
+// Detect returns the format indicated by the leading bytes of a stream,
+// or None when no known magic number matches
+func Detect(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(header, bzip2Magic):
+		return Bzip2
+	default:
+		return None
+	}
+}
+
+// FormatFromExt returns the format for a file name extension (.gz, .zst, .bz2)
+func FormatFromExt(name string) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".tgz":
+		return Gzip
+	case ".zst", ".zstd":
+		return Zstd
+	case ".bz2":
+		return Bzip2
+	default:
+		return None
+	}
+}
+
+// Ext returns the conventional file extension for the format, including the dot
+func Ext(format Format) string {
+	switch format {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	case Bzip2:
+		return ".bz2"
+	default:
+		return ""
+	}
+}
+
+// ParseFormat converts a name such as "gzip", "gz", "zstd" or "bz2" to a Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return None, nil
+	case "gzip", "gz":
+		return Gzip, nil
+	case "zstd", "zst":
+		return Zstd, nil
+	case "bzip2", "bz2":
+		return Bzip2, nil
+	default:
+		return "", fmt.Errorf("unsupported compression format %q", name)
+	}
+}
+
+// NewWriter wraps w with a compressor for the format. The returned writer must be
+// closed to flush the compressed stream; closing does not close w.
+func NewWriter(w io.Writer, format Format, level Level) (io.WriteCloser, error) {
+	if level < DefaultCompression || level > BestCompression {
+		return nil, fmt.Errorf("compression level must be between 0 and 9, got %d", level)
+	}
+
+	switch format {
+	case None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		gzipLevel := gzip.DefaultCompression
+		if level != DefaultCompression {
+			gzipLevel = int(level)
+		}
+		return gzip.NewWriterLevel(w, gzipLevel)
+	case Zstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel(level)))
+	case Bzip2:
+		bzipLevel := bzip2.DefaultCompression
+		if level != DefaultCompression {
+			bzipLevel = int(level)
+		}
+		return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: bzipLevel})
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
+
+// NewReader wraps r with a decompressor for the format
+func NewReader(r io.Reader, format Format) (io.ReadCloser, error) {
+	switch format {
+	case None:
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case Bzip2:
+		return bzip2.NewReader(r, nil)
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
+
+// NewAutoReader detects the format from the stream's magic bytes and returns a
+// matching decompressor. Uncompressed input is passed through unchanged.
+func NewAutoReader(r io.Reader) (io.ReadCloser, Format, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, None, fmt.Errorf("failed to read stream header: %w", err)
+	}
+
+	format := Detect(header)
+	reader, err := NewReader(buffered, format)
+	if err != nil {
+		return nil, format, err
+	}
+	return reader, format, nil
+}
+
+// CompressFile compresses src into dst. An empty dst appends the format's
+// extension to src. The original file is kept.
+func CompressFile(src, dst string, format Format, level Level) (string, error) {
+	if dst == "" {
+		dst = src + Ext(format)
+	}
+	if dst == src {
+		return "", fmt.Errorf("destination must differ from source %s", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	err = writeFile(dst, func(out io.Writer) error {
+		writer, err := NewWriter(out, format, level)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, in); err != nil {
+			writer.Close()
+			return err
+		}
+		return writer.Close()
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to compress %s: %w", src, err)
+	}
+	return dst, nil
+}
+
+// DecompressFile decompresses src into dst, detecting the format from its content.
+// An empty dst strips the compression extension from src.
+func DecompressFile(src, dst string) (string, error) {
+	if dst == "" {
+		dst = strings.TrimSuffix(src, filepath.Ext(src))
+	}
+	if dst == src {
+		return "", fmt.Errorf("destination must differ from source %s", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	reader, _, err := NewAutoReader(in)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress %s: %w", src, err)
+	}
+	defer reader.Close()
+
+	err = writeFile(dst, func(out io.Writer) error {
+		_, err := io.Copy(out, reader)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress %s: %w", src, err)
+	}
+	return dst, nil
+}
+
+// writeFile creates path, runs fn against it, and removes the file on failure
+func writeFile(path string, fn func(out io.Writer) error) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = fn(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// zstdLevel maps a 1-9 level onto the zstd encoder presets
+func zstdLevel(level Level) zstd.EncoderLevel {
+	switch {
+	case level == DefaultCompression:
+		return zstd.SpeedDefault
+	case level <= 2:
+		return zstd.SpeedFastest
+	case level <= 5:
+		return zstd.SpeedDefault
+	case level <= 7:
+		return zstd.SpeedBetterCompression
+	default:
+		return zstd.SpeedBestCompression
+	}
+}
+
+// nopWriteCloser adds a no-op Close to an io.Writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,154 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var sample = []byte(strings.Repeat("timestamp,level,message\n2024-01-01,INFO,started\n", 200))
+
+func TestRoundTrip(t *testing.T) {
+	formats := []Format{None, Gzip, Zstd, Bzip2}
+	levels := []Level{DefaultCompression, BestSpeed, 5, BestCompression}
+
+	for _, format := range formats {
+		for _, level := range levels {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format, level)
+			if err != nil {
+				t.Fatalf("NewWriter(%s, %d) failed: %v", format, level, err)
+			}
+			if _, err := w.Write(sample); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close failed: %v", err)
+			}
+
+			if format != None && buf.Len() >= len(sample) {
+				t.Errorf("%s level %d did not compress (%d >= %d)", format, level, buf.Len(), len(sample))
+			}
+
+			r, err := NewReader(bytes.NewReader(buf.Bytes()), format)
+			if err != nil {
+				t.Fatalf("NewReader(%s) failed: %v", format, err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			if !bytes.Equal(got, sample) {
+				t.Errorf("%s level %d round trip mismatch", format, level)
+			}
+		}
+	}
+}
+
+func TestAutoReader(t *testing.T) {
+	for _, format := range []Format{None, Gzip, Zstd, Bzip2} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, _ := NewWriter(&buf, format, DefaultCompression)
+			w.Write(sample)
+			w.Close()
+
+			r, detected, err := NewAutoReader(&buf)
+			if err != nil {
+				t.Fatalf("NewAutoReader failed: %v", err)
+			}
+			defer r.Close()
+			if detected != format {
+				t.Errorf("detected %s, expected %s", detected, format)
+			}
+			got, _ := io.ReadAll(r)
+			if !bytes.Equal(got, sample) {
+				t.Error("auto reader output mismatch")
+			}
+		})
+	}
+
+	// Short and empty inputs are passed through
+	r, format, err := NewAutoReader(strings.NewReader("hi"))
+	if err != nil || format != None {
+		t.Fatalf("unexpected result for short input: %s, %v", format, err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "hi" {
+		t.Errorf("got %q, expected %q", got, "hi")
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	if _, err := NewWriter(io.Discard, Gzip, 10); err == nil {
+		t.Error("expected error for level above 9")
+	}
+	if _, err := NewWriter(io.Discard, Format("lz4"), DefaultCompression); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if _, err := NewReader(strings.NewReader(""), Format("lz4")); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestFormatHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+	}{
+		{"app.log.gz", Gzip},
+		{"app.log.ZST", Zstd},
+		{"app.log.bz2", Bzip2},
+		{"app.log", None},
+	}
+	for _, tt := range tests {
+		if got := FormatFromExt(tt.name); got != tt.want {
+			t.Errorf("FormatFromExt(%q) = %s, expected %s", tt.name, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"gz", "zstd", "bz2", "none"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("rar"); err == nil {
+		t.Error("expected error for unknown format name")
+	}
+}
+
+func TestCompressFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(src, sample, 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	for _, format := range []Format{Gzip, Zstd, Bzip2} {
+		t.Run(string(format), func(t *testing.T) {
+			compressed, err := CompressFile(src, "", format, BestCompression)
+			if err != nil {
+				t.Fatalf("CompressFile failed: %v", err)
+			}
+			if compressed != src+Ext(format) {
+				t.Errorf("compressed path = %s", compressed)
+			}
+
+			restored := filepath.Join(dir, "restored-"+string(format)+".log")
+			if _, err := DecompressFile(compressed, restored); err != nil {
+				t.Fatalf("DecompressFile failed: %v", err)
+			}
+			got, _ := os.ReadFile(restored)
+			if !bytes.Equal(got, sample) {
+				t.Error("decompressed file mismatch")
+			}
+		})
+	}
+
+	if _, err := CompressFile(filepath.Join(dir, "missing.log"), "", Gzip, DefaultCompression); err == nil {
+		t.Error("expected error for missing source")
+	}
+}
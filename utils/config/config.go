@@ -0,0 +1,259 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/romisugianto/go-utils/utils/humanize"
+)
+
+// Resolver resolves a secret reference such as the path in "file:/run/secrets/db"
+type Resolver func(ref string) (string, error)
+
+// Options controls how configuration is loaded
+type Options struct {
+	// EnvPrefix is prepended to every `env` tag, e.g. "APP_" turns env:"PORT" into APP_PORT
+	EnvPrefix string
+	// Resolvers maps a value scheme to a resolver; "file" is always available.
+	// A string value of the form "scheme:ref" is replaced with the resolved secret.
+	Resolvers map[string]Resolver
+}
+
+// envRef matches ${VAR} and ${VAR:-default} references in string values
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Load reads the configuration file at path into target, a pointer to a struct.
+// See LoadWithOptions for the supported struct tags.
+func Load(path string, target any) error {
+	return LoadWithOptions(path, target, Options{})
+}
+
+// LoadWithOptions loads configuration into target in the following order:
+//  1. `default:"..."` tag values
+//  2. the YAML, JSON, or TOML file at path (by extension); an empty path skips the file
+//  3. environment variables named by `env:"..."` tags
+//  4. ${VAR} expansion and "scheme:ref" secret resolution in string fields
+//
+// Fields tagged `required:"true"` must be non-zero afterwards. All problems are
+// reported together in a single error.
+func LoadWithOptions(path string, target any, opts Options) error {
+	root := reflect.ValueOf(target)
+	if root.Kind() != reflect.Pointer || root.IsNil() || root.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target must be a non-nil pointer to a struct, got %T", target)
+	}
+
+	resolvers := map[string]Resolver{"file": readSecretFile}
+	for scheme, resolver := range opts.Resolvers {
+		resolvers[scheme] = resolver
+	}
+
+	var errs []error
+	walkFields(root.Elem(), "", func(field reflect.Value, info reflect.StructField, name string) {
+		if def, ok := info.Tag.Lookup("default"); ok && field.IsZero() {
+			if err := setValue(field, def); err != nil {
+				errs = append(errs, fmt.Errorf("invalid default for %s: %w", name, err))
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if path != "" {
+		if err := decodeFile(path, target); err != nil {
+			return err
+		}
+	}
+
+	walkFields(root.Elem(), "", func(field reflect.Value, info reflect.StructField, name string) {
+		if key := info.Tag.Get("env"); key != "" {
+			if raw, ok := os.LookupEnv(opts.EnvPrefix + key); ok {
+				if err := setValue(field, raw); err != nil {
+					errs = append(errs, fmt.Errorf("invalid value for %s from %s: %w", name, opts.EnvPrefix+key, err))
+				}
+			}
+		}
+
+		if err := expandField(field, resolvers); err != nil {
+			errs = append(errs, fmt.Errorf("failed to expand %s: %w", name, err))
+		}
+
+		if info.Tag.Get("required") == "true" && field.IsZero() {
+			errs = append(errs, fmt.Errorf("missing required field %s", name))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// decodeFile parses the file into target based on its extension
+func decodeFile(path string, target any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, target)
+	case ".json":
+		err = json.Unmarshal(data, target)
+	case ".toml":
+		err = toml.Unmarshal(data, target)
+	default:
+		return fmt.Errorf("unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// walkFields calls fn for every exported leaf field, descending into nested structs.
+// The name passed to fn is the dotted Go field path used in error messages.
+func walkFields(v reflect.Value, prefix string, fn func(field reflect.Value, info reflect.StructField, name string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		info := t.Field(i)
+		if !info.IsExported() {
+			continue
+		}
+		field := v.Field(i)
+		name := prefix + info.Name
+
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}) {
+			walkFields(field, name+".", fn)
+			continue
+		}
+		fn(field, info, name)
+	}
+}
+
+// setValue parses raw into the field according to its type. Fields whose
+// type implements encoding.TextUnmarshaler, such as humanize.ByteSize, parse
+// themselves; durations also accept days and weeks, e.g. "3d12h".
+func setValue(field reflect.Value, raw string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := humanize.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// expandField expands environment references and secrets in string and []string fields
+func expandField(field reflect.Value, resolvers map[string]Resolver) error {
+	switch {
+	case field.Kind() == reflect.String:
+		expanded, err := expandValue(field.String(), resolvers)
+		if err != nil {
+			return err
+		}
+		field.SetString(expanded)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < field.Len(); i++ {
+			expanded, err := expandValue(field.Index(i).String(), resolvers)
+			if err != nil {
+				return err
+			}
+			field.Index(i).SetString(expanded)
+		}
+	}
+	return nil
+}
+
+// expandValue replaces ${VAR} references and resolves "scheme:ref" secrets
+func expandValue(value string, resolvers map[string]Resolver) (string, error) {
+	var missing []string
+	value = envRef.ReplaceAllStringFunc(value, func(ref string) string {
+		match := envRef.FindStringSubmatch(ref)
+		if v, ok := os.LookupEnv(match[1]); ok {
+			return v
+		}
+		if match[2] != "" {
+			return match[3]
+		}
+		missing = append(missing, match[1])
+		return ref
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	if scheme, ref, ok := strings.Cut(value, ":"); ok {
+		if resolver, found := resolvers[scheme]; found {
+			return resolver(ref)
+		}
+	}
+	return value, nil
+}
+
+// readSecretFile returns the contents of a secret file without the trailing newline
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/humanize"
+)
+
+type s3Config struct {
+	Bucket string `yaml:"bucket" json:"bucket" toml:"bucket" env:"S3_BUCKET" required:"true"`
+	Region string `yaml:"region" json:"region" toml:"region" default:"us-east-1"`
+}
+
+type appConfig struct {
+	Name     string            `yaml:"name" json:"name" toml:"name" required:"true"`
+	Workers  int               `yaml:"workers" json:"workers" toml:"workers" env:"WORKERS" default:"4"`
+	Debug    bool              `yaml:"debug" json:"debug" toml:"debug" env:"DEBUG"`
+	Timeout  time.Duration     `yaml:"timeout" json:"timeout" toml:"timeout" env:"TIMEOUT" default:"30s"`
+	Tags     []string          `yaml:"tags" json:"tags" toml:"tags" env:"TAGS"`
+	Password string            `yaml:"password" json:"password" toml:"password"`
+	MaxSize  humanize.ByteSize `yaml:"max_size" json:"max_size" toml:"max_size" env:"MAX_SIZE" default:"64MiB"`
+	S3       s3Config          `yaml:"s3" json:"s3" toml:"s3"`
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+	}{
+		{"yaml", "app.yaml", "name: splitter\nworkers: 8\ntags: [a, b]\ns3:\n  bucket: data\n"},
+		{"json", "app.json", `{"name": "splitter", "workers": 8, "tags": ["a", "b"], "s3": {"bucket": "data"}}`},
+		{"toml", "app.toml", "name = \"splitter\"\nworkers = 8\ntags = [\"a\", \"b\"]\n[s3]\nbucket = \"data\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg appConfig
+			if err := Load(writeConfig(t, tt.filename, tt.content), &cfg); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if cfg.Name != "splitter" || cfg.Workers != 8 || cfg.S3.Bucket != "data" {
+				t.Errorf("unexpected config: %+v", cfg)
+			}
+			if strings.Join(cfg.Tags, ",") != "a,b" {
+				t.Errorf("Tags = %v, expected [a b]", cfg.Tags)
+			}
+			// Defaults fill fields missing from the file
+			if cfg.Timeout != 30*time.Second || cfg.S3.Region != "us-east-1" || int64(cfg.MaxSize) != 64*humanize.MiB {
+				t.Errorf("expected defaults, got Timeout=%v Region=%q MaxSize=%v", cfg.Timeout, cfg.S3.Region, cfg.MaxSize)
+			}
+		})
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "app.yaml", "name: splitter\nworkers: 8\ns3:\n  bucket: data\n")
+	t.Setenv("APP_WORKERS", "16")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_TIMEOUT", "1d")
+	t.Setenv("APP_MAX_SIZE", "1.5GB")
+	t.Setenv("APP_TAGS", "x, y")
+	t.Setenv("APP_S3_BUCKET", "override")
+
+	var cfg appConfig
+	if err := LoadWithOptions(path, &cfg, Options{EnvPrefix: "APP_"}); err != nil {
+		t.Fatalf("LoadWithOptions failed: %v", err)
+	}
+	if cfg.Workers != 16 || !cfg.Debug || cfg.Timeout != 24*time.Hour || cfg.MaxSize != 1_500_000_000 || cfg.S3.Bucket != "override" {
+		t.Errorf("env overrides not applied: %+v", cfg)
+	}
+	if strings.Join(cfg.Tags, ",") != "x,y" {
+		t.Errorf("Tags = %v, expected [x y]", cfg.Tags)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	t.Run("missing required fields are aggregated", func(t *testing.T) {
+		var cfg appConfig
+		err := Load(writeConfig(t, "app.yaml", "workers: 2\n"), &cfg)
+		if err == nil {
+			t.Fatal("expected error for missing required fields")
+		}
+		for _, field := range []string{"Name", "S3.Bucket"} {
+			if !strings.Contains(err.Error(), field) {
+				t.Errorf("expected error to mention %s, got %q", field, err.Error())
+			}
+		}
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		t.Setenv("WORKERS", "many")
+		var cfg appConfig
+		err := Load(writeConfig(t, "app.yaml", "name: x\ns3:\n  bucket: b\n"), &cfg)
+		if err == nil || !strings.Contains(err.Error(), "WORKERS") {
+			t.Errorf("expected error mentioning WORKERS, got %v", err)
+		}
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		var cfg appConfig
+		if err := Load(writeConfig(t, "app.ini", "name=x"), &cfg); err == nil {
+			t.Error("expected error for unsupported extension")
+		}
+	})
+
+	t.Run("non-pointer target", func(t *testing.T) {
+		if err := Load("", appConfig{}); err == nil {
+			t.Error("expected error for non-pointer target")
+		}
+	})
+}
+
+func TestLoadSecrets(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secretPath, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	t.Run("file reference", func(t *testing.T) {
+		var cfg appConfig
+		content := fmt.Sprintf("name: x\npassword: file:%s\ns3:\n  bucket: b\n", secretPath)
+		if err := Load(writeConfig(t, "app.yaml", content), &cfg); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cfg.Password != "s3cr3t" {
+			t.Errorf("Password = %q, expected %q", cfg.Password, "s3cr3t")
+		}
+	})
+
+	t.Run("environment expansion", func(t *testing.T) {
+		t.Setenv("DB_PASS", "from-env")
+		var cfg appConfig
+		content := "name: ${JOB_NAME:-nightly}\npassword: ${DB_PASS}\ns3:\n  bucket: b\n"
+		if err := Load(writeConfig(t, "app.yaml", content), &cfg); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cfg.Name != "nightly" || cfg.Password != "from-env" {
+			t.Errorf("unexpected expansion: Name=%q Password=%q", cfg.Name, cfg.Password)
+		}
+	})
+
+	t.Run("unset variable", func(t *testing.T) {
+		var cfg appConfig
+		content := "name: x\npassword: ${UNSET_CONFIG_TEST_VAR}\ns3:\n  bucket: b\n"
+		if err := Load(writeConfig(t, "app.yaml", content), &cfg); err == nil {
+			t.Error("expected error for unset variable")
+		}
+	})
+
+	t.Run("custom resolver", func(t *testing.T) {
+		var cfg appConfig
+		content := "name: x\npassword: vault:db/password\ns3:\n  bucket: b\n"
+		opts := Options{Resolvers: map[string]Resolver{
+			"vault": func(ref string) (string, error) { return "resolved-" + ref, nil },
+		}}
+		if err := LoadWithOptions(writeConfig(t, "app.yaml", content), &cfg, opts); err != nil {
+			t.Fatalf("LoadWithOptions failed: %v", err)
+		}
+		if cfg.Password != "resolved-db/password" {
+			t.Errorf("Password = %q", cfg.Password)
+		}
+	})
+}
@@ -0,0 +1,437 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package crypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeySize is the length of an AES-256 key in bytes
+const KeySize = 32
+
+// DefaultChunkSize is the amount of plaintext sealed per chunk
+const DefaultChunkSize = 64 * 1024
+
+// maxChunkSize bounds the buffer allocated for a chunk read from a header
+const maxChunkSize = 16 * 1024 * 1024
+
+// Ext is the extension added by EncryptFile
+const Ext = ".enc"
+
+// ErrAuthentication is returned when data was modified, truncated or
+// decrypted with the wrong key
+var ErrAuthentication = errors.New("message authentication failed")
+
+// ErrFormat is returned for input that is not produced by this package
+var ErrFormat = errors.New("not an encrypted stream")
+
+// magic identifies the stream format
+var magic = []byte("GUCE")
+
+const (
+	formatVersion = 1
+	saltSize      = 16
+	prefixSize    = 7
+	// magic, version, KDF, three KDF parameters, salt, chunk size, nonce prefix
+	headerSize = 4 + 1 + 1 + 4 + 4 + 1 + saltSize + 4 + prefixSize
+)
+
+// Secret supplies the key used to encrypt or decrypt a stream
+type Secret struct {
+	key        []byte
+	passphrase string
+	kdf        KDF
+}
+
+// Key uses a raw 32-byte key, e.g. from GenerateKey
+func Key(key []byte) Secret {
+	return Secret{key: key}
+}
+
+// Passphrase derives the key from a passphrase with a random salt. The KDF
+// is used when encrypting; decryption reads it from the stream header.
+// An empty kdf uses Argon2id.
+func Passphrase(passphrase string, kdf KDF) Secret {
+	if kdf == "" {
+		kdf = Argon2id
+	}
+	return Secret{passphrase: passphrase, kdf: kdf}
+}
+
+// GenerateKey returns a random 32-byte key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// header is the unencrypted stream header. Its encoding is authenticated as
+// additional data of every chunk.
+type header struct {
+	kdf       kdfParams
+	salt      [saltSize]byte
+	chunkSize uint32
+	prefix    [prefixSize]byte
+}
+
+func (h *header) marshal() []byte {
+	buf := make([]byte, 0, headerSize)
+	buf = append(buf, magic...)
+	buf = append(buf, formatVersion, h.kdf.id)
+	buf = binary.BigEndian.AppendUint32(buf, h.kdf.p1)
+	buf = binary.BigEndian.AppendUint32(buf, h.kdf.p2)
+	buf = append(buf, h.kdf.p3)
+	buf = append(buf, h.salt[:]...)
+	buf = binary.BigEndian.AppendUint32(buf, h.chunkSize)
+	return append(buf, h.prefix[:]...)
+}
+
+func readHeader(r io.Reader) (*header, []byte, error) {
+	buf := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, nil, ErrFormat
+		}
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if !bytes.Equal(buf[:4], magic) {
+		return nil, nil, ErrFormat
+	}
+	if buf[4] != formatVersion {
+		return nil, nil, fmt.Errorf("unsupported format version %d", buf[4])
+	}
+
+	h := &header{kdf: kdfParams{
+		id: buf[5],
+		p1: binary.BigEndian.Uint32(buf[6:10]),
+		p2: binary.BigEndian.Uint32(buf[10:14]),
+		p3: buf[14],
+	}}
+	copy(h.salt[:], buf[15:15+saltSize])
+	h.chunkSize = binary.BigEndian.Uint32(buf[15+saltSize : 19+saltSize])
+	copy(h.prefix[:], buf[19+saltSize:])
+	if h.chunkSize == 0 || h.chunkSize > maxChunkSize {
+		return nil, nil, fmt.Errorf("%w: invalid chunk size %d", ErrFormat, h.chunkSize)
+	}
+	return h, buf, nil
+}
+
+// newAEAD creates the AES-256-GCM cipher for key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce builds the chunk nonce: the random prefix, the chunk counter and a
+// flag marking the final chunk, so chunks cannot be reordered or truncated
+func nonce(prefix [prefixSize]byte, counter uint32, last bool) []byte {
+	n := make([]byte, 0, 12)
+	n = append(n, prefix[:]...)
+	n = binary.BigEndian.AppendUint32(n, counter)
+	if last {
+		return append(n, 1)
+	}
+	return append(n, 0)
+}
+
+// Writer encrypts data written to it in authenticated chunks
+type Writer struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	prefix  [prefixSize]byte
+	buf     []byte
+	counter uint32
+	err     error
+	closed  bool
+}
+
+// NewWriter writes the stream header to w and returns a writer encrypting
+// everything written to it. Close must be called to write the final chunk.
+func NewWriter(w io.Writer, secret Secret) (*Writer, error) {
+	h := &header{chunkSize: DefaultChunkSize}
+	if _, err := rand.Read(h.prefix[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	key := secret.key
+	if secret.passphrase != "" {
+		params, err := defaultParams(secret.kdf)
+		if err != nil {
+			return nil, err
+		}
+		h.kdf = params
+		if _, err := rand.Read(h.salt[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		if key, err = h.kdf.derive(secret.passphrase, h.salt[:]); err != nil {
+			return nil, err
+		}
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := h.marshal()
+	if _, err := w.Write(encoded); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	return &Writer{
+		w:      w,
+		aead:   aead,
+		header: encoded,
+		prefix: h.prefix,
+		buf:    make([]byte, 0, h.chunkSize),
+	}, nil
+}
+
+// Write encrypts p. Full chunks are sealed once more data follows them.
+func (e *Writer) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.closed {
+		return 0, fmt.Errorf("write to closed writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		if len(e.buf) == cap(e.buf) {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := min(len(p), cap(e.buf)-len(e.buf))
+		e.buf = append(e.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the final chunk. It does not close the underlying writer.
+func (e *Writer) Close() error {
+	if e.closed {
+		return e.err
+	}
+	e.closed = true
+	if e.err != nil {
+		return e.err
+	}
+	return e.seal(true)
+}
+
+// seal encrypts and writes the buffered chunk
+func (e *Writer) seal(last bool) error {
+	if !last && e.counter == ^uint32(0) {
+		e.err = fmt.Errorf("stream too large")
+		return e.err
+	}
+	sealed := e.aead.Seal(nil, nonce(e.prefix, e.counter, last), e.buf, e.header)
+	if _, err := e.w.Write(sealed); err != nil {
+		e.err = err
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// Reader decrypts and authenticates a stream chunk by chunk
+type Reader struct {
+	r         *bufio.Reader
+	aead      cipher.AEAD
+	header    []byte
+	prefix    [prefixSize]byte
+	chunk     []byte
+	plaintext []byte
+	counter   uint32
+	done      bool
+	err       error
+}
+
+// NewReader reads the stream header from r and returns a reader yielding the
+// plaintext. Each chunk is authenticated before it is returned; a modified or
+// truncated stream fails with ErrAuthentication.
+func NewReader(r io.Reader, secret Secret) (*Reader, error) {
+	h, encoded, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	key := secret.key
+	if h.kdf.id != kdfNone {
+		if secret.passphrase == "" {
+			return nil, fmt.Errorf("stream is passphrase-protected")
+		}
+		if key, err = h.kdf.derive(secret.passphrase, h.salt[:]); err != nil {
+			return nil, err
+		}
+	} else if secret.passphrase != "" {
+		return nil, fmt.Errorf("stream is encrypted with a key, not a passphrase")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: encoded,
+		prefix: h.prefix,
+		chunk:  make([]byte, int(h.chunkSize)+aead.Overhead()),
+	}, nil
+}
+
+// Read returns decrypted, authenticated plaintext
+func (d *Reader) Read(p []byte) (int, error) {
+	for len(d.plaintext) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.plaintext)
+	d.plaintext = d.plaintext[n:]
+	return n, nil
+}
+
+// next reads and opens the next chunk. A chunk shorter than the chunk size,
+// or a full chunk at the end of the input, must be the final chunk.
+func (d *Reader) next() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	last := false
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, err := d.r.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	plaintext, err := d.aead.Open(d.chunk[:0], nonce(d.prefix, d.counter, last), d.chunk[:n], d.header)
+	if err != nil {
+		return ErrAuthentication
+	}
+	d.counter++
+	d.done = last
+	d.plaintext = plaintext
+	return nil
+}
+
+// Encrypt encrypts src into dst
+func Encrypt(dst io.Writer, src io.Reader, secret Secret) error {
+	w, err := NewWriter(dst, secret)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Decrypt decrypts src into dst. Plaintext is written as each chunk is
+// authenticated, so on error dst may hold a verified prefix of the data.
+func Decrypt(dst io.Writer, src io.Reader, secret Secret) error {
+	r, err := NewReader(src, secret)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// Verify authenticates every chunk of src without keeping the plaintext
+func Verify(src io.Reader, secret Secret) error {
+	return Decrypt(io.Discard, src, secret)
+}
+
+// EncryptFile encrypts src into dst. An empty dst appends Ext to src.
+// The original file is kept.
+func EncryptFile(src, dst string, secret Secret) (string, error) {
+	if dst == "" {
+		dst = src + Ext
+	}
+	return dst, transformFile(src, dst, "encrypt", func(out io.Writer, in io.Reader) error {
+		return Encrypt(out, in, secret)
+	})
+}
+
+// DecryptFile decrypts src into dst. An empty dst strips Ext from src. The
+// destination is removed if the data fails authentication.
+func DecryptFile(src, dst string, secret Secret) (string, error) {
+	if dst == "" {
+		dst = strings.TrimSuffix(src, Ext)
+	}
+	return dst, transformFile(src, dst, "decrypt", func(out io.Writer, in io.Reader) error {
+		return Decrypt(out, in, secret)
+	})
+}
+
+// VerifyFile checks that path decrypts and authenticates with secret
+func VerifyFile(path string, secret Secret) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer in.Close()
+	if err := Verify(in, secret); err != nil {
+		return fmt.Errorf("failed to verify %s: %w", path, err)
+	}
+	return nil
+}
+
+// transformFile streams src through fn into dst, removing dst on failure
+func transformFile(src, dst, operation string, fn func(out io.Writer, in io.Reader) error) error {
+	if dst == src {
+		return fmt.Errorf("destination must differ from source %s", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", dst, err)
+	}
+	err = fn(out, bufio.NewReader(in))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to %s %s: %w", operation, src, err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	return key
+}
+
+func encrypt(t *testing.T, data []byte, secret Secret) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encrypt(&buf, bytes.NewReader(data), secret); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := testKey(t)
+	sizes := []int{0, 1, DefaultChunkSize - 1, DefaultChunkSize, DefaultChunkSize + 1, 3*DefaultChunkSize + 17}
+
+	for _, size := range sizes {
+		data := make([]byte, size)
+		rand.Read(data)
+
+		encrypted := encrypt(t, data, Key(key))
+		chunks := max(1, (size+DefaultChunkSize-1)/DefaultChunkSize)
+		if want := headerSize + size + 16*chunks; len(encrypted) != want {
+			t.Errorf("size %d: encrypted length %d, expected %d", size, len(encrypted), want)
+		}
+
+		var out bytes.Buffer
+		if err := Decrypt(&out, bytes.NewReader(encrypted), Key(key)); err != nil {
+			t.Fatalf("size %d: Decrypt failed: %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), data) {
+			t.Errorf("size %d: decrypted data differs", size)
+		}
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	data := []byte("order_id,amount\n1,9.99\n")
+	for _, kdf := range []KDF{Scrypt, Argon2id} {
+		encrypted := encrypt(t, data, Passphrase("correct horse", kdf))
+
+		var out bytes.Buffer
+		if err := Decrypt(&out, bytes.NewReader(encrypted), Passphrase("correct horse", "")); err != nil {
+			t.Fatalf("%s: Decrypt failed: %v", kdf, err)
+		}
+		if out.String() != string(data) {
+			t.Errorf("%s: decrypted %q", kdf, out.String())
+		}
+		if err := Verify(bytes.NewReader(encrypted), Passphrase("wrong", "")); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: expected ErrAuthentication for wrong passphrase, got %v", kdf, err)
+		}
+		if err := Verify(bytes.NewReader(encrypted), Key(testKey(t))); err == nil {
+			t.Errorf("%s: expected error decrypting a passphrase stream with a key", kdf)
+		}
+	}
+
+	if _, err := NewWriter(io.Discard, Passphrase("secret", "md5")); err == nil {
+		t.Error("expected error for unsupported KDF")
+	}
+}
+
+func TestKDFLimits(t *testing.T) {
+	encrypted := encrypt(t, []byte("data"), Passphrase("x", Scrypt))
+
+	// Headers are read before they are authenticated, so excessive
+	// parameters must be rejected before deriving the key
+	tests := map[string]struct {
+		logN, r uint32
+		p       byte
+	}{
+		"huge r":         {20, 65536, 1},
+		"huge p":         {15, 8, 255},
+		"memory over 1G": {20, 16, 1},
+		"small N":        {4, 8, 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			crafted := append([]byte(nil), encrypted...)
+			binary.BigEndian.PutUint32(crafted[6:10], tt.logN)
+			binary.BigEndian.PutUint32(crafted[10:14], tt.r)
+			crafted[14] = tt.p
+			if _, err := NewReader(bytes.NewReader(crafted), Passphrase("x", "")); !errors.Is(err, ErrFormat) {
+				t.Errorf("expected ErrFormat, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTampering(t *testing.T) {
+	key := testKey(t)
+	data := make([]byte, 2*DefaultChunkSize+100)
+	rand.Read(data)
+	encrypted := encrypt(t, data, Key(key))
+
+	tests := map[string][]byte{
+		"flipped byte":     flip(encrypted, headerSize+DefaultChunkSize+50),
+		"modified header":  flip(encrypted, 20),
+		"truncated chunk":  encrypted[:len(encrypted)-10],
+		"dropped chunk":    encrypted[:headerSize+DefaultChunkSize+16],
+		"empty body":       encrypted[:headerSize],
+		"appended garbage": append(append([]byte(nil), encrypted...), 0),
+	}
+	for name, tampered := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := Verify(bytes.NewReader(tampered), Key(key)); err == nil {
+				t.Error("expected verification to fail")
+			}
+		})
+	}
+
+	if err := Verify(bytes.NewReader(encrypted), Key(testKey(t))); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("expected ErrAuthentication for wrong key, got %v", err)
+	}
+	if err := Verify(bytes.NewReader([]byte("plain text file")), Key(key)); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat for plaintext, got %v", err)
+	}
+	if _, err := NewWriter(io.Discard, Key([]byte("short"))); err == nil {
+		t.Error("expected error for short key")
+	}
+}
+
+func flip(data []byte, i int) []byte {
+	out := append([]byte(nil), data...)
+	out[i] ^= 0x01
+	return out
+}
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "report.csv")
+	os.WriteFile(src, []byte("a,b,c\n1,2,3\n"), 0644)
+	key := testKey(t)
+
+	encrypted, err := EncryptFile(src, "", Key(key))
+	if err != nil {
+		t.Fatalf("EncryptFile failed: %v", err)
+	}
+	if encrypted != src+Ext {
+		t.Errorf("encrypted path = %s", encrypted)
+	}
+	if err := VerifyFile(encrypted, Key(key)); err != nil {
+		t.Errorf("VerifyFile failed: %v", err)
+	}
+
+	os.Remove(src)
+	decrypted, err := DecryptFile(encrypted, "", Key(key))
+	if err != nil {
+		t.Fatalf("DecryptFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(decrypted); string(content) != "a,b,c\n1,2,3\n" {
+		t.Errorf("decrypted content %q", content)
+	}
+
+	// A failed decryption leaves no partial output
+	dst := filepath.Join(dir, "out.csv")
+	if _, err := DecryptFile(encrypted, dst, Key(testKey(t))); err == nil {
+		t.Error("expected error for wrong key")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("expected output to be removed after failed decryption")
+	}
+	if _, err := EncryptFile(src, src, Key(key)); err == nil {
+		t.Error("expected error when destination equals source")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	a, err := DeriveKey("secret", salt, Scrypt)
+	if err != nil {
+		t.Fatalf("DeriveKey failed: %v", err)
+	}
+	b, _ := DeriveKey("secret", salt, Scrypt)
+	c, _ := DeriveKey("secret", salt, Argon2id)
+	if len(a) != KeySize || !bytes.Equal(a, b) || bytes.Equal(a, c) {
+		t.Error("expected deterministic 32-byte keys that differ per KDF")
+	}
+	if _, err := DeriveKey("", salt, Scrypt); err == nil {
+		t.Error("expected error for empty passphrase")
+	}
+}
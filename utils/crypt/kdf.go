@@ -0,0 +1,93 @@
+package crypt
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF selects how a passphrase is turned into a key
+type KDF string
+
+// Supported key derivation functions
+const (
+	Scrypt   KDF = "scrypt"
+	Argon2id KDF = "argon2id"
+)
+
+// KDF identifiers stored in the stream header
+const (
+	kdfNone     byte = 0
+	kdfScrypt   byte = 1
+	kdfArgon2id byte = 2
+)
+
+// Limits on parameters read from a header, so a crafted file cannot force
+// excessive memory use during derivation
+const (
+	maxScryptLogN   = 20
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxScryptMemory = 1 << 30     // bytes, 128*r*N
+	maxArgon2Memory = 1024 * 1024 // KiB
+	maxArgon2Time   = 16
+)
+
+// kdfParams are the derivation parameters recorded in the header. For scrypt
+// p1 is log2(N), p2 is r and p3 is p; for Argon2id p1 is the number of passes,
+// p2 the memory in KiB and p3 the parallelism.
+type kdfParams struct {
+	id byte
+	p1 uint32
+	p2 uint32
+	p3 uint8
+}
+
+// defaultParams returns the recommended parameters for kdf
+func defaultParams(kdf KDF) (kdfParams, error) {
+	switch kdf {
+	case Scrypt:
+		return kdfParams{id: kdfScrypt, p1: 15, p2: 8, p3: 1}, nil
+	case Argon2id:
+		return kdfParams{id: kdfArgon2id, p1: 3, p2: 64 * 1024, p3: 4}, nil
+	default:
+		return kdfParams{}, fmt.Errorf("unsupported KDF %q", kdf)
+	}
+}
+
+// DeriveKey derives a 32-byte key from passphrase and salt with the default
+// parameters of kdf, e.g. to cache the key for many files
+func DeriveKey(passphrase string, salt []byte, kdf KDF) ([]byte, error) {
+	params, err := defaultParams(kdf)
+	if err != nil {
+		return nil, err
+	}
+	return params.derive(passphrase, salt)
+}
+
+// derive computes the key after validating the parameters
+func (k kdfParams) derive(passphrase string, salt []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+	switch k.id {
+	case kdfScrypt:
+		if k.p1 < 10 || k.p1 > maxScryptLogN || k.p2 == 0 || k.p2 > maxScryptR || k.p3 == 0 || k.p3 > maxScryptP ||
+			128*uint64(k.p2)<<k.p1 > maxScryptMemory {
+			return nil, fmt.Errorf("%w: invalid scrypt parameters", ErrFormat)
+		}
+		key, err := scrypt.Key([]byte(passphrase), salt, 1<<k.p1, int(k.p2), int(k.p3), KeySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		return key, nil
+	case kdfArgon2id:
+		if k.p1 == 0 || k.p1 > maxArgon2Time || k.p2 < 8 || k.p2 > maxArgon2Memory || k.p3 == 0 {
+			return nil, fmt.Errorf("%w: invalid argon2id parameters", ErrFormat)
+		}
+		return argon2.IDKey([]byte(passphrase), salt, k.p1, k.p2, k.p3, KeySize), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown KDF %d", ErrFormat, k.id)
+	}
+}
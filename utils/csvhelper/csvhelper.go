@@ -0,0 +1,496 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package csvhelper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrTooManyBadRows is returned by Next once more rows than Options.MaxBadRows
+// have been quarantined
+var ErrTooManyBadRows = errors.New("too many bad rows")
+
+// Options configures a Reader
+type Options struct {
+	// Comma is the field delimiter; 0 detects it from the first lines
+	Comma rune
+	// Quote is the quote character; 0 detects a double or single quote
+	Quote rune
+	// Header names the columns when the input has no header row; by default
+	// the first row is the header
+	Header []string
+	// TrimSpace removes leading and trailing whitespace from every field
+	TrimSpace bool
+	// Comment skips lines starting with this character (0 for none)
+	Comment rune
+	// Rules are checked against every row; failing rows are quarantined
+	Rules []Rule
+	// QuarantinePath receives bad rows as CSV with _line and _error columns;
+	// when empty bad rows are only counted
+	QuarantinePath string
+	// MaxBadRows stops reading once more rows than this are quarantined (0 for no limit)
+	MaxBadRows int
+}
+
+// Stats counts the rows seen by a Reader
+type Stats struct {
+	Rows        int
+	Valid       int
+	Quarantined int
+}
+
+// RowError describes why a row was rejected
+type RowError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: column %s: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Row is a record with its source line and column lookup
+type Row struct {
+	// Line is the line number where the record starts
+	Line   int
+	Fields []string
+	index  map[string]int
+}
+
+// Get returns the value of the named column, or "" if it does not exist
+func (r Row) Get(column string) string {
+	i, ok := r.index[column]
+	if !ok || i >= len(r.Fields) {
+		return ""
+	}
+	return r.Fields[i]
+}
+
+// Map returns the row as a column name to value map
+func (r Row) Map() map[string]string {
+	m := make(map[string]string, len(r.index))
+	for name, i := range r.index {
+		if i < len(r.Fields) {
+			m[name] = r.Fields[i]
+		}
+	}
+	return m
+}
+
+// Reader streams validated rows from CSV input
+type Reader struct {
+	parser *parser
+	opts   Options
+	header []string
+	index  map[string]int
+	stats  Stats
+
+	file       *os.File
+	quarantine *os.File
+	qwriter    *csv.Writer
+}
+
+// sampleSize is how much input is inspected for delimiter detection
+const sampleSize = 64 << 10
+
+// NewReader reads the header (unless opts.Header is set) and detects the
+// delimiter and quote character when not configured. A nil opts uses the defaults.
+func NewReader(r io.Reader, opts *Options) (*Reader, error) {
+	reader := &Reader{}
+	if opts != nil {
+		reader.opts = *opts
+	}
+
+	buffered := bufio.NewReaderSize(r, sampleSize)
+	// Skip a UTF-8 byte order mark written by spreadsheet exports
+	if bom, _ := buffered.Peek(3); bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+		buffered.Discard(3)
+	}
+
+	if reader.opts.Comma == 0 || reader.opts.Quote == 0 {
+		sample, err := buffered.Peek(sampleSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, fmt.Errorf("failed to read sample: %w", err)
+		}
+		comma, quote := Detect(sample)
+		if reader.opts.Comma == 0 {
+			reader.opts.Comma = comma
+		}
+		if reader.opts.Quote == 0 {
+			reader.opts.Quote = quote
+		}
+	}
+	if reader.opts.Comma == reader.opts.Quote {
+		return nil, fmt.Errorf("delimiter and quote must differ, both are %q", reader.opts.Comma)
+	}
+	reader.parser = &parser{r: buffered, comma: reader.opts.Comma, quote: reader.opts.Quote}
+
+	reader.header = reader.opts.Header
+	if reader.header == nil {
+		fields, _, err := reader.readRecord()
+		if err == io.EOF {
+			return nil, fmt.Errorf("input has no header row")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		reader.header = make([]string, len(fields))
+		for i, name := range fields {
+			reader.header[i] = strings.TrimSpace(name)
+		}
+	}
+
+	reader.index = make(map[string]int, len(reader.header))
+	for i, name := range reader.header {
+		if _, exists := reader.index[name]; exists {
+			return nil, fmt.Errorf("duplicate column %q in header", name)
+		}
+		reader.index[name] = i
+	}
+	for _, rule := range reader.opts.Rules {
+		if _, ok := reader.index[rule.Column]; !ok {
+			return nil, fmt.Errorf("rule references unknown column %q", rule.Column)
+		}
+	}
+	return reader, nil
+}
+
+// Open opens a CSV file for reading. Close releases the file.
+func Open(path string, opts *Options) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	reader, err := NewReader(file, opts)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	reader.file = file
+	return reader, nil
+}
+
+// Header returns the column names
+func (r *Reader) Header() []string {
+	return r.header
+}
+
+// Comma returns the delimiter in use
+func (r *Reader) Comma() rune {
+	return r.opts.Comma
+}
+
+// Quote returns the quote character in use
+func (r *Reader) Quote() rune {
+	return r.opts.Quote
+}
+
+// Stats returns the row counts so far
+func (r *Reader) Stats() Stats {
+	stats := r.stats
+	stats.Valid = stats.Rows - stats.Quarantined
+	return stats
+}
+
+// Next returns the next row that passes validation, quarantining the rest.
+// It returns io.EOF at the end of the input.
+func (r *Reader) Next() (Row, error) {
+	for {
+		fields, line, err := r.readRecord()
+		if err == io.EOF {
+			return Row{}, io.EOF
+		}
+		var parseErr *ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return Row{}, fmt.Errorf("failed to read line %d: %w", line, err)
+		}
+
+		r.stats.Rows++
+		row := Row{Line: line, Fields: fields, index: r.index}
+		if err == nil {
+			err = r.validate(row)
+		}
+		if err != nil {
+			if qerr := r.Quarantine(row, err); qerr != nil {
+				return Row{}, qerr
+			}
+			continue
+		}
+		return row, nil
+	}
+}
+
+// Quarantine records a row as bad, e.g. when it fails a check done by the
+// caller. It returns ErrTooManyBadRows once MaxBadRows is exceeded.
+func (r *Reader) Quarantine(row Row, reason error) error {
+	r.stats.Quarantined++
+
+	if r.opts.QuarantinePath != "" {
+		if r.qwriter == nil {
+			if err := r.openQuarantine(); err != nil {
+				return err
+			}
+		}
+		message := strings.ReplaceAll(reason.Error(), "\n", "; ")
+		record := append(append([]string(nil), row.Fields...), strconv.Itoa(row.Line), message)
+		if err := r.qwriter.Write(record); err != nil {
+			return fmt.Errorf("failed to write quarantine file: %w", err)
+		}
+	}
+
+	if r.opts.MaxBadRows > 0 && r.stats.Quarantined > r.opts.MaxBadRows {
+		return fmt.Errorf("%w: %d rows quarantined, limit is %d", ErrTooManyBadRows, r.stats.Quarantined, r.opts.MaxBadRows)
+	}
+	return nil
+}
+
+// Close flushes the quarantine file and closes any files opened by the Reader
+func (r *Reader) Close() error {
+	var errs []error
+	if r.qwriter != nil {
+		r.qwriter.Flush()
+		if err := r.qwriter.Error(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write quarantine file: %w", err))
+		}
+		if err := r.quarantine.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		r.qwriter = nil
+	}
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		r.file = nil
+	}
+	return errors.Join(errs...)
+}
+
+// openQuarantine creates the quarantine file and writes its header
+func (r *Reader) openQuarantine() error {
+	if err := os.MkdirAll(filepath.Dir(r.opts.QuarantinePath), 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	file, err := os.Create(r.opts.QuarantinePath)
+	if err != nil {
+		return fmt.Errorf("failed to create quarantine file: %w", err)
+	}
+	r.quarantine = file
+	r.qwriter = csv.NewWriter(file)
+	r.qwriter.Comma = r.opts.Comma
+	header := append(append([]string(nil), r.header...), "_line", "_error")
+	if err := r.qwriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write quarantine file: %w", err)
+	}
+	return nil
+}
+
+// readRecord reads the next non-blank, non-comment record
+func (r *Reader) readRecord() ([]string, int, error) {
+	for {
+		fields, line, err := r.parser.readRecord()
+		if err != nil {
+			return fields, line, err
+		}
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		if r.opts.Comment != 0 && strings.HasPrefix(fields[0], string(r.opts.Comment)) {
+			continue
+		}
+		if r.opts.TrimSpace {
+			for i := range fields {
+				fields[i] = strings.TrimSpace(fields[i])
+			}
+		}
+		return fields, line, nil
+	}
+}
+
+// validate checks the field count and rules, reporting every failure
+func (r *Reader) validate(row Row) error {
+	if len(row.Fields) != len(r.header) {
+		return &RowError{Line: row.Line, Err: fmt.Errorf("expected %d fields, got %d", len(r.header), len(row.Fields))}
+	}
+	var errs []error
+	for _, rule := range r.opts.Rules {
+		if err := rule.Check(row.Get(rule.Column)); err != nil {
+			errs = append(errs, &RowError{Line: row.Line, Column: rule.Column, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Detect guesses the delimiter and quote character from a sample of CSV text.
+// The delimiter is the candidate (',', ';', '\t', '|') that splits the sample
+// lines into the most consistent number of fields; it defaults to ','.
+func Detect(sample []byte) (comma, quote rune) {
+	// Drop a trailing partial line from a truncated sample
+	if len(sample) >= sampleSize {
+		if i := bytes.LastIndexByte(sample, '\n'); i > 0 {
+			sample = sample[:i]
+		}
+	}
+	quote = detectQuote(sample)
+
+	comma = ','
+	bestScore := 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		p := &parser{r: bufio.NewReader(bytes.NewReader(sample)), comma: candidate, quote: quote}
+		counts := map[int]int{}
+		lines := 0
+		for lines < 20 {
+			fields, _, err := p.readRecord()
+			if err != nil {
+				break
+			}
+			if len(fields) == 1 && fields[0] == "" {
+				continue
+			}
+			counts[len(fields)]++
+			lines++
+		}
+
+		// Score by the most common field count, weighted by how many lines agree
+		for fieldCount, agreeing := range counts {
+			if fieldCount < 2 {
+				continue
+			}
+			if score := agreeing*100 + fieldCount; score > bestScore {
+				bestScore, comma = score, candidate
+			}
+		}
+	}
+	return comma, quote
+}
+
+// detectQuote picks the single quote when it opens fields and the double quote never does
+func detectQuote(sample []byte) rune {
+	double, single := 0, 0
+	atStart := true
+	for _, b := range sample {
+		if atStart {
+			switch b {
+			case '"':
+				double++
+			case '\'':
+				single++
+			}
+		}
+		atStart = b == ',' || b == ';' || b == '\t' || b == '|' || b == '\n'
+	}
+	if single > 0 && double == 0 {
+		return '\''
+	}
+	return '"'
+}
+
+// ParseError reports malformed CSV, such as an unterminated quoted field
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parser reads records with a configurable delimiter and quote character.
+// Quoted fields may span lines and escape the quote by doubling it.
+type parser struct {
+	r     *bufio.Reader
+	comma rune
+	quote rune
+	line  int
+}
+
+// readRecord returns the fields of the next record and the line it starts on
+func (p *parser) readRecord() ([]string, int, error) {
+	startLine := p.line + 1
+	var fields []string
+	var field strings.Builder
+	inQuotes, atStart, empty := false, true, true
+
+	for {
+		ch, _, err := p.r.ReadRune()
+		if err == io.EOF {
+			if inQuotes {
+				p.line++
+				fields = append(fields, field.String())
+				return fields, startLine, &ParseError{Line: startLine, Err: errors.New("unterminated quoted field")}
+			}
+			if empty {
+				return nil, startLine, io.EOF
+			}
+			p.line++
+			return append(fields, field.String()), startLine, nil
+		}
+		if err != nil {
+			return nil, startLine, err
+		}
+		empty = false
+
+		if inQuotes {
+			if ch == p.quote {
+				if next, _, err := p.r.ReadRune(); err == nil {
+					if next == p.quote {
+						field.WriteRune(p.quote)
+						continue
+					}
+					p.r.UnreadRune()
+				}
+				inQuotes = false
+				continue
+			}
+			if ch == '\n' {
+				p.line++
+			}
+			field.WriteRune(ch)
+			continue
+		}
+
+		switch ch {
+		case p.comma:
+			fields = append(fields, field.String())
+			field.Reset()
+			atStart = true
+		case '\r':
+			if next, _, err := p.r.ReadRune(); err == nil && next != '\n' {
+				p.r.UnreadRune()
+			}
+			p.line++
+			return append(fields, field.String()), startLine, nil
+		case '\n':
+			p.line++
+			return append(fields, field.String()), startLine, nil
+		default:
+			if ch == p.quote && atStart {
+				inQuotes = true
+			} else {
+				// Quotes inside unquoted fields are kept literally
+				field.WriteRune(ch)
+			}
+			atStart = false
+		}
+	}
+}
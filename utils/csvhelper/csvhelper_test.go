@@ -0,0 +1,251 @@
+package csvhelper
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		comma  rune
+		quote  rune
+	}{
+		{"comma", "id,name,amount\n1,alpha,10\n2,beta,20\n", ',', '"'},
+		{"semicolon with commas in values", "id;name;amount\n1;\"Doe, Jane\";10,5\n2;Smith;20,0\n", ';', '"'},
+		{"tab", "id\tname\n1\talpha\n", '\t', '"'},
+		{"pipe", "id|name|note\n1|alpha|x\n", '|', '"'},
+		{"single quotes", "id,name\n1,'Doe, Jane'\n2,'Smith'\n", ',', '\''},
+		{"single column falls back to comma", "name\nalpha\n", ',', '"'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comma, quote := Detect([]byte(tt.sample))
+			if comma != tt.comma || quote != tt.quote {
+				t.Errorf("Detect = %q %q, expected %q %q", comma, quote, tt.comma, tt.quote)
+			}
+		})
+	}
+}
+
+func TestReaderParsing(t *testing.T) {
+	input := "\xef\xbb\xbfid;note\r\n" +
+		"1;\"multi\nline\"\r\n" +
+		"\n" +
+		"# comment\n" +
+		"2;\"say \"\"hi\"\"\"\n" +
+		"3;plain \"quote\"\n" +
+		"4;last"
+
+	r, err := NewReader(strings.NewReader(input), &Options{Comment: '#'})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if r.Comma() != ';' || strings.Join(r.Header(), ",") != "id,note" {
+		t.Fatalf("comma %q, header %v", r.Comma(), r.Header())
+	}
+
+	want := []struct {
+		line int
+		note string
+	}{
+		{2, "multi\nline"},
+		{6, `say "hi"`},
+		{7, `plain "quote"`},
+		{8, "last"},
+	}
+	for _, w := range want {
+		row, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		if row.Line != w.line || row.Get("note") != w.note {
+			t.Errorf("row = line %d %q, expected line %d %q", row.Line, row.Get("note"), w.line, w.note)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestValidationAndQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	quarantinePath := filepath.Join(dir, "bad", "orders_bad.csv")
+	input := "id,status,email,amount\n" +
+		"1,open,a@example.com,10.5\n" +
+		"2,unknown,a@example.com,10\n" +
+		",open,not-an-email,abc\n" +
+		"4,closed\n" +
+		"5,closed,b@example.com,\n"
+
+	r, err := NewReader(strings.NewReader(input), &Options{
+		Rules: []Rule{
+			Required("id"),
+			Integer("id"),
+			OneOf("status", "open", "closed"),
+			Matches("email", regexp.MustCompile(`^[^@]+@[^@]+$`)),
+			Number("amount"),
+			MaxLength("email", 32),
+		},
+		QuarantinePath: quarantinePath,
+	})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var ids []string
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		ids = append(ids, row.Get("id"))
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if strings.Join(ids, ",") != "1,5" {
+		t.Errorf("valid ids = %v, expected 1,5", ids)
+	}
+	if stats := r.Stats(); stats != (Stats{Rows: 5, Valid: 2, Quarantined: 3}) {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	content, err := os.ReadFile(quarantinePath)
+	if err != nil {
+		t.Fatalf("failed to read quarantine file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 4 || lines[0] != "id,status,email,amount,_line,_error" {
+		t.Fatalf("unexpected quarantine file:\n%s", content)
+	}
+	if !strings.Contains(lines[1], "column status") || !strings.HasPrefix(lines[1], "2,unknown") {
+		t.Errorf("unexpected quarantine row: %s", lines[1])
+	}
+	// Every failing rule is reported
+	for _, column := range []string{"column id", "column email", "column amount"} {
+		if !strings.Contains(lines[2], column) {
+			t.Errorf("quarantine row missing %s: %s", column, lines[2])
+		}
+	}
+	if !strings.Contains(lines[3], "expected 4 fields, got 2") {
+		t.Errorf("unexpected quarantine row: %s", lines[3])
+	}
+}
+
+func TestMaxBadRows(t *testing.T) {
+	input := "id\nx\ny\n1\nz\n"
+	r, _ := NewReader(strings.NewReader(input), &Options{Rules: []Rule{Integer("id")}, MaxBadRows: 1})
+	if _, err := r.Next(); !errors.Is(err, ErrTooManyBadRows) {
+		t.Errorf("expected ErrTooManyBadRows, got %v", err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	if _, err := NewReader(strings.NewReader(""), nil); err == nil {
+		t.Error("expected error for empty input")
+	}
+	if _, err := NewReader(strings.NewReader("a,a\n"), nil); err == nil {
+		t.Error("expected error for duplicate column")
+	}
+	if _, err := NewReader(strings.NewReader("a,b\n"), &Options{Rules: []Rule{Required("c")}}); err == nil {
+		t.Error("expected error for rule on unknown column")
+	}
+
+	// An unterminated quote quarantines the rest of the input
+	r, _ := NewReader(strings.NewReader("a,b\n1,\"open\n2,3\n"), nil)
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if r.Stats().Quarantined != 1 {
+		t.Errorf("expected 1 quarantined row, got %d", r.Stats().Quarantined)
+	}
+}
+
+type order struct {
+	ID       int       `csv:"order_id"`
+	Customer string    // matched case-insensitively
+	Amount   float64   `csv:"amount"`
+	Paid     bool      `csv:"paid"`
+	Placed   time.Time `csv:"placed"`
+	Discount *float64  `csv:"discount"`
+	Internal string    `csv:"-"`
+}
+
+func TestDecodeEach(t *testing.T) {
+	input := "order_id,customer,amount,paid,placed,discount,internal\n" +
+		"1,alpha,10.50,true,2024-03-01,,x\n" +
+		"2,beta,abc,false,2024-03-02,,x\n" +
+		"3,gamma,7,1,2024-03-03T10:00:00Z,0.1,x\n"
+
+	r, err := NewReader(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var orders []order
+	err = DecodeEach(r, func(o order, row Row) error {
+		orders = append(orders, o)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeEach failed: %v", err)
+	}
+
+	if len(orders) != 2 || r.Stats().Quarantined != 1 {
+		t.Fatalf("decoded %d orders, quarantined %d", len(orders), r.Stats().Quarantined)
+	}
+	first, last := orders[0], orders[1]
+	if first.ID != 1 || first.Customer != "alpha" || first.Amount != 10.5 || !first.Paid || first.Discount != nil || first.Internal != "" {
+		t.Errorf("unexpected first order: %+v", first)
+	}
+	if !first.Placed.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected placed date: %s", first.Placed)
+	}
+	if last.Discount == nil || *last.Discount != 0.1 || last.Placed.Hour() != 10 {
+		t.Errorf("unexpected last order: %+v", last)
+	}
+
+	// Errors from fn stop reading
+	r, _ = NewReader(strings.NewReader(input), nil)
+	stop := errors.New("stop")
+	if err := DecodeEach(r, func(o order, row Row) error { return stop }); err != stop {
+		t.Errorf("expected fn error, got %v", err)
+	}
+
+	var row Row
+	if err := row.Decode(order{}); err == nil {
+		t.Error("expected error decoding into non-pointer")
+	}
+}
+
+func TestOpenWithHeaderOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.tsv")
+	os.WriteFile(path, []byte("1\talpha\n2\tbeta\n"), 0644)
+
+	r, err := Open(path, &Options{Header: []string{"id", "name"}})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer r.Close()
+
+	row, err := r.Next()
+	if err != nil || row.Get("name") != "alpha" || row.Line != 1 {
+		t.Errorf("first row = %+v, %v", row, err)
+	}
+	if m := row.Map(); m["id"] != "1" {
+		t.Errorf("Map = %v", m)
+	}
+}
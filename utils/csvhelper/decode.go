@@ -0,0 +1,180 @@
+package csvhelper
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeLayouts are tried in order when decoding into time.Time
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// structField maps a column name to a struct field index
+type structField struct {
+	column string
+	index  []int
+}
+
+// fieldCache holds the column mapping per struct type
+var fieldCache sync.Map
+
+// Decode stores the row into the struct pointed to by dst. Columns map to
+// fields by the `csv:"name"` tag, falling back to a case-insensitive match on
+// the field name; `csv:"-"` skips a field. Empty values leave fields at their
+// zero value and pointer fields nil.
+func (r Row) Decode(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a non-nil pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+
+	for _, f := range structFields(v.Type()) {
+		i, ok := r.index[f.column]
+		if !ok {
+			i, ok = r.lookupFold(f.column)
+		}
+		if !ok || i >= len(r.Fields) {
+			continue
+		}
+		if err := setValue(v.FieldByIndex(f.index), r.Fields[i]); err != nil {
+			return &RowError{Line: r.Line, Column: f.column, Err: err}
+		}
+	}
+	return nil
+}
+
+// lookupFold finds a column by case-insensitive name
+func (r Row) lookupFold(column string) (int, bool) {
+	for name, i := range r.index {
+		if strings.EqualFold(name, column) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// DecodeEach decodes every valid row into a T and calls fn with it. Rows that
+// fail to decode are quarantined. Reading stops at the first error from fn.
+func DecodeEach[T any](r *Reader, fn func(v T, row Row) error) error {
+	for {
+		row, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var v T
+		if err := row.Decode(&v); err != nil {
+			if qerr := r.Quarantine(row, err); qerr != nil {
+				return qerr
+			}
+			continue
+		}
+		if err := fn(v, row); err != nil {
+			return err
+		}
+	}
+}
+
+// structFields returns the cached column mapping for a struct type
+func structFields(t reflect.Type) []structField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var fields []structField
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		column := sf.Name
+		if tag, ok := sf.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			if name, _, _ := strings.Cut(tag, ","); name != "" {
+				column = name
+			}
+		}
+		fields = append(fields, structField{column: column, index: sf.Index})
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// setValue parses s into v according to its type
+func setValue(v reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	switch v.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case time.Time:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid time %q", s)
+	}
+	// Other types that parse themselves, e.g. net.IP or big.Int
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package csvhelper
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule validates the value of one column. Apart from Required, the provided
+// rules accept empty values so they can be combined with Required as needed.
+type Rule struct {
+	Column string
+	Check  func(value string) error
+}
+
+// Required rejects empty or whitespace-only values
+func Required(column string) Rule {
+	return Rule{Column: column, Check: func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("value is required")
+		}
+		return nil
+	}}
+}
+
+// MaxLength rejects values longer than n characters
+func MaxLength(column string, n int) Rule {
+	return Rule{Column: column, Check: func(value string) error {
+		if length := utf8.RuneCountInString(value); length > n {
+			return fmt.Errorf("value is %d characters, maximum is %d", length, n)
+		}
+		return nil
+	}}
+}
+
+// Matches rejects values that do not match pattern
+func Matches(column string, pattern *regexp.Regexp) Rule {
+	return Rule{Column: column, Check: func(value string) error {
+		if value != "" && !pattern.MatchString(value) {
+			return fmt.Errorf("value %q does not match %s", value, pattern)
+		}
+		return nil
+	}}
+}
+
+// OneOf rejects values not in the allowed list
+func OneOf(column string, allowed ...string) Rule {
+	return Rule{Column: column, Check: func(value string) error {
+		if value != "" && !slices.Contains(allowed, value) {
+			return fmt.Errorf("value %q is not one of %s", value, strings.Join(allowed, ", "))
+		}
+		return nil
+	}}
+}
+
+// Integer rejects values that are not base 10 integers
+func Integer(column string) Rule {
+	return Rule{Column: column, Check: func(value string) error {
+		if value == "" {
+			return nil
+		}
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("value %q is not an integer", value)
+		}
+		return nil
+	}}
+}
+
+// Number rejects values that are not decimal numbers
+func Number(column string) Rule {
+	return Rule{Column: column, Check: func(value string) error {
+		if value == "" {
+			return nil
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("value %q is not a number", value)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,218 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/romisugianto/go-utils/utils/config"
+	"github.com/romisugianto/go-utils/utils/health"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/metrics"
+	"github.com/romisugianto/go-utils/utils/scheduler"
+	"github.com/romisugianto/go-utils/utils/shutdown"
+)
+
+// App describes a long-running utility. Only Name is required.
+type App struct {
+	// Name is used for the log file and, cleaned up, as the metrics namespace
+	Name string
+	// Banner is displayed with the build version at startup when set
+	Banner string
+
+	// Config is a pointer to the configuration struct, loaded with
+	// config.LoadWithOptions before Setup runs; nil skips loading
+	Config any
+	// ConfigPath is the YAML, JSON or TOML file to load; empty uses the
+	// struct defaults and environment variables only
+	ConfigPath    string
+	ConfigOptions config.Options
+
+	// HealthAddr serves /healthz and /readyz, e.g. ":8081"; empty disables it
+	HealthAddr string
+	// MetricsAddr serves /metrics, e.g. ":9100"; empty disables it
+	MetricsAddr string
+
+	Health   health.Options
+	Shutdown shutdown.Options
+
+	// Setup registers jobs, readiness checks and shutdown hooks. Returning an
+	// error aborts startup.
+	Setup func(ctx context.Context, rt *Runtime) error
+	// Main optionally runs alongside the scheduler, e.g. a watcher or a queue
+	// consumer. The daemon shuts down when it returns.
+	Main func(ctx context.Context, rt *Runtime) error
+}
+
+// Runtime holds the components wired up by Run
+type Runtime struct {
+	Logger    *logger.Logger
+	Metrics   *metrics.Metrics
+	Scheduler *scheduler.Scheduler
+	Health    *health.Server
+	Shutdown  *shutdown.Manager
+}
+
+// Run loads the configuration, sets up logging, metrics, the scheduler and
+// the health endpoints, calls Setup and then runs until a termination signal
+// arrives or Main returns. It returns an error when startup fails, Main or
+// a server fails, or a shutdown hook fails or times out.
+func Run(app App) error {
+	if app.Name == "" {
+		return fmt.Errorf("app name cannot be empty")
+	}
+	if app.HealthAddr != "" && app.HealthAddr == app.MetricsAddr {
+		return fmt.Errorf("health and metrics addresses must differ, both are %s", app.HealthAddr)
+	}
+
+	log, err := logger.NewLogger(app.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer log.Close()
+	if app.Banner != "" {
+		log.DisplayBuildCredits(app.Banner, app.Name)
+	}
+
+	rt, err := newRuntime(app, log)
+	if err != nil {
+		log.Error("Startup failed: %v", err)
+		return err
+	}
+	ctx := rt.Shutdown.Context()
+
+	if app.Setup != nil {
+		if err := app.Setup(ctx, rt); err != nil {
+			log.Error("Setup failed: %v", err)
+			rt.Shutdown.Shutdown()
+			return fmt.Errorf("failed to set up %s: %w", app.Name, err)
+		}
+	}
+
+	// Failures of the servers and Main end the daemon and are reported by Run
+	var (
+		errMu sync.Mutex
+		errs  []error
+	)
+	fail := func(err error) {
+		errMu.Lock()
+		errs = append(errs, err)
+		errMu.Unlock()
+		go rt.Shutdown.Shutdown()
+	}
+
+	// Hooks run in reverse order: Main returns, the scheduler waits for
+	// running jobs, the servers stop, then the hooks registered by Setup run
+	if app.HealthAddr != "" {
+		rt.Shutdown.Register("health server", background(func() error {
+			return rt.Health.ListenAndServe(ctx, app.HealthAddr)
+		}, fail))
+	}
+	if app.MetricsAddr != "" {
+		rt.Shutdown.Register("metrics server", background(func() error {
+			log.Info("Metrics server listening on %s", app.MetricsAddr)
+			return rt.Metrics.Serve(ctx, app.MetricsAddr)
+		}, fail))
+	}
+
+	if err := rt.Scheduler.Start(ctx); err != nil {
+		log.Error("Failed to start scheduler: %v", err)
+		rt.Shutdown.Shutdown()
+		return err
+	}
+	rt.Shutdown.Register("scheduler", func(ctx context.Context) error {
+		rt.Scheduler.Stop()
+		return nil
+	})
+
+	if app.Main != nil {
+		rt.Shutdown.Register("main", background(func() error {
+			if err := app.Main(ctx, rt); err != nil && !errors.Is(err, context.Canceled) {
+				return fmt.Errorf("main failed: %w", err)
+			}
+			log.Info("%s finished", app.Name)
+			go rt.Shutdown.Shutdown()
+			return nil
+		}, fail))
+	}
+
+	log.Info("%s started", app.Name)
+	report := rt.Shutdown.Wait()
+
+	errMu.Lock()
+	defer errMu.Unlock()
+	if !report.Clean() {
+		errs = append(errs, fmt.Errorf("shutdown did not complete cleanly: %d hooks failed, %d skipped", len(report.Failed), len(report.Skipped)))
+	}
+	return errors.Join(errs...)
+}
+
+// newRuntime loads the configuration and creates the components of app
+func newRuntime(app App, log *logger.Logger) (*Runtime, error) {
+	if app.Config != nil {
+		if err := config.LoadWithOptions(app.ConfigPath, app.Config, app.ConfigOptions); err != nil {
+			return nil, fmt.Errorf("failed to load configuration: %w", err)
+		}
+	}
+
+	m, err := metrics.New(namespace(app.Name))
+	if err != nil {
+		return nil, err
+	}
+	m.InstrumentLogger(log)
+
+	sched, err := scheduler.NewScheduler(log)
+	if err != nil {
+		return nil, err
+	}
+	server, err := health.NewServer(log, &app.Health)
+	if err != nil {
+		return nil, err
+	}
+	manager, err := shutdown.New(log, &app.Shutdown)
+	if err != nil {
+		return nil, err
+	}
+	return &Runtime{Logger: log, Metrics: m, Scheduler: sched, Health: server, Shutdown: manager}, nil
+}
+
+// background starts fn and returns a shutdown hook that waits for it to
+// return. An error from fn is passed to fail, which triggers shutdown.
+func background(fn func() error, fail func(error)) shutdown.Hook {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := fn(); err != nil {
+			fail(err)
+		}
+	}()
+	return func(ctx context.Context) error {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// namespace turns an app name such as "order-sync" into a valid metrics
+// namespace such as "order_sync"
+func namespace(name string) string {
+	ns := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+	if ns[0] >= '0' && ns[0] <= '9' {
+		ns = "_" + ns
+	}
+	return ns
+}
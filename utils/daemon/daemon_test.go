@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Inbound  string        `yaml:"inbound" required:"true"`
+	Interval time.Duration `yaml:"interval" default:"10ms"`
+}
+
+func TestRunValidation(t *testing.T) {
+	if err := Run(App{}); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if err := Run(App{Name: "daemon_test", HealthAddr: ":8081", MetricsAddr: ":8081"}); err == nil {
+		t.Error("expected error for shared health and metrics address")
+	}
+	if err := Run(App{Name: "daemon_test", Config: &testConfig{}}); err == nil || !strings.Contains(err.Error(), "Inbound") {
+		t.Errorf("expected configuration error, got %v", err)
+	}
+}
+
+func TestRunMain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.yaml")
+	if err := os.WriteFile(path, []byte("inbound: ./inbound\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg testConfig
+	var runs atomic.Int32
+	var cleaned atomic.Bool
+	err := Run(App{
+		Name:       "daemon_test",
+		Config:     &cfg,
+		ConfigPath: path,
+		HealthAddr: "127.0.0.1:0",
+		Setup: func(ctx context.Context, rt *Runtime) error {
+			rt.Shutdown.Register("cleanup", func(ctx context.Context) error {
+				cleaned.Store(true)
+				return nil
+			})
+			return rt.Scheduler.AddInterval("poll", cfg.Interval, func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			}, nil)
+		},
+		Main: func(ctx context.Context, rt *Runtime) error {
+			deadline := time.After(5 * time.Second)
+			for runs.Load() < 2 {
+				select {
+				case <-deadline:
+					return errors.New("job did not run")
+				case <-time.After(5 * time.Millisecond):
+				}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if cfg.Inbound != "./inbound" || !cleaned.Load() {
+		t.Errorf("config %+v, cleanup ran %t", cfg, cleaned.Load())
+	}
+}
+
+func TestRunMainError(t *testing.T) {
+	err := Run(App{
+		Name: "daemon_test",
+		Main: func(ctx context.Context, rt *Runtime) error { return errors.New("queue unavailable") },
+	})
+	if err == nil || !strings.Contains(err.Error(), "queue unavailable") {
+		t.Errorf("expected main error, got %v", err)
+	}
+}
+
+func TestRunUntilShutdown(t *testing.T) {
+	var stopped atomic.Bool
+	err := Run(App{
+		Name: "daemon_test",
+		Setup: func(ctx context.Context, rt *Runtime) error {
+			return rt.Scheduler.AddInterval("stop", 10*time.Millisecond, func(ctx context.Context) error {
+				go rt.Shutdown.Shutdown()
+				<-ctx.Done()
+				stopped.Store(true)
+				return nil
+			}, nil)
+		},
+	})
+	if err != nil || !stopped.Load() {
+		t.Errorf("Run = %v, job stopped %t", err, stopped.Load())
+	}
+}
+
+func TestSetupError(t *testing.T) {
+	var cleaned atomic.Bool
+	err := Run(App{
+		Name: "daemon_test",
+		Setup: func(ctx context.Context, rt *Runtime) error {
+			rt.Shutdown.Register("cleanup", func(ctx context.Context) error {
+				cleaned.Store(true)
+				return nil
+			})
+			return errors.New("database unreachable")
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "database unreachable") || !cleaned.Load() {
+		t.Errorf("Run = %v, cleanup ran %t", err, cleaned.Load())
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	tests := map[string]string{"order-sync": "order_sync", "S3 Mirror": "s3_mirror", "2fa": "_2fa"}
+	for input, expected := range tests {
+		if got := namespace(input); got != expected {
+			t.Errorf("namespace(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
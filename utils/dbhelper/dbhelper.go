@@ -0,0 +1,300 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package dbhelper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Supported drivers
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+)
+
+// Config holds the connection and pool settings
+type Config struct {
+	// Driver is "mysql" or "postgres" ("postgresql" and "pg" are accepted)
+	Driver string
+	// DSN is used as-is when set; otherwise it is built from the fields below
+	DSN      string
+	Host     string
+	Port     int // defaults to 3306 for MySQL and 5432 for Postgres
+	User     string
+	Password string
+	Database string
+	// Params are extra DSN parameters, e.g. {"sslmode": "disable"} or {"tls": "true"}
+	Params map[string]string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// QueryTimeout bounds each helper call whose context has no deadline (0 for none)
+	QueryTimeout time.Duration
+	// SlowQueryThreshold logs queries taking longer than this as warnings (0 to disable)
+	SlowQueryThreshold time.Duration
+}
+
+// DBHelper wraps a *sql.DB with query logging, timing and migrations
+type DBHelper struct {
+	SQL    *sql.DB
+	driver string
+	config Config
+	logger *logger.Logger
+}
+
+// NewDBHelper opens a connection pool from cfg and verifies it with a ping
+func NewDBHelper(log *logger.Logger, cfg Config) (*DBHelper, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+
+	driver, err := normalizeDriver(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Driver = driver
+
+	dsn := cfg.DSN
+	if dsn == "" {
+		dsn = buildDSN(cfg)
+	}
+	return open(log, cfg, driver, dsn)
+}
+
+// open creates the helper with an explicit database/sql driver name
+func open(log *logger.Logger, cfg Config, driverName, dsn string) (*DBHelper, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", cfg.Driver, err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	h := &DBHelper{SQL: db, driver: cfg.Driver, config: cfg, logger: log}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.HealthCheck(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Info("Connected to %s database %s", cfg.Driver, cfg.Database)
+	return h, nil
+}
+
+// Close closes the connection pool
+func (h *DBHelper) Close() error {
+	return h.SQL.Close()
+}
+
+// Driver returns the normalized driver name
+func (h *DBHelper) Driver() string {
+	return h.driver
+}
+
+// HealthCheck pings the database
+func (h *DBHelper) HealthCheck(ctx context.Context) error {
+	if err := h.SQL.PingContext(ctx); err != nil {
+		return fmt.Errorf("database health check failed: %w", err)
+	}
+	return nil
+}
+
+// Stats returns the connection pool statistics
+func (h *DBHelper) Stats() sql.DBStats {
+	return h.SQL.Stats()
+}
+
+// Exec runs a statement with logging and timing
+func (h *DBHelper) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := h.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	result, err := h.SQL.ExecContext(ctx, query, args...)
+	h.logQuery("Exec", query, start, err)
+	return result, err
+}
+
+// Query runs a query with logging and timing. The caller must close the rows.
+// QueryTimeout is not applied because it would cancel the rows while they are read.
+func (h *DBHelper) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := h.SQL.QueryContext(ctx, query, args...)
+	h.logQuery("Query", query, start, err)
+	return rows, err
+}
+
+// QueryRow runs a query expected to return at most one row and scans it into dest
+func (h *DBHelper) QueryRow(ctx context.Context, query string, args []any, dest ...any) error {
+	ctx, cancel := h.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	err := h.SQL.QueryRowContext(ctx, query, args...).Scan(dest...)
+	logErr := err
+	if err == sql.ErrNoRows {
+		// No rows is an expected outcome, not a failure worth logging
+		logErr = nil
+	}
+	h.logQuery("QueryRow", query, start, logErr)
+	return err
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling back otherwise
+func (h *DBHelper) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := h.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			h.logger.Error("Failed to roll back transaction: %v", rollbackErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rebind converts ? placeholders to the driver's style ($1, $2, ... for Postgres).
+// Question marks inside single-quoted literals are left alone.
+func (h *DBHelper) Rebind(query string) string {
+	if h.driver != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case r == '?' && !inQuote:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// withTimeout applies QueryTimeout when ctx has no deadline
+func (h *DBHelper) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || h.config.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.config.QueryTimeout)
+}
+
+// logQuery records the query duration and outcome
+func (h *DBHelper) logQuery(operation, query string, start time.Time, err error) {
+	elapsed := time.Since(start).Round(time.Microsecond)
+	summary := summarize(query)
+	switch {
+	case err != nil:
+		h.logger.Error("%s failed after %s: %v [%s]", operation, elapsed, err, summary)
+	case h.config.SlowQueryThreshold > 0 && elapsed >= h.config.SlowQueryThreshold:
+		h.logger.Warning("Slow %s took %s [%s]", operation, elapsed, summary)
+	default:
+		h.logger.Debug("%s took %s [%s]", operation, elapsed, summary)
+	}
+}
+
+// summarize collapses whitespace and truncates long queries for logging
+func summarize(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > 200 {
+		return query[:200] + "..."
+	}
+	return query
+}
+
+// normalizeDriver maps accepted driver aliases to MySQL or Postgres
+func normalizeDriver(driver string) (string, error) {
+	switch strings.ToLower(driver) {
+	case "mysql", "mariadb":
+		return MySQL, nil
+	case "postgres", "postgresql", "pg":
+		return Postgres, nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q (use mysql or postgres)", driver)
+	}
+}
+
+// buildDSN builds a driver-specific DSN from the connection fields
+func buildDSN(cfg Config) string {
+	host := cfg.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	switch cfg.Driver {
+	case MySQL:
+		port := cfg.Port
+		if port == 0 {
+			port = 3306
+		}
+		mysqlCfg := mysql.NewConfig()
+		mysqlCfg.User = cfg.User
+		mysqlCfg.Passwd = cfg.Password
+		mysqlCfg.Net = "tcp"
+		mysqlCfg.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+		mysqlCfg.DBName = cfg.Database
+		mysqlCfg.ParseTime = true
+		// Migration files usually contain several statements
+		mysqlCfg.MultiStatements = true
+		if len(cfg.Params) > 0 {
+			mysqlCfg.Params = cfg.Params
+		}
+		return mysqlCfg.FormatDSN()
+	default:
+		port := cfg.Port
+		if port == 0 {
+			port = 5432
+		}
+		u := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.User, cfg.Password),
+			Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+			Path:   "/" + cfg.Database,
+		}
+		query := url.Values{}
+		for key, value := range cfg.Params {
+			query.Set(key, value)
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+}
@@ -0,0 +1,90 @@
+package dbhelper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MigrationsTable records applied migration versions
+const MigrationsTable = "schema_migrations"
+
+// Migrate applies the .sql files in dir that have not been applied yet, in
+// lexical order (e.g. 001_create_users.sql, 002_add_index.sql). Each file runs
+// in its own transaction together with its schema_migrations record; note that
+// MySQL commits DDL statements implicitly. Returns the versions applied.
+func (h *DBHelper) Migrate(ctx context.Context, dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations in %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	if _, err := h.Exec(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version VARCHAR(255) PRIMARY KEY, applied_at TIMESTAMP NOT NULL)",
+		MigrationsTable)); err != nil {
+		return nil, fmt.Errorf("failed to create %s table: %w", MigrationsTable, err)
+	}
+
+	applied, err := h.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, file := range files {
+		version := strings.TrimSuffix(filepath.Base(file), ".sql")
+		if applied[version] {
+			continue
+		}
+
+		script, err := os.ReadFile(file)
+		if err != nil {
+			return versions, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		start := time.Now()
+		err = h.WithTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, h.Rebind(fmt.Sprintf("INSERT INTO %s (version, applied_at) VALUES (?, ?)", MigrationsTable)), version, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return versions, fmt.Errorf("failed to apply migration %s: %w", version, err)
+		}
+
+		h.logger.Info("Applied migration %s in %s", version, time.Since(start).Round(time.Millisecond))
+		versions = append(versions, version)
+	}
+
+	if len(versions) == 0 {
+		h.logger.Info("Database schema is up to date")
+	}
+	return versions, nil
+}
+
+// appliedMigrations returns the set of versions already recorded
+func (h *DBHelper) appliedMigrations(ctx context.Context) (map[string]bool, error) {
+	rows, err := h.Query(ctx, "SELECT version FROM "+MigrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,333 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/dirwalk"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/validate"
+)
+
+// Action is what Run does with the duplicates it finds
+type Action string
+
+const (
+	// Report only lists duplicates
+	Report Action = "report"
+	// HardLink replaces each duplicate with a hard link to the kept file
+	HardLink Action = "hardlink"
+	// Delete removes duplicates, leaving only the kept file
+	Delete Action = "delete"
+)
+
+// Keep selects which file of a duplicate group is kept
+type Keep string
+
+const (
+	// KeepOldest keeps the file with the oldest modification time
+	KeepOldest Keep = "oldest"
+	// KeepNewest keeps the file with the newest modification time
+	KeepNewest Keep = "newest"
+	// KeepFirstDir keeps the file from the earliest directory passed to Run,
+	// so the first directory acts as the primary copy
+	KeepFirstDir Keep = "first-dir"
+)
+
+// Options configures a Deduper
+type Options struct {
+	// Action applied to duplicates (default Report)
+	Action Action
+	// DryRun logs what Action would do without changing any file
+	DryRun bool
+	// Keep selects the file kept from each group (default KeepOldest)
+	Keep Keep
+	// MinSize skips files smaller than this many bytes; empty files are always skipped
+	MinSize int64
+	// Exclude skips files whose base name matches a filepath.Match pattern
+	Exclude []string
+	// Algorithm used to compare files of equal size (default SHA256)
+	Algorithm checksum.Algorithm
+}
+
+// Group is a set of files with identical content
+type Group struct {
+	Size int64
+	Hash string
+	// Keep is the file that is kept
+	Keep string
+	// Duplicates are the other copies, handled according to the action
+	Duplicates []string
+}
+
+// Result summarizes a Run
+type Result struct {
+	Scanned int
+	Groups  []Group
+	// Reclaimed is the bytes freed (or, in a dry run, that would be freed)
+	Reclaimed int64
+	// Failed maps duplicates that could not be handled to the error
+	Failed map[string]error
+}
+
+// Deduper finds and removes duplicate files across directories
+type Deduper struct {
+	logger *logger.Logger
+	opts   Options
+}
+
+// file is a candidate found during the scan
+type file struct {
+	path   string
+	dirIdx int
+	info   fs.FileInfo
+}
+
+// NewDeduper creates a Deduper. A nil opts reports duplicates without changing anything.
+func NewDeduper(log *logger.Logger, opts *Options) (*Deduper, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	d := &Deduper{logger: log}
+	if opts != nil {
+		d.opts = *opts
+	}
+	if d.opts.Action == "" {
+		d.opts.Action = Report
+	}
+	if d.opts.Keep == "" {
+		d.opts.Keep = KeepOldest
+	}
+	if d.opts.Algorithm == "" {
+		d.opts.Algorithm = checksum.SHA256
+	}
+
+	switch d.opts.Action {
+	case Report, HardLink, Delete:
+	default:
+		return nil, fmt.Errorf("unsupported action %q", d.opts.Action)
+	}
+	switch d.opts.Keep {
+	case KeepOldest, KeepNewest, KeepFirstDir:
+	default:
+		return nil, fmt.Errorf("unsupported keep policy %q", d.opts.Keep)
+	}
+	if _, err := checksum.NewHash(d.opts.Algorithm); err != nil {
+		return nil, err
+	}
+	for _, pattern := range d.opts.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+	}
+	return d, nil
+}
+
+// Run scans dirs recursively, groups files by size and then by content hash,
+// and applies the action to every duplicate. Files that are already hard links
+// to each other count as one file.
+func (d *Deduper) Run(dirs ...string) (*Result, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("at least one directory is required")
+	}
+	startTime := time.Now()
+
+	files, err := d.scan(dirs)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Scanned: len(files), Failed: map[string]error{}}
+
+	bySize := map[int64][]file{}
+	for _, f := range files {
+		bySize[f.info.Size()] = append(bySize[f.info.Size()], f)
+	}
+	sizes := make([]int64, 0, len(bySize))
+	for size, candidates := range bySize {
+		if len(candidates) > 1 {
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+
+	for _, size := range sizes {
+		byHash := map[string][]file{}
+		for _, f := range uniqueInodes(bySize[size]) {
+			sum, err := checksum.SumFile(f.path, d.opts.Algorithm)
+			if err != nil {
+				d.logger.Error("Failed to hash %s: %v", f.path, err)
+				continue
+			}
+			byHash[sum] = append(byHash[sum], f)
+		}
+		for sum, same := range byHash {
+			if len(same) < 2 {
+				continue
+			}
+			result.Groups = append(result.Groups, d.handle(size, sum, same, result))
+		}
+	}
+	sort.Slice(result.Groups, func(i, j int) bool { return result.Groups[i].Keep < result.Groups[j].Keep })
+
+	d.logSummary(result, time.Since(startTime))
+	return result, nil
+}
+
+// scan collects the regular files under dirs that pass the filters
+func (d *Deduper) scan(dirs []string) ([]file, error) {
+	var files []file
+	seen := map[string]bool{}
+	for i, dir := range dirs {
+		if err := validate.Dir(dir); err != nil {
+			return nil, err
+		}
+		err := dirwalk.Walk(context.Background(), dir, &dirwalk.Options{
+			Filter: func(e dirwalk.Entry) bool {
+				return e.IsDir() || !d.excluded(e.Name())
+			},
+			OnError: func(path string, err error) error {
+				d.logger.Error("Error accessing path %s: %v", path, err)
+				return nil
+			},
+		}, func(e dirwalk.Entry) error {
+			if !e.Mode().IsRegular() {
+				return nil
+			}
+			abs, err := filepath.Abs(e.Path)
+			if err != nil || seen[abs] {
+				// Overlapping directories would otherwise report a file as its own duplicate
+				return nil
+			}
+			seen[abs] = true
+
+			if e.Size() == 0 || e.Size() < d.opts.MinSize {
+				return nil
+			}
+			files = append(files, file{path: e.Path, dirIdx: i, info: e.FileInfo})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error walking directory %s: %w", dir, err)
+		}
+	}
+	return files, nil
+}
+
+func (d *Deduper) excluded(name string) bool {
+	for _, pattern := range d.opts.Exclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// handle picks the file to keep and applies the action to the others
+func (d *Deduper) handle(size int64, sum string, same []file, result *Result) Group {
+	sort.SliceStable(same, func(i, j int) bool {
+		a, b := same[i], same[j]
+		aTime, bTime := a.info.ModTime(), b.info.ModTime()
+		switch d.opts.Keep {
+		case KeepNewest:
+			if !aTime.Equal(bTime) {
+				return aTime.After(bTime)
+			}
+		case KeepFirstDir:
+			if a.dirIdx != b.dirIdx {
+				return a.dirIdx < b.dirIdx
+			}
+		}
+		if !aTime.Equal(bTime) {
+			return aTime.Before(bTime)
+		}
+		return a.path < b.path
+	})
+
+	group := Group{Size: size, Hash: sum, Keep: same[0].path}
+	for _, dup := range same[1:] {
+		group.Duplicates = append(group.Duplicates, dup.path)
+		if err := d.apply(group.Keep, dup.path); err != nil {
+			result.Failed[dup.path] = err
+			d.logger.Error("Failed to %s duplicate %s: %v", d.opts.Action, dup.path, err)
+			continue
+		}
+		if d.opts.Action != Report {
+			result.Reclaimed += size
+		}
+	}
+	return group
+}
+
+// apply performs the action on a duplicate of keep
+func (d *Deduper) apply(keep, dup string) error {
+	if d.opts.Action == Report {
+		return nil
+	}
+	if d.opts.DryRun {
+		d.logger.Info("[dry run] Would %s %s (duplicate of %s)", d.opts.Action, dup, keep)
+		return nil
+	}
+
+	switch d.opts.Action {
+	case Delete:
+		return os.Remove(dup)
+	case HardLink:
+		// Link under a temporary name first so the duplicate is replaced atomically
+		tmp := dup + ".dedupe-tmp"
+		if err := os.Link(keep, tmp); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, dup); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueInodes drops files that are hard links to a file already in the list
+func uniqueInodes(files []file) []file {
+	var unique []file
+	for _, f := range files {
+		linked := false
+		for _, u := range unique {
+			if os.SameFile(f.info, u.info) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			unique = append(unique, f)
+		}
+	}
+	return unique
+}
+
+// logSummary logs the outcome of a run
+func (d *Deduper) logSummary(result *Result, elapsed time.Duration) {
+	duplicates := 0
+	for _, group := range result.Groups {
+		duplicates += len(group.Duplicates)
+	}
+	prefix := ""
+	if d.opts.DryRun && d.opts.Action != Report {
+		prefix = "[dry run] "
+	}
+
+	d.logger.Summary("%sDeduplication (%s) finished in %.2f seconds", prefix, d.opts.Action, elapsed.Seconds())
+	d.logger.Summary("  - Files scanned: %d", result.Scanned)
+	d.logger.Summary("  - Duplicate groups: %d, duplicates: %d", len(result.Groups), duplicates)
+	if d.opts.Action != Report {
+		d.logger.Summary("  - Bytes reclaimed: %d", result.Reclaimed)
+	}
+	if len(result.Failed) > 0 {
+		d.logger.Summary("  - Failed: %d", len(result.Failed))
+	}
+}
@@ -0,0 +1,162 @@
+package dedupe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func newTestDeduper(t *testing.T, opts *Options) *Deduper {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("dedupe_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	d, err := NewDeduper(testLogger, opts)
+	if err != nil {
+		t.Fatalf("NewDeduper failed: %v", err)
+	}
+	return d
+}
+
+// setupDirs creates two directories sharing duplicate files. a.csv is the
+// oldest copy and c.csv the newest.
+func setupDirs(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	inbound, archive := filepath.Join(root, "inbound"), filepath.Join(root, "archive")
+	os.MkdirAll(filepath.Join(archive, "2024"), 0755)
+	os.MkdirAll(inbound, 0755)
+
+	write := func(path, content string, age time.Duration) {
+		os.WriteFile(path, []byte(content), 0644)
+		modTime := time.Now().Add(-age)
+		os.Chtimes(path, modTime, modTime)
+	}
+	write(filepath.Join(archive, "2024", "a.csv"), "id,amount\n1,10\n", 3*time.Hour)
+	write(filepath.Join(inbound, "b.csv"), "id,amount\n1,10\n", 2*time.Hour)
+	write(filepath.Join(inbound, "c.csv"), "id,amount\n1,10\n", time.Hour)
+	// Same size, different content
+	write(filepath.Join(inbound, "d.csv"), "id,amount\n2,20\n", time.Hour)
+	write(filepath.Join(inbound, "empty.csv"), "", time.Hour)
+	write(filepath.Join(archive, "empty.csv"), "", time.Hour)
+	write(filepath.Join(inbound, "e.tmp"), "id,amount\n1,10\n", time.Hour)
+	return inbound, archive
+}
+
+func TestNewDeduperValidation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("dedupe_test")
+	defer testLogger.Close()
+
+	invalid := []*Options{
+		{Action: "move"},
+		{Keep: "largest"},
+		{Algorithm: "sha3"},
+		{Exclude: []string{"["}},
+	}
+	for _, opts := range invalid {
+		if _, err := NewDeduper(testLogger, opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+	if _, err := NewDeduper(nil, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+}
+
+func TestReport(t *testing.T) {
+	inbound, archive := setupDirs(t)
+	d := newTestDeduper(t, &Options{Exclude: []string{"*.tmp"}})
+
+	result, err := d.Run(inbound, archive, inbound)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Scanned != 4 {
+		t.Errorf("scanned %d files, expected 4", result.Scanned)
+	}
+	if len(result.Groups) != 1 || len(result.Groups[0].Duplicates) != 2 {
+		t.Fatalf("unexpected groups %+v", result.Groups)
+	}
+	if result.Groups[0].Keep != filepath.Join(archive, "2024", "a.csv") {
+		t.Errorf("expected oldest file to be kept, got %s", result.Groups[0].Keep)
+	}
+	if result.Reclaimed != 0 {
+		t.Errorf("report should not reclaim anything, got %d", result.Reclaimed)
+	}
+	if _, err := os.Stat(filepath.Join(inbound, "c.csv")); err != nil {
+		t.Error("report should not remove files")
+	}
+
+	if _, err := d.Run(filepath.Join(inbound, "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestDeleteAndDryRun(t *testing.T) {
+	inbound, archive := setupDirs(t)
+
+	dry := newTestDeduper(t, &Options{Action: Delete, DryRun: true, Keep: KeepFirstDir})
+	result, err := dry.Run(inbound, archive)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Reclaimed != 3*15 {
+		t.Errorf("expected 45 reclaimable bytes, got %d", result.Reclaimed)
+	}
+	if _, err := os.Stat(filepath.Join(archive, "2024", "a.csv")); err != nil {
+		t.Error("dry run should not remove files")
+	}
+
+	d := newTestDeduper(t, &Options{Action: Delete, Keep: KeepFirstDir, Exclude: []string{"*.tmp"}})
+	result, err = d.Run(inbound, archive)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// The oldest copy in the first directory is kept
+	if result.Groups[0].Keep != filepath.Join(inbound, "b.csv") {
+		t.Errorf("kept %s", result.Groups[0].Keep)
+	}
+	for _, removed := range []string{filepath.Join(inbound, "c.csv"), filepath.Join(archive, "2024", "a.csv")} {
+		if _, err := os.Stat(removed); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", removed)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(inbound, "d.csv")); err != nil {
+		t.Error("file with different content should be kept")
+	}
+}
+
+func TestHardLink(t *testing.T) {
+	inbound, archive := setupDirs(t)
+	d := newTestDeduper(t, &Options{Action: HardLink, Keep: KeepNewest})
+
+	result, err := d.Run(inbound, archive)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Failed) > 0 {
+		t.Fatalf("unexpected failures %v", result.Failed)
+	}
+
+	kept, _ := os.Stat(filepath.Join(inbound, "c.csv"))
+	for _, name := range []string{filepath.Join(inbound, "b.csv"), filepath.Join(archive, "2024", "a.csv")} {
+		info, err := os.Stat(name)
+		if err != nil || !os.SameFile(kept, info) {
+			t.Errorf("expected %s to be a hard link to c.csv", name)
+		}
+	}
+
+	// Already linked files are not reported again
+	result, err = d.Run(inbound, archive)
+	if err != nil {
+		t.Fatalf("second Run failed: %v", err)
+	}
+	for _, group := range result.Groups {
+		if group.Size == 15 && len(group.Duplicates) > 1 {
+			t.Errorf("linked files reported as duplicates: %+v", group)
+		}
+	}
+}
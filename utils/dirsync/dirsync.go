@@ -0,0 +1,351 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package dirsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/dirwalk"
+	"github.com/romisugianto/go-utils/utils/iocopy"
+	"github.com/romisugianto/go-utils/utils/pool"
+	"github.com/romisugianto/go-utils/utils/ratelimit"
+	"github.com/romisugianto/go-utils/utils/validate"
+)
+
+// Compare selects how a destination file is found to be up to date
+type Compare string
+
+// Supported comparisons
+const (
+	// SizeModTime treats files with the same size and modification time as
+	// equal. It is the default and reads no file content.
+	SizeModTime Compare = "size-mtime"
+	// Size treats files with the same size as equal
+	Size Compare = "size"
+	// Checksum hashes files of the same size and compares their content
+	Checksum Compare = "checksum"
+)
+
+// Options configures a mirror
+type Options struct {
+	// Compare defaults to SizeModTime
+	Compare Compare
+	// Algorithm is used by Checksum (default SHA-256)
+	Algorithm checksum.Algorithm
+	// ModTimeWindow tolerates modification time differences, e.g. 2s for
+	// FAT or SMB shares that store coarse timestamps
+	ModTimeWindow time.Duration
+	// Delete removes destination files and directories missing from the
+	// source. Files excluded by Include, Exclude or SkipHidden are kept.
+	Delete bool
+	// Include and Exclude are globs matched against the relative path and
+	// the base name, as in dirwalk
+	Include []string
+	Exclude []string
+	// SkipHidden skips dot-prefixed files and directories
+	SkipHidden bool
+	// Limiter caps the bytes per second copied across all workers, e.g.
+	// ratelimit.NewLimiter(10<<20, 1<<20) for 10 MiB/s; nil is unlimited
+	Limiter *ratelimit.Limiter
+	// Concurrency is the number of files copied in parallel (default GOMAXPROCS)
+	Concurrency int
+	// DryRun reports what would change without touching the destination
+	DryRun bool
+}
+
+// Result lists the changes made, or planned in a dry run. Paths are
+// slash-separated and relative to the directories.
+type Result struct {
+	// Copied are files missing from the destination
+	Copied []string
+	// Updated are destination files that differed from the source
+	Updated []string
+	// Deleted are destination files and directories missing from the source
+	Deleted []string
+	// Skipped are files already up to date
+	Skipped []string
+	// Bytes is the size of the copied and updated files
+	Bytes  int64
+	DryRun bool
+}
+
+// Changed reports whether the mirror copied, updated or deleted anything
+func (r *Result) Changed() bool {
+	return len(r.Copied)+len(r.Updated)+len(r.Deleted) > 0
+}
+
+// Summary describes the result in one line, e.g. for logging
+func (r *Result) Summary() string {
+	prefix := ""
+	if r.DryRun {
+		prefix = "dry run: "
+	}
+	return fmt.Sprintf("%s%d copied, %d updated, %d deleted, %d up to date, %d bytes",
+		prefix, len(r.Copied), len(r.Updated), len(r.Deleted), len(r.Skipped), r.Bytes)
+}
+
+// String lists the changes one per line, prefixed with "+" for copied, "~"
+// for updated and "-" for deleted paths
+func (r *Result) String() string {
+	var b strings.Builder
+	for _, group := range []struct {
+		prefix string
+		paths  []string
+	}{{"+", r.Copied}, {"~", r.Updated}, {"-", r.Deleted}} {
+		for _, path := range group.paths {
+			fmt.Fprintf(&b, "%s %s\n", group.prefix, path)
+		}
+	}
+	return b.String()
+}
+
+// Mirror makes dst a copy of src: missing and changed files are copied,
+// keeping their permissions and modification times, and with Options.Delete
+// files missing from src are removed. Files are written to a temporary file
+// and renamed into place, so an interrupted mirror never leaves partial
+// files. A nil opts compares by size and modification time.
+func Mirror(ctx context.Context, src, dst string, opts *Options) (*Result, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	switch o.Compare {
+	case "":
+		o.Compare = SizeModTime
+	case SizeModTime, Size, Checksum:
+	default:
+		return nil, fmt.Errorf("unsupported comparison %q", o.Compare)
+	}
+	if o.Algorithm == "" {
+		o.Algorithm = checksum.SHA256
+	}
+	if _, err := checksum.NewHash(o.Algorithm); err != nil {
+		return nil, err
+	}
+	if err := validate.Dir(src); err != nil {
+		return nil, fmt.Errorf("invalid source: %w", err)
+	}
+
+	walkOpts := &dirwalk.Options{Include: o.Include, Exclude: o.Exclude, SkipHidden: o.SkipHidden, IncludeDirs: true}
+	srcEntries, err := dirwalk.Collect(ctx, src, walkOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", src, err)
+	}
+	dstEntries, err := dirwalk.Collect(ctx, dst, walkOpts)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to scan %s: %w", dst, err)
+	}
+	existing := make(map[string]dirwalk.Entry, len(dstEntries))
+	for _, e := range dstEntries {
+		existing[e.RelPath] = e
+	}
+
+	result := &Result{DryRun: o.DryRun}
+	var copies []dirwalk.Entry
+	var mu sync.Mutex
+	var updated map[string]bool
+
+	// Decide what to copy, hashing candidates in parallel for Checksum
+	var candidates []dirwalk.Entry
+	for _, e := range srcEntries {
+		if e.IsDir() {
+			continue
+		}
+		d, ok := existing[e.RelPath]
+		switch {
+		case !ok:
+			copies = append(copies, e)
+		case d.IsDir() || d.Size() != e.Size():
+			candidates = append(candidates, e)
+		case o.Compare == Checksum:
+			candidates = append(candidates, e)
+		case o.Compare == SizeModTime && !sameTime(e.ModTime(), d.ModTime(), o.ModTimeWindow):
+			candidates = append(candidates, e)
+		default:
+			result.Skipped = append(result.Skipped, e.RelPath)
+		}
+	}
+	updated = make(map[string]bool, len(candidates))
+	err = pool.ForEach(ctx, workers(o), candidates, func(ctx context.Context, e dirwalk.Entry) error {
+		d := existing[e.RelPath]
+		if o.Compare == Checksum && !d.IsDir() && d.Size() == e.Size() {
+			equal, err := sameContent(e.Path, d.Path, o.Algorithm)
+			if err != nil {
+				return err
+			}
+			if equal {
+				mu.Lock()
+				result.Skipped = append(result.Skipped, e.RelPath)
+				mu.Unlock()
+				return nil
+			}
+		}
+		mu.Lock()
+		updated[e.RelPath] = true
+		mu.Unlock()
+		return nil
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range candidates {
+		if updated[e.RelPath] {
+			copies = append(copies, e)
+		}
+	}
+
+	for _, e := range copies {
+		if updated[e.RelPath] {
+			result.Updated = append(result.Updated, e.RelPath)
+		} else {
+			result.Copied = append(result.Copied, e.RelPath)
+		}
+		result.Bytes += e.Size()
+	}
+	if o.Delete {
+		result.Deleted = extraneous(srcEntries, dstEntries)
+	}
+	sort.Strings(result.Copied)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Skipped)
+	if o.DryRun {
+		return result, nil
+	}
+
+	// Deleting first frees space and removes directories that files replace
+	if err := remove(dst, result.Deleted); err != nil {
+		return result, err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return result, fmt.Errorf("failed to create destination %s: %w", dst, err)
+	}
+	err = pool.ForEach(ctx, workers(o), copies, func(ctx context.Context, e dirwalk.Entry) error {
+		return copyFile(ctx, e, filepath.Join(dst, filepath.FromSlash(e.RelPath)), o.Limiter)
+	}, true)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// workers returns the copy concurrency
+func workers(o Options) int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// sameTime reports whether a and b differ by at most window
+func sameTime(a, b time.Time, window time.Duration) bool {
+	d := a.Sub(b)
+	if d < 0 {
+		d = -d
+	}
+	return d <= window
+}
+
+// sameContent reports whether two files have the same checksum
+func sameContent(a, b string, algo checksum.Algorithm) (bool, error) {
+	sumA, err := checksum.SumFile(a, algo)
+	if err != nil {
+		return false, err
+	}
+	sumB, err := checksum.SumFile(b, algo)
+	if err != nil {
+		return false, err
+	}
+	return sumA == sumB, nil
+}
+
+// extraneous returns the destination paths missing from the source, or
+// whose type differs. Paths inside a removed directory are not listed.
+func extraneous(srcEntries, dstEntries []dirwalk.Entry) []string {
+	source := make(map[string]bool, len(srcEntries))
+	for _, e := range srcEntries {
+		source[e.RelPath+kind(e)] = true
+	}
+
+	var paths []string
+	removedDirs := map[string]bool{}
+	for _, e := range dstEntries {
+		if source[e.RelPath+kind(e)] || insideRemoved(e.RelPath, removedDirs) {
+			continue
+		}
+		if e.IsDir() {
+			removedDirs[e.RelPath] = true
+		}
+		paths = append(paths, e.RelPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// kind distinguishes directories from files with the same path
+func kind(e dirwalk.Entry) string {
+	if e.IsDir() {
+		return "/"
+	}
+	return ""
+}
+
+// insideRemoved reports whether relPath is inside one of the directories
+func insideRemoved(relPath string, dirs map[string]bool) bool {
+	for dir := strings.TrimSuffix(relPath, "/"); ; {
+		i := strings.LastIndex(dir, "/")
+		if i < 0 {
+			return false
+		}
+		dir = dir[:i]
+		if dirs[dir] {
+			return true
+		}
+	}
+}
+
+// remove deletes the relative paths under root
+func remove(root string, paths []string) error {
+	for _, relPath := range paths {
+		if err := os.RemoveAll(filepath.Join(root, filepath.FromSlash(relPath))); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", relPath, err)
+		}
+	}
+	return nil
+}
+
+// copyFile copies e to target through a temporary file, keeping its
+// permissions and modification time
+func copyFile(ctx context.Context, e dirwalk.Entry, target string, limiter *ratelimit.Limiter) error {
+	in, err := os.Open(e.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", e.Path, err)
+	}
+	defer in.Close()
+
+	out, err := atomicfile.Create(target, e.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+	if _, err := iocopy.Copy(ctx, out, in, &iocopy.Options{Limiter: limiter}); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", e.RelPath, err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(target, e.ModTime(), e.ModTime()); err != nil {
+		return fmt.Errorf("failed to set modification time of %s: %w", e.RelPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package dirsync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/ratelimit"
+)
+
+// writeFiles creates files under root from a relative path to content map
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for relPath, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMirror(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "nas", "staging")
+	writeFiles(t, src, map[string]string{
+		"orders.csv":           "id,total\n1,10\n",
+		"2024/06/payments.csv": "id,amount\n",
+		".cache/state":         "x",
+	})
+	os.Chmod(filepath.Join(src, "orders.csv"), 0600)
+
+	result, err := Mirror(context.Background(), src, dst, &Options{SkipHidden: true})
+	if err != nil {
+		t.Fatalf("Mirror failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Copied, []string{"2024/06/payments.csv", "orders.csv"}) || result.Bytes != 24 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	srcInfo, _ := os.Stat(filepath.Join(src, "orders.csv"))
+	dstInfo, err := os.Stat(filepath.Join(dst, "orders.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dstInfo.Mode().Perm() != 0600 || !dstInfo.ModTime().Equal(srcInfo.ModTime()) {
+		t.Errorf("mode %v and time %v not kept", dstInfo.Mode(), dstInfo.ModTime())
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".cache")); !os.IsNotExist(err) {
+		t.Error("hidden directory should not be copied")
+	}
+
+	// A second run finds everything up to date
+	result, err = Mirror(context.Background(), src, dst, &Options{SkipHidden: true})
+	if err != nil {
+		t.Fatalf("Mirror failed: %v", err)
+	}
+	if result.Changed() || len(result.Skipped) != 2 {
+		t.Errorf("expected no changes, got %+v", result)
+	}
+
+	// Changed and extraneous files, reported by a dry run first
+	writeFiles(t, src, map[string]string{"orders.csv": "id,total\n1,10\n2,20\n"})
+	writeFiles(t, dst, map[string]string{"old.csv": "stale", "archive/2023/a.csv": "a", ".keep": ""})
+	opts := &Options{SkipHidden: true, Delete: true, DryRun: true}
+	result, err = Mirror(context.Background(), src, dst, opts)
+	if err != nil {
+		t.Fatalf("Mirror failed: %v", err)
+	}
+	expected := "~ orders.csv\n- archive\n- old.csv\n"
+	if result.String() != expected {
+		t.Errorf("String() = %q, expected %q", result.String(), expected)
+	}
+	if !strings.HasPrefix(result.Summary(), "dry run: 0 copied, 1 updated, 2 deleted") {
+		t.Errorf("Summary() = %q", result.Summary())
+	}
+	if _, err := os.Stat(filepath.Join(dst, "old.csv")); err != nil {
+		t.Error("dry run should not delete files")
+	}
+
+	opts.DryRun = false
+	if _, err := Mirror(context.Background(), src, dst, opts); err != nil {
+		t.Fatalf("Mirror failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "orders.csv")); string(data) != "id,total\n1,10\n2,20\n" {
+		t.Errorf("orders.csv not updated: %q", data)
+	}
+	for _, relPath := range []string{"old.csv", "archive"} {
+		if _, err := os.Stat(filepath.Join(dst, relPath)); !os.IsNotExist(err) {
+			t.Errorf("%s should be deleted", relPath)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".keep")); err != nil {
+		t.Error("skipped files should not be deleted")
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 3 {
+		t.Errorf("unexpected destination entries %v", entries)
+	}
+}
+
+func TestMirror_Compare(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	writeFiles(t, src, map[string]string{"a.txt": "hello"})
+	writeFiles(t, dst, map[string]string{"a.txt": "jello"})
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dst, "a.txt"), past, past)
+
+	tests := []struct {
+		opts    Options
+		updated int
+	}{
+		{Options{Compare: Size}, 0},
+		{Options{ModTimeWindow: 2 * time.Hour}, 0},
+		{Options{}, 1},
+		{Options{Compare: Checksum}, 1},
+	}
+	for _, test := range tests {
+		test.opts.DryRun = true
+		result, err := Mirror(context.Background(), src, dst, &test.opts)
+		if err != nil {
+			t.Fatalf("Mirror(%+v) failed: %v", test.opts, err)
+		}
+		if len(result.Updated) != test.updated {
+			t.Errorf("Mirror(%+v) updated %v, expected %d", test.opts, result.Updated, test.updated)
+		}
+	}
+
+	// Identical content is skipped by Checksum even when times differ
+	writeFiles(t, dst, map[string]string{"a.txt": "hello"})
+	os.Chtimes(filepath.Join(dst, "a.txt"), past, past)
+	result, err := Mirror(context.Background(), src, dst, &Options{Compare: Checksum})
+	if err != nil || result.Changed() || len(result.Skipped) != 1 {
+		t.Errorf("expected a.txt up to date, got %+v, %v", result, err)
+	}
+
+	if _, err := Mirror(context.Background(), src, dst, &Options{Compare: "fuzzy"}); err == nil {
+		t.Error("expected error for unsupported comparison")
+	}
+	if _, err := Mirror(context.Background(), filepath.Join(dir, "missing"), dst, nil); err == nil {
+		t.Error("expected error for missing source")
+	}
+}
+
+func TestMirror_Limiter(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	writeFiles(t, src, map[string]string{"a.bin": strings.Repeat("a", 3000), "b.bin": strings.Repeat("b", 3000)})
+
+	// 6000 bytes at 20000 bytes per second with a 1000 byte burst
+	start := time.Now()
+	result, err := Mirror(context.Background(), src, dst, &Options{Limiter: ratelimit.NewLimiter(20000, 1000)})
+	if err != nil {
+		t.Fatalf("Mirror failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("copy took %v, expected the limiter to slow it down", elapsed)
+	}
+	if result.Bytes != 6000 {
+		t.Errorf("Bytes = %d", result.Bytes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Mirror(ctx, src, filepath.Join(dir, "other"), nil); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
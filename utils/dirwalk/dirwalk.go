@@ -0,0 +1,276 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package dirwalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// SkipDir returned by the callback for a directory skips its contents
+var SkipDir = fs.SkipDir
+
+// SkipAll returned by the callback stops the walk without an error
+var SkipAll = fs.SkipAll
+
+// Entry is a file or directory found during a walk
+type Entry struct {
+	fs.FileInfo
+	// Path is the root joined with RelPath
+	Path string
+	// RelPath is the slash-separated path relative to the root
+	RelPath string
+	// Depth is 1 for entries directly in the root
+	Depth int
+}
+
+// Options configures a walk
+type Options struct {
+	// MaxDepth limits recursion; 1 visits only entries directly in the root, 0 is unlimited
+	MaxDepth int
+	// Include limits reported files to those matching at least one glob
+	// (path.Match syntax, matched against the relative path and the base name)
+	Include []string
+	// Exclude skips matching files and directories
+	Exclude []string
+	// SkipHidden skips dot-prefixed files and directories
+	SkipHidden bool
+	// Filter is an additional check; a directory it rejects is not descended into
+	Filter func(e Entry) bool
+	// IncludeDirs also passes directories to the callback
+	IncludeDirs bool
+	// Concurrency is the number of directories read in parallel (default GOMAXPROCS)
+	Concurrency int
+	// OnError handles a directory or entry that cannot be read. Returning nil
+	// skips it and continues; the default stops the walk with the error.
+	OnError func(path string, err error) error
+}
+
+// dirResult is the content of one directory read by a worker
+type dirResult struct {
+	dir     Entry
+	entries []Entry
+	errs    map[string]error
+}
+
+// Walk visits the entries under root, reading directories concurrently. The
+// callback is never called concurrently, but the order of directories is not
+// deterministic; use Collect for a sorted result. Entries within a directory
+// are visited in name order. A nil opts visits every file.
+func Walk(ctx context.Context, root string, opts *Options, fn func(e Entry) error) error {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if err := validate(&o); err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("failed to access directory %q: %w", root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", root)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan Entry)
+	results := make(chan dirResult)
+	for i := 0; i < o.Concurrency; i++ {
+		go readDirs(ctx, jobs, results)
+	}
+	defer close(jobs)
+
+	queue := []Entry{{FileInfo: info, Path: root}}
+	pending := 0
+	for len(queue) > 0 || pending > 0 {
+		var send chan Entry
+		var next Entry
+		if len(queue) > 0 {
+			send, next = jobs, queue[len(queue)-1]
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case send <- next:
+			queue = queue[:len(queue)-1]
+			pending++
+		case res := <-results:
+			pending--
+			dirs, err := visit(&o, res, fn)
+			if err != nil {
+				if errors.Is(err, SkipAll) {
+					return nil
+				}
+				return err
+			}
+			// Push in reverse so the queue pops directories in name order
+			for i := len(dirs) - 1; i >= 0; i-- {
+				queue = append(queue, dirs[i])
+			}
+		}
+	}
+	return nil
+}
+
+// Collect returns the entries under root in the order filepath.WalkDir would
+// visit them
+func Collect(ctx context.Context, root string, opts *Options) ([]Entry, error) {
+	var entries []Entry
+	err := Walk(ctx, root, opts, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return lessPath(entries[i].RelPath, entries[j].RelPath)
+	})
+	return entries, nil
+}
+
+func validate(o *Options) error {
+	for _, pattern := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if o.MaxDepth < 0 {
+		return fmt.Errorf("MaxDepth must be >= 0, got %d", o.MaxDepth)
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	return nil
+}
+
+// readDirs lists the directories sent on jobs until it is closed
+func readDirs(ctx context.Context, jobs <-chan Entry, results chan<- dirResult) {
+	for dir := range jobs {
+		res := dirResult{dir: dir, errs: map[string]error{}}
+		dirEntries, err := os.ReadDir(dir.Path)
+		if err != nil {
+			res.errs[dir.Path] = err
+		}
+		for _, d := range dirEntries {
+			info, err := d.Info()
+			if err != nil {
+				// Files removed since the directory was read are skipped silently
+				if !os.IsNotExist(err) {
+					res.errs[filepath.Join(dir.Path, d.Name())] = err
+				}
+				continue
+			}
+			res.entries = append(res.entries, Entry{
+				FileInfo: info,
+				Path:     filepath.Join(dir.Path, d.Name()),
+				RelPath:  path.Join(dir.RelPath, d.Name()),
+				Depth:    dir.Depth + 1,
+			})
+		}
+
+		select {
+		case results <- res:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// visit passes the entries of a directory to fn and returns the
+// subdirectories to descend into
+func visit(o *Options, res dirResult, fn func(e Entry) error) ([]Entry, error) {
+	paths := make([]string, 0, len(res.errs))
+	for p := range res.errs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if o.OnError == nil {
+			return nil, fmt.Errorf("error walking %s: %w", p, res.errs[p])
+		}
+		if err := o.OnError(p, res.errs[p]); err != nil {
+			return nil, err
+		}
+	}
+
+	var dirs []Entry
+	for _, e := range res.entries {
+		if !accept(o, e) {
+			continue
+		}
+		if e.IsDir() {
+			if o.IncludeDirs {
+				if err := fn(e); err != nil {
+					if errors.Is(err, SkipDir) {
+						continue
+					}
+					return nil, err
+				}
+			}
+			if o.MaxDepth == 0 || e.Depth < o.MaxDepth {
+				dirs = append(dirs, e)
+			}
+			continue
+		}
+		if len(o.Include) > 0 && !matchAny(e.RelPath, o.Include) {
+			continue
+		}
+		if err := fn(e); err != nil && !errors.Is(err, SkipDir) {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// accept applies the filters shared by files and directories
+func accept(o *Options, e Entry) bool {
+	if o.SkipHidden && strings.HasPrefix(e.Name(), ".") {
+		return false
+	}
+	if matchAny(e.RelPath, o.Exclude) {
+		return false
+	}
+	return o.Filter == nil || o.Filter(e)
+}
+
+// matchAny reports whether relPath or its base name matches any glob
+func matchAny(relPath string, globs []string) bool {
+	base := path.Base(relPath)
+	for _, pattern := range globs {
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lessPath orders slash-separated paths element by element, so a directory's
+// contents sort directly after it
+func lessPath(a, b string) bool {
+	for {
+		aElem, aRest, aMore := strings.Cut(a, "/")
+		bElem, bRest, bMore := strings.Cut(b, "/")
+		if aElem != bElem {
+			return aElem < bElem
+		}
+		if !aMore || !bMore {
+			return !aMore && bMore
+		}
+		a, b = aRest, bRest
+	}
+}
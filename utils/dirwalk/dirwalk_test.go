@@ -0,0 +1,144 @@
+package dirwalk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTree creates a small directory tree and returns its root
+func setupTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, name := range []string{
+		"a.csv",
+		"a/b.csv",
+		"a/c/d.csv",
+		"a/c/e.tmp",
+		"b.txt",
+		".hidden/f.csv",
+		"z/g.csv",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(name), 0644)
+	}
+	return root
+}
+
+func relPaths(entries []Entry) string {
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.RelPath)
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestCollect(t *testing.T) {
+	root := setupTree(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		opts *Options
+		want string
+	}{
+		{"all files", nil, ".hidden/f.csv,a/b.csv,a/c/d.csv,a/c/e.tmp,a.csv,b.txt,z/g.csv"},
+		{"with dirs", &Options{IncludeDirs: true, SkipHidden: true, MaxDepth: 2}, "a,a/b.csv,a/c,a.csv,b.txt,z,z/g.csv"},
+		{"depth 1", &Options{MaxDepth: 1}, "a.csv,b.txt"},
+		{"include", &Options{Include: []string{"*.csv"}, SkipHidden: true}, "a/b.csv,a/c/d.csv,a.csv,z/g.csv"},
+		{"exclude dir", &Options{Exclude: []string{"a/c", "*.txt", ".*"}}, "a/b.csv,a.csv,z/g.csv"},
+		{"filter", &Options{Concurrency: 1, Filter: func(e Entry) bool { return e.Name() != "z" && e.Size() > 5 }}, ".hidden/f.csv,a/b.csv,a/c/d.csv,a/c/e.tmp"},
+	}
+	for _, c := range cases {
+		entries, err := Collect(ctx, root, c.opts)
+		if err != nil {
+			t.Fatalf("%s: Collect failed: %v", c.name, err)
+		}
+		if got := relPaths(entries); got != c.want {
+			t.Errorf("%s: got %s, expected %s", c.name, got, c.want)
+		}
+	}
+
+	entries, _ := Collect(ctx, root, &Options{Include: []string{"d.csv"}})
+	e := entries[0]
+	if e.Path != filepath.Join(root, "a", "c", "d.csv") || e.Depth != 3 || e.Size() != int64(len("a/c/d.csv")) || e.IsDir() {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestWalkEarlyTermination(t *testing.T) {
+	root := setupTree(t)
+	ctx := context.Background()
+
+	var visited []string
+	err := Walk(ctx, root, &Options{IncludeDirs: true, Concurrency: 1}, func(e Entry) error {
+		visited = append(visited, e.RelPath)
+		if e.RelPath == "a" {
+			return SkipDir
+		}
+		if e.RelPath == "b.txt" {
+			return SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	for _, p := range visited {
+		if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "z") {
+			t.Errorf("visited %s after SkipDir or SkipAll", p)
+		}
+	}
+
+	stop := errors.New("stop")
+	if err := Walk(ctx, root, nil, func(e Entry) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("expected callback error, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := Walk(cancelled, root, nil, func(e Entry) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	root := setupTree(t)
+	ctx := context.Background()
+
+	if _, err := Collect(ctx, filepath.Join(root, "missing"), nil); err == nil {
+		t.Error("expected error for missing root")
+	}
+	if _, err := Collect(ctx, filepath.Join(root, "a.csv"), nil); err == nil {
+		t.Error("expected error for file root")
+	}
+	if _, err := Collect(ctx, root, &Options{Include: []string{"["}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := Collect(ctx, root, &Options{MaxDepth: -1}); err == nil {
+		t.Error("expected error for negative depth")
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission errors cannot be tested as root")
+	}
+	locked := filepath.Join(root, "a", "c")
+	os.Chmod(locked, 0)
+	defer os.Chmod(locked, 0755)
+
+	if _, err := Collect(ctx, root, nil); err == nil {
+		t.Error("expected error for unreadable directory")
+	}
+	var skipped []string
+	entries, err := Collect(ctx, root, &Options{OnError: func(path string, err error) error {
+		skipped = append(skipped, path)
+		return nil
+	}})
+	if err != nil || len(skipped) != 1 || len(entries) != 5 {
+		t.Errorf("expected unreadable directory to be skipped, got %v, %v, %d entries", err, skipped, len(entries))
+	}
+}
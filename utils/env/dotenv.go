@@ -0,0 +1,144 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// keyPattern matches valid variable names
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Load reads each .env file (default ".env") and sets the variables that are
+// not already set in the environment, so real environment variables always
+// win. Missing files are an error; use LoadIfExists for optional files.
+func Load(paths ...string) error {
+	return load(paths, false, false)
+}
+
+// LoadIfExists is like Load but skips files that do not exist
+func LoadIfExists(paths ...string) error {
+	return load(paths, false, true)
+}
+
+// Overload is like Load but replaces variables that are already set
+func Overload(paths ...string) error {
+	return load(paths, true, false)
+}
+
+func load(paths []string, override, optional bool) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	for _, path := range paths {
+		values, err := Read(path)
+		if err != nil {
+			if optional && os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		for key, value := range values {
+			if _, exists := os.LookupEnv(key); exists && !override {
+				continue
+			}
+			if err := os.Setenv(key, value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Read parses a .env file without changing the environment. Lines have the
+// form KEY=value with an optional "export " prefix. Values may be single
+// quoted (taken literally), double quoted (with \n, \t, \" and \\ escapes) or
+// unquoted, where a " #" starts a comment. ${VAR} references in unquoted and
+// double-quoted values are expanded from earlier lines and the environment.
+func Read(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	expand := func(name string) string {
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid line %q", path, lineNum, scanner.Text())
+		}
+
+		value, err := parseValue(strings.TrimSpace(raw), expand)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseValue unquotes a raw value and expands variable references
+func parseValue(raw string, expand func(string) string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return os.Expand(b.String(), expand), nil
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = strings.TrimSpace(raw[:i])
+	}
+	return os.Expand(raw, expand), nil
+}
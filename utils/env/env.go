@@ -0,0 +1,229 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/humanize"
+)
+
+// GetString returns the value of key, or def when it is unset or empty
+func GetString(key, def string) string {
+	if value, ok := lookup(key); ok {
+		return value
+	}
+	return def
+}
+
+// GetInt returns key parsed as an integer, or def when it is unset, empty or invalid
+func GetInt(key string, def int) int {
+	value, err := parseInt(key, def)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetBool returns key parsed by strconv.ParseBool (also accepting yes/no and
+// on/off), or def when it is unset, empty or invalid
+func GetBool(key string, def bool) bool {
+	value, err := parseBool(key, def)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetDuration returns key parsed as a duration such as "90s" or "3d12h", or
+// def when it is unset, empty or invalid
+func GetDuration(key string, def time.Duration) time.Duration {
+	value, err := parseDuration(key, def)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetBytes returns key parsed as a size such as "500MB" or "2GiB", or def
+// when it is unset, empty or invalid
+func GetBytes(key string, def int64) int64 {
+	value, err := parseBytes(key, def)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Require checks that every key is set to a non-empty value and reports all
+// missing keys in one error
+func Require(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if _, ok := lookup(key); !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required environment variables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Parser reads typed variables with an optional prefix and collects every
+// missing or invalid one, so a misconfigured deployment reports all problems
+// at once instead of failing on the first
+type Parser struct {
+	prefix string
+	errs   []error
+}
+
+// NewParser creates a Parser that prepends prefix to every key, e.g. "APP_"
+// turns "PORT" into APP_PORT
+func NewParser(prefix string) *Parser {
+	return &Parser{prefix: prefix}
+}
+
+// String returns the value of key, or def when it is unset or empty
+func (p *Parser) String(key, def string) string {
+	return GetString(p.prefix+key, def)
+}
+
+// Int returns key parsed as an integer, or def when it is unset or empty.
+// An invalid value is recorded as an error.
+func (p *Parser) Int(key string, def int) int {
+	value, err := parseInt(p.prefix+key, def)
+	p.record(err)
+	return value
+}
+
+// Bool returns key parsed as a boolean, or def when it is unset or empty.
+// An invalid value is recorded as an error.
+func (p *Parser) Bool(key string, def bool) bool {
+	value, err := parseBool(p.prefix+key, def)
+	p.record(err)
+	return value
+}
+
+// Duration returns key parsed as a duration, or def when it is unset or
+// empty. An invalid value is recorded as an error.
+func (p *Parser) Duration(key string, def time.Duration) time.Duration {
+	value, err := parseDuration(p.prefix+key, def)
+	p.record(err)
+	return value
+}
+
+// Bytes returns key parsed as a size such as "500MB", or def when it is
+// unset or empty. An invalid value is recorded as an error.
+func (p *Parser) Bytes(key string, def int64) int64 {
+	value, err := parseBytes(p.prefix+key, def)
+	p.record(err)
+	return value
+}
+
+// RequiredString returns the value of key, recording an error when it is unset or empty
+func (p *Parser) RequiredString(key string) string {
+	value, ok := lookup(p.prefix + key)
+	if !ok {
+		p.record(fmt.Errorf("missing required environment variable %s", p.prefix+key))
+	}
+	return value
+}
+
+// RequiredInt returns key parsed as an integer, recording an error when it
+// is unset, empty or invalid
+func (p *Parser) RequiredInt(key string) int {
+	if _, ok := lookup(p.prefix + key); !ok {
+		p.record(fmt.Errorf("missing required environment variable %s", p.prefix+key))
+		return 0
+	}
+	return p.Int(key, 0)
+}
+
+// RequiredDuration returns key parsed as a duration, recording an error when
+// it is unset, empty or invalid
+func (p *Parser) RequiredDuration(key string) time.Duration {
+	if _, ok := lookup(p.prefix + key); !ok {
+		p.record(fmt.Errorf("missing required environment variable %s", p.prefix+key))
+		return 0
+	}
+	return p.Duration(key, 0)
+}
+
+// Err returns every problem recorded so far joined into one error, or nil
+func (p *Parser) Err() error {
+	return errors.Join(p.errs...)
+}
+
+func (p *Parser) record(err error) {
+	if err != nil {
+		p.errs = append(p.errs, err)
+	}
+}
+
+// lookup returns the value of key, treating an empty value as unset
+func lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func parseInt(key string, def int) (int, error) {
+	raw, ok := lookup(key)
+	if !ok {
+		return def, nil
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return def, fmt.Errorf("invalid integer for %s: %q", key, raw)
+	}
+	return value, nil
+}
+
+func parseBool(key string, def bool) (bool, error) {
+	raw, ok := lookup(key)
+	if !ok {
+		return def, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	value, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return def, fmt.Errorf("invalid boolean for %s: %q", key, raw)
+	}
+	return value, nil
+}
+
+func parseDuration(key string, def time.Duration) (time.Duration, error) {
+	raw, ok := lookup(key)
+	if !ok {
+		return def, nil
+	}
+	value, err := humanize.ParseDuration(raw)
+	if err != nil {
+		return def, fmt.Errorf("invalid duration for %s: %q", key, raw)
+	}
+	return value, nil
+}
+
+func parseBytes(key string, def int64) (int64, error) {
+	raw, ok := lookup(key)
+	if !ok {
+		return def, nil
+	}
+	value, err := humanize.ParseBytes(raw)
+	if err != nil {
+		return def, fmt.Errorf("invalid size for %s: %q", key, raw)
+	}
+	return value, nil
+}
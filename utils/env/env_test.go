@@ -0,0 +1,130 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetters(t *testing.T) {
+	t.Setenv("ENV_TEST_NAME", "orders")
+	t.Setenv("ENV_TEST_EMPTY", "")
+	t.Setenv("ENV_TEST_WORKERS", "8")
+	t.Setenv("ENV_TEST_BAD_INT", "eight")
+	t.Setenv("ENV_TEST_DRY_RUN", "yes")
+	t.Setenv("ENV_TEST_TIMEOUT", "90s")
+	t.Setenv("ENV_TEST_RETENTION", "3d12h")
+	t.Setenv("ENV_TEST_MAX_SIZE", "500MB")
+
+	if got := GetString("ENV_TEST_NAME", "x"); got != "orders" {
+		t.Errorf("GetString = %q", got)
+	}
+	if got := GetString("ENV_TEST_EMPTY", "fallback"); got != "fallback" {
+		t.Errorf("expected default for empty value, got %q", got)
+	}
+	if got := GetInt("ENV_TEST_WORKERS", 1); got != 8 {
+		t.Errorf("GetInt = %d", got)
+	}
+	if got := GetInt("ENV_TEST_BAD_INT", 4); got != 4 {
+		t.Errorf("expected default for invalid integer, got %d", got)
+	}
+	if !GetBool("ENV_TEST_DRY_RUN", false) || !GetBool("ENV_TEST_UNSET", true) {
+		t.Error("GetBool returned unexpected value")
+	}
+	if got := GetDuration("ENV_TEST_TIMEOUT", time.Second); got != 90*time.Second {
+		t.Errorf("GetDuration = %s", got)
+	}
+	if got := GetDuration("ENV_TEST_RETENTION", time.Second); got != 84*time.Hour {
+		t.Errorf("GetDuration with days = %s", got)
+	}
+	if got := GetBytes("ENV_TEST_MAX_SIZE", 1); got != 500_000_000 {
+		t.Errorf("GetBytes = %d", got)
+	}
+	if got := GetBytes("ENV_TEST_NAME", 1024); got != 1024 {
+		t.Errorf("expected default for invalid size, got %d", got)
+	}
+}
+
+func TestRequireAndParser(t *testing.T) {
+	t.Setenv("APP_DB_DSN", "postgres://etl@db/orders")
+	t.Setenv("APP_PORT", "not-a-port")
+	t.Setenv("APP_TIMEOUT", "30s")
+
+	err := Require("APP_DB_DSN", "APP_SFTP_HOST", "APP_BUCKET")
+	if err == nil || !strings.Contains(err.Error(), "APP_SFTP_HOST, APP_BUCKET") {
+		t.Errorf("expected both missing keys in error, got %v", err)
+	}
+
+	p := NewParser("APP_")
+	dsn := p.RequiredString("DB_DSN")
+	port := p.Int("PORT", 8080)
+	timeout := p.RequiredDuration("TIMEOUT")
+	p.RequiredString("SFTP_HOST")
+	workers := p.Int("WORKERS", 4)
+
+	if dsn != "postgres://etl@db/orders" || port != 8080 || timeout != 30*time.Second || workers != 4 {
+		t.Errorf("unexpected values %q %d %s %d", dsn, port, timeout, workers)
+	}
+	err = p.Err()
+	if err == nil {
+		t.Fatal("expected aggregated error")
+	}
+	for _, want := range []string{"APP_PORT", "APP_SFTP_HOST"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s in error %q", want, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	os.WriteFile(path, []byte(`# pipeline settings
+export ENV_LOAD_HOST=sftp.example.com
+ENV_LOAD_USER = 'etl user'
+ENV_LOAD_URL=sftp://${ENV_LOAD_HOST}:22 # inline comment
+ENV_LOAD_BANNER="line one\nline \"two\""
+ENV_LOAD_EXISTING=from-file
+ENV_LOAD_EMPTY=
+`), 0644)
+	t.Setenv("ENV_LOAD_EXISTING", "from-env")
+	for _, key := range []string{"ENV_LOAD_HOST", "ENV_LOAD_USER", "ENV_LOAD_URL", "ENV_LOAD_BANNER", "ENV_LOAD_EMPTY"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	if err := Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	expected := map[string]string{
+		"ENV_LOAD_HOST":     "sftp.example.com",
+		"ENV_LOAD_USER":     "etl user",
+		"ENV_LOAD_URL":      "sftp://sftp.example.com:22",
+		"ENV_LOAD_BANNER":   "line one\nline \"two\"",
+		"ENV_LOAD_EXISTING": "from-env",
+	}
+	for key, want := range expected {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, expected %q", key, got, want)
+		}
+	}
+
+	if err := Overload(path); err != nil || os.Getenv("ENV_LOAD_EXISTING") != "from-file" {
+		t.Errorf("expected Overload to replace existing value, got %q, %v", os.Getenv("ENV_LOAD_EXISTING"), err)
+	}
+
+	if err := Load(filepath.Join(dir, "missing.env")); err == nil {
+		t.Error("expected error for missing file")
+	}
+	if err := LoadIfExists(filepath.Join(dir, "missing.env")); err != nil {
+		t.Errorf("LoadIfExists failed: %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.env")
+	os.WriteFile(bad, []byte("GOOD=1\nnot a variable\n"), 0644)
+	if _, err := Read(bad); err == nil || !strings.Contains(err.Error(), "bad.env:2") {
+		t.Errorf("expected error with line number, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package excel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Ext is the extension of written workbooks
+const Ext = ".xlsx"
+
+// ReadOptions configures a Reader
+type ReadOptions struct {
+	// Sheet is the sheet to read, defaulting to the first one
+	Sheet string
+	// HeaderRow is the 1-based row holding the column names (defaults to 1).
+	// Rows above it, e.g. report titles, are skipped.
+	HeaderRow int
+	// Raw returns unformatted cell values, e.g. "45444" instead of "6/1/24"
+	// for dates and "1234.5" instead of "1,234.50"
+	Raw bool
+	// Password opens an encrypted workbook
+	Password string
+}
+
+// Row is a data row of a sheet
+type Row struct {
+	// Line is the 1-based row number in the sheet
+	Line  int
+	Cells []string
+	index map[string]int
+}
+
+// Get returns the value of the named column, or "" if the column is unknown
+// or the row is shorter
+func (r Row) Get(column string) string {
+	i, ok := r.index[column]
+	if !ok || i >= len(r.Cells) {
+		return ""
+	}
+	return r.Cells[i]
+}
+
+// Map returns the row as a column name to value map
+func (r Row) Map() map[string]string {
+	m := make(map[string]string, len(r.index))
+	for name := range r.index {
+		m[name] = r.Get(name)
+	}
+	return m
+}
+
+// Reader iterates over the rows of one sheet without loading it into memory
+type Reader struct {
+	file   *excelize.File
+	rows   *excelize.Rows
+	opts   ReadOptions
+	sheet  string
+	header []string
+	index  map[string]int
+	line   int
+}
+
+// Open opens an xlsx file and reads the header of the sheet. Close releases
+// the file.
+func Open(path string, opts *ReadOptions) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader, err := NewReader(file, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return reader, nil
+}
+
+// NewReader reads an xlsx workbook from r and the header of the sheet. A nil
+// opts uses the defaults.
+func NewReader(r io.Reader, opts *ReadOptions) (*Reader, error) {
+	reader := &Reader{}
+	if opts != nil {
+		reader.opts = *opts
+	}
+	if reader.opts.HeaderRow <= 0 {
+		reader.opts.HeaderRow = 1
+	}
+
+	file, err := excelize.OpenReader(r, excelize.Options{Password: reader.opts.Password})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	reader.file = file
+
+	reader.sheet = reader.opts.Sheet
+	if reader.sheet == "" {
+		reader.sheet = file.GetSheetName(0)
+	}
+	if reader.rows, err = file.Rows(reader.sheet); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read sheet %q: %w", reader.sheet, err)
+	}
+
+	for reader.line < reader.opts.HeaderRow {
+		cells, err := reader.readRow()
+		if err == io.EOF {
+			reader.Close()
+			return nil, fmt.Errorf("sheet %q has no header row", reader.sheet)
+		}
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		reader.header = cells
+	}
+
+	reader.index = make(map[string]int, len(reader.header))
+	for i, name := range reader.header {
+		name = strings.TrimSpace(name)
+		reader.header[i] = name
+		if name == "" {
+			continue
+		}
+		if _, exists := reader.index[name]; exists {
+			reader.Close()
+			return nil, fmt.Errorf("duplicate column %q in header", name)
+		}
+		reader.index[name] = i
+	}
+	return reader, nil
+}
+
+// Sheets returns the names of the sheets in the workbook
+func (r *Reader) Sheets() []string {
+	return r.file.GetSheetList()
+}
+
+// Sheet returns the name of the sheet being read
+func (r *Reader) Sheet() string {
+	return r.sheet
+}
+
+// Header returns the column names
+func (r *Reader) Header() []string {
+	return r.header
+}
+
+// Next returns the next row, skipping empty rows. It returns io.EOF at the
+// end of the sheet.
+func (r *Reader) Next() (Row, error) {
+	for {
+		cells, err := r.readRow()
+		if err != nil {
+			return Row{}, err
+		}
+		if !isEmpty(cells) {
+			return Row{Line: r.line, Cells: cells, index: r.index}, nil
+		}
+	}
+}
+
+// Close releases the workbook
+func (r *Reader) Close() error {
+	var err error
+	if r.rows != nil {
+		err = r.rows.Close()
+		r.rows = nil
+	}
+	if r.file != nil {
+		if closeErr := r.file.Close(); err == nil {
+			err = closeErr
+		}
+		r.file = nil
+	}
+	return err
+}
+
+// readRow returns the cells of the next row in the sheet
+func (r *Reader) readRow() ([]string, error) {
+	if !r.rows.Next() {
+		if err := r.rows.Error(); err != nil {
+			return nil, fmt.Errorf("failed to read row %d: %w", r.line+1, err)
+		}
+		return nil, io.EOF
+	}
+	r.line++
+	cells, err := r.rows.Columns(excelize.Options{RawCellValue: r.opts.Raw})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read row %d: %w", r.line, err)
+	}
+	return cells, nil
+}
+
+// isEmpty reports whether every cell is blank
+func isEmpty(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package excel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "reconciliation"+Ext)
+	paidOn := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	err := WriteFile(path,
+		Sheet{
+			Name: "June 2024",
+			Columns: []Column{
+				{Title: "Account"},
+				{Title: "Paid On", Format: FormatDate},
+				{Title: "Amount", Format: FormatAmount, Total: true},
+				{Title: "Matched", Width: 12},
+			},
+			Rows: [][]any{
+				{"ACME-001", paidOn, 1250.5, true},
+				{"ACME-002", &paidOn, -75, false},
+				{"ACME-003", nil, int64(10), errors.New("missing statement")},
+			},
+		},
+		Sheet{
+			Name:    "Summary",
+			Columns: []Column{{Title: "Metric"}, {Title: "Value"}},
+			Rows:    [][]any{{"Duration", 90 * time.Second}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("expected temporary file to be removed")
+	}
+
+	// Check the layout with excelize directly
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer file.Close()
+	if sheets := file.GetSheetList(); len(sheets) != 2 || sheets[0] != "June 2024" || sheets[1] != "Summary" {
+		t.Errorf("sheets = %v", sheets)
+	}
+	if formula, _ := file.GetCellFormula("June 2024", "C5"); formula != "SUM(C2:C4)" {
+		t.Errorf("totals formula = %q", formula)
+	}
+	if label, _ := file.GetCellValue("June 2024", "A5"); label != "Total" {
+		t.Errorf("totals label = %q", label)
+	}
+	if amount, _ := file.GetCellValue("June 2024", "C2"); amount != "1,250.50" {
+		t.Errorf("formatted amount = %q", amount)
+	}
+	if width, _ := file.GetColWidth("June 2024", "D"); width != 12 {
+		t.Errorf("column D width = %v, expected 12", width)
+	}
+	if width, _ := file.GetColWidth("June 2024", "A"); width != 10 {
+		t.Errorf("column A width = %v, expected fitted 10", width)
+	}
+	if panes, _ := file.GetPanes("June 2024"); !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("expected frozen header, got %+v", panes)
+	}
+
+	reader, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer reader.Close()
+	if reader.Sheet() != "June 2024" || len(reader.Sheets()) != 2 {
+		t.Errorf("Sheet = %q, Sheets = %v", reader.Sheet(), reader.Sheets())
+	}
+	var rows []Row
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 3 rows and totals, got %d", len(rows))
+	}
+	if rows[0].Line != 2 || rows[0].Get("Paid On") != "2024-06-01" || rows[0].Get("Matched") != "TRUE" {
+		t.Errorf("unexpected first row %+v", rows[0])
+	}
+	if m := rows[1].Map(); m["Amount"] != "-75.00" || m["Paid On"] != "2024-06-01" {
+		t.Errorf("unexpected second row %v", m)
+	}
+	if rows[2].Get("Paid On") != "" || rows[2].Get("Matched") != "missing statement" || rows[2].Get("Unknown") != "" {
+		t.Errorf("unexpected third row %+v", rows[2])
+	}
+
+	summary, err := Open(path, &ReadOptions{Sheet: "Summary", Raw: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer summary.Close()
+	if row, err := summary.Next(); err != nil || row.Get("Value") != "1m30s" {
+		t.Errorf("unexpected summary row %+v, %v", row, err)
+	}
+	if _, err := Open(path, &ReadOptions{Sheet: "Missing"}); err == nil {
+		t.Error("expected error for missing sheet")
+	}
+}
+
+func TestReaderOptions(t *testing.T) {
+	file := excelize.NewFile()
+	defer file.Close()
+	file.SetSheetRow("Sheet1", "A1", &[]any{"Monthly export"})
+	file.SetSheetRow("Sheet1", "A3", &[]any{" id ", "amount"})
+	file.SetSheetRow("Sheet1", "A4", &[]any{1, 1234.5})
+	file.SetSheetRow("Sheet1", "A6", &[]any{2, 10})
+	style, _ := file.NewStyle(&excelize.Style{NumFmt: 4})
+	file.SetCellStyle("Sheet1", "B4", "B6", style)
+	var buf bytes.Buffer
+	file.WriteTo(&buf)
+
+	reader, err := NewReader(bytes.NewReader(buf.Bytes()), &ReadOptions{HeaderRow: 3})
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if header := reader.Header(); len(header) != 2 || header[0] != "id" {
+		t.Errorf("header = %q", header)
+	}
+	first, _ := reader.Next()
+	second, _ := reader.Next()
+	if first.Get("amount") != "1,234.50" || second.Line != 6 || second.Get("id") != "2" {
+		t.Errorf("unexpected rows %+v %+v", first, second)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	reader.Close()
+
+	raw, _ := NewReader(bytes.NewReader(buf.Bytes()), &ReadOptions{HeaderRow: 3, Raw: true})
+	defer raw.Close()
+	if row, _ := raw.Next(); row.Get("amount") != "1234.5" {
+		t.Errorf("raw amount = %q", row.Get("amount"))
+	}
+
+	if _, err := NewReader(bytes.NewReader(buf.Bytes()), &ReadOptions{HeaderRow: 10}); err == nil {
+		t.Error("expected error for header row past the end")
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("not a workbook")), nil); err == nil {
+		t.Error("expected error for invalid workbook")
+	}
+}
+
+func TestWorkbookErrors(t *testing.T) {
+	workbook := NewWorkbook()
+	defer workbook.Close()
+	if err := workbook.Write(io.Discard); err == nil {
+		t.Error("expected error for workbook without sheets")
+	}
+	if err := workbook.AddSheet(Sheet{Name: "Empty"}); err == nil {
+		t.Error("expected error for sheet without columns")
+	}
+	if err := workbook.AddSheet(Sheet{Columns: []Column{{Title: "A"}}}); err == nil {
+		t.Error("expected error for sheet without name")
+	}
+	if err := workbook.AddSheet(Sheet{Name: "Data", Columns: []Column{{Title: "A"}}}); err != nil {
+		t.Fatalf("AddSheet failed: %v", err)
+	}
+	if err := workbook.AddSheet(Sheet{Name: "Data", Columns: []Column{{Title: "A"}}}); err == nil {
+		t.Error("expected error for duplicate sheet name")
+	}
+}
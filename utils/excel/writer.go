@@ -0,0 +1,297 @@
+package excel
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+)
+
+// Common number formats for Column.Format
+const (
+	FormatInteger  = "#,##0"
+	FormatAmount   = "#,##0.00"
+	FormatPercent  = "0.00%"
+	FormatDate     = "yyyy-mm-dd"
+	FormatDateTime = "yyyy-mm-dd hh:mm:ss"
+)
+
+// maxColumnWidth caps the fitted width of a column, in characters
+const maxColumnWidth = 60
+
+// Column describes a column of a written sheet
+type Column struct {
+	Title string
+	// Width is the column width in characters; 0 fits the title and values
+	Width float64
+	// Format is an Excel number format such as FormatAmount or "0.0%"
+	Format string
+	// Total adds the SUM of the column to a totals row below the data
+	Total bool
+}
+
+// Sheet is a table written as one worksheet, with a bold header row that is
+// frozen and filterable
+type Sheet struct {
+	Name    string
+	Columns []Column
+	// Rows hold the cell values. Numbers, booleans and time.Time values are
+	// stored as such, nil leaves a cell empty and other values are written
+	// as text.
+	Rows [][]any
+	// TotalsLabel is written in the first column of the totals row
+	// (defaults to "Total")
+	TotalsLabel string
+}
+
+// Workbook builds an xlsx workbook from sheets
+type Workbook struct {
+	file   *excelize.File
+	sheets int
+	styles map[string]int
+}
+
+// NewWorkbook creates an empty workbook. Close releases it.
+func NewWorkbook() *Workbook {
+	return &Workbook{file: excelize.NewFile(), styles: map[string]int{}}
+}
+
+// WriteFile writes the sheets to a new workbook at path
+func WriteFile(path string, sheets ...Sheet) error {
+	workbook := NewWorkbook()
+	defer workbook.Close()
+	for _, sheet := range sheets {
+		if err := workbook.AddSheet(sheet); err != nil {
+			return err
+		}
+	}
+	return workbook.Save(path)
+}
+
+// AddSheet adds a worksheet after the existing ones
+func (w *Workbook) AddSheet(sheet Sheet) error {
+	if sheet.Name == "" {
+		return fmt.Errorf("sheet name cannot be empty")
+	}
+	if len(sheet.Columns) == 0 {
+		return fmt.Errorf("sheet %q has no columns", sheet.Name)
+	}
+	if index, _ := w.file.GetSheetIndex(sheet.Name); w.sheets > 0 && index >= 0 {
+		return fmt.Errorf("sheet %q already exists", sheet.Name)
+	}
+
+	// The first sheet replaces the default "Sheet1" of a new file
+	if w.sheets == 0 {
+		if err := w.file.SetSheetName(w.file.GetSheetName(0), sheet.Name); err != nil {
+			return fmt.Errorf("failed to name sheet %q: %w", sheet.Name, err)
+		}
+	} else if _, err := w.file.NewSheet(sheet.Name); err != nil {
+		return fmt.Errorf("failed to add sheet %q: %w", sheet.Name, err)
+	}
+	w.sheets++
+
+	if err := w.writeSheet(sheet); err != nil {
+		return fmt.Errorf("failed to write sheet %q: %w", sheet.Name, err)
+	}
+	return nil
+}
+
+// writeSheet writes the header, rows, totals and layout of sheet
+func (w *Workbook) writeSheet(sheet Sheet) error {
+	name := sheet.Name
+	lastCol, _ := excelize.ColumnNumberToName(len(sheet.Columns))
+	lastRow := len(sheet.Rows) + 1
+
+	header := make([]any, len(sheet.Columns))
+	widths := make([]float64, len(sheet.Columns))
+	for i, column := range sheet.Columns {
+		header[i] = column.Title
+		widths[i] = float64(utf8.RuneCountInString(column.Title))
+	}
+	if err := w.file.SetSheetRow(name, "A1", &header); err != nil {
+		return err
+	}
+	headerStyle, err := w.style("header", "", true)
+	if err != nil {
+		return err
+	}
+	if err := w.file.SetCellStyle(name, "A1", lastCol+"1", headerStyle); err != nil {
+		return err
+	}
+
+	for r, values := range sheet.Rows {
+		row := make([]any, len(values))
+		for i, value := range values {
+			row[i] = cellValue(value)
+			if i < len(widths) {
+				widths[i] = max(widths[i], valueWidth(row[i], sheet.Columns[i].Format))
+			}
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, r+2)
+		if err := w.file.SetSheetRow(name, cell, &row); err != nil {
+			return err
+		}
+	}
+
+	hasTotals := false
+	for _, column := range sheet.Columns {
+		hasTotals = hasTotals || column.Total
+	}
+	totalsRow := lastRow + 1
+	if hasTotals {
+		label := sheet.TotalsLabel
+		if label == "" {
+			label = "Total"
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, totalsRow)
+		if err := w.file.SetCellValue(name, cell, label); err != nil {
+			return err
+		}
+		totalsStyle, err := w.style("totals", "", true)
+		if err != nil {
+			return err
+		}
+		if err := w.file.SetCellStyle(name, cell, fmt.Sprintf("%s%d", lastCol, totalsRow), totalsStyle); err != nil {
+			return err
+		}
+	}
+
+	for i, column := range sheet.Columns {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if column.Format != "" && lastRow > 1 {
+			style, err := w.style("format:"+column.Format, column.Format, false)
+			if err != nil {
+				return err
+			}
+			if err := w.file.SetCellStyle(name, col+"2", fmt.Sprintf("%s%d", col, lastRow), style); err != nil {
+				return err
+			}
+		}
+		if column.Total {
+			cell := fmt.Sprintf("%s%d", col, totalsRow)
+			if err := w.file.SetCellFormula(name, cell, fmt.Sprintf("SUM(%s2:%s%d)", col, col, lastRow)); err != nil {
+				return err
+			}
+			style, err := w.style("totals:"+column.Format, column.Format, true)
+			if err != nil {
+				return err
+			}
+			if err := w.file.SetCellStyle(name, cell, cell, style); err != nil {
+				return err
+			}
+		}
+
+		width := column.Width
+		if width == 0 {
+			width = min(widths[i]+2, maxColumnWidth)
+		}
+		if err := w.file.SetColWidth(name, col, col, width); err != nil {
+			return err
+		}
+	}
+
+	if err := w.file.SetPanes(name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	return w.file.AutoFilter(name, fmt.Sprintf("A1:%s%d", lastCol, lastRow), nil)
+}
+
+// style returns the style registered under key, creating it on first use
+func (w *Workbook) style(key, format string, bold bool) (int, error) {
+	if id, ok := w.styles[key]; ok {
+		return id, nil
+	}
+	style := &excelize.Style{}
+	if bold {
+		style.Font = &excelize.Font{Bold: true}
+	}
+	if key == "header" {
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D9E1F2"}}
+		style.Border = []excelize.Border{{Type: "bottom", Color: "#000000", Style: 1}}
+	}
+	if format != "" {
+		style.CustomNumFmt = &format
+	}
+	id, err := w.file.NewStyle(style)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create style: %w", err)
+	}
+	w.styles[key] = id
+	return id, nil
+}
+
+// Write writes the workbook to out
+func (w *Workbook) Write(out io.Writer) error {
+	if w.sheets == 0 {
+		return fmt.Errorf("workbook has no sheets")
+	}
+	if _, err := w.file.WriteTo(out); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
+
+// Save writes the workbook to path, creating its directory. The file is
+// written to a temporary file first, so readers never see a partial workbook.
+func (w *Workbook) Save(path string) error {
+	file, err := atomicfile.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if err := w.Write(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Close releases the workbook
+func (w *Workbook) Close() error {
+	return w.file.Close()
+}
+
+// cellValue converts value into a type excelize writes natively
+func cellValue(value any) any {
+	switch v := value.(type) {
+	case nil, string, bool, time.Time,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(value)
+}
+
+// valueWidth estimates the displayed width of a cell value in characters
+func valueWidth(value any, format string) float64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return float64(utf8.RuneCountInString(v))
+	case time.Time:
+		if format != "" {
+			return float64(len(format))
+		}
+		return float64(len(FormatDateTime))
+	}
+	width := float64(len(fmt.Sprint(value)))
+	if format != "" {
+		// Allow for thousands separators and decimals added by the format
+		width += width/3 + 3
+	}
+	return width
+}
@@ -0,0 +1,567 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package ftphelper
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSMode selects how the connection is secured
+type TLSMode int
+
+const (
+	// TLSNone uses plain FTP
+	TLSNone TLSMode = iota
+	// TLSExplicit upgrades the control connection with AUTH TLS (FTPES, usually port 21)
+	TLSExplicit
+	// TLSImplicit starts TLS immediately on connect (FTPS, usually port 990)
+	TLSImplicit
+)
+
+// FTPHelper holds the configuration for FTP and FTPS operations. The control
+// connection is opened on first use and reused until Close is called.
+type FTPHelper struct {
+	Host string
+	Port int // defaults to 21, or 990 with TLSImplicit
+	// User and Password default to anonymous login when User is empty
+	User     string
+	Password string
+
+	TLSMode TLSMode
+	// TLSConfig customizes the TLS client (CAs, client certificates). ServerName
+	// defaults to Host and session resumption is enabled for data connections.
+	TLSConfig *tls.Config
+	// InsecureSkipVerify disables certificate verification for this helper only
+	InsecureSkipVerify bool
+
+	// ActiveMode makes the server connect back to the client (PORT/EPRT)
+	// instead of the default passive mode (EPSV/PASV)
+	ActiveMode bool
+	// ActiveAddr is the local IP advertised in active mode
+	// (defaults to the control connection's local address)
+	ActiveAddr string
+
+	// Timeout bounds connects and each transfer's idle time (defaults to 30 seconds)
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	conn      net.Conn
+	text      *textproto.Conn
+	tlsConfig *tls.Config
+	noEPSV    bool
+}
+
+// UploadFile uploads a local file to the specified remote path, creating parent directories
+func (u *FTPHelper) UploadFile(filePath, remotePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %v", filePath, err)
+	}
+	defer file.Close()
+
+	remotePath = cleanPath(remotePath)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.connect(); err != nil {
+		return err
+	}
+
+	if dir := path.Dir(remotePath); dir != "." && dir != "/" {
+		if err := u.mkdirAll(dir); err != nil {
+			return err
+		}
+	}
+
+	if err := u.transfer("STOR "+remotePath, func(data net.Conn) error {
+		_, err := io.Copy(data, file)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to upload file to %q: %v", remotePath, err)
+	}
+
+	log.Printf("Successfully uploaded %q to ftp://%s%s", filePath, u.Host, remotePath)
+	return nil
+}
+
+// DownloadFile downloads a remote file to the local filesystem
+func (u *FTPHelper) DownloadFile(remotePath, localPath string) error {
+	// Create the directory for the local file if it doesn't exist
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %v", dir, err)
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file %q: %v", localPath, err)
+	}
+	defer file.Close()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.connect(); err != nil {
+		return err
+	}
+
+	if err := u.transfer("RETR "+cleanPath(remotePath), func(data net.Conn) error {
+		_, err := io.Copy(file, data)
+		return err
+	}); err != nil {
+		file.Close()
+		os.Remove(localPath)
+		return fmt.Errorf("failed to download file %q: %v", remotePath, err)
+	}
+
+	log.Printf("Successfully downloaded ftp://%s%s to %s", u.Host, remotePath, localPath)
+	return nil
+}
+
+// ListFiles lists the files directly inside the remote directory. Servers that
+// support MLSD report only regular files; older servers fall back to NLST,
+// which may include subdirectory names.
+func (u *FTPHelper) ListFiles(remoteDir string) ([]string, error) {
+	remoteDir = cleanPath(remoteDir)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.connect(); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	readLines := func(data net.Conn) error {
+		scanner := bufio.NewScanner(data)
+		for scanner.Scan() {
+			if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return scanner.Err()
+	}
+
+	var files []string
+	err := u.transfer("MLSD "+remoteDir, readLines)
+	if isNotImplemented(err) {
+		lines = nil
+		if err = u.transfer("NLST "+remoteDir, readLines); err == nil {
+			for _, line := range lines {
+				files = append(files, path.Join(remoteDir, path.Base(line)))
+			}
+		}
+	} else if err == nil {
+		for _, line := range lines {
+			if name, ok := parseMLSDFile(line); ok {
+				files = append(files, path.Join(remoteDir, name))
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %q: %v", remoteDir, err)
+	}
+	return files, nil
+}
+
+// DeleteFile deletes a remote file
+func (u *FTPHelper) DeleteFile(remotePath string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.connect(); err != nil {
+		return err
+	}
+
+	if _, err := u.cmd(2, "DELE %s", cleanPath(remotePath)); err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", remotePath, err)
+	}
+
+	log.Printf("Successfully deleted ftp://%s%s", u.Host, remotePath)
+	return nil
+}
+
+// MakeDir creates a remote directory and any missing parents
+func (u *FTPHelper) MakeDir(remoteDir string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.connect(); err != nil {
+		return err
+	}
+	return u.mkdirAll(cleanPath(remoteDir))
+}
+
+// Close sends QUIT and closes the control connection. The helper reconnects on next use.
+func (u *FTPHelper) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.text == nil {
+		return nil
+	}
+	u.cmd(2, "QUIT")
+	return u.closeLocked()
+}
+
+// closeLocked closes the control connection; the caller must hold u.mu
+func (u *FTPHelper) closeLocked() error {
+	err := u.text.Close()
+	u.text = nil
+	u.conn = nil
+	return err
+}
+
+// connect opens and authenticates the control connection, reusing a live one;
+// the caller must hold u.mu
+func (u *FTPHelper) connect() error {
+	if u.text != nil {
+		if _, err := u.cmd(2, "NOOP"); err == nil {
+			return nil
+		}
+		log.Printf("FTP connection to %s lost, reconnecting", u.Host)
+		u.closeLocked()
+	}
+
+	port := u.Port
+	if port == 0 {
+		port = 21
+		if u.TLSMode == TLSImplicit {
+			port = 990
+		}
+	}
+	addr := net.JoinHostPort(u.Host, strconv.Itoa(port))
+
+	u.tlsConfig = u.newTLSConfig()
+	dialer := &net.Dialer{Timeout: u.timeout()}
+	var conn net.Conn
+	var err error
+	if u.TLSMode == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, u.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	u.conn = conn
+	u.text = textproto.NewConn(conn)
+
+	if err := u.login(); err != nil {
+		u.closeLocked()
+		return fmt.Errorf("failed to log in to %s: %v", addr, err)
+	}
+	return nil
+}
+
+// login reads the greeting, negotiates TLS and authenticates
+func (u *FTPHelper) login() error {
+	u.conn.SetDeadline(time.Now().Add(u.timeout()))
+	defer u.conn.SetDeadline(time.Time{})
+
+	if _, _, err := u.text.ReadResponse(2); err != nil {
+		return err
+	}
+
+	if u.TLSMode == TLSExplicit {
+		if _, err := u.cmd(2, "AUTH TLS"); err != nil {
+			return err
+		}
+		tlsConn := tls.Client(u.conn, u.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return fmt.Errorf("TLS handshake failed: %v", err)
+		}
+		u.conn = tlsConn
+		u.text = textproto.NewConn(tlsConn)
+	}
+
+	user, password := u.User, u.Password
+	if user == "" {
+		user, password = "anonymous", "anonymous@"
+	}
+	code, err := u.cmd(0, "USER %s", user)
+	if err != nil {
+		return err
+	}
+	switch {
+	case code == 331:
+		if _, err := u.cmd(230, "PASS %s", password); err != nil {
+			return err
+		}
+	case code != 230:
+		return fmt.Errorf("unexpected response to USER: %d", code)
+	}
+
+	if u.TLSMode != TLSNone {
+		// Protect the data channel as well as the control channel
+		if _, err := u.cmd(2, "PBSZ 0"); err != nil {
+			return err
+		}
+		if _, err := u.cmd(2, "PROT P"); err != nil {
+			return err
+		}
+	}
+
+	_, err = u.cmd(2, "TYPE I")
+	return err
+}
+
+// newTLSConfig returns the client TLS configuration with session resumption enabled
+func (u *FTPHelper) newTLSConfig() *tls.Config {
+	config := &tls.Config{}
+	if u.TLSConfig != nil {
+		config = u.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = u.Host
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	if u.InsecureSkipVerify {
+		config.InsecureSkipVerify = true
+	}
+	// Many servers require data connections to resume the control session
+	if config.ClientSessionCache == nil {
+		config.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	return config
+}
+
+// cmd sends a command and reads the reply. expect is a full code (230) or a
+// class (2 for any 2xx); 0 accepts any reply below 400.
+func (u *FTPHelper) cmd(expect int, format string, args ...any) (int, error) {
+	if err := u.text.PrintfLine(format, args...); err != nil {
+		return 0, err
+	}
+	code, msg, err := u.text.ReadResponse(expect)
+	if err == nil && expect == 0 && code >= 400 {
+		err = &textproto.Error{Code: code, Msg: msg}
+	}
+	return code, err
+}
+
+// transfer opens a data connection, sends command, runs fn against the data
+// connection and waits for the transfer to complete
+func (u *FTPHelper) transfer(command string, fn func(data net.Conn) error) error {
+	var data net.Conn
+	var err error
+	if u.ActiveMode {
+		data, err = u.activeData(command)
+	} else {
+		data, err = u.passiveData(command)
+	}
+	if err != nil {
+		return err
+	}
+
+	if u.TLSMode != TLSNone {
+		data = tls.Client(data, u.tlsConfig)
+	}
+
+	data.SetDeadline(time.Now().Add(u.timeout()))
+	fnErr := fn(data)
+	closeErr := data.Close()
+
+	// The server confirms the transfer with 226 once the data connection closes
+	if _, _, err := u.text.ReadResponse(2); err != nil {
+		return err
+	}
+	if fnErr != nil {
+		return fnErr
+	}
+	return closeErr
+}
+
+// passiveData asks the server for a data port (EPSV, falling back to PASV)
+// and connects to it before sending command
+func (u *FTPHelper) passiveData(command string) (net.Conn, error) {
+	host, _, _ := net.SplitHostPort(u.conn.RemoteAddr().String())
+
+	var port int
+	if !u.noEPSV {
+		if err := u.text.PrintfLine("EPSV"); err != nil {
+			return nil, err
+		}
+		code, msg, err := u.text.ReadResponse(2)
+		switch {
+		case err == nil:
+			port, err = parseEPSV(msg)
+			if err != nil {
+				return nil, err
+			}
+		case code >= 500:
+			u.noEPSV = true
+		default:
+			return nil, err
+		}
+	}
+	if port == 0 {
+		if err := u.text.PrintfLine("PASV"); err != nil {
+			return nil, err
+		}
+		_, msg, err := u.text.ReadResponse(227)
+		if err != nil {
+			return nil, err
+		}
+		// The advertised IP is ignored; NATed servers commonly report a private address
+		if port, err = parsePASV(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), u.timeout())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data connection: %v", err)
+	}
+	if err := u.startTransfer(command); err != nil {
+		data.Close()
+		return nil, err
+	}
+	return data, nil
+}
+
+// activeData listens for the server's data connection (PORT/EPRT), sends
+// command and accepts the connection
+func (u *FTPHelper) activeData(command string) (net.Conn, error) {
+	localIP := u.ActiveAddr
+	if localIP == "" {
+		localIP, _, _ = net.SplitHostPort(u.conn.LocalAddr().String())
+	}
+	ip := net.ParseIP(localIP)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid active mode address %q", localIP)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(localIP, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for data connection: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if ip4 := ip.To4(); ip4 != nil {
+		_, err = u.cmd(2, "PORT %d,%d,%d,%d,%d,%d", ip4[0], ip4[1], ip4[2], ip4[3], port>>8, port&0xff)
+	} else {
+		_, err = u.cmd(2, "EPRT |2|%s|%d|", ip.String(), port)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := u.startTransfer(command); err != nil {
+		return nil, err
+	}
+
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(u.timeout()))
+	data, err := listener.Accept()
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept data connection: %v", err)
+	}
+	return data, nil
+}
+
+// startTransfer sends a transfer command and waits for the 1xx preliminary reply
+func (u *FTPHelper) startTransfer(command string) error {
+	if err := u.text.PrintfLine("%s", command); err != nil {
+		return err
+	}
+	_, _, err := u.text.ReadResponse(1)
+	return err
+}
+
+// mkdirAll creates each component of dir, ignoring directories that already exist
+func (u *FTPHelper) mkdirAll(dir string) error {
+	current := ""
+	if strings.HasPrefix(dir, "/") {
+		current = "/"
+	}
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		code, err := u.cmd(0, "MKD %s", current)
+		// 550 is returned both for existing directories and real failures;
+		// a real failure surfaces on the following STOR
+		if err != nil && code != 550 {
+			return fmt.Errorf("failed to create directory %q: %v", current, err)
+		}
+	}
+	return nil
+}
+
+// timeout returns the configured timeout or the default
+func (u *FTPHelper) timeout() time.Duration {
+	if u.Timeout == 0 {
+		return 30 * time.Second
+	}
+	return u.Timeout
+}
+
+// parsePASV extracts the port from a "227 Entering Passive Mode (h1,h2,h3,h4,p1,p2)" reply
+func parsePASV(msg string) (int, error) {
+	start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("invalid PASV response %q", msg)
+	}
+	parts := strings.Split(msg[start+1:end], ",")
+	if len(parts) != 6 {
+		return 0, fmt.Errorf("invalid PASV response %q", msg)
+	}
+	p1, err1 := strconv.Atoi(strings.TrimSpace(parts[4]))
+	p2, err2 := strconv.Atoi(strings.TrimSpace(parts[5]))
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("invalid PASV response %q", msg)
+	}
+	return p1<<8 | p2, nil
+}
+
+// parseEPSV extracts the port from a "229 Entering Extended Passive Mode (|||port|)" reply
+func parseEPSV(msg string) (int, error) {
+	start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("invalid EPSV response %q", msg)
+	}
+	fields := strings.Split(msg[start+1:end], "|")
+	if len(fields) != 5 {
+		return 0, fmt.Errorf("invalid EPSV response %q", msg)
+	}
+	port, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return 0, fmt.Errorf("invalid EPSV response %q", msg)
+	}
+	return port, nil
+}
+
+// parseMLSDFile returns the name from an MLSD fact line when it describes a regular file
+func parseMLSDFile(line string) (string, bool) {
+	facts, name, ok := strings.Cut(line, " ")
+	if !ok {
+		return "", false
+	}
+	for _, fact := range strings.Split(facts, ";") {
+		key, value, _ := strings.Cut(fact, "=")
+		if strings.EqualFold(key, "type") {
+			return name, strings.EqualFold(value, "file")
+		}
+	}
+	return "", false
+}
+
+// isNotImplemented reports whether err is a 500/502 "command not implemented" reply
+func isNotImplemented(err error) bool {
+	protoErr, ok := err.(*textproto.Error)
+	return ok && (protoErr.Code == 500 || protoErr.Code == 502)
+}
+
+// cleanPath normalizes a remote path to a slash-separated path
+func cleanPath(remotePath string) string {
+	return path.Clean(filepath.ToSlash(remotePath))
+}
@@ -0,0 +1,433 @@
+package ftphelper
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFTP is a minimal in-memory FTP server supporting passive and active
+// transfers, explicit and implicit TLS, and optional MLSD/EPSV
+type fakeFTP struct {
+	listener net.Listener
+	tls      *tls.Config
+	implicit bool
+	noMLSD   bool
+	noEPSV   bool
+
+	mu    sync.Mutex
+	files map[string][]byte
+	dirs  map[string]bool
+	conns int
+}
+
+func newFakeFTP(t *testing.T, implicit bool) *fakeFTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeFTP{
+		listener: listener,
+		tls:      &tls.Config{Certificates: []tls.Certificate{selfSigned(t)}},
+		implicit: implicit,
+		files:    make(map[string][]byte),
+		dirs:     map[string]bool{"/": true},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			f.mu.Unlock()
+			if implicit {
+				conn = tls.Server(conn, f.tls)
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func selfSigned(t *testing.T) tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (f *fakeFTP) helper() *FTPHelper {
+	return &FTPHelper{
+		Host:               "127.0.0.1",
+		Port:               f.listener.Addr().(*net.TCPAddr).Port,
+		User:               "partner",
+		Password:           "s3cret",
+		InsecureSkipVerify: true,
+		Timeout:            5 * time.Second,
+	}
+}
+
+func (f *fakeFTP) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(format string, args ...any) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var passive net.Listener
+	var activeAddr string
+	protected := f.implicit
+	openData := func() (net.Conn, error) {
+		var data net.Conn
+		var err error
+		if passive != nil {
+			data, err = passive.Accept()
+			passive.Close()
+			passive = nil
+		} else if activeAddr != "" {
+			data, err = net.Dial("tcp", activeAddr)
+			activeAddr = ""
+		} else {
+			return nil, fmt.Errorf("no data connection")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if protected {
+			data = tls.Server(data, f.tls)
+		}
+		return data, nil
+	}
+
+	reply("220 fake ftp ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+
+		switch strings.ToUpper(command) {
+		case "AUTH":
+			reply("234 AUTH TLS ok")
+			tlsConn := tls.Server(conn, f.tls)
+			conn, reader = tlsConn, bufio.NewReader(tlsConn)
+		case "USER":
+			reply("331 password required")
+		case "PASS":
+			if arg != "s3cret" {
+				reply("530 login incorrect")
+				continue
+			}
+			reply("230 logged in")
+		case "PBSZ", "TYPE", "NOOP":
+			reply("200 ok")
+		case "PROT":
+			protected = arg == "P"
+			reply("200 ok")
+		case "EPSV", "PASV":
+			if command == "EPSV" && f.noEPSV {
+				reply("502 not implemented")
+				continue
+			}
+			passive, _ = net.Listen("tcp", "127.0.0.1:0")
+			port := passive.Addr().(*net.TCPAddr).Port
+			if command == "EPSV" {
+				reply("229 Entering Extended Passive Mode (|||%d|)", port)
+			} else {
+				reply("227 Entering Passive Mode (10,0,0,1,%d,%d)", port>>8, port&0xff)
+			}
+		case "PORT":
+			parts := strings.Split(arg, ",")
+			p1, _ := strconv.Atoi(parts[4])
+			p2, _ := strconv.Atoi(parts[5])
+			activeAddr = net.JoinHostPort(strings.Join(parts[:4], "."), strconv.Itoa(p1<<8|p2))
+			reply("200 PORT ok")
+		case "STOR":
+			reply("150 opening data connection")
+			data, err := openData()
+			if err != nil {
+				reply("425 %v", err)
+				continue
+			}
+			content, _ := io.ReadAll(data)
+			data.Close()
+			f.mu.Lock()
+			f.files[arg] = content
+			f.mu.Unlock()
+			reply("226 transfer complete")
+		case "RETR":
+			f.mu.Lock()
+			content, ok := f.files[arg]
+			f.mu.Unlock()
+			if !ok {
+				reply("550 file not found")
+				continue
+			}
+			reply("150 opening data connection")
+			data, err := openData()
+			if err != nil {
+				reply("425 %v", err)
+				continue
+			}
+			data.Write(content)
+			data.Close()
+			reply("226 transfer complete")
+		case "MLSD", "NLST":
+			if command == "MLSD" && f.noMLSD {
+				reply("500 unknown command")
+				continue
+			}
+			reply("150 opening data connection")
+			data, err := openData()
+			if err != nil {
+				reply("425 %v", err)
+				continue
+			}
+			for _, entry := range f.list(arg) {
+				if command == "MLSD" {
+					kind := "file"
+					if f.dirs[path.Join(arg, entry)] {
+						kind = "dir"
+					}
+					fmt.Fprintf(data, "type=%s;size=1; %s\r\n", kind, entry)
+				} else {
+					fmt.Fprintf(data, "%s\r\n", path.Join(arg, entry))
+				}
+			}
+			data.Close()
+			reply("226 transfer complete")
+		case "DELE":
+			f.mu.Lock()
+			_, ok := f.files[arg]
+			delete(f.files, arg)
+			f.mu.Unlock()
+			if !ok {
+				reply("550 file not found")
+				continue
+			}
+			reply("250 deleted")
+		case "MKD":
+			f.mu.Lock()
+			exists := f.dirs[arg]
+			f.dirs[arg] = true
+			f.mu.Unlock()
+			if exists {
+				reply("550 directory exists")
+				continue
+			}
+			reply("257 created")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// list returns the names of files and directories directly inside dir
+func (f *fakeFTP) list(dir string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range f.files {
+		if path.Dir(name) == dir {
+			names = append(names, path.Base(name))
+		}
+	}
+	for name := range f.dirs {
+		if name != dir && path.Dir(name) == dir {
+			names = append(names, path.Base(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestTransferModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		implicit bool
+		mode     TLSMode
+		active   bool
+		noEPSV   bool
+	}{
+		{"plain passive", false, TLSNone, false, false},
+		{"plain passive PASV fallback", false, TLSNone, false, true},
+		{"plain active", false, TLSNone, true, false},
+		{"explicit TLS passive", false, TLSExplicit, false, false},
+		{"explicit TLS active", false, TLSExplicit, true, false},
+		{"implicit TLS passive", true, TLSImplicit, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeFTP(t, tt.implicit)
+			server.noEPSV = tt.noEPSV
+			helper := server.helper()
+			helper.TLSMode = tt.mode
+			helper.ActiveMode = tt.active
+			defer helper.Close()
+
+			localDir := t.TempDir()
+			localFile := filepath.Join(localDir, "report.csv")
+			os.WriteFile(localFile, []byte("id,name\n1,alpha\n"), 0644)
+
+			if err := helper.UploadFile(localFile, "/outbound/2024/report.csv"); err != nil {
+				t.Fatalf("UploadFile failed: %v", err)
+			}
+			if !server.dirs["/outbound/2024"] {
+				t.Error("parent directories were not created")
+			}
+
+			files, err := helper.ListFiles("/outbound/2024")
+			if err != nil {
+				t.Fatalf("ListFiles failed: %v", err)
+			}
+			if len(files) != 1 || files[0] != "/outbound/2024/report.csv" {
+				t.Errorf("ListFiles = %v", files)
+			}
+
+			downloaded := filepath.Join(localDir, "copy", "report.csv")
+			if err := helper.DownloadFile("/outbound/2024/report.csv", downloaded); err != nil {
+				t.Fatalf("DownloadFile failed: %v", err)
+			}
+			content, _ := os.ReadFile(downloaded)
+			if string(content) != "id,name\n1,alpha\n" {
+				t.Errorf("downloaded content = %q", content)
+			}
+
+			if err := helper.DeleteFile("/outbound/2024/report.csv"); err != nil {
+				t.Fatalf("DeleteFile failed: %v", err)
+			}
+			if server.conns != 1 {
+				t.Errorf("expected 1 control connection, got %d", server.conns)
+			}
+		})
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	for _, noMLSD := range []bool{false, true} {
+		server := newFakeFTP(t, false)
+		server.noMLSD = noMLSD
+		server.files["/in/a.csv"] = []byte("a")
+		server.files["/in/b.csv"] = []byte("b")
+		server.dirs["/in"] = true
+		server.dirs["/in/archive"] = true
+
+		helper := server.helper()
+		files, err := helper.ListFiles("/in")
+		helper.Close()
+		if err != nil {
+			t.Fatalf("ListFiles failed: %v", err)
+		}
+
+		expected := "/in/a.csv,/in/b.csv"
+		if noMLSD {
+			// NLST cannot distinguish directories
+			expected = "/in/a.csv,/in/archive,/in/b.csv"
+		}
+		sort.Strings(files)
+		if got := strings.Join(files, ","); got != expected {
+			t.Errorf("noMLSD=%v: ListFiles = %s, expected %s", noMLSD, got, expected)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	server := newFakeFTP(t, false)
+
+	helper := server.helper()
+	helper.Password = "wrong"
+	if err := helper.MakeDir("/x"); err == nil || !strings.Contains(err.Error(), "failed to log in") {
+		t.Errorf("expected login error, got %v", err)
+	}
+
+	helper = server.helper()
+	defer helper.Close()
+	if err := helper.DownloadFile("/missing.csv", filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("expected error downloading missing file")
+	}
+	if err := helper.DeleteFile("/missing.csv"); err == nil {
+		t.Error("expected error deleting missing file")
+	}
+	// The connection stays usable after failed commands
+	if err := helper.MakeDir("/a/b"); err != nil {
+		t.Errorf("MakeDir failed: %v", err)
+	}
+	if !server.dirs["/a/b"] {
+		t.Error("MakeDir did not create nested directories")
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	server := newFakeFTP(t, false)
+	helper := server.helper()
+	defer helper.Close()
+
+	if err := helper.MakeDir("/a"); err != nil {
+		t.Fatalf("MakeDir failed: %v", err)
+	}
+	// Simulate the server dropping an idle connection
+	helper.mu.Lock()
+	helper.conn.Close()
+	helper.mu.Unlock()
+
+	if err := helper.MakeDir("/b"); err != nil {
+		t.Fatalf("MakeDir after drop failed: %v", err)
+	}
+	if server.conns != 2 {
+		t.Errorf("expected 2 control connections, got %d", server.conns)
+	}
+}
+
+func TestParseReplies(t *testing.T) {
+	if port, err := parsePASV("Entering Passive Mode (192,168,1,10,195,80)."); err != nil || port != 50000 {
+		t.Errorf("parsePASV = %d, %v", port, err)
+	}
+	if port, err := parseEPSV("Entering Extended Passive Mode (|||50000|)"); err != nil || port != 50000 {
+		t.Errorf("parseEPSV = %d, %v", port, err)
+	}
+	if _, err := parsePASV("garbage"); err == nil {
+		t.Error("expected error for invalid PASV reply")
+	}
+	if name, ok := parseMLSDFile("Type=file;Size=10;Modify=20240101000000; report.csv"); !ok || name != "report.csv" {
+		t.Errorf("parseMLSDFile = %q, %v", name, ok)
+	}
+	if _, ok := parseMLSDFile("type=cdir; ."); ok {
+		t.Error("directories should not be reported as files")
+	}
+}
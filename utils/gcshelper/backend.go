@@ -0,0 +1,135 @@
+package gcshelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+var _ storage.Backend = (*GCSHelper)(nil)
+
+// Put streams r to the object at key. The content type is detected from the
+// key's extension.
+func (g *GCSHelper) Put(ctx context.Context, key string, r io.Reader) error {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	key = cleanKey(key)
+	writer := client.Bucket(g.BucketName).Object(key).NewWriter(ctx)
+	writer.ContentType = g.detectContentType(key)
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to upload %q to GCS: %v", key, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to upload %q to GCS: %v", key, err)
+	}
+	return nil
+}
+
+// Get opens the object at key for reading
+func (g *GCSHelper) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key = cleanKey(key)
+	reader, err := client.Bucket(g.BucketName).Object(key).NewReader(ctx)
+	if err != nil {
+		client.Close()
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return nil, storage.NotFound(key)
+		}
+		return nil, fmt.Errorf("failed to get object %q from GCS: %v", key, err)
+	}
+	return &objectReader{Reader: reader, client: client}, nil
+}
+
+// objectReader closes the client together with the object reader
+type objectReader struct {
+	*gcs.Reader
+	client *gcs.Client
+}
+
+func (r *objectReader) Close() error {
+	err := r.Reader.Close()
+	r.client.Close()
+	return err
+}
+
+// List returns the objects whose key starts with prefix
+func (g *GCSHelper) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	// GCS lists objects in lexicographic order
+	var objects []storage.ObjectInfo
+	it := client.Bucket(g.BucketName).Objects(ctx, &gcs.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+		objects = append(objects, objectInfo(attrs))
+	}
+	return objects, nil
+}
+
+// Delete removes the object at key
+func (g *GCSHelper) Delete(ctx context.Context, key string) error {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	key = cleanKey(key)
+	err = client.Bucket(g.BucketName).Object(key).Delete(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return storage.NotFound(key)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", key, err)
+	}
+	return nil
+}
+
+// Stat returns the size, modification time and ETag of the object at key
+func (g *GCSHelper) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	defer client.Close()
+
+	key = cleanKey(key)
+	attrs, err := client.Bucket(g.BucketName).Object(key).Attrs(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return storage.ObjectInfo{}, storage.NotFound(key)
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("failed to stat object %q: %v", key, err)
+	}
+	return objectInfo(attrs), nil
+}
+
+// objectInfo converts GCS object attributes to a storage.ObjectInfo
+func objectInfo(attrs *gcs.ObjectAttrs) storage.ObjectInfo {
+	return storage.ObjectInfo{Key: attrs.Name, Size: attrs.Size, ModTime: attrs.Updated, ETag: attrs.Etag}
+}
@@ -0,0 +1,235 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package gcshelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCSHelper holds the configuration for Google Cloud Storage operations
+type GCSHelper struct {
+	BucketName string
+	// CredentialsFile is a service account key file. When empty, Application
+	// Default Credentials are used (GOOGLE_APPLICATION_CREDENTIALS, gcloud
+	// login or the metadata server).
+	CredentialsFile string
+	// EndpointURL overrides the JSON API endpoint, e.g.
+	// "http://localhost:4443/storage/v1/" for fake-gcs-server
+	EndpointURL string
+	// Anonymous sends unauthenticated requests, for emulators and public buckets
+	Anonymous bool
+	// ContentTypes overrides the detected content type by file extension (e.g. ".dat": "text/csv").
+	// Keys are matched case-insensitively, with or without the leading dot.
+	ContentTypes map[string]string
+}
+
+// UploadOptions holds optional object metadata applied to an upload
+type UploadOptions struct {
+	ContentType        string            // explicit content type, overriding detection
+	CacheControl       string            // e.g. "public, max-age=86400"
+	ContentDisposition string            // e.g. `attachment; filename="report.csv"`
+	ContentLanguage    string            // e.g. "en-US"
+	Metadata           map[string]string // custom object metadata
+}
+
+// newClient creates a storage client from the helper configuration
+func (g *GCSHelper) newClient(ctx context.Context) (*storage.Client, error) {
+	if g.BucketName == "" {
+		return nil, fmt.Errorf("BucketName cannot be empty")
+	}
+
+	var opts []option.ClientOption
+	if g.EndpointURL != "" {
+		opts = append(opts, option.WithEndpoint(g.EndpointURL))
+	}
+	switch {
+	case g.Anonymous:
+		opts = append(opts, option.WithoutAuthentication())
+	case g.CredentialsFile != "":
+		opts = append(opts, option.WithAuthCredentialsFile(option.ServiceAccount, g.CredentialsFile))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %v", err)
+	}
+	return client, nil
+}
+
+// UploadFile uploads a local file to the specified GCS path
+func (g *GCSHelper) UploadFile(filePath, gcsPath string) error {
+	return g.UploadFileWithOptions(filePath, gcsPath, nil)
+}
+
+// UploadFileWithOptions uploads a local file to the specified GCS path with
+// additional object metadata such as Cache-Control and Content-Disposition
+func (g *GCSHelper) UploadFileWithOptions(filePath, gcsPath string, opts *UploadOptions) error {
+	ctx := context.Background()
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %v", filePath, err)
+	}
+	defer file.Close()
+
+	gcsPath = cleanKey(gcsPath)
+
+	writer := client.Bucket(g.BucketName).Object(gcsPath).NewWriter(ctx)
+	writer.ContentType = g.detectContentType(filePath)
+	if opts != nil {
+		if opts.ContentType != "" {
+			writer.ContentType = opts.ContentType
+		}
+		writer.CacheControl = opts.CacheControl
+		writer.ContentDisposition = opts.ContentDisposition
+		writer.ContentLanguage = opts.ContentLanguage
+		writer.Metadata = opts.Metadata
+	}
+
+	if _, err := io.Copy(writer, file); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to upload file to GCS: %v", err)
+	}
+	// The object is only committed when the writer is closed
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to upload file to GCS: %v", err)
+	}
+
+	log.Printf("Successfully uploaded %q to gs://%s/%s", filePath, g.BucketName, gcsPath)
+	return nil
+}
+
+// detectContentType determines the content type of a file from its extension,
+// preferring the helper's ContentTypes overrides over the system MIME table
+func (g *GCSHelper) detectContentType(filePath string) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	for key, contentType := range g.ContentTypes {
+		key = strings.ToLower(key)
+		if !strings.HasPrefix(key, ".") {
+			key = "." + key
+		}
+		if key == ext {
+			return contentType
+		}
+	}
+
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" {
+		contentType = "application/octet-stream" // Default content type
+	}
+	return contentType
+}
+
+// ListFiles lists all files in the specified GCS path prefix
+func (g *GCSHelper) ListFiles(prefix string) ([]string, error) {
+	ctx := context.Background()
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var files []string
+	it := client.Bucket(g.BucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+		files = append(files, attrs.Name)
+	}
+
+	return files, nil
+}
+
+// DeleteFile deletes a file from GCS
+func (g *GCSHelper) DeleteFile(gcsPath string) error {
+	ctx := context.Background()
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Bucket(g.BucketName).Object(gcsPath).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", gcsPath, err)
+	}
+
+	log.Printf("Successfully deleted gs://%s/%s", g.BucketName, gcsPath)
+	return nil
+}
+
+// DownloadFile downloads a file from GCS to the local filesystem
+func (g *GCSHelper) DownloadFile(gcsPath, localPath string) error {
+	ctx := context.Background()
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	reader, err := client.Bucket(g.BucketName).Object(gcsPath).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get object %q from GCS: %v", gcsPath, err)
+	}
+	defer reader.Close()
+
+	// Create the directory for the local file if it doesn't exist
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %v", dir, err)
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file %q: %v", localPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.ReadFrom(reader); err != nil {
+		return fmt.Errorf("failed to write to local file %q: %v", localPath, err)
+	}
+
+	log.Printf("Successfully downloaded gs://%s/%s to %s", g.BucketName, gcsPath, localPath)
+	return nil
+}
+
+// CheckBucket verifies that the bucket exists and the credentials can access it,
+// e.g. for readiness checks
+func (g *GCSHelper) CheckBucket(ctx context.Context) error {
+	client, err := g.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if _, err := client.Bucket(g.BucketName).Attrs(ctx); err != nil {
+		return fmt.Errorf("bucket %q is not accessible: %v", g.BucketName, err)
+	}
+	return nil
+}
+
+// cleanKey normalizes a GCS path by removing leading and trailing slashes
+func cleanKey(gcsPath string) string {
+	return strings.TrimPrefix(path.Clean(gcsPath), "/")
+}
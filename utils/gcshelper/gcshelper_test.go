@@ -0,0 +1,319 @@
+package gcshelper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// fakeObject is an object stored by fakeGCS
+type fakeObject struct {
+	data     []byte
+	metadata map[string]any
+}
+
+// fakeGCS implements the parts of the GCS JSON and XML APIs used by GCSHelper
+type fakeGCS struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]*fakeObject
+}
+
+func newFakeGCS(t *testing.T, bucket string) (*fakeGCS, *GCSHelper) {
+	t.Helper()
+	fake := &fakeGCS{bucket: bucket, objects: map[string]*fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, &GCSHelper{BucketName: bucket, EndpointURL: server.URL + "/storage/v1/", Anonymous: true}
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objectsPath := "/storage/v1/b/" + f.bucket + "/o"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload"+objectsPath:
+		f.upload(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == objectsPath:
+		f.list(w, r)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, objectsPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, objectsPath+"/")
+		if _, ok := f.objects[name]; !ok {
+			notFound(w)
+			return
+		}
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, objectsPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, objectsPath+"/")
+		if _, ok := f.objects[name]; !ok {
+			notFound(w)
+			return
+		}
+		json.NewEncoder(w).Encode(f.resource(name))
+	case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/"+f.bucket:
+		json.NewEncoder(w).Encode(map[string]string{"kind": "storage#bucket", "name": f.bucket})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/"+f.bucket+"/"):
+		// XML API download
+		obj, ok := f.objects[strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")]
+		if !ok {
+			notFound(w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(obj.data)
+	default:
+		notFound(w)
+	}
+}
+
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 404, "message": "Not Found"}})
+}
+
+// upload stores a multipart/related upload of metadata and media
+func (f *fakeGCS) upload(w http.ResponseWriter, r *http.Request) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	var metadata map[string]any
+	part, err := reader.NextPart()
+	if err == nil {
+		err = json.NewDecoder(part).Decode(&metadata)
+	}
+	if err == nil {
+		part, err = reader.NextPart()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, _ := io.ReadAll(part)
+
+	name, _ := metadata["name"].(string)
+	f.objects[name] = &fakeObject{data: data, metadata: metadata}
+	json.NewEncoder(w).Encode(f.resource(name))
+}
+
+// resource returns the JSON API representation of an object
+func (f *fakeGCS) resource(name string) map[string]any {
+	data := f.objects[name].data
+	return map[string]any{
+		"name":    name,
+		"bucket":  f.bucket,
+		"size":    strconv.Itoa(len(data)),
+		"etag":    "etag-" + strconv.Itoa(len(data)),
+		"updated": "2024-06-01T12:00:00Z",
+	}
+}
+
+// list returns the objects under the prefix, two per page
+func (f *fakeGCS) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	var names []string
+	for name := range f.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	start := 0
+	if token := r.URL.Query().Get("pageToken"); token != "" {
+		start = sort.SearchStrings(names, token)
+	}
+	end := min(start+2, len(names))
+	response := map[string]any{"kind": "storage#objects"}
+	var items []map[string]any
+	for _, name := range names[start:end] {
+		items = append(items, f.resource(name))
+	}
+	response["items"] = items
+	if end < len(names) {
+		response["nextPageToken"] = names[end]
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func TestUploadDownload(t *testing.T) {
+	fake, helper := newFakeGCS(t, "deliveries")
+	helper.ContentTypes = map[string]string{"dat": "text/csv"}
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "export.dat")
+	os.WriteFile(src, []byte("id,name\n1,acme\n"), 0644)
+	if err := helper.UploadFile(src, "/acme/2024-06-01/export.dat"); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	obj := fake.objects["acme/2024-06-01/export.dat"]
+	if obj == nil {
+		t.Fatalf("object not stored, have %v", fake.objects)
+	}
+	if obj.metadata["contentType"] != "text/csv" {
+		t.Errorf("contentType = %v, expected text/csv", obj.metadata["contentType"])
+	}
+
+	err := helper.UploadFileWithOptions(src, "acme/report.csv", &UploadOptions{
+		ContentType:        "application/x-custom",
+		CacheControl:       "no-cache",
+		ContentDisposition: `attachment; filename="report.csv"`,
+		Metadata:           map[string]string{"customer": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("UploadFileWithOptions failed: %v", err)
+	}
+	metadata := fake.objects["acme/report.csv"].metadata
+	if metadata["contentType"] != "application/x-custom" || metadata["cacheControl"] != "no-cache" || metadata["contentDisposition"] != `attachment; filename="report.csv"` {
+		t.Errorf("unexpected metadata %v", metadata)
+	}
+	if custom, _ := metadata["metadata"].(map[string]any); custom["customer"] != "acme" {
+		t.Errorf("unexpected custom metadata %v", metadata["metadata"])
+	}
+
+	dst := filepath.Join(dir, "downloads", "nested", "export.dat")
+	if err := helper.DownloadFile("acme/2024-06-01/export.dat", dst); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "id,name\n1,acme\n" {
+		t.Errorf("downloaded content = %q", data)
+	}
+
+	if err := helper.DownloadFile("acme/missing.csv", filepath.Join(dir, "missing.csv")); err == nil {
+		t.Error("expected error for missing object")
+	}
+	if err := helper.UploadFile(filepath.Join(dir, "missing.dat"), "x"); err == nil {
+		t.Error("expected error for missing local file")
+	}
+}
+
+func TestListDelete(t *testing.T) {
+	fake, helper := newFakeGCS(t, "deliveries")
+	for _, name := range []string{"acme/a.csv", "acme/b.csv", "acme/c.csv", "other/d.csv"} {
+		fake.objects[name] = &fakeObject{data: []byte(name)}
+	}
+
+	files, err := helper.ListFiles("acme/")
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if strings.Join(files, ",") != "acme/a.csv,acme/b.csv,acme/c.csv" {
+		t.Errorf("ListFiles = %v", files)
+	}
+
+	if err := helper.DeleteFile("acme/b.csv"); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if _, ok := fake.objects["acme/b.csv"]; ok {
+		t.Error("expected object to be deleted")
+	}
+	if err := helper.DeleteFile("acme/b.csv"); err == nil {
+		t.Error("expected error deleting a missing object")
+	}
+}
+
+func TestCheckBucket(t *testing.T) {
+	_, helper := newFakeGCS(t, "deliveries")
+	if err := helper.CheckBucket(context.Background()); err != nil {
+		t.Errorf("CheckBucket failed: %v", err)
+	}
+
+	helper.BucketName = "missing"
+	if err := helper.CheckBucket(context.Background()); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+
+	helper.BucketName = ""
+	if _, err := helper.ListFiles(""); err == nil {
+		t.Error("expected error for empty BucketName")
+	}
+}
+
+func TestBackend(t *testing.T) {
+	fake, helper := newFakeGCS(t, "deliveries")
+	ctx := context.Background()
+
+	if err := helper.Put(ctx, "/acme/export.csv", strings.NewReader("id,name\n1,acme\n")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	obj := fake.objects["acme/export.csv"]
+	if obj == nil {
+		t.Fatalf("object not stored, have %v", fake.objects)
+	}
+	if obj.metadata["contentType"] != "text/csv; charset=utf-8" {
+		t.Errorf("contentType = %v", obj.metadata["contentType"])
+	}
+	fake.objects["acme/b.csv"] = &fakeObject{data: []byte("b")}
+	fake.objects["other/c.csv"] = &fakeObject{data: []byte("c")}
+
+	r, err := helper.Get(ctx, "acme/export.csv")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "id,name\n1,acme\n" {
+		t.Errorf("Get content = %q", data)
+	}
+
+	objects, err := helper.List(ctx, "acme/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != "acme/b.csv" || objects[1].Key != "acme/export.csv" || objects[1].Size != 15 {
+		t.Errorf("List = %+v", objects)
+	}
+
+	info, err := helper.Stat(ctx, "acme/export.csv")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != 15 || info.ETag != "etag-15" || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v", info)
+	}
+
+	if err := helper.Delete(ctx, "acme/export.csv"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := helper.Delete(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Delete, got %v", err)
+	}
+	if _, err := helper.Stat(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Stat, got %v", err)
+	}
+	if _, err := helper.Get(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Get, got %v", err)
+	}
+}
+
+func TestCleanKey(t *testing.T) {
+	tests := map[string]string{
+		"/acme/export.csv":  "acme/export.csv",
+		"acme//export.csv/": "acme/export.csv",
+		"acme/./export.csv": "acme/export.csv",
+		"export.csv":        "export.csv",
+	}
+	for input, expected := range tests {
+		if got := cleanKey(input); got != expected {
+			t.Errorf("cleanKey(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romisugianto/go-utils/utils/dbhelper"
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/s3helper"
+)
+
+// DiskSpace fails when the filesystem holding path has less than minFreeBytes available
+func DiskSpace(path string, minFreeBytes uint64) Check {
+	return func(ctx context.Context) error {
+		free, err := freeSpace(path)
+		if err != nil {
+			return fmt.Errorf("failed to read free space of %s: %w", path, err)
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("only %s free on %s, need %s", humanize.Bytes(int64(free)), path, humanize.Bytes(int64(minFreeBytes)))
+		}
+		return nil
+	}
+}
+
+// S3Bucket fails when the helper's bucket cannot be reached
+func S3Bucket(u *s3helper.S3Helper) Check {
+	return u.CheckBucket
+}
+
+// Database fails when the database does not answer a ping
+func Database(h *dbhelper.DBHelper) Check {
+	return h.HealthCheck
+}
@@ -0,0 +1,236 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Check reports whether a dependency is usable. A nil error means healthy.
+type Check func(ctx context.Context) error
+
+// Options configures a Server
+type Options struct {
+	// CheckTimeout bounds each readiness check (default 5s)
+	CheckTimeout time.Duration
+	// ShutdownTimeout bounds the wait for in-flight requests on shutdown (default 10s)
+	ShutdownTimeout time.Duration
+	// DrainDelay keeps serving with /readyz failing for this long before
+	// shutting down, so load balancers stop sending traffic first
+	DrainDelay time.Duration
+}
+
+// CheckResult is the outcome of one readiness check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Response is the JSON body returned by /healthz and /readyz
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Server serves /healthz (liveness) and /readyz (readiness) endpoints
+type Server struct {
+	logger *logger.Logger
+	opts   Options
+
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+
+	draining atomic.Bool
+}
+
+// NewServer creates a health server. A nil opts uses the defaults.
+func NewServer(log *logger.Logger, opts *Options) (*Server, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	s := &Server{logger: log, checks: map[string]Check{}}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.CheckTimeout <= 0 {
+		s.opts.CheckTimeout = 5 * time.Second
+	}
+	if s.opts.ShutdownTimeout <= 0 {
+		s.opts.ShutdownTimeout = 10 * time.Second
+	}
+	return s, nil
+}
+
+// AddCheck registers a named readiness check
+func (s *Server) AddCheck(name string, check Check) error {
+	if name == "" {
+		return fmt.Errorf("check name cannot be empty")
+	}
+	if check == nil {
+		return fmt.Errorf("failed to add check %s: check cannot be nil", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.checks[name]; ok {
+		return fmt.Errorf("failed to add check %s: a check with this name already exists", name)
+	}
+	s.names = append(s.names, name)
+	s.checks[name] = check
+	return nil
+}
+
+// Handler returns an HTTP handler serving /healthz and /readyz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	return mux
+}
+
+// Ready runs every check concurrently and returns the combined result
+func (s *Server) Ready(ctx context.Context) Response {
+	s.mu.Lock()
+	names := append([]string(nil), s.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = s.checks[name]
+	}
+	s.mu.Unlock()
+
+	results := make([]CheckResult, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.run(ctx, checks[i])
+		}()
+	}
+	wg.Wait()
+
+	response := Response{Status: "ok", Checks: make(map[string]CheckResult, len(names))}
+	if s.draining.Load() {
+		response.Status = "shutting down"
+	}
+	for i, name := range names {
+		response.Checks[name] = results[i]
+		if results[i].Status != "ok" {
+			response.Status = "unavailable"
+		}
+	}
+	return response
+}
+
+// ListenAndServe serves the endpoints on addr (e.g. ":8081") until ctx is
+// done, then fails /readyz for DrainDelay and shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve is like ListenAndServe but uses an existing listener
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	s.draining.Store(false)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+	s.logger.Info("Health server listening on %s", listener.Addr())
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("health server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	if s.opts.DrainDelay > 0 {
+		s.logger.Info("Health server draining for %s", s.opts.DrainDelay)
+		time.Sleep(s.opts.DrainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down health server: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	s.logger.Info("Health server stopped")
+	return nil
+}
+
+// handleHealthz reports that the process is alive
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Response{Status: "ok"})
+}
+
+// handleReadyz runs the checks and fails while any check fails or the server is draining
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	response := s.Ready(r.Context())
+	status := http.StatusOK
+	if response.Status != "ok" {
+		status = http.StatusServiceUnavailable
+		for name, result := range response.Checks {
+			if result.Status != "ok" {
+				s.logger.Warning("Readiness check %s failed: %s", name, result.Error)
+			}
+		}
+	}
+	writeJSON(w, status, response)
+}
+
+// run executes a check with the timeout, converting a panic into a failure.
+// A check that ignores its context is abandoned once the timeout passes.
+func (s *Server) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.CheckTimeout)
+	defer cancel()
+	start := time.Now()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check did not complete: %w", ctx.Err())
+	}
+
+	result := CheckResult{Status: "ok", Duration: time.Since(start).Round(time.Millisecond).String()}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
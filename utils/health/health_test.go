@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func newTestServer(t *testing.T, opts *Options) *Server {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("health_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	s, err := NewServer(testLogger, opts)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	return s
+}
+
+func get(t *testing.T, handler http.Handler, path string) (int, Response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	var response Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON from %s: %v", path, err)
+	}
+	return recorder.Code, response
+}
+
+func TestAddCheckValidation(t *testing.T) {
+	if _, err := NewServer(nil, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+
+	s := newTestServer(t, nil)
+	if err := s.AddCheck("", func(context.Context) error { return nil }); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if err := s.AddCheck("db", nil); err == nil {
+		t.Error("expected error for nil check")
+	}
+	s.AddCheck("db", func(context.Context) error { return nil })
+	if err := s.AddCheck("db", func(context.Context) error { return nil }); err == nil {
+		t.Error("expected error for duplicate check")
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	s := newTestServer(t, &Options{CheckTimeout: 50 * time.Millisecond})
+	failing := true
+	s.AddCheck("ok", func(context.Context) error { return nil })
+	s.AddCheck("s3", func(context.Context) error {
+		if failing {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	handler := s.Handler()
+
+	if code, response := get(t, handler, "/healthz"); code != http.StatusOK || response.Status != "ok" {
+		t.Errorf("/healthz = %d %+v", code, response)
+	}
+
+	code, response := get(t, handler, "/readyz")
+	if code != http.StatusServiceUnavailable || response.Status != "unavailable" {
+		t.Errorf("/readyz = %d %+v, expected 503", code, response)
+	}
+	if result := response.Checks["s3"]; result.Status != "error" || result.Error != "connection refused" {
+		t.Errorf("unexpected s3 result %+v", result)
+	}
+	if result := response.Checks["ok"]; result.Status != "ok" {
+		t.Errorf("unexpected ok result %+v", result)
+	}
+
+	failing = false
+	if code, _ := get(t, handler, "/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz = %d, expected 200", code)
+	}
+}
+
+func TestCheckTimeoutAndPanic(t *testing.T) {
+	s := newTestServer(t, &Options{CheckTimeout: 50 * time.Millisecond})
+	s.AddCheck("hung", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	s.AddCheck("panics", func(context.Context) error { panic("nil client") })
+
+	start := time.Now()
+	response := s.Ready(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Ready took %s, expected the hung check to be abandoned", elapsed)
+	}
+	if !strings.Contains(response.Checks["hung"].Error, "deadline exceeded") {
+		t.Errorf("unexpected hung result %+v", response.Checks["hung"])
+	}
+	if !strings.Contains(response.Checks["panics"].Error, "panicked") {
+		t.Errorf("unexpected panic result %+v", response.Checks["panics"])
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := DiskSpace(dir, 1)(context.Background()); err != nil {
+		t.Errorf("DiskSpace failed: %v", err)
+	}
+	if err := DiskSpace(dir, 1<<62)(context.Background()); err == nil {
+		t.Error("expected error when free space is below the minimum")
+	}
+	if err := DiskSpace(dir+"/missing", 1)(context.Background()); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
+
+func TestServeDrainsBeforeShutdown(t *testing.T) {
+	s := newTestServer(t, &Options{DrainDelay: 200 * time.Millisecond})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	url := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, listener) }()
+
+	resp, err := http.Get(url + "/readyz")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("/readyz = %d before shutdown", resp.StatusCode)
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	resp, err = http.Get(url + "/readyz")
+	if err != nil {
+		t.Fatalf("request during drain failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("/readyz = %d while draining, expected 503", resp.StatusCode)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
@@ -2,13 +2,15 @@
 package housekeeper
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 	"time"
 
+	"github.com/romisugianto/go-utils/utils/dirwalk"
 	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/validate"
 )
 
 // Processor handles file splitting operations
@@ -31,8 +33,8 @@ func (h *Housekeeper) HousekeepFilesByAge(dir string, maxAgeDays int, recursive
 	}
 
 	// Verify directory exists
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return fmt.Errorf("directory does not exist: %s", dir)
+	if err := validate.Dir(dir); err != nil {
+		return err
 	}
 
 	recursiveFlag := false
@@ -40,30 +42,25 @@ func (h *Housekeeper) HousekeepFilesByAge(dir string, maxAgeDays int, recursive
 		recursiveFlag = recursive[0]
 	}
 
+	maxDepth := 1
+	if recursiveFlag {
+		maxDepth = 0
+	}
+
 	var removed []string
 	now := time.Now()
 	cutoff := now.Add(-time.Duration(maxAgeDays*24) * time.Hour)
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			h.logger.Error("Error accessing path %s: %v", path, err)
-			return nil
-		}
-
-		// Skip directories unless we're processing them recursively
-		if info.IsDir() {
-			if !recursiveFlag && path != dir {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if info.ModTime().Before(cutoff) {
-			if err := os.Remove(path); err != nil {
-				h.logger.Error("Failed to remove file %s: %v", path, err)
+	err := dirwalk.Walk(context.Background(), dir, &dirwalk.Options{
+		MaxDepth: maxDepth,
+		OnError:  h.logAccessError,
+	}, func(e dirwalk.Entry) error {
+		if e.ModTime().Before(cutoff) {
+			if err := os.Remove(e.Path); err != nil {
+				h.logger.Error("Failed to remove file %s: %v", e.Path, err)
 				return nil
 			}
-			removed = append(removed, path)
+			removed = append(removed, e.Path)
 		}
 		return nil
 	})
@@ -81,11 +78,14 @@ func (h *Housekeeper) HousekeepFilesByCount(dir string, maxFiles int) error {
 		return fmt.Errorf("maxFiles must be >= 0, got %d", maxFiles)
 	}
 
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return fmt.Errorf("directory does not exist: %s", dir)
+	if err := validate.Dir(dir); err != nil {
+		return err
 	}
 
-	files, err := os.ReadDir(dir)
+	files, err := dirwalk.Collect(context.Background(), dir, &dirwalk.Options{
+		MaxDepth:    1,
+		IncludeDirs: true,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to read directory %s: %w", dir, err)
 	}
@@ -96,15 +96,13 @@ func (h *Housekeeper) HousekeepFilesByCount(dir string, maxFiles int) error {
 	}
 
 	// Sort by mod time (oldest first)
-	sort.Slice(files, func(i, j int) bool {
-		infoI, _ := files[i].Info()
-		infoJ, _ := files[j].Info()
-		return infoI.ModTime().Before(infoJ.ModTime())
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
 	})
 
 	var removed []string
 	for i := 0; i < len(files)-maxFiles; i++ {
-		path := filepath.Join(dir, files[i].Name())
+		path := files[i].Path
 		if err := os.Remove(path); err != nil {
 			h.logger.Error("Failed to remove file %s: %v", path, err)
 			continue
@@ -116,6 +114,12 @@ func (h *Housekeeper) HousekeepFilesByCount(dir string, maxFiles int) error {
 	return nil
 }
 
+// logAccessError logs a path that cannot be read and continues the walk
+func (h *Housekeeper) logAccessError(path string, err error) error {
+	h.logger.Error("Error accessing path %s: %v", path, err)
+	return nil
+}
+
 func (h *Housekeeper) logRemovals(files []string, operation string) {
 	if len(files) == 0 {
 		h.logger.Summary("No files removed during %s", operation)
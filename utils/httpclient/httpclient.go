@@ -0,0 +1,322 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/retry"
+)
+
+// DefaultTimeout bounds a single attempt when Options.Timeout is zero
+const DefaultTimeout = 30 * time.Second
+
+// Options configures a Client
+type Options struct {
+	// Timeout bounds each attempt, including reading the response body (default 30s).
+	// Downloads are not bounded as a whole: the response headers must arrive
+	// and the body must not stall for longer than Timeout.
+	Timeout time.Duration
+	// Retry controls retries on connection errors and 5xx responses (default 3 attempts)
+	Retry retry.Options
+	// Headers are added to every request that does not already set them
+	Headers map[string]string
+	// UserAgent is sent when the request has no User-Agent header
+	UserAgent string
+	// Transport replaces the default transport, e.g. for custom TLS settings
+	Transport http.RoundTripper
+}
+
+// Client wraps an *http.Client with retries and request logging
+type Client struct {
+	HTTP   *http.Client
+	logger *logger.Logger
+	opts   Options
+}
+
+// StatusError is returned by the helpers for non-2xx responses
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string // first 512 bytes of the response body
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s %s: unexpected status %s", e.Method, e.URL, e.Status)
+	}
+	return fmt.Sprintf("%s %s: unexpected status %s: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// NewClient creates a new Client. A nil opts uses the defaults.
+func NewClient(log *logger.Logger, opts *Options) (*Client, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultTimeout
+	}
+
+	transport := o.Transport
+	if transport == nil {
+		defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		defaultTransport.TLSHandshakeTimeout = 10 * time.Second
+		defaultTransport.ResponseHeaderTimeout = o.Timeout
+		transport = defaultTransport
+	}
+
+	return &Client{
+		HTTP:   &http.Client{Timeout: o.Timeout, Transport: transport},
+		logger: log,
+		opts:   o,
+	}, nil
+}
+
+// Do sends the request, retrying connection errors and 5xx responses. When all
+// attempts return 5xx the last response is returned without error, as with
+// http.Client. Requests with a body are retried only if req.GetBody is set,
+// which http.NewRequest does for in-memory bodies.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req, c.HTTP)
+}
+
+// do sends the request with client, retrying like Do
+func (c *Client) do(req *http.Request, client *http.Client) (*http.Response, error) {
+	c.applyHeaders(req)
+
+	opts := c.opts.Retry
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		opts.MaxAttempts = 1
+	}
+	opts.OnRetry = func(attempt int, err error, delay time.Duration) {
+		c.logger.Warning("HTTP %s %s attempt %d failed: %v; retrying in %s", req.Method, req.URL.Redacted(), attempt, err, delay.Round(time.Millisecond))
+		if c.opts.Retry.OnRetry != nil {
+			c.opts.Retry.OnRetry(attempt, err, delay)
+		}
+	}
+
+	var last *http.Response
+	attempt := 0
+	err := retry.Do(req.Context(), func() error {
+		attempt++
+		if last != nil {
+			drain(last)
+			last = nil
+		}
+
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return retry.Permanent(fmt.Errorf("failed to rewind request body: %w", err))
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		c.logger.Debug("HTTP %s %s", req.Method, req.URL.Redacted())
+		start := time.Now()
+		resp, err := client.Do(attemptReq)
+		if err != nil {
+			if req.Context().Err() != nil {
+				return retry.Permanent(err)
+			}
+			return err
+		}
+
+		c.logger.Info("HTTP %s %s -> %d (%s)", req.Method, req.URL.Redacted(), resp.StatusCode, time.Since(start).Round(time.Millisecond))
+		last = resp
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("server returned %s", resp.Status)
+		}
+		return nil
+	}, opts)
+
+	if last != nil {
+		return last, nil
+	}
+	if err != nil {
+		c.logger.Error("HTTP %s %s failed: %v", req.Method, req.URL.Redacted(), err)
+	}
+	return nil, err
+}
+
+// Get sends a GET request
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.Do(req)
+}
+
+// GetJSON sends a GET request and decodes a 2xx JSON response into result
+func (c *Client) GetJSON(ctx context.Context, url string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return c.doJSON(req, result)
+}
+
+// PostJSON encodes body as JSON, posts it and decodes a 2xx JSON response into
+// result. A nil result discards the response body.
+func (c *Client) PostJSON(ctx context.Context, url string, body, result any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	return c.doJSON(req, result)
+}
+
+// doJSON sends req and decodes the response into result
+func (c *Client) doJSON(req *http.Request, result any) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+
+	if err := checkStatus(req, resp); err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// DownloadFile downloads url to filePath, creating parent directories. The body
+// is written to a temporary file that is renamed into place on success.
+func (c *Client) DownloadFile(url, filePath string) error {
+	return c.DownloadFileContext(context.Background(), url, filePath)
+}
+
+// DownloadFileContext is DownloadFile with a context for cancellation. Large
+// files are not cut off by Options.Timeout: it only bounds the wait for the
+// response headers and any stall of the body, so use ctx to limit the total
+// time of the download.
+func (c *Client) DownloadFileContext(ctx context.Context, url, filePath string) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The transport's ResponseHeaderTimeout bounds the wait for the response
+	client := *c.HTTP
+	client.Timeout = 0
+	resp, err := c.do(req, &client)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+
+	if err := checkStatus(resp.Request, resp); err != nil {
+		return err
+	}
+
+	file, err := atomicfile.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+
+	stalled := fmt.Errorf("download stalled for %s", c.opts.Timeout)
+	idle := time.AfterFunc(c.opts.Timeout, func() { cancel(stalled) })
+	defer idle.Stop()
+	written, err := io.Copy(file, &idleReader{r: resp.Body, idle: idle, timeout: c.opts.Timeout})
+	if err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	c.logger.Info("Downloaded %s to %s (%d bytes)", url, filePath, written)
+	return nil
+}
+
+// idleReader restarts the idle timer whenever data arrives
+type idleReader struct {
+	r       io.Reader
+	idle    *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.idle.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// applyHeaders sets the default headers that the request does not already have
+func (c *Client) applyHeaders(req *http.Request) {
+	for key, value := range c.opts.Headers {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	}
+	if c.opts.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+}
+
+// checkStatus returns a *StatusError for non-2xx responses
+func checkStatus(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(bytes.TrimSpace(snippet)),
+	}
+}
+
+// IsStatus reports whether err is a *StatusError with the given status code
+func IsStatus(err error, code int) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == code
+}
+
+// drain discards the rest of the body so the connection can be reused
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,249 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/retry"
+)
+
+func newTestClient(t *testing.T, opts *Options) *Client {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("httpclient_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	opts.Retry.InitialDelay = time.Millisecond
+	client, err := NewClient(testLogger, opts)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(nil, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+
+	client := newTestClient(t, nil)
+	if client.HTTP.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %s, expected %s", client.HTTP.Timeout, DefaultTimeout)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		failStatus   int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{"success first try", 0, 0, 200, 1},
+		{"recovers after 5xx", 2, 503, 200, 3},
+		{"gives up after max attempts", 5, 502, 502, 3},
+		{"4xx is not retried", 5, 404, 404, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "payload" {
+					t.Errorf("attempt %d received body %q", attempts.Load()+1, body)
+				}
+				if int(attempts.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, nil)
+			req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, tt.wantStatus)
+			}
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("attempts = %d, expected %d", attempts.Load(), tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestConnectionErrorRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var retries int
+	client := newTestClient(t, &Options{Retry: retry.Options{
+		MaxAttempts: 2,
+		OnRetry:     func(int, error, time.Duration) { retries++ },
+	}})
+	if _, err := client.Get(context.Background(), url); err == nil {
+		t.Fatal("expected connection error")
+	}
+	if retries != 1 {
+		t.Errorf("retries = %d, expected 1", retries)
+	}
+}
+
+func TestContextCancelNotRetried(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	client := newTestClient(t, nil)
+	if _, err := client.Get(ctx, server.URL); err == nil {
+		t.Fatal("expected context error")
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("attempts = %d, expected 1", attempts.Load())
+	}
+}
+
+func TestJSONHelpers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" || r.Header.Get("User-Agent") != "go-utils-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"unauthorized"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/echo":
+			if r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+			}
+			var payload map[string]any
+			json.NewDecoder(r.Body).Decode(&payload)
+			payload["echoed"] = true
+			json.NewEncoder(w).Encode(payload)
+		case "/status":
+			w.Write([]byte(`{"status":"ok"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, &Options{
+		Headers:   map[string]string{"X-Api-Key": "secret"},
+		UserAgent: "go-utils-test",
+	})
+	ctx := context.Background()
+
+	var echo map[string]any
+	if err := client.PostJSON(ctx, server.URL+"/echo", map[string]string{"name": "alpha"}, &echo); err != nil {
+		t.Fatalf("PostJSON failed: %v", err)
+	}
+	if echo["name"] != "alpha" || echo["echoed"] != true {
+		t.Errorf("unexpected response: %v", echo)
+	}
+
+	var status struct{ Status string }
+	if err := client.GetJSON(ctx, server.URL+"/status", &status); err != nil || status.Status != "ok" {
+		t.Errorf("GetJSON = %+v, %v", status, err)
+	}
+
+	err := client.GetJSON(ctx, server.URL+"/missing", &status)
+	if !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("expected 404 StatusError, got %v", err)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/report.csv" {
+			w.Write([]byte("id,name\n1,alpha\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, nil)
+	dest := filepath.Join(t.TempDir(), "downloads", "report.csv")
+
+	if err := client.DownloadFile(server.URL+"/report.csv", dest); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(dest)
+	if string(content) != "id,name\n1,alpha\n" {
+		t.Errorf("downloaded content = %q", content)
+	}
+
+	dir := t.TempDir()
+	if err := client.DownloadFile(server.URL+"/missing.csv", filepath.Join(dir, "missing.csv")); !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("expected 404 StatusError, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("no file should be created for a failed download, got %d", len(entries))
+	}
+}
+
+func TestDownloadFileTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Send a chunk every 40ms, with a pause of 300ms on /stall
+		for i := 0; i < 8; i++ {
+			w.Write([]byte("chunk\n"))
+			w.(http.Flusher).Flush()
+			pause := 40 * time.Millisecond
+			if r.URL.Path == "/stall" && i == 2 {
+				pause = 300 * time.Millisecond
+			}
+			select {
+			case <-time.After(pause):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	// The download takes longer than Timeout but never stalls that long
+	client := newTestClient(t, &Options{Timeout: 150 * time.Millisecond})
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "slow.txt")
+	if err := client.DownloadFile(server.URL+"/slow", dest); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(dest); string(content) != strings.Repeat("chunk\n", 8) {
+		t.Errorf("downloaded content = %q", content)
+	}
+
+	stalled := filepath.Join(dir, "stalled.txt")
+	if err := client.DownloadFile(server.URL+"/stall", stalled); err == nil || !strings.Contains(err.Error(), "stalled") {
+		t.Errorf("expected a stalled download error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the completed download, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,264 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package humanize
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Byte size units. The binary units (KiB, MiB, ...) are powers of 1024 and
+// the decimal units (KB, MB, ...) powers of 1000.
+const (
+	Byte int64 = 1
+	KiB        = 1024 * Byte
+	MiB        = 1024 * KiB
+	GiB        = 1024 * MiB
+	TiB        = 1024 * GiB
+	PiB        = 1024 * TiB
+	KB         = 1000 * Byte
+	MB         = 1000 * KB
+	GB         = 1000 * MB
+	TB         = 1000 * GB
+	PB         = 1000 * TB
+)
+
+// Day and Week extend time.Duration for ParseDuration and Duration
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// byteUnits maps lowercase unit names accepted by ParseBytes to their size
+var byteUnits = map[string]int64{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "ki": KiB, "kib": KiB,
+	"m": MB, "mb": MB, "mi": MiB, "mib": MiB,
+	"g": GB, "gb": GB, "gi": GiB, "gib": GiB,
+	"t": TB, "tb": TB, "ti": TiB, "tib": TiB,
+	"p": PB, "pb": PB, "pi": PiB, "pib": PiB,
+}
+
+// Bytes formats a size with binary units, e.g. "1.5 MiB" or "512 B"
+func Bytes(n int64) string {
+	return formatBytes(n, 1024, []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"})
+}
+
+// SIBytes formats a size with decimal units, e.g. "1.6 MB" or "512 B", as
+// disk vendors and network links count
+func SIBytes(n int64) string {
+	return formatBytes(n, 1000, []string{"KB", "MB", "GB", "TB", "PB", "EB"})
+}
+
+// formatBytes formats n with one decimal in the largest unit below it
+func formatBytes(n int64, base float64, units []string) string {
+	sign := ""
+	value := float64(n)
+	if n < 0 {
+		sign, value = "-", -value
+	}
+	if value < base {
+		return fmt.Sprintf("%s%d B", sign, int64(value))
+	}
+	exp := 0
+	for value /= base; value >= base && exp < len(units)-1; value /= base {
+		exp++
+	}
+	// Avoid "1024.0 KiB" when rounding reaches the next unit
+	if math.Round(value*10)/10 >= base && exp < len(units)-1 {
+		value /= base
+		exp++
+	}
+	return fmt.Sprintf("%s%.1f %s", sign, value, units[exp])
+}
+
+// ParseBytes parses a size such as "500MB", "1.5 GiB", "64k" or "1024".
+// Units are case-insensitive: KB, MB, GB, TB and PB (or K, M, G, T, P) are
+// decimal, KiB, MiB, GiB, TiB and PiB binary, and a bare number is bytes.
+func ParseBytes(s string) (int64, error) {
+	raw := strings.TrimSpace(s)
+	i := strings.IndexFunc(raw, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		i = len(raw)
+	}
+	number, unit := raw[:i], strings.ToLower(strings.TrimSpace(raw[i:]))
+	size, ok := byteUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n > math.MaxInt64/size || n < math.MinInt64/size {
+			return 0, fmt.Errorf("size %q is too large", s)
+		}
+		return n * size, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	value := f * float64(size)
+	if value >= math.MaxInt64 || value <= math.MinInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(math.Round(value)), nil
+}
+
+// ByteSize is a size that configuration files and environment variables can
+// give as "500MB" or "2GiB" as well as a plain number of bytes
+type ByteSize int64
+
+// String formats the size with binary units
+func (b ByteSize) String() string {
+	return Bytes(int64(b))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseBytes
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := ParseBytes(string(text))
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// UnmarshalJSON accepts a JSON number of bytes or a string for ParseBytes
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return b.UnmarshalText([]byte(text))
+	}
+	return b.UnmarshalText(data)
+}
+
+// Duration formats d with its two largest units, e.g. "3d12h", "1h32m",
+// "5m3s", "12.5s" or "250ms"
+func Duration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	switch {
+	case d < time.Second:
+		return sign + d.Round(time.Microsecond).String()
+	case d < time.Minute:
+		return sign + d.Round(100*time.Millisecond).String()
+	}
+
+	d = d.Round(time.Second)
+	units := []struct {
+		size time.Duration
+		name string
+	}{{Day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}}
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, unit := range units {
+		if d < unit.size {
+			continue
+		}
+		fmt.Fprintf(&b, "%d%s", d/unit.size, unit.name)
+		if rest := d % unit.size; rest >= units[i+1].size {
+			fmt.Fprintf(&b, "%d%s", rest/units[i+1].size, units[i+1].name)
+		}
+		break
+	}
+	return b.String()
+}
+
+// ParseDuration parses a duration like time.ParseDuration, additionally
+// accepting days ("d") and weeks ("w"), e.g. "3d12h", "1w" or "1.5d"
+func ParseDuration(s string) (time.Duration, error) {
+	raw := strings.TrimSpace(s)
+	if !strings.ContainsAny(raw, "dw") {
+		return time.ParseDuration(raw)
+	}
+
+	rest := raw
+	sign := time.Duration(1)
+	if strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+		if rest[0] == '-' {
+			sign = -1
+		}
+		rest = rest[1:]
+	}
+	var total time.Duration
+	for rest != "" {
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		j := strings.IndexFunc(rest[i:], func(r rune) bool { return (r >= '0' && r <= '9') || r == '.' })
+		if j < 0 {
+			j = len(rest) - i
+		}
+		number, unit := rest[:i], rest[i:i+j]
+		rest = rest[i+j:]
+
+		var part time.Duration
+		switch unit {
+		case "d", "w":
+			f, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			size := Day
+			if unit == "w" {
+				size = Week
+			}
+			if f*float64(size) > math.MaxInt64 {
+				return 0, fmt.Errorf("duration %q is too large", s)
+			}
+			part = time.Duration(f * float64(size))
+		default:
+			d, err := time.ParseDuration(number + unit)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			part = d
+		}
+		if total > math.MaxInt64-part {
+			return 0, fmt.Errorf("duration %q is too large", s)
+		}
+		total += part
+	}
+	return sign * total, nil
+}
+
+// Rate formats the throughput of n bytes in d, e.g. "12.5 MiB/s"
+func Rate(n int64, d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	perSecond := float64(n) / d.Seconds()
+	if math.Abs(perSecond) >= math.MaxInt64 {
+		return "-"
+	}
+	return Bytes(int64(math.Round(perSecond))) + "/s"
+}
+
+// Count formats n with thousands separators, e.g. "1,234,567"
+func Count(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
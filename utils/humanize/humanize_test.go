@@ -0,0 +1,136 @@
+package humanize
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		n      int64
+		binary string
+		si     string
+	}{
+		{0, "0 B", "0 B"},
+		{512, "512 B", "512 B"},
+		{1000, "1000 B", "1.0 KB"},
+		{1536, "1.5 KiB", "1.5 KB"},
+		{1048575, "1.0 MiB", "1.0 MB"},
+		{5 * GiB / 2, "2.5 GiB", "2.7 GB"},
+		{-2048, "-2.0 KiB", "-2.0 KB"},
+		{1 << 62, "4.0 EiB", "4.6 EB"},
+	}
+	for _, test := range tests {
+		if got := Bytes(test.n); got != test.binary {
+			t.Errorf("Bytes(%d) = %q, expected %q", test.n, got, test.binary)
+		}
+		if got := SIBytes(test.n); got != test.si {
+			t.Errorf("SIBytes(%d) = %q, expected %q", test.n, got, test.si)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := map[string]int64{
+		"1024":     1024,
+		"500MB":    500 * MB,
+		"500 mb":   500 * MB,
+		"1.5GiB":   3 * GiB / 2,
+		"64k":      64 * KB,
+		"64KiB":    64 * KiB,
+		"2 Ti":     2 * TiB,
+		" 10 B ":   10,
+		"0.5 kb":   500,
+		"-1 KiB":   -1024,
+		"8388608B": 8 * MiB,
+	}
+	for input, expected := range tests {
+		if got, err := ParseBytes(input); err != nil || got != expected {
+			t.Errorf("ParseBytes(%q) = %d, %v, expected %d", input, got, err, expected)
+		}
+	}
+	for _, input := range []string{"", "MB", "12 XB", "1.2.3MB", "9999999PB", "1e3"} {
+		if _, err := ParseBytes(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	var config struct {
+		MaxUpload ByteSize `json:"max_upload"`
+		Buffer    ByteSize `json:"buffer"`
+	}
+	if err := json.Unmarshal([]byte(`{"max_upload": "2GiB", "buffer": 65536}`), &config); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if int64(config.MaxUpload) != 2*GiB || config.Buffer != 65536 {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if config.MaxUpload.String() != "2.0 GiB" {
+		t.Errorf("String() = %q", config.MaxUpload.String())
+	}
+	if err := json.Unmarshal([]byte(`{"buffer": "lots"}`), &config); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                             "0s",
+		250 * time.Millisecond:        "250ms",
+		1500 * time.Microsecond:       "1.5ms",
+		12500 * time.Millisecond:      "12.5s",
+		5*time.Minute + 3*time.Second: "5m3s",
+		time.Hour + 32*time.Minute + 10*time.Second: "1h32m",
+		2 * time.Hour:                        "2h",
+		3*Day + 12*time.Hour + 5*time.Minute: "3d12h",
+		10 * Day:                             "10d",
+		-90 * time.Second:                    "-1m30s",
+	}
+	for d, expected := range tests {
+		if got := Duration(d); got != expected {
+			t.Errorf("Duration(%v) = %q, expected %q", d, got, expected)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90m":     90 * time.Minute,
+		"3d12h":   3*Day + 12*time.Hour,
+		"1w":      Week,
+		"1.5d":    36 * time.Hour,
+		"2w3d":    17 * Day,
+		"1d30m5s": Day + 30*time.Minute + 5*time.Second,
+		"-1d":     -Day,
+		" 7d ":    7 * Day,
+	}
+	for input, expected := range tests {
+		if got, err := ParseDuration(input); err != nil || got != expected {
+			t.Errorf("ParseDuration(%q) = %v, %v, expected %v", input, got, err, expected)
+		}
+	}
+	for _, input := range []string{"", "d", "3x", "1d2", "1dd", "100000000w", "3 days"} {
+		if _, err := ParseDuration(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestRateAndCount(t *testing.T) {
+	if got := Rate(25*MiB, 2*time.Second); got != "12.5 MiB/s" {
+		t.Errorf("Rate = %q", got)
+	}
+	if got := Rate(100, 0); got != "-" {
+		t.Errorf("Rate with zero duration = %q", got)
+	}
+
+	counts := map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -45000: "-45,000"}
+	for n, expected := range counts {
+		if got := Count(n); got != expected {
+			t.Errorf("Count(%d) = %q, expected %q", n, got, expected)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs: no I, L, O or U,
+// so IDs cannot be misread or spell words
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// shortAlphabet is the lowercase alphabet of Short and Random, safe in file
+// names, object keys and URLs on case-insensitive systems
+const shortAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// shortLength gives Short 60 random bits, about a one in a billion chance
+// of any collision among 50,000 IDs
+const shortLength = 12
+
+// ulidLength is the length of an encoded ULID
+const ulidLength = 26
+
+// generator keeps the last timestamp and random bits so IDs generated within
+// the same millisecond still sort in creation order
+type generator struct {
+	mu     sync.Mutex
+	uuidMs uint64   // timestamp of the last UUIDv7
+	seq    uint16   // 12-bit counter of the last UUIDv7
+	ulidMs uint64   // timestamp of the last ULID
+	ulid   [10]byte // random part of the last ULID
+}
+
+var gen generator
+
+// now returns the current time in Unix milliseconds, replaced in tests
+var now = func() uint64 { return uint64(time.Now().UnixMilli()) }
+
+// random fills b from crypto/rand, which never fails on supported platforms
+func random(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("id: failed to read random bytes: %v", err))
+	}
+}
+
+// UUIDv4 returns a random RFC 9562 version 4 UUID, e.g.
+// "3b241101-e2bb-4255-8caf-4136c566a962"
+func UUIDv4() string {
+	var u [16]byte
+	random(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// UUIDv7 returns a time-ordered RFC 9562 version 7 UUID. IDs from one process
+// sort in creation order, which keeps them close together in database
+// indexes and object listings.
+func UUIDv7() string {
+	var u [16]byte
+	random(u[8:])
+
+	gen.mu.Lock()
+	ms := now()
+	if ms <= gen.uuidMs {
+		// Same millisecond or clock moved back: count up, borrowing the next
+		// millisecond when the 12-bit counter overflows
+		ms = gen.uuidMs
+		gen.seq++
+		if gen.seq > 0xfff {
+			ms++
+			gen.seq = 0
+		}
+	} else {
+		// Start low so the counter rarely overflows
+		var b [2]byte
+		random(b[:])
+		gen.seq = binary.BigEndian.Uint16(b[:]) & 0x7ff
+	}
+	gen.uuidMs = ms
+	seq := gen.seq
+	gen.mu.Unlock()
+
+	binary.BigEndian.PutUint64(u[:8], ms<<16)
+	u[6] = 0x70 | byte(seq>>8)
+	u[7] = byte(seq)
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// formatUUID formats u in the canonical 8-4-4-4-12 form
+func formatUUID(u [16]byte) string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// ULID returns a 26 character Universally Unique Lexicographically Sortable
+// Identifier, e.g. "01HZX3J8K9M2N4P6Q8R0S2T4V6". ULIDs from one process sort in
+// creation order, also within the same millisecond.
+func ULID() string {
+	var u [16]byte
+
+	gen.mu.Lock()
+	ms := now()
+	if ms <= gen.ulidMs {
+		ms = gen.ulidMs
+		if !increment(gen.ulid[:]) {
+			// The random part overflowed, move to the next millisecond
+			ms++
+			random(gen.ulid[:])
+		}
+	} else {
+		random(gen.ulid[:])
+	}
+	gen.ulidMs = ms
+	copy(u[6:], gen.ulid[:])
+	gen.mu.Unlock()
+
+	u[0], u[1], u[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	u[3], u[4], u[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	return encodeULID(u)
+}
+
+// increment adds one to a big-endian number, reporting false on overflow
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID encodes 128 bits as 26 Crockford base32 characters
+func encodeULID(u [16]byte) string {
+	var b [ulidLength]byte
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	// 26 characters hold 130 bits, so the first character holds the top 3
+	for i := ulidLength - 1; i >= 0; i-- {
+		b[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(b[:])
+}
+
+// Time returns the creation time encoded in a ULID or UUIDv7, with
+// millisecond precision
+func Time(id string) (time.Time, error) {
+	switch {
+	case len(id) == ulidLength:
+		if id[0] > '7' {
+			return time.Time{}, fmt.Errorf("invalid ULID %q", id)
+		}
+		var ms uint64
+		for _, c := range strings.ToUpper(id[:10]) {
+			i := strings.IndexRune(crockford, c)
+			if i < 0 {
+				return time.Time{}, fmt.Errorf("invalid ULID %q", id)
+			}
+			ms = ms<<5 | uint64(i)
+		}
+		return time.UnixMilli(int64(ms)), nil
+	case len(id) == 36 && id[14] == '7':
+		raw, err := hex.DecodeString(strings.ReplaceAll(id[:13], "-", ""))
+		if err != nil || strings.Count(id, "-") != 4 {
+			return time.Time{}, fmt.Errorf("invalid UUID %q", id)
+		}
+		var ms uint64
+		for _, b := range raw {
+			ms = ms<<8 | uint64(b)
+		}
+		return time.UnixMilli(int64(ms)), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a ULID or version 7 UUID", id)
+}
+
+// Random returns n random characters from a lowercase alphabet without
+// ambiguous letters, each carrying 5 bits
+func Random(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	random(b)
+	for i := range b {
+		b[i] = shortAlphabet[b[i]&0x1f]
+	}
+	return string(b)
+}
+
+// Short returns a 12 character random ID such as "k3f9x2m7q1zc", for temp
+// object keys, session file names and correlation IDs
+func Short() string {
+	return Random(shortLength)
+}
+
+// RunID returns a readable, sortable ID for jobs and runs such as
+// "orders-20240601-150405-k3f9x2m7". An empty prefix is omitted.
+func RunID(prefix string) string {
+	id := time.Now().Format("20060102-150405") + "-" + Random(8)
+	if prefix == "" {
+		return id
+	}
+	return prefix + "-" + id
+}
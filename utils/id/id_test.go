@@ -0,0 +1,109 @@
+package id
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+var (
+	uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+// generate returns n IDs and fails on duplicates
+func generate(t *testing.T, n int, fn func() string) []string {
+	t.Helper()
+	seen := make(map[string]bool, n)
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fn()
+		if seen[ids[i]] {
+			t.Fatalf("duplicate ID %q after %d", ids[i], i)
+		}
+		seen[ids[i]] = true
+	}
+	return ids
+}
+
+func TestUUID(t *testing.T) {
+	for _, u := range generate(t, 1000, UUIDv4) {
+		if !uuidV4Pattern.MatchString(u) {
+			t.Fatalf("invalid UUIDv4 %q", u)
+		}
+	}
+
+	ids := generate(t, 10000, UUIDv7)
+	for _, u := range ids {
+		if !uuidV7Pattern.MatchString(u) {
+			t.Fatalf("invalid UUIDv7 %q", u)
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Error("UUIDv7 values are not in creation order")
+	}
+	created, err := Time(ids[0])
+	if err != nil || time.Since(created) > time.Minute || time.Since(created) < 0 {
+		t.Errorf("Time(%q) = %v, %v", ids[0], created, err)
+	}
+}
+
+func TestULID(t *testing.T) {
+	ids := generate(t, 10000, ULID)
+	for _, u := range ids {
+		if !ulidPattern.MatchString(u) {
+			t.Fatalf("invalid ULID %q", u)
+		}
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Error("ULIDs are not in creation order")
+	}
+
+	// A known timestamp encodes into the first 10 characters
+	defer func(original func() uint64) { now = original }(now)
+	now = func() uint64 { return 1717200000000 }
+	gen.mu.Lock()
+	gen.ulidMs = 0
+	gen.mu.Unlock()
+	u := ULID()
+	if u[:10] != "01HZ8HH500" {
+		t.Errorf("ULID timestamp = %s, expected 01HZ8HH500", u[:10])
+	}
+	created, err := Time(u)
+	if err != nil || !created.Equal(time.UnixMilli(1717200000000)) {
+		t.Errorf("Time(%q) = %v, %v", u, created, err)
+	}
+	// A clock moving back keeps the order
+	now = func() uint64 { return 1717199999000 }
+	if next := ULID(); next <= u {
+		t.Errorf("ULID %s after clock moved back sorts before %s", next, u)
+	}
+
+	for _, invalid := range []string{"", "not-an-id", "81HZ8HH500ABCDEFGHJKMNPQRS", "01HZ8HH50UABCDEFGHJKMNPQRS", UUIDv4()} {
+		if _, err := Time(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestShortAndRunID(t *testing.T) {
+	short := regexp.MustCompile(`^[0-9a-hjkmnp-tv-z]{12}$`)
+	for _, s := range generate(t, 10000, Short) {
+		if !short.MatchString(s) {
+			t.Fatalf("invalid short ID %q", s)
+		}
+	}
+	if Random(0) != "" || len(Random(30)) != 30 {
+		t.Error("Random returned unexpected length")
+	}
+
+	run := regexp.MustCompile(`^orders-\d{8}-\d{6}-[0-9a-z]{8}$`)
+	if id := RunID("orders"); !run.MatchString(id) {
+		t.Errorf("RunID = %q", id)
+	}
+	if id := RunID(""); len(id) != len("20060102-150405-")+8 {
+		t.Errorf("RunID without prefix = %q", id)
+	}
+}
@@ -0,0 +1,92 @@
+package integrity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+)
+
+// Change is a file whose content or permissions differ from the baseline
+type Change struct {
+	Path string `json:"path"`
+	Old  File   `json:"old"`
+	New  File   `json:"new"`
+	// Reasons lists what changed: "content" and/or "mode"
+	Reasons []string `json:"reasons"`
+}
+
+// Diff is the difference between a baseline and the current tree
+type Diff struct {
+	Added   []File   `json:"added"`
+	Changed []Change `json:"changed"`
+	Removed []File   `json:"removed"`
+}
+
+// compare merges two path-sorted file lists. Modification times alone do not
+// count as a change, since copies and extractions reset them.
+func compare(baseline, current []File) *Diff {
+	diff := &Diff{}
+	i, j := 0, 0
+	for i < len(baseline) || j < len(current) {
+		switch {
+		case j == len(current) || (i < len(baseline) && baseline[i].Path < current[j].Path):
+			diff.Removed = append(diff.Removed, baseline[i])
+			i++
+		case i == len(baseline) || current[j].Path < baseline[i].Path:
+			diff.Added = append(diff.Added, current[j])
+			j++
+		default:
+			old, cur := baseline[i], current[j]
+			var reasons []string
+			if old.Size != cur.Size || old.Hash != cur.Hash {
+				reasons = append(reasons, "content")
+			}
+			if old.Mode != cur.Mode {
+				reasons = append(reasons, "mode")
+			}
+			if len(reasons) > 0 {
+				diff.Changed = append(diff.Changed, Change{Path: old.Path, Old: old, New: cur, Reasons: reasons})
+			}
+			i++
+			j++
+		}
+	}
+	return diff
+}
+
+// Clean reports whether the tree matches the baseline
+func (d *Diff) Clean() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// Summary returns the counts, e.g. "1 added, 2 changed, 0 removed"
+func (d *Diff) Summary() string {
+	return fmt.Sprintf("%d added, %d changed, %d removed", len(d.Added), len(d.Changed), len(d.Removed))
+}
+
+// String lists every difference on its own line, prefixed with "+" for added,
+// "~" for changed and "-" for removed files
+func (d *Diff) String() string {
+	var b strings.Builder
+	for _, f := range d.Added {
+		fmt.Fprintf(&b, "+ %s (%d bytes)\n", f.Path, f.Size)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s (%s)\n", c.Path, strings.Join(c.Reasons, ", "))
+	}
+	for _, f := range d.Removed {
+		fmt.Fprintf(&b, "- %s\n", f.Path)
+	}
+	return b.String()
+}
+
+// Save writes the diff as an indented JSON report
+func (d *Diff) Save(path string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode diff: %w", err)
+	}
+	return atomicfile.WriteFile(path, data)
+}
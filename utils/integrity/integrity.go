@@ -0,0 +1,150 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/dirwalk"
+	"github.com/romisugianto/go-utils/utils/pool"
+)
+
+// Options selects the files of a baseline and how they are hashed
+type Options struct {
+	// Algorithm defaults to SHA-256
+	Algorithm checksum.Algorithm `json:"algorithm"`
+	// Include and Exclude are globs matched against the relative path and
+	// the base name, as in dirwalk
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// SkipHidden skips dot-prefixed files and directories
+	SkipHidden bool `json:"skip_hidden,omitempty"`
+	// Concurrency is the number of files hashed in parallel (default GOMAXPROCS)
+	Concurrency int `json:"-"`
+}
+
+// File is the recorded state of one file
+type File struct {
+	// Path is slash-separated and relative to the root
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	Hash    string      `json:"hash"`
+}
+
+// Manifest is a baseline of a directory tree
+type Manifest struct {
+	Root    string    `json:"root"`
+	Created time.Time `json:"created"`
+	Options Options   `json:"options"`
+	// Files are sorted by path
+	Files []File `json:"files"`
+}
+
+// Baseline hashes every file under root into a manifest. A nil opts hashes
+// all files with SHA-256.
+func Baseline(ctx context.Context, root string, opts *Options) (*Manifest, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Algorithm == "" {
+		o.Algorithm = checksum.SHA256
+	}
+	if _, err := checksum.NewHash(o.Algorithm); err != nil {
+		return nil, err
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	files, err := scan(ctx, absRoot, o)
+	if err != nil {
+		return nil, err
+	}
+	return &Manifest{Root: absRoot, Created: time.Now(), Options: o, Files: files}, nil
+}
+
+// scan walks root and hashes the selected files in parallel
+func scan(ctx context.Context, root string, o Options) ([]File, error) {
+	entries, err := dirwalk.Collect(ctx, root, &dirwalk.Options{
+		Include:    o.Include,
+		Exclude:    o.Exclude,
+		SkipHidden: o.SkipHidden,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, len(entries))
+	indexes := make([]int, len(entries))
+	for i, e := range entries {
+		indexes[i] = i
+		files[i] = File{Path: e.RelPath, Size: e.Size(), Mode: e.Mode().Perm(), ModTime: e.ModTime()}
+	}
+
+	workers := o.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	err = pool.ForEach(ctx, workers, indexes, func(ctx context.Context, i int) error {
+		sum, err := checksum.SumFile(entries[i].Path, o.Algorithm)
+		if err != nil {
+			return err
+		}
+		files[i].Hash = sum
+		return nil
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Check rescans the tree with the manifest's options and compares it with
+// the baseline. An empty root checks the manifest's own root, e.g. after
+// the archive was moved.
+func (m *Manifest) Check(ctx context.Context, root string) (*Diff, error) {
+	if root == "" {
+		root = m.Root
+	}
+	current, err := scan(ctx, root, m.Options)
+	if err != nil {
+		return nil, err
+	}
+	return compare(m.Files, current), nil
+}
+
+// Save writes the manifest as indented JSON, replacing the file atomically
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return atomicfile.WriteFile(path, data)
+}
+
+// Load reads a manifest written by Save
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,131 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBaselineAndCheck(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"orders.csv":          "id\n1\n",
+		"2024/06/returns.csv": "id\n2\n",
+		"2024/06/notes.txt":   "keep",
+		"readme.txt":          "hello",
+	})
+	ctx := context.Background()
+
+	manifest, err := Baseline(ctx, root, &Options{Exclude: []string{"*.tmp"}, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Baseline failed: %v", err)
+	}
+	if manifest.Options.Algorithm != checksum.SHA256 || len(manifest.Files) != 4 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	if manifest.Files[0].Path != "2024/06/notes.txt" || manifest.Files[3].Path != "readme.txt" {
+		t.Errorf("expected files sorted by path, got %+v", manifest.Files)
+	}
+	expected, _ := checksum.SumFile(filepath.Join(root, "orders.csv"), checksum.SHA256)
+	if manifest.Files[2].Hash != expected || manifest.Files[2].Size != 5 {
+		t.Errorf("unexpected entry %+v", manifest.Files[2])
+	}
+
+	diff, err := manifest.Check(ctx, "")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !diff.Clean() {
+		t.Errorf("expected clean diff, got %s", diff)
+	}
+
+	// Tamper with the tree; same-size edits must be detected by hash
+	writeFiles(t, root, map[string]string{"orders.csv": "id\n9\n", "new.csv": "x", "ignored.tmp": "x"})
+	os.Remove(filepath.Join(root, "readme.txt"))
+	os.Chmod(filepath.Join(root, "2024", "06", "notes.txt"), 0600)
+
+	diff, err = manifest.Check(ctx, root)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if diff.Summary() != "1 added, 2 changed, 1 removed" {
+		t.Fatalf("Summary() = %q:\n%s", diff.Summary(), diff)
+	}
+	if diff.Added[0].Path != "new.csv" || diff.Removed[0].Path != "readme.txt" {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+	if c := diff.Changed[0]; c.Path != "2024/06/notes.txt" || strings.Join(c.Reasons, ",") != "mode" {
+		t.Errorf("unexpected mode change %+v", c)
+	}
+	if c := diff.Changed[1]; c.Path != "orders.csv" || strings.Join(c.Reasons, ",") != "content" || c.Old.Hash == c.New.Hash {
+		t.Errorf("unexpected content change %+v", c)
+	}
+	if s := diff.String(); !strings.Contains(s, "+ new.csv (1 bytes)\n") || !strings.Contains(s, "~ orders.csv (content)\n") || !strings.Contains(s, "- readme.txt\n") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.csv": "a", "b.csv": "b"})
+	ctx := context.Background()
+
+	manifest, err := Baseline(ctx, root, &Options{Algorithm: checksum.MD5})
+	if err != nil {
+		t.Fatalf("Baseline failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "baselines", "delivery.json")
+	if err := manifest.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Root != manifest.Root || loaded.Options.Algorithm != checksum.MD5 || len(loaded.Files) != 2 || loaded.Files[0].Hash != manifest.Files[0].Hash {
+		t.Errorf("loaded manifest %+v differs from %+v", loaded, manifest)
+	}
+
+	// A moved copy of the tree is checked against the same baseline
+	moved := t.TempDir()
+	writeFiles(t, moved, map[string]string{"a.csv": "a", "b.csv": "changed"})
+	diff, err := loaded.Check(ctx, moved)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Path != "b.csv" {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+	report := filepath.Join(t.TempDir(), "diff.json")
+	if err := diff.Save(report); err != nil {
+		t.Fatalf("Save diff failed: %v", err)
+	}
+	if data, _ := os.ReadFile(report); !strings.Contains(string(data), `"path": "b.csv"`) {
+		t.Errorf("unexpected diff report %s", data)
+	}
+
+	if _, err := Load(filepath.Join(root, "a.csv")); err == nil {
+		t.Error("expected error for invalid manifest")
+	}
+	if _, err := Baseline(ctx, root, &Options{Algorithm: "crc64"}); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+	if _, err := Baseline(ctx, filepath.Join(root, "missing"), nil); err == nil {
+		t.Error("expected error for missing root")
+	}
+}
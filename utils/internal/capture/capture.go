@@ -0,0 +1,85 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package capture
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// MaxCapture bounds the output kept by a TailBuffer; earlier output is
+// dropped
+const MaxCapture = 64 << 10
+
+// Writers combines the non-nil writers
+func Writers(ws ...io.Writer) io.Writer {
+	var out []io.Writer
+	for _, w := range ws {
+		if w != nil {
+			out = append(out, w)
+		}
+	}
+	return io.MultiWriter(out...)
+}
+
+// LineWriter calls a function for each complete line written to it
+type LineWriter struct {
+	fn      func(line string)
+	pending []byte
+}
+
+// NewLineWriter returns a LineWriter calling fn, or discarding the output
+// when fn is nil
+func NewLineWriter(fn func(line string)) *LineWriter {
+	return &LineWriter{fn: fn}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	if w.fn == nil {
+		return len(p), nil
+	}
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(strings.TrimRight(string(w.pending[:i]), "\r"))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush emits a last line without a trailing newline
+func (w *LineWriter) Flush() {
+	if w.fn != nil && len(w.pending) > 0 {
+		w.fn(strings.TrimRight(string(w.pending), "\r"))
+		w.pending = nil
+	}
+}
+
+// TailBuffer keeps the last MaxCapture bytes written to it
+type TailBuffer struct {
+	buf []byte
+}
+
+func (b *TailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > MaxCapture {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-MaxCapture:]...)
+	}
+	return len(p), nil
+}
+
+func (b *TailBuffer) String() string {
+	return string(b.buf)
+}
+
+// LastLine returns the last non-empty line of s
+func LastLine(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
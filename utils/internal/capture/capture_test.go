@@ -0,0 +1,37 @@
+package capture
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := NewLineWriter(func(line string) { lines = append(lines, line) })
+	fmt.Fprint(w, "first\r\nsec")
+	fmt.Fprint(w, "ond\nlast")
+	if len(lines) != 2 || lines[0] != "first" || lines[1] != "second" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+	w.Flush()
+	if len(lines) != 3 || lines[2] != "last" {
+		t.Errorf("expected the last line on Flush, got %q", lines)
+	}
+
+	if n, err := NewLineWriter(nil).Write([]byte("dropped\n")); n != 8 || err != nil {
+		t.Errorf("Write without a function = %d, %v", n, err)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	var b TailBuffer
+	fmt.Fprint(&b, strings.Repeat("a", MaxCapture))
+	fmt.Fprint(&b, "tail\n")
+	if len(b.String()) != MaxCapture || !strings.HasSuffix(b.String(), "tail\n") {
+		t.Errorf("expected the last %d bytes, got %d", MaxCapture, len(b.String()))
+	}
+	if got := LastLine("one\n  two \r\n"); got != "two" {
+		t.Errorf("LastLine = %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package iocopy
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/ratelimit"
+)
+
+// DefaultBufferSize is the copy buffer size used when Options.BufferSize is 0
+const DefaultBufferSize = 32 * 1024
+
+// Progress describes a copy in flight
+type Progress struct {
+	Bytes   int64         // bytes written so far
+	Total   int64         // expected size from Options.Total, 0 when unknown
+	Elapsed time.Duration // time since the copy started
+}
+
+// Percent returns the share of Total written so far, or -1 when Total is unknown
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Bytes) / float64(p.Total) * 100
+}
+
+// Options controls a copy. The zero value copies like io.Copy but stops when
+// the context is done.
+type Options struct {
+	// Limiter throttles the copy in bytes per second, e.g.
+	// ratelimit.NewLimiter(10<<20, 1<<20) for 10 MiB/s; nil is unlimited
+	Limiter *ratelimit.Limiter
+	// Algorithms lists checksums computed while copying, e.g. checksum.SHA256
+	Algorithms []checksum.Algorithm
+	// Total is the expected size reported in Progress (0 when unknown)
+	Total int64
+	// OnProgress is called after writes, at most once per ProgressInterval,
+	// and once more when the copy finishes
+	OnProgress func(p Progress)
+	// ProgressInterval throttles OnProgress calls (0 calls it after every write)
+	ProgressInterval time.Duration
+	// BufferSize sets the copy buffer size (defaults to DefaultBufferSize)
+	BufferSize int
+}
+
+// Result reports the outcome of a copy
+type Result struct {
+	Bytes     int64
+	Duration  time.Duration
+	Checksums map[checksum.Algorithm]string // lowercase hex digests
+}
+
+// Rate formats the average throughput, e.g. "12.5 MiB/s"
+func (r *Result) Rate() string {
+	return humanize.Rate(r.Bytes, r.Duration)
+}
+
+// Copy copies src to dst until EOF, an error or ctx is done. It throttles,
+// reports progress and computes checksums as configured by opts, which may
+// be nil. On error the returned Result holds the bytes written so far and no
+// checksums.
+func Copy(ctx context.Context, dst io.Writer, src io.Reader, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	result := &Result{}
+
+	hashes := make(map[checksum.Algorithm]hash.Hash, len(opts.Algorithms))
+	writers := []io.Writer{dst}
+	for _, algo := range opts.Algorithms {
+		h, err := checksum.NewHash(algo)
+		if err != nil {
+			return result, err
+		}
+		hashes[algo] = h
+		writers = append(writers, h)
+	}
+	if len(writers) > 1 {
+		dst = io.MultiWriter(writers...)
+	}
+	src = ratelimit.NewReader(ctx, src, opts.Limiter)
+
+	size := opts.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	buf := make([]byte, size)
+
+	start := time.Now()
+	var lastReport time.Time
+	report := func(final bool) {
+		if opts.OnProgress == nil {
+			return
+		}
+		now := time.Now()
+		if !final && opts.ProgressInterval > 0 && now.Sub(lastReport) < opts.ProgressInterval {
+			return
+		}
+		lastReport = now
+		opts.OnProgress(Progress{Bytes: result.Bytes, Total: opts.Total, Elapsed: now.Sub(start)})
+	}
+
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			written, err := dst.Write(buf[:n])
+			result.Bytes += int64(written)
+			if err == nil && written < n {
+				err = io.ErrShortWrite
+			}
+			if err != nil {
+				result.Duration = time.Since(start)
+				return result, fmt.Errorf("failed to write: %w", err)
+			}
+			report(false)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			result.Duration = time.Since(start)
+			return result, readErr
+		}
+	}
+
+	result.Duration = time.Since(start)
+	if len(hashes) > 0 {
+		result.Checksums = make(map[checksum.Algorithm]string, len(hashes))
+		for algo, h := range hashes {
+			result.Checksums[algo] = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	report(true)
+	return result, nil
+}
+
+// Verify copies src to dst like Copy and checks the result against an
+// expected hex digest, returning an error wrapping checksum.ErrMismatch when
+// they differ. The caller should discard dst after a mismatch.
+func Verify(ctx context.Context, dst io.Writer, src io.Reader, algo checksum.Algorithm, expected string, opts *Options) (*Result, error) {
+	copied := Options{}
+	if opts != nil {
+		copied = *opts
+	}
+	copied.Algorithms = append([]checksum.Algorithm{algo}, copied.Algorithms...)
+
+	result, err := Copy(ctx, dst, src, &copied)
+	if err != nil {
+		return result, err
+	}
+	if actual := result.Checksums[algo]; !strings.EqualFold(actual, expected) {
+		return result, fmt.Errorf("%w: expected %s %s, got %s", checksum.ErrMismatch, algo, expected, actual)
+	}
+	return result, nil
+}
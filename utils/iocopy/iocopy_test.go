@@ -0,0 +1,114 @@
+package iocopy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/ratelimit"
+)
+
+func TestCopy(t *testing.T) {
+	data := strings.Repeat("0123456789", 1000)
+	var dst bytes.Buffer
+	var reports []Progress
+
+	result, err := Copy(context.Background(), &dst, strings.NewReader(data), &Options{
+		Algorithms: []checksum.Algorithm{checksum.SHA256, checksum.MD5},
+		Total:      int64(len(data)),
+		BufferSize: 4096,
+		OnProgress: func(p Progress) { reports = append(reports, p) },
+	})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if dst.String() != data || result.Bytes != int64(len(data)) {
+		t.Fatalf("copied %d bytes, expected %d", result.Bytes, len(data))
+	}
+
+	expected, _ := checksum.Sum(strings.NewReader(data), checksum.SHA256)
+	if got := result.Checksums[checksum.SHA256]; got != expected {
+		t.Errorf("sha256 = %q", got)
+	}
+	if len(result.Checksums[checksum.MD5]) != 32 {
+		t.Errorf("md5 = %q", result.Checksums[checksum.MD5])
+	}
+
+	// Two full buffers, one partial and the final report
+	if len(reports) != 3+1 {
+		t.Fatalf("expected 4 progress reports, got %d", len(reports))
+	}
+	last := reports[len(reports)-1]
+	if last.Bytes != int64(len(data)) || last.Percent() != 100 {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+	if reports[0].Bytes != 4096 {
+		t.Errorf("unexpected first progress %+v", reports[0])
+	}
+}
+
+func TestCopyNilOptions(t *testing.T) {
+	var dst bytes.Buffer
+	result, err := Copy(context.Background(), &dst, strings.NewReader("hello"), nil)
+	if err != nil || result.Bytes != 5 || result.Checksums != nil {
+		t.Errorf("Copy = %+v, %v", result, err)
+	}
+	if (Progress{Bytes: 5}).Percent() != -1 {
+		t.Error("expected -1 percent for unknown total")
+	}
+}
+
+func TestCopyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var dst bytes.Buffer
+	_, err := Copy(ctx, &dst, strings.NewReader("data"), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if dst.Len() != 0 {
+		t.Errorf("expected nothing copied, got %q", dst.String())
+	}
+}
+
+func TestCopyThrottled(t *testing.T) {
+	// 2 KB burst then 20 KB/s: the remaining 2 KB take about 100ms
+	limiter := ratelimit.NewLimiter(20*1024, 2*1024)
+	var dst bytes.Buffer
+
+	result, err := Copy(context.Background(), &dst, bytes.NewReader(make([]byte, 4*1024)), &Options{Limiter: limiter})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if result.Duration < 80*time.Millisecond {
+		t.Errorf("copy finished in %v, expected throttling", result.Duration)
+	}
+	if result.Rate() == "-" {
+		t.Errorf("unexpected rate %q", result.Rate())
+	}
+}
+
+func TestVerify(t *testing.T) {
+	data := "payload"
+	sum, _ := checksum.Sum(strings.NewReader(data), checksum.SHA256)
+	sum = strings.ToUpper(sum)
+
+	var dst bytes.Buffer
+	if _, err := Verify(context.Background(), &dst, strings.NewReader(data), checksum.SHA256, sum, nil); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+
+	dst.Reset()
+	_, err := Verify(context.Background(), &dst, strings.NewReader("tampered"), checksum.SHA256, sum, nil)
+	if !errors.Is(err, checksum.ErrMismatch) {
+		t.Errorf("expected ErrMismatch, got %v", err)
+	}
+	if _, err := Copy(context.Background(), &dst, strings.NewReader(data), &Options{Algorithms: []checksum.Algorithm{"sha3"}}); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
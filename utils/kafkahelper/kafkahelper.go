@@ -0,0 +1,278 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package kafkahelper
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/romisugianto/go-utils/utils/pipeline"
+)
+
+// Producer publishes messages to Kafka topics. The connection is set up on
+// first use and messages are sent in batches per partition.
+type Producer struct {
+	// Brokers lists bootstrap servers, e.g. []string{"kafka-1:9092", "kafka-2:9092"}
+	Brokers []string
+	// Topic is used for messages that do not set their own
+	Topic string
+	// ClientID identifies the producer in broker logs and quotas
+	ClientID string
+
+	// Username and Password enable SASL authentication
+	Username string
+	Password string
+	// Mechanism is the SASL mechanism: "PLAIN" (default), "SCRAM-SHA-256"
+	// or "SCRAM-SHA-512"
+	Mechanism string
+	// TLS encrypts connections to the brokers
+	TLS bool
+	// InsecureSkipVerify disables certificate verification (testing only)
+	InsecureSkipVerify bool
+
+	// BatchSize is the maximum number of messages per batch (default 100)
+	BatchSize int
+	// BatchTimeout is how long a partial batch waits for more messages
+	// (default 50ms)
+	BatchTimeout time.Duration
+	// Compression is "gzip", "snappy", "lz4", "zstd" or empty for none
+	Compression string
+	// Acks is "all" (default), "one" or "none"
+	Acks string
+	// MaxAttempts bounds delivery attempts per batch (default 3)
+	MaxAttempts int
+	// WriteTimeout bounds each write to a broker (default 10s)
+	WriteTimeout time.Duration
+
+	mu     sync.Mutex
+	writer messageWriter
+}
+
+// messageWriter is the part of *kafka.Writer used by the producer
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Message is a record to publish
+type Message struct {
+	// Topic overrides Producer.Topic
+	Topic string
+	// Key selects the partition; messages with the same key keep their order
+	Key     string
+	Value   []byte
+	Headers map[string]string
+	// Time defaults to the time of sending
+	Time time.Time
+}
+
+// Failure is a message that could not be delivered
+type Failure struct {
+	Message Message
+	Err     error
+}
+
+// DeliveryError is returned by Send when some messages were not delivered.
+// The other messages were.
+type DeliveryError struct {
+	Failed []Failure
+	Total  int
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("failed to deliver %d of %d messages: %v", len(e.Failed), e.Total, e.Failed[0].Err)
+}
+
+// Unwrap returns the errors of the failed messages
+func (e *DeliveryError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, failure := range e.Failed {
+		errs[i] = failure.Err
+	}
+	return errs
+}
+
+// getWriter creates the writer on first use
+func (p *Producer) getWriter() (messageWriter, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.writer != nil {
+		return p.writer, nil
+	}
+	if len(p.Brokers) == 0 {
+		return nil, fmt.Errorf("brokers cannot be empty")
+	}
+
+	transport := &kafka.Transport{ClientID: p.ClientID}
+	if p.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: p.InsecureSkipVerify}
+	}
+	if p.Username != "" {
+		mechanism, err := p.saslMechanism()
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(p.Brokers...),
+		Balancer:     &kafka.Hash{},
+		BatchSize:    p.BatchSize,
+		BatchTimeout: p.BatchTimeout,
+		MaxAttempts:  p.MaxAttempts,
+		WriteTimeout: p.WriteTimeout,
+		RequiredAcks: kafka.RequireAll,
+		Transport:    transport,
+	}
+	if writer.BatchSize <= 0 {
+		writer.BatchSize = 100
+	}
+	if writer.BatchTimeout <= 0 {
+		writer.BatchTimeout = 50 * time.Millisecond
+	}
+	if writer.MaxAttempts <= 0 {
+		writer.MaxAttempts = 3
+	}
+	if writer.WriteTimeout <= 0 {
+		writer.WriteTimeout = 10 * time.Second
+	}
+
+	switch strings.ToLower(p.Acks) {
+	case "", "all":
+	case "one":
+		writer.RequiredAcks = kafka.RequireOne
+	case "none":
+		writer.RequiredAcks = kafka.RequireNone
+	default:
+		return nil, fmt.Errorf("unsupported acks %q", p.Acks)
+	}
+
+	switch strings.ToLower(p.Compression) {
+	case "":
+	case "gzip":
+		writer.Compression = kafka.Gzip
+	case "snappy":
+		writer.Compression = kafka.Snappy
+	case "lz4":
+		writer.Compression = kafka.Lz4
+	case "zstd":
+		writer.Compression = kafka.Zstd
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", p.Compression)
+	}
+
+	p.writer = writer
+	return writer, nil
+}
+
+// saslMechanism returns the configured SASL mechanism
+func (p *Producer) saslMechanism() (sasl.Mechanism, error) {
+	switch strings.ToUpper(p.Mechanism) {
+	case "", "PLAIN":
+		return plain.Mechanism{Username: p.Username, Password: p.Password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, p.Username, p.Password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, p.Username, p.Password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q", p.Mechanism)
+	}
+}
+
+// Send publishes messages and waits until the brokers acknowledge them. When
+// some messages fail, the error is a *DeliveryError listing them.
+func (p *Producer) Send(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	writer, err := p.getWriter()
+	if err != nil {
+		return err
+	}
+
+	records := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		topic := msg.Topic
+		if topic == "" {
+			topic = p.Topic
+		}
+		if topic == "" {
+			return fmt.Errorf("message %d has no topic and Topic is not set", i)
+		}
+		records[i] = kafka.Message{Topic: topic, Value: msg.Value, Time: msg.Time}
+		if msg.Key != "" {
+			records[i].Key = []byte(msg.Key)
+		}
+		for key, value := range msg.Headers {
+			records[i].Headers = append(records[i].Headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+	}
+
+	err = writer.WriteMessages(ctx, records...)
+	if err == nil {
+		return nil
+	}
+	var writeErrs kafka.WriteErrors
+	if !errors.As(err, &writeErrs) || len(writeErrs) != len(msgs) {
+		return fmt.Errorf("failed to deliver %d messages: %w", len(msgs), err)
+	}
+	deliveryErr := &DeliveryError{Total: len(msgs)}
+	for i, writeErr := range writeErrs {
+		if writeErr != nil {
+			deliveryErr.Failed = append(deliveryErr.Failed, Failure{Message: msgs[i], Err: writeErr})
+		}
+	}
+	return deliveryErr
+}
+
+// SendJSON publishes v encoded as JSON with the given key and headers
+func (p *Producer) SendJSON(ctx context.Context, key string, v any, headers map[string]string) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %v", err)
+	}
+	return p.Send(ctx, Message{Key: key, Value: value, Headers: headers})
+}
+
+// PipelineHook returns a pipeline OnFinish hook that publishes every run
+// report as JSON, keyed by pipeline name with "pipeline", "run_id" and
+// "status" headers. Delivery failures are logged.
+func (p *Producer) PipelineHook() func(ctx context.Context, report *pipeline.Report) {
+	return func(ctx context.Context, report *pipeline.Report) {
+		err := p.SendJSON(ctx, report.Pipeline, report, map[string]string{
+			"pipeline": report.Pipeline,
+			"run_id":   report.RunID,
+			"status":   string(report.Status),
+		})
+		if err != nil {
+			log.Printf("Failed to publish report for run %s: %v", report.RunID, err)
+		}
+	}
+}
+
+// Close flushes pending messages and closes the connections
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.writer == nil {
+		return nil
+	}
+	err := p.writer.Close()
+	p.writer = nil
+	if err != nil {
+		return fmt.Errorf("failed to close producer: %w", err)
+	}
+	return nil
+}
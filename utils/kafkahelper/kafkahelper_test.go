@@ -0,0 +1,172 @@
+package kafkahelper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/romisugianto/go-utils/utils/pipeline"
+)
+
+// fakeWriter records written messages
+type fakeWriter struct {
+	written []kafka.Message
+	err     error
+	closed  bool
+}
+
+func (f *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+func (f *fakeWriter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newFakeProducer() (*Producer, *fakeWriter) {
+	fake := &fakeWriter{}
+	return &Producer{Topic: "records", writer: fake}, fake
+}
+
+func TestSend(t *testing.T) {
+	producer, fake := newFakeProducer()
+
+	err := producer.Send(context.Background(),
+		Message{Key: "orders.csv", Value: []byte("line 1"), Headers: map[string]string{"part": "1"}},
+		Message{Topic: "audit", Value: []byte("split done")},
+	)
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(fake.written) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(fake.written))
+	}
+	first, second := fake.written[0], fake.written[1]
+	if first.Topic != "records" || string(first.Key) != "orders.csv" || string(first.Value) != "line 1" {
+		t.Errorf("unexpected first message %+v", first)
+	}
+	if len(first.Headers) != 1 || first.Headers[0].Key != "part" || string(first.Headers[0].Value) != "1" {
+		t.Errorf("unexpected headers %v", first.Headers)
+	}
+	if second.Topic != "audit" || second.Key != nil {
+		t.Errorf("unexpected second message %+v", second)
+	}
+}
+
+func TestSendWithoutTopic(t *testing.T) {
+	producer, fake := newFakeProducer()
+	producer.Topic = ""
+	if err := producer.Send(context.Background(), Message{Value: []byte("x")}); err == nil {
+		t.Error("expected error for missing topic")
+	}
+	if len(fake.written) != 0 {
+		t.Error("no message should be written")
+	}
+}
+
+func TestSendWithoutBrokers(t *testing.T) {
+	producer := &Producer{Topic: "records"}
+	if err := producer.Send(context.Background(), Message{Value: []byte("x")}); err == nil {
+		t.Error("expected error for missing brokers")
+	}
+}
+
+func TestInvalidSettings(t *testing.T) {
+	for _, producer := range []*Producer{
+		{Brokers: []string{"localhost:9092"}, Acks: "some"},
+		{Brokers: []string{"localhost:9092"}, Compression: "brotli"},
+		{Brokers: []string{"localhost:9092"}, Username: "svc", Mechanism: "GSSAPI"},
+	} {
+		if _, err := producer.getWriter(); err == nil {
+			t.Errorf("expected error for %+v", producer)
+		}
+	}
+
+	producer := &Producer{Brokers: []string{"localhost:9092"}, Username: "svc", Password: "secret", Mechanism: "SCRAM-SHA-512", TLS: true, Compression: "zstd"}
+	writer, err := producer.getWriter()
+	if err != nil {
+		t.Fatalf("getWriter failed: %v", err)
+	}
+	w := writer.(*kafka.Writer)
+	if w.BatchSize != 100 || w.RequiredAcks != kafka.RequireAll || w.Compression != kafka.Zstd {
+		t.Errorf("unexpected writer settings %+v", w)
+	}
+	if err := producer.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestDeliveryError(t *testing.T) {
+	producer, fake := newFakeProducer()
+	refused := errors.New("leader not available")
+	fake.err = kafka.WriteErrors{nil, refused, nil}
+
+	msgs := []Message{{Value: []byte("a")}, {Value: []byte("b")}, {Value: []byte("c")}}
+	err := producer.Send(context.Background(), msgs...)
+
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("expected *DeliveryError, got %v", err)
+	}
+	if deliveryErr.Total != 3 || len(deliveryErr.Failed) != 1 || string(deliveryErr.Failed[0].Message.Value) != "b" {
+		t.Errorf("unexpected delivery error %+v", deliveryErr)
+	}
+	if !errors.Is(err, refused) {
+		t.Error("expected delivery error to wrap the message error")
+	}
+
+	fake.err = errors.New("connection refused")
+	if err := producer.Send(context.Background(), msgs...); errors.As(err, &deliveryErr) || err == nil {
+		t.Errorf("expected plain error, got %v", err)
+	}
+}
+
+func TestSendJSON(t *testing.T) {
+	producer, fake := newFakeProducer()
+	if err := producer.SendJSON(context.Background(), "orders.csv", map[string]int{"lines": 42}, nil); err != nil {
+		t.Fatalf("SendJSON failed: %v", err)
+	}
+	var decoded map[string]int
+	if err := json.Unmarshal(fake.written[0].Value, &decoded); err != nil || decoded["lines"] != 42 {
+		t.Errorf("unexpected value %s", fake.written[0].Value)
+	}
+	if err := producer.SendJSON(context.Background(), "k", make(chan int), nil); err == nil {
+		t.Error("expected error for unencodable value")
+	}
+}
+
+func TestPipelineHook(t *testing.T) {
+	producer, fake := newFakeProducer()
+	producer.PipelineHook()(context.Background(), &pipeline.Report{Pipeline: "ingest", RunID: "run-1", Status: pipeline.StatusSucceeded})
+
+	msg := fake.written[0]
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if string(msg.Key) != "ingest" || headers["run_id"] != "run-1" || headers["status"] != "succeeded" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+
+	// Failures are logged, not returned
+	fake.err = errors.New("broker down")
+	producer.PipelineHook()(context.Background(), &pipeline.Report{Pipeline: "ingest", RunID: "run-2"})
+}
+
+func TestClose(t *testing.T) {
+	producer, fake := newFakeProducer()
+	if err := producer.Close(); err != nil || !fake.closed {
+		t.Errorf("Close = %v, closed %t", err, fake.closed)
+	}
+	if err := producer.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrLocked is returned when another live process holds the lock
+var ErrLocked = errors.New("lock is held by another process")
+
+// Lock is a held single-instance lock backed by a pidfile
+type Lock struct {
+	path string
+
+	mu       sync.Mutex
+	released bool
+}
+
+// Acquire takes the lock with the given name in the system temp directory.
+// A name containing a path separator is used as the lock file path.
+//
+// If the lock file exists but its process is no longer running (e.g. after a
+// crash), the stale lock is reclaimed. If a live process holds it, the error
+// wraps ErrLocked so callers can exit cleanly.
+func Acquire(name string) (*Lock, error) {
+	if name == "" {
+		return nil, fmt.Errorf("lock name cannot be empty")
+	}
+
+	path := name
+	if !strings.ContainsRune(name, os.PathSeparator) && !strings.Contains(name, "/") {
+		path = filepath.Join(os.TempDir(), name+".lock")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	// Retry once after reclaiming a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		err := create(path)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", path, err)
+		}
+
+		pid, readErr := readPID(path)
+		if readErr == nil && processAlive(pid) {
+			return nil, fmt.Errorf("%w: %s (pid %d)", ErrLocked, path, pid)
+		}
+		if os.IsNotExist(readErr) {
+			continue
+		}
+
+		// Re-check the owner right before removing to avoid deleting a lock
+		// another process reclaimed in the meantime
+		if current, err := readPID(path); err == nil && current != pid {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lock %s: %w", path, err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+}
+
+// Path returns the lock file path
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// Release removes the lock file if it is still owned by this process.
+// It is safe to call more than once.
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return nil
+	}
+	l.released = true
+
+	pid, err := readPID(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read lock file %s: %w", l.path, err)
+	}
+	if pid != os.Getpid() {
+		return fmt.Errorf("lock file %s is now owned by pid %d", l.path, pid)
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// create atomically creates the lock file holding the current PID. The PID
+// is written to a temporary file that is then linked into place, so other
+// processes never see an empty lock file and mistake it for a stale one.
+func create(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), path)
+}
+
+// readPID returns the PID recorded in the lock file. An empty or corrupt file
+// yields PID 0, which is treated as stale.
+func readPID(path string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return 0, nil
+	}
+	return pid, nil
+}
@@ -0,0 +1,142 @@
+package lockfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nightly-export.lock")
+
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != strconv.Itoa(os.Getpid())+"\n" {
+		t.Errorf("lock file content = %q", content)
+	}
+
+	// A second acquisition while the holder is alive fails with ErrLocked
+	if _, err := Acquire(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Errorf("second Release failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("lock file should be removed after Release")
+	}
+
+	lock, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire after Release failed: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireNeverEmpty(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nightly-export.lock")
+
+	// Another process reading the lock while it is created must find the
+	// PID, or it would reclaim the lock as stale
+	done := make(chan struct{})
+	var empty atomic.Int32
+	go func() {
+		defer close(done)
+		for i := 0; i < 2000; i++ {
+			lock, err := Acquire(path)
+			if err != nil {
+				t.Errorf("Acquire failed: %v", err)
+				return
+			}
+			lock.Release()
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if n := empty.Load(); n > 0 {
+				t.Errorf("lock file was read empty %d times", n)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("expected no files left, got %d", len(entries))
+			}
+			return
+		default:
+		}
+		if content, err := os.ReadFile(path); err == nil && len(content) == 0 {
+			empty.Add(1)
+		}
+	}
+}
+
+func TestAcquireStaleLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"dead process", "999999999\n"},
+		{"corrupt content", "not-a-pid"},
+		{"empty file", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "job.lock")
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			lock, err := Acquire(path)
+			if err != nil {
+				t.Fatalf("expected stale lock to be reclaimed, got %v", err)
+			}
+			defer lock.Release()
+
+			pid, _ := readPID(path)
+			if pid != os.Getpid() {
+				t.Errorf("lock owner = %d, expected %d", pid, os.Getpid())
+			}
+		})
+	}
+}
+
+func TestAcquireByName(t *testing.T) {
+	lock, err := Acquire("go-utils-lockfile-test")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer lock.Release()
+
+	if lock.Path() != filepath.Join(os.TempDir(), "go-utils-lockfile-test.lock") {
+		t.Errorf("Path = %s", lock.Path())
+	}
+	if _, err := Acquire(""); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestReleaseNotOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.lock")
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	// Another process took over the lock file
+	os.WriteFile(path, []byte("1\n"), 0644)
+	if err := lock.Release(); err == nil {
+		t.Error("expected error releasing a lock owned by another process")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("lock owned by another process must not be removed")
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// defaultArchiveTimeout bounds each upload of ArchiveOptions
+const defaultArchiveTimeout = 5 * time.Minute
+
+// ArchiveOptions configures uploading finished log files
+type ArchiveOptions struct {
+	// Backend receives the files, e.g. an *s3helper.S3Helper
+	Backend storage.Backend
+	// Prefix is prepended to the file names, e.g. "logs/etl"
+	Prefix string
+	// Timeout bounds each upload (default 5 minutes)
+	Timeout time.Duration
+}
+
+// archiver uploads log files to a storage backend
+type archiver struct {
+	opts ArchiveOptions
+}
+
+func newArchiver(opts ArchiveOptions) (*archiver, error) {
+	if opts.Backend == nil {
+		return nil, errors.New("log archive backend cannot be nil")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultArchiveTimeout
+	}
+	return &archiver{opts: opts}, nil
+}
+
+// upload stores the file at name under Prefix/<file name>
+func (a *archiver) upload(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.opts.Timeout)
+	defer cancel()
+	key := path.Join(a.opts.Prefix, filepath.Base(name))
+	if err := storage.PutFile(ctx, a.opts.Backend, name, key); err != nil {
+		return fmt.Errorf("failed to archive log file %s: %w", name, err)
+	}
+	return nil
+}
+
+// rotated uploads a file rotated in the background, reporting failures on
+// stderr since the logger cannot log them
+func (a *archiver) rotated(name string) {
+	if err := a.upload(name); err != nil {
+		fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+	}
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Defaults of the buffered file used in async mode
+const (
+	defaultFlushInterval = time.Second
+	defaultBufferSize    = 64 * 1024
+)
+
+// bufferedFile buffers whole records in memory and writes them to the log
+// file when the buffer is full, every interval and on Sync and Close, so a
+// record is never split across rotated files
+type bufferedFile struct {
+	file logWriter
+	size int
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newBufferedFile wraps file and starts the background flusher
+func newBufferedFile(file logWriter, size int, interval time.Duration) *bufferedFile {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	b := &bufferedFile{
+		file: file,
+		size: size,
+		buf:  make([]byte, 0, size),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go b.flusher(interval)
+	return b
+}
+
+// flusher writes the buffer to the file every interval until Close
+func (b *bufferedFile) flusher(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.mu.Lock()
+			b.flush()
+			b.mu.Unlock()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+func (b *bufferedFile) Write(p []byte) (int, error) {
+	return b.WriteString(string(p))
+}
+
+// WriteString buffers one record, writing the buffer first if the record
+// does not fit. Records larger than the buffer are written directly.
+func (b *bufferedFile) WriteString(s string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, errors.New("log file is closed")
+	}
+	if len(b.buf)+len(s) > b.size {
+		if err := b.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if len(s) > b.size {
+		return b.file.WriteString(s)
+	}
+	b.buf = append(b.buf, s...)
+	return len(s), nil
+}
+
+// flush writes the buffered records to the file; b.mu must be held
+func (b *bufferedFile) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.file.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+// Sync writes the buffered records and syncs the file to disk
+func (b *bufferedFile) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	return errors.Join(b.flush(), b.file.Sync())
+}
+
+// Close stops the flusher, writes the buffered records and closes the file
+func (b *bufferedFile) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return errors.Join(b.flush(), b.file.Close())
+}
+
+func (b *bufferedFile) Name() string {
+	return b.file.Name()
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// callerInfo is the source location of a log call
+type callerInfo struct {
+	// File is the file's directory and name, e.g. "cmd/main.go"
+	File     string
+	Line     int
+	Function string
+}
+
+// String returns "file:line function"
+func (c callerInfo) String() string {
+	return c.File + ":" + strconv.Itoa(c.Line) + " " + c.Function
+}
+
+// WithCallerSkip returns a logger that reports the caller skip frames
+// further up the stack, so a helper wrapping the logger reports the line
+// that called the helper. Skips add up across derived loggers.
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	derived := l.derive()
+	derived.callerSkip = l.callerSkip + skip
+	return derived
+}
+
+// callerPC returns the program counter of the code calling a logging method
+// such as Info, or 0 if caller information is disabled. depth counts the
+// frames between callerPC and that method.
+func (l *Logger) callerPC(depth int) uintptr {
+	if !l.owner().caller {
+		return 0
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(depth+2+l.callerSkip, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// callerAt resolves a program counter; ok is false for 0
+func callerAt(pc uintptr) (c callerInfo, ok bool) {
+	if pc == 0 {
+		return c, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return c, false
+	}
+	c.File = filepath.Base(filepath.Dir(frame.File)) + "/" + filepath.Base(frame.File)
+	c.Line = frame.Line
+	// Drop the import path, keeping "package.Function"
+	c.Function = frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+	return c, true
+}
@@ -0,0 +1,44 @@
+package logger
+
+import "context"
+
+// contextKey keys the values stored in a context by this package
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	correlationIDKey
+)
+
+// WithContext returns a copy of ctx carrying l, so the steps of a pipeline
+// run can log through FromContext without passing the logger around
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// ContextWithCorrelationID returns a copy of ctx carrying a run or trace
+// ID, written with every message of the logger returned by FromContext
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationIDFromContext returns the ID set by ContextWithCorrelationID,
+// or ""
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// FromContext returns the logger stored by WithContext, tagged with the
+// correlation ID of ctx if it has one. Without a stored logger it returns
+// a logger writing to the console only.
+func FromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerKey).(*Logger)
+	if l == nil {
+		l = &Logger{}
+	}
+	if id := CorrelationIDFromContext(ctx); id != "" && id != l.correlationID {
+		l = l.WithCorrelationID(id)
+	}
+	return l
+}
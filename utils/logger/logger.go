@@ -2,90 +2,807 @@
 package logger
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/romisugianto/go-utils/utils/buildinfo"
+	"github.com/romisugianto/go-utils/utils/rotatewriter"
 )
 
 // Logger provides logging capabilities with file and console output
 type Logger struct {
-	logFile    *os.File
+	logFile    logWriter
 	logPath    string
+
+	hooksMu sync.RWMutex
+	hooks   []Hook
+
+	// outputs receive every formatted line in addition to stdout and the file
+	outputsMu sync.Mutex
+	outputs   []io.Writer
+	// syslog receives every record when set
+	syslog *syslogWriter
+	// shipper posts every record to an HTTP endpoint when set
+	shipper *shipper
+	// archiver uploads rotated and closed log files when set
+	archiver *archiver
+
+	// level is the minimum Level written, shared with derived loggers
+	level atomic.Int32
+	// consoleLevel and fileLevel are the minimum levels of stdout and the
+	// log file, on top of level
+	consoleLevel atomic.Int32
+	fileLevel    atomic.Int32
+	// format is the output format of the owner
+	format Format
+	// async is set when the log file is buffered and synced by Flush
+	async bool
+	// color is set when console lines are colored by level
+	color bool
+	// caller is set when records show the file, line and function of the
+	// logging call
+	caller bool
+	// callerSkip is the number of extra frames skipped to find the caller
+	callerSkip int
+	// exitCode is the status Fatal exits with
+	exitCode int
+
+	// redactPatterns and redactFields mask secrets before anything is written
+	redactMu       sync.RWMutex
+	redactPatterns []*regexp.Regexp
+	redactFields   map[string]bool
+
+	// repeatWindow collapses identical consecutive messages when positive
+	repeatWindow time.Duration
+	repeats      repeatState
+	// sampler drops messages of chatty levels when set
+	sampler *sampler
+
+	// correlationID is written with every message of a derived logger
+	correlationID string
+	// fields are appended to every message of a derived logger
+	fields map[string]any
+	// parent owns the log file and hooks of a derived logger
+	parent *Logger
+}
+
+// Level is the severity of a log message
+type Level int32
+
+// Levels in increasing severity. Summary messages are logged at LevelInfo.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelDebug:   "DEBUG",
+	LevelInfo:    "INFO",
+	LevelWarning: "WARNING",
+	LevelError:   "ERROR",
+	LevelFatal:   "FATAL",
+}
+
+// String returns the label written for the level, e.g. "WARNING"
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// ParseLevel parses a level name such as "debug", "info", "warn" or
+// "warning", "error" or "fatal", ignoring case, e.g. from a LOG_LEVEL
+// environment variable
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarning, nil
+	case "ERROR":
+		return LevelError, nil
+	case "FATAL":
+		return LevelFatal, nil
+	}
+	return LevelDebug, fmt.Errorf("unknown log level %q", name)
+}
+
+// levelOf returns the severity of a message label; unknown labels such as
+// "SUMMARY" are informational, and "PANIC" is fatal
+func levelOf(label string) Level {
+	if label == "PANIC" {
+		return LevelFatal
+	}
+	for level, name := range levelNames {
+		if name == label {
+			return level
+		}
+	}
+	return LevelInfo
 }
 
-// NewLogger creates a new logger instance
+// logWriter is the log file, a rotatewriter.Writer
+type logWriter interface {
+	io.WriteCloser
+	io.StringWriter
+	Sync() error
+	Name() string
+}
+
+// Hook is called with the level and formatted message of every log entry
+type Hook func(level, message string)
+
+// Format is the output format of a logger
+type Format string
+
+// Output formats
+const (
+	// FormatText writes "[2006-01-02 15:04:05] [INFO] message" lines
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line with the time, level,
+	// message and correlation ID, for ingestion by ELK or Loki
+	FormatJSON Format = "json"
+)
+
+// ColorMode controls ANSI colors on the console
+type ColorMode string
+
+// Color modes
+const (
+	// ColorAuto colors the console when stdout is a terminal, unless the
+	// NO_COLOR environment variable is set or TERM is "dumb"
+	ColorAuto ColorMode = "auto"
+	// ColorAlways colors the console, e.g. for CI logs that render ANSI codes
+	ColorAlways ColorMode = "always"
+	// ColorNever disables colors
+	ColorNever ColorMode = "never"
+)
+
+// levelColors are the ANSI colors of console lines by level
+var levelColors = map[string]string{
+	"DEBUG":   "\x1b[90m",
+	"WARNING": "\x1b[33m",
+	"ERROR":   "\x1b[31m",
+	"FATAL":   "\x1b[1;31m",
+	"PANIC":   "\x1b[1;31m",
+	"SUMMARY": "\x1b[36m",
+}
+
+// colorReset ends a colored line
+const colorReset = "\x1b[0m"
+
+// Options configures a logger created by NewLoggerWithOptions
+type Options struct {
+	// Dir is the directory of the log files (default "logs")
+	Dir string
+	// Format defaults to FormatText
+	Format Format
+	// Level is the minimum level written (default LevelDebug)
+	Level Level
+	// ConsoleLevel and FileLevel are the minimum levels written to stdout
+	// and to the log file (default LevelDebug), e.g. LevelWarning on the
+	// console of an interactive tool while the file keeps every message
+	ConsoleLevel Level
+	FileLevel    Level
+	// Caller adds the file, line and function of the logging call to every
+	// record, e.g. "[INFO] [cmd/main.go:42 main.run] message"
+	Caller bool
+	// CallerSkip skips extra frames for all records, e.g. 1 when every
+	// message goes through a helper of the application. See WithCallerSkip.
+	CallerSkip int
+	// ExitCode is the exit status of Fatal (default 1)
+	ExitCode int
+	// Redact masks DefaultRedactPatterns and DefaultRedactFields. More can be
+	// added with AddRedactPattern, AddRedactSecret and RedactFields.
+	Redact bool
+	// SuppressRepeats collapses identical consecutive messages (same level,
+	// text, correlation ID and fields) logged within this window into one
+	// "last message repeated N times" line, written when a different
+	// message arrives, when the window has passed or on Close. Hooks still
+	// receive every message. 0 disables it.
+	SuppressRepeats time.Duration
+	// Sampling limits the messages written per level, e.g.
+	// {LevelInfo: {First: 50, Thereafter: 100}} keeps the first 50 Info
+	// messages, then one in 100. Errors and fatal messages are always kept,
+	// and hooks still receive every message.
+	Sampling map[Level]Sampling
+	// SamplingTick restarts the counts of Sampling periodically, e.g. every
+	// second (0 counts over the logger's lifetime)
+	SamplingTick time.Duration
+	// Color colors console lines by level in FormatText (default ColorAuto).
+	// The log file is never colored.
+	Color ColorMode
+
+	// MaxSize also rotates the file of the day once it would exceed MaxSize
+	// bytes (0 for no limit), renaming it to <appName>_<date>-<time>.log
+	MaxSize int64
+	// Compress gzips log files once they are rotated by size or date and
+	// removes the plain text original
+	Compress bool
+	// MaxBackups keeps the newest MaxBackups rotated files of the app (0
+	// keeps all)
+	MaxBackups int
+	// MaxAge removes rotated files of the app older than MaxAge (0 keeps all).
+	// MaxBackups and MaxAge apply when the logger is created and whenever
+	// a file is rotated, e.g. 50 and 14 days, so neither daily jobs nor
+	// long-lived services need a separate cleanup of the log directory.
+	// Other files in the directory are left alone.
+	MaxAge time.Duration
+
+	// Syslog, when set, also sends every record to a syslog server
+	Syslog *SyslogOptions
+	// DisableFile writes no log file, e.g. when Syslog collects the logs
+	DisableFile bool
+	// Archive, when set, uploads every log file once it is rotated and the
+	// current file on Close, e.g. to S3 for central batch job logs
+	Archive *ArchiveOptions
+	// Ship, when set, also posts every record in batches to Loki or
+	// Elasticsearch
+	Ship *ShipOptions
+
+	// Async buffers the log file in memory instead of syncing it after every
+	// message. A background flusher writes the buffer every FlushInterval;
+	// Flush and Close write it immediately. Messages logged since the last
+	// flush are lost if the process crashes.
+	Async bool
+	// FlushInterval defaults to one second
+	FlushInterval time.Duration
+	// BufferSize is the size of the buffer in bytes (default 64 KiB)
+	BufferSize int
+}
+
+// Environment variables read by NewLogger and OptionsFromEnv
+const (
+	EnvLevel  = "GOUTILS_LOG_LEVEL"
+	EnvFormat = "GOUTILS_LOG_FORMAT"
+	EnvDir    = "GOUTILS_LOG_DIR"
+)
+
+// NewLogger creates a new logger instance, configured by the
+// GOUTILS_LOG_LEVEL, GOUTILS_LOG_FORMAT and GOUTILS_LOG_DIR environment
+// variables when they are set
 func NewLogger(appName string) (*Logger, error) {
+	opts, err := OptionsFromEnv(Options{})
+	if err != nil {
+		return nil, err
+	}
+	return NewLoggerWithOptions(appName, opts)
+}
+
+// OptionsFromEnv returns opts with Level, Format and Dir replaced by the
+// GOUTILS_LOG_LEVEL (see ParseLevel), GOUTILS_LOG_FORMAT ("text" or "json")
+// and GOUTILS_LOG_DIR environment variables that are set and not empty, so
+// operators can change the logging of a job without recompiling it
+func OptionsFromEnv(opts Options) (Options, error) {
+	if value := os.Getenv(EnvLevel); value != "" {
+		level, err := ParseLevel(value)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %w", EnvLevel, err)
+		}
+		opts.Level = level
+	}
+	if value := os.Getenv(EnvFormat); value != "" {
+		format := Format(strings.ToLower(strings.TrimSpace(value)))
+		if format != FormatText && format != FormatJSON {
+			return opts, fmt.Errorf("invalid %s: unknown log format %q", EnvFormat, value)
+		}
+		opts.Format = format
+	}
+	if value := os.Getenv(EnvDir); value != "" {
+		opts.Dir = value
+	}
+	return opts, nil
+}
+
+// NewLoggerWithOptions creates a logger writing to Dir/<appName>_<date>.log
+// in the given format, switching to a new file at local midnight
+func NewLoggerWithOptions(appName string, opts Options) (*Logger, error) {
 	// Use provided app name or fallback to default
 	if appName == "" {
 		appName = "script"
 	}
+	switch opts.Format {
+	case "":
+		opts.Format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	color, err := colorEnabled(opts.Color)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Logger{format: opts.Format, color: color && opts.Format == FormatText, caller: opts.Caller, callerSkip: opts.CallerSkip, exitCode: opts.ExitCode, repeatWindow: opts.SuppressRepeats}
+	l.SetLevel(opts.Level)
+	l.SetConsoleLevel(opts.ConsoleLevel)
+	l.SetFileLevel(opts.FileLevel)
+	if len(opts.Sampling) > 0 {
+		l.sampler = newSampler(opts.Sampling, opts.SamplingTick)
+	}
+	if opts.Redact {
+		l.AddRedactPattern(DefaultRedactPatterns...)
+		l.RedactFields(DefaultRedactFields...)
+	}
+	if opts.Archive != nil {
+		arch, err := newArchiver(*opts.Archive)
+		if err != nil {
+			return nil, err
+		}
+		l.archiver = arch
+	}
+	if !opts.DisableFile {
+		logFile, err := openLogFile(appName, opts, l.archiver)
+		if err != nil {
+			return nil, err
+		}
+		l.logFile, l.logPath = logFile, logFile.Name()
+		if opts.Async {
+			l.logFile, l.async = newBufferedFile(logFile, opts.BufferSize, opts.FlushInterval), true
+		}
+	}
+	if opts.Syslog != nil {
+		sw, err := newSyslogWriter(*opts.Syslog, appName)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.syslog = sw
+	}
+	if opts.Ship != nil {
+		logDir := opts.Dir
+		if logDir == "" {
+			logDir = "logs"
+		}
+		sh, err := newShipper(*opts.Ship, appName, logDir)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.shipper = sh
+	}
+	return l, nil
+}
+
+// colorEnabled resolves a color mode against stdout and the environment
+func colorEnabled(mode ColorMode) (bool, error) {
+	switch mode {
+	case ColorAlways:
+		return true, nil
+	case ColorNever:
+		return false, nil
+	case "", ColorAuto:
+	default:
+		return false, fmt.Errorf("unknown color mode %q", mode)
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false, nil
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+}
 
+// openLogFile opens the dated log file of appName in opts.Dir, uploading
+// rotated files with arch if it is not nil
+func openLogFile(appName string, opts Options, arch *archiver) (*rotatewriter.Writer, error) {
 	// Create logs directory if it doesn't exist
-	logsDir := "logs"
+	logsDir := opts.Dir
+	if logsDir == "" {
+		logsDir = "logs"
+	}
 	if err := os.MkdirAll(logsDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create logs directory: %w", err)
 	}
 
-	// Create log file with timestamp
-	timestamp := time.Now().Format("2006-01-02")
-	logPath := filepath.Join(logsDir, fmt.Sprintf("%s_%s.log", appName, timestamp))
-
-	// open file in append mode or create if it doesn't exist
-	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	// Create log file with timestamp, appending if it exists. The first
+	// write after midnight closes it and opens the file of the new day.
+	rotateOpts := &rotatewriter.Options{
+		Every:      24 * time.Hour,
+		MaxSize:    opts.MaxSize,
+		Compress:   opts.Compress,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAge,
+		Now:        now,
+	}
+	if arch != nil {
+		rotateOpts.OnRotate = arch.rotated
+	}
+	logFile, err := rotatewriter.New(filepath.Join(logsDir, appName+"_{2006-01-02}.log"), rotateOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
+	return logFile, nil
+}
 
-	return &Logger{
-		logFile:    logFile,
-		logPath:    logPath,
-	}, nil
+// Flush writes the messages buffered in async mode to the log file and
+// syncs it to disk. Derived loggers flush the file of the original logger.
+func (l *Logger) Flush() error {
+	if l.logFile == nil {
+		return nil
+	}
+	if err := l.logFile.Sync(); err != nil {
+		return fmt.Errorf("failed to flush log file: %w", err)
+	}
+	return nil
 }
 
-// Close closes the logger's file handle
+// Close flushes and closes the logger's file handle, uploading it if
+// Options.Archive is set. Closing a logger returned by WithCorrelationID
+// does nothing; the original logger owns the file.
 func (l *Logger) Close() error {
+	if l.parent != nil {
+		return nil
+	}
+	l.flushRepeats()
+	var errs []error
 	if l.logFile != nil {
-		return l.logFile.Close()
+		name := l.logFile.Name()
+		err := l.logFile.Close()
+		errs = append(errs, err)
+		if l.archiver != nil && err == nil {
+			errs = append(errs, l.archiver.upload(name))
+			// Closing again does not upload the file again
+			l.archiver = nil
+		}
 	}
-	return nil
+	if l.syslog != nil {
+		errs = append(errs, l.syslog.Close())
+	}
+	if l.shipper != nil {
+		errs = append(errs, l.shipper.Close())
+	}
+	return errors.Join(errs...)
 }
 
 // logRaw rewrites a raw message to the log file
 func (l *Logger) logRaw(message string) {
+	// Raw text would break the one object per line of JSON output
+	if l.owner().format == FormatJSON {
+		return
+	}
+
+	if l.redacting() {
+		message = l.redact(message)
+	}
+
 	// Print to console
-	fmt.Print(message)
+	l.writeConsole("", message)
 
 	// Write to log file
-	if l.logFile != nil {
-		l.logFile.WriteString(message)
-		l.logFile.Sync() // Ensure it's written to disk
+	l.writeFile(LevelInfo, message)
+	l.writeOutputs(message)
+}
+
+// AddHook registers a function called after each message is written,
+// e.g. to count log entries by level. Hooks are shared with loggers returned
+// by WithCorrelationID.
+func (l *Logger) AddHook(hook Hook) {
+	l = l.owner()
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// AddOutput writes every formatted log line to w as well, e.g. os.Stderr,
+// a network connection or a bytes.Buffer in tests. Writes are serialized
+// and their errors ignored, so a failing output never blocks logging.
+// Outputs are shared with derived loggers.
+func (l *Logger) AddOutput(w io.Writer) {
+	l = l.owner()
+	l.outputsMu.Lock()
+	defer l.outputsMu.Unlock()
+	l.outputs = append(l.outputs, w)
+}
+
+// writeOutputs writes a formatted line to the added outputs
+func (l *Logger) writeOutputs(line string) {
+	owner := l.owner()
+	owner.outputsMu.Lock()
+	defer owner.outputsMu.Unlock()
+	for _, w := range owner.outputs {
+		io.WriteString(w, line)
+	}
+}
+
+// WithCorrelationID returns a logger writing to the same file that prefixes
+// every message with id, e.g. "[2024-06-01 02:00:00] [INFO] [k3f9x2m7q1zc] ...",
+// so the lines of one job or request can be found among concurrent ones.
+// id.Short() generates suitable IDs.
+func (l *Logger) WithCorrelationID(id string) *Logger {
+	derived := l.derive()
+	derived.correlationID = id
+	return derived
+}
+
+// WithFields returns a logger writing to the same file that attaches the
+// key-value pairs to every message, as "key=value" after the text message
+// or under "fields" in JSON, e.g.
+// log.WithFields(map[string]any{"job": "split", "file": name}).Info("done").
+// Fields add to and override those of l.
+func (l *Logger) WithFields(fields map[string]any) *Logger {
+	derived := l.derive()
+	derived.fields = make(map[string]any, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		derived.fields[k] = v
+	}
+	for k, v := range fields {
+		derived.fields[k] = v
+	}
+	return derived
+}
+
+// WithField returns a logger attaching one key-value pair, like WithFields
+func (l *Logger) WithField(key string, value any) *Logger {
+	return l.WithFields(map[string]any{key: value})
+}
+
+// Fields returns a copy of the fields set by WithFields
+func (l *Logger) Fields() map[string]any {
+	fields := make(map[string]any, len(l.fields))
+	for k, v := range l.fields {
+		fields[k] = v
 	}
+	return fields
+}
+
+// derive returns a logger sharing the file, hooks and level of l, with its
+// correlation ID and fields
+func (l *Logger) derive() *Logger {
+	owner := l.owner()
+	return &Logger{logFile: owner.logFile, logPath: owner.logPath, correlationID: l.correlationID, fields: l.fields, callerSkip: l.callerSkip, parent: owner}
+}
+
+// CorrelationID returns the ID set by WithCorrelationID, or ""
+func (l *Logger) CorrelationID() string {
+	return l.correlationID
+}
+
+// owner returns the logger holding the file and hooks
+func (l *Logger) owner() *Logger {
+	if l.parent != nil {
+		return l.parent
+	}
+	return l
+}
+
+// SetLevel sets the minimum level written to the console, the file and the
+// hooks; less severe messages are dropped. The default, LevelDebug, writes
+// everything. Derived loggers share the level. Fatal messages are always
+// written.
+func (l *Logger) SetLevel(level Level) {
+	l.owner().level.Store(int32(min(max(level, LevelDebug), LevelFatal)))
 }
 
-// GetLogFilePath returns the path to the current log file
+// Level returns the minimum level set by SetLevel
+func (l *Logger) Level() Level {
+	return Level(l.owner().level.Load())
+}
+
+// SetConsoleLevel sets the minimum level printed to stdout. Messages are
+// still filtered by SetLevel first. Derived loggers share the level.
+func (l *Logger) SetConsoleLevel(level Level) {
+	l.owner().consoleLevel.Store(int32(min(max(level, LevelDebug), LevelFatal)))
+}
+
+// ConsoleLevel returns the minimum level set by SetConsoleLevel
+func (l *Logger) ConsoleLevel() Level {
+	return Level(l.owner().consoleLevel.Load())
+}
+
+// SetFileLevel sets the minimum level written to the log file. Messages are
+// still filtered by SetLevel first. Derived loggers share the level.
+func (l *Logger) SetFileLevel(level Level) {
+	l.owner().fileLevel.Store(int32(min(max(level, LevelDebug), LevelFatal)))
+}
+
+// FileLevel returns the minimum level set by SetFileLevel
+func (l *Logger) FileLevel() Level {
+	return Level(l.owner().fileLevel.Load())
+}
+
+// Enabled reports whether messages of level are written, e.g. to skip
+// building an expensive debug message
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+// GetLogFilePath returns the path to the current log file, which changes
+// when the date rolls over
 func (l *Logger) GetLogFilePath() string {
+	if l.logFile != nil {
+		return l.logFile.Name()
+	}
 	return l.logPath
 }
 
 // log writes a log message to both stdout and the log file
 func (l *Logger) log(level, format string, args ...any) {
-	// Format the message with timestamp and level
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	message := fmt.Sprintf(format, args...)
-	formattedMsg := fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
+	if !l.Enabled(levelOf(level)) {
+		return
+	}
+
+	// Frames: callerPC, log, the logging method and its caller
+	l.write(now(), level, fmt.Sprintf(format, args...), l.callerPC(2))
+}
+
+// write masks secrets in a message logged at t from the caller at pc (0
+// for none), drops it if it is sampled out or repeats the last one and
+// emits it otherwise
+func (l *Logger) write(t time.Time, level, message string, pc uintptr) {
+	if l.redacting() {
+		message = l.redact(message)
+		masked := l.derive()
+		masked.fields = l.redactedFields(l.fields)
+		l = masked
+	}
+
+	owner := l.owner()
+	if owner.sampler != nil && !owner.sampler.keep(levelOf(level), t) {
+		l.runHooks(level, message)
+		return
+	}
+	if owner.repeatWindow > 0 {
+		// Decide under the lock but write outside it, so hooks and slow
+		// outputs may log through the same logger
+		owner.repeats.mu.Lock()
+		suppressed, summary := owner.repeats.suppressRepeat(l, owner.repeatWindow, t, level, message)
+		owner.repeats.mu.Unlock()
+		summary.emit(t)
+		if suppressed {
+			l.runHooks(level, message)
+			return
+		}
+	}
+	l.emit(t, level, message, pc)
+	l.runHooks(level, message)
+}
+
+// emit formats a message and writes it to stdout, the log file, the
+// outputs and syslog
+func (l *Logger) emit(t time.Time, level, message string, pc uintptr) {
+	formattedMsg := l.formatRecord(t, level, message, pc)
 
 	// Write to stdout
-	fmt.Print(formattedMsg)
+	l.writeConsole(level, formattedMsg)
 
 	// Write to log file
-	if l.logFile != nil {
-		l.logFile.WriteString(formattedMsg)
+	l.writeFile(levelOf(level), formattedMsg)
+	l.writeOutputs(formattedMsg)
+
+	owner := l.owner()
+	if owner.syslog != nil {
+		owner.syslog.send(t, levelOf(level), message, l.correlationID, l.fields)
+	}
+	if owner.shipper != nil {
+		owner.shipper.send(l.record(t, level, message, pc))
+	}
+}
+
+// runHooks calls the hooks with a message
+func (l *Logger) runHooks(level, message string) {
+	owner := l.owner()
+	owner.hooksMu.RLock()
+	defer owner.hooksMu.RUnlock()
+	for _, hook := range owner.hooks {
+		hook(level, message)
+	}
+}
+
+// writeConsole prints a formatted line of level to stdout unless the
+// console level is higher, colored by level if enabled. Raw lines have no
+// level and are printed as info without color.
+func (l *Logger) writeConsole(level, line string) {
+	if levelOf(level) < l.ConsoleLevel() {
+		return
+	}
+	if color, ok := levelColors[level]; ok && l.owner().color {
+		line = color + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+	}
+	fmt.Print(line)
+}
+
+// writeFile writes a formatted line of level to the log file, syncing it to
+// disk unless the file is buffered
+func (l *Logger) writeFile(level Level, line string) {
+	if l.logFile == nil || level < l.FileLevel() {
+		return
+	}
+	l.logFile.WriteString(line)
+	if !l.owner().async {
 		l.logFile.Sync() // Ensure it's written to disk
 	}
 }
 
+// jsonRecord is a log entry in FormatJSON
+type jsonRecord struct {
+	Time          string         `json:"time"`
+	Level         string         `json:"level"`
+	Message       string         `json:"message"`
+	CorrelationID string         `json:"correlation_id,omitempty"`
+	Caller        string         `json:"caller,omitempty"`
+	Function      string         `json:"function,omitempty"`
+	Fields        map[string]any `json:"fields,omitempty"`
+}
+
+// record returns a message logged at t from the caller at pc as a
+// jsonRecord
+func (l *Logger) record(t time.Time, level, message string, pc uintptr) jsonRecord {
+	record := jsonRecord{
+		Time:          t.Format(time.RFC3339Nano),
+		Level:         level,
+		Message:       message,
+		CorrelationID: l.correlationID,
+		Fields:        l.fields,
+	}
+	if caller, ok := callerAt(pc); ok {
+		record.Caller = caller.File + ":" + strconv.Itoa(caller.Line)
+		record.Function = caller.Function
+	}
+	return record
+}
+
+// formatRecord formats one log line, including the trailing newline
+func (l *Logger) formatRecord(t time.Time, level, message string, pc uintptr) string {
+	if l.owner().format == FormatJSON {
+		data, err := json.Marshal(l.record(t, level, message, pc))
+		if err == nil {
+			return string(data) + "\n"
+		}
+	}
+
+	caller, hasCaller := callerAt(pc)
+	timestamp := t.Format("2006-01-02 15:04:05")
+	message += formatFields(l.fields)
+	if l.correlationID != "" {
+		message = "[" + l.correlationID + "] " + message
+	}
+	if hasCaller {
+		message = "[" + caller.String() + "] " + message
+	}
+	return fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
+}
+
+// formatFields formats fields as " key=value" pairs sorted by key, quoting
+// values with spaces, quotes or equal signs
+func formatFields(fields map[string]any) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		value := fmt.Sprint(fields[k])
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, value)
+	}
+	return b.String()
+}
+
 // Info logs an informational message
 func (l *Logger) Info(format string, args ...any) {
 	l.log("INFO", format, args...)
@@ -96,14 +813,37 @@ func (l *Logger) Error(format string, args ...any) {
 	l.log("ERROR", format, args...)
 }
 
-// Fatal logs a fatal error message and exits the application
+// exit is os.Exit, replaced in tests
+var exit = os.Exit
+
+// now is time.Now, replaced in tests
+var now = time.Now
+
+// Fatal logs a fatal error message, flushes and closes the log file and
+// exits the application with Options.ExitCode (default 1). Deferred
+// functions do not run.
 func (l *Logger) Fatal(format string, args ...any) {
 	l.log("FATAL", format, args...)
+	owner := l.owner()
 	// Close the log file before exiting
-	if err := l.Close(); err != nil {
+	if err := owner.Close(); err != nil {
 		fmt.Printf("Failed to close log file: %v\n", err)
 	}
-	os.Exit(1)
+	code := owner.exitCode
+	if code == 0 {
+		code = 1
+	}
+	exit(code)
+}
+
+// Panic logs a message at LevelFatal with the PANIC label, flushes the log
+// file and panics with the message, so deferred functions run and the panic
+// can be recovered
+func (l *Logger) Panic(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	l.log("PANIC", "%s", message)
+	l.Flush()
+	panic(message)
 }
 
 // Warning logs a warning message
@@ -132,3 +872,11 @@ func (l *Logger) DisplayCredits(banner string, appName string, appVersion string
 	// Also log the banner to the file
 	l.Info("%s v%s started", appNameUpper, appVersion)
 }
+
+// DisplayBuildCredits displays the banner like DisplayCredits, taking the
+// version from buildinfo and logging the commit and build date
+func (l *Logger) DisplayBuildCredits(banner string, appName string) {
+	info := buildinfo.Get()
+	l.DisplayCredits(banner, appName, info.Version)
+	l.Info("build %s", info)
+}
@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpScope is the instrumentation scope of the exported records
+const otlpScope = "github.com/romisugianto/go-utils/utils/logger"
+
+// otlpSeverity maps levels to OpenTelemetry severity numbers
+var otlpSeverity = map[Level]int{
+	LevelDebug:   5,
+	LevelInfo:    9,
+	LevelWarning: 13,
+	LevelError:   17,
+	LevelFatal:   21,
+}
+
+// otlpValue is an OTLP AnyValue in the JSON encoding, where 64-bit
+// integers are strings
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpRecord struct {
+	TimeUnixNano         string          `json:"timeUnixNano"`
+	ObservedTimeUnixNano string          `json:"observedTimeUnixNano"`
+	SeverityNumber       int             `json:"severityNumber"`
+	SeverityText         string          `json:"severityText"`
+	Body                 otlpValue       `json:"body"`
+	Attributes           []otlpAttribute `json:"attributes,omitempty"`
+}
+
+// encodeOTLP builds an ExportLogsServiceRequest with one resource for the
+// application
+func (s *shipper) encodeOTLP(batch []jsonRecord) ([]byte, string, error) {
+	resource := []otlpAttribute{stringAttribute("service.name", s.appName)}
+	for _, k := range sortedKeys(s.opts.Labels) {
+		resource = append(resource, stringAttribute(k, s.opts.Labels[k]))
+	}
+
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]otlpRecord, 0, len(batch))
+	for _, r := range batch {
+		t, err := time.Parse(time.RFC3339Nano, r.Time)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode log record: %w", err)
+		}
+		record := otlpRecord{
+			TimeUnixNano:         strconv.FormatInt(t.UnixNano(), 10),
+			ObservedTimeUnixNano: now,
+			SeverityNumber:       otlpSeverity[levelOf(r.Level)],
+			SeverityText:         r.Level,
+			Body:                 otlpValue{StringValue: &r.Message},
+		}
+		if r.CorrelationID != "" {
+			record.Attributes = append(record.Attributes, stringAttribute("correlation_id", r.CorrelationID))
+		}
+		if file, line, ok := strings.Cut(r.Caller, ":"); ok {
+			record.Attributes = append(record.Attributes,
+				stringAttribute("code.filepath", file),
+				otlpAttribute{Key: "code.lineno", Value: otlpValue{IntValue: &line}},
+				stringAttribute("code.function", r.Function))
+		}
+		for _, k := range sortedKeys(r.Fields) {
+			record.Attributes = append(record.Attributes, otlpAttribute{Key: k, Value: toOTLPValue(r.Fields[k])})
+		}
+		records = append(records, record)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": resource},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]string{"name": otlpScope},
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode log records: %w", err)
+	}
+	return data, "application/json", nil
+}
+
+// otlpRejected reports the records a collector rejected. The rest of the
+// batch was accepted, so the batch is not retried or spilled: the rejected
+// records would be rejected again and the others exported twice.
+func otlpRejected(data []byte) {
+	var result struct {
+		PartialSuccess struct {
+			RejectedLogRecords json.Number `json:"rejectedLogRecords"`
+			ErrorMessage       string      `json:"errorMessage"`
+		} `json:"partialSuccess"`
+	}
+	if json.Unmarshal(data, &result) != nil {
+		return
+	}
+	if n, _ := result.PartialSuccess.RejectedLogRecords.Int64(); n > 0 {
+		fmt.Fprintf(os.Stderr, "logger: collector rejected %d records: %s\n", n, result.PartialSuccess.ErrorMessage)
+	}
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+// toOTLPValue converts a field value, writing other types as text
+func toOTLPValue(v any) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(v)
+		return otlpValue{IntValue: &s}
+	case float32:
+		f := float64(v)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	}
+	s := fmt.Sprint(v)
+	return otlpValue{StringValue: &s}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces masked secrets
+const redactedValue = "***"
+
+// DefaultRedactPatterns match common credentials: AWS access key IDs and
+// secret keys, password, secret, token and API key assignments, bearer
+// tokens and passwords in URLs. Only the first group of a pattern is
+// masked when it has one.
+var DefaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`),
+	regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key)["']?\s*[=:]\s*["']?([^\s"'&,;]+)`),
+	regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/=-]+)`),
+	regexp.MustCompile(`://[^/\s:@]+:([^/\s@]+)@`),
+}
+
+// DefaultRedactFields are field names whose values are masked
+var DefaultRedactFields = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "access_key", "secret_key"}
+
+// AddRedactPattern masks the matches of each pattern in messages, field
+// values and banners before they are written anywhere. When a pattern has
+// groups only the first group is masked, e.g. `password=(\S+)` keeps the
+// key. Patterns are shared with derived loggers.
+func (l *Logger) AddRedactPattern(patterns ...*regexp.Regexp) {
+	l = l.owner()
+	l.redactMu.Lock()
+	defer l.redactMu.Unlock()
+	l.redactPatterns = append(l.redactPatterns, patterns...)
+}
+
+// AddRedactSecret masks literal values, e.g. a password read from the
+// environment at startup. Empty values are ignored.
+func (l *Logger) AddRedactSecret(secrets ...string) {
+	var patterns []*regexp.Regexp
+	for _, secret := range secrets {
+		if secret != "" {
+			patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(secret)))
+		}
+	}
+	l.AddRedactPattern(patterns...)
+}
+
+// RedactFields masks the values of fields with these names, ignoring case
+func (l *Logger) RedactFields(names ...string) {
+	l = l.owner()
+	l.redactMu.Lock()
+	defer l.redactMu.Unlock()
+	if l.redactFields == nil {
+		l.redactFields = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		l.redactFields[strings.ToLower(name)] = true
+	}
+}
+
+// redacting reports whether any pattern or field name is registered
+func (l *Logger) redacting() bool {
+	owner := l.owner()
+	owner.redactMu.RLock()
+	defer owner.redactMu.RUnlock()
+	return len(owner.redactPatterns) > 0 || len(owner.redactFields) > 0
+}
+
+// redact masks the registered patterns in s
+func (l *Logger) redact(s string) string {
+	owner := l.owner()
+	owner.redactMu.RLock()
+	defer owner.redactMu.RUnlock()
+	for _, re := range owner.redactPatterns {
+		s = maskMatches(re, s)
+	}
+	return s
+}
+
+// redactedFields returns a copy of fields with masked values, or fields
+// itself when nothing is masked
+func (l *Logger) redactedFields(fields map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return fields
+	}
+	owner := l.owner()
+	owner.redactMu.RLock()
+	byName := owner.redactFields
+	owner.redactMu.RUnlock()
+
+	masked := make(map[string]any, len(fields))
+	for k, v := range fields {
+		switch {
+		case byName[strings.ToLower(k)]:
+			masked[k] = redactedValue
+		case isText(v):
+			masked[k] = l.redact(toText(v))
+		default:
+			masked[k] = v
+		}
+	}
+	return masked
+}
+
+// isText reports whether a field value is written as text that may hold a
+// secret, such as a string or an error
+func isText(v any) bool {
+	switch v.(type) {
+	case string, error:
+		return true
+	}
+	return false
+}
+
+func toText(v any) string {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v.(string)
+}
+
+// maskMatches replaces the first group of each match of re in s, or the
+// whole match when re has no groups
+func maskMatches(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(redactedValue)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
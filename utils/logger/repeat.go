@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// repeatState tracks the last message written when repeats are suppressed
+type repeatState struct {
+	mu sync.Mutex
+	// key identifies the last message by level, correlation ID, fields and
+	// text
+	key    string
+	logger *Logger
+	level  string
+	// written is when the last message or summary was written
+	written time.Time
+	// count is the number of repeats suppressed since then
+	count int
+}
+
+// repeatSummary is a pending "last message repeated N times" line
+type repeatSummary struct {
+	logger *Logger
+	level  string
+	count  int
+}
+
+// emit writes the summary, if any
+func (s *repeatSummary) emit(t time.Time) {
+	if s == nil {
+		return
+	}
+	s.logger.emit(t, s.level, fmt.Sprintf("last message repeated %d times", s.count), 0)
+}
+
+// suppressRepeat reports whether a message repeats the last one within
+// the window and should be dropped. Otherwise it remembers the message and
+// returns the summary of the suppressed repeats, if any, which the caller
+// writes before the message after releasing r.mu. The caller must hold
+// r.mu.
+func (r *repeatState) suppressRepeat(l *Logger, window time.Duration, t time.Time, level, message string) (bool, *repeatSummary) {
+	key := level + "\x00" + l.correlationID + "\x00" + formatFields(l.fields) + "\x00" + message
+	if key == r.key && t.Sub(r.written) < window {
+		r.count++
+		return true, nil
+	}
+	summary := r.summarize(t)
+	r.key, r.logger, r.level, r.written = key, l, level, t
+	return false, summary
+}
+
+// summarize returns the summary of the suppressed repeats, or nil if there
+// are none, and resets the count. The caller must hold r.mu.
+func (r *repeatState) summarize(t time.Time) *repeatSummary {
+	if r.count == 0 {
+		return nil
+	}
+	summary := &repeatSummary{logger: r.logger, level: r.level, count: r.count}
+	r.count = 0
+	r.written = t
+	return summary
+}
+
+// flushRepeats writes the summary of pending repeats, e.g. on Close
+func (l *Logger) flushRepeats() {
+	owner := l.owner()
+	if owner.repeatWindow <= 0 {
+		return
+	}
+	t := now()
+	owner.repeats.mu.Lock()
+	summary := owner.repeats.summarize(t)
+	owner.repeats.key = ""
+	owner.repeats.mu.Unlock()
+	summary.emit(t)
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampling limits the messages of one level: the First messages are
+// written, then one in every Thereafter
+type Sampling struct {
+	First int
+	// Thereafter keeps one in every Thereafter messages after the first
+	// ones; 0 drops them all
+	Thereafter int
+}
+
+// sampler counts messages per level for Options.Sampling
+type sampler struct {
+	rules map[Level]Sampling
+	// tick resets the counts periodically when positive
+	tick time.Duration
+
+	mu     sync.Mutex
+	counts map[Level]int
+	start  time.Time
+}
+
+func newSampler(rules map[Level]Sampling, tick time.Duration) *sampler {
+	s := &sampler{rules: make(map[Level]Sampling, len(rules)), tick: tick, counts: map[Level]int{}}
+	for level, rule := range rules {
+		// Errors are always kept
+		if level < LevelError {
+			s.rules[level] = rule
+		}
+	}
+	return s
+}
+
+// keep reports whether a message of level logged at t is written
+func (s *sampler) keep(level Level, t time.Time) bool {
+	rule, ok := s.rules[level]
+	if !ok {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tick > 0 && t.Sub(s.start) >= s.tick {
+		clear(s.counts)
+		s.start = t
+	}
+	s.counts[level]++
+	n := s.counts[level]
+	if n <= rule.First {
+		return true
+	}
+	return rule.Thereafter > 0 && (n-rule.First)%rule.Thereafter == 0
+}
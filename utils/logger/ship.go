@@ -0,0 +1,418 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/retry"
+)
+
+// ShipFormat is the API of a log shipping endpoint
+type ShipFormat string
+
+// Shipping formats
+const (
+	// ShipLoki posts to the Loki push API, e.g.
+	// http://loki:3100/loki/api/v1/push
+	ShipLoki ShipFormat = "loki"
+	// ShipElasticsearch posts to the Elasticsearch bulk API, e.g.
+	// http://elasticsearch:9200/_bulk
+	ShipElasticsearch ShipFormat = "elasticsearch"
+	// ShipOTLP posts OpenTelemetry log records as OTLP/HTTP JSON to a
+	// collector, e.g. http://otel-collector:4318/v1/logs
+	ShipOTLP ShipFormat = "otlp"
+)
+
+// Defaults of ShipOptions
+const (
+	defaultShipBatchSize     = 100
+	defaultShipFlushInterval = 5 * time.Second
+	defaultShipTimeout       = 10 * time.Second
+	defaultShipQueueSize     = 10000
+)
+
+// ShipOptions configures shipping log records to an HTTP endpoint
+type ShipOptions struct {
+	// URL is the push or bulk endpoint
+	URL    string
+	Format ShipFormat
+	// Labels are added to the Loki stream labels "app" and "level", or to
+	// the OTLP resource attribute "service.name"
+	Labels map[string]string
+	// Index is the Elasticsearch index (default the application name,
+	// lower-cased)
+	Index string
+	// Headers are set on every request, e.g. Authorization
+	Headers map[string]string
+
+	// BatchSize is the number of records per request (default 100)
+	BatchSize int
+	// FlushInterval is the longest a record waits for its batch (default 5s)
+	FlushInterval time.Duration
+	// Timeout bounds each request (default 10s)
+	Timeout time.Duration
+	// Retry configures the attempts of a batch; server errors, 429 and
+	// network errors are retried
+	Retry retry.Options
+	// QueueSize caps the records waiting to be sent while the endpoint is
+	// slow or down (default 10000). Further records are dropped and counted.
+	QueueSize int
+
+	// SpillDir receives the batches that failed with retryable errors, in
+	// <appName>_spill.jsonl (default the log directory). Spilled records are
+	// sent again by the next logger of the app. Batches the endpoint rejects,
+	// e.g. with a 4xx status, are reported and dropped.
+	SpillDir string
+	// Client defaults to an http.Client with Timeout
+	Client *http.Client
+}
+
+// shipper batches records and posts them in the background
+type shipper struct {
+	opts      ShipOptions
+	appName   string
+	spillPath string
+
+	mu      sync.Mutex
+	pending []jsonRecord
+	dropped int
+	// replay is set while the spill file holds the records queued from it
+	replay bool
+
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newShipper validates opts and starts the background sender. Records
+// spilled by an earlier run are queued first; the spill file is rewritten
+// once they have been sent.
+func newShipper(opts ShipOptions, appName, logDir string) (*shipper, error) {
+	if u, err := url.Parse(opts.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid log shipping URL %q", opts.URL)
+	}
+	if opts.Format != ShipLoki && opts.Format != ShipElasticsearch && opts.Format != ShipOTLP {
+		return nil, fmt.Errorf("unknown log shipping format %q", opts.Format)
+	}
+	if opts.Index == "" {
+		opts.Index = strings.ToLower(appName)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultShipBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultShipFlushInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultShipTimeout
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultShipQueueSize
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+	if opts.SpillDir == "" {
+		opts.SpillDir = logDir
+	}
+
+	s := &shipper{
+		opts:      opts,
+		appName:   appName,
+		spillPath: filepath.Join(opts.SpillDir, appName+"_spill.jsonl"),
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	spilled, err := readSpill(s.spillPath)
+	if err != nil {
+		return nil, err
+	}
+	s.pending = spilled
+	if _, err := os.Stat(s.spillPath); err == nil {
+		s.replay = true
+	}
+	go s.run()
+	return s, nil
+}
+
+// send queues a record, waking the sender once a batch is full. Records
+// beyond QueueSize are dropped.
+func (s *shipper) send(r jsonRecord) {
+	s.mu.Lock()
+	if len(s.pending) >= s.opts.QueueSize {
+		s.dropped++
+		s.mu.Unlock()
+		return
+	}
+	s.pending = append(s.pending, r)
+	full := len(s.pending) >= s.opts.BatchSize
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run sends the pending records every FlushInterval or when woken, until
+// Close
+func (s *shipper) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.stop:
+			return
+		}
+		s.flush()
+	}
+}
+
+// flush sends the pending records in batches, spilling the batches that
+// fail with retryable errors and dropping the ones the endpoint rejects
+func (s *shipper) flush() {
+	s.mu.Lock()
+	records := s.pending
+	s.pending = nil
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "logger: dropped %d records while the shipping queue was full\n", dropped)
+	}
+
+	var failed []jsonRecord
+	for len(records) > 0 {
+		n := min(len(records), s.opts.BatchSize)
+		batch := records[:n]
+		records = records[n:]
+		if err := s.post(batch); err != nil {
+			if retry.IsPermanent(err) {
+				fmt.Fprintf(os.Stderr, "logger: dropped %d records rejected by %s: %v\n", len(batch), s.opts.URL, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "logger: failed to ship %d records to %s: %v\n", len(batch), s.opts.URL, err)
+			failed = append(failed, batch...)
+		}
+	}
+	if err := s.spill(failed); err != nil {
+		fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+	}
+}
+
+// post sends one batch with retries. Errors that must not be retried are
+// returned marked with retry.Permanent.
+func (s *shipper) post(batch []jsonRecord) error {
+	body, contentType, err := s.encode(batch)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	var last error
+	err = retry.Do(context.Background(), func() error {
+		last = s.postOnce(body, contentType)
+		return last
+	}, s.opts.Retry)
+	if retry.IsPermanent(last) {
+		return last
+	}
+	return err
+}
+
+// postOnce makes one attempt to send an encoded batch
+func (s *shipper) postOnce(body []byte, contentType string) error {
+	req, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		err := fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return retry.Permanent(err)
+		}
+		return err
+	}
+	switch s.opts.Format {
+	case ShipElasticsearch:
+		return bulkError(data)
+	case ShipOTLP:
+		otlpRejected(data)
+	}
+	return nil
+}
+
+// bulkError returns an error if an Elasticsearch bulk response reports
+// failed items. Items are not retried, since the others were indexed.
+func bulkError(data []byte) error {
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if json.Unmarshal(data, &result) == nil && result.Errors {
+		return retry.Permanent(errors.New("bulk request had failed items"))
+	}
+	return nil
+}
+
+// shipDoc is a record as sent to an endpoint
+type shipDoc struct {
+	Timestamp string `json:"@timestamp,omitempty"`
+	App       string `json:"app"`
+	jsonRecord
+}
+
+// encode builds the request body of a batch
+func (s *shipper) encode(batch []jsonRecord) ([]byte, string, error) {
+	if s.opts.Format == ShipOTLP {
+		return s.encodeOTLP(batch)
+	}
+	var buf bytes.Buffer
+	if s.opts.Format == ShipElasticsearch {
+		action, _ := json.Marshal(map[string]any{"index": map[string]string{"_index": s.opts.Index}})
+		for _, r := range batch {
+			doc, err := json.Marshal(shipDoc{Timestamp: r.Time, App: s.appName, jsonRecord: r})
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to encode log record: %w", err)
+			}
+			buf.Write(action)
+			buf.WriteByte('\n')
+			buf.Write(doc)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+
+	// Loki takes one stream per label set, here per level
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	var streams []*stream
+	byLevel := map[string]*stream{}
+	for _, r := range batch {
+		st, ok := byLevel[r.Level]
+		if !ok {
+			labels := map[string]string{"app": s.appName, "level": strings.ToLower(r.Level)}
+			for k, v := range s.opts.Labels {
+				labels[k] = v
+			}
+			st = &stream{Stream: labels}
+			byLevel[r.Level] = st
+			streams = append(streams, st)
+		}
+		t, err := time.Parse(time.RFC3339Nano, r.Time)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode log record: %w", err)
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode log record: %w", err)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(t.UnixNano(), 10), string(line)})
+	}
+	if err := json.NewEncoder(&buf).Encode(map[string]any{"streams": streams}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode log records: %w", err)
+	}
+	return buf.Bytes(), "application/json", nil
+}
+
+// spill appends records to the spill file. The first flush after a replay
+// replaces the file instead, since its records were queued again.
+func (s *shipper) spill(records []jsonRecord) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if s.replay {
+		s.replay = false
+		if len(records) == 0 {
+			if err := os.Remove(s.spillPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove spill file: %w", err)
+			}
+			return nil
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.spillPath), 0755); err != nil {
+		return fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	file, err := os.OpenFile(s.spillPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open spill file: %w", err)
+	}
+	enc := json.NewEncoder(file)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write spill file: %w", err)
+		}
+	}
+	return file.Close()
+}
+
+// readSpill reads the records spilled by an earlier run. The file is kept
+// until they are sent, so a crash in between does not lose them.
+func readSpill(path string) ([]jsonRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	defer file.Close()
+
+	var records []jsonRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var r jsonRecord
+		// Skip a line cut off by a crash
+		if json.Unmarshal(scanner.Bytes(), &r) == nil {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	return records, nil
+}
+
+// Close stops the sender and sends the pending records. It is safe to call
+// more than once.
+func (s *shipper) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.flush()
+	})
+	return nil
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogHandler routes log/slog records into a Logger
+type slogHandler struct {
+	logger *Logger
+	// attrs are the flattened attributes added by WithAttrs
+	attrs map[string]any
+	// group prefixes the keys of later attributes, e.g. "request."
+	group string
+}
+
+// NewSlogHandler returns a slog.Handler writing records to l, so code using
+// log/slog logs to the same files in the same format. Attributes become
+// fields (see WithFields), with groups flattened into dotted keys such as
+// "request.id". slog levels map to the nearest level at or below them, and
+// the logger's level applies. The correlation ID of a context passed to
+// InfoContext and friends is written with the record.
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// fromSlog maps a slog level to a Level
+func fromSlog(level slog.Level) Level {
+	switch {
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarning
+	case level >= slog.LevelInfo:
+		return LevelInfo
+	}
+	return LevelDebug
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(fromSlog(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for k, v := range h.attrs {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.group, a)
+		return true
+	})
+
+	l := h.logger
+	if id := CorrelationIDFromContext(ctx); id != "" && id != l.correlationID {
+		l = l.WithCorrelationID(id)
+	}
+	if len(fields) > 0 {
+		l = l.WithFields(fields)
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = now()
+	}
+	level := fromSlog(r.Level)
+	if l.Enabled(level) {
+		var pc uintptr
+		if l.owner().caller {
+			pc = r.PC
+		}
+		l.write(t, level.String(), r.Message, pc)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	merged := make(map[string]any, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		merged[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(merged, h.group, a)
+	}
+	return &slogHandler{logger: h.logger, attrs: merged, group: h.group}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, attrs: h.attrs, group: h.group + name + "."}
+}
+
+// addAttr flattens an attribute into fields under prefix
+func addAttr(fields map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
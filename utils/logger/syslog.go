@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogOptions configures an RFC 5424 syslog output
+type SyslogOptions struct {
+	// Network is "udp", "tcp", "unix" or "unixgram". Empty Network and
+	// Address send to the local syslog socket (/dev/log).
+	Network string
+	// Address of the server, e.g. "logs.example.com:514"
+	Address string
+	// Facility defaults to 1 (user-level messages); 16-23 are local0-local7
+	Facility int
+	// Hostname defaults to os.Hostname()
+	Hostname string
+	// AppName defaults to the logger's application name
+	AppName string
+}
+
+// syslogSeverity maps levels to RFC 5424 severities
+var syslogSeverity = map[Level]int{
+	LevelDebug:   7,
+	LevelInfo:    6,
+	LevelWarning: 4,
+	LevelError:   3,
+	LevelFatal:   2,
+}
+
+// localSyslogSockets are tried in order when no address is given
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogWriter sends log records to a syslog server, reconnecting once per
+// record after a failed write
+type syslogWriter struct {
+	opts SyslogOptions
+	pid  int
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newSyslogWriter validates opts and connects to the server
+func newSyslogWriter(opts SyslogOptions, appName string) (*syslogWriter, error) {
+	if opts.Facility < 0 || opts.Facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", opts.Facility)
+	}
+	if opts.Facility == 0 {
+		opts.Facility = 1
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.AppName == "" {
+		opts.AppName = appName
+	}
+	w := &syslogWriter{opts: opts, pid: os.Getpid()}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect dials the configured server or the first local socket found
+func (w *syslogWriter) connect() error {
+	if w.opts.Network != "" || w.opts.Address != "" {
+		conn, err := net.DialTimeout(w.opts.Network, w.opts.Address, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog at %s: %w", w.opts.Address, err)
+		}
+		w.conn = conn
+		return nil
+	}
+	for _, socket := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, socket); err == nil {
+				w.conn = conn
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("failed to connect to local syslog: no socket found")
+}
+
+// send writes one record, framed by octet counting on stream connections
+func (w *syslogWriter) send(t time.Time, level Level, message, correlationID string, fields map[string]any) {
+	line := w.format(t, level, message, correlationID, fields)
+	if w.stream() {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err := w.connect(); err != nil {
+				return
+			}
+		}
+		if _, err := w.conn.Write([]byte(line)); err == nil {
+			return
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// format builds an RFC 5424 message. Fields and the correlation ID are sent
+// as structured data.
+func (w *syslogWriter) format(t time.Time, level Level, message, correlationID string, fields map[string]any) string {
+	severity, ok := syslogSeverity[level]
+	if !ok {
+		severity = 6
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %d - %s %s",
+		w.opts.Facility*8+severity,
+		t.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeader(w.opts.Hostname, 255),
+		syslogHeader(w.opts.AppName, 48),
+		w.pid,
+		structuredData(correlationID, fields),
+		message)
+}
+
+// stream reports whether the connection needs message framing
+func (w *syslogWriter) stream() bool {
+	switch w.conn.(type) {
+	case *net.TCPConn:
+		return true
+	case *net.UnixConn:
+		return w.conn.LocalAddr().Network() == "unix"
+	}
+	return false
+}
+
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// syslogHeader returns a header field of printable ASCII without spaces,
+// or the nil value "-"
+func syslogHeader(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	return value[:min(len(value), maxLen)]
+}
+
+// structuredData formats the correlation ID and fields as the SD element
+// "fields@32473", or "-" when there are none
+func structuredData(correlationID string, fields map[string]any) string {
+	if correlationID == "" && len(fields) == 0 {
+		return "-"
+	}
+	params := make(map[string]string, len(fields)+1)
+	for k, v := range fields {
+		params[k] = fmt.Sprint(v)
+	}
+	if correlationID != "" {
+		params["correlation_id"] = correlationID
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	var b strings.Builder
+	b.WriteString("[fields@32473")
+	for _, k := range keys {
+		fmt.Fprintf(&b, ` %s="%s"`, sdName(k), escaper.Replace(params[k]))
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// sdName removes the characters not allowed in a parameter name
+func sdName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ' ' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	return name[:min(len(name), 32)]
+}
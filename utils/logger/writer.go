@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+)
+
+// maxLineLength is the longest partial line buffered by a level writer
+const maxLineLength = 64 * 1024
+
+// Write implements io.Writer, logging every line of p as an Info message.
+// Each call is taken as complete lines, as written by the standard log
+// package; use Writer for streams that may split lines across writes.
+func (l *Logger) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			l.log(LevelInfo.String(), "%s", line)
+		}
+	}
+	return len(p), nil
+}
+
+// Writer returns an io.WriteCloser logging every line written to it at
+// level, e.g. as the Stdout and Stderr of an exec.Cmd. Partial lines are
+// buffered until their newline or Close, and lines longer than 64 KiB are
+// split.
+func (l *Logger) Writer(level Level) io.WriteCloser {
+	return &levelWriter{logger: l, level: level}
+}
+
+// StdLogger returns a standard library logger writing to l at level, e.g.
+// for http.Server.ErrorLog
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(l.Writer(level), "", 0)
+}
+
+// levelWriter logs complete lines at a fixed level
+type levelWriter struct {
+	logger *Logger
+	level  Level
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			if len(w.buf) >= maxLineLength {
+				w.emit(w.buf[:maxLineLength])
+				w.buf = w.buf[maxLineLength:]
+				continue
+			}
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	// Release the buffer's memory once it is drained
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Close logs a final line without a newline, if any
+func (w *levelWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *levelWriter) emit(line []byte) {
+	if s := strings.TrimRight(string(line), "\r"); s != "" {
+		w.logger.log(w.level.String(), "%s", s)
+	}
+}
@@ -0,0 +1,344 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/id"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// Version is the schema version written by this package. Manifests with a
+// newer version are rejected by Read.
+const Version = 1
+
+// Status is the transfer state of an entry
+type Status string
+
+// Entry statuses
+const (
+	StatusPending  Status = "pending"
+	StatusComplete Status = "complete"
+	StatusSkipped  Status = "skipped"
+	StatusFailed   Status = "failed"
+)
+
+var (
+	// ErrInvalid is wrapped by the errors returned by Validate
+	ErrInvalid = errors.New("invalid manifest")
+	// ErrUnsupportedVersion is returned for manifests written by a newer schema
+	ErrUnsupportedVersion = errors.New("unsupported manifest version")
+)
+
+// Entry is one file or object of a batch
+type Entry struct {
+	// Path is the file's path, slash-separated and usually relative to the
+	// batch root. Path or Key identifies the entry.
+	Path string `json:"path,omitempty"`
+	// Key is the remote object key, if any
+	Key  string `json:"key,omitempty"`
+	Size int64  `json:"size"`
+	// Checksum is prefixed with its algorithm, e.g. "sha256:ab12...", so it
+	// can be passed to checksum.VerifyFile
+	Checksum string    `json:"checksum,omitempty"`
+	ModTime  time.Time `json:"mod_time,omitzero"`
+	Status   Status    `json:"status"`
+	// Updated is when Status was last set
+	Updated time.Time `json:"updated,omitzero"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Name returns the entry's identity: its Path, or its Key when Path is empty
+func (e Entry) Name() string {
+	if e.Path != "" {
+		return e.Path
+	}
+	return e.Key
+}
+
+// Manifest describes a batch of files, e.g. the parts written by a split,
+// the objects of an upload batch or the files of a backup
+type Manifest struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	// Kind names the producer, e.g. "split", "upload" or "backup"
+	Kind     string            `json:"kind"`
+	Created  time.Time         `json:"created"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Entries  []Entry           `json:"entries"`
+	// Signature is set by Sign and SignEd25519 and cleared by any change
+	// made through the methods below
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// Totals summarizes the entries of a manifest
+type Totals struct {
+	Entries  int            `json:"entries"`
+	Bytes    int64          `json:"bytes"`
+	ByStatus map[Status]int `json:"by_status"`
+}
+
+// New creates an empty manifest of kind with a fresh ULID
+func New(kind string) *Manifest {
+	return &Manifest{Version: Version, ID: id.ULID(), Kind: kind, Created: time.Now().UTC()}
+}
+
+// Get returns the entry named name (its Path or Key)
+func (m *Manifest) Get(name string) (Entry, bool) {
+	if i := m.index(name); i >= 0 {
+		return m.Entries[i], true
+	}
+	return Entry{}, false
+}
+
+// Set adds e, replacing an existing entry with the same name. Updated
+// defaults to now.
+func (m *Manifest) Set(e Entry) {
+	if e.Updated.IsZero() {
+		e.Updated = time.Now().UTC()
+	}
+	m.Signature = nil
+	if i := m.index(e.Name()); i >= 0 {
+		m.Entries[i] = e
+		return
+	}
+	m.Entries = append(m.Entries, e)
+}
+
+// SetStatus updates the status of the entry named name, recording err as
+// its error. It returns false if there is no such entry.
+func (m *Manifest) SetStatus(name string, status Status, err error) bool {
+	i := m.index(name)
+	if i < 0 {
+		return false
+	}
+	e := &m.Entries[i]
+	e.Status, e.Updated, e.Error = status, time.Now().UTC(), ""
+	if err != nil {
+		e.Error = err.Error()
+	}
+	m.Signature = nil
+	return true
+}
+
+// AddFile hashes the local file at filePath with algo and adds it as a
+// pending entry named name (filePath when empty)
+func (m *Manifest) AddFile(filePath, name string, algo checksum.Algorithm) (Entry, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	sum, err := checksum.SumFile(filePath, algo)
+	if err != nil {
+		return Entry{}, err
+	}
+	if name == "" {
+		name = filepath.ToSlash(filePath)
+	}
+	e := Entry{
+		Path:     name,
+		Size:     info.Size(),
+		Checksum: string(algo) + ":" + sum,
+		ModTime:  info.ModTime().UTC(),
+		Status:   StatusPending,
+	}
+	m.Set(e)
+	return e, nil
+}
+
+// Totals counts the entries, their bytes and the entries per status
+func (m *Manifest) Totals() Totals {
+	totals := Totals{Entries: len(m.Entries), ByStatus: map[Status]int{}}
+	for _, e := range m.Entries {
+		totals.Bytes += e.Size
+		totals.ByStatus[e.Status]++
+	}
+	return totals
+}
+
+// Validate checks the manifest against the schema: a supported version, an
+// ID, and entries with a unique name, a non-negative size, a known status
+// and a well-formed checksum. All problems are returned together.
+func (m *Manifest) Validate() error {
+	var errs []error
+	if m.Version < 1 || m.Version > Version {
+		errs = append(errs, fmt.Errorf("%w: version %d", ErrUnsupportedVersion, m.Version))
+	}
+	if m.ID == "" {
+		errs = append(errs, fmt.Errorf("%w: missing id", ErrInvalid))
+	}
+
+	seen := make(map[string]bool, len(m.Entries))
+	for i, e := range m.Entries {
+		name := e.Name()
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("%w: entry %d has neither path nor key", ErrInvalid, i))
+		case seen[name]:
+			errs = append(errs, fmt.Errorf("%w: duplicate entry %s", ErrInvalid, name))
+		}
+		seen[name] = true
+
+		if e.Size < 0 {
+			errs = append(errs, fmt.Errorf("%w: entry %s has negative size %d", ErrInvalid, name, e.Size))
+		}
+		switch e.Status {
+		case StatusPending, StatusComplete, StatusSkipped, StatusFailed:
+		default:
+			errs = append(errs, fmt.Errorf("%w: entry %s has unknown status %q", ErrInvalid, name, e.Status))
+		}
+		if e.Checksum != "" {
+			if err := validChecksum(e.Checksum); err != nil {
+				errs = append(errs, fmt.Errorf("%w: entry %s: %v", ErrInvalid, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validChecksum checks an "algo:hex" checksum
+func validChecksum(sum string) error {
+	name, digest, ok := strings.Cut(sum, ":")
+	if !ok {
+		return fmt.Errorf("checksum %q has no algorithm prefix", sum)
+	}
+	if _, err := checksum.ParseAlgorithm(name); err != nil {
+		return err
+	}
+	if digest == "" || strings.Trim(strings.ToLower(digest), "0123456789abcdef") != "" {
+		return fmt.Errorf("checksum %q is not hexadecimal", sum)
+	}
+	return nil
+}
+
+// VerifyFiles checks the complete entries against the files below root,
+// matched by Path, and returns the names of the entries that are missing or
+// differ in size or checksum. The error aggregates all failures.
+func (m *Manifest) VerifyFiles(root string) ([]string, error) {
+	var failed []string
+	var errs []error
+	for _, e := range m.Entries {
+		if e.Status != StatusComplete || e.Path == "" {
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(e.Path))
+		err := verifyFile(p, e)
+		if err != nil {
+			failed = append(failed, e.Name())
+			errs = append(errs, err)
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// verifyFile compares a file with the size and checksum of its entry
+func verifyFile(p string, e Entry) error {
+	info, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", p, err)
+	}
+	if info.Size() != e.Size {
+		return fmt.Errorf("%w for %s: expected %d bytes, got %d", checksum.ErrMismatch, p, e.Size, info.Size())
+	}
+	if e.Checksum == "" {
+		return nil
+	}
+	return checksum.VerifyFile(p, e.Checksum)
+}
+
+// Write encodes the manifest as indented JSON
+func (m *Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return nil
+}
+
+// Read decodes a manifest and checks its version. Use Validate for a full
+// schema check and Verify or VerifyEd25519 for the signature.
+func Read(r io.Reader) (*Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedVersion, m.Version)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest to path, replacing the file atomically
+func (m *Manifest) Save(path string) error {
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write manifest %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads a manifest written by Save
+func Load(path string) (*Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	defer file.Close()
+	m, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Upload stores the manifest under key on a storage backend
+func (m *Manifest) Upload(ctx context.Context, b storage.Backend, key string) error {
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		return err
+	}
+	if err := b.Put(ctx, key, &buf); err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
+
+// Download reads the manifest stored under key, returning an error
+// wrapping storage.ErrNotFound if it does not exist
+func Download(ctx context.Context, b storage.Backend, key string) (*Manifest, error) {
+	r, err := b.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest %s: %w", key, err)
+	}
+	defer r.Close()
+	m, err := Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return m, nil
+}
+
+// index returns the position of the entry named name, or -1
+func (m *Manifest) index(name string) int {
+	for i, e := range m.Entries {
+		if e.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,220 @@
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+func newTestManifest(t *testing.T) (*Manifest, string) {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.csv"), []byte("alpha"), 0644)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "b.csv"), []byte("bravo!"), 0644)
+
+	m := New("upload")
+	m.Metadata = map[string]string{"partner": "acme"}
+	for _, name := range []string{"a.csv", "sub/b.csv"} {
+		if _, err := m.AddFile(filepath.Join(dir, filepath.FromSlash(name)), name, checksum.SHA256); err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+	}
+	return m, dir
+}
+
+func TestBuildAndValidate(t *testing.T) {
+	m, dir := newTestManifest(t)
+	if m.Version != Version || m.ID == "" || len(m.Entries) != 2 {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	e, ok := m.Get("sub/b.csv")
+	if !ok || e.Size != 6 || e.Status != StatusPending || !strings.HasPrefix(e.Checksum, "sha256:") || e.ModTime.IsZero() {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	if !m.SetStatus("a.csv", StatusComplete, nil) || !m.SetStatus("sub/b.csv", StatusFailed, errors.New("timeout")) {
+		t.Fatal("SetStatus did not find the entries")
+	}
+	if m.SetStatus("missing.csv", StatusComplete, nil) {
+		t.Error("SetStatus found a missing entry")
+	}
+	totals := m.Totals()
+	if totals.Entries != 2 || totals.Bytes != 11 || totals.ByStatus[StatusComplete] != 1 || totals.ByStatus[StatusFailed] != 1 {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+
+	// Only complete entries are checked against the files
+	if failed, err := m.VerifyFiles(dir); err != nil || len(failed) != 0 {
+		t.Errorf("VerifyFiles = %v, %v", failed, err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.csv"), []byte("ALPHA"), 0644)
+	if failed, err := m.VerifyFiles(dir); !errors.Is(err, checksum.ErrMismatch) || len(failed) != 1 || failed[0] != "a.csv" {
+		t.Errorf("expected a mismatch for a.csv, got %v, %v", failed, err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	m := &Manifest{Version: 2, Entries: []Entry{
+		{Path: "a", Status: StatusComplete},
+		{Path: "a", Status: StatusComplete},
+		{Size: 1, Status: StatusPending},
+		{Key: "b", Size: -1, Status: "lost"},
+		{Key: "c", Status: StatusComplete, Checksum: "abc"},
+		{Key: "d", Status: StatusComplete, Checksum: "sha3:abc"},
+		{Key: "e", Status: StatusComplete, Checksum: "md5:xyz"},
+	}}
+	err := m.Validate()
+	if !errors.Is(err, ErrInvalid) || !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrInvalid and ErrUnsupportedVersion, got %v", err)
+	}
+	for _, want := range []string{"missing id", "duplicate entry a", "neither path nor key", "negative size", "unknown status", "no algorithm prefix", "sha3", "not hexadecimal"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	m, _ := newTestManifest(t)
+	key := []byte("shared-secret")
+
+	if err := m.Verify(key); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("expected ErrUnsigned, got %v", err)
+	}
+	if err := m.Sign(key, "acme"); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if m.Signature.Algorithm != HMACSHA256 || m.Signature.KeyID != "acme" {
+		t.Errorf("unexpected signature %+v", m.Signature)
+	}
+
+	// The signature survives a round trip and detects tampering
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if err := read.Verify(key); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if err := read.Verify([]byte("wrong")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for the wrong key, got %v", err)
+	}
+	read.Entries[0].Size++
+	if err := read.Verify(key); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature after tampering, got %v", err)
+	}
+
+	// Changes through the methods drop the signature
+	m.SetStatus("a.csv", StatusComplete, nil)
+	if m.Signature != nil {
+		t.Error("expected SetStatus to clear the signature")
+	}
+
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	if err := m.SignEd25519(priv, "ops"); err != nil {
+		t.Fatalf("SignEd25519 failed: %v", err)
+	}
+	if err := m.VerifyEd25519(pub); err != nil {
+		t.Errorf("VerifyEd25519 failed: %v", err)
+	}
+	if err := m.Verify(key); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for an algorithm mismatch, got %v", err)
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	if err := m.VerifyEd25519(other); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for another key, got %v", err)
+	}
+	if err := m.Sign(nil, ""); err == nil {
+		t.Error("expected error for an empty key")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := New("upload")
+	first.Metadata = map[string]string{"run": "1", "partner": "acme"}
+	first.Set(Entry{Path: "a.csv", Checksum: "md5:aa", Status: StatusComplete})
+	first.Set(Entry{Path: "b.csv", Checksum: "md5:bb", Status: StatusFailed, Error: "timeout"})
+	first.Set(Entry{Path: "c.csv", Checksum: "md5:cc", Status: StatusComplete})
+
+	retry := New("upload")
+	retry.Metadata = map[string]string{"run": "2"}
+	retry.Set(Entry{Path: "a.csv", Checksum: "md5:aa", Status: StatusFailed, Error: "throttled"})
+	retry.Set(Entry{Path: "b.csv", Checksum: "md5:bb", Status: StatusComplete})
+	retry.Set(Entry{Path: "c.csv", Checksum: "md5:c2", Status: StatusPending})
+	retry.Set(Entry{Path: "d.csv", Status: StatusSkipped})
+
+	merged, err := Merge(first, retry)
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	want := map[string]Status{"a.csv": StatusComplete, "b.csv": StatusComplete, "c.csv": StatusPending, "d.csv": StatusSkipped}
+	if len(merged.Entries) != len(want) || merged.Entries[3].Path != "d.csv" {
+		t.Fatalf("unexpected entries %+v", merged.Entries)
+	}
+	for name, status := range want {
+		if e, _ := merged.Get(name); e.Status != status {
+			t.Errorf("%s has status %s, expected %s", name, e.Status, status)
+		}
+	}
+	if merged.Kind != "upload" || merged.Metadata["run"] != "2" || merged.Metadata["partner"] != "acme" || merged.ID == first.ID {
+		t.Errorf("unexpected merged manifest %+v", merged)
+	}
+
+	if merged, _ := Merge(first, New("split")); merged.Kind != "merged" {
+		t.Errorf("expected kind merged, got %s", merged.Kind)
+	}
+	if _, err := Merge(first, &Manifest{Version: 9}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+	if _, err := Merge(); err == nil {
+		t.Error("expected error for no manifests")
+	}
+}
+
+func TestStorage(t *testing.T) {
+	m, _ := newTestManifest(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "out", "manifest.json")
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil || loaded.ID != m.ID || len(loaded.Entries) != 2 || !loaded.Entries[0].ModTime.Equal(m.Entries[0].ModTime) {
+		t.Errorf("Load = %+v, %v", loaded, err)
+	}
+
+	backend := &storage.Local{Root: t.TempDir()}
+	if err := m.Upload(ctx, backend, "batches/manifest.json"); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if downloaded, err := Download(ctx, backend, "batches/manifest.json"); err != nil || downloaded.ID != m.ID {
+		t.Errorf("Download = %+v, %v", downloaded, err)
+	}
+	if _, err := Download(ctx, backend, "missing.json"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := Read(strings.NewReader(`{"version":2,"id":"x"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+	if _, err := Read(strings.NewReader(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
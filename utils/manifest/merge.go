@@ -0,0 +1,57 @@
+package manifest
+
+import (
+	"fmt"
+	"time"
+)
+
+// rank orders statuses by progress for Merge
+var rank = map[Status]int{StatusPending: 0, StatusFailed: 1, StatusSkipped: 2, StatusComplete: 3}
+
+// Merge combines manifests, e.g. the runs of a retried batch, into a new
+// unsigned manifest. Entries are matched by name. When both entries have the
+// same checksum (or either has none) the one with the more advanced status
+// wins, so a retry that failed never hides an earlier completed transfer;
+// otherwise the file changed and the later entry wins. Metadata is merged
+// with later values winning. The kind is kept when all manifests share it.
+func Merge(manifests ...*Manifest) (*Manifest, error) {
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no manifests to merge")
+	}
+
+	merged := New(manifests[0].Kind)
+	for _, m := range manifests {
+		if m.Version < 1 || m.Version > Version {
+			return nil, fmt.Errorf("%w: manifest %s has version %d", ErrUnsupportedVersion, m.ID, m.Version)
+		}
+		if m.Kind != merged.Kind {
+			merged.Kind = "merged"
+		}
+		for k, v := range m.Metadata {
+			if merged.Metadata == nil {
+				merged.Metadata = make(map[string]string)
+			}
+			merged.Metadata[k] = v
+		}
+		for _, e := range m.Entries {
+			i := merged.index(e.Name())
+			if i < 0 {
+				merged.Entries = append(merged.Entries, e)
+				continue
+			}
+			if supersedes(e, merged.Entries[i]) {
+				merged.Entries[i] = e
+			}
+		}
+	}
+	merged.Created = time.Now().UTC()
+	return merged, nil
+}
+
+// supersedes reports whether a later entry replaces an earlier one
+func supersedes(later, earlier Entry) bool {
+	if later.Checksum != "" && earlier.Checksum != "" && later.Checksum != earlier.Checksum {
+		return true
+	}
+	return rank[later.Status] >= rank[earlier.Status]
+}
@@ -0,0 +1,128 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Signature algorithms
+const (
+	HMACSHA256 = "hmac-sha256"
+	Ed25519    = "ed25519"
+)
+
+var (
+	// ErrUnsigned is returned when verifying a manifest without a signature
+	ErrUnsigned = errors.New("manifest is not signed")
+	// ErrBadSignature is returned when a signature does not match
+	ErrBadSignature = errors.New("manifest signature does not match")
+)
+
+// Signature authenticates the content of a manifest
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	// KeyID tells the verifier which key to use, e.g. a partner name
+	KeyID string `json:"key_id,omitempty"`
+	// Value is the base64-encoded MAC or signature
+	Value string `json:"value"`
+}
+
+// payload returns the signed bytes: the JSON encoding of the manifest
+// without its signature
+func (m *Manifest) payload() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = nil
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return data, nil
+}
+
+// Sign signs the manifest with HMAC-SHA256 and a shared key
+func (m *Manifest) Sign(key []byte, keyID string) error {
+	if len(key) == 0 {
+		return fmt.Errorf("signing key cannot be empty")
+	}
+	data, err := m.payload()
+	if err != nil {
+		return err
+	}
+	m.Signature = &Signature{Algorithm: HMACSHA256, KeyID: keyID, Value: base64.StdEncoding.EncodeToString(mac(key, data))}
+	return nil
+}
+
+// Verify checks an HMAC-SHA256 signature made by Sign with key
+func (m *Manifest) Verify(key []byte) error {
+	sig, err := m.signature(HMACSHA256)
+	if err != nil {
+		return err
+	}
+	data, err := m.payload()
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(sig, mac(key, data)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// SignEd25519 signs the manifest with an Ed25519 private key, so recipients
+// can verify it without being able to sign
+func (m *Manifest) SignEd25519(key ed25519.PrivateKey, keyID string) error {
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid Ed25519 private key size %d", len(key))
+	}
+	data, err := m.payload()
+	if err != nil {
+		return err
+	}
+	m.Signature = &Signature{Algorithm: Ed25519, KeyID: keyID, Value: base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))}
+	return nil
+}
+
+// VerifyEd25519 checks a signature made by SignEd25519
+func (m *Manifest) VerifyEd25519(key ed25519.PublicKey) error {
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid Ed25519 public key size %d", len(key))
+	}
+	sig, err := m.signature(Ed25519)
+	if err != nil {
+		return err
+	}
+	data, err := m.payload()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// signature decodes the signature, checking its algorithm
+func (m *Manifest) signature(algorithm string) ([]byte, error) {
+	if m.Signature == nil {
+		return nil, ErrUnsigned
+	}
+	if m.Signature.Algorithm != algorithm {
+		return nil, fmt.Errorf("%w: signed with %s, expected %s", ErrBadSignature, m.Signature.Algorithm, algorithm)
+	}
+	sig, err := base64.StdEncoding.DecodeString(m.Signature.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	return sig, nil
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
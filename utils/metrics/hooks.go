@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/housekeeper"
+	"github.com/romisugianto/go-utils/utils/s3helper"
+	"github.com/romisugianto/go-utils/utils/splitter"
+)
+
+// Component labels used by the instrumented operations
+const (
+	ComponentSplitter    = "splitter"
+	ComponentHousekeeper = "housekeeper"
+	ComponentS3          = "s3helper"
+)
+
+// SplitFileByLines runs s.SplitFileByLines and records its duration, outcome
+// and the size of the split file
+func (m *Metrics) SplitFileByLines(s *splitter.Splitter, filePath string, linesPerFile int, outputDir, processedDir string) error {
+	size := fileSize(filePath)
+	start := time.Now()
+	err := s.SplitFileByLines(filePath, linesPerFile, outputDir, processedDir)
+	m.Observe(ComponentSplitter, "split", start, err)
+	if err == nil {
+		m.AddBytes(ComponentSplitter, "split", size)
+	}
+	return err
+}
+
+// HousekeepFilesByAge runs h.HousekeepFilesByAge and records its duration and outcome
+func (m *Metrics) HousekeepFilesByAge(h *housekeeper.Housekeeper, dir string, maxAgeDays int, recursive ...bool) error {
+	return m.Track(ComponentHousekeeper, "by_age", func() error {
+		return h.HousekeepFilesByAge(dir, maxAgeDays, recursive...)
+	})
+}
+
+// HousekeepFilesByCount runs h.HousekeepFilesByCount and records its duration and outcome
+func (m *Metrics) HousekeepFilesByCount(h *housekeeper.Housekeeper, dir string, maxFiles int) error {
+	return m.Track(ComponentHousekeeper, "by_count", func() error {
+		return h.HousekeepFilesByCount(dir, maxFiles)
+	})
+}
+
+// UploadFile runs u.UploadFile and records its duration, outcome and bytes sent
+func (m *Metrics) UploadFile(u *s3helper.S3Helper, filePath, s3Path string) error {
+	start := time.Now()
+	err := u.UploadFile(filePath, s3Path)
+	m.Observe(ComponentS3, "upload", start, err)
+	if err == nil {
+		m.AddBytes(ComponentS3, "upload", fileSize(filePath))
+	}
+	return err
+}
+
+// DownloadFile runs u.DownloadFile and records its duration, outcome and bytes received
+func (m *Metrics) DownloadFile(u *s3helper.S3Helper, s3Path, localPath string) error {
+	start := time.Now()
+	err := u.DownloadFile(s3Path, localPath)
+	m.Observe(ComponentS3, "download", start, err)
+	if err == nil {
+		m.AddBytes(ComponentS3, "download", fileSize(localPath))
+	}
+	return err
+}
+
+// UploadBatch runs u.UploadBatch and records its duration, outcome and the
+// bytes of the files uploaded in this run
+func (m *Metrics) UploadBatch(u *s3helper.S3Helper, items []s3helper.BatchItem, manifestPath string) (*s3helper.BatchResult, error) {
+	start := time.Now()
+	result, err := u.UploadBatch(items, manifestPath)
+	m.Observe(ComponentS3, "upload_batch", start, err)
+	if result != nil {
+		uploaded := make(map[string]bool, len(result.Uploaded))
+		for _, key := range result.Uploaded {
+			uploaded[key] = true
+		}
+		for _, item := range items {
+			if uploaded[cleanKey(item.S3Path)] {
+				m.AddBytes(ComponentS3, "upload", fileSize(item.FilePath))
+			}
+		}
+	}
+	return result, err
+}
+
+// cleanKey mirrors the key normalization applied by s3helper
+func cleanKey(s3Path string) string {
+	return strings.TrimPrefix(filepath.Clean(s3Path), "/")
+}
+
+// fileSize returns the size of a file, or 0 if it cannot be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
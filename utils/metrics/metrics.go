@@ -0,0 +1,189 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Metrics holds a Prometheus registry with the standard operation metrics
+type Metrics struct {
+	namespace string
+	registry  *prometheus.Registry
+
+	operations  *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	bytes       *prometheus.CounterVec
+	logMessages *prometheus.CounterVec
+}
+
+// New creates a registry whose metric names are prefixed with namespace
+// (e.g. "myapp"). Go runtime and process metrics are included.
+func New(namespace string) (*Metrics, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace cannot be empty")
+	}
+
+	m := &Metrics{namespace: namespace, registry: prometheus.NewRegistry()}
+	m.operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Operations performed, by component, operation and status.",
+	}, []string{"component", "operation", "status"})
+	m.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of operations, by component and operation.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"component", "operation"})
+	m.bytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_processed_total",
+		Help:      "Bytes read or transferred by successful operations.",
+	}, []string{"component", "operation"})
+	m.logMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "log_messages_total",
+		Help:      "Log messages written, by level.",
+	}, []string{"level"})
+
+	for _, c := range []prometheus.Collector{
+		m.operations, m.duration, m.bytes, m.logMessages,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	} {
+		if err := m.registry.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register collector: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Registry returns the underlying registry for registering other collectors
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Counter registers a counter with the given labels under the namespace
+func (m *Metrics) Counter(name, help string, labels ...string) (*prometheus.CounterVec, error) {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: m.namespace, Name: name, Help: help}, labels)
+	if err := m.registry.Register(c); err != nil {
+		return nil, fmt.Errorf("failed to register counter %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// Gauge registers a gauge with the given labels under the namespace
+func (m *Metrics) Gauge(name, help string, labels ...string) (*prometheus.GaugeVec, error) {
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: m.namespace, Name: name, Help: help}, labels)
+	if err := m.registry.Register(g); err != nil {
+		return nil, fmt.Errorf("failed to register gauge %s: %w", name, err)
+	}
+	return g, nil
+}
+
+// Histogram registers a histogram with the given labels under the namespace.
+// Nil buckets use the Prometheus defaults.
+func (m *Metrics) Histogram(name, help string, buckets []float64, labels ...string) (*prometheus.HistogramVec, error) {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: m.namespace, Name: name, Help: help, Buckets: buckets}, labels)
+	if err := m.registry.Register(h); err != nil {
+		return nil, fmt.Errorf("failed to register histogram %s: %w", name, err)
+	}
+	return h, nil
+}
+
+// Observe records the outcome and duration of an operation that started at start
+func (m *Metrics) Observe(component, operation string, start time.Time, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	m.operations.WithLabelValues(component, operation, status).Inc()
+	m.duration.WithLabelValues(component, operation).Observe(time.Since(start).Seconds())
+}
+
+// AddBytes adds to the bytes processed by an operation
+func (m *Metrics) AddBytes(component, operation string, n int64) {
+	if n > 0 {
+		m.bytes.WithLabelValues(component, operation).Add(float64(n))
+	}
+}
+
+// Track runs fn and records its outcome and duration
+func (m *Metrics) Track(component, operation string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	m.Observe(component, operation, start, err)
+	return err
+}
+
+// Handler returns an HTTP handler serving the registry in the Prometheus format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Serve exposes /metrics on addr (e.g. ":9100") until ctx is done
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return m.serve(ctx, listener)
+}
+
+// serve runs the metrics server on listener until ctx is done
+func (m *Metrics) serve(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("metrics server failed: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down metrics server: %w", err)
+		}
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// Push sends all metrics to a Pushgateway under the given job name, replacing
+// the job's previous metrics. Use it at the end of batch runs that exit before
+// they can be scraped.
+func (m *Metrics) Push(ctx context.Context, gatewayURL, job string) error {
+	if gatewayURL == "" || job == "" {
+		return fmt.Errorf("gateway URL and job must not be empty")
+	}
+	err := push.New(strings.TrimSuffix(gatewayURL, "/"), job).Gatherer(m.registry).PushContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", gatewayURL, err)
+	}
+	return nil
+}
+
+// InstrumentLogger counts the messages written by log, by level
+func (m *Metrics) InstrumentLogger(log *logger.Logger) {
+	log.AddHook(func(level, message string) {
+		m.logMessages.WithLabelValues(strings.ToLower(level)).Inc()
+	})
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/housekeeper"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/splitter"
+)
+
+func newTestMetrics(t *testing.T) *Metrics {
+	t.Helper()
+	m, err := New("test")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return m
+}
+
+// scrape returns the exposition output of the registry
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return recorder.Body.String()
+}
+
+func expectLines(t *testing.T, output string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(output, line) {
+			t.Errorf("expected %q in metrics output", line)
+		}
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Error("expected error for empty namespace")
+	}
+}
+
+func TestTrackAndCustomCollectors(t *testing.T) {
+	m := newTestMetrics(t)
+
+	m.Track("export", "query", func() error { return nil })
+	m.Track("export", "query", func() error { return errors.New("timeout") })
+	m.AddBytes("export", "query", 2048)
+
+	files, err := m.Counter("files_received_total", "Files received.", "source")
+	if err != nil {
+		t.Fatalf("Counter failed: %v", err)
+	}
+	files.WithLabelValues("sftp").Add(3)
+	queue, _ := m.Gauge("queue_depth", "Files waiting.")
+	queue.WithLabelValues().Set(7)
+	if _, err := m.Histogram("row_count", "Rows per file.", []float64{100, 1000}, "table"); err != nil {
+		t.Fatalf("Histogram failed: %v", err)
+	}
+	if _, err := m.Counter("files_received_total", "Duplicate.", "source"); err == nil {
+		t.Error("expected error for duplicate registration")
+	}
+
+	expectLines(t, scrape(t, m),
+		`test_operations_total{component="export",operation="query",status="success"} 1`,
+		`test_operations_total{component="export",operation="query",status="error"} 1`,
+		`test_operation_duration_seconds_count{component="export",operation="query"} 2`,
+		`test_bytes_processed_total{component="export",operation="query"} 2048`,
+		`test_files_received_total{source="sftp"} 3`,
+		`test_queue_depth 7`,
+		`go_goroutines`,
+	)
+}
+
+func TestInstrumentedOperations(t *testing.T) {
+	m := newTestMetrics(t)
+	testLogger, _ := logger.NewLogger("metrics_test")
+	defer testLogger.Close()
+	m.InstrumentLogger(testLogger)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	os.WriteFile(input, []byte("a\nb\nc\n"), 0644)
+
+	s, _ := splitter.NewSplitter(testLogger)
+	if err := m.SplitFileByLines(s, input, 2, filepath.Join(dir, "out"), filepath.Join(dir, "done")); err != nil {
+		t.Fatalf("SplitFileByLines failed: %v", err)
+	}
+	hk, _ := housekeeper.NewHousekeeper(testLogger)
+	m.HousekeepFilesByCount(hk, filepath.Join(dir, "missing"), 1)
+	testLogger.Warning("disk almost full")
+
+	expectLines(t, scrape(t, m),
+		`test_operations_total{component="splitter",operation="split",status="success"} 1`,
+		`test_bytes_processed_total{component="splitter",operation="split"} 6`,
+		`test_operations_total{component="housekeeper",operation="by_count",status="error"} 1`,
+		`test_log_messages_total{level="warning"} 1`,
+		`test_log_messages_total{level="summary"}`,
+	)
+}
+
+func TestServe(t *testing.T) {
+	m := newTestMetrics(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.serve(ctx, listener) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "go_goroutines") {
+		t.Errorf("unexpected response %d", resp.StatusCode)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestPush(t *testing.T) {
+	m := newTestMetrics(t)
+	m.Track("export", "query", func() error { return nil })
+
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		content, _ := io.ReadAll(r.Body)
+		body = string(content)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if err := m.Push(context.Background(), server.URL+"/", "nightly_export"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if path != "/metrics/job/nightly_export" {
+		t.Errorf("pushed to %s", path)
+	}
+	if !strings.Contains(body, "test_operations_total") {
+		t.Error("expected operation metrics in push body")
+	}
+	if err := m.Push(context.Background(), "", "job"); err == nil {
+		t.Error("expected error for empty gateway URL")
+	}
+}
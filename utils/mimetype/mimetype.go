@@ -0,0 +1,160 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package mimetype
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SniffLen is the number of leading bytes inspected by Detect
+const SniffLen = 512
+
+// Default is the content type of unrecognized data
+const Default = "application/octet-stream"
+
+// signature is a magic byte sequence at a fixed offset
+type signature struct {
+	offset      int
+	magic       []byte
+	contentType string
+}
+
+// signatures lists formats net/http does not recognize, mostly the
+// compression and columnar formats found in data pipelines
+var signatures = []signature{
+	{0, []byte("PAR1"), "application/vnd.apache.parquet"},
+	{0, []byte("Obj\x01"), "application/avro"},
+	{0, []byte("\x28\xb5\x2f\xfd"), "application/zstd"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte("\xfd7zXZ\x00"), "application/x-xz"},
+	{0, []byte("7z\xbc\xaf\x27\x1c"), "application/x-7z-compressed"},
+	{0, []byte("\x04\x22\x4d\x18"), "application/x-lz4"},
+	{0, []byte("SQLite format 3\x00"), "application/vnd.sqlite3"},
+	{0, []byte("-----BEGIN PGP MESSAGE-----"), "application/pgp-encrypted"},
+	{0, []byte("-----BEGIN PGP SIGNATURE-----"), "application/pgp-signature"},
+	{0, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), "application/x-ole-storage"},
+	{0, []byte("\x89HDF\r\n\x1a\n"), "application/x-hdf5"},
+	{257, []byte("ustar"), "application/x-tar"},
+}
+
+// extensions covers data formats missing from minimal system MIME tables,
+// so results do not depend on the host's /etc/mime.types
+var extensions = map[string]string{
+	".csv":     "text/csv; charset=utf-8",
+	".tsv":     "text/tab-separated-values; charset=utf-8",
+	".txt":     "text/plain; charset=utf-8",
+	".ndjson":  "application/x-ndjson",
+	".jsonl":   "application/x-ndjson",
+	".parquet": "application/vnd.apache.parquet",
+	".avro":    "application/avro",
+	".xls":     "application/vnd.ms-excel",
+	".xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".docx":    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// generic lists detected types that say little about the content; a known
+// extension refines them, e.g. a zip named .xlsx or plain text named .csv
+var generic = map[string]bool{
+	Default:                     true,
+	"application/zip":           true,
+	"application/x-ole-storage": true,
+	"text/plain; charset=utf-8": true,
+	"text/xml; charset=utf-8":   true,
+	"application/json":          true,
+}
+
+// Detect returns the content type of data from its leading magic bytes, or
+// Default when nothing matches. Only the first SniffLen bytes are used.
+func Detect(data []byte) string {
+	if len(data) == 0 {
+		return Default
+	}
+	if len(data) > SniffLen {
+		data = data[:SniffLen]
+	}
+	for _, sig := range signatures {
+		if bytes.HasPrefix(data[min(sig.offset, len(data)):], sig.magic) {
+			return sig.contentType
+		}
+	}
+
+	contentType := http.DetectContentType(data)
+	if contentType == "text/plain; charset=utf-8" && looksLikeJSON(data) {
+		return "application/json"
+	}
+	return contentType
+}
+
+// ByExtension returns the content type for the extension of name, or "" when
+// it is unknown
+func ByExtension(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if contentType, ok := extensions[ext]; ok {
+		return contentType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// TypeOf combines content and name: a specific magic-byte match wins, and a
+// generic one such as plain text or zip falls back to the extension, e.g.
+// "text/csv" for orders.csv but "application/gzip" for a gzipped orders.csv
+func TypeOf(name string, data []byte) string {
+	detected := Detect(data)
+	if generic[detected] {
+		if byExt := ByExtension(name); byExt != "" {
+			return byExt
+		}
+	}
+	return detected
+}
+
+// DetectReader sniffs the content type of r and returns a reader that still
+// yields all of r, so a stream can be inspected before it is uploaded
+func DetectReader(name string, r io.Reader) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, SniffLen)
+	head, err := br.Peek(SniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	return TypeOf(name, head), br, nil
+}
+
+// DetectFile returns the content type of a file from its content and name
+func DetectFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %q: %w", filePath, err)
+	}
+	defer file.Close()
+
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read file %q: %w", filePath, err)
+	}
+	return TypeOf(filePath, head[:n]), nil
+}
+
+// looksLikeJSON reports whether text starts like a JSON object or array
+func looksLikeJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) < 2 {
+		return false
+	}
+	switch data[0] {
+	case '{':
+		rest := bytes.TrimLeft(data[1:], " \t\r\n")
+		return len(rest) > 0 && (rest[0] == '"' || rest[0] == '}')
+	case '[':
+		rest := bytes.TrimLeft(data[1:], " \t\r\n")
+		return len(rest) > 0 && bytes.IndexByte([]byte(`{["0123456789-tfn]`), rest[0]) >= 0
+	}
+	return false
+}
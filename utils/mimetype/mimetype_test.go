@@ -0,0 +1,102 @@
+package mimetype
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDetect(t *testing.T) {
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar\x0000")
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"empty", nil, Default},
+		{"gzip", []byte("\x1f\x8b\x08\x00"), "application/x-gzip"},
+		{"zstd", []byte("\x28\xb5\x2f\xfd\x04"), "application/zstd"},
+		{"bzip2", []byte("BZh91AY&SY"), "application/x-bzip2"},
+		{"parquet", []byte("PAR1\x15\x04"), "application/vnd.apache.parquet"},
+		{"avro", []byte("Obj\x01\x04\x14avro.codec"), "application/avro"},
+		{"pgp", []byte("-----BEGIN PGP MESSAGE-----\n\nhQEM"), "application/pgp-encrypted"},
+		{"tar", tar, "application/x-tar"},
+		{"zip", []byte("PK\x03\x04\x14\x00"), "application/zip"},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00"), "image/png"},
+		{"json object", []byte(`  {"id": 1}`), "application/json"},
+		{"json array", []byte("[\n  {\"id\": 1}\n]"), "application/json"},
+		{"text", []byte("id,name\n1,alpha\n"), "text/plain; charset=utf-8"},
+		{"bracketed text", []byte("[INFO] started"), "text/plain; charset=utf-8"},
+		{"binary", []byte{0x00, 0x01, 0x02, 0xfe}, Default},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.data); got != tt.expected {
+			t.Errorf("Detect(%s) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"orders.csv", "id,name\n1,alpha\n", "text/csv; charset=utf-8"},
+		{"orders.csv", "\x1f\x8b\x08\x00", "application/x-gzip"},
+		{"ORDERS_20240601", "\x1f\x8b\x08\x00", "application/x-gzip"},
+		{"ORDERS_20240601", "id,name\n", "text/plain; charset=utf-8"},
+		{"report.XLSX", "PK\x03\x04\x14\x00", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"bundle", "PK\x03\x04\x14\x00", "application/zip"},
+		{"empty.json", "", "application/json"},
+		{"empty", "", Default},
+	}
+	for _, tt := range tests {
+		if got := TypeOf(tt.name, []byte(tt.data)); got != tt.expected {
+			t.Errorf("TypeOf(%q, %q) = %q, expected %q", tt.name, tt.data, got, tt.expected)
+		}
+	}
+}
+
+func TestDetectReader(t *testing.T) {
+	data := "PAR1" + strings.Repeat("x", 2*SniffLen)
+	contentType, r, err := DetectReader("export", strings.NewReader(data))
+	if err != nil || contentType != "application/vnd.apache.parquet" {
+		t.Fatalf("DetectReader = %q, %v", contentType, err)
+	}
+	if all, _ := io.ReadAll(r); string(all) != data {
+		t.Error("expected the reader to replay the sniffed bytes")
+	}
+
+	contentType, r, err = DetectReader("short.json", strings.NewReader("{}"))
+	if all, _ := io.ReadAll(r); err != nil || contentType != "application/json" || string(all) != "{}" {
+		t.Errorf("DetectReader(short) = %q, %q, %v", contentType, all, err)
+	}
+
+	failure := errors.New("connection reset")
+	if _, _, err := DetectReader("x", iotest.ErrReader(failure)); !errors.Is(err, failure) {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+func TestDetectFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "delivery")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("\x89PNG\r\n\x1a\n"), 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DetectFile(path); err != nil || got != "image/png" {
+		t.Errorf("DetectFile = %q, %v", got, err)
+	}
+	if _, err := DetectFile(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
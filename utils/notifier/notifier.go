@@ -0,0 +1,92 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Level indicates the outcome a message reports and controls its styling
+type Level string
+
+// Message levels
+const (
+	LevelInfo    Level = "info"
+	LevelSuccess Level = "success"
+	LevelWarning Level = "warning"
+	LevelFailure Level = "failure"
+)
+
+// Field is a labelled value shown in a message summary, e.g. "Files: 12"
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is a notification such as a batch job summary
+type Message struct {
+	Title  string
+	Text   string
+	Level  Level
+	Fields []Field
+	// Attachments are file paths sent with the message by channels that support
+	// files, e.g. the run's log file; other channels ignore them
+	Attachments []string
+}
+
+// Notifier sends messages to a notification channel
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Multi sends each message to every notifier and joins their errors
+type Multi []Notifier
+
+// Notify sends msg to all notifiers, continuing past failures
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// defaultHTTPClient is used by notifiers without a custom HTTP client
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON posts payload as JSON and returns the response body of a 2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return respBody, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+	return respBody, nil
+}
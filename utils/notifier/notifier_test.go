@@ -0,0 +1,455 @@
+package notifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// capture records JSON request bodies posted to a test server
+type capture struct {
+	bodies []map[string]any
+	status int
+}
+
+func newCaptureServer(t *testing.T) (*capture, *httptest.Server) {
+	c := &capture{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid JSON body: %v", err)
+		}
+		c.bodies = append(c.bodies, body)
+		w.WriteHeader(c.status)
+		if c.status != http.StatusOK {
+			w.Write([]byte("invalid_payload"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func TestSlackSendText(t *testing.T) {
+	c, server := newCaptureServer(t)
+	slack := &SlackNotifier{WebhookURL: server.URL, Channel: "#batch", Username: "batch-bot"}
+
+	if err := slack.SendText(context.Background(), "Nightly export started"); err != nil {
+		t.Fatalf("SendText failed: %v", err)
+	}
+	body := c.bodies[0]
+	if body["text"] != "Nightly export started" || body["channel"] != "#batch" || body["username"] != "batch-bot" {
+		t.Errorf("unexpected payload: %v", body)
+	}
+	if _, ok := body["attachments"]; ok {
+		t.Error("plain text message should not have attachments")
+	}
+}
+
+func TestSlackNotify(t *testing.T) {
+	c, server := newCaptureServer(t)
+	slack := &SlackNotifier{WebhookURL: server.URL}
+
+	fields := []Field{{"Files", "12"}, {"Duration", "3m2s"}}
+	for i := 0; i < 10; i++ {
+		fields = append(fields, Field{fmt.Sprintf("Part %d", i), "ok"})
+	}
+	msg := Message{Title: "Export failed", Text: "2 files could not be uploaded", Level: LevelFailure, Fields: fields}
+	if err := slack.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	raw, _ := json.Marshal(c.bodies[0])
+	var payload slackPayload
+	json.Unmarshal(raw, &payload)
+
+	if payload.Text != "Export failed\n2 files could not be uploaded" {
+		t.Errorf("fallback text = %q", payload.Text)
+	}
+	if len(payload.Attachments) != 1 || payload.Attachments[0].Color != slackColors[LevelFailure] {
+		t.Fatalf("unexpected attachments: %+v", payload.Attachments)
+	}
+
+	blocks := payload.Attachments[0].Blocks
+	if len(blocks) != 4 {
+		t.Fatalf("expected header, text and 2 field blocks, got %d", len(blocks))
+	}
+	if blocks[0].Type != "header" || blocks[0].Text.Text != "Export failed" {
+		t.Errorf("unexpected header block: %+v", blocks[0])
+	}
+	if len(blocks[2].Fields) != 10 || len(blocks[3].Fields) != 2 {
+		t.Errorf("fields not split into sections of 10: %d, %d", len(blocks[2].Fields), len(blocks[3].Fields))
+	}
+	if blocks[2].Fields[0].Text != "*Files*\n12" {
+		t.Errorf("field text = %q", blocks[2].Fields[0].Text)
+	}
+}
+
+func TestSlackErrors(t *testing.T) {
+	if err := (&SlackNotifier{}).SendText(context.Background(), "hi"); err == nil {
+		t.Error("expected error for missing webhook URL")
+	}
+
+	c, server := newCaptureServer(t)
+	c.status = http.StatusBadRequest
+	err := (&SlackNotifier{WebhookURL: server.URL}).SendText(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "invalid_payload") {
+		t.Errorf("expected error with response body, got %v", err)
+	}
+}
+
+type fakeNotifier struct {
+	err  error
+	sent []Message
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, msg Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+func TestMulti(t *testing.T) {
+	failing := &fakeNotifier{err: errors.New("channel down")}
+	working := &fakeNotifier{}
+
+	err := Multi{failing, working}.Notify(context.Background(), Message{Text: "done"})
+	if err == nil || !strings.Contains(err.Error(), "channel down") {
+		t.Errorf("expected joined error, got %v", err)
+	}
+	if len(working.sent) != 1 {
+		t.Error("remaining notifiers should still receive the message")
+	}
+}
+
+// fakeSMTP is a minimal SMTP server that records delivered messages
+type fakeSMTP struct {
+	listener net.Listener
+	tls      *tls.Config
+	auth     string
+	from     string
+	rcpts    []string
+	data     string
+	tlsUsed  bool
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeSMTP{listener: listener, tls: &tls.Config{Certificates: []tls.Certificate{selfSigned(t)}}}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		f.serve(conn)
+	}()
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake smtp ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			text.PrintfLine("250-fake")
+			if !f.tlsUsed {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			text.PrintfLine("220 ready to start TLS")
+			conn = tls.Server(conn, f.tls)
+			text = textproto.NewConn(conn)
+			f.tlsUsed = true
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			f.auth = string(decoded)
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			f.from = arg
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			f.rcpts = append(f.rcpts, arg)
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, _ := text.ReadDotBytes()
+			f.data = string(data)
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func selfSigned(t *testing.T) tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSMTPNotify(t *testing.T) {
+	server := newFakeSMTP(t)
+	logPath := filepath.Join(t.TempDir(), "export_2024-01-01.log")
+	os.WriteFile(logPath, []byte(strings.Repeat("[ERROR] upload failed\n", 20)), 0644)
+
+	mailer := &SMTPNotifier{
+		Host:               "127.0.0.1",
+		Port:               server.port(),
+		Username:           "batch",
+		Password:           "s3cret",
+		InsecureSkipVerify: true,
+		From:               "batch@example.com",
+		To:                 []string{"ops@example.com"},
+		Cc:                 []string{"lead@example.com"},
+		SubjectTemplate:    "{{.Title}} ({{len .Attachments}} attachment)",
+		Timeout:            5 * time.Second,
+	}
+	msg := Message{
+		Title:       "Export failed ✗",
+		Text:        "See attached log",
+		Level:       LevelFailure,
+		Fields:      []Field{{"Files", "12"}},
+		Attachments: []string{logPath},
+	}
+	if err := mailer.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if !server.tlsUsed {
+		t.Error("expected STARTTLS to be used")
+	}
+	if server.auth != "\x00batch\x00s3cret" {
+		t.Errorf("auth = %q", server.auth)
+	}
+	if server.from != "FROM:<batch@example.com>" || len(server.rcpts) != 2 {
+		t.Errorf("envelope = %s %v", server.from, server.rcpts)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "Export failed ✗ (1 attachment)" {
+		t.Errorf("subject = %q", subject)
+	}
+
+	_, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	bodyPart, _ := reader.NextPart()
+	body, _ := io.ReadAll(bodyPart)
+	if !strings.Contains(string(body), "See attached log") || !strings.Contains(string(body), "Files: 12") {
+		t.Errorf("body = %q", body)
+	}
+
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("missing attachment: %v", err)
+	}
+	if attachment.FileName() != "export_2024-01-01.log" {
+		t.Errorf("attachment name = %q", attachment.FileName())
+	}
+	encoded, _ := io.ReadAll(attachment)
+	content, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if string(content) != strings.Repeat("[ERROR] upload failed\n", 20) {
+		t.Error("attachment content mismatch")
+	}
+}
+
+func TestSMTPSendHTML(t *testing.T) {
+	server := newFakeSMTP(t)
+	mailer := &SMTPNotifier{
+		Host:               "127.0.0.1",
+		Port:               server.port(),
+		InsecureSkipVerify: true,
+		From:               "batch@example.com",
+		To:                 []string{"ops@example.com"},
+		Timeout:            5 * time.Second,
+	}
+	email := Email{Subject: "Daily report", Text: "Files: 12\n", HTML: "<p>Files: <b>12</b></p>"}
+	if err := mailer.Send(context.Background(), email); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	_, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	outer, err := multipart.NewReader(parsed.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatalf("missing body part: %v", err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(outer.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %s", mediaType)
+	}
+
+	reader := multipart.NewReader(outer, params["boundary"])
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(part)
+		types = append(types, part.Header.Get("Content-Type"))
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/html") && !strings.Contains(string(body), "<b>12</b>") {
+			t.Errorf("html body = %q", body)
+		}
+	}
+	if strings.Join(types, ",") != "text/plain; charset=utf-8,text/html; charset=utf-8" {
+		t.Errorf("unexpected alternatives %v", types)
+	}
+}
+
+func TestSMTPValidation(t *testing.T) {
+	if err := (&SMTPNotifier{Host: "localhost"}).Notify(context.Background(), Message{}); err == nil {
+		t.Error("expected error for missing From and recipients")
+	}
+
+	mailer := &SMTPNotifier{Host: "localhost", From: "a@example.com", To: []string{"b@example.com"}, BodyTemplate: "{{.Missing"}
+	if err := mailer.Notify(context.Background(), Message{}); err == nil || !strings.Contains(err.Error(), "template") {
+		t.Errorf("expected template error, got %v", err)
+	}
+
+	mailer = &SMTPNotifier{Host: "localhost", From: "a@example.com", To: []string{"b@example.com"}}
+	if err := mailer.Notify(context.Background(), Message{Attachments: []string{"/nonexistent/file.log"}}); err == nil {
+		t.Error("expected error for missing attachment")
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	msg := Message{Title: "Done", Text: "All good", Level: LevelSuccess, Fields: []Field{{"Files", "3"}}}
+	subject, _ := renderTemplate("subject", "", DefaultSubjectTemplate, msg)
+	if subject != "[success] Done" {
+		t.Errorf("subject = %q", subject)
+	}
+	body, _ := renderTemplate("body", "", DefaultBodyTemplate, msg)
+	if body != "Done\n\nAll good\n\nFiles: 3\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestTelegramNotify(t *testing.T) {
+	var messages []telegramMessage
+	var documents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bot123:abc/sendMessage":
+			var msg telegramMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			messages = append(messages, msg)
+		case "/bot123:abc/sendDocument":
+			file, header, err := r.FormFile("document")
+			if err != nil || r.FormValue("chat_id") != "-1001" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(file)
+			documents = append(documents, header.Filename+":"+string(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(t.TempDir(), "run.log")
+	os.WriteFile(logPath, []byte("log content"), 0644)
+
+	telegram := &TelegramNotifier{BotToken: "123:abc", ChatID: "-1001", APIURL: server.URL, DisableNotification: true}
+	msg := Message{
+		Title:       "Export <nightly> failed",
+		Text:        "3 & more errors",
+		Level:       LevelFailure,
+		Fields:      []Field{{"Files", "12"}},
+		Attachments: []string{logPath},
+	}
+	if err := telegram.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	expected := "❌ <b>Export &lt;nightly&gt; failed</b>\n3 &amp; more errors\n\n<b>Files:</b> 12"
+	if messages[0].Text != expected || messages[0].ParseMode != "HTML" || !messages[0].DisableNotification || messages[0].ChatID != "-1001" {
+		t.Errorf("unexpected message: %+v", messages[0])
+	}
+	if len(documents) != 1 || documents[0] != "run.log:log content" {
+		t.Errorf("documents = %v", documents)
+	}
+
+	// Oversized messages are truncated and sent as plain text
+	if err := telegram.Notify(context.Background(), Message{Text: strings.Repeat("é", 3000)}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	last := messages[len(messages)-1]
+	if len(last.Text) > telegramMaxLength || last.ParseMode != "" || !strings.HasSuffix(last.Text, "…") {
+		t.Errorf("oversized message not truncated: %d bytes, parse mode %q", len(last.Text), last.ParseMode)
+	}
+
+	wrongToken := &TelegramNotifier{BotToken: "bad", ChatID: "-1001", APIURL: server.URL}
+	if err := wrongToken.SendText(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "Not Found") {
+		t.Errorf("expected API error, got %v", err)
+	}
+	if err := (&TelegramNotifier{}).SendText(context.Background(), "hi"); err == nil {
+		t.Error("expected error for missing token and chat ID")
+	}
+}
@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SlackNotifier posts messages to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	// Channel, Username and IconEmoji override the webhook defaults where the workspace allows it
+	Channel   string
+	Username  string
+	IconEmoji string
+	// HTTPClient replaces the default client with a 30 second timeout
+	HTTPClient *http.Client
+}
+
+// slackColors maps message levels to attachment bar colors
+var slackColors = map[Level]string{
+	LevelInfo:    "#439FE0",
+	LevelSuccess: "#2EB67D",
+	LevelWarning: "#ECB22E",
+	LevelFailure: "#E01E5A",
+}
+
+// slackPayload is the incoming webhook request body
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	IconEmoji   string            `json:"icon_emoji,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color,omitempty"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SendText posts a plain text message
+func (s *SlackNotifier) SendText(ctx context.Context, text string) error {
+	return s.send(ctx, s.payload(text))
+}
+
+// Notify posts msg as a Block Kit attachment colored by its level. The title
+// and text are also sent as the plain text fallback used in notifications.
+func (s *SlackNotifier) Notify(ctx context.Context, msg Message) error {
+	payload := s.payload(fallbackText(msg))
+
+	var blocks []slackBlock
+	if msg.Title != "" {
+		blocks = append(blocks, slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: msg.Title}})
+	}
+	if msg.Text != "" {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: msg.Text}})
+	}
+	// Slack allows at most 10 fields per section block
+	for start := 0; start < len(msg.Fields); start += 10 {
+		end := min(start+10, len(msg.Fields))
+		block := slackBlock{Type: "section"}
+		for _, field := range msg.Fields[start:end] {
+			block.Fields = append(block.Fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", field.Name, field.Value)})
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) > 0 {
+		payload.Attachments = []slackAttachment{{Color: slackColors[msg.Level], Blocks: blocks}}
+	}
+	return s.send(ctx, payload)
+}
+
+// payload returns a webhook payload with the configured overrides
+func (s *SlackNotifier) payload(text string) slackPayload {
+	return slackPayload{
+		Text:      text,
+		Channel:   s.Channel,
+		Username:  s.Username,
+		IconEmoji: s.IconEmoji,
+	}
+}
+
+// send posts the payload to the webhook
+func (s *SlackNotifier) send(ctx context.Context, payload slackPayload) error {
+	if s.WebhookURL == "" {
+		return fmt.Errorf("slack webhook URL is not set")
+	}
+	if _, err := postJSON(ctx, s.HTTPClient, s.WebhookURL, payload); err != nil {
+		return fmt.Errorf("failed to send slack message: %w", err)
+	}
+	return nil
+}
+
+// fallbackText combines the title and text for clients that cannot render blocks
+func fallbackText(msg Message) string {
+	switch {
+	case msg.Title == "":
+		return msg.Text
+	case msg.Text == "":
+		return msg.Title
+	default:
+		return msg.Title + "\n" + msg.Text
+	}
+}
@@ -0,0 +1,324 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/render"
+)
+
+// SMTPSecurity selects how the SMTP connection is secured
+type SMTPSecurity int
+
+const (
+	// SecurityStartTLS upgrades a plain connection with STARTTLS (usually port 587)
+	SecurityStartTLS SMTPSecurity = iota
+	// SecurityTLS connects with TLS from the start (usually port 465)
+	SecurityTLS
+	// SecurityNone sends without encryption; only for trusted local relays
+	SecurityNone
+)
+
+// Default templates used when SubjectTemplate or BodyTemplate is empty
+const (
+	DefaultSubjectTemplate = `{{if .Level}}[{{.Level}}] {{end}}{{.Title}}`
+	DefaultBodyTemplate    = `{{if .Title}}{{.Title}}
+
+{{end}}{{if .Text}}{{.Text}}
+{{end}}{{if .Fields}}
+{{range .Fields}}{{.Name}}: {{.Value}}
+{{end}}{{end}}`
+)
+
+// SMTPNotifier sends messages as email, with msg.Attachments attached as files
+type SMTPNotifier struct {
+	Host string
+	Port int // defaults to 587, or 465 with SecurityTLS
+	// Username and Password enable PLAIN authentication
+	Username string
+	Password string
+
+	Security SMTPSecurity
+	// TLSConfig customizes the TLS client; ServerName defaults to Host
+	TLSConfig *tls.Config
+	// InsecureSkipVerify disables certificate verification for this notifier only
+	InsecureSkipVerify bool
+
+	From string
+	To   []string
+	Cc   []string
+
+	// SubjectTemplate and BodyTemplate are text/template sources executed with
+	// the Message as data, e.g. "{{.Title}} ({{len .Fields}} fields)". The
+	// render package helpers such as bytes and date are available.
+	SubjectTemplate string
+	BodyTemplate    string
+
+	// Timeout bounds the whole SMTP conversation (defaults to 30 seconds)
+	Timeout time.Duration
+}
+
+// Email is a message sent as-is by SMTPNotifier.Send
+type Email struct {
+	Subject string
+	// Text is the plain text body
+	Text string
+	// HTML is an optional HTML alternative shown by clients that support it
+	HTML string
+	// Attachments are file paths attached to the email
+	Attachments []string
+}
+
+// Notify renders msg with the templates and sends it to all recipients
+func (s *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	subject, err := renderTemplate("subject", s.SubjectTemplate, DefaultSubjectTemplate, msg)
+	if err != nil {
+		return err
+	}
+	body, err := renderTemplate("body", s.BodyTemplate, DefaultBodyTemplate, msg)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, Email{Subject: subject, Text: body, Attachments: msg.Attachments})
+}
+
+// Send delivers an already rendered email to all recipients, bypassing the templates
+func (s *SMTPNotifier) Send(ctx context.Context, email Email) error {
+	if s.Host == "" || s.From == "" || len(s.To)+len(s.Cc) == 0 {
+		return fmt.Errorf("smtp notifier requires Host, From and at least one recipient")
+	}
+
+	data, err := s.buildMessage(strings.TrimSpace(email.Subject), email.Text, email.HTML, email.Attachments)
+	if err != nil {
+		return err
+	}
+	if err := s.send(ctx, data); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", s.Host, err)
+	}
+	return nil
+}
+
+// send delivers the message over SMTP
+func (s *SMTPNotifier) send(ctx context.Context, data []byte) error {
+	port := s.Port
+	if port == 0 {
+		port = 587
+		if s.Security == SecurityTLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(port))
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	tlsConfig := &tls.Config{}
+	if s.TLSConfig != nil {
+		tlsConfig = s.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = s.Host
+	}
+	if s.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if s.Security == SecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.From); err != nil {
+		return err
+	}
+	for _, rcpt := range append(append([]string{}, s.To...), s.Cc...) {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", rcpt, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage assembles a MIME message with a text body, an optional HTML
+// alternative and base64 attachments
+func (s *SMTPNotifier) buildMessage(subject, body, html string, attachments []string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", s.From)
+	header("To", strings.Join(s.To, ", "))
+	if len(s.Cc) > 0 {
+		header("Cc", strings.Join(s.Cc, ", "))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(s.Host))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+	buf.WriteString("\r\n")
+
+	if html == "" {
+		if err := writeQuotedPrintable(writer, "text/plain; charset=utf-8", body); err != nil {
+			return nil, err
+		}
+	} else {
+		var alternatives bytes.Buffer
+		altWriter := multipart.NewWriter(&alternatives)
+		if err := writeQuotedPrintable(altWriter, "text/plain; charset=utf-8", body); err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(altWriter, "text/html; charset=utf-8", html); err != nil {
+			return nil, err
+		}
+		altWriter.Close()
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"multipart/alternative; boundary=" + altWriter.Boundary()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		part.Write(alternatives.Bytes())
+	}
+
+	for _, filePath := range attachments {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %s: %w", filePath, err)
+		}
+
+		name := filepath.Base(filePath)
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64Lines(part, content)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable adds a quoted-printable part with CRLF line endings
+func writeQuotedPrintable(writer *multipart.Writer, contentType, content string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	qp.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n")))
+	return qp.Close()
+}
+
+// renderTemplate executes source, or fallback when source is empty, with msg as data
+func renderTemplate(name, source, fallback string, msg Message) (string, error) {
+	if source == "" {
+		source = fallback
+	}
+	tmpl, err := template.New(name).Funcs(render.Funcs()).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// writeBase64Lines writes content as base64 wrapped at 76 characters per RFC 2045
+func writeBase64Lines(w io.Writer, content []byte) {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// messageID returns a unique Message-ID header value
+func messageID(host string) string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%x@%s>", time.Now().UnixNano(), random, host)
+}
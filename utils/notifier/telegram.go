@@ -0,0 +1,183 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTelegramAPIURL is the Bot API base URL used when APIURL is empty
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// telegramMaxLength is the Bot API limit for message text
+const telegramMaxLength = 4096
+
+// TelegramNotifier sends messages through a Telegram bot to a chat.
+// msg.Attachments are sent as documents after the message.
+type TelegramNotifier struct {
+	BotToken string
+	// ChatID is a numeric chat ID or a "@channelusername"
+	ChatID string
+	// DisableNotification delivers messages silently
+	DisableNotification bool
+	// APIURL overrides the Bot API base URL, e.g. for a local Bot API server
+	APIURL string
+	// HTTPClient replaces the default client with a 30 second timeout
+	HTTPClient *http.Client
+}
+
+// telegramEmoji prefixes the title by message level
+var telegramEmoji = map[Level]string{
+	LevelInfo:    "ℹ️",
+	LevelSuccess: "✅",
+	LevelWarning: "⚠️",
+	LevelFailure: "❌",
+}
+
+// telegramMessage is the sendMessage request body
+type telegramMessage struct {
+	ChatID              string `json:"chat_id"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode,omitempty"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+}
+
+// SendText sends a plain text message
+func (t *TelegramNotifier) SendText(ctx context.Context, text string) error {
+	return t.sendMessage(ctx, telegramMessage{Text: truncate(text, telegramMaxLength)})
+}
+
+// Notify sends msg formatted as HTML with a level emoji, then any attachments
+func (t *TelegramNotifier) Notify(ctx context.Context, msg Message) error {
+	var b strings.Builder
+	if msg.Title != "" {
+		if emoji := telegramEmoji[msg.Level]; emoji != "" {
+			b.WriteString(emoji + " ")
+		}
+		fmt.Fprintf(&b, "<b>%s</b>\n", html.EscapeString(msg.Title))
+	}
+	if msg.Text != "" {
+		b.WriteString(html.EscapeString(msg.Text) + "\n")
+	}
+	if len(msg.Fields) > 0 {
+		b.WriteString("\n")
+		for _, field := range msg.Fields {
+			fmt.Fprintf(&b, "<b>%s:</b> %s\n", html.EscapeString(field.Name), html.EscapeString(field.Value))
+		}
+	}
+
+	text := strings.TrimSpace(b.String())
+	parseMode := "HTML"
+	if len(text) > telegramMaxLength {
+		// Truncating could cut through a tag, so fall back to plain text
+		text, parseMode = truncate(fallbackText(msg), telegramMaxLength), ""
+	}
+
+	if err := t.sendMessage(ctx, telegramMessage{Text: text, ParseMode: parseMode}); err != nil {
+		return err
+	}
+	for _, filePath := range msg.Attachments {
+		if err := t.sendDocument(ctx, filePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendMessage calls the sendMessage method
+func (t *TelegramNotifier) sendMessage(ctx context.Context, message telegramMessage) error {
+	url, err := t.methodURL("sendMessage")
+	if err != nil {
+		return err
+	}
+	message.ChatID = t.ChatID
+	message.DisableNotification = t.DisableNotification
+
+	if _, err := postJSON(ctx, t.HTTPClient, url, message); err != nil {
+		return fmt.Errorf("failed to send telegram message: %w", err)
+	}
+	return nil
+}
+
+// sendDocument uploads a file with the sendDocument method
+func (t *TelegramNotifier) sendDocument(ctx context.Context, filePath string) error {
+	url, err := t.methodURL("sendDocument")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open attachment %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("chat_id", t.ChatID)
+	if t.DisableNotification {
+		writer.WriteField("disable_notification", "true")
+	}
+	part, err := writer.CreateFormFile("document", filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to read attachment %s: %w", filePath, err)
+	}
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := t.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telegram document %s: %w", filePath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return fmt.Errorf("failed to send telegram document %s: unexpected status %s: %s", filePath, resp.Status, bytes.TrimSpace(respBody))
+	}
+	return nil
+}
+
+// methodURL returns the Bot API URL for a method
+func (t *TelegramNotifier) methodURL(method string) (string, error) {
+	if t.BotToken == "" || t.ChatID == "" {
+		return "", fmt.Errorf("telegram notifier requires BotToken and ChatID")
+	}
+	base := t.APIURL
+	if base == "" {
+		base = DefaultTelegramAPIURL
+	}
+	return fmt.Sprintf("%s/bot%s/%s", strings.TrimRight(base, "/"), t.BotToken, method), nil
+}
+
+// truncate shortens s to at most limit bytes without splitting a UTF-8 sequence
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	s = s[:limit-len("…")]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "…"
+}
@@ -0,0 +1,265 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package parquet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/csvhelper"
+)
+
+// Ext is the extension of converted files
+const Ext = ".parquet"
+
+// Compression selects the codec used for column pages
+type Compression string
+
+// Supported compression codecs
+const (
+	Snappy       Compression = "snappy"
+	Gzip         Compression = "gzip"
+	Zstd         Compression = "zstd"
+	Uncompressed Compression = "none"
+)
+
+// batchSize is how many rows are buffered before they are written
+const batchSize = 1024
+
+// Options configures a conversion
+type Options struct {
+	// Schema describes the output columns. It is required.
+	Schema Schema
+	// Compression defaults to Snappy
+	Compression Compression
+	// CSV configures how the delimited input is read, e.g. the delimiter,
+	// validation rules and quarantine file. Rows that fail to convert to the
+	// schema are quarantined like rows failing a rule.
+	CSV *csvhelper.Options
+	// RowGroupSize limits the rows per row group (defaults to the library default)
+	RowGroupSize int64
+}
+
+// Result describes a converted file
+type Result struct {
+	Source string
+	Path   string
+	// Rows is the number of rows written
+	Rows        int
+	Quarantined int
+	Size        int64
+
+	header []string
+}
+
+// codec returns the compression codec for c
+func (c Compression) codec() (compress.Codec, error) {
+	switch c {
+	case "", Snappy:
+		return &pq.Snappy, nil
+	case Gzip:
+		return &pq.Gzip, nil
+	case Zstd:
+		return &pq.Zstd, nil
+	case Uncompressed:
+		return &pq.Uncompressed, nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", c)
+}
+
+// LoadSchema reads a schema from a JSON file holding an array of columns,
+// e.g. [{"name": "id", "type": "int64"}, {"name": "amount", "type": "decimal", "scale": 2}]
+func LoadSchema(path string) (Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %w", path, err)
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// Convert converts the delimited file src into a Parquet file at dst. An
+// empty dst replaces the extension of src with Ext. The output is written to
+// a temporary file first and removed if the conversion fails.
+func Convert(ctx context.Context, src, dst string, opts *Options) (*Result, error) {
+	var csvOpts *csvhelper.Options
+	if opts != nil {
+		csvOpts = opts.CSV
+	}
+	return convert(ctx, src, dst, opts, csvOpts)
+}
+
+// ConvertParts converts the parts written by splitter.SplitFileByLines, in
+// order, into one Parquet file each. Only the first part has a header row,
+// so its header is used for the rest. Outputs are written to outputDir, or
+// next to each part when outputDir is empty. A CSV QuarantinePath gets a
+// "_partN" suffix per part, and MaxBadRows applies to each part.
+func ConvertParts(ctx context.Context, parts []string, outputDir string, opts *Options) ([]*Result, error) {
+	var csvOpts csvhelper.Options
+	if opts != nil && opts.CSV != nil {
+		csvOpts = *opts.CSV
+	}
+	quarantine := csvOpts.QuarantinePath
+
+	var results []*Result
+	for i, part := range parts {
+		dst := ""
+		if outputDir != "" {
+			dst = filepath.Join(outputDir, outputName(part))
+		}
+		if i == 1 && csvOpts.Header == nil {
+			// The first part read the header; the remaining parts only hold rows
+			csvOpts.Header = results[0].header
+		}
+		if quarantine != "" {
+			ext := filepath.Ext(quarantine)
+			csvOpts.QuarantinePath = fmt.Sprintf("%s_part%d%s", strings.TrimSuffix(quarantine, ext), i+1, ext)
+		}
+		result, err := convert(ctx, part, dst, opts, &csvOpts)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Parts returns the parts splitter.SplitFileByLines wrote to outputDir for
+// the source file, ordered by part number
+func Parts(outputDir, sourceFile string) ([]string, error) {
+	ext := filepath.Ext(sourceFile)
+	base := strings.TrimSuffix(filepath.Base(sourceFile), ext)
+
+	var parts []string
+	for n := 1; ; n++ {
+		path := filepath.Join(outputDir, fmt.Sprintf("%s_part%d%s", base, n, ext))
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		parts = append(parts, path)
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no parts of %s found in %s", filepath.Base(sourceFile), outputDir)
+	}
+	return parts, nil
+}
+
+// outputName returns the Parquet file name for src
+func outputName(src string) string {
+	name := filepath.Base(src)
+	return strings.TrimSuffix(name, filepath.Ext(name)) + Ext
+}
+
+// convert converts src to dst reading it with csvOpts
+func convert(ctx context.Context, src, dst string, opts *Options, csvOpts *csvhelper.Options) (*Result, error) {
+	if opts == nil || len(opts.Schema) == 0 {
+		return nil, fmt.Errorf("a schema is required")
+	}
+	if err := opts.Schema.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	codec, err := opts.Compression.codec()
+	if err != nil {
+		return nil, err
+	}
+	if dst == "" {
+		dst = filepath.Join(filepath.Dir(src), outputName(src))
+	}
+
+	reader, err := csvhelper.Open(src, csvOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	columns, err := opts.Schema.bind(reader.Header())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s to schema: %w", src, err)
+	}
+
+	file, err := atomicfile.Create(dst)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Abort()
+
+	writerOpts := []pq.WriterOption{opts.Schema.parquetSchema(), pq.Compression(codec)}
+	if opts.RowGroupSize > 0 {
+		writerOpts = append(writerOpts, pq.MaxRowsPerRowGroup(opts.RowGroupSize))
+	}
+	writer := pq.NewWriter(file, writerOpts...)
+
+	result := &Result{Source: src, Path: dst, header: reader.Header()}
+	err = writeRows(ctx, reader, writer, columns, result)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %w", src, err)
+	}
+	if err := reader.Close(); err != nil {
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	result.Quarantined = reader.Stats().Quarantined
+	if info, err := os.Stat(dst); err == nil {
+		result.Size = info.Size()
+	}
+	return result, nil
+}
+
+// writeRows converts the rows of reader and writes them in batches
+func writeRows(ctx context.Context, reader *csvhelper.Reader, writer *pq.Writer, columns []boundColumn, result *Result) error {
+	batch := make([]pq.Row, 0, batchSize)
+	flush := func() error {
+		if _, err := writer.WriteRows(batch); err != nil {
+			return err
+		}
+		result.Rows += len(batch)
+		batch = batch[:0]
+		return ctx.Err()
+	}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		values, err := convertRow(row, columns)
+		if err != nil {
+			if qerr := reader.Quarantine(row, err); qerr != nil {
+				return qerr
+			}
+			continue
+		}
+		if batch = append(batch, values); len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
@@ -0,0 +1,230 @@
+package parquet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+
+	"github.com/romisugianto/go-utils/utils/csvhelper"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/splitter"
+)
+
+var paymentsSchema = Schema{
+	{Name: "id", Type: Int64},
+	{Name: "amount", Type: Decimal, Scale: 2, Precision: 12},
+	{Name: "paid_on", Type: Date, Source: "date", Layout: "02/01/2006"},
+	{Name: "settled_at", Type: Timestamp, Nullable: true},
+	{Name: "reference", Type: String, Nullable: true},
+	{Name: "refund", Type: Boolean},
+}
+
+// readFile returns the column names and rows of a Parquet file
+func readFile(t *testing.T, path string) ([]string, []pq.Row) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := pq.NewReader(file)
+	defer reader.Close()
+	var names []string
+	for _, field := range reader.Schema().Fields() {
+		names = append(names, field.Name())
+	}
+	var rows []pq.Row
+	for {
+		buf := make([]pq.Row, 16)
+		n, err := reader.ReadRows(buf)
+		rows = append(rows, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ReadRows failed: %v", err)
+		}
+	}
+	return names, rows
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "payments.csv")
+	os.WriteFile(src, []byte(strings.Join([]string{
+		"id;date;amount;settled_at;reference;refund",
+		"1;01/06/2024;1250.5;2024-06-01T10:30:00Z;INV-1;false",
+		"2;02/06/2024;-0.75;;;true",
+		"3;03/06/2024;12.345;;INV-3;false",
+		"x;04/06/2024;1;;;false",
+		"5;2024-06-05;1;;;false",
+		"6;06/06/2024;99;2024-06-06 08:00:00;INV-6;no",
+	}, "\n")+"\n"), 0644)
+
+	for _, compression := range []Compression{"", Gzip, Zstd, Uncompressed} {
+		result, err := Convert(context.Background(), src, "", &Options{
+			Schema:      paymentsSchema,
+			Compression: compression,
+			CSV:         &csvhelper.Options{QuarantinePath: filepath.Join(dir, "bad.csv")},
+		})
+		if err != nil {
+			t.Fatalf("Convert(%s) failed: %v", compression, err)
+		}
+		if result.Path != filepath.Join(dir, "payments.parquet") || result.Rows != 2 || result.Quarantined != 4 || result.Size == 0 {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	names, rows := readFile(t, filepath.Join(dir, "payments.parquet"))
+	if strings.Join(names, ",") != "id,amount,paid_on,settled_at,reference,refund" {
+		t.Errorf("columns = %v, expected schema order", names)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	first, second := rows[0], rows[1]
+	if first[0].Int64() != 1 || first[1].Int64() != 125050 || first[4].String() != "INV-1" || first[5].Boolean() {
+		t.Errorf("unexpected first row %v", first)
+	}
+	paidOn := time.Unix(int64(first[2].Int32())*86400, 0).UTC()
+	if paidOn.Format("2006-01-02") != "2024-06-01" {
+		t.Errorf("paid_on = %v", paidOn)
+	}
+	if settled := time.UnixMicro(first[3].Int64()).UTC(); !settled.Equal(time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("settled_at = %v", settled)
+	}
+	if second[1].Int64() != -75 || !second[3].IsNull() || !second[4].IsNull() || !second[5].Boolean() {
+		t.Errorf("unexpected second row %v", second)
+	}
+
+	quarantined, _ := os.ReadFile(filepath.Join(dir, "bad.csv"))
+	for _, reason := range []string{"column amount: decimal", "column id: invalid int64", "column paid_on: invalid date", "column refund: invalid boolean"} {
+		if !strings.Contains(string(quarantined), reason) {
+			t.Errorf("quarantine file missing %q:\n%s", reason, quarantined)
+		}
+	}
+
+	// Conversion stops once too many rows are bad and leaves no output
+	dst := filepath.Join(dir, "out", "strict.parquet")
+	_, err := Convert(context.Background(), src, dst, &Options{Schema: paymentsSchema, CSV: &csvhelper.Options{MaxBadRows: 1}})
+	if !errors.Is(err, csvhelper.ErrTooManyBadRows) {
+		t.Errorf("expected ErrTooManyBadRows, got %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dst)); len(entries) != 0 {
+		t.Errorf("expected no output, found %v", entries)
+	}
+
+	if _, err := Convert(context.Background(), src, dst, &Options{Schema: Schema{{Name: "missing", Type: String}}}); err == nil {
+		t.Error("expected error for column missing from input")
+	}
+	if _, err := Convert(context.Background(), src, dst, &Options{Schema: paymentsSchema, Compression: "lzo"}); err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+	if _, err := Convert(context.Background(), src, dst, nil); err == nil {
+		t.Error("expected error without schema")
+	}
+}
+
+func TestConvertParts(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "orders.csv")
+	lines := []string{"id,customer,total"}
+	for i := 1; i <= 7; i++ {
+		lines = append(lines, strings.Join([]string{string(rune('0' + i)), "acme", "10.5"}, ","))
+	}
+	os.WriteFile(src, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+
+	log, err := logger.NewLogger("parquet_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	s, _ := splitter.NewSplitter(log)
+	if err := s.SplitFileByLines(src, 3, filepath.Join(dir, "parts"), filepath.Join(dir, "processed")); err != nil {
+		t.Fatalf("SplitFileByLines failed: %v", err)
+	}
+
+	parts, err := Parts(filepath.Join(dir, "parts"), src)
+	if err != nil {
+		t.Fatalf("Parts failed: %v", err)
+	}
+	if len(parts) != 3 || filepath.Base(parts[2]) != "orders_part3.csv" {
+		t.Fatalf("unexpected parts %v", parts)
+	}
+
+	schema := Schema{{Name: "id", Type: Int32}, {Name: "customer", Type: String}, {Name: "total", Type: Double}}
+	results, err := ConvertParts(context.Background(), parts, filepath.Join(dir, "lake"), &Options{Schema: schema})
+	if err != nil {
+		t.Fatalf("ConvertParts failed: %v", err)
+	}
+	total := 0
+	for _, result := range results {
+		total += result.Rows
+	}
+	// The first part holds the header and two rows
+	if len(results) != 3 || results[0].Rows != 2 || total != 7 {
+		t.Errorf("unexpected results %+v", results)
+	}
+	_, rows := readFile(t, filepath.Join(dir, "lake", "orders_part3.parquet"))
+	if len(rows) != 2 || rows[1][0].Int32() != 7 || rows[1][2].Double() != 10.5 {
+		t.Errorf("unexpected rows %v", rows)
+	}
+
+	if _, err := Parts(dir, "missing.csv"); err == nil {
+		t.Error("expected error when no parts exist")
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	os.WriteFile(path, []byte(`[{"name": "id", "type": "int64"}, {"name": "amount", "type": "decimal", "scale": 2, "nullable": true}]`), 0644)
+	schema, err := LoadSchema(path)
+	if err != nil {
+		t.Fatalf("LoadSchema failed: %v", err)
+	}
+	if len(schema) != 2 || schema[1].Type != Decimal || schema[1].Scale != 2 || !schema[1].Nullable {
+		t.Errorf("unexpected schema %+v", schema)
+	}
+
+	invalid := []Schema{
+		{{Name: "id", Type: "uuid"}},
+		{{Name: "id", Type: Int64}, {Name: "id", Type: String}},
+		{{Type: String}},
+		{{Name: "amount", Type: Decimal, Precision: 19}},
+		{{Name: "amount", Type: Decimal, Scale: 5, Precision: 4}},
+	}
+	for _, schema := range invalid {
+		if err := schema.Validate(); err == nil {
+			t.Errorf("expected error for schema %+v", schema)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]int64{
+		"1250.5":  125050,
+		"-0.75":   -75,
+		"+3":      300,
+		"007.10":  710,
+		".5":      50,
+		"9999.99": 999999,
+	}
+	for input, expected := range tests {
+		if got, err := parseDecimal(input, 2, 6); err != nil || got != expected {
+			t.Errorf("parseDecimal(%q) = %d, %v, expected %d", input, got, err, expected)
+		}
+	}
+	for _, input := range []string{"1.234", "10000", "1e3", "1,5", "-", ".", "--1", "1.-5"} {
+		if _, err := parseDecimal(input, 2, 6); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package parquet
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+
+	"github.com/romisugianto/go-utils/utils/csvhelper"
+)
+
+// Type is the logical type of a column
+type Type string
+
+// Supported column types
+const (
+	String  Type = "string"
+	Int32   Type = "int32"
+	Int64   Type = "int64"
+	Float   Type = "float"
+	Double  Type = "double"
+	Boolean Type = "boolean"
+	// Decimal stores exact values such as amounts as an int64 scaled by Scale
+	Decimal Type = "decimal"
+	// Date stores days since the Unix epoch
+	Date Type = "date"
+	// Timestamp stores microseconds since the Unix epoch in UTC
+	Timestamp Type = "timestamp"
+)
+
+// maxDecimalPrecision is the number of digits an int64 decimal can hold
+const maxDecimalPrecision = 18
+
+// timeLayouts are tried in order for Date and Timestamp columns without a Layout
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// Column describes one output column
+type Column struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Source is the input column read into this one, defaulting to Name
+	Source string `json:"source,omitempty"`
+	// Nullable stores empty values as null. Empty values in other columns
+	// are rejected, except for strings, which are stored as "".
+	Nullable bool `json:"nullable,omitempty"`
+	// Layout parses Date and Timestamp values, e.g. "02/01/2006"
+	Layout string `json:"layout,omitempty"`
+	// Scale is the number of fractional digits of a Decimal, e.g. 2 for amounts
+	Scale int `json:"scale,omitempty"`
+	// Precision is the total number of digits of a Decimal (defaults to 18, the maximum)
+	Precision int `json:"precision,omitempty"`
+}
+
+// Schema lists the output columns in order
+type Schema []Column
+
+// boundColumn is a column mapped to its field in the input
+type boundColumn struct {
+	Column
+	field int
+}
+
+// Validate checks the column names, types and decimal settings
+func (s Schema) Validate() error {
+	seen := make(map[string]bool, len(s))
+	for _, column := range s {
+		if column.Name == "" {
+			return fmt.Errorf("column name cannot be empty")
+		}
+		if seen[column.Name] {
+			return fmt.Errorf("duplicate column %q", column.Name)
+		}
+		seen[column.Name] = true
+
+		switch column.Type {
+		case String, Int32, Int64, Float, Double, Boolean, Date, Timestamp:
+		case Decimal:
+			if column.Precision < 0 || column.Precision > maxDecimalPrecision {
+				return fmt.Errorf("column %q: precision must be between 1 and %d", column.Name, maxDecimalPrecision)
+			}
+			if column.Scale < 0 || column.Scale > column.precision() {
+				return fmt.Errorf("column %q: scale must be between 0 and the precision", column.Name)
+			}
+		default:
+			return fmt.Errorf("column %q: unsupported type %q", column.Name, column.Type)
+		}
+	}
+	return nil
+}
+
+// precision returns the Decimal precision, applying the default
+func (c Column) precision() int {
+	if c.Precision == 0 {
+		return maxDecimalPrecision
+	}
+	return c.Precision
+}
+
+// node returns the Parquet node for the column
+func (c Column) node() pq.Node {
+	var node pq.Node
+	switch c.Type {
+	case String:
+		node = pq.String()
+	case Int32:
+		node = pq.Leaf(pq.Int32Type)
+	case Int64:
+		node = pq.Leaf(pq.Int64Type)
+	case Float:
+		node = pq.Leaf(pq.FloatType)
+	case Double:
+		node = pq.Leaf(pq.DoubleType)
+	case Boolean:
+		node = pq.Leaf(pq.BooleanType)
+	case Decimal:
+		node = pq.Decimal(c.Scale, c.precision(), pq.Int64Type)
+	case Date:
+		node = pq.Date()
+	case Timestamp:
+		node = pq.Timestamp(pq.Microsecond)
+	}
+	if c.Nullable {
+		return pq.Optional(node)
+	}
+	return pq.Required(node)
+}
+
+// orderedGroup is a group node that keeps its fields in schema order rather
+// than the alphabetical order of pq.Group
+type orderedGroup struct {
+	pq.Group
+	names []string
+}
+
+// Fields returns the fields in schema order
+func (g orderedGroup) Fields() []pq.Field {
+	fields := g.Group.Fields()
+	slices.SortFunc(fields, func(a, b pq.Field) int {
+		return slices.Index(g.names, a.Name()) - slices.Index(g.names, b.Name())
+	})
+	return fields
+}
+
+// parquetSchema returns the Parquet schema
+func (s Schema) parquetSchema() *pq.Schema {
+	group := orderedGroup{Group: make(pq.Group, len(s)), names: make([]string, len(s))}
+	for i, column := range s {
+		group.Group[column.Name] = column.node()
+		group.names[i] = column.Name
+	}
+	return pq.NewSchema("schema", group)
+}
+
+// bind maps every column to its field in header
+func (s Schema) bind(header []string) ([]boundColumn, error) {
+	columns := make([]boundColumn, len(s))
+	for i, column := range s {
+		source := column.Source
+		if source == "" {
+			source = column.Name
+		}
+		field := slices.Index(header, source)
+		if field < 0 {
+			return nil, fmt.Errorf("column %q not found in input", source)
+		}
+		columns[i] = boundColumn{Column: column, field: field}
+	}
+	return columns, nil
+}
+
+// convertRow converts the fields of row into a Parquet row
+func convertRow(row csvhelper.Row, columns []boundColumn) (pq.Row, error) {
+	values := make(pq.Row, len(columns))
+	for i, column := range columns {
+		var raw string
+		if column.field < len(row.Fields) {
+			raw = row.Fields[column.field]
+		}
+		value, err := column.parse(raw)
+		if err != nil {
+			return nil, &csvhelper.RowError{Line: row.Line, Column: column.Name, Err: err}
+		}
+		definition := 0
+		if column.Nullable && !value.IsNull() {
+			definition = 1
+		}
+		values[i] = value.Level(0, definition, i)
+	}
+	return values, nil
+}
+
+// parse converts raw into a value of the column type
+func (c Column) parse(raw string) (pq.Value, error) {
+	if c.Type == String {
+		if raw == "" && c.Nullable {
+			return pq.NullValue(), nil
+		}
+		return pq.ByteArrayValue([]byte(raw)), nil
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if c.Nullable {
+			return pq.NullValue(), nil
+		}
+		return pq.Value{}, fmt.Errorf("value is required")
+	}
+
+	switch c.Type {
+	case Int32:
+		n, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return pq.Value{}, fmt.Errorf("invalid int32 %q", raw)
+		}
+		return pq.Int32Value(int32(n)), nil
+	case Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return pq.Value{}, fmt.Errorf("invalid int64 %q", raw)
+		}
+		return pq.Int64Value(n), nil
+	case Float:
+		f, err := strconv.ParseFloat(raw, 32)
+		if err != nil {
+			return pq.Value{}, fmt.Errorf("invalid float %q", raw)
+		}
+		return pq.FloatValue(float32(f)), nil
+	case Double:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return pq.Value{}, fmt.Errorf("invalid double %q", raw)
+		}
+		return pq.DoubleValue(f), nil
+	case Boolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return pq.Value{}, fmt.Errorf("invalid boolean %q", raw)
+		}
+		return pq.BooleanValue(b), nil
+	case Decimal:
+		n, err := parseDecimal(raw, c.Scale, c.precision())
+		if err != nil {
+			return pq.Value{}, err
+		}
+		return pq.Int64Value(n), nil
+	case Date:
+		t, err := c.parseTime(raw)
+		if err != nil {
+			return pq.Value{}, err
+		}
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return pq.Int32Value(int32(day.Unix() / 86400)), nil
+	case Timestamp:
+		t, err := c.parseTime(raw)
+		if err != nil {
+			return pq.Value{}, err
+		}
+		return pq.Int64Value(t.UnixMicro()), nil
+	}
+	return pq.Value{}, fmt.Errorf("unsupported type %q", c.Type)
+}
+
+// parseTime parses raw with the column layout or the default layouts
+func (c Column) parseTime(raw string) (time.Time, error) {
+	layouts := timeLayouts
+	if c.Layout != "" {
+		layouts = []string{c.Layout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q", c.Type, raw)
+}
+
+// parseDecimal parses a decimal string such as "-1234.5" into an integer
+// scaled by scale digits, e.g. -123450 for scale 2. Values with more
+// fractional digits than scale are rejected rather than rounded.
+func parseDecimal(raw string, scale, precision int) (int64, error) {
+	digits, negative := raw, false
+	if raw[0] == '-' || raw[0] == '+' {
+		digits, negative = raw[1:], raw[0] == '-'
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid decimal %q", raw)
+	}
+	if len(fraction) > scale {
+		return 0, fmt.Errorf("decimal %q has more than %d fractional digits", raw, scale)
+	}
+	whole = strings.TrimLeft(whole, "0")
+	if len(whole)+scale > precision {
+		return 0, fmt.Errorf("decimal %q exceeds precision %d", raw, precision)
+	}
+
+	// Precision is at most 18 digits, so the unscaled value fits an int64
+	n, err := strconv.ParseUint("0"+whole+fraction+strings.Repeat("0", scale-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimal %q", raw)
+	}
+	if negative {
+		return -int64(n), nil
+	}
+	return int64(n), nil
+}
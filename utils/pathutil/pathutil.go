@@ -0,0 +1,127 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package pathutil
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnsafePath is returned when an untrusted path is absolute or would
+// escape the directory it is joined to
+var ErrUnsafePath = errors.New("unsafe path")
+
+// maxFilenameBytes is the file name limit of most file systems
+const maxFilenameBytes = 255
+
+// reservedNames are device names Windows does not allow as file names, with
+// or without an extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Normalize converts p to a clean slash-separated path, treating both "/"
+// and "\" as separators so Windows paths from archives, manifests or
+// configs compare equal on every platform. A trailing separator is dropped.
+func Normalize(p string) string {
+	if p == "" {
+		return ""
+	}
+	return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// ToKey converts a local relative path to an object storage key: separators
+// become "/", the path is cleaned and leading slashes are removed. "." and ""
+// become "".
+func ToKey(p string) string {
+	key := strings.TrimLeft(path.Clean(filepath.ToSlash(p)), "/")
+	if key == "." {
+		return ""
+	}
+	return key
+}
+
+// IsAbs reports whether p is absolute on any platform: it starts with a
+// separator or a Windows drive letter such as "C:"
+func IsAbs(p string) bool {
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) {
+		return true
+	}
+	return len(p) >= 2 && p[1] == ':' && p[0] < utf8.RuneSelf && unicode.IsLetter(rune(p[0]))
+}
+
+// SafeJoin joins untrusted relative elements, such as archive entry names
+// or object keys, to root. Elements may use "/" or "\" separators. It
+// returns ErrUnsafePath when an element is absolute or the result would be
+// outside root; ".." components that stay inside root are allowed.
+func SafeJoin(root string, elems ...string) (string, error) {
+	rel := ""
+	for _, elem := range elems {
+		if IsAbs(elem) {
+			return "", fmt.Errorf("%w: %q is absolute", ErrUnsafePath, elem)
+		}
+		rel = path.Join(rel, strings.ReplaceAll(elem, `\`, "/"))
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%w: %q escapes %s", ErrUnsafePath, strings.Join(elems, "/"), root)
+	}
+	return filepath.Join(root, filepath.FromSlash(rel)), nil
+}
+
+// IsSubpath reports whether target is base or inside it. Both paths are
+// cleaned and compared lexically; symbolic links are not resolved.
+func IsSubpath(base, target string) bool {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// SanitizeFilename makes name safe as a single file name on Windows, macOS
+// and Linux. Separators, control characters and characters Windows rejects
+// are replaced with "_", trailing dots and spaces are removed, reserved
+// device names such as "CON" get a "_" suffix and the name is shortened to
+// 255 bytes keeping its extension. An empty result becomes "_".
+func SanitizeFilename(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, r < 0x20, r == 0x7f, strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	clean := strings.TrimRight(b.String(), ". ")
+	if clean == "" {
+		return "_"
+	}
+
+	stem := clean
+	if i := strings.IndexByte(stem, '.'); i >= 0 {
+		stem = stem[:i]
+	}
+	if reservedNames[strings.ToUpper(strings.TrimSpace(stem))] {
+		clean = stem + "_" + clean[len(stem):]
+	}
+
+	if len(clean) > maxFilenameBytes {
+		ext := path.Ext(clean)
+		if len(ext) > maxFilenameBytes/2 {
+			ext = ""
+		}
+		head := clean[:maxFilenameBytes-len(ext)]
+		for !utf8.ValidString(head) {
+			head = head[:len(head)-1]
+		}
+		clean = strings.TrimRight(head, ". ") + ext
+	}
+	return clean
+}
@@ -0,0 +1,117 @@
+package pathutil
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.Join("data", "extract")
+	valid := map[string][]string{
+		filepath.Join(root, "a", "b.txt"): {"a/b.txt"},
+		filepath.Join(root, "a", "c.txt"): {"a", `b\..\c.txt`},
+		filepath.Join(root, "b.txt"):      {"./a/../b.txt"},
+		filepath.Join(root, "win", "x"):   {`win\x`},
+		root:                              {""},
+	}
+	for expected, elems := range valid {
+		got, err := SafeJoin(root, elems...)
+		if err != nil || got != expected {
+			t.Errorf("SafeJoin(%q) = %q, %v, expected %q", elems, got, err, expected)
+		}
+	}
+
+	for _, elems := range [][]string{
+		{"../evil.txt"},
+		{"sub/../../evil.txt"},
+		{`..\evil.txt`},
+		{"a", "../../evil"},
+		{"/etc/passwd"},
+		{`C:\Windows\evil.dll`},
+		{`\\server\share`},
+	} {
+		if _, err := SafeJoin(root, elems...); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("SafeJoin(%q) = %v, expected ErrUnsafePath", elems, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		`reports\2024\june.csv`: "reports/2024/june.csv",
+		"a//b/./c/":             "a/b/c",
+		"a/b/../c":              "a/c",
+		"/abs/path/":            "/abs/path",
+		"":                      "",
+		".":                     ".",
+	}
+	for input, expected := range tests {
+		if got := Normalize(input); got != expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	keys := map[string]string{
+		"/inbound/orders.csv": "inbound/orders.csv",
+		"inbound//parts/":     "inbound/parts",
+		"./a/../b":            "b",
+		"/":                   "",
+		".":                   "",
+	}
+	for input, expected := range keys {
+		if got := ToKey(input); got != expected {
+			t.Errorf("ToKey(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestIsSubpath(t *testing.T) {
+	base := filepath.Join("srv", "inbound")
+	tests := []struct {
+		target   string
+		expected bool
+	}{
+		{filepath.Join(base, "a.csv"), true},
+		{filepath.Join(base, "sub", "b.csv"), true},
+		{base, true},
+		{filepath.Join(base, "..", "inbound", "c.csv"), true},
+		{filepath.Join("srv", "inbound2", "a.csv"), false},
+		{filepath.Join(base, "..", "outbound"), false},
+		{"srv", false},
+		{"..", false},
+	}
+	for _, test := range tests {
+		if got := IsSubpath(base, test.target); got != test.expected {
+			t.Errorf("IsSubpath(%q, %q) = %t, expected %t", base, test.target, got, test.expected)
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"report 2024-06-01.csv": "report 2024-06-01.csv",
+		"run:2024/06/01":        "run_2024_06_01",
+		`a<b>c"d|e?f*g\h`:       "a_b_c_d_e_f_g_h",
+		"tab\there\x00":         "tab_here_",
+		"trailing. . ":          "trailing",
+		"CON":                   "CON_",
+		"nul.txt":               "nul_.txt",
+		"console.log":           "console.log",
+		"":                      "_",
+		"..":                    "_",
+		"données-été.csv":       "données-été.csv",
+		"invalid\xffutf8.txt":   "invalid_utf8.txt",
+	}
+	for input, expected := range tests {
+		if got := SanitizeFilename(input); got != expected {
+			t.Errorf("SanitizeFilename(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	long := SanitizeFilename(strings.Repeat("é", 200) + ".csv")
+	if len(long) > 255 || !strings.HasSuffix(long, "é.csv") {
+		t.Errorf("long name not shortened correctly: %d bytes, %q", len(long), long[len(long)-10:])
+	}
+}
@@ -0,0 +1,359 @@
+package pgp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// Keyring holds the public keys of partners and our own private keys. It is
+// safe for concurrent use.
+type Keyring struct {
+	mu       sync.RWMutex
+	entities openpgp.EntityList
+}
+
+// KeyInfo describes a key in the keyring
+type KeyInfo struct {
+	// Fingerprint is the upper-case hex fingerprint of the primary key
+	Fingerprint string
+	// KeyID is the 16 character hex key ID
+	KeyID      string
+	Identities []string
+	// HasPrivate reports whether the private key is available; Locked
+	// reports whether it still needs a passphrase
+	HasPrivate bool
+	Locked     bool
+	Created    time.Time
+	// Expires is zero for keys that do not expire
+	Expires time.Time
+}
+
+// GenerateOptions configures Generate
+type GenerateOptions struct {
+	// RSABits generates an RSA key of that size, for partners that do not
+	// support elliptic curves. Zero generates an Ed25519/Curve25519 key.
+	RSABits int
+	// Lifetime sets the key expiry; zero never expires
+	Lifetime time.Duration
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{}
+}
+
+// LoadKeyring creates a keyring from armored or binary key files
+func LoadKeyring(paths ...string) (*Keyring, error) {
+	k := NewKeyring()
+	for _, path := range paths {
+		if err := k.ImportFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// ImportFile adds the keys in an armored or binary key file
+func (k *Keyring) ImportFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open key file %s: %w", path, err)
+	}
+	defer file.Close()
+	if err := k.Import(file); err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	return nil
+}
+
+// Import adds the keys read from r, armored or binary. A private key
+// replaces an already imported public key with the same fingerprint.
+func (k *Keyring) Import(r io.Reader) error {
+	body, err := dearmor(r)
+	if err != nil {
+		return err
+	}
+	entities, err := openpgp.ReadKeyRing(body)
+	if err != nil {
+		return fmt.Errorf("failed to read keys: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, entity := range entities {
+		k.add(entity)
+	}
+	return nil
+}
+
+// add inserts or replaces entity; k.mu must be held
+func (k *Keyring) add(entity *openpgp.Entity) {
+	for i, existing := range k.entities {
+		if bytes.Equal(existing.PrimaryKey.Fingerprint, entity.PrimaryKey.Fingerprint) {
+			if entity.PrivateKey != nil || existing.PrivateKey == nil {
+				k.entities[i] = entity
+			}
+			return
+		}
+	}
+	k.entities = append(k.entities, entity)
+}
+
+// Generate creates a key pair for name and email and adds it to the keyring
+func (k *Keyring) Generate(name, email string, opts *GenerateOptions) (KeyInfo, error) {
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	if opts != nil {
+		if opts.RSABits > 0 {
+			config = &packet.Config{Algorithm: packet.PubKeyAlgoRSA, RSABits: opts.RSABits}
+		}
+		config.KeyLifetimeSecs = uint32(opts.Lifetime / time.Second)
+	}
+
+	entity, err := openpgp.NewEntity(name, "", email, config)
+	if err != nil {
+		return KeyInfo{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.add(entity)
+	return keyInfo(entity), nil
+}
+
+// Unlock decrypts the private keys matching identity with passphrase. An
+// empty identity unlocks every locked key the passphrase opens.
+func (k *Keyring) Unlock(identity string, passphrase []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var candidates []*openpgp.Entity
+	if identity != "" {
+		entity, err := k.find(identity)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, entity)
+	} else {
+		candidates = k.entities
+	}
+
+	unlocked := 0
+	var lastErr error
+	for _, entity := range candidates {
+		if entity.PrivateKey == nil || !entity.PrivateKey.Encrypted {
+			continue
+		}
+		if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+			lastErr = err
+			continue
+		}
+		unlocked++
+	}
+	if unlocked == 0 && lastErr != nil {
+		return fmt.Errorf("failed to unlock private key: %w", lastErr)
+	}
+	return nil
+}
+
+// Keys lists the keys in the keyring
+func (k *Keyring) Keys() []KeyInfo {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	keys := make([]KeyInfo, 0, len(k.entities))
+	for _, entity := range k.entities {
+		keys = append(keys, keyInfo(entity))
+	}
+	return keys
+}
+
+// Find returns the key matching identity. See find for the accepted forms.
+func (k *Keyring) Find(identity string) (KeyInfo, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	entity, err := k.find(identity)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	return keyInfo(entity), nil
+}
+
+// Remove deletes the key matching identity
+func (k *Keyring) Remove(identity string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entity, err := k.find(identity)
+	if err != nil {
+		return err
+	}
+	for i, e := range k.entities {
+		if e == entity {
+			k.entities = append(k.entities[:i], k.entities[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// ExportPublic writes the armored public key matching identity
+func (k *Keyring) ExportPublic(w io.Writer, identity string) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	entity, err := k.find(identity)
+	if err != nil {
+		return err
+	}
+
+	aw, err := armor.Encode(w, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return err
+	}
+	if err := entity.Serialize(aw); err != nil {
+		return fmt.Errorf("failed to export public key: %w", err)
+	}
+	return aw.Close()
+}
+
+// ExportPrivate writes the armored private key matching identity, protected
+// with passphrase unless it is empty. The key must be unlocked.
+func (k *Keyring) ExportPrivate(w io.Writer, identity string, passphrase []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entity, err := k.find(identity)
+	if err != nil {
+		return err
+	}
+	if entity.PrivateKey == nil {
+		return fmt.Errorf("%w: no private key for %q", ErrKeyNotFound, identity)
+	}
+	if entity.PrivateKey.Encrypted {
+		return fmt.Errorf("private key for %q is locked", identity)
+	}
+
+	aw, err := armor.Encode(w, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return err
+	}
+	if len(passphrase) > 0 {
+		// Protect the serialized copy, then unlock the key again for use
+		if err := entity.EncryptPrivateKeys(passphrase, nil); err != nil {
+			return fmt.Errorf("failed to protect private key: %w", err)
+		}
+		defer entity.DecryptPrivateKeys(passphrase)
+	}
+	if err := entity.SerializePrivateWithoutSigning(aw, nil); err != nil {
+		return fmt.Errorf("failed to export private key: %w", err)
+	}
+	return aw.Close()
+}
+
+// find returns the single key matching identity, given as a fingerprint, a
+// 16 or 8 character key ID (optionally prefixed with "0x"), an email address
+// or a full user ID such as "Acme Bank <pgp@acme.example>". Matching is
+// case-insensitive. k.mu must be held.
+func (k *Keyring) find(identity string) (*openpgp.Entity, error) {
+	want := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(identity), "0x"))
+	if want == "" {
+		return nil, fmt.Errorf("%w: empty identity", ErrKeyNotFound)
+	}
+
+	var matches []*openpgp.Entity
+	for _, entity := range k.entities {
+		if matchesIdentity(entity, want) {
+			matches = append(matches, entity)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, identity)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("identity %q matches %d keys, use a fingerprint", identity, len(matches))
+	}
+}
+
+func matchesIdentity(entity *openpgp.Entity, want string) bool {
+	fingerprint := hex.EncodeToString(entity.PrimaryKey.Fingerprint)
+	if want == fingerprint || (len(want) == 16 || len(want) == 8) && strings.HasSuffix(fingerprint, want) {
+		return true
+	}
+	for name, id := range entity.Identities {
+		if strings.ToLower(name) == want || strings.ToLower(id.UserId.Email) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// entitiesFor returns the keys for each identity; k.mu must be held
+func (k *Keyring) entitiesFor(identities []string) ([]*openpgp.Entity, error) {
+	entities := make([]*openpgp.Entity, 0, len(identities))
+	for _, identity := range identities {
+		entity, err := k.find(identity)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// signer returns the unlocked private key for identity; k.mu must be held
+func (k *Keyring) signer(identity string) (*openpgp.Entity, error) {
+	entity, err := k.find(identity)
+	if err != nil {
+		return nil, err
+	}
+	if entity.PrivateKey == nil {
+		return nil, fmt.Errorf("%w: no private key for %q", ErrKeyNotFound, identity)
+	}
+	if entity.PrivateKey.Encrypted {
+		return nil, fmt.Errorf("private key for %q is locked, call Unlock first", identity)
+	}
+	return entity, nil
+}
+
+// keyInfo describes entity
+func keyInfo(entity *openpgp.Entity) KeyInfo {
+	info := KeyInfo{
+		Fingerprint: strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)),
+		KeyID:       entity.PrimaryKey.KeyIdString(),
+		HasPrivate:  entity.PrivateKey != nil,
+		Locked:      entity.PrivateKey != nil && entity.PrivateKey.Encrypted,
+		Created:     entity.PrimaryKey.CreationTime,
+	}
+	for name := range entity.Identities {
+		info.Identities = append(info.Identities, name)
+	}
+	sort.Strings(info.Identities)
+	if sig, _ := entity.PrimarySelfSignature(); sig != nil && sig.KeyLifetimeSecs != nil && *sig.KeyLifetimeSecs > 0 {
+		info.Expires = info.Created.Add(time.Duration(*sig.KeyLifetimeSecs) * time.Second)
+	}
+	return info
+}
+
+// dearmor returns the binary content of r, decoding ASCII armor when present
+func dearmor(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(64)
+	if !bytes.HasPrefix(bytes.TrimLeft(head, " \t\r\n"), []byte("-----BEGIN PGP")) {
+		return br, nil
+	}
+	block, err := armor.Decode(br)
+	if err != nil {
+		return nil, fmt.Errorf("invalid armor: %w", err)
+	}
+	return block.Body, nil
+}
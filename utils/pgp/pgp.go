@@ -0,0 +1,301 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package pgp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// ErrKeyNotFound is returned when no key matches an identity
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrSignature is returned for invalid, unknown or missing signatures
+var ErrSignature = errors.New("signature verification failed")
+
+// Extensions added by EncryptFile and SignFile
+const (
+	Ext      = ".pgp"
+	ArmorExt = ".asc"
+)
+
+// EncryptOptions configures Encrypt
+type EncryptOptions struct {
+	// Recipients are the identities the message is encrypted to
+	Recipients []string
+	// Signer, when set, signs the message with that unlocked private key
+	Signer string
+	// Armor writes ASCII-armored output instead of binary
+	Armor bool
+	// Compress compresses the data with ZLIB before encryption
+	Compress bool
+	// FileName is stored in the message; EncryptFile uses the source name
+	FileName string
+}
+
+// DecryptOptions configures Decrypt
+type DecryptOptions struct {
+	// RequireSignature rejects messages that are not signed by a key in the
+	// keyring. Without it, a signature by an unknown key is reported in
+	// Result.Signed but not verified.
+	RequireSignature bool
+}
+
+// Result describes a decrypted message or verified signature
+type Result struct {
+	// FileName is the name stored by the sender, if any
+	FileName string
+	Signed   bool
+	// Signer is the key that made a verified signature, nil when the message
+	// is unsigned or the signing key is not in the keyring
+	Signer *KeyInfo
+}
+
+// Encrypt encrypts src to the recipients in opts and writes the message to dst
+func (k *Keyring) Encrypt(dst io.Writer, src io.Reader, opts *EncryptOptions) error {
+	if opts == nil || len(opts.Recipients) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	recipients, err := k.entitiesFor(opts.Recipients)
+	if err != nil {
+		return err
+	}
+	for i, recipient := range recipients {
+		if _, ok := recipient.EncryptionKey(time.Now()); !ok {
+			return fmt.Errorf("key for %q has no valid encryption key (expired or revoked)", opts.Recipients[i])
+		}
+	}
+	var signer *openpgp.Entity
+	if opts.Signer != "" {
+		if signer, err = k.signer(opts.Signer); err != nil {
+			return err
+		}
+	}
+
+	config := &packet.Config{}
+	if opts.Compress {
+		config.DefaultCompressionAlgo = packet.CompressionZLIB
+	}
+
+	out := dst
+	var aw io.WriteCloser
+	if opts.Armor {
+		if aw, err = armor.Encode(dst, "PGP MESSAGE", nil); err != nil {
+			return err
+		}
+		out = aw
+	}
+
+	plaintext, err := openpgp.Encrypt(out, recipients, signer, &openpgp.FileHints{IsBinary: true, FileName: opts.FileName}, config)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt: %w", err)
+	}
+	if _, err := io.Copy(plaintext, src); err != nil {
+		return err
+	}
+	if err := plaintext.Close(); err != nil {
+		return err
+	}
+	if aw != nil {
+		return aw.Close()
+	}
+	return nil
+}
+
+// Decrypt decrypts the armored or binary message in src to dst with a private
+// key from the keyring, verifying the signature when the message is signed.
+// The plaintext is written before the signature can be checked, so dst must be
+// discarded when an error is returned; DecryptFile does this.
+func (k *Keyring) Decrypt(dst io.Writer, src io.Reader, opts *DecryptOptions) (*Result, error) {
+	body, err := dearmor(src)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	md, err := openpgp.ReadMessage(body, k.entities, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	if _, err := io.Copy(dst, md.UnverifiedBody); err != nil {
+		if md.IsSigned && md.SignatureError != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSignature, md.SignatureError)
+		}
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	result := &Result{Signed: md.IsSigned}
+	if md.LiteralData != nil {
+		result.FileName = md.LiteralData.FileName
+	}
+	switch {
+	case md.IsSigned && md.SignedBy != nil:
+		if md.SignatureError != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSignature, md.SignatureError)
+		}
+		info := keyInfo(md.SignedBy.Entity)
+		result.Signer = &info
+	case opts != nil && opts.RequireSignature && md.IsSigned:
+		return nil, fmt.Errorf("%w: signed by unknown key %016X", ErrSignature, md.SignedByKeyId)
+	case opts != nil && opts.RequireSignature:
+		return nil, fmt.Errorf("%w: message is not signed", ErrSignature)
+	}
+	return result, nil
+}
+
+// Sign writes a detached signature of src made with signer's private key
+func (k *Keyring) Sign(dst io.Writer, src io.Reader, signer string, armored bool) error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	entity, err := k.signer(signer)
+	if err != nil {
+		return err
+	}
+	if armored {
+		err = openpgp.ArmoredDetachSign(dst, entity, src, nil)
+	} else {
+		err = openpgp.DetachSign(dst, entity, src, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sign: %w", err)
+	}
+	return nil
+}
+
+// Verify checks an armored or binary detached signature of data against the
+// keys in the keyring and returns the signer
+func (k *Keyring) Verify(data, signature io.Reader) (*KeyInfo, error) {
+	sig, err := dearmor(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	signer, err := openpgp.CheckDetachedSignature(k.entities, data, sig, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	info := keyInfo(signer)
+	return &info, nil
+}
+
+// EncryptFile encrypts src into dst. An empty dst appends Ext, or ArmorExt
+// for armored output, to src. The original file is kept.
+func (k *Keyring) EncryptFile(src, dst string, opts *EncryptOptions) (string, error) {
+	var o EncryptOptions
+	if opts != nil {
+		o = *opts
+	}
+	if dst == "" {
+		dst = src + Ext
+		if o.Armor {
+			dst = src + ArmorExt
+		}
+	}
+	if o.FileName == "" {
+		o.FileName = filepath.Base(src)
+	}
+	return dst, transformFile(src, dst, "encrypt", func(out io.Writer, in io.Reader) error {
+		return k.Encrypt(out, in, &o)
+	})
+}
+
+// DecryptFile decrypts src into dst. An empty dst strips a .pgp, .gpg or .asc
+// extension from src. The destination is removed if decryption or signature
+// verification fails.
+func (k *Keyring) DecryptFile(src, dst string, opts *DecryptOptions) (string, *Result, error) {
+	if dst == "" {
+		dst = src
+		for _, ext := range []string{Ext, ".gpg", ArmorExt} {
+			if strings.HasSuffix(strings.ToLower(src), ext) {
+				dst = src[:len(src)-len(ext)]
+				break
+			}
+		}
+	}
+	var result *Result
+	err := transformFile(src, dst, "decrypt", func(out io.Writer, in io.Reader) error {
+		var err error
+		result, err = k.Decrypt(out, in, opts)
+		return err
+	})
+	return dst, result, err
+}
+
+// SignFile writes an armored detached signature of path to path + ArmorExt
+// and returns the signature path
+func (k *Keyring) SignFile(path, signer string) (string, error) {
+	sigPath := path + ArmorExt
+	return sigPath, transformFile(path, sigPath, "sign", func(out io.Writer, in io.Reader) error {
+		return k.Sign(out, in, signer, true)
+	})
+}
+
+// VerifyFile checks the detached signature in sigPath for path. An empty
+// sigPath uses path + ArmorExt, falling back to path + ".sig".
+func (k *Keyring) VerifyFile(path, sigPath string) (*KeyInfo, error) {
+	if sigPath == "" {
+		sigPath = path + ArmorExt
+		if _, err := os.Stat(sigPath); err != nil {
+			sigPath = path + ".sig"
+		}
+	}
+
+	data, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer data.Close()
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature %s: %w", sigPath, err)
+	}
+
+	signer, err := k.Verify(bufio.NewReader(data), bytes.NewReader(sig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify %s: %w", path, err)
+	}
+	return signer, nil
+}
+
+// transformFile streams src through fn into dst, removing dst on failure
+func transformFile(src, dst, operation string, fn func(out io.Writer, in io.Reader) error) error {
+	if dst == src {
+		return fmt.Errorf("destination must differ from source %s", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", dst, err)
+	}
+	err = fn(out, bufio.NewReader(in))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to %s %s: %w", operation, src, err)
+	}
+	return nil
+}
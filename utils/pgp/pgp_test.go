@@ -0,0 +1,233 @@
+package pgp
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newParties returns our keyring, holding our private key and the bank's
+// public key, and the bank's keyring, holding the reverse
+func newParties(t *testing.T) (ours, bank *Keyring) {
+	t.Helper()
+	ours, bank = NewKeyring(), NewKeyring()
+	if _, err := ours.Generate("Acme Deliveries", "pgp@acme.example", nil); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, err := bank.Generate("First Bank", "files@bank.example", nil); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	var pub bytes.Buffer
+	if err := bank.ExportPublic(&pub, "files@bank.example"); err != nil {
+		t.Fatalf("ExportPublic failed: %v", err)
+	}
+	if err := ours.Import(&pub); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	pub.Reset()
+	ours.ExportPublic(&pub, "pgp@acme.example")
+	if err := bank.Import(&pub); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	return ours, bank
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ours, bank := newParties(t)
+	plaintext := strings.Repeat("id,amount\n1,100.00\n", 100)
+
+	for _, armored := range []bool{false, true} {
+		var msg bytes.Buffer
+		err := ours.Encrypt(&msg, strings.NewReader(plaintext), &EncryptOptions{
+			Recipients: []string{"files@bank.example"},
+			Signer:     "pgp@acme.example",
+			Armor:      armored,
+			Compress:   true,
+			FileName:   "payments.csv",
+		})
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		if armored != strings.HasPrefix(msg.String(), "-----BEGIN PGP MESSAGE-----") {
+			t.Errorf("armor = %v, got %.30q", armored, msg.String())
+		}
+
+		var out bytes.Buffer
+		result, err := bank.Decrypt(&out, &msg, &DecryptOptions{RequireSignature: true})
+		if err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		if out.String() != plaintext {
+			t.Error("decrypted content differs")
+		}
+		if !result.Signed || result.Signer == nil || result.Signer.Identities[0] != "Acme Deliveries <pgp@acme.example>" || result.FileName != "payments.csv" {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	// We cannot read what we encrypted to the bank
+	var msg bytes.Buffer
+	ours.Encrypt(&msg, strings.NewReader("secret"), &EncryptOptions{Recipients: []string{"files@bank.example"}})
+	if _, err := ours.Decrypt(&bytes.Buffer{}, bytes.NewReader(msg.Bytes()), nil); err == nil {
+		t.Error("expected error decrypting without the private key")
+	}
+	// Unsigned messages are rejected when a signature is required
+	if _, err := bank.Decrypt(&bytes.Buffer{}, bytes.NewReader(msg.Bytes()), &DecryptOptions{RequireSignature: true}); !errors.Is(err, ErrSignature) {
+		t.Errorf("expected ErrSignature for unsigned message, got %v", err)
+	}
+	if result, err := bank.Decrypt(&bytes.Buffer{}, bytes.NewReader(msg.Bytes()), nil); err != nil || result.Signed {
+		t.Errorf("unexpected result %+v, %v", result, err)
+	}
+
+	if err := ours.Encrypt(&msg, strings.NewReader("x"), &EncryptOptions{Recipients: []string{"nobody@example.com"}}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := ours.Encrypt(&msg, strings.NewReader("x"), nil); err == nil {
+		t.Error("expected error without recipients")
+	}
+	if err := ours.Encrypt(&msg, strings.NewReader("x"), &EncryptOptions{Recipients: []string{"files@bank.example"}, Signer: "files@bank.example"}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound signing without a private key, got %v", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	ours, bank := newParties(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "payments.csv")
+	os.WriteFile(src, []byte("id,amount\n1,100.00\n"), 0644)
+
+	encrypted, err := ours.EncryptFile(src, "", &EncryptOptions{Recipients: []string{"files@bank.example"}, Signer: "pgp@acme.example"})
+	if err != nil {
+		t.Fatalf("EncryptFile failed: %v", err)
+	}
+	if encrypted != src+".pgp" {
+		t.Errorf("EncryptFile wrote %s", encrypted)
+	}
+
+	os.Remove(src)
+	decrypted, result, err := bank.DecryptFile(encrypted, "", &DecryptOptions{RequireSignature: true})
+	if err != nil {
+		t.Fatalf("DecryptFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(decrypted); decrypted != src || string(data) != "id,amount\n1,100.00\n" || result.FileName != "payments.csv" {
+		t.Errorf("DecryptFile wrote %s = %q, result %+v", decrypted, data, result)
+	}
+
+	// A corrupted message leaves no output behind
+	data, _ := os.ReadFile(encrypted)
+	data[len(data)-10] ^= 0xff
+	corrupted := filepath.Join(dir, "corrupted.csv.gpg")
+	os.WriteFile(corrupted, data, 0644)
+	if _, _, err := bank.DecryptFile(corrupted, "", nil); err == nil {
+		t.Error("expected error for corrupted message")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "corrupted.csv")); !os.IsNotExist(err) {
+		t.Error("expected output of failed decryption to be removed")
+	}
+
+	// Detached signatures
+	sigPath, err := ours.SignFile(src, "pgp@acme.example")
+	if err != nil {
+		t.Fatalf("SignFile failed: %v", err)
+	}
+	signer, err := bank.VerifyFile(src, "")
+	if err != nil {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	if sigPath != src+".asc" || signer.Identities[0] != "Acme Deliveries <pgp@acme.example>" {
+		t.Errorf("unexpected signature %s by %+v", sigPath, signer)
+	}
+	os.WriteFile(src, []byte("id,amount\n1,999.00\n"), 0644)
+	if _, err := bank.VerifyFile(src, sigPath); !errors.Is(err, ErrSignature) {
+		t.Errorf("expected ErrSignature for tampered file, got %v", err)
+	}
+
+	var binarySig bytes.Buffer
+	if err := ours.Sign(&binarySig, strings.NewReader("data"), "pgp@acme.example", false); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := bank.Verify(strings.NewReader("data"), &binarySig); err != nil {
+		t.Errorf("Verify of binary signature failed: %v", err)
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	keyring := NewKeyring()
+	info, err := keyring.Generate("Acme Deliveries", "pgp@acme.example", &GenerateOptions{Lifetime: 365 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(info.Fingerprint) != 40 || len(info.KeyID) != 16 || !info.HasPrivate || info.Locked {
+		t.Errorf("unexpected key %+v", info)
+	}
+	if until := time.Until(info.Expires); until < 364*24*time.Hour || until > 366*24*time.Hour {
+		t.Errorf("unexpected expiry %v", info.Expires)
+	}
+
+	for _, identity := range []string{info.Fingerprint, strings.ToLower(info.Fingerprint), info.KeyID, "0x" + info.KeyID[8:], "PGP@acme.example", "Acme Deliveries <pgp@acme.example>"} {
+		if found, err := keyring.Find(identity); err != nil || found.Fingerprint != info.Fingerprint {
+			t.Errorf("Find(%q) = %+v, %v", identity, found, err)
+		}
+	}
+
+	// Export the private key with a passphrase and load it elsewhere
+	dir := t.TempDir()
+	var priv bytes.Buffer
+	if err := keyring.ExportPrivate(&priv, "pgp@acme.example", []byte("s3cret")); err != nil {
+		t.Fatalf("ExportPrivate failed: %v", err)
+	}
+	keyPath := filepath.Join(dir, "acme.key.asc")
+	os.WriteFile(keyPath, priv.Bytes(), 0600)
+	if _, err := keyring.Find("pgp@acme.example"); err != nil {
+		t.Fatalf("key removed by export: %v", err)
+	}
+	if keys := keyring.Keys(); keys[0].Locked {
+		t.Error("expected exported key to stay unlocked")
+	}
+
+	loaded, err := LoadKeyring(keyPath)
+	if err != nil {
+		t.Fatalf("LoadKeyring failed: %v", err)
+	}
+	if keys := loaded.Keys(); len(keys) != 1 || !keys[0].Locked {
+		t.Fatalf("expected one locked key, got %+v", keys)
+	}
+	if err := loaded.Sign(&bytes.Buffer{}, strings.NewReader("x"), "pgp@acme.example", true); err == nil {
+		t.Error("expected error signing with a locked key")
+	}
+	if err := loaded.Unlock("", []byte("wrong")); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+	if err := loaded.Unlock("pgp@acme.example", []byte("s3cret")); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := loaded.Sign(&bytes.Buffer{}, strings.NewReader("x"), "pgp@acme.example", true); err != nil {
+		t.Errorf("Sign after Unlock failed: %v", err)
+	}
+
+	// Importing the public key again keeps the private key
+	var pub bytes.Buffer
+	keyring.ExportPublic(&pub, info.KeyID)
+	loaded.Import(&pub)
+	if keys := loaded.Keys(); len(keys) != 1 || !keys[0].HasPrivate {
+		t.Errorf("expected private key to be kept, got %+v", keys)
+	}
+
+	if err := loaded.Remove(info.Fingerprint); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := loaded.Find(info.Fingerprint); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound after Remove, got %v", err)
+	}
+	if _, err := LoadKeyring(filepath.Join(dir, "missing.asc")); err == nil {
+		t.Error("expected error for missing key file")
+	}
+	if err := keyring.Import(strings.NewReader("not a key")); err == nil {
+		t.Error("expected error for invalid key data")
+	}
+}
@@ -0,0 +1,327 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/retry"
+	"github.com/romisugianto/go-utils/utils/watcher"
+)
+
+// Status is the outcome of a run or step
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusResumed marks a step that succeeded in an earlier attempt of the run
+	StatusResumed Status = "resumed"
+)
+
+// StepFunc processes the files produced by the previous step (or the run's
+// inputs) and returns the files passed to the next step
+type StepFunc func(ctx context.Context, files []string) ([]string, error)
+
+// Step is a named stage of a pipeline
+type Step struct {
+	Name string
+	Run  StepFunc
+	// Retry overrides the pipeline's retry options for this step
+	Retry *retry.Options
+}
+
+// Options configures a Pipeline
+type Options struct {
+	// StateDir stores one JSON report per run. When set, a failed run started
+	// again with the same run ID skips the steps that already succeeded.
+	StateDir string
+	// Retry applies to every step without its own Retry (default a single attempt)
+	Retry *retry.Options
+	// OnFinish is called with the final report after every run that executes,
+	// whether it succeeded or failed, e.g. to email a run summary
+	OnFinish func(ctx context.Context, report *Report)
+}
+
+// StepReport records the outcome of one step
+type StepReport struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Attempts int           `json:"attempts"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Inputs   []string      `json:"inputs"`
+	Outputs  []string      `json:"outputs"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Report records the outcome of a run
+type Report struct {
+	Pipeline string        `json:"pipeline"`
+	RunID    string        `json:"run_id"`
+	Status   Status        `json:"status"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Inputs   []string      `json:"inputs"`
+	Outputs  []string      `json:"outputs"`
+	Steps    []StepReport  `json:"steps"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Pipeline runs an ordered list of steps over a set of files
+type Pipeline struct {
+	name   string
+	logger *logger.Logger
+	opts   Options
+	steps  []Step
+}
+
+// New creates a pipeline. A nil opts uses the defaults.
+func New(name string, log *logger.Logger, opts *Options) (*Pipeline, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("pipeline name cannot be empty")
+	}
+	p := &Pipeline{name: name, logger: log}
+	if opts != nil {
+		p.opts = *opts
+	}
+	return p, nil
+}
+
+// Add appends steps to the pipeline. Step names must be unique.
+func (p *Pipeline) Add(steps ...Step) error {
+	for _, step := range steps {
+		if step.Name == "" {
+			return fmt.Errorf("step name cannot be empty")
+		}
+		if step.Run == nil {
+			return fmt.Errorf("failed to add step %s: run function cannot be nil", step.Name)
+		}
+		for _, existing := range p.steps {
+			if existing.Name == step.Name {
+				return fmt.Errorf("failed to add step %s: a step with this name already exists", step.Name)
+			}
+		}
+		p.steps = append(p.steps, step)
+	}
+	return nil
+}
+
+// Run executes the steps in order, starting with files. The report is saved to
+// StateDir after every step; if a previous run with the same runID failed, the
+// steps it completed are skipped and their recorded outputs are reused.
+func (p *Pipeline) Run(ctx context.Context, runID string, files []string) (*Report, error) {
+	if len(p.steps) == 0 {
+		return nil, fmt.Errorf("pipeline %s has no steps", p.name)
+	}
+	runID = sanitizeRunID(runID)
+	if runID == "" {
+		return nil, fmt.Errorf("run ID cannot be empty")
+	}
+
+	previous, err := p.loadReport(runID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Status == StatusSucceeded {
+		p.logger.Info("Pipeline %s run %s already succeeded, skipping", p.name, runID)
+		return previous, nil
+	}
+
+	report := &Report{
+		Pipeline: p.name,
+		RunID:    runID,
+		Started:  time.Now(),
+		Inputs:   files,
+	}
+	p.logger.Info("Pipeline %s run %s started with %d files", p.name, runID, len(files))
+
+	current := files
+	resuming := previous != nil
+	for i, step := range p.steps {
+		// Only the leading steps that completed before can be skipped
+		if resuming {
+			if prev, ok := previous.completed(i, step.Name); ok {
+				prev.Status = StatusResumed
+				report.Steps = append(report.Steps, prev)
+				current = prev.Outputs
+				p.logger.Info("Step %s already completed, resuming with %d files", step.Name, len(current))
+				continue
+			}
+			resuming = false
+		}
+
+		stepReport, err := p.runStep(ctx, step, current)
+		report.Steps = append(report.Steps, stepReport)
+		if err != nil {
+			report.Error = fmt.Sprintf("step %s: %v", step.Name, err)
+			p.finish(ctx, report, StatusFailed)
+			return report, fmt.Errorf("pipeline %s failed at step %s: %w", p.name, step.Name, err)
+		}
+		current = stepReport.Outputs
+		if err := p.saveReport(report); err != nil {
+			p.logger.Warning("Failed to save pipeline state: %v", err)
+		}
+	}
+
+	report.Outputs = current
+	p.finish(ctx, report, StatusSucceeded)
+	return report, nil
+}
+
+// Handler returns a watcher.Handler that runs the pipeline for each stable
+// file. The run ID combines the file name and modification time, so a retried
+// delivery of the same file resumes while a new version starts a new run.
+func (p *Pipeline) Handler() watcher.Handler {
+	return func(ctx context.Context, path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		runID := fmt.Sprintf("%s-%d", filepath.Base(path), info.ModTime().Unix())
+		_, err = p.Run(ctx, runID, []string{path})
+		return err
+	}
+}
+
+// runStep runs a step with retries and records its outcome
+func (p *Pipeline) runStep(ctx context.Context, step Step, files []string) (StepReport, error) {
+	report := StepReport{Name: step.Name, Started: time.Now(), Inputs: files}
+
+	opts := retry.Options{MaxAttempts: 1}
+	if step.Retry != nil {
+		opts = *step.Retry
+	} else if p.opts.Retry != nil {
+		opts = *p.opts.Retry
+	}
+	onRetry := opts.OnRetry
+	opts.OnRetry = func(attempt int, err error, delay time.Duration) {
+		p.logger.Warning("Step %s attempt %d failed, retrying in %s: %v", step.Name, attempt, delay.Round(time.Millisecond), err)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+
+	p.logger.Info("Step %s started with %d files", step.Name, len(files))
+	err := retry.Do(ctx, func() error {
+		report.Attempts++
+		outputs, err := step.Run(ctx, files)
+		if err != nil {
+			return err
+		}
+		report.Outputs = outputs
+		return nil
+	}, opts)
+
+	report.Duration = time.Since(report.Started)
+	if err != nil {
+		report.Status = StatusFailed
+		report.Error = err.Error()
+		p.logger.Error("Step %s failed after %d attempts in %s: %v", step.Name, report.Attempts, report.Duration.Round(time.Millisecond), err)
+		return report, err
+	}
+	report.Status = StatusSucceeded
+	p.logger.Info("Step %s completed in %s with %d files", step.Name, report.Duration.Round(time.Millisecond), len(report.Outputs))
+	return report, nil
+}
+
+// finish sets the final status, saves the report, logs a summary and calls OnFinish
+func (p *Pipeline) finish(ctx context.Context, report *Report, status Status) {
+	report.Status = status
+	report.Duration = time.Since(report.Started)
+	if err := p.saveReport(report); err != nil {
+		p.logger.Warning("Failed to save pipeline state: %v", err)
+	}
+
+	p.logger.Summary("Pipeline %s run %s %s in %.2f seconds", p.name, report.RunID, status, report.Duration.Seconds())
+	for _, step := range report.Steps {
+		p.logger.Summary("  - %s: %s (attempts: %d, files: %d)", step.Name, step.Status, step.Attempts, len(step.Outputs))
+	}
+
+	if p.opts.OnFinish != nil {
+		p.opts.OnFinish(ctx, report)
+	}
+}
+
+// reportPath returns the state file for a run, or "" without StateDir
+func (p *Pipeline) reportPath(runID string) string {
+	if p.opts.StateDir == "" {
+		return ""
+	}
+	return filepath.Join(p.opts.StateDir, sanitizeRunID(p.name), runID+".json")
+}
+
+// loadReport reads the saved report of a run, returning nil if there is none
+func (p *Pipeline) loadReport(runID string) (*Report, error) {
+	path := p.reportPath(runID)
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline state %s: %w", path, err)
+	}
+	var report Report
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline state %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// saveReport atomically writes the report to the run's state file
+func (p *Pipeline) saveReport(report *Report) error {
+	path := p.reportPath(report.RunID)
+	if path == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, content)
+}
+
+// LoadReport reads the saved report of a run from stateDir
+func LoadReport(stateDir, pipelineName, runID string) (*Report, error) {
+	p := &Pipeline{name: pipelineName, opts: Options{StateDir: stateDir}}
+	report, err := p.loadReport(sanitizeRunID(runID))
+	if err == nil && report == nil {
+		return nil, fmt.Errorf("no report for pipeline %s run %s: %w", pipelineName, runID, os.ErrNotExist)
+	}
+	return report, err
+}
+
+// completed returns the recorded step at index i if it succeeded under the same name
+func (r *Report) completed(i int, name string) (StepReport, bool) {
+	if r == nil || i >= len(r.Steps) {
+		return StepReport{}, false
+	}
+	step := r.Steps[i]
+	if step.Name != name || (step.Status != StatusSucceeded && step.Status != StatusResumed) {
+		return StepReport{}, false
+	}
+	return step, true
+}
+
+// sanitizeRunID makes an ID safe to use as a file name
+func sanitizeRunID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(id))
+}
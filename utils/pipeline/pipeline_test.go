@@ -0,0 +1,214 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/retry"
+	"github.com/romisugianto/go-utils/utils/splitter"
+)
+
+func newTestLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("pipeline_test")
+	t.Cleanup(func() { testLogger.Close() })
+	return testLogger
+}
+
+// appendStep returns a step that appends suffix to every file name
+func appendStep(name, suffix string, calls *int) Step {
+	return Step{Name: name, Run: func(ctx context.Context, files []string) ([]string, error) {
+		*calls++
+		outputs := make([]string, len(files))
+		for i, file := range files {
+			outputs[i] = file + suffix
+		}
+		return outputs, nil
+	}}
+}
+
+func TestNewAndAddValidation(t *testing.T) {
+	testLogger := newTestLogger(t)
+	if _, err := New("nightly", nil, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+	if _, err := New("", testLogger, nil); err == nil {
+		t.Error("expected error for empty name")
+	}
+
+	p, _ := New("nightly", testLogger, nil)
+	if _, err := p.Run(context.Background(), "run", nil); err == nil {
+		t.Error("expected error for pipeline without steps")
+	}
+	var calls int
+	if err := p.Add(appendStep("a", ".a", &calls)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := p.Add(appendStep("a", ".b", &calls)); err == nil {
+		t.Error("expected error for duplicate step name")
+	}
+	if err := p.Add(Step{Name: "nil"}); err == nil {
+		t.Error("expected error for nil run function")
+	}
+	if _, err := p.Run(context.Background(), " ", nil); err == nil {
+		t.Error("expected error for empty run ID")
+	}
+}
+
+func TestRunChainsSteps(t *testing.T) {
+	var finished []*Report
+	p, _ := New("chain", newTestLogger(t), &Options{
+		OnFinish: func(ctx context.Context, report *Report) { finished = append(finished, report) },
+	})
+	var calls int
+	p.Add(appendStep("first", ".1", &calls), appendStep("second", ".2", &calls))
+
+	report, err := p.Run(context.Background(), "run-1", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Status != StatusSucceeded {
+		t.Errorf("status = %s, expected %s", report.Status, StatusSucceeded)
+	}
+	if got := strings.Join(report.Outputs, ","); got != "a.1.2,b.1.2" {
+		t.Errorf("outputs = %s", got)
+	}
+	if len(report.Steps) != 2 || report.Steps[1].Attempts != 1 || report.Steps[1].Inputs[0] != "a.1" {
+		t.Errorf("unexpected step reports: %+v", report.Steps)
+	}
+	if len(finished) != 1 || finished[0] != report {
+		t.Errorf("expected OnFinish to receive the report once, got %d calls", len(finished))
+	}
+}
+
+func TestRunRetriesAndResumes(t *testing.T) {
+	stateDir := t.TempDir()
+	p, _ := New("resume", newTestLogger(t), &Options{
+		StateDir: stateDir,
+		Retry:    &retry.Options{MaxAttempts: 2, InitialDelay: time.Millisecond},
+	})
+
+	var firstCalls, lastCalls, flakyCalls int
+	failing := true
+	p.Add(
+		appendStep("first", ".1", &firstCalls),
+		Step{Name: "flaky", Run: func(ctx context.Context, files []string) ([]string, error) {
+			flakyCalls++
+			if failing {
+				return nil, errors.New("endpoint unavailable")
+			}
+			return files, nil
+		}},
+		appendStep("last", ".3", &lastCalls),
+	)
+
+	report, err := p.Run(context.Background(), "2024/01/15", []string{"in.csv"})
+	if err == nil {
+		t.Fatal("expected error from failing step")
+	}
+	if report.Status != StatusFailed || report.Steps[1].Attempts != 2 || flakyCalls != 2 {
+		t.Errorf("unexpected failed report: %+v (calls %d)", report, flakyCalls)
+	}
+	if lastCalls != 0 {
+		t.Error("steps after the failure must not run")
+	}
+
+	saved, err := LoadReport(stateDir, "resume", "2024/01/15")
+	if err != nil {
+		t.Fatalf("LoadReport failed: %v", err)
+	}
+	if saved.Status != StatusFailed || !strings.Contains(saved.Error, "endpoint unavailable") {
+		t.Errorf("unexpected saved report: %+v", saved)
+	}
+
+	// Re-running skips the completed step and reuses its outputs
+	failing = false
+	report, err = p.Run(context.Background(), "2024/01/15", []string{"in.csv"})
+	if err != nil {
+		t.Fatalf("resumed Run failed: %v", err)
+	}
+	if firstCalls != 1 {
+		t.Errorf("first step ran %d times, expected 1", firstCalls)
+	}
+	if report.Steps[0].Status != StatusResumed || report.Outputs[0] != "in.csv.1.3" {
+		t.Errorf("unexpected resumed report: %+v", report)
+	}
+
+	// A succeeded run is not repeated
+	if _, err := p.Run(context.Background(), "2024/01/15", []string{"in.csv"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if lastCalls != 1 {
+		t.Errorf("last step ran %d times, expected 1", lastCalls)
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	p, _ := New("cancel", newTestLogger(t), &Options{Retry: &retry.Options{MaxAttempts: retry.Unlimited, InitialDelay: time.Hour}})
+	p.Add(Step{Name: "fail", Run: func(ctx context.Context, files []string) ([]string, error) {
+		return nil, errors.New("boom")
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p.Run(ctx, "run", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+}
+
+func TestSplitAndCompressSteps(t *testing.T) {
+	dir := t.TempDir()
+	inbound := filepath.Join(dir, "inbound", "orders.csv")
+	os.MkdirAll(filepath.Dir(inbound), 0755)
+	var lines []string
+	for i := 0; i < 25; i++ {
+		lines = append(lines, fmt.Sprintf("%d,item", i))
+	}
+	os.WriteFile(inbound, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+
+	testLogger := newTestLogger(t)
+	s, _ := splitter.NewSplitter(testLogger)
+	p, _ := New("split-compress", testLogger, nil)
+	p.Add(
+		SplitStep(s, 10, filepath.Join(dir, "parts"), filepath.Join(dir, "processed")),
+		CompressStep(compress.Gzip, compress.DefaultCompression, true),
+	)
+
+	report, err := p.Run(context.Background(), "orders", []string{inbound})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Outputs) != 3 {
+		t.Fatalf("expected 3 compressed parts, got %v", report.Outputs)
+	}
+	for _, output := range report.Outputs {
+		if !strings.HasPrefix(filepath.Base(output), "orders_part") || !strings.HasSuffix(output, ".csv.gz") {
+			t.Errorf("unexpected output %s", output)
+		}
+		if _, err := os.Stat(output); err != nil {
+			t.Errorf("output missing: %v", err)
+		}
+		if _, err := os.Stat(strings.TrimSuffix(output, ".gz")); !os.IsNotExist(err) {
+			t.Errorf("expected uncompressed part to be removed")
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "processed", "orders.csv")); err != nil {
+		t.Errorf("expected original in processed directory: %v", err)
+	}
+
+	// Steps are safe to retry after they already moved or compressed files
+	if _, err := p.steps[0].Run(context.Background(), report.Steps[0].Inputs); err != nil {
+		t.Errorf("retried split failed: %v", err)
+	}
+	if _, err := p.steps[1].Run(context.Background(), report.Steps[1].Inputs); err != nil {
+		t.Errorf("retried compress failed: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/housekeeper"
+	"github.com/romisugianto/go-utils/utils/s3helper"
+	"github.com/romisugianto/go-utils/utils/splitter"
+)
+
+// SplitStep splits each file into parts of linesPerFile lines in outputDir and
+// moves the original to processedDir. It outputs the created parts.
+func SplitStep(s *splitter.Splitter, linesPerFile int, outputDir, processedDir string) Step {
+	return Step{
+		Name: "split",
+		Run: func(ctx context.Context, files []string) ([]string, error) {
+			var parts []string
+			for _, file := range files {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				// A retried split finds the original already moved
+				if _, err := os.Stat(file); os.IsNotExist(err) {
+					if _, err := os.Stat(filepath.Join(processedDir, filepath.Base(file))); err != nil {
+						return nil, fmt.Errorf("file %s not found", file)
+					}
+				} else if err := s.SplitFileByLines(file, linesPerFile, outputDir, processedDir); err != nil {
+					return nil, err
+				}
+
+				ext := filepath.Ext(file)
+				base := strings.TrimSuffix(filepath.Base(file), ext)
+				matches, err := filepath.Glob(filepath.Join(outputDir, escapeGlob(base)+"_part*"+escapeGlob(ext)))
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, matches...)
+			}
+			return parts, nil
+		},
+	}
+}
+
+// CompressStep compresses each file next to the original. With removeSource,
+// the original is deleted once compressed. It outputs the compressed files.
+func CompressStep(format compress.Format, level compress.Level, removeSource bool) Step {
+	return Step{
+		Name: "compress",
+		Run: func(ctx context.Context, files []string) ([]string, error) {
+			outputs := make([]string, 0, len(files))
+			for _, file := range files {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				dst := file + compress.Ext(format)
+				// Skip files compressed by an earlier attempt
+				if _, err := os.Stat(file); os.IsNotExist(err) {
+					if _, err := os.Stat(dst); err == nil {
+						outputs = append(outputs, dst)
+						continue
+					}
+				}
+				dst, err := compress.CompressFile(file, dst, format, level)
+				if err != nil {
+					return nil, err
+				}
+				if removeSource {
+					if err := os.Remove(file); err != nil {
+						return nil, fmt.Errorf("failed to remove %s: %w", file, err)
+					}
+				}
+				outputs = append(outputs, dst)
+			}
+			return outputs, nil
+		},
+	}
+}
+
+// UploadStep uploads each file to prefix/<file name> with s3helper.UploadBatch,
+// recording completed uploads in manifestPath so a retry only sends missing
+// files. It outputs its input files.
+func UploadStep(u *s3helper.S3Helper, prefix, manifestPath string) Step {
+	return Step{
+		Name: "upload",
+		Run: func(ctx context.Context, files []string) ([]string, error) {
+			items := make([]s3helper.BatchItem, 0, len(files))
+			for _, file := range files {
+				items = append(items, s3helper.BatchItem{
+					FilePath: file,
+					S3Path:   strings.TrimSuffix(prefix, "/") + "/" + filepath.Base(file),
+				})
+			}
+			if _, err := u.UploadBatch(items, manifestPath); err != nil {
+				return nil, err
+			}
+			return files, nil
+		},
+	}
+}
+
+// HousekeepStep removes files older than maxAgeDays from dir. It outputs its
+// input files.
+func HousekeepStep(h *housekeeper.Housekeeper, dir string, maxAgeDays int) Step {
+	return Step{
+		Name: "housekeep",
+		Run: func(ctx context.Context, files []string) ([]string, error) {
+			if err := h.HousekeepFilesByAge(dir, maxAgeDays); err != nil {
+				return nil, err
+			}
+			return files, nil
+		},
+	}
+}
+
+// escapeGlob escapes glob metacharacters in a literal path segment
+func escapeGlob(s string) string {
+	replacer := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,145 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Task is a unit of work run by the pool
+type Task func(ctx context.Context) error
+
+// Options configures a Pool
+type Options struct {
+	// FailFast cancels the pool's context after the first task error, so
+	// pending Submit calls fail and running tasks can stop early
+	FailFast bool
+}
+
+// PanicError is recorded when a task panics
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", p.Value)
+}
+
+// Pool runs tasks on at most a fixed number of goroutines and collects their errors
+type Pool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   Options
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// New creates a pool running up to workers tasks concurrently. Tasks receive a
+// context derived from ctx that is cancelled when ctx is done, on the first error
+// with FailFast, or after Wait returns. A nil opts uses the defaults.
+func New(ctx context.Context, workers int, opts *Options) (*Pool, error) {
+	if workers <= 0 {
+		return nil, fmt.Errorf("workers must be > 0, got %d", workers)
+	}
+
+	p := &Pool{slots: make(chan struct{}, workers)}
+	if opts != nil {
+		p.opts = *opts
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return p, nil
+}
+
+// Submit runs task when a worker is free, blocking until one is. It returns
+// the context error without running the task if the pool is cancelled first.
+func (p *Pool) Submit(task Task) error {
+	select {
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	case p.slots <- struct{}{}:
+	}
+
+	// The slot may have been acquired in a race with cancellation
+	if err := p.ctx.Err(); err != nil {
+		<-p.slots
+		return err
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.slots }()
+
+		if err := p.run(task); err != nil {
+			p.mu.Lock()
+			p.errs = append(p.errs, err)
+			p.mu.Unlock()
+			if p.opts.FailFast {
+				p.cancel()
+			}
+		}
+	}()
+	return nil
+}
+
+// run executes a task, converting a panic into a *PanicError
+func (p *Pool) run(task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return task(p.ctx)
+}
+
+// Wait blocks until all submitted tasks finish and returns their errors joined.
+// The pool cannot be reused afterwards.
+func (p *Pool) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	return errors.Join(p.Errors()...)
+}
+
+// Errors returns the errors recorded so far, in completion order
+func (p *Pool) Errors() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]error(nil), p.errs...)
+}
+
+// Context returns the context passed to tasks
+func (p *Pool) Context() context.Context {
+	return p.ctx
+}
+
+// ForEach runs fn for every item with at most workers running concurrently and
+// returns the joined errors. With failFast, remaining items are skipped after
+// the first error.
+func ForEach[T any](ctx context.Context, workers int, items []T, fn func(ctx context.Context, item T) error, failFast ...bool) error {
+	p, err := New(ctx, workers, &Options{FailFast: len(failFast) > 0 && failFast[0]})
+	if err != nil {
+		return err
+	}
+
+	var submitErr error
+	for _, item := range items {
+		if err := p.Submit(func(ctx context.Context) error { return fn(ctx, item) }); err != nil {
+			submitErr = err
+			break
+		}
+	}
+
+	err = p.Wait()
+	// A FailFast cancellation is already explained by the task error
+	if submitErr != nil && err == nil {
+		return submitErr
+	}
+	return err
+}
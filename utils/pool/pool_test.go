@@ -0,0 +1,144 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	p, err := New(context.Background(), 3, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	var running, maxRunning, done atomic.Int32
+	for i := 0; i < 20; i++ {
+		p.Submit(func(ctx context.Context) error {
+			n := running.Add(1)
+			for {
+				current := maxRunning.Load()
+				if n <= current || maxRunning.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+			return nil
+		})
+	}
+
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if done.Load() != 20 {
+		t.Errorf("completed %d tasks, expected 20", done.Load())
+	}
+	if maxRunning.Load() > 3 {
+		t.Errorf("max concurrent tasks = %d, expected at most 3", maxRunning.Load())
+	}
+}
+
+func TestPoolCollectsErrorsAndPanics(t *testing.T) {
+	p, _ := New(context.Background(), 2, nil)
+
+	p.Submit(func(ctx context.Context) error { return errors.New("upload failed") })
+	p.Submit(func(ctx context.Context) error { panic("boom") })
+	p.Submit(func(ctx context.Context) error { return nil })
+
+	err := p.Wait()
+	if err == nil {
+		t.Fatal("expected joined error")
+	}
+	if len(p.Errors()) != 2 {
+		t.Errorf("expected 2 errors, got %v", p.Errors())
+	}
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Errorf("expected PanicError with stack, got %v", err)
+	}
+}
+
+func TestPoolFailFast(t *testing.T) {
+	p, _ := New(context.Background(), 1, &Options{FailFast: true})
+
+	p.Submit(func(ctx context.Context) error { return errors.New("first failure") })
+
+	var ran atomic.Int32
+	var submitErr error
+	for i := 0; i < 10 && submitErr == nil; i++ {
+		submitErr = p.Submit(func(ctx context.Context) error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	if !errors.Is(submitErr, context.Canceled) {
+		t.Errorf("expected Submit to fail after cancellation, got %v", submitErr)
+	}
+	if err := p.Wait(); err == nil || err.Error() != "first failure" {
+		t.Errorf("Wait error = %v", err)
+	}
+	if ran.Load() == 10 {
+		t.Error("expected remaining tasks to be skipped")
+	}
+}
+
+func TestPoolContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p, _ := New(ctx, 1, nil)
+
+	started := make(chan struct{})
+	p.Submit(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	cancel()
+
+	if err := p.Submit(func(ctx context.Context) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled Submit, got %v", err)
+	}
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected running task to observe cancellation, got %v", err)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(context.Background(), 0, nil); err == nil {
+		t.Error("expected error for zero workers")
+	}
+}
+
+func TestForEach(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	var sum atomic.Int64
+	err := ForEach(context.Background(), 2, items, func(ctx context.Context, n int) error {
+		sum.Add(int64(n))
+		if n%2 == 0 {
+			return fmt.Errorf("item %d failed", n)
+		}
+		return nil
+	})
+
+	if sum.Load() != 15 {
+		t.Errorf("sum = %d, expected all items processed", sum.Load())
+	}
+	if err == nil || len(err.(interface{ Unwrap() []error }).Unwrap()) != 2 {
+		t.Errorf("expected 2 joined errors, got %v", err)
+	}
+
+	var processed atomic.Int32
+	err = ForEach(context.Background(), 1, make([]int, 100), func(ctx context.Context, n int) error {
+		processed.Add(1)
+		return errors.New("stop")
+	}, true)
+	if err == nil || processed.Load() >= 100 {
+		t.Errorf("fail fast processed %d items, err %v", processed.Load(), err)
+	}
+}
@@ -0,0 +1,383 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package procutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/internal/capture"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/retry"
+)
+
+// ExitClass classifies how a command ended
+type ExitClass string
+
+// Exit classes returned by Classify
+const (
+	ExitOK ExitClass = "ok"
+	// ExitFailed is a non-zero exit status
+	ExitFailed ExitClass = "failed"
+	// ExitSignaled is a process killed by a signal, e.g. by the OOM killer
+	ExitSignaled ExitClass = "signaled"
+	// ExitTimeout is a process stopped at Options.Timeout
+	ExitTimeout ExitClass = "timeout"
+	// ExitCanceled is a process stopped because the caller's context ended
+	ExitCanceled ExitClass = "canceled"
+	// ExitNotFound is a missing executable
+	ExitNotFound ExitClass = "not_found"
+	// ExitStartFailed is any other failure to start the process
+	ExitStartFailed ExitClass = "start_failed"
+)
+
+// Options configures a command
+type Options struct {
+	// Dir is the working directory (default the current one)
+	Dir string
+	// Env adds variables to the current environment, or replaces it with
+	// CleanEnv
+	Env      map[string]string
+	CleanEnv bool
+	// Stdin is sent to the command's standard input. With Retry it must be
+	// an io.Seeker so it can be rewound before each attempt.
+	Stdin io.Reader
+	// Stdout and Stderr receive a copy of the output, e.g. a file
+	Stdout io.Writer
+	Stderr io.Writer
+	// Quiet stops output lines from being logged
+	Quiet bool
+
+	// Timeout bounds each attempt (0 relies on the context only)
+	Timeout time.Duration
+	// KillGrace is how long a stopped process may take to exit after
+	// SIGTERM before it is killed (default 5s)
+	KillGrace time.Duration
+
+	// SuccessCodes are exit codes treated as success (default 0 only), e.g.
+	// 0 and 1 for tools using 1 for "nothing to do"
+	SuccessCodes []int
+	// Retry re-runs failed and timed out attempts; nil runs once. Missing
+	// executables and cancellations are never retried.
+	Retry *retry.Options
+	// RetryExitCodes limits retries of failed attempts to these exit codes,
+	// e.g. 75 (EX_TEMPFAIL)
+	RetryExitCodes []int
+
+	// Secrets are replaced by "***" wherever the command line is logged or
+	// reported, e.g. a passphrase passed as an argument
+	Secrets []string
+}
+
+// Result describes a finished command
+type Result struct {
+	// Command is the command line as logged, with secrets redacted
+	Command  string
+	ExitCode int
+	// Stdout and Stderr hold the last 64 KiB of each stream of the last attempt
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+	Attempts int
+}
+
+// ExitError is returned when a command ran but did not succeed
+type ExitError struct {
+	Command string
+	// Class is ExitFailed, ExitSignaled, ExitTimeout or ExitCanceled
+	Class ExitClass
+	// ExitCode is the exit status, or -1 when the process was killed
+	ExitCode int
+	// Stderr is the end of the standard error output
+	Stderr  string
+	Timeout time.Duration
+	err     error
+}
+
+func (e *ExitError) Error() string {
+	var msg string
+	switch e.Class {
+	case ExitTimeout:
+		msg = fmt.Sprintf("%s timed out after %s", e.Command, e.Timeout)
+	case ExitCanceled:
+		msg = fmt.Sprintf("%s was canceled", e.Command)
+	case ExitSignaled:
+		msg = fmt.Sprintf("%s was killed: %v", e.Command, e.err)
+	default:
+		msg = fmt.Sprintf("%s exited with status %d", e.Command, e.ExitCode)
+	}
+	if stderr := capture.LastLine(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Unwrap returns the underlying *exec.ExitError or context error
+func (e *ExitError) Unwrap() error {
+	return e.err
+}
+
+// Classify returns the class of an error returned by Run
+func Classify(err error) ExitClass {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exitErr):
+		return exitErr.Class
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return ExitNotFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ExitCanceled
+	default:
+		return ExitStartFailed
+	}
+}
+
+// ExitCode returns the exit status of an error returned by Run: 0 for nil
+// and -1 when the process did not exit normally or did not start
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode
+	default:
+		return -1
+	}
+}
+
+// Runner executes local programs with their output streamed to the logger
+type Runner struct {
+	logger *logger.Logger
+}
+
+// NewRunner creates a Runner logging to log
+func NewRunner(log *logger.Logger) (*Runner, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	return &Runner{logger: log}, nil
+}
+
+// Run executes name with args, without a shell, logging each output line:
+// stdout as info and stderr as warnings. An unsuccessful exit returns the
+// result together with an *ExitError.
+func (r *Runner) Run(ctx context.Context, name string, args []string, opts *Options) (*Result, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	display := redact(commandLine(name, args), o.Secrets)
+	if o.Retry == nil {
+		return r.attempt(ctx, name, args, display, o, 1)
+	}
+
+	seeker, rewind := o.Stdin.(io.Seeker)
+	if o.Stdin != nil && !rewind {
+		return nil, fmt.Errorf("retrying %s needs a seekable Stdin", display)
+	}
+	retryOpts := *o.Retry
+	if retryOpts.Retryable == nil {
+		retryOpts.Retryable = o.retryable
+	}
+	if retryOpts.OnRetry == nil {
+		retryOpts.OnRetry = func(attempt int, err error, delay time.Duration) {
+			r.logger.Warning("Attempt %d of %s failed, retrying in %s: %v", attempt, display, delay.Round(time.Millisecond), err)
+		}
+	}
+
+	var result *Result
+	attempts := 0
+	err := retry.Do(ctx, func() error {
+		attempts++
+		if seeker != nil {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return retry.Permanent(fmt.Errorf("failed to rewind stdin: %w", err))
+			}
+		}
+		var err error
+		result, err = r.attempt(ctx, name, args, display, o, attempts)
+		return err
+	}, retryOpts)
+	return result, err
+}
+
+// Output runs a command quietly and returns its standard output without the
+// trailing newline
+func (r *Runner) Output(ctx context.Context, name string, args ...string) (string, error) {
+	result, err := r.Run(ctx, name, args, &Options{Quiet: true})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(result.Stdout, "\r\n"), nil
+}
+
+// attempt runs the command once
+func (r *Runner) attempt(ctx context.Context, name string, args []string, display string, o Options, attempt int) (*Result, error) {
+	runCtx := ctx
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(runCtx, name, args...)
+	cmd.Dir = o.Dir
+	cmd.Env = o.environ()
+	cmd.Stdin = o.Stdin
+	cmd.Cancel = func() error {
+		// Ask politely first; WaitDelay kills the process if it lingers
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = o.KillGrace
+	if cmd.WaitDelay <= 0 {
+		cmd.WaitDelay = 5 * time.Second
+	}
+
+	stdout := &capture.TailBuffer{}
+	stderr := &capture.TailBuffer{}
+	stdoutLines := r.lineLogger(o.Quiet, name, r.logger.Info)
+	stderrLines := r.lineLogger(o.Quiet, name, r.logger.Warning)
+	cmd.Stdout = capture.Writers(stdout, stdoutLines, o.Stdout)
+	cmd.Stderr = capture.Writers(stderr, stderrLines, o.Stderr)
+
+	r.logger.Info("Running %s", display)
+	start := time.Now()
+	err := cmd.Run()
+	stdoutLines.Flush()
+	stderrLines.Flush()
+
+	result := &Result{
+		Command:  display,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+		Attempts: attempt,
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil || (errors.As(err, &exitErr) && o.success(result.ExitCode) && runCtx.Err() == nil):
+		r.logger.Info("Finished %s in %s (exit status %d)", display, result.Duration.Round(time.Millisecond), result.ExitCode)
+		return result, nil
+	case cmd.ProcessState == nil:
+		return nil, fmt.Errorf("failed to start %s: %w", display, err)
+	}
+
+	failure := &ExitError{Command: display, ExitCode: result.ExitCode, Stderr: result.Stderr, Timeout: o.Timeout, err: err}
+	switch {
+	case ctx.Err() != nil:
+		failure.Class, failure.err = ExitCanceled, ctx.Err()
+	case runCtx.Err() != nil:
+		failure.Class, failure.err = ExitTimeout, runCtx.Err()
+	case result.ExitCode < 0:
+		failure.Class = ExitSignaled
+	default:
+		failure.Class = ExitFailed
+	}
+	r.logger.Error("%v", failure)
+	return result, failure
+}
+
+// success reports whether code counts as a successful exit
+func (o Options) success(code int) bool {
+	if len(o.SuccessCodes) == 0 {
+		return code == 0
+	}
+	for _, c := range o.SuccessCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryable is the default retry classification
+func (o Options) retryable(err error) bool {
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	switch exitErr.Class {
+	case ExitTimeout, ExitSignaled:
+		return true
+	case ExitFailed:
+		if len(o.RetryExitCodes) == 0 {
+			return true
+		}
+		for _, code := range o.RetryExitCodes {
+			if code == exitErr.ExitCode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// environ returns the environment of the command, or nil to inherit it
+func (o Options) environ() []string {
+	if len(o.Env) == 0 && !o.CleanEnv {
+		return nil
+	}
+	var env []string
+	if !o.CleanEnv {
+		env = os.Environ()
+	}
+	for key, value := range o.Env {
+		env = append(env, key+"="+value)
+	}
+	if env == nil {
+		env = []string{}
+	}
+	return env
+}
+
+// lineLogger returns a writer logging each complete line with the program
+// name
+func (r *Runner) lineLogger(quiet bool, name string, logf func(format string, args ...any)) *capture.LineWriter {
+	if quiet {
+		return capture.NewLineWriter(nil)
+	}
+	prefix := name
+	if i := strings.LastIndexAny(prefix, `/\`); i >= 0 {
+		prefix = prefix[i+1:]
+	}
+	return capture.NewLineWriter(func(line string) { logf("[%s] %s", prefix, line) })
+}
+
+// commandLine formats name and args for logs, quoting arguments as needed
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, part := range append([]string{name}, args...) {
+		if part == "" || strings.ContainsAny(part, " \t\n\"'\\$`;&|<>*?()") {
+			part = "'" + strings.ReplaceAll(part, "'", `'\''`) + "'"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// redact replaces every secret in s by "***"
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "***")
+		}
+	}
+	return s
+}
@@ -0,0 +1,227 @@
+package procutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/retry"
+)
+
+func newRunner(t *testing.T) *Runner {
+	t.Helper()
+	log, err := logger.NewLogger("procutil_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	r, err := NewRunner(log)
+	if err != nil {
+		t.Fatalf("NewRunner failed: %v", err)
+	}
+	return r
+}
+
+func TestNewRunner(t *testing.T) {
+	if _, err := NewRunner(nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := newRunner(t)
+	ctx := context.Background()
+
+	var copied bytes.Buffer
+	result, err := r.Run(ctx, "sh", []string{"-c", "echo encrypted 42 files; echo slow disk >&2"}, &Options{Stdout: &copied})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Stdout != "encrypted 42 files\n" || result.Stderr != "slow disk\n" || result.ExitCode != 0 || result.Attempts != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if copied.String() != result.Stdout {
+		t.Errorf("expected stdout copy, got %q", copied.String())
+	}
+
+	content, _ := os.ReadFile(r.logger.GetLogFilePath())
+	if !strings.Contains(string(content), "[sh] encrypted 42 files") || !strings.Contains(string(content), "[sh] slow disk") {
+		t.Errorf("expected output lines in log, got:\n%s", content)
+	}
+
+	result, err = r.Run(ctx, "cat", nil, &Options{Stdin: strings.NewReader("line 1\nline 2"), Quiet: true})
+	if err != nil || result.Stdout != "line 1\nline 2" {
+		t.Errorf("Run(cat) = %+v, %v", result, err)
+	}
+
+	dir := t.TempDir()
+	if out, err := r.Output(ctx, "echo", "ok"); err != nil || out != "ok" {
+		t.Errorf("Output(echo) = %q, %v", out, err)
+	}
+	result, err = r.Run(ctx, "pwd", nil, &Options{Dir: dir, Quiet: true})
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(result.Stdout)); err != nil || got != mustEvalSymlinks(t, dir) {
+		t.Errorf("Run(pwd) in %s = %+v, %v", dir, result, err)
+	}
+}
+
+func mustEvalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestEnv(t *testing.T) {
+	r := newRunner(t)
+	ctx := context.Background()
+	t.Setenv("PROCUTIL_INHERITED", "yes")
+
+	script := []string{"-c", `printf '%s,%s' "$PROCUTIL_INHERITED" "$BATCH_DATE"`}
+	result, err := r.Run(ctx, "sh", script, &Options{Env: map[string]string{"BATCH_DATE": "2024-06-01"}})
+	if err != nil || result.Stdout != "yes,2024-06-01" {
+		t.Errorf("Run with Env = %+v, %v", result, err)
+	}
+
+	result, err = r.Run(ctx, "sh", script, &Options{Env: map[string]string{"BATCH_DATE": "2024-06-02"}, CleanEnv: true})
+	if err != nil || result.Stdout != ",2024-06-02" {
+		t.Errorf("Run with CleanEnv = %+v, %v", result, err)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	r := newRunner(t)
+	ctx := context.Background()
+
+	result, err := r.Run(ctx, "sh", []string{"-c", "echo checking files; echo 'warning: slow disk' >&2; echo 'error: key expired' >&2; exit 3"}, nil)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 3 || Classify(err) != ExitFailed || ExitCode(err) != 3 {
+		t.Fatalf("expected ExitError with status 3, got %v", err)
+	}
+	if result == nil || result.ExitCode != 3 || result.Stdout != "checking files\n" || !strings.Contains(result.Stderr, "slow disk") {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if !strings.HasSuffix(err.Error(), "exited with status 3: error: key expired") {
+		t.Errorf("expected status and last stderr line in error, got %q", err)
+	}
+
+	if _, err := r.Run(ctx, "sh", []string{"-c", "exit 1"}, &Options{SuccessCodes: []int{0, 1}}); err != nil {
+		t.Errorf("expected exit status 1 to succeed, got %v", err)
+	}
+
+	_, err = r.Run(ctx, "procutil-no-such-binary", nil, nil)
+	if Classify(err) != ExitNotFound || ExitCode(err) != -1 {
+		t.Errorf("expected ExitNotFound, got %v (%s)", err, Classify(err))
+	}
+
+	_, err = r.Run(ctx, "sh", []string{"-c", "kill -9 $$"}, nil)
+	if Classify(err) != ExitSignaled || ExitCode(err) != -1 {
+		t.Errorf("expected ExitSignaled, got %v (%s)", err, Classify(err))
+	}
+
+	if Classify(nil) != ExitOK || ExitCode(nil) != 0 {
+		t.Error("expected nil to classify as ExitOK")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	r := newRunner(t)
+
+	start := time.Now()
+	_, err := r.Run(context.Background(), "sleep", []string{"10"}, &Options{Timeout: 100 * time.Millisecond, KillGrace: time.Second})
+	if Classify(err) != ExitTimeout || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("command was not stopped at the timeout")
+	}
+
+	// A process ignoring SIGTERM is killed after KillGrace
+	start = time.Now()
+	_, err = r.Run(context.Background(), "sh", []string{"-c", "trap '' TERM; sleep 10"}, &Options{Timeout: 100 * time.Millisecond, KillGrace: 200 * time.Millisecond})
+	if Classify(err) != ExitTimeout {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("command ignoring SIGTERM was not killed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := r.Run(ctx, "sleep", []string{"10"}, nil); Classify(err) != ExitCanceled || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	r := newRunner(t)
+	ctx := context.Background()
+	counter := filepath.Join(t.TempDir(), "attempts")
+
+	// Fails with EX_TEMPFAIL twice, then succeeds
+	script := []string{"-c", `echo x >> "$1"; [ $(wc -l < "$1") -ge 3 ] || exit 75; echo done`, "sh", counter}
+	retryOpts := &retry.Options{MaxAttempts: 5, InitialDelay: time.Millisecond}
+	result, err := r.Run(ctx, "sh", script, &Options{Retry: retryOpts, RetryExitCodes: []int{75}})
+	if err != nil || result.Attempts != 3 || result.Stdout != "done\n" {
+		t.Errorf("Run with retry = %+v, %v", result, err)
+	}
+
+	// Other exit codes are not retried
+	os.Remove(counter)
+	failing := []string{"-c", `echo x >> "$1"; exit 2`, "sh", counter}
+	result, err = r.Run(ctx, "sh", failing, &Options{Retry: retryOpts, RetryExitCodes: []int{75}})
+	if ExitCode(err) != 2 || result.Attempts != 1 {
+		t.Errorf("expected a single attempt, got %+v, %v", result, err)
+	}
+
+	_, err = r.Run(ctx, "procutil-no-such-binary", nil, &Options{Retry: retryOpts})
+	if Classify(err) != ExitNotFound {
+		t.Errorf("expected ExitNotFound without retries, got %v", err)
+	}
+
+	// Stdin is rewound before each attempt
+	os.Remove(counter)
+	script = []string{"-c", `echo x >> "$1"; [ $(wc -l < "$1") -ge 2 ] || exit 1; cat`, "sh", counter}
+	result, err = r.Run(ctx, "sh", script, &Options{Retry: retryOpts, Stdin: strings.NewReader("payload")})
+	if err != nil || result.Stdout != "payload" {
+		t.Errorf("Run with stdin retry = %+v, %v", result, err)
+	}
+	if _, err := r.Run(ctx, "cat", nil, &Options{Retry: retryOpts, Stdin: &bytes.Buffer{}}); err == nil {
+		t.Error("expected error for a non-seekable stdin with retries")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	r := newRunner(t)
+
+	_, err := r.Run(context.Background(), "sh", []string{"-c", "exit 4", "encryptor", "--passphrase", "hunter2"}, &Options{Secrets: []string{"hunter2"}})
+	if err == nil || strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), "***") {
+		t.Errorf("expected the secret to be redacted, got %v", err)
+	}
+	content, _ := os.ReadFile(r.logger.GetLogFilePath())
+	if strings.Contains(string(content), "hunter2") {
+		t.Errorf("secret leaked to the log:\n%s", content)
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		"gpg --batch":                 {"gpg", "--batch"},
+		"encrypt 'my file.csv' ''":    {"encrypt", "my file.csv", ""},
+		`sh -c 'echo '\''hi'\'''`:     {"sh", "-c", "echo 'hi'"},
+		"/opt/bin/tool '$HOME' 'a;b'": {"/opt/bin/tool", "$HOME", "a;b"},
+	}
+	for expected, argv := range tests {
+		if got := commandLine(argv[0], argv[1:]); got != expected {
+			t.Errorf("commandLine(%q) = %s, expected %s", argv, got, expected)
+		}
+	}
+}
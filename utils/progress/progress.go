@@ -0,0 +1,345 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Unit selects how a bar formats its counts
+type Unit int
+
+// Supported units
+const (
+	Bytes Unit = iota // sizes such as "1.2 GiB" and rates such as "12.5 MiB/s"
+	Files             // counts such as "1,204" and rates such as "35.0/s"
+)
+
+// Defaults used when the matching Options field is zero
+const (
+	DefaultRefreshInterval = 200 * time.Millisecond
+	DefaultMilestone       = 25
+	DefaultWidth           = 30
+)
+
+// Options controls how progress is displayed and logged
+type Options struct {
+	// Output receives the bars (defaults to os.Stderr). Nothing is drawn when
+	// it is not a terminal, e.g. when piped or run from cron.
+	Output io.Writer
+	// Force draws bars even when Output is not a terminal
+	Force bool
+	// RefreshInterval is the time between redraws (defaults to 200ms)
+	RefreshInterval time.Duration
+	// Width is the number of characters of a bar (defaults to 30)
+	Width int
+	// Milestone logs a bar every time it passes a multiple of this
+	// percentage (defaults to 25; negative disables milestone logging)
+	Milestone int
+}
+
+// Multi draws one or more bars below each other and logs their milestones
+type Multi struct {
+	log         *logger.Logger
+	opts        Options
+	out         io.Writer
+	interactive bool
+
+	mu      sync.Mutex
+	bars    []*Bar
+	lines   int // lines drawn by the last render
+	stopped bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Bar tracks the progress of one transfer or batch
+type Bar struct {
+	multi *Multi
+	name  string
+	unit  Unit
+	start time.Time
+	owned bool // the bar was created by NewBar and stops its Multi on Finish
+
+	// guarded by multi.mu
+	current       int64
+	total         int64
+	nextMilestone int
+	finished      time.Time
+}
+
+// New creates a Multi that logs milestones to log, which may be nil, and
+// starts drawing when Output is a terminal. Call Stop when done.
+func New(log *logger.Logger, opts *Options) *Multi {
+	m := &Multi{log: log, stop: make(chan struct{}), done: make(chan struct{})}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.Output == nil {
+		m.opts.Output = os.Stderr
+	}
+	if m.opts.RefreshInterval <= 0 {
+		m.opts.RefreshInterval = DefaultRefreshInterval
+	}
+	if m.opts.Width <= 0 {
+		m.opts.Width = DefaultWidth
+	}
+	if m.opts.Milestone == 0 {
+		m.opts.Milestone = DefaultMilestone
+	}
+	m.out = m.opts.Output
+	m.interactive = m.opts.Force || isTerminal(m.out)
+
+	if m.interactive {
+		go m.loop()
+	} else {
+		close(m.done)
+	}
+	return m
+}
+
+// NewBar creates a single bar with its own Multi, stopped by Finish.
+// A total of 0 means unknown: the bar then shows the count and rate only.
+func NewBar(log *logger.Logger, name string, total int64, unit Unit, opts *Options) *Bar {
+	bar := New(log, opts).Add(name, total, unit)
+	bar.owned = true
+	return bar
+}
+
+// Add adds a bar below the existing ones
+func (m *Multi) Add(name string, total int64, unit Unit) *Bar {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bar := &Bar{multi: m, name: name, unit: unit, start: time.Now(), total: total, nextMilestone: m.opts.Milestone}
+	m.bars = append(m.bars, bar)
+	return bar
+}
+
+// Interactive reports whether bars are drawn, i.e. Output is a terminal or
+// Force is set
+func (m *Multi) Interactive() bool {
+	return m.interactive
+}
+
+// Stop draws the final state of every bar and stops redrawing
+func (m *Multi) Stop() {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+	m.stopped = true
+	close(m.stop)
+	m.mu.Unlock()
+	<-m.done
+
+	if m.interactive {
+		m.mu.Lock()
+		m.render()
+		m.mu.Unlock()
+	}
+}
+
+// loop redraws the bars until Stop is called
+func (m *Multi) loop() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			m.render()
+			m.mu.Unlock()
+		}
+	}
+}
+
+// render redraws every bar over the previous render. The caller holds m.mu.
+func (m *Multi) render() {
+	var b strings.Builder
+	if m.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", m.lines)
+	}
+	nameWidth := 0
+	for _, bar := range m.bars {
+		nameWidth = max(nameWidth, len(bar.name))
+	}
+	for _, bar := range m.bars {
+		b.WriteString("\r\x1b[K")
+		b.WriteString(bar.line(nameWidth, m.opts.Width))
+		b.WriteByte('\n')
+	}
+	m.lines = len(m.bars)
+	io.WriteString(m.out, b.String())
+}
+
+// logf clears the bars and logs a message, so the next render draws the
+// bars below it. The caller holds m.mu.
+func (m *Multi) logf(format string, args ...any) {
+	if m.log == nil {
+		return
+	}
+	if m.interactive && m.lines > 0 {
+		fmt.Fprintf(m.out, "\x1b[%dA\x1b[J", m.lines)
+		m.lines = 0
+	}
+	m.log.Info(format, args...)
+}
+
+// Add advances the bar by n
+func (b *Bar) Add(n int64) {
+	b.multi.mu.Lock()
+	defer b.multi.mu.Unlock()
+	b.current += n
+	b.milestones()
+}
+
+// Increment advances the bar by one, e.g. after each processed file
+func (b *Bar) Increment() {
+	b.Add(1)
+}
+
+// Set sets the current count, e.g. from a cumulative iocopy.Progress
+func (b *Bar) Set(n int64) {
+	b.multi.mu.Lock()
+	defer b.multi.mu.Unlock()
+	b.current = n
+	b.milestones()
+}
+
+// SetTotal changes the expected total, e.g. once a listing completes
+func (b *Bar) SetTotal(total int64) {
+	b.multi.mu.Lock()
+	defer b.multi.mu.Unlock()
+	b.total = total
+	b.milestones()
+}
+
+// Write counts len(p) bytes, so a Bar can be used with io.TeeReader or
+// io.MultiWriter
+func (b *Bar) Write(p []byte) (int, error) {
+	b.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Current returns the current count
+func (b *Bar) Current() int64 {
+	b.multi.mu.Lock()
+	defer b.multi.mu.Unlock()
+	return b.current
+}
+
+// Finish marks the bar as done and logs a summary. A bar created by NewBar
+// also stops drawing.
+func (b *Bar) Finish() {
+	b.multi.mu.Lock()
+	if b.finished.IsZero() {
+		b.finished = time.Now()
+		elapsed := b.finished.Sub(b.start)
+		b.multi.logf("%s: done, %s in %s (%s)", b.name, b.format(b.current), humanize.Duration(elapsed), b.rate(elapsed))
+	}
+	b.multi.mu.Unlock()
+
+	if b.owned {
+		b.multi.Stop()
+	}
+}
+
+// String formats the bar as it is drawn
+func (b *Bar) String() string {
+	b.multi.mu.Lock()
+	defer b.multi.mu.Unlock()
+	return b.line(len(b.name), b.multi.opts.Width)
+}
+
+// milestones logs the highest milestone passed since the last one. The caller
+// holds multi.mu.
+func (b *Bar) milestones() {
+	step := b.multi.opts.Milestone
+	if step < 0 || b.total <= 0 || !b.finished.IsZero() {
+		return
+	}
+	percent := int(min(b.current*100/b.total, 100))
+	if percent < b.nextMilestone {
+		return
+	}
+	// Log only the highest milestone passed, e.g. once when jumping from 10% to 80%
+	reached := percent / step * step
+	b.nextMilestone = reached + step
+	elapsed := time.Since(b.start)
+	b.multi.logf("%s: %d%% (%s of %s, %s, ETA %s)", b.name, reached, b.format(b.current), b.format(b.total), b.rate(elapsed), b.eta(elapsed))
+}
+
+// line formats the bar padded to nameWidth. The caller holds multi.mu.
+func (b *Bar) line(nameWidth, width int) string {
+	elapsed := time.Since(b.start)
+	status := "ETA " + b.eta(elapsed)
+	if !b.finished.IsZero() {
+		elapsed = b.finished.Sub(b.start)
+		status = "done in " + humanize.Duration(elapsed)
+	}
+
+	if b.total <= 0 {
+		return fmt.Sprintf("%-*s %s  %s", nameWidth, b.name, b.format(b.current), b.rate(elapsed))
+	}
+
+	filled := int(min(b.current, b.total) * int64(width) / b.total)
+	graph := strings.Repeat("=", filled)
+	if filled < width {
+		graph += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	percent := min(b.current*100/b.total, 100)
+	return fmt.Sprintf("%-*s [%s] %3d%% %s / %s  %s  %s", nameWidth, b.name, graph, percent,
+		b.format(b.current), b.format(b.total), b.rate(elapsed), status)
+}
+
+// format formats a count in the bar's unit
+func (b *Bar) format(n int64) string {
+	if b.unit == Bytes {
+		return humanize.Bytes(n)
+	}
+	return humanize.Count(n)
+}
+
+// rate formats the average throughput since the bar started
+func (b *Bar) rate(elapsed time.Duration) string {
+	if b.unit == Bytes {
+		return humanize.Rate(b.current, elapsed)
+	}
+	if elapsed <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f/s", float64(b.current)/elapsed.Seconds())
+}
+
+// eta estimates the remaining time from the average rate so far
+func (b *Bar) eta(elapsed time.Duration) string {
+	switch {
+	case b.total > 0 && b.current >= b.total:
+		return "0s"
+	case b.total <= 0 || b.current <= 0:
+		return "-"
+	}
+	remaining := time.Duration(float64(elapsed) * float64(b.total-b.current) / float64(b.current))
+	return humanize.Duration(remaining)
+}
+
+// isTerminal reports whether w is a terminal that understands cursor movement
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,128 @@
+package progress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// syncBuffer is a bytes.Buffer safe for the render goroutine
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestLogger(t *testing.T) (*logger.Logger, *[]string) {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("progress_test")
+	t.Cleanup(func() { testLogger.Close() })
+	var messages []string
+	testLogger.AddHook(func(level, message string) { messages = append(messages, message) })
+	return testLogger, &messages
+}
+
+func TestBarLine(t *testing.T) {
+	bar := NewBar(nil, "orders.csv", 4096, Bytes, &Options{Output: io.Discard, Width: 10})
+	bar.Add(1024)
+	line := bar.String()
+	if !strings.HasPrefix(line, "orders.csv [==>       ]  25% 1.0 KiB / 4.0 KiB") || !strings.Contains(line, "ETA") {
+		t.Errorf("unexpected line %q", line)
+	}
+
+	bar.Set(4096)
+	bar.Finish()
+	if line := bar.String(); !strings.Contains(line, "[==========] 100%") || !strings.Contains(line, "done in") {
+		t.Errorf("unexpected finished line %q", line)
+	}
+
+	files := NewBar(nil, "files", 0, Files, &Options{Output: io.Discard})
+	files.Add(1234)
+	if line := files.String(); !strings.HasPrefix(line, "files 1,234  ") || strings.Contains(line, "[") {
+		t.Errorf("unexpected line for unknown total %q", line)
+	}
+	files.Finish()
+}
+
+func TestMilestones(t *testing.T) {
+	testLogger, messages := newTestLogger(t)
+	bar := NewBar(testLogger, "upload", 1000, Files, &Options{Output: io.Discard})
+
+	bar.Add(100)
+	bar.Add(200) // 30%: logs 25%
+	bar.Add(500) // 80%: logs 75% only
+	bar.Set(1000)
+	bar.Finish()
+	bar.Finish()
+
+	expected := []string{"upload: 25% (300 of 1,000", "upload: 75% (800 of 1,000", "upload: 100% (1,000 of 1,000", "upload: done, 1,000 in"}
+	if len(*messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %q", len(expected), *messages)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix((*messages)[i], prefix) {
+			t.Errorf("message %d = %q, expected prefix %q", i, (*messages)[i], prefix)
+		}
+	}
+}
+
+func TestMultiRender(t *testing.T) {
+	var out syncBuffer
+	m := New(nil, &Options{Output: &out, Force: true, RefreshInterval: 10 * time.Millisecond})
+	if !m.Interactive() {
+		t.Fatal("expected forced interactive output")
+	}
+	a := m.Add("a.csv", 100, Bytes)
+	b := m.Add("longer.csv", 0, Files)
+	a.Add(50)
+	b.Increment()
+	time.Sleep(30 * time.Millisecond)
+	a.Finish()
+	b.Finish()
+	m.Stop()
+	m.Stop()
+
+	output := out.String()
+	if !strings.Contains(output, "\x1b[2A") {
+		t.Error("expected cursor movement between redraws")
+	}
+	if !strings.Contains(output, "a.csv      [") || !strings.Contains(output, "longer.csv 1") {
+		t.Errorf("expected aligned bar names, got %q", output)
+	}
+}
+
+func TestNonInteractive(t *testing.T) {
+	var out bytes.Buffer
+	m := New(nil, &Options{Output: &out})
+	if m.Interactive() {
+		t.Fatal("expected a buffer not to be treated as a terminal")
+	}
+	bar := m.Add("piped", 10, Bytes)
+	if _, err := io.Copy(bar, strings.NewReader("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	bar.Finish()
+	m.Stop()
+	if out.Len() != 0 {
+		t.Errorf("expected no output when piped, got %q", out.String())
+	}
+	if bar.Current() != 10 {
+		t.Errorf("Current() = %d", bar.Current())
+	}
+}
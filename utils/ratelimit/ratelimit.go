@@ -0,0 +1,219 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket that refills at a fixed rate up to a burst size.
+// Use it for operations per second (one token per operation) or bytes per
+// second (one token per byte). A nil *Limiter never limits.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second; <= 0 means unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter creates a limiter allowing rate tokens per second with bursts of up
+// to burst tokens. The bucket starts full. A rate <= 0 disables limiting.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &Limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Rate returns the current rate in tokens per second
+func (l *Limiter) Rate() float64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Burst returns the bucket size
+func (l *Limiter) Burst() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.burst)
+}
+
+// SetRate changes the refill rate, e.g. to throttle harder during business hours
+func (l *Limiter) SetRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.rate = rate
+}
+
+// Allow reports whether one token is available and consumes it if so
+func (l *Limiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n tokens are available and consumes them if so
+func (l *Limiter) AllowN(n int) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return true
+	}
+	l.refill(time.Now())
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Wait blocks until one token is available or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are available or ctx is done. Requests larger
+// than the burst are allowed by borrowing against future refills, so the
+// average rate is still respected.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	l.refill(now)
+	l.tokens -= float64(n)
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Return the reserved tokens that were not used
+		l.mu.Lock()
+		l.tokens = math.Min(l.tokens+float64(n), l.burst)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// refill adds tokens for the time elapsed since the last update; the caller must hold l.mu
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed <= 0 || l.rate <= 0 {
+		return
+	}
+	l.tokens = math.Min(l.tokens+elapsed*l.rate, l.burst)
+}
+
+// chunk returns the largest single request size callers should use
+func (l *Limiter) chunk(size int) int {
+	if burst := l.Burst(); burst > 0 && size > burst {
+		return burst
+	}
+	return size
+}
+
+// reader throttles reads to the limiter's rate in bytes per second
+type reader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *Limiter
+}
+
+// NewReader returns a reader that limits throughput to the limiter's rate in
+// bytes per second. Reads fail with the context error once ctx is done.
+func NewReader(ctx context.Context, r io.Reader, limiter *Limiter) io.Reader {
+	return &reader{ctx: ctx, r: r, limiter: limiter}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if r.limiter == nil || len(p) == 0 {
+		return r.r.Read(p)
+	}
+
+	n, err := r.r.Read(p[:r.limiter.chunk(len(p))])
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// writer throttles writes to the limiter's rate in bytes per second
+type writer struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *Limiter
+}
+
+// NewWriter returns a writer that limits throughput to the limiter's rate in
+// bytes per second. Writes fail with the context error once ctx is done.
+func NewWriter(ctx context.Context, w io.Writer, limiter *Limiter) io.Writer {
+	return &writer{ctx: ctx, w: w, limiter: limiter}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.limiter == nil {
+		if err := w.ctx.Err(); err != nil {
+			return 0, err
+		}
+		return w.w.Write(p)
+	}
+
+	written := 0
+	for written < len(p) {
+		size := w.limiter.chunk(len(p) - written)
+		if err := w.limiter.WaitN(w.ctx, size); err != nil {
+			return written, err
+		}
+		n, err := w.w.Write(p[written : written+size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// String describes the limiter, e.g. "10/s (burst 10)"
+func (l *Limiter) String() string {
+	if l == nil || l.Rate() <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%g/s (burst %d)", l.Rate(), l.Burst())
+}
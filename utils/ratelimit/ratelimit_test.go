@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	l := NewLimiter(10, 3)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("token %d should be available from the initial burst", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Error("bucket should be empty after the burst")
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	if !l.Allow() {
+		t.Error("a token should have been refilled after 100ms at 10/s")
+	}
+}
+
+func TestWaitN(t *testing.T) {
+	l := NewLimiter(100, 10)
+	start := time.Now()
+	// 10 tokens from the burst, then 20 more at 100/s take ~200ms
+	for i := 0; i < 3; i++ {
+		if err := l.WaitN(context.Background(), 10); err != nil {
+			t.Fatalf("WaitN failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("elapsed = %s, expected about 200ms", elapsed)
+	}
+}
+
+func TestWaitCancel(t *testing.T) {
+	l := NewLimiter(1, 1)
+	l.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	var nilLimiter *Limiter
+	if !nilLimiter.Allow() || nilLimiter.WaitN(context.Background(), 1<<30) != nil {
+		t.Error("nil limiter should never limit")
+	}
+
+	l := NewLimiter(0, 0)
+	for i := 0; i < 1000; i++ {
+		if !l.Allow() {
+			t.Fatal("zero-rate limiter should never limit")
+		}
+	}
+	if l.String() != "unlimited" {
+		t.Errorf("String = %q", l.String())
+	}
+}
+
+func TestSetRate(t *testing.T) {
+	l := NewLimiter(1, 1)
+	l.Allow()
+	l.SetRate(1000)
+	time.Sleep(5 * time.Millisecond)
+	if !l.Allow() {
+		t.Error("raised rate should refill quickly")
+	}
+	if l.String() != "1000/s (burst 1)" {
+		t.Errorf("String = %q", l.String())
+	}
+}
+
+func TestReaderWriter(t *testing.T) {
+	data := []byte(strings.Repeat("x", 3000))
+
+	// 3000 bytes at 10000 bytes/s with a 1000 byte burst take about 200ms
+	start := time.Now()
+	got, err := io.ReadAll(NewReader(context.Background(), bytes.NewReader(data), NewLimiter(10000, 1000)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes, err %v", len(got), err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("reader was not throttled: %s", elapsed)
+	}
+
+	var buf bytes.Buffer
+	start = time.Now()
+	n, err := NewWriter(context.Background(), &buf, NewLimiter(10000, 1000)).Write(data)
+	if err != nil || n != len(data) || !bytes.Equal(buf.Bytes(), data) {
+		t.Fatalf("wrote %d bytes, err %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("writer was not throttled: %s", elapsed)
+	}
+}
+
+func TestWriterCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	var buf bytes.Buffer
+	n, err := NewWriter(ctx, &buf, NewLimiter(100, 100)).Write(make([]byte, 1000))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if n != buf.Len() || n >= 1000 {
+		t.Errorf("written = %d, buffered = %d", n, buf.Len())
+	}
+}
@@ -0,0 +1,182 @@
+package redishelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/romisugianto/go-utils/utils/id"
+)
+
+// Scripts that only touch a lock still owned by the caller's token
+var (
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// Lock is a held distributed lock. It expires after its TTL unless
+// refreshed, so a crashed holder cannot block other hosts forever.
+type Lock struct {
+	helper *RedisHelper
+	name   string
+	owner  string
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	released bool
+}
+
+// AcquireLock takes the named lock, e.g. "file:orders.csv", for ttl. The
+// owner is recorded as "host:pid:random" so other hosts can report who is
+// processing. If the lock is held, the error wraps ErrLocked and names the
+// current owner.
+func (h *RedisHelper) AcquireLock(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if name == "" {
+		return nil, fmt.Errorf("lock name cannot be empty")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lock TTL must be positive")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	owner := fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), id.Random(8))
+
+	acquired, err := h.SetIfAbsent(ctx, lockKey(name), owner, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if !acquired {
+		current, err := h.LockOwner(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, name)
+		}
+		return nil, fmt.Errorf("%w: %s (owner %s)", ErrLocked, name, current)
+	}
+
+	h.logger.Debug("Acquired lock %s as %s for %s", name, owner, ttl)
+	return &Lock{helper: h, name: name, owner: owner, ttl: ttl}, nil
+}
+
+// LockOwner returns the owner of the named lock, or an error wrapping
+// ErrNotFound when it is free
+func (h *RedisHelper) LockOwner(ctx context.Context, name string) (string, error) {
+	return h.Get(ctx, lockKey(name))
+}
+
+// WithLock runs fn while holding the named lock, refreshing it every third
+// of ttl. If the lock is lost, fn's context is cancelled. The lock is
+// released when fn returns.
+func (h *RedisHelper) WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	lock, err := h.AcquireLock(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if err := lock.Refresh(runCtx); err != nil && runCtx.Err() == nil {
+					h.logger.Error("Failed to refresh lock %s: %v", name, err)
+					if errors.Is(err, ErrLockLost) {
+						cancel(err)
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	fnErr := fn(runCtx)
+	cause := context.Cause(runCtx)
+	cancel(nil)
+	<-done
+
+	// Release with a fresh context so a cancelled caller still frees the lock
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer releaseCancel()
+	releaseErr := lock.Release(releaseCtx)
+
+	if errors.Is(cause, ErrLockLost) {
+		return errors.Join(fnErr, cause)
+	}
+	if fnErr != nil {
+		return fnErr
+	}
+	return releaseErr
+}
+
+// Name returns the lock name
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Owner returns the owner token recorded in Redis
+func (l *Lock) Owner() string {
+	return l.owner
+}
+
+// Refresh extends the lock by its TTL. It returns an error wrapping
+// ErrLockLost if the lock expired or was taken over.
+func (l *Lock) Refresh(ctx context.Context) error {
+	ok, err := refreshScript.Run(ctx, l.helper.Client, []string{l.helper.Key(lockKey(l.name))}, l.owner, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to refresh lock %s: %w", l.name, err)
+	}
+	if ok == 0 {
+		return fmt.Errorf("%w: %s", ErrLockLost, l.name)
+	}
+	return nil
+}
+
+// Release frees the lock if it is still owned by this holder. It is safe to
+// call more than once.
+func (l *Lock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		return nil
+	}
+	l.released = true
+
+	n, err := releaseScript.Run(ctx, l.helper.Client, []string{l.helper.Key(lockKey(l.name))}, l.owner).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.name, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrLockLost, l.name)
+	}
+	l.helper.logger.Debug("Released lock %s", l.name)
+	return nil
+}
+
+// lockKey returns the key holding the named lock
+func lockKey(name string) string {
+	return "lock:" + name
+}
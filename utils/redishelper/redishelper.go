@@ -0,0 +1,213 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package redishelper
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// ErrNotFound is returned by Get when the key does not exist
+var ErrNotFound = errors.New("key not found")
+
+// ErrLocked is returned when another holder owns the lock
+var ErrLocked = errors.New("lock is held by another owner")
+
+// ErrLockLost is returned when a lock expired or was taken over before it
+// was refreshed or released
+var ErrLockLost = errors.New("lock is no longer held")
+
+// Config holds the connection settings
+type Config struct {
+	// Addr is the server address (default "localhost:6379")
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	// TLS encrypts the connection, e.g. for managed Redis services
+	TLS bool
+	// InsecureSkipVerify disables certificate verification (testing only)
+	InsecureSkipVerify bool
+
+	// KeyPrefix namespaces every key, e.g. "orders-pipeline:", so several
+	// applications can share a server
+	KeyPrefix string
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolSize     int
+}
+
+// RedisHelper wraps a Redis client with key namespacing, counters and
+// distributed locks for coordinating pipeline runs across hosts
+type RedisHelper struct {
+	Client *redis.Client
+	config Config
+	logger *logger.Logger
+}
+
+// NewRedisHelper connects to the server from cfg and verifies the connection
+// with a ping
+func NewRedisHelper(log *logger.Logger, cfg Config) (*RedisHelper, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = "localhost:6379"
+	}
+
+	opts := &redis.Options{
+		Addr:         cfg.Addr,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		PoolSize:     cfg.PoolSize,
+	}
+	if cfg.TLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	}
+
+	h := &RedisHelper{Client: redis.NewClient(opts), config: cfg, logger: log}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.HealthCheck(ctx); err != nil {
+		h.Client.Close()
+		return nil, err
+	}
+
+	log.Info("Connected to Redis at %s (db %d)", cfg.Addr, cfg.DB)
+	return h, nil
+}
+
+// Close closes the connection pool
+func (h *RedisHelper) Close() error {
+	return h.Client.Close()
+}
+
+// HealthCheck pings the server
+func (h *RedisHelper) HealthCheck(ctx context.Context) error {
+	if err := h.Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis health check failed: %w", err)
+	}
+	return nil
+}
+
+// Key returns key with the configured prefix, for use with Client directly
+func (h *RedisHelper) Key(key string) string {
+	return h.config.KeyPrefix + key
+}
+
+// Get returns the value of key, or an error wrapping ErrNotFound
+func (h *RedisHelper) Get(ctx context.Context, key string) (string, error) {
+	value, err := h.Client.Get(ctx, h.Key(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set stores value under key. A ttl of 0 keeps the key until deleted.
+func (h *RedisHelper) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := h.Client.Set(ctx, h.Key(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// SetIfAbsent stores value under key only if it does not exist and reports
+// whether it was stored
+func (h *RedisHelper) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	err := h.Client.SetArgs(ctx, h.Key(key), value, redis.SetArgs{Mode: "NX", TTL: ttl}).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Delete removes keys and returns how many existed
+func (h *RedisHelper) Delete(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = h.Key(key)
+	}
+	n, err := h.Client.Del(ctx, prefixed...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete keys: %w", err)
+	}
+	return n, nil
+}
+
+// Expire sets the time to live of key and reports whether the key exists
+func (h *RedisHelper) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := h.Client.Expire(ctx, h.Key(key), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set expiry of %s: %w", key, err)
+	}
+	return ok, nil
+}
+
+// TTL returns the remaining time to live of key: -1 when it does not expire
+// and an error wrapping ErrNotFound when it does not exist
+func (h *RedisHelper) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := h.Client.PTTL(ctx, h.Key(key)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get TTL of %s: %w", key, err)
+	}
+	// PTTL returns -2 for a missing key and -1 for a key without expiry
+	if ttl == -2 {
+		return 0, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return ttl, nil
+}
+
+// incrScript increments a counter and sets its expiry when it is created, so
+// windowed counters such as "files:2024-06-01" clean themselves up
+var incrScript = redis.NewScript(`
+local n = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return n
+`)
+
+// Incr adds n to the counter at key and returns the new value. A ttl above 0
+// is set when the counter is created and not extended by later increments.
+func (h *RedisHelper) Incr(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	value, err := incrScript.Run(ctx, h.Client, []string{h.Key(key)}, n, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Counter returns the value of the counter at key, or 0 when it does not exist
+func (h *RedisHelper) Counter(ctx context.Context, key string) (int64, error) {
+	value, err := h.Client.Get(ctx, h.Key(key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counter %s: %w", key, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,202 @@
+package redishelper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func newTestHelper(t *testing.T) (*RedisHelper, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	log, err := logger.NewLogger("redishelper_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	h, err := NewRedisHelper(log, Config{Addr: server.Addr(), KeyPrefix: "test:"})
+	if err != nil {
+		t.Fatalf("NewRedisHelper failed: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h, server
+}
+
+func TestNewRedisHelperValidation(t *testing.T) {
+	if _, err := NewRedisHelper(nil, Config{}); err == nil {
+		t.Error("expected error for nil logger")
+	}
+	log, err := logger.NewLogger("redishelper_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	if _, err := NewRedisHelper(log, Config{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond}); err == nil {
+		t.Error("expected error for unreachable server")
+	}
+}
+
+func TestGetSetExpire(t *testing.T) {
+	h, server := newTestHelper(t)
+	ctx := context.Background()
+
+	if _, err := h.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := h.Set(ctx, "status", "running", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if value, err := h.Get(ctx, "status"); err != nil || value != "running" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+	if !server.Exists("test:status") {
+		t.Error("expected key to be prefixed")
+	}
+	if ttl, err := h.TTL(ctx, "status"); err != nil || ttl != -1 {
+		t.Errorf("TTL = %v, %v", ttl, err)
+	}
+
+	if ok, err := h.Expire(ctx, "status", time.Minute); err != nil || !ok {
+		t.Errorf("Expire = %t, %v", ok, err)
+	}
+	if ttl, err := h.TTL(ctx, "status"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL = %v, %v", ttl, err)
+	}
+	server.FastForward(2 * time.Minute)
+	if _, err := h.TTL(ctx, "status"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired key, got %v", err)
+	}
+
+	if ok, err := h.SetIfAbsent(ctx, "once", "a", 0); err != nil || !ok {
+		t.Errorf("first SetIfAbsent = %t, %v", ok, err)
+	}
+	if ok, err := h.SetIfAbsent(ctx, "once", "b", 0); err != nil || ok {
+		t.Errorf("second SetIfAbsent = %t, %v", ok, err)
+	}
+	if n, err := h.Delete(ctx, "once", "missing"); err != nil || n != 1 {
+		t.Errorf("Delete = %d, %v", n, err)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	h, server := newTestHelper(t)
+	ctx := context.Background()
+
+	if n, err := h.Counter(ctx, "files"); err != nil || n != 0 {
+		t.Errorf("Counter = %d, %v", n, err)
+	}
+	if n, err := h.Incr(ctx, "files", 1, time.Hour); err != nil || n != 1 {
+		t.Errorf("Incr = %d, %v", n, err)
+	}
+	server.FastForward(30 * time.Minute)
+	if n, err := h.Incr(ctx, "files", 4, time.Hour); err != nil || n != 5 {
+		t.Errorf("Incr = %d, %v", n, err)
+	}
+	// The expiry is set on creation only
+	if ttl := server.TTL("test:files"); ttl != 30*time.Minute {
+		t.Errorf("expected 30m TTL, got %v", ttl)
+	}
+	if n, err := h.Counter(ctx, "files"); err != nil || n != 5 {
+		t.Errorf("Counter = %d, %v", n, err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	h, server := newTestHelper(t)
+	ctx := context.Background()
+
+	lock, err := h.AcquireLock(ctx, "file:orders.csv", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if owner, err := h.LockOwner(ctx, "file:orders.csv"); err != nil || owner != lock.Owner() {
+		t.Errorf("LockOwner = %q, %v", owner, err)
+	}
+
+	_, err = h.AcquireLock(ctx, "file:orders.csv", time.Minute)
+	if !errors.Is(err, ErrLocked) || !strings.Contains(err.Error(), lock.Owner()) {
+		t.Errorf("expected ErrLocked naming the owner, got %v", err)
+	}
+
+	server.FastForward(50 * time.Second)
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("Refresh failed: %v", err)
+	}
+	server.FastForward(50 * time.Second)
+	if err := lock.Release(ctx); err != nil {
+		t.Errorf("Release failed: %v", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Errorf("second Release failed: %v", err)
+	}
+	if _, err := h.LockOwner(ctx, "file:orders.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected free lock, got %v", err)
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	h, server := newTestHelper(t)
+	ctx := context.Background()
+
+	lock, err := h.AcquireLock(ctx, "run", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(2 * time.Second)
+	other, err := h.AcquireLock(ctx, "run", time.Minute)
+	if err != nil {
+		t.Fatalf("expected expired lock to be taken over: %v", err)
+	}
+	if err := lock.Refresh(ctx); !errors.Is(err, ErrLockLost) {
+		t.Errorf("expected ErrLockLost from Refresh, got %v", err)
+	}
+	if err := lock.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Errorf("expected ErrLockLost from Release, got %v", err)
+	}
+	if owner, _ := h.LockOwner(ctx, "run"); owner != other.Owner() {
+		t.Error("release of a lost lock must not free the new owner's lock")
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	h, server := newTestHelper(t)
+	ctx := context.Background()
+
+	err := h.WithLock(ctx, "run", time.Minute, func(ctx context.Context) error {
+		if _, err := h.AcquireLock(ctx, "run", time.Minute); !errors.Is(err, ErrLocked) {
+			t.Errorf("expected lock to be held, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithLock failed: %v", err)
+	}
+	if server.Exists("test:lock:run") {
+		t.Error("expected lock to be released")
+	}
+
+	failure := errors.New("upload failed")
+	if err := h.WithLock(ctx, "run", time.Minute, func(ctx context.Context) error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("expected fn error, got %v", err)
+	}
+
+	// A lock taken over while fn runs cancels fn's context
+	err = h.WithLock(ctx, "run", 30*time.Millisecond, func(ctx context.Context) error {
+		server.Set("test:lock:run", "other-host")
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, ErrLockLost) {
+		t.Errorf("expected ErrLockLost, got %v", err)
+	}
+	if owner, _ := h.LockOwner(ctx, "run"); owner != "other-host" {
+		t.Errorf("expected other owner to keep the lock, got %q", owner)
+	}
+}
@@ -0,0 +1,154 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/humanize"
+)
+
+// Funcs returns the helper functions available to every template. Templates
+// parsed elsewhere can use them with Funcs(render.Funcs()).
+//
+// Values are piped as the last argument, so calls chain:
+//
+//	{{now | addDays -1 | date "2006-01-02"}}
+func Funcs() map[string]any {
+	return map[string]any{
+		// Formatting
+		"bytes":    Bytes,
+		"duration": Duration,
+		"pad":      pad,
+		// Dates
+		"now":          time.Now,
+		"date":         date,
+		"since":        since,
+		"add":          add,
+		"addDays":      addDays,
+		"addMonths":    addMonths,
+		"startOfDay":   startOfDay,
+		"startOfMonth": startOfMonth,
+		// Strings
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"replace": replace,
+		"join":    join,
+		"default": defaultValue,
+	}
+}
+
+// Bytes formats a size with a binary unit, e.g. "1.5 MiB". It accepts any
+// integer type.
+func Bytes(size any) (string, error) {
+	n, err := toInt64(size)
+	if err != nil {
+		return "", err
+	}
+	return humanize.Bytes(n), nil
+}
+
+// Duration rounds a duration for display, e.g. "1m32s". Numbers are taken as
+// seconds.
+func Duration(d any) (string, error) {
+	var value time.Duration
+	switch v := d.(type) {
+	case time.Duration:
+		value = v
+	case float32:
+		value = time.Duration(float64(v) * float64(time.Second))
+	case float64:
+		value = time.Duration(v * float64(time.Second))
+	default:
+		n, err := toInt64(d)
+		if err != nil {
+			return "", err
+		}
+		value = time.Duration(n) * time.Second
+	}
+	if value < time.Second {
+		return value.Round(time.Millisecond).String(), nil
+	}
+	return value.Round(time.Second).String(), nil
+}
+
+// toInt64 converts any integer type to int64
+func toInt64(v any) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("expected an integer, got %T", v)
+}
+
+// pad left-pads a number with zeros to width digits, e.g. part numbers in
+// file names
+func pad(width int, n any) (string, error) {
+	v, err := toInt64(n)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", width, v), nil
+}
+
+// date formats t with a Go layout, e.g. {{date "20060102" .Started}}
+func date(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// since returns the rounded time elapsed since t
+func since(t time.Time) string {
+	d, _ := Duration(time.Since(t))
+	return d
+}
+
+// add adds a duration such as "-2h" or "90m" to t
+func add(duration string, t time.Time) (time.Time, error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(d), nil
+}
+
+func addDays(days int, t time.Time) time.Time {
+	return t.AddDate(0, 0, days)
+}
+
+func addMonths(months int, t time.Time) time.Time {
+	return t.AddDate(0, months, 0)
+}
+
+// startOfDay returns midnight of t's day in t's location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfMonth returns midnight of the first day of t's month
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+func join(sep string, elems []string) string {
+	return strings.Join(elems, sep)
+}
+
+// defaultValue returns def when value is empty, e.g. {{.Env | default "dev"}}
+func defaultValue(def, value any) any {
+	if value == nil {
+		return def
+	}
+	if rv := reflect.ValueOf(value); rv.IsZero() {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,129 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package render
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+)
+
+// Template is a parsed text/template or html/template with the helper
+// functions from Funcs available
+type Template struct {
+	name string
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+// New parses a text template
+func New(name, source string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(Funcs()).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return &Template{name: name, text: tmpl}, nil
+}
+
+// NewHTML parses an HTML template, whose output is escaped for the context
+// each value appears in
+func NewHTML(name, source string) (*Template, error) {
+	tmpl, err := htmltemplate.New(name).Funcs(Funcs()).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+	return &Template{name: name, html: tmpl}, nil
+}
+
+// ParseFile parses a template file, named after its base name. Files ending
+// in .html or .htm, optionally followed by .tmpl, are parsed as HTML.
+func ParseFile(path string) (*Template, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %q: %v", path, err)
+	}
+	name := filepath.Base(path)
+	if isHTML(path) {
+		return NewHTML(name, string(source))
+	}
+	return New(name, string(source))
+}
+
+// isHTML reports whether path names an HTML template
+func isHTML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(path, ".tmpl")))
+	return ext == ".html" || ext == ".htm"
+}
+
+// Name returns the template name
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Execute renders the template with data to w
+func (t *Template) Execute(w io.Writer, data any) error {
+	var err error
+	if t.html != nil {
+		err = t.html.Execute(w, data)
+	} else {
+		err = t.text.Execute(w, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render %s template: %w", t.name, err)
+	}
+	return nil
+}
+
+// Render renders the template with data to a string
+func (t *Template) Render(data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// WriteFile renders the template with data to a file, creating its directory.
+// The output is written to a temporary file first, so a failed render never
+// leaves a partial file behind.
+func (t *Template) WriteFile(path string, data any) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, buf.Bytes())
+}
+
+// String parses and renders a text template in one step, e.g. for file names
+// and notification subjects
+func String(source string, data any) (string, error) {
+	tmpl, err := New("string", source)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(data)
+}
+
+// HTML parses and renders an HTML template in one step
+func HTML(source string, data any) (string, error) {
+	tmpl, err := NewHTML("html", source)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(data)
+}
+
+// File parses and renders a template file in one step
+func File(path string, data any) (string, error) {
+	tmpl, err := ParseFile(path)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Render(data)
+}
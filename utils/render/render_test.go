@@ -0,0 +1,107 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	started := time.Date(2024, 6, 1, 15, 4, 5, 0, time.UTC)
+	data := map[string]any{
+		"Customer": "acme",
+		"Part":     7,
+		"Started":  started,
+		"Size":     int64(1536),
+		"Took":     92 * time.Second,
+		"Env":      "",
+		"Tags":     []string{"daily", "csv"},
+	}
+
+	tests := map[string]string{
+		`{{.Customer | upper}}_{{date "20060102" .Started}}_part{{pad 3 .Part}}.csv`: "ACME_20240601_part007.csv",
+		`{{bytes .Size}} in {{duration .Took}}`:                                      "1.5 KiB in 1m32s",
+		`{{bytes 512}} {{duration 90}} {{duration 0.25}}`:                            "512 B 1m30s 250ms",
+		`{{.Started | addDays -1 | date "2006-01-02"}}`:                              "2024-05-31",
+		`{{.Started | addMonths 1 | startOfMonth | date "2006-01-02T15:04"}}`:        "2024-07-01T00:00",
+		`{{.Started | add "-2h" | startOfDay | date "2006-01-02T15:04"}}`:            "2024-06-01T00:00",
+		`{{.Env | default "dev"}} {{.Customer | default "none"}}`:                    "dev acme",
+		`{{join "," .Tags}} {{replace "-" "_" "a-b"}} [{{trim "  x "}}]`:             "daily,csv a_b [x]",
+	}
+	for source, expected := range tests {
+		got, err := String(source, data)
+		if err != nil {
+			t.Errorf("String(%q) failed: %v", source, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("String(%q) = %q, expected %q", source, got, expected)
+		}
+	}
+
+	if _, err := String(`{{bytes "big"}}`, nil); err == nil {
+		t.Error("expected error for a non-integer size")
+	}
+	if _, err := String(`{{.Started | add "soon"}}`, data); err == nil {
+		t.Error("expected error for an invalid duration")
+	}
+	if _, err := String(`{{.Missing`, nil); err == nil || !strings.Contains(err.Error(), "failed to parse") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got, err := HTML(`<p title="{{.}}">{{.}}</p>`, `<b>"acme"</b>`)
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	if got != `<p title="&lt;b&gt;&#34;acme&#34;&lt;/b&gt;">&lt;b&gt;&#34;acme&#34;&lt;/b&gt;</p>` {
+		t.Errorf("HTML = %q", got)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	text := filepath.Join(dir, "banner.txt")
+	html := filepath.Join(dir, "report.html.tmpl")
+	os.WriteFile(text, []byte(`== {{upper .}} ==`), 0644)
+	os.WriteFile(html, []byte(`<h1>{{.}}</h1>`), 0644)
+
+	if got, err := File(text, "<acme>"); err != nil || got != "== <ACME> ==" {
+		t.Errorf("File(text) = %q, %v", got, err)
+	}
+	tmpl, err := ParseFile(html)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if tmpl.Name() != "report.html.tmpl" {
+		t.Errorf("Name() = %q", tmpl.Name())
+	}
+
+	out := filepath.Join(dir, "out", "report.html")
+	if err := tmpl.WriteFile(out, "<acme>"); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "<h1>&lt;acme&gt;</h1>" {
+		t.Errorf("written content = %q", data)
+	}
+
+	// A failed render must not replace the existing output
+	broken, _ := New("broken", `{{.Name.First}}`)
+	if err := broken.WriteFile(out, map[string]any{"Name": 1}); err == nil {
+		t.Error("expected render error")
+	}
+	if data, _ := os.ReadFile(out); string(data) != "<h1>&lt;acme&gt;</h1>" {
+		t.Errorf("output changed after failed render: %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(out))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files, have %d entries", len(entries))
+	}
+
+	if _, err := ParseFile(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("expected error for missing template file")
+	}
+}
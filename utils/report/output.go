@@ -0,0 +1,191 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/render"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// Format is a report output format
+type Format string
+
+// Supported formats
+const (
+	JSON Format = "json"
+	CSV  Format = "csv"
+	HTML Format = "html"
+)
+
+// FormatFromExt returns the format for a file name by its extension,
+// defaulting to JSON
+func FormatFromExt(name string) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV
+	case ".html", ".htm":
+		return HTML
+	default:
+		return JSON
+	}
+}
+
+// snapshot is the report as written, with the operations and totals
+type snapshot struct {
+	Title      string        `json:"title"`
+	RunID      string        `json:"run_id,omitempty"`
+	Status     string        `json:"status"`
+	Started    time.Time     `json:"started"`
+	Finished   time.Time     `json:"finished"`
+	Duration   time.Duration `json:"duration"`
+	Totals     Totals        `json:"totals"`
+	Operations []Operation   `json:"operations"`
+}
+
+func (r *Report) snapshot() snapshot {
+	totals := r.Totals()
+	operations := r.Operations()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := snapshot{
+		Title:      r.Title,
+		RunID:      r.RunID,
+		Status:     StatusSucceeded,
+		Started:    r.Started,
+		Finished:   r.Finished,
+		Totals:     totals,
+		Operations: operations,
+	}
+	if s.Finished.IsZero() {
+		s.Finished = time.Now()
+	}
+	if !s.Started.IsZero() {
+		s.Duration = s.Finished.Sub(s.Started)
+	}
+	if totals.Failed > 0 {
+		s.Status = StatusFailed
+	}
+	return s
+}
+
+// Write writes the report to w in the given format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case JSON:
+		return r.WriteJSON(w)
+	case CSV:
+		return r.WriteCSV(w)
+	case HTML:
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// WriteJSON writes the report, its totals and all operations as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.snapshot()); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+// csvHeader is the header row of CSV reports
+var csvHeader = []string{"operation", "kind", "status", "path", "action", "size", "checksum", "error"}
+
+// WriteCSV writes one row per item. Operations without items get a single
+// row with an empty path, so failures are never dropped.
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, op := range r.Operations() {
+		if len(op.Items) == 0 {
+			writer.Write([]string{op.Name, op.Kind, op.Status, "", "", "", "", op.Error})
+			continue
+		}
+		for _, item := range op.Items {
+			itemErr := item.Error
+			if itemErr == "" {
+				itemErr = op.Error
+			}
+			writer.Write([]string{op.Name, op.Kind, op.Status, item.Path, item.Action, strconv.FormatInt(item.Size, 10), item.Checksum, itemErr})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+	return nil
+}
+
+// WriteHTML writes the report rendered with DefaultHTMLTemplate
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r.snapshot())
+}
+
+// Save writes the report to a local file in the format given by its
+// extension, creating its directory
+func (r *Report) Save(path string) error {
+	var buf bytes.Buffer
+	if err := r.Write(&buf, FormatFromExt(path)); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write report %q: %v", path, err)
+	}
+	return nil
+}
+
+// Upload stores the report under key on a storage backend such as
+// s3helper.S3Helper, in the format given by the key's extension
+func (r *Report) Upload(ctx context.Context, b storage.Backend, key string) error {
+	var buf bytes.Buffer
+	if err := r.Write(&buf, FormatFromExt(key)); err != nil {
+		return err
+	}
+	if err := b.Put(ctx, key, &buf); err != nil {
+		return fmt.Errorf("failed to upload report: %w", err)
+	}
+	return nil
+}
+
+// DefaultHTMLTemplate renders a report snapshot as a standalone page
+const DefaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: Arial, sans-serif; font-size: 14px; color: #222;">
+<h2 style="color: {{if eq .Status "failed"}}#c62828{{else}}#2e7d32{{end}};">{{.Title}} {{.Status}}</h2>
+<p>{{if .RunID}}Run {{.RunID}}, started{{else}}Started{{end}} {{date "2006-01-02 15:04:05" .Started}}, took {{duration .Duration}}</p>
+<p>{{.Totals.Operations}} operation(s), {{.Totals.Failed}} failed, {{bytes .Totals.Bytes}} in total{{range $action, $count := .Totals.Items}}, {{$count}} {{$action}}{{end}}</p>
+{{range .Operations}}<h3>{{.Name}} <small>({{.Kind}}, {{.Status}}, {{duration .Duration}})</small></h3>
+{{if .Error}}<p style="color: #c62828;"><b>Error:</b> {{.Error}}</p>{{end}}
+{{if .Items}}<table cellpadding="6" border="1" style="border-collapse: collapse;">
+<tr><th>Path</th><th>Action</th><th>Size</th><th>Checksum</th><th>Error</th></tr>
+{{range .Items}}<tr><td>{{.Path}}</td><td>{{.Action}}</td><td>{{bytes .Size}}</td><td>{{.Checksum}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}</body>
+</html>
+`
+
+var htmlTemplate = mustHTML(DefaultHTMLTemplate)
+
+func mustHTML(source string) *render.Template {
+	tmpl, err := render.NewHTML("report", source)
+	if err != nil {
+		panic(err)
+	}
+	return tmpl
+}
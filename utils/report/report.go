@@ -0,0 +1,283 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package report
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/manifest"
+	"github.com/romisugianto/go-utils/utils/pipeline"
+	"github.com/romisugianto/go-utils/utils/s3helper"
+)
+
+// Operation statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Item actions recorded by the Add helpers
+const (
+	ActionCreated   = "created"
+	ActionProcessed = "processed"
+	ActionUploaded  = "uploaded"
+	ActionCopied    = "copied"
+	ActionSkipped   = "skipped"
+	ActionDeleted   = "deleted"
+	ActionFailed    = "failed"
+	ActionOutput    = "output"
+	ActionPending   = "pending"
+)
+
+// Report aggregates the results of the operations of one run, e.g. a split,
+// a housekeeping pass and an upload batch, for auditing. It is safe for
+// concurrent use.
+type Report struct {
+	Title    string
+	RunID    string
+	Started  time.Time
+	Finished time.Time
+
+	mu         sync.Mutex
+	operations []Operation
+}
+
+// Operation is the outcome of one operation
+type Operation struct {
+	Name string `json:"name"`
+	// Kind groups operations, e.g. "split", "housekeep", "upload" or "sync"
+	Kind string `json:"kind"`
+	// Status defaults to "failed" when Error is set and "succeeded" otherwise
+	Status   string        `json:"status"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Items    []Item        `json:"items"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Item is a file or object handled by an operation
+type Item struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Size     int64  `json:"size,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Totals summarizes all operations of a report
+type Totals struct {
+	Operations int `json:"operations"`
+	Failed     int `json:"failed"`
+	// Items counts the items by action
+	Items map[string]int `json:"items"`
+	Bytes int64          `json:"bytes"`
+}
+
+// New creates a report started now
+func New(title, runID string) *Report {
+	return &Report{Title: title, RunID: runID, Started: time.Now()}
+}
+
+// Add records an operation
+func (r *Report) Add(op Operation) {
+	if op.Status == "" {
+		op.Status = StatusSucceeded
+		if op.Error != "" {
+			op.Status = StatusFailed
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, op)
+}
+
+// Finish records the finish time. Reports written before Finish is called
+// use the time of writing.
+func (r *Report) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Finished = time.Now()
+}
+
+// Operations returns a copy of the recorded operations
+func (r *Report) Operations() []Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Operation(nil), r.operations...)
+}
+
+// Totals counts the operations, items by action and bytes of all items
+func (r *Report) Totals() Totals {
+	totals := Totals{Items: map[string]int{}}
+	for _, op := range r.Operations() {
+		totals.Operations++
+		if op.Status == StatusFailed {
+			totals.Failed++
+		}
+		for _, item := range op.Items {
+			totals.Items[item.Action]++
+			totals.Bytes += item.Size
+		}
+	}
+	return totals
+}
+
+// Succeeded reports whether no operation failed
+func (r *Report) Succeeded() bool {
+	return r.Totals().Failed == 0
+}
+
+// AddSplit records a file split into parts, e.g. by splitter.SplitFileByLines
+func (r *Report) AddSplit(name, source string, parts []string, err error) {
+	op := Operation{Name: name, Kind: "split", Items: []Item{fileItem(source, ActionProcessed)}}
+	for _, part := range parts {
+		op.Items = append(op.Items, fileItem(part, ActionCreated))
+	}
+	if err != nil {
+		op.Error = err.Error()
+	}
+	r.Add(op)
+}
+
+// AddHousekeep records files removed by housekeeping. Sizes must be taken
+// before deletion, so deleted maps each path to its size.
+func (r *Report) AddHousekeep(name string, deleted map[string]int64, err error) {
+	op := Operation{Name: name, Kind: "housekeep"}
+	for _, path := range sortedKeys(deleted) {
+		op.Items = append(op.Items, Item{Path: path, Action: ActionDeleted, Size: deleted[path]})
+	}
+	if err != nil {
+		op.Error = err.Error()
+	}
+	r.Add(op)
+}
+
+// AddBatch records the result of s3helper.UploadBatch. Sizes and checksums
+// are taken from the batch manifest when manifestPath is set.
+func (r *Report) AddBatch(name string, result *s3helper.BatchResult, manifestPath string) error {
+	var manifest map[string]s3helper.ManifestEntry
+	if manifestPath != "" {
+		var err error
+		if manifest, err = s3helper.LoadManifest(manifestPath); err != nil {
+			return fmt.Errorf("failed to load manifest: %w", err)
+		}
+	}
+
+	op := Operation{Name: name, Kind: "upload"}
+	for _, key := range result.Uploaded {
+		entry := manifest[key]
+		op.Items = append(op.Items, Item{Path: key, Action: ActionUploaded, Size: entry.Size, Checksum: entry.MD5})
+	}
+	for _, key := range result.Skipped {
+		entry := manifest[key]
+		op.Items = append(op.Items, Item{Path: key, Action: ActionSkipped, Size: entry.Size, Checksum: entry.MD5})
+	}
+	for _, key := range sortedKeys(result.Failed) {
+		op.Items = append(op.Items, Item{Path: key, Action: ActionFailed, Error: result.Failed[key].Error()})
+	}
+	if len(result.Failed) > 0 {
+		op.Error = fmt.Sprintf("%d of %d uploads failed", len(result.Failed), len(result.Uploaded)+len(result.Skipped)+len(result.Failed))
+	}
+	r.Add(op)
+	return nil
+}
+
+// manifestActions maps transfer manifest statuses to item actions
+var manifestActions = map[manifest.Status]string{
+	manifest.StatusComplete: ActionProcessed,
+	manifest.StatusSkipped:  ActionSkipped,
+	manifest.StatusFailed:   ActionFailed,
+	manifest.StatusPending:  ActionPending,
+}
+
+// AddManifest records the entries of a transfer manifest, e.g. from
+// s3helper.BatchManifest or a split, under the manifest's kind. Entries are
+// listed by key when they have one, so uploads show their destination.
+func (r *Report) AddManifest(name string, m *manifest.Manifest) {
+	op := Operation{Name: name, Kind: m.Kind, Started: m.Created}
+	failed := 0
+	for _, e := range m.Entries {
+		item := Item{Path: e.Key, Action: manifestActions[e.Status], Size: e.Size, Checksum: e.Checksum, Error: e.Error}
+		if item.Path == "" {
+			item.Path = e.Path
+		}
+		if item.Action == "" {
+			item.Action = string(e.Status)
+		}
+		if e.Status == manifest.StatusFailed {
+			failed++
+		}
+		op.Items = append(op.Items, item)
+	}
+	if failed > 0 {
+		op.Error = fmt.Sprintf("%d of %d entries failed", failed, len(m.Entries))
+	}
+	r.Add(op)
+}
+
+// AddSync records the result of s3helper.SyncRemote
+func (r *Report) AddSync(name string, result *s3helper.SyncResult, err error) {
+	op := Operation{Name: name, Kind: "sync"}
+	if result != nil {
+		for _, key := range result.Copied {
+			op.Items = append(op.Items, Item{Path: key, Action: ActionCopied})
+		}
+		for _, key := range result.Skipped {
+			op.Items = append(op.Items, Item{Path: key, Action: ActionSkipped})
+		}
+		for _, key := range result.Deleted {
+			op.Items = append(op.Items, Item{Path: key, Action: ActionDeleted})
+		}
+	}
+	if err != nil {
+		op.Error = err.Error()
+	}
+	r.Add(op)
+}
+
+// AddPipeline records each step of a pipeline run as an operation, with the
+// step outputs as items. The report takes the run ID of the pipeline when
+// it has none.
+func (r *Report) AddPipeline(report *pipeline.Report) {
+	r.mu.Lock()
+	if r.RunID == "" {
+		r.RunID = report.RunID
+	}
+	r.mu.Unlock()
+
+	for _, step := range report.Steps {
+		op := Operation{
+			Name:     step.Name,
+			Kind:     report.Pipeline,
+			Status:   string(step.Status),
+			Started:  step.Started,
+			Duration: step.Duration,
+			Error:    step.Error,
+		}
+		for _, output := range step.Outputs {
+			op.Items = append(op.Items, fileItem(output, ActionOutput))
+		}
+		r.Add(op)
+	}
+}
+
+// fileItem returns an item for a local file, with its size when it exists
+func fileItem(path, action string) Item {
+	item := Item{Path: path, Action: action}
+	if info, err := os.Stat(path); err == nil {
+		item.Size = info.Size()
+	}
+	return item
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
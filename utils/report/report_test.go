@@ -0,0 +1,195 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/manifest"
+	"github.com/romisugianto/go-utils/utils/pipeline"
+	"github.com/romisugianto/go-utils/utils/s3helper"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// newTestReport builds a report with one operation of each kind
+func newTestReport(t *testing.T) *Report {
+	t.Helper()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "orders.csv")
+	parts := []string{filepath.Join(dir, "orders_part1.csv"), filepath.Join(dir, "orders_part2.csv")}
+	os.WriteFile(source, []byte("0123456789"), 0644)
+	os.WriteFile(parts[0], []byte("01234"), 0644)
+	os.WriteFile(parts[1], []byte("56789"), 0644)
+
+	manifest := filepath.Join(dir, "manifest.jsonl")
+	os.WriteFile(manifest, []byte(`{"key":"acme/orders_part1.csv","file_path":"x","size":5,"md5":"abc"}`+"\n"), 0644)
+
+	r := New("Nightly delivery", "run-1")
+	r.AddSplit("split orders", source, parts, nil)
+	r.AddHousekeep("purge archive", map[string]int64{"/archive/b.csv": 20, "/archive/a.csv": 10}, nil)
+	err := r.AddBatch("upload", &s3helper.BatchResult{
+		Uploaded: []string{"acme/orders_part1.csv"},
+		Skipped:  []string{"acme/orders_part2.csv"},
+		Failed:   map[string]error{"acme/orders.csv": errors.New("access denied")},
+	}, manifest)
+	if err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+	r.AddSync("mirror", &s3helper.SyncResult{Copied: []string{"acme/orders_part1.csv"}}, nil)
+	r.AddPipeline(&pipeline.Report{
+		Pipeline: "ingest",
+		RunID:    "pipeline-run",
+		Steps: []pipeline.StepReport{
+			{Name: "compress", Status: pipeline.StatusSucceeded, Duration: time.Second, Outputs: []string{parts[0]}},
+		},
+	})
+	r.Add(Operation{Name: "notify", Kind: "email", Error: "smtp timeout"})
+	return r
+}
+
+func TestTotals(t *testing.T) {
+	r := newTestReport(t)
+	ops := r.Operations()
+	if len(ops) != 6 {
+		t.Fatalf("expected 6 operations, got %d", len(ops))
+	}
+	if ops[1].Items[0].Path != "/archive/a.csv" {
+		t.Errorf("expected housekeeping items sorted by path, got %+v", ops[1].Items)
+	}
+	if ops[2].Status != StatusFailed || ops[2].Items[0].Checksum != "abc" || ops[2].Items[0].Size != 5 {
+		t.Errorf("unexpected batch operation %+v", ops[2])
+	}
+	if ops[4].Kind != "ingest" || ops[4].Status != StatusSucceeded || ops[4].Items[0].Size != 5 {
+		t.Errorf("unexpected pipeline operation %+v", ops[4])
+	}
+	if ops[5].Status != StatusFailed {
+		t.Errorf("expected failed status from Error, got %q", ops[5].Status)
+	}
+	if r.RunID != "run-1" {
+		t.Errorf("RunID = %q, expected the report's own run ID", r.RunID)
+	}
+
+	totals := r.Totals()
+	if totals.Operations != 6 || totals.Failed != 2 {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+	if totals.Items[ActionCreated] != 2 || totals.Items[ActionDeleted] != 2 || totals.Items[ActionFailed] != 1 || totals.Items[ActionCopied] != 1 {
+		t.Errorf("unexpected item counts %v", totals.Items)
+	}
+	// 10 + 5 + 5 split, 30 deleted, 5 uploaded, 5 pipeline output
+	if totals.Bytes != 60 {
+		t.Errorf("Bytes = %d, expected 60", totals.Bytes)
+	}
+	if r.Succeeded() {
+		t.Error("expected report with failures not to succeed")
+	}
+}
+
+func TestAddManifest(t *testing.T) {
+	m := manifest.New("upload")
+	m.Set(manifest.Entry{Path: "out/a.csv", Key: "acme/a.csv", Size: 10, Checksum: "md5:abc", Status: manifest.StatusComplete})
+	m.Set(manifest.Entry{Path: "out/b.csv", Size: 20, Status: manifest.StatusFailed, Error: "access denied"})
+	m.Set(manifest.Entry{Path: "out/c.csv", Size: 30, Status: manifest.StatusPending})
+
+	r := New("Reconciliation", "run-2")
+	r.AddManifest("upload batch", m)
+	op := r.Operations()[0]
+	if op.Kind != "upload" || op.Status != StatusFailed || op.Error != "1 of 3 entries failed" {
+		t.Errorf("unexpected operation %+v", op)
+	}
+	if op.Items[0].Path != "acme/a.csv" || op.Items[0].Action != ActionProcessed || op.Items[0].Checksum != "md5:abc" {
+		t.Errorf("unexpected item %+v", op.Items[0])
+	}
+	if op.Items[1].Path != "out/b.csv" || op.Items[1].Error != "access denied" || op.Items[2].Action != ActionPending {
+		t.Errorf("unexpected items %+v", op.Items[1:])
+	}
+	if totals := r.Totals(); totals.Bytes != 60 {
+		t.Errorf("Bytes = %d, expected 60", totals.Bytes)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	r := newTestReport(t)
+	r.Finish()
+	dir := t.TempDir()
+
+	for _, name := range []string{"report.json", "report.csv", "out/report.html"} {
+		if err := r.Save(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("Save(%q) failed: %v", name, err)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "report.json"))
+	var decoded struct {
+		Title      string      `json:"title"`
+		Status     string      `json:"status"`
+		Totals     Totals      `json:"totals"`
+		Operations []Operation `json:"operations"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	if decoded.Title != "Nightly delivery" || decoded.Status != StatusFailed || decoded.Totals.Operations != 6 || len(decoded.Operations) != 6 {
+		t.Errorf("unexpected JSON report %+v", decoded)
+	}
+
+	file, _ := os.Open(filepath.Join(dir, "report.csv"))
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV report: %v", err)
+	}
+	// header + 3 split + 2 housekeep + 3 batch + 1 sync + 1 pipeline + 1 notify
+	if len(rows) != 12 || strings.Join(rows[0], ",") != "operation,kind,status,path,action,size,checksum,error" {
+		t.Fatalf("unexpected CSV rows %v", rows)
+	}
+	if last := rows[len(rows)-1]; last[0] != "notify" || last[3] != "" || last[7] != "smtp timeout" {
+		t.Errorf("expected a row for an operation without items, got %v", last)
+	}
+
+	html, _ := os.ReadFile(filepath.Join(dir, "out", "report.html"))
+	for _, expected := range []string{"Nightly delivery failed", "Run run-1", "split orders", "access denied", "6 operation(s), 2 failed"} {
+		if !strings.Contains(string(html), expected) {
+			t.Errorf("HTML report missing %q", expected)
+		}
+	}
+
+	if err := r.Write(os.Stdout, "xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestUpload(t *testing.T) {
+	r := New("Nightly delivery", "")
+	r.AddPipeline(&pipeline.Report{Pipeline: "ingest", RunID: "pipeline-run"})
+	backend := &storage.Local{Root: t.TempDir()}
+
+	if err := r.Upload(context.Background(), backend, "reports/run.csv"); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	info, err := backend.Stat(context.Background(), "reports/run.csv")
+	if err != nil || info.Size == 0 {
+		t.Errorf("report not stored: %+v, %v", info, err)
+	}
+	if r.RunID != "pipeline-run" {
+		t.Errorf("RunID = %q, expected it to be taken from the pipeline", r.RunID)
+	}
+	if !r.Succeeded() {
+		t.Error("expected empty report to succeed")
+	}
+}
+
+func TestFormatFromExt(t *testing.T) {
+	tests := map[string]Format{"a.json": JSON, "a.CSV": CSV, "a.htm": HTML, "a.html": HTML, "a": JSON}
+	for name, expected := range tests {
+		if got := FormatFromExt(name); got != expected {
+			t.Errorf("FormatFromExt(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
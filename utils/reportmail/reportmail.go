@@ -0,0 +1,242 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package reportmail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/notifier"
+	"github.com/romisugianto/go-utils/utils/pipeline"
+)
+
+// Summary is the data the report templates are rendered with
+type Summary struct {
+	// Title defaults to "<Pipeline> run <RunID>"
+	Title    string
+	Pipeline string
+	RunID    string
+	// Status is "succeeded" or "failed"
+	Status   string
+	Started  time.Time
+	Duration time.Duration
+	Error    string
+	Steps    []StepSummary
+
+	Split     SplitStats
+	Housekeep HousekeepStats
+	Transfers TransferStats
+
+	// Fields are extra labelled values listed after the statistics
+	Fields []notifier.Field
+	// Attachments are file paths attached to the email
+	Attachments []string
+}
+
+// StepSummary is the outcome of one pipeline step
+type StepSummary struct {
+	Name     string
+	Status   string
+	Attempts int
+	Files    int
+	Duration time.Duration
+	Error    string
+}
+
+// SplitStats counts the files handled by the splitter
+type SplitStats struct {
+	SourceFiles int
+	Parts       int
+}
+
+// HousekeepStats counts the files removed by housekeeping
+type HousekeepStats struct {
+	Deleted int
+	// Freed is the bytes released by the deleted files
+	Freed int64
+}
+
+// TransferStats counts S3 transfers
+type TransferStats struct {
+	Uploaded   int
+	Downloaded int
+	Skipped    int
+	Failed     int
+	Bytes      int64
+}
+
+// Succeeded reports whether the run succeeded
+func (s *Summary) Succeeded() bool {
+	return s.Status == string(pipeline.StatusSucceeded)
+}
+
+// FromPipeline builds a summary from a pipeline report. Split and upload
+// statistics are taken from the steps named "split" and "upload" (as created by
+// pipeline.SplitStep and pipeline.UploadStep); housekeeping counts are not
+// recorded by the pipeline and can be filled in by the caller.
+func FromPipeline(report *pipeline.Report) *Summary {
+	s := &Summary{
+		Pipeline: report.Pipeline,
+		RunID:    report.RunID,
+		Status:   string(report.Status),
+		Started:  report.Started,
+		Duration: report.Duration,
+		Error:    report.Error,
+	}
+	for _, step := range report.Steps {
+		s.Steps = append(s.Steps, StepSummary{
+			Name:     step.Name,
+			Status:   string(step.Status),
+			Attempts: step.Attempts,
+			Files:    len(step.Outputs),
+			Duration: step.Duration,
+			Error:    step.Error,
+		})
+		if step.Status == pipeline.StatusFailed {
+			continue
+		}
+		switch step.Name {
+		case "split":
+			s.Split.SourceFiles += len(step.Inputs)
+			s.Split.Parts += len(step.Outputs)
+		case "upload":
+			s.Transfers.Uploaded += len(step.Outputs)
+			for _, file := range step.Outputs {
+				if info, err := os.Stat(file); err == nil {
+					s.Transfers.Bytes += info.Size()
+				}
+			}
+		}
+	}
+	return s
+}
+
+// Options configures a Mailer
+type Options struct {
+	// SubjectTemplate and TextTemplate are text/template sources and
+	// HTMLTemplate an html/template source, all executed with the *Summary.
+	// Empty values use the defaults; set HTMLTemplate to "-" to send text only.
+	SubjectTemplate string
+	TextTemplate    string
+	HTMLTemplate    string
+	// OnlyFailures skips successful runs
+	OnlyFailures bool
+	// AttachLog attaches the logger's current log file to failure reports
+	AttachLog bool
+}
+
+// Mailer renders run summaries and emails them through an SMTPNotifier
+type Mailer struct {
+	logger  *logger.Logger
+	smtp    *notifier.SMTPNotifier
+	opts    Options
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// NewMailer parses the templates and creates a Mailer that sends through smtp,
+// whose recipients form the distribution list. A nil opts uses the defaults.
+func NewMailer(log *logger.Logger, smtp *notifier.SMTPNotifier, opts *Options) (*Mailer, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if smtp == nil {
+		return nil, fmt.Errorf("smtp notifier cannot be nil")
+	}
+	m := &Mailer{logger: log, smtp: smtp}
+	if opts != nil {
+		m.opts = *opts
+	}
+
+	var err error
+	if m.subject, err = template.New("subject").Funcs(funcs).Parse(orDefault(m.opts.SubjectTemplate, DefaultSubjectTemplate)); err != nil {
+		return nil, fmt.Errorf("failed to parse subject template: %w", err)
+	}
+	if m.text, err = template.New("text").Funcs(funcs).Parse(orDefault(m.opts.TextTemplate, DefaultTextTemplate)); err != nil {
+		return nil, fmt.Errorf("failed to parse text template: %w", err)
+	}
+	if m.opts.HTMLTemplate != "-" {
+		if m.html, err = htmltemplate.New("html").Funcs(funcs).Parse(orDefault(m.opts.HTMLTemplate, DefaultHTMLTemplate)); err != nil {
+			return nil, fmt.Errorf("failed to parse html template: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Render renders the email for a summary without sending it
+func (m *Mailer) Render(summary *Summary) (notifier.Email, error) {
+	s := *summary
+	if s.Title == "" {
+		s.Title = fmt.Sprintf("%s run %s", s.Pipeline, s.RunID)
+	}
+	email := notifier.Email{Attachments: s.Attachments}
+	if m.opts.AttachLog && !s.Succeeded() && m.logger.GetLogFilePath() != "" {
+		email.Attachments = append(append([]string{}, email.Attachments...), m.logger.GetLogFilePath())
+	}
+
+	var buf bytes.Buffer
+	if err := m.subject.Execute(&buf, &s); err != nil {
+		return email, fmt.Errorf("failed to render subject template: %w", err)
+	}
+	email.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := m.text.Execute(&buf, &s); err != nil {
+		return email, fmt.Errorf("failed to render text template: %w", err)
+	}
+	email.Text = buf.String()
+
+	if m.html != nil {
+		buf.Reset()
+		if err := m.html.Execute(&buf, &s); err != nil {
+			return email, fmt.Errorf("failed to render html template: %w", err)
+		}
+		email.HTML = buf.String()
+	}
+	return email, nil
+}
+
+// Send renders the summary and emails it to the distribution list
+func (m *Mailer) Send(ctx context.Context, summary *Summary) error {
+	if m.opts.OnlyFailures && summary.Succeeded() {
+		return nil
+	}
+	email, err := m.Render(summary)
+	if err != nil {
+		return err
+	}
+	if err := m.smtp.Send(ctx, email); err != nil {
+		return err
+	}
+	m.logger.Info("Sent report for %s run %s to %d recipient(s)", summary.Pipeline, summary.RunID, len(m.smtp.To)+len(m.smtp.Cc))
+	return nil
+}
+
+// PipelineHook returns a function for pipeline.Options.OnFinish that emails a
+// summary of every run. enrich, if not nil, can add statistics the pipeline
+// does not record. Send failures are logged rather than failing the run.
+func (m *Mailer) PipelineHook(enrich func(summary *Summary)) func(ctx context.Context, report *pipeline.Report) {
+	return func(ctx context.Context, report *pipeline.Report) {
+		summary := FromPipeline(report)
+		if enrich != nil {
+			enrich(summary)
+		}
+		if err := m.Send(ctx, summary); err != nil {
+			m.logger.Error("Failed to send report for %s run %s: %v", report.Pipeline, report.RunID, err)
+		}
+	}
+}
+
+func orDefault(source, fallback string) string {
+	if source == "" {
+		return fallback
+	}
+	return source
+}
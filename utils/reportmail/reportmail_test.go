@@ -0,0 +1,146 @@
+package reportmail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/notifier"
+	"github.com/romisugianto/go-utils/utils/pipeline"
+)
+
+func newTestMailer(t *testing.T, smtp *notifier.SMTPNotifier, opts *Options) *Mailer {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("reportmail_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	if smtp == nil {
+		smtp = &notifier.SMTPNotifier{Host: "localhost", From: "batch@example.com", To: []string{"ops@example.com"}}
+	}
+	m, err := NewMailer(testLogger, smtp, opts)
+	if err != nil {
+		t.Fatalf("NewMailer failed: %v", err)
+	}
+	return m
+}
+
+func testReport(t *testing.T) *pipeline.Report {
+	dir := t.TempDir()
+	parts := []string{filepath.Join(dir, "orders_1.csv"), filepath.Join(dir, "orders_2.csv")}
+	for _, part := range parts {
+		os.WriteFile(part, make([]byte, 1024), 0644)
+	}
+	return &pipeline.Report{
+		Pipeline: "orders",
+		RunID:    "orders.csv-1700000000",
+		Status:   pipeline.StatusFailed,
+		Started:  time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC),
+		Duration: 95 * time.Second,
+		Error:    "step housekeep: permission denied",
+		Steps: []pipeline.StepReport{
+			{Name: "split", Status: pipeline.StatusSucceeded, Attempts: 1, Inputs: []string{"orders.csv"}, Outputs: parts},
+			{Name: "upload", Status: pipeline.StatusResumed, Attempts: 2, Inputs: parts, Outputs: parts},
+			{Name: "housekeep", Status: pipeline.StatusFailed, Attempts: 1, Error: "permission denied"},
+		},
+	}
+}
+
+func TestFromPipeline(t *testing.T) {
+	s := FromPipeline(testReport(t))
+	if s.Split.SourceFiles != 1 || s.Split.Parts != 2 {
+		t.Errorf("split stats = %+v", s.Split)
+	}
+	if s.Transfers.Uploaded != 2 || s.Transfers.Bytes != 2048 {
+		t.Errorf("transfer stats = %+v", s.Transfers)
+	}
+	if len(s.Steps) != 3 || s.Steps[2].Error != "permission denied" || s.Succeeded() {
+		t.Errorf("unexpected summary %+v", s)
+	}
+}
+
+func TestRender(t *testing.T) {
+	m := newTestMailer(t, nil, &Options{AttachLog: true})
+	s := FromPipeline(testReport(t))
+	s.Housekeep = HousekeepStats{Deleted: 3, Freed: 3 << 20}
+	s.Fields = []notifier.Field{{Name: "Customer", Value: "<ACME>"}}
+
+	email, err := m.Render(s)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if email.Subject != "[failed] orders run orders.csv-1700000000" {
+		t.Errorf("subject = %q", email.Subject)
+	}
+	for _, want := range []string{
+		"orders run orders.csv-1700000000 failed in 1m35s",
+		"Split: 1 file(s) into 2 part(s)",
+		"Housekeeping: 3 file(s) deleted, 3.0 MiB freed",
+		"S3: 2 uploaded",
+		"2.0 KiB transferred",
+		"Customer: <ACME>",
+		"  - housekeep: failed (0 files, 1 attempt(s), 0s) permission denied",
+	} {
+		if !strings.Contains(email.Text, want) {
+			t.Errorf("text body missing %q:\n%s", want, email.Text)
+		}
+	}
+	if !strings.Contains(email.HTML, "&lt;ACME&gt;") || !strings.Contains(email.HTML, "#c62828") {
+		t.Errorf("unexpected html body:\n%s", email.HTML)
+	}
+	if len(email.Attachments) != 1 || email.Attachments[0] != m.logger.GetLogFilePath() {
+		t.Errorf("expected the log file to be attached to a failure report, got %v", email.Attachments)
+	}
+
+	textOnly := newTestMailer(t, nil, &Options{HTMLTemplate: "-", SubjectTemplate: "{{.Pipeline}}\n{{.Status}}"})
+	email, _ = textOnly.Render(s)
+	if email.HTML != "" || email.Subject != "orders failed" {
+		t.Errorf("unexpected email %+v", email)
+	}
+}
+
+func TestNewMailerValidation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("reportmail_test")
+	defer testLogger.Close()
+	smtp := &notifier.SMTPNotifier{}
+
+	if _, err := NewMailer(nil, smtp, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+	if _, err := NewMailer(testLogger, nil, nil); err == nil {
+		t.Error("expected error for nil smtp notifier")
+	}
+	for _, opts := range []*Options{{SubjectTemplate: "{{.Title"}, {TextTemplate: "{{end}}"}, {HTMLTemplate: "{{range}}"}} {
+		if _, err := NewMailer(testLogger, smtp, opts); err == nil {
+			t.Errorf("expected template error for %+v", opts)
+		}
+	}
+}
+
+func TestPipelineHook(t *testing.T) {
+	// Nothing listens on the port, so sending fails
+	smtp := &notifier.SMTPNotifier{Host: "127.0.0.1", Port: 1, From: "batch@example.com", To: []string{"ops@example.com"}, Timeout: time.Second}
+	m := newTestMailer(t, smtp, &Options{OnlyFailures: true})
+
+	succeeded := &Summary{Pipeline: "orders", Status: string(pipeline.StatusSucceeded)}
+	if err := m.Send(context.Background(), succeeded); err != nil {
+		t.Errorf("expected successful run to be skipped, got %v", err)
+	}
+	if err := m.Send(context.Background(), FromPipeline(testReport(t))); err == nil {
+		t.Error("expected send error for failed run")
+	}
+
+	var enriched bool
+	hook := m.PipelineHook(func(s *Summary) {
+		enriched = true
+		s.Housekeep.Deleted = 4
+	})
+	// Send failures are logged, not returned
+	hook(context.Background(), testReport(t))
+	if !enriched {
+		t.Error("expected enrich to be called")
+	}
+}
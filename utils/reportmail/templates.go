@@ -0,0 +1,45 @@
+package reportmail
+
+import "github.com/romisugianto/go-utils/utils/render"
+
+// Default templates used when the corresponding option is empty
+const (
+	DefaultSubjectTemplate = `[{{.Status}}] {{.Title}}`
+
+	DefaultTextTemplate = `{{.Title}} {{.Status}} in {{duration .Duration}}
+Started: {{.Started.Format "2006-01-02 15:04:05"}}
+{{if .Error}}Error: {{.Error}}
+{{end}}
+Split: {{.Split.SourceFiles}} file(s) into {{.Split.Parts}} part(s)
+Housekeeping: {{.Housekeep.Deleted}} file(s) deleted, {{bytes .Housekeep.Freed}} freed
+S3: {{.Transfers.Uploaded}} uploaded, {{.Transfers.Downloaded}} downloaded, {{.Transfers.Skipped}} skipped, {{.Transfers.Failed}} failed, {{bytes .Transfers.Bytes}} transferred
+{{range .Fields}}{{.Name}}: {{.Value}}
+{{end}}{{if .Steps}}
+Steps:
+{{range .Steps}}  - {{.Name}}: {{.Status}} ({{.Files}} files, {{.Attempts}} attempt(s), {{duration .Duration}}){{if .Error}} {{.Error}}{{end}}
+{{end}}{{end}}`
+
+	DefaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; font-size: 14px; color: #222;">
+<h2 style="color: {{if .Succeeded}}#2e7d32{{else}}#c62828{{end}};">{{.Title}} {{.Status}}</h2>
+<p>Started {{.Started.Format "2006-01-02 15:04:05"}}, took {{duration .Duration}}</p>
+{{if .Error}}<p style="color: #c62828;"><b>Error:</b> {{.Error}}</p>{{end}}
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr><td><b>Split</b></td><td>{{.Split.SourceFiles}} file(s) into {{.Split.Parts}} part(s)</td></tr>
+<tr><td><b>Housekeeping</b></td><td>{{.Housekeep.Deleted}} file(s) deleted, {{bytes .Housekeep.Freed}} freed</td></tr>
+<tr><td><b>S3</b></td><td>{{.Transfers.Uploaded}} uploaded, {{.Transfers.Downloaded}} downloaded, {{.Transfers.Skipped}} skipped, {{.Transfers.Failed}} failed, {{bytes .Transfers.Bytes}} transferred</td></tr>
+{{range .Fields}}<tr><td><b>{{.Name}}</b></td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{if .Steps}}<h3>Steps</h3>
+<table cellpadding="6" border="1" style="border-collapse: collapse;">
+<tr><th>Step</th><th>Status</th><th>Files</th><th>Attempts</th><th>Duration</th><th>Error</th></tr>
+{{range .Steps}}<tr><td>{{.Name}}</td><td>{{.Status}}</td><td>{{.Files}}</td><td>{{.Attempts}}</td><td>{{duration .Duration}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`
+)
+
+// funcs are the helpers available to all templates
+var funcs = render.Funcs()
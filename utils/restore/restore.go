@@ -0,0 +1,282 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package restore
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/backup"
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/pathutil"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+// Options selects the files to restore
+type Options struct {
+	// Include limits the restore to files matching at least one glob
+	// (path.Match syntax, matched against the relative path and the base name)
+	Include []string
+	// Exclude skips matching files and files below matching directories
+	Exclude []string
+	// Overwrite replaces existing files; otherwise they fail the restore
+	Overwrite bool
+}
+
+// Result reports a finished restore
+type Result struct {
+	// Files lists the restored files in archive order
+	Files    []backup.File
+	Skipped  int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Restorer restores backups written by the backup package
+type Restorer struct {
+	backend storage.Backend
+	logger  *logger.Logger
+}
+
+// NewRestorer creates a Restorer reading from backend
+func NewRestorer(log *logger.Logger, backend storage.Backend) (*Restorer, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if backend == nil {
+		return nil, fmt.Errorf("backend cannot be nil")
+	}
+	return &Restorer{backend: backend, logger: log}, nil
+}
+
+// Run restores the backup described by the manifest at manifestKey into
+// target, e.g. backup.Backup.ManifestKey(id)
+func (r *Restorer) Run(ctx context.Context, manifestKey, target string, opts *Options) (*Result, error) {
+	m, err := backup.ReadManifest(ctx, r.backend, manifestKey)
+	if err != nil {
+		return nil, err
+	}
+	return r.Restore(ctx, m, target, opts)
+}
+
+// Restore streams the parts of m in order, verifying each against its size
+// and checksum, and extracts the selected files into target. Every file is
+// written to a temporary name, compared with its checksum in the manifest
+// and only then renamed into place, with its mode and modification time.
+// Reading stops once the selected files are restored, so a selective
+// restore skips the remaining parts.
+func (r *Restorer) Restore(ctx context.Context, m *backup.Manifest, target string, opts *Options) (*Result, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if err := validatePatterns(o); err != nil {
+		return nil, err
+	}
+	if _, err := checksum.NewHash(m.Algorithm); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]backup.File)
+	result := &Result{}
+	for _, f := range m.Files {
+		if o.selected(f.Path) {
+			wanted[f.Path] = f
+		} else {
+			result.Skipped++
+		}
+	}
+	r.logger.Info("Restoring %d of %d files of backup %s/%s to %s", len(wanted), len(m.Files), m.Name, m.ID, target)
+	start := time.Now()
+
+	stream := &partReader{ctx: ctx, backend: r.backend, parts: m.Parts, algo: m.Algorithm}
+	defer stream.Close()
+	zr, err := compress.NewReader(stream, m.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore backup %s: %w", m.ID, err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for len(wanted) > 0 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to read backup %s: %w", m.ID, err)
+		}
+		file, ok := wanted[header.Name]
+		if !ok {
+			continue
+		}
+		if err := r.restoreFile(tr, file, target, o, m.Algorithm); err != nil {
+			return result, err
+		}
+		delete(wanted, header.Name)
+		result.Files = append(result.Files, file)
+		result.Bytes += file.Size
+	}
+	for name := range wanted {
+		return result, fmt.Errorf("file %s of backup %s is missing from the archive", name, m.ID)
+	}
+
+	result.Duration = time.Since(start)
+	r.logger.Info("Restored %d files (%s) from backup %s/%s in %s",
+		len(result.Files), humanize.Bytes(result.Bytes), m.Name, m.ID, result.Duration.Round(time.Millisecond))
+	return result, nil
+}
+
+// restoreFile writes one archived file below target and verifies it
+func (r *Restorer) restoreFile(src io.Reader, file backup.File, target string, o Options, algo checksum.Algorithm) error {
+	dest, err := pathutil.SafeJoin(target, file.Path)
+	if err != nil {
+		return fmt.Errorf("unsafe path %q in backup: %w", file.Path, err)
+	}
+	if !o.Overwrite {
+		if _, err := os.Lstat(dest); err == nil {
+			return fmt.Errorf("failed to restore %s: %w", dest, fs.ErrExist)
+		}
+	}
+
+	out, err := atomicfile.Create(dest, file.Mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+
+	h, _ := checksum.NewHash(algo)
+	n, err := io.Copy(io.MultiWriter(out, h), src)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", dest, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); n != file.Size || sum != file.Checksum {
+		return fmt.Errorf("%w for %s: expected %d bytes with %s %s, got %d bytes with %s",
+			checksum.ErrMismatch, file.Path, file.Size, algo, file.Checksum, n, sum)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(dest, file.ModTime, file.ModTime); err != nil {
+		r.logger.Warning("Failed to set modification time on %s: %v", dest, err)
+	}
+	return nil
+}
+
+// selected reports whether a file passes the include and exclude patterns,
+// also excluding files below an excluded directory
+func (o Options) selected(name string) bool {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if matchAny(dir, o.Exclude) {
+			return false
+		}
+	}
+	return !matchAny(name, o.Exclude) && (len(o.Include) == 0 || matchAny(name, o.Include))
+}
+
+// matchAny reports whether the relative path or its base name matches any pattern
+func matchAny(relPath string, patterns []string) bool {
+	base := path.Base(relPath)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatterns checks that all glob patterns are well-formed
+func validatePatterns(o Options) error {
+	for _, pattern := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// partReader reads the parts of a backup as one stream, downloading them
+// one at a time and verifying each when it has been read completely
+type partReader struct {
+	ctx     context.Context
+	backend storage.Backend
+	parts   []backup.Part
+	algo    checksum.Algorithm
+	current io.ReadCloser
+	hash    hash.Hash
+	size    int64
+}
+
+func (p *partReader) Read(b []byte) (int, error) {
+	for {
+		if p.current == nil {
+			if len(p.parts) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := p.backend.Get(p.ctx, p.parts[0].Key)
+			if err != nil {
+				return 0, fmt.Errorf("failed to download part %s: %w", p.parts[0].Key, err)
+			}
+			p.current = rc
+			p.hash, _ = checksum.NewHash(p.algo)
+			p.size = 0
+		}
+
+		n, err := p.current.Read(b)
+		p.hash.Write(b[:n])
+		p.size += int64(n)
+		if err == io.EOF {
+			if verr := p.finishPart(); verr != nil {
+				return n, verr
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			return n, fmt.Errorf("failed to read part %s: %w", p.parts[0].Key, err)
+		}
+		return n, nil
+	}
+}
+
+// finishPart verifies the current part and moves on to the next
+func (p *partReader) finishPart() error {
+	part := p.parts[0]
+	p.current.Close()
+	p.current = nil
+	p.parts = p.parts[1:]
+	if sum := hex.EncodeToString(p.hash.Sum(nil)); p.size != part.Size || sum != part.Checksum {
+		return fmt.Errorf("%w for part %s: expected %d bytes with %s %s, got %d bytes with %s",
+			checksum.ErrMismatch, part.Key, part.Size, p.algo, part.Checksum, p.size, sum)
+	}
+	return nil
+}
+
+// Close closes the part being read, if any
+func (p *partReader) Close() error {
+	if p.current == nil {
+		return nil
+	}
+	err := p.current.Close()
+	p.current = nil
+	return err
+}
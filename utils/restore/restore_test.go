@@ -0,0 +1,190 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/backup"
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/compress"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+var testFiles = map[string]string{
+	"orders.csv":          strings.Repeat("1,alpha,100\n", 400),
+	"sub/invoices.csv":    strings.Repeat("2,beta,200\n", 300),
+	"sub/deep/notes.txt":  "remember the milk",
+	"logs/2024/app.log":   strings.Repeat("INFO started\n", 200),
+	"logs/2024/empty.log": "",
+}
+
+func newLogger(t *testing.T) *logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger("restore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return log
+}
+
+// setup backs up testFiles and returns the backend root, the manifest and
+// a restorer
+func setup(t *testing.T, opts backup.Options) (string, *backup.Backup, *backup.Manifest, *Restorer) {
+	t.Helper()
+	source := t.TempDir()
+	modTime := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	for name, content := range testFiles {
+		p := filepath.Join(source, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(p, modTime, modTime)
+	}
+
+	root := t.TempDir()
+	backend := &storage.Local{Root: root}
+	log := newLogger(t)
+	opts.Name = "exports"
+	b, err := backup.NewBackup(log, backend, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := b.Run(context.Background(), source)
+	if err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	r, err := NewRestorer(log, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, b, m, r
+}
+
+func listTree(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(root, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func TestRestore(t *testing.T) {
+	for _, format := range []compress.Format{compress.Zstd, compress.Gzip, compress.None} {
+		t.Run(string(format), func(t *testing.T) {
+			_, b, m, r := setup(t, backup.Options{Compression: format, PartSize: 100})
+			if len(m.Parts) < 2 {
+				t.Fatalf("expected several parts, got %d", len(m.Parts))
+			}
+
+			target := filepath.Join(t.TempDir(), "restored")
+			result, err := r.Run(context.Background(), b.ManifestKey(m.ID), target, nil)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if len(result.Files) != len(testFiles) || result.Skipped != 0 {
+				t.Errorf("unexpected result %+v", result)
+			}
+			for name, content := range testFiles {
+				p := filepath.Join(target, filepath.FromSlash(name))
+				got, err := os.ReadFile(p)
+				if err != nil || string(got) != content {
+					t.Errorf("%s = %d bytes, %v", name, len(got), err)
+				}
+				info, _ := os.Stat(p)
+				if info.Mode().Perm() != 0640 || !info.ModTime().Equal(time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)) {
+					t.Errorf("%s has mode %v and time %v", name, info.Mode(), info.ModTime())
+				}
+			}
+		})
+	}
+}
+
+func TestSelectiveRestore(t *testing.T) {
+	_, _, m, r := setup(t, backup.Options{})
+
+	target := t.TempDir()
+	result, err := r.Restore(context.Background(), m, target, &Options{Include: []string{"*.csv", "*.txt"}, Exclude: []string{"deep"}})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := strings.Join(listTree(t, target), ","); got != "orders.csv,sub/invoices.csv" {
+		t.Errorf("restored %s", got)
+	}
+	if result.Skipped != 3 || result.Bytes != int64(len(testFiles["orders.csv"])+len(testFiles["sub/invoices.csv"])) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	if _, err := r.Restore(context.Background(), m, target, &Options{Include: []string{"orders.csv"}}); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected ErrExist without Overwrite, got %v", err)
+	}
+	os.WriteFile(filepath.Join(target, "orders.csv"), []byte("stale"), 0644)
+	if _, err := r.Restore(context.Background(), m, target, &Options{Include: []string{"orders.csv"}, Overwrite: true}); err != nil {
+		t.Fatalf("Restore with Overwrite failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(target, "orders.csv")); string(got) != testFiles["orders.csv"] {
+		t.Error("expected the stale file to be replaced")
+	}
+
+	if _, err := r.Restore(context.Background(), m, target, &Options{Include: []string{"[bad"}}); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
+
+func TestRestoreCorruptPart(t *testing.T) {
+	root, _, m, r := setup(t, backup.Options{Compression: compress.None, PartSize: 4096})
+
+	// Flip a byte inside file content in the first part
+	partPath := filepath.Join(root, filepath.FromSlash(m.Parts[0].Key))
+	data, _ := os.ReadFile(partPath)
+	data[1000] ^= 0xff
+	os.WriteFile(partPath, data, 0644)
+
+	target := t.TempDir()
+	_, err := r.Restore(context.Background(), m, target, nil)
+	if !errors.Is(err, checksum.ErrMismatch) {
+		t.Fatalf("expected ErrMismatch, got %v", err)
+	}
+	for _, name := range listTree(t, target) {
+		content, _ := os.ReadFile(filepath.Join(target, filepath.FromSlash(name)))
+		if string(content) != testFiles[name] {
+			t.Errorf("corrupt file %s was published", name)
+		}
+	}
+}
+
+func TestRestoreMissingPart(t *testing.T) {
+	root, b, m, r := setup(t, backup.Options{PartSize: 100})
+	os.Remove(filepath.Join(root, filepath.FromSlash(m.Parts[len(m.Parts)-1].Key)))
+
+	if _, err := r.Run(context.Background(), b.ManifestKey(m.ID), t.TempDir(), nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing part, got %v", err)
+	}
+	if _, err := r.Run(context.Background(), "exports/missing/manifest.json", t.TempDir(), nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing manifest, got %v", err)
+	}
+}
+
+func TestNewRestorer(t *testing.T) {
+	if _, err := NewRestorer(nil, &storage.Local{Root: t.TempDir()}); err == nil {
+		t.Error("expected error for nil logger")
+	}
+	if _, err := NewRestorer(newLogger(t), nil); err == nil {
+		t.Error("expected error for nil backend")
+	}
+}
@@ -0,0 +1,140 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Unlimited can be used as MaxAttempts to retry until MaxElapsed or the context ends
+const Unlimited = -1
+
+// Default values used for zero-valued Options fields
+const (
+	DefaultMaxAttempts  = 3
+	DefaultInitialDelay = 100 * time.Millisecond
+	DefaultMaxDelay     = 30 * time.Second
+	DefaultMultiplier   = 2.0
+)
+
+// Options configures the retry behavior of Do
+type Options struct {
+	MaxAttempts  int           // total attempts including the first (default 3, Unlimited for no limit)
+	InitialDelay time.Duration // delay before the second attempt (default 100ms)
+	MaxDelay     time.Duration // upper bound for a single delay (default 30s)
+	Multiplier   float64       // growth factor between delays (default 2)
+	Jitter       float64       // randomizes each delay by ±Jitter (0 to 1), e.g. 0.2 for ±20%
+	MaxElapsed   time.Duration // stop retrying once this much time has passed (0 for no limit)
+
+	// Retryable classifies errors; nil retries every error not marked Permanent
+	Retryable func(err error) bool
+	// OnRetry is called before sleeping, e.g. to log the failed attempt
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// permanentError marks an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so Do returns it immediately without further attempts
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, the attempts or
+// elapsed time are exhausted, or ctx is done. Delays grow exponentially with jitter.
+func Do(ctx context.Context, fn func() error, opts Options) error {
+	opts = opts.withDefaults()
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if IsPermanent(err) {
+			if p, ok := err.(*permanentError); ok {
+				return p.err
+			}
+			return err
+		}
+		if opts.Retryable != nil && !opts.Retryable(err) {
+			return err
+		}
+		if opts.MaxAttempts != Unlimited && attempt >= opts.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := opts.Delay(attempt)
+		if opts.MaxElapsed > 0 && time.Since(start)+delay > opts.MaxElapsed {
+			return fmt.Errorf("giving up after %d attempts and %s: %w", attempt, time.Since(start).Round(time.Millisecond), err)
+		}
+
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// Delay returns the wait after the given failed attempt (starting at 1),
+// including jitter and capped at MaxDelay
+func (o Options) Delay(attempt int) time.Duration {
+	o = o.withDefaults()
+
+	delay := float64(o.InitialDelay) * math.Pow(o.Multiplier, float64(attempt-1))
+	if o.Jitter > 0 {
+		delay *= 1 + o.Jitter*(2*rand.Float64()-1)
+	}
+	if delay > float64(o.MaxDelay) || math.IsInf(delay, 0) {
+		delay = float64(o.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// withDefaults fills zero-valued fields with the package defaults
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = DefaultMaxAttempts
+	}
+	if o.InitialDelay <= 0 {
+		o.InitialDelay = DefaultInitialDelay
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = DefaultMaxDelay
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = DefaultMultiplier
+	}
+	o.Jitter = min(max(o.Jitter, 0), 1)
+	return o
+}
@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errTemporary = errors.New("temporary failure")
+
+func TestDo(t *testing.T) {
+	fast := Options{InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		opts         Options
+		failures     int   // number of failures before success
+		err          error // error returned while failing
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"succeeds first time", fast, 0, errTemporary, 1, false},
+		{"succeeds after retries", fast, 2, errTemporary, 3, false},
+		{"exhausts attempts", fast, 10, errTemporary, 3, true},
+		{"permanent error stops", fast, 10, Permanent(errTemporary), 1, true},
+		{
+			name:         "non-retryable error stops",
+			opts:         Options{InitialDelay: time.Millisecond, Retryable: func(err error) bool { return false }},
+			failures:     10,
+			err:          errTemporary,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "custom max attempts",
+			opts:         Options{MaxAttempts: 5, InitialDelay: time.Millisecond},
+			failures:     10,
+			err:          errTemporary,
+			wantAttempts: 5,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}, tt.opts)
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error result: %v", err)
+			}
+			if err != nil && !errors.Is(err, errTemporary) {
+				t.Errorf("expected error to wrap the last failure, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDoOnRetry(t *testing.T) {
+	var calls []int
+	opts := Options{
+		InitialDelay: time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			calls = append(calls, attempt)
+		},
+	}
+
+	Do(context.Background(), func() error { return errTemporary }, opts)
+
+	// OnRetry runs before each wait, not after the final attempt
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Errorf("OnRetry calls = %v, expected [1 2]", calls)
+	}
+}
+
+func TestDoContextCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	err := Do(ctx, func() error {
+		attempts++
+		return errTemporary
+	}, Options{MaxAttempts: Unlimited, InitialDelay: 5 * time.Millisecond, MaxDelay: 5 * time.Millisecond})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if attempts < 2 {
+		t.Errorf("expected several attempts before cancellation, got %d", attempts)
+	}
+}
+
+func TestDoMaxElapsed(t *testing.T) {
+	start := time.Now()
+	err := Do(context.Background(), func() error { return errTemporary }, Options{
+		MaxAttempts:  Unlimited,
+		InitialDelay: 10 * time.Millisecond,
+		Multiplier:   1,
+		MaxElapsed:   35 * time.Millisecond,
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "giving up") {
+		t.Errorf("expected giving up error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("MaxElapsed not respected, took %v", elapsed)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	opts := Options{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, want := range expected {
+		if got := opts.Delay(i + 1); got != want*time.Millisecond {
+			t.Errorf("Delay(%d) = %v, expected %v", i+1, got, want*time.Millisecond)
+		}
+	}
+
+	opts.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := opts.Delay(1)
+		if got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Delay with jitter out of range: %v", got)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+	err := Permanent(errTemporary)
+	if !IsPermanent(err) || !errors.Is(err, errTemporary) {
+		t.Errorf("expected permanent error wrapping the cause, got %v", err)
+	}
+	if IsPermanent(errTemporary) {
+		t.Error("plain error should not be permanent")
+	}
+}
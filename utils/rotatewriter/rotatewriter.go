@@ -0,0 +1,471 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package rotatewriter
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackupTimeFormat is the timestamp added to files rotated by size or, for
+// paths without a time layout, by period
+const BackupTimeFormat = "20060102T150405.000"
+
+// Options configures rotation and retention. The zero value never rotates.
+type Options struct {
+	// MaxSize rotates the file before a write would grow it beyond MaxSize
+	// bytes (0 for no limit). A single larger write goes to a file of its own.
+	MaxSize int64
+	// Every rotates the file when the wall clock enters a new period, e.g.
+	// 24*time.Hour at local midnight or time.Hour on the hour (0 to disable).
+	// Daily periods follow the calendar, so they stay at midnight across
+	// daylight saving changes.
+	Every time.Duration
+	// Compress gzips rotated files in the background
+	Compress bool
+	// MaxBackups keeps the newest MaxBackups rotated files (0 keeps all)
+	MaxBackups int
+	// MaxAge removes rotated files older than MaxAge (0 keeps all)
+	MaxAge time.Duration
+	// Perm is the mode of new files (default 0644)
+	Perm fs.FileMode
+	// OnRotate is called in the background with the final name of every
+	// rotated file, after compression and before retention, e.g. to upload
+	// it. Calls are serialized.
+	OnRotate func(name string)
+	// Now returns the current time (default time.Now), e.g. a fixed clock
+	// in tests
+	Now func() time.Time
+}
+
+// Writer is an io.WriteCloser writing to a file that is rotated by size and
+// time. It is safe for concurrent use.
+//
+// The path may contain a time layout in braces, e.g. "logs/app_{2006-01-02}.log",
+// which is expanded with the start of the current period, so every period
+// writes a file of its own. Without a layout, the file is renamed to
+// "<name>-<BackupTimeFormat><ext>" when it is rotated and a new one is
+// created at path. Files rotated by size are always renamed that way.
+type Writer struct {
+	path string
+	opts Options
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	name   string
+	size   int64
+	period time.Time
+
+	millMu sync.Mutex
+	millWG sync.WaitGroup
+}
+
+// New opens the file for path, creating its directory, and appends to it
+// if it exists. Files left by earlier runs beyond MaxBackups or MaxAge are
+// removed in the background, so short jobs that never rotate still clean up.
+// A nil opts never rotates.
+func New(path string, opts *Options) (*Writer, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxSize < 0 || o.Every < 0 || o.MaxBackups < 0 || o.MaxAge < 0 {
+		return nil, fmt.Errorf("rotation limits must not be negative")
+	}
+	if o.Perm == 0 {
+		o.Perm = 0644
+	}
+	if strings.Count(path, "{") != strings.Count(path, "}") || strings.Count(path, "{") > 1 ||
+		strings.ContainsAny(filepath.Dir(path), "{}") {
+		return nil, fmt.Errorf("invalid path %q: expected at most one {layout} in the file name", path)
+	}
+
+	now := time.Now
+	if o.Now != nil {
+		now = o.Now
+	}
+	return newWriter(path, o, now)
+}
+
+// newWriter opens a writer using now as its clock
+func newWriter(path string, opts Options, now func() time.Time) (*Writer, error) {
+	w := &Writer{path: path, opts: opts, now: now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.pruneOnOpen()
+	return w, nil
+}
+
+// pruneOnOpen applies retention to the files of earlier runs in the
+// background
+func (w *Writer) pruneOnOpen() {
+	if w.opts.MaxBackups == 0 && w.opts.MaxAge == 0 {
+		return
+	}
+	active := w.name
+	w.millWG.Add(1)
+	go func() {
+		defer w.millWG.Done()
+		w.millMu.Lock()
+		defer w.millMu.Unlock()
+		if err := w.prune(active); err != nil {
+			fmt.Fprintf(os.Stderr, "rotatewriter: %v\n", err)
+		}
+	}()
+}
+
+// Write writes p to the current file, rotating it first when the period
+// changed or p would exceed MaxSize
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.opts.Every > 0 && !w.periodStart(w.now()).Equal(w.period) {
+		if err := w.rotate(false); err != nil {
+			return 0, err
+		}
+	}
+	if w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize {
+		if err := w.rotate(true); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// WriteString writes s like Write
+func (w *Writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Sync commits the current file to disk
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Rotate closes the current file, renames it to a backup name and opens a
+// new one, e.g. on SIGHUP
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate(true)
+}
+
+// Name returns the path of the file being written
+func (w *Writer) Name() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.name
+}
+
+// Close closes the file and waits for background compression and cleanup
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.millWG.Wait()
+	return err
+}
+
+// open opens the file of the current period
+func (w *Writer) open() error {
+	now := w.now()
+	w.period = w.periodStart(now)
+	name := w.expand(w.period)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+
+	// A file left by an earlier period is rotated before it is appended to
+	if info, err := os.Stat(name); err == nil && w.opts.Every > 0 && !w.templated() &&
+		info.ModTime().Before(w.period) {
+		if err := w.archive(name, info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, w.opts.Perm)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	w.file, w.name, w.size = file, name, info.Size()
+	return nil
+}
+
+// rotate closes the current file and opens the next one. The closed file
+// is renamed to a backup name when rename is set or path has no layout;
+// otherwise the next period simply has another name.
+func (w *Writer) rotate(rename bool) error {
+	// A layout coarser than the period, e.g. a daily name rotated hourly,
+	// names the next period like the current file, which is kept open
+	if !rename && w.templated() {
+		if next := w.periodStart(w.now()); w.expand(next) == w.name {
+			w.period = next
+			return nil
+		}
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", w.name, err)
+	}
+	w.file = nil
+	if rename || !w.templated() {
+		if err := w.archive(w.name, w.now()); err != nil {
+			return err
+		}
+	} else {
+		w.mill(w.name)
+	}
+	return w.open()
+}
+
+// archive renames name to its backup name for t and hands it to the mill
+func (w *Writer) archive(name string, t time.Time) error {
+	ext := filepath.Ext(name)
+	stem := fmt.Sprintf("%s-%s", strings.TrimSuffix(name, ext), t.Format(BackupTimeFormat))
+	backup := stem + ext
+	// Keep earlier backups made within the same millisecond
+	for i := 1; exists(backup) || exists(backup+".gz"); i++ {
+		backup = fmt.Sprintf("%s.%d%s", stem, i, ext)
+	}
+	if err := os.Rename(name, backup); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", name, err)
+	}
+	w.mill(backup)
+	return nil
+}
+
+// mill compresses a rotated file and applies retention in the background
+func (w *Writer) mill(rotated string) {
+	if !w.opts.Compress && w.opts.MaxBackups == 0 && w.opts.MaxAge == 0 && w.opts.OnRotate == nil {
+		return
+	}
+	active := filepath.Clean(w.expand(w.periodStart(w.now())))
+	w.millWG.Add(1)
+	go func() {
+		defer w.millWG.Done()
+		w.millMu.Lock()
+		defer w.millMu.Unlock()
+		name := rotated
+		if w.opts.Compress {
+			if err := compressFile(rotated, w.opts.Perm); err != nil {
+				fmt.Fprintf(os.Stderr, "rotatewriter: failed to compress %s: %v\n", rotated, err)
+			} else {
+				name += ".gz"
+			}
+		}
+		if w.opts.OnRotate != nil {
+			w.opts.OnRotate(name)
+		}
+		if err := w.prune(active); err != nil {
+			fmt.Fprintf(os.Stderr, "rotatewriter: %v\n", err)
+		}
+	}()
+}
+
+// prune removes rotated files beyond MaxBackups and older than MaxAge
+func (w *Writer) prune(active string) error {
+	if w.opts.MaxBackups == 0 && w.opts.MaxAge == 0 {
+		return nil
+	}
+	backups, err := w.backups(active)
+	if err != nil {
+		return err
+	}
+
+	cutoff := w.now().Add(-w.opts.MaxAge)
+	var errs []string
+	for i, b := range backups {
+		if (w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups) || (w.opts.MaxAge > 0 && b.ModTime().Before(cutoff)) {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove old files: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+type backupFile struct {
+	fs.FileInfo
+	path string
+}
+
+// stampPattern matches the timestamp added by archive
+const stampPattern = `-\d{8}T\d{6}\.\d{3}(\.\d+)?`
+
+var (
+	backupStamp = regexp.MustCompile(stampPattern + "$")
+	onlyStamp   = regexp.MustCompile("^" + stampPattern + "$")
+)
+
+// backups returns the rotated files of the writer, newest first, except
+// active. These are the files named like the path with a time layout that
+// parses, or a backup stamp, so other files in the directory are kept.
+func (w *Writer) backups(active string) ([]backupFile, error) {
+	dir, base := filepath.Split(w.path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rotated files: %w", err)
+	}
+
+	var backups []backupFile
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if e.IsDir() || p == active || !w.rotated(base, strings.TrimSuffix(e.Name(), ".gz")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{FileInfo: info, path: p})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ModTime().After(backups[j].ModTime()) })
+	return backups, nil
+}
+
+// rotated reports whether name is a file rotated from the path's base name
+func (w *Writer) rotated(base, name string) bool {
+	start, end := strings.Index(base, "{"), strings.Index(base, "}")
+	if start < 0 {
+		ext := filepath.Ext(base)
+		stem, ok := strings.CutSuffix(name, ext)
+		stamp, found := strings.CutPrefix(stem, strings.TrimSuffix(base, ext))
+		return ok && found && onlyStamp.MatchString(stamp)
+	}
+
+	prefix, suffix := base[:start], base[end+1:]
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) || len(name) < len(prefix)+len(suffix) {
+		return false
+	}
+	stamp := name[len(prefix) : len(name)-len(suffix)]
+	if _, err := time.Parse(base[start+1:end], stamp); err == nil {
+		return true
+	}
+	_, err := time.Parse(base[start+1:end], backupStamp.ReplaceAllString(stamp, ""))
+	return err == nil
+}
+
+// compressFile gzips name to name.gz, keeping its modification time, and
+// removes the original
+func compressFile(name string, perm fs.FileMode) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err := os.Rename(tmp, name+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// templated reports whether the path contains a time layout
+func (w *Writer) templated() bool {
+	return strings.Contains(w.path, "{")
+}
+
+// expand formats the path's time layout with t
+func (w *Writer) expand(t time.Time) string {
+	start, end := strings.Index(w.path, "{"), strings.Index(w.path, "}")
+	if start < 0 || end < start {
+		return w.path
+	}
+	return w.path[:start] + t.Format(w.path[start+1:end]) + w.path[end+1:]
+}
+
+// periodStart returns the start of the period containing t. Periods that
+// divide a day or last whole days follow the local calendar, so they start
+// at local midnight even on days of 23 or 25 hours; other periods are
+// aligned to the Unix epoch. Without Every the period starts at t, e.g. when
+// the writer was opened.
+func (w *Writer) periodStart(t time.Time) time.Time {
+	every := w.opts.Every
+	if every <= 0 {
+		if w.file != nil || !w.period.IsZero() {
+			return w.period
+		}
+		return t
+	}
+	const day = 24 * time.Hour
+	year, month, mday := t.Date()
+	switch {
+	case every%day == 0:
+		// Count calendar days, not elapsed time
+		days := time.Date(year, month, mday, 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second)
+		n := int64(every / day)
+		return time.Date(1970, 1, 1+int(days/n*n), 0, 0, 0, 0, t.Location())
+	case day%every == 0:
+		// Truncate the wall clock, so a repeated hour stays in its period
+		hour, minute, sec := t.Clock()
+		wall := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute +
+			time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+		return time.Date(year, month, mday, 0, 0, 0, int(wall/every*every), t.Location())
+	}
+	return t.Truncate(every)
+}
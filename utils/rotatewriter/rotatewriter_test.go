@@ -0,0 +1,438 @@
+package rotatewriter
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source for period tests
+type clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *clock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+// newWithClock creates a writer whose time starts at start
+func newWithClock(t *testing.T, path string, opts *Options, start time.Time) (*Writer, *clock) {
+	t.Helper()
+	c := &clock{t: start}
+	o := *opts
+	o.Perm = 0644
+	w, err := newWriter(path, o, c.now)
+	if err != nil {
+		t.Fatalf("newWriter failed: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w, c
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func write(t *testing.T, w io.Writer, s string) {
+	t.Helper()
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+}
+
+func TestSizeRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "audit.log")
+	w, err := New(path, &Options{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	write(t, w, "12345")
+	write(t, w, "67890")
+	write(t, w, "abc")                   // rotates: 10 + 3 > 10
+	write(t, w, strings.Repeat("x", 25)) // rotates, then exceeds MaxSize on its own
+	write(t, w, "z")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Error("expected error writing to a closed writer")
+	}
+
+	names := listDir(t, filepath.Join(dir, "logs"))
+	if len(names) != 4 || names[3] != "audit.log" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	var contents []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, "audit") || !strings.HasSuffix(name, ".log") {
+			t.Errorf("unexpected backup name %s", name)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, "logs", name))
+		contents = append(contents, string(data))
+	}
+	sort.Strings(contents)
+	if strings.Join(contents, "|") != "1234567890|abc|"+strings.Repeat("x", 25)+"|z" {
+		t.Errorf("unexpected contents %q", contents)
+	}
+}
+
+func TestAppendAndManualRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte("existing\n"), 0644)
+
+	w, err := New(path, &Options{MaxSize: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "abc")
+	if data, _ := os.ReadFile(path); string(data) != "existing\nabc" {
+		t.Errorf("expected append to the existing file, got %q", data)
+	}
+	write(t, w, "d") // 13 bytes would exceed MaxSize
+	if data, _ := os.ReadFile(path); string(data) != "d" {
+		t.Errorf("expected a rotated file, got %q", data)
+	}
+
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Size() != 0 || w.Name() != path {
+		t.Errorf("expected a fresh file at %s", w.Name())
+	}
+	w.Close()
+	if len(listDir(t, filepath.Dir(path))) != 3 {
+		t.Errorf("expected 2 backups, got %v", listDir(t, filepath.Dir(path)))
+	}
+}
+
+func TestTemplatedPeriodRotation(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2024, 6, 1, 23, 59, 0, 0, time.Local)
+	w, c := newWithClock(t, filepath.Join(dir, "app_{2006-01-02}.log"), &Options{Every: 24 * time.Hour}, day)
+
+	write(t, w, "saturday\n")
+	c.set(day.Add(2 * time.Minute))
+	write(t, w, "sunday\n")
+	if w.Name() != filepath.Join(dir, "app_2024-06-02.log") {
+		t.Errorf("unexpected current file %s", w.Name())
+	}
+	w.Close()
+
+	if got := strings.Join(listDir(t, dir), ","); got != "app_2024-06-01.log,app_2024-06-02.log" {
+		t.Fatalf("unexpected files %s", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "app_2024-06-01.log")); string(data) != "saturday\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name   string
+		layout string
+		every  time.Duration
+		writes []time.Time
+		want   map[string]string
+	}{
+		{
+			// 2024-11-03 has 25 hours; 23:30 is 24.5h after midnight
+			name:   "daily across fall back",
+			layout: "2006-01-02",
+			every:  24 * time.Hour,
+			writes: []time.Time{at(11, 2, 12, 0), at(11, 3, 0, 30), at(11, 3, 23, 30), at(11, 4, 0, 30)},
+			want:   map[string]string{"2024-11-02.gz": "1", "2024-11-03.gz": "23", "2024-11-04": "4"},
+		},
+		{
+			// 2024-03-10 has 23 hours
+			name:   "daily across spring forward",
+			layout: "2006-01-02",
+			every:  24 * time.Hour,
+			writes: []time.Time{at(3, 9, 23, 30), at(3, 10, 1, 30), at(3, 10, 23, 30), at(3, 11, 0, 30)},
+			want:   map[string]string{"2024-03-09.gz": "1", "2024-03-10.gz": "23", "2024-03-11": "4"},
+		},
+		{
+			name:   "weekly across fall back",
+			layout: "2006-01-02",
+			every:  7 * 24 * time.Hour,
+			writes: []time.Time{at(10, 31, 12, 0), at(11, 3, 23, 30), at(11, 6, 23, 59), at(11, 7, 0, 0)},
+			want:   map[string]string{"2024-10-31.gz": "123", "2024-11-07": "4"},
+		},
+		{
+			// 01:30 happens twice and writes to the same hourly file
+			name:   "hourly across fall back",
+			layout: "2006-01-02T15",
+			every:  time.Hour,
+			writes: []time.Time{at(11, 3, 0, 30), at(11, 3, 1, 30), at(11, 3, 1, 30).Add(time.Hour), at(11, 3, 2, 30)},
+			want:   map[string]string{"2024-11-03T00.gz": "1", "2024-11-03T01.gz": "23", "2024-11-03T02": "4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app_{"+tt.layout+"}.log")
+			var rotated []string
+			opts := &Options{Every: tt.every, Compress: true, OnRotate: func(name string) {
+				rotated = append(rotated, filepath.Base(name))
+			}}
+			w, c := newWithClock(t, path, opts, tt.writes[0])
+			for i, when := range tt.writes {
+				c.set(when)
+				write(t, w, fmt.Sprint(i+1))
+			}
+			w.Close()
+
+			var want, wantRotated []string
+			for name := range tt.want {
+				stamp, gz := strings.CutSuffix(name, ".gz")
+				file := "app_" + stamp + ".log"
+				if gz {
+					file += ".gz"
+					wantRotated = append(wantRotated, file)
+				}
+				want = append(want, file)
+				if got := readLog(t, filepath.Join(dir, file)); got != tt.want[name] {
+					t.Errorf("%s contains %q, want %q", file, got, tt.want[name])
+				}
+			}
+			sort.Strings(want)
+			if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("unexpected files %v, want %v", got, want)
+			}
+			// Every period is rotated once, never while it is written
+			sort.Strings(rotated)
+			sort.Strings(wantRotated)
+			if strings.Join(rotated, ",") != strings.Join(wantRotated, ",") {
+				t.Errorf("rotated %v, want %v", rotated, wantRotated)
+			}
+		})
+	}
+}
+
+// readLog returns the content of a log file, decompressing .gz files
+func readLog(t *testing.T, name string) string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPeriodRotationRenames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.log")
+	hour := time.Date(2024, 6, 1, 10, 30, 0, 0, time.Local)
+	w, c := newWithClock(t, path, &Options{Every: time.Hour}, hour)
+
+	write(t, w, "10h\n")
+	c.set(hour.Add(40 * time.Minute))
+	write(t, w, "11h\n")
+	w.Close()
+
+	names := listDir(t, dir)
+	if len(names) != 2 || names[0] != "events-20240601T111000.000.log" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "11h\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	// A file from an earlier period is rotated when it is reopened
+	old := hour.Add(-3 * time.Hour)
+	os.Chtimes(path, old, old)
+	w, _ = newWithClock(t, path, &Options{Every: time.Hour}, hour.Add(time.Hour))
+	write(t, w, "12h\n")
+	w.Close()
+	if names := listDir(t, dir); len(names) != 3 || names[0] != "events-20240601T073000.000.log" {
+		t.Errorf("expected the stale file to be rotated, got %v", names)
+	}
+}
+
+func TestCompressAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "quarantine.csv")
+	os.WriteFile(filepath.Join(dir, "quarantined.csv"), []byte("unrelated"), 0644)
+	os.WriteFile(filepath.Join(dir, "quarantine-notes.csv"), []byte("unrelated"), 0644)
+
+	w, err := New(path, &Options{MaxSize: 5, Compress: true, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []string{"row1\n", "row2\n", "row3\n", "row4\n"} {
+		write(t, w, row)
+		time.Sleep(5 * time.Millisecond)
+	}
+	w.Close()
+
+	names := listDir(t, dir)
+	var gz []string
+	for _, name := range names {
+		if strings.HasSuffix(name, ".csv.gz") {
+			gz = append(gz, name)
+		}
+	}
+	if len(names) != 5 || len(gz) != 2 {
+		t.Fatalf("expected the active file, 2 compressed backups and the unrelated files, got %v", names)
+	}
+
+	// The newest backups are kept
+	f, _ := os.Open(filepath.Join(dir, gz[1]))
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != "row3\n" {
+		t.Errorf("unexpected backup content %q", data)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "app_2024-01-01.log")
+	recent := filepath.Join(dir, "app_2024-05-31.log")
+	other := filepath.Join(dir, "app_test_2024-01-01.log")
+	os.WriteFile(old, []byte("old"), 0644)
+	os.WriteFile(recent, []byte("recent"), 0644)
+	os.WriteFile(other, []byte("another app"), 0644)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	os.Chtimes(old, start.AddDate(0, -5, 0), start.AddDate(0, -5, 0))
+	os.Chtimes(other, start.AddDate(0, -5, 0), start.AddDate(0, -5, 0))
+	os.Chtimes(recent, start.AddDate(0, 0, -1), start.AddDate(0, 0, -1))
+
+	w, c := newWithClock(t, filepath.Join(dir, "app_{2006-01-02}.log"), &Options{Every: 24 * time.Hour, MaxAge: 7 * 24 * time.Hour}, start)
+	write(t, w, "june 1\n")
+	c.set(start.Add(24 * time.Hour))
+	write(t, w, "june 2\n")
+	w.Close()
+
+	if got := strings.Join(listDir(t, dir), ","); got != "app_2024-05-31.log,app_2024-06-01.log,app_2024-06-02.log,app_test_2024-01-01.log" {
+		t.Errorf("unexpected files %s", got)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(filepath.Join(dir, "a.log"), &Options{MaxSize: -1}); err == nil {
+		t.Error("expected error for a negative MaxSize")
+	}
+	if _, err := New(filepath.Join(dir, "a_{2006.log"), nil); err == nil {
+		t.Error("expected error for an unterminated layout")
+	}
+	if _, err := New(filepath.Join(dir, "{2006}_{01}.log"), nil); err == nil {
+		t.Error("expected error for two layouts")
+	}
+	if _, err := New(filepath.Join(dir, "{2006}", "app.log"), nil); err == nil {
+		t.Error("expected error for a layout in the directory")
+	}
+
+	w, err := New(filepath.Join(dir, "plain.log"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, strings.Repeat("x", 1<<16))
+	if err := w.Sync(); err != nil {
+		t.Errorf("Sync failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if len(listDir(t, dir)) != 1 {
+		t.Error("expected no rotation without options")
+	}
+}
+
+func TestOnRotate(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var rotated []string
+	w, err := New(filepath.Join(dir, "jobs.log"), &Options{MaxSize: 4, Compress: true, OnRotate: func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		rotated = append(rotated, name)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, "abcd")
+	write(t, w, "efgh")
+	w.Close()
+
+	if len(rotated) != 1 || filepath.Dir(rotated[0]) != dir || !strings.HasSuffix(rotated[0], ".log.gz") {
+		t.Fatalf("unexpected rotated files %v", rotated)
+	}
+	if _, err := os.Stat(rotated[0]); err != nil {
+		t.Errorf("expected the rotated file to exist: %v", err)
+	}
+}
+
+func TestPruneOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 6, 20, 12, 0, 0, 0, time.Local)
+	for day := 1; day <= 19; day++ {
+		name := filepath.Join(dir, fmt.Sprintf("job_2024-06-%02d.log", day))
+		os.WriteFile(name, []byte("old run"), 0644)
+		mtime := time.Date(2024, 6, day, 23, 0, 0, 0, time.Local)
+		os.Chtimes(name, mtime, mtime)
+	}
+	os.WriteFile(filepath.Join(dir, "other_2024-06-01.log"), []byte("another app"), 0644)
+
+	// A job running once a day never rotates, so retention applies on open
+	w, _ := newWithClock(t, filepath.Join(dir, "job_{2006-01-02}.log"), &Options{Every: 24 * time.Hour, MaxAge: 14 * 24 * time.Hour, MaxBackups: 10}, start)
+	write(t, w, "today\n")
+	w.Close()
+
+	names := listDir(t, dir)
+	if len(names) != 12 || names[0] != "job_2024-06-10.log" || names[10] != "job_2024-06-20.log" || names[11] != "other_2024-06-01.log" {
+		t.Errorf("expected the newest 10 old files, today's and the other app's, got %v", names)
+	}
+}
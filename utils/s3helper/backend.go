@@ -0,0 +1,145 @@
+package s3helper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/romisugianto/go-utils/utils/mimetype"
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+var _ storage.Backend = (*S3Helper)(nil)
+
+// Put streams r to the object at key. The content type is detected from the
+// leading bytes of r and the key's extension.
+func (u *S3Helper) Put(ctx context.Context, key string, r io.Reader) error {
+	sess, err := u.newSession()
+	if err != nil {
+		return err
+	}
+
+	key = cleanKey(key)
+	// Peek at the leading bytes to sniff the content type; read errors
+	// surface from the upload itself
+	body := bufio.NewReaderSize(r, mimetype.SniffLen)
+	head, _ := body.Peek(mimetype.SniffLen)
+	contentType := u.detectContentType(key, head)
+	uploader := s3manager.NewUploaderWithClient(s3.New(sess))
+	_, err = uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(u.BucketName),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %q to S3: %v", key, err)
+	}
+	return nil
+}
+
+// Get opens the object at key for reading
+func (u *S3Helper) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	key = cleanKey(key)
+	result, err := s3.New(sess).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return nil, storage.NotFound(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %q from S3: %v", key, err)
+	}
+	return result.Body, nil
+}
+
+// List returns the objects whose key starts with prefix
+func (u *S3Helper) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := u.listObjects(s3.New(sess), prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]storage.ObjectInfo, 0, len(objects))
+	for _, obj := range objects {
+		infos = append(infos, storage.ObjectInfo{
+			Key:     aws.StringValue(obj.Key),
+			Size:    aws.Int64Value(obj.Size),
+			ModTime: aws.TimeValue(obj.LastModified),
+			ETag:    aws.StringValue(obj.ETag),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// Delete removes the object at key. S3 does not report missing keys, so
+// deleting one succeeds.
+func (u *S3Helper) Delete(ctx context.Context, key string) error {
+	sess, err := u.newSession()
+	if err != nil {
+		return err
+	}
+
+	key = cleanKey(key)
+	_, err = s3.New(sess).DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", key, err)
+	}
+	return nil
+}
+
+// Stat returns the size, modification time and ETag of the object at key
+func (u *S3Helper) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+
+	key = cleanKey(key)
+	head, err := s3.New(sess).HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return storage.ObjectInfo{}, storage.NotFound(key)
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("failed to stat object %q: %v", key, err)
+	}
+	return storage.ObjectInfo{
+		Key:     key,
+		Size:    aws.Int64Value(head.ContentLength),
+		ModTime: aws.TimeValue(head.LastModified),
+		ETag:    aws.StringValue(head.ETag),
+	}, nil
+}
+
+// isNotFound reports whether err is a 404 response from S3
+func isNotFound(err error) bool {
+	var reqErr awserr.RequestFailure
+	return errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound
+}
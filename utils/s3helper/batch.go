@@ -0,0 +1,243 @@
+package s3helper
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/romisugianto/go-utils/utils/manifest"
+)
+
+// BatchItem is a single file scheduled for upload by UploadBatch
+type BatchItem struct {
+	FilePath string
+	S3Path   string
+}
+
+// ManifestEntry records a completed upload in a batch manifest
+type ManifestEntry struct {
+	Key        string    `json:"key"`
+	FilePath   string    `json:"file_path"`
+	Size       int64     `json:"size"`
+	MD5        string    `json:"md5"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// BatchResult summarizes the outcome of UploadBatch
+type BatchResult struct {
+	Uploaded []string         // keys uploaded during this run
+	Skipped  []string         // keys already recorded in the manifest with a matching checksum
+	Failed   map[string]error // keys that failed to upload, with the cause
+}
+
+// UploadBatch uploads the given items, recording each completed object (key and MD5)
+// as a JSON line in manifestPath. Items already present in the manifest with the
+// same checksum are skipped, so a failed or interrupted batch can be re-run and
+// only the missing files are transferred. Failed items do not stop the batch.
+func (u *S3Helper) UploadBatch(items []BatchItem, manifestPath string) (*BatchResult, error) {
+	if manifestPath == "" {
+		return nil, fmt.Errorf("manifestPath must not be empty")
+	}
+
+	completed, err := LoadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create manifest directory: %v", err)
+	}
+	manifestFile, err := os.OpenFile(manifestPath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest %q: %v", manifestPath, err)
+	}
+	defer manifestFile.Close()
+	if err := trimPartialLine(manifestFile); err != nil {
+		return nil, fmt.Errorf("failed to repair manifest %q: %v", manifestPath, err)
+	}
+
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	s3Client := s3.New(sess)
+
+	result := &BatchResult{Failed: make(map[string]error)}
+	for _, item := range items {
+		key := cleanKey(item.S3Path)
+
+		sum, size, err := fileMD5(item.FilePath)
+		if err != nil {
+			result.Failed[key] = err
+			continue
+		}
+
+		if entry, ok := completed[key]; ok && entry.MD5 == sum {
+			result.Skipped = append(result.Skipped, key)
+			continue
+		}
+
+		if err := u.putFile(s3Client, item.FilePath, key, nil); err != nil {
+			result.Failed[key] = err
+			continue
+		}
+
+		entry := ManifestEntry{
+			Key:        key,
+			FilePath:   item.FilePath,
+			Size:       size,
+			MD5:        sum,
+			UploadedAt: time.Now().UTC(),
+		}
+		if err := appendManifestEntry(manifestFile, entry); err != nil {
+			return result, fmt.Errorf("failed to record %q in manifest: %v", key, err)
+		}
+		completed[key] = entry
+		result.Uploaded = append(result.Uploaded, key)
+	}
+
+	log.Printf("Batch upload to s3://%s finished (uploaded: %d, skipped: %d, failed: %d)",
+		u.BucketName, len(result.Uploaded), len(result.Skipped), len(result.Failed))
+
+	if len(result.Failed) > 0 {
+		return result, fmt.Errorf("%d of %d uploads failed", len(result.Failed), len(items))
+	}
+	return result, nil
+}
+
+// LoadManifest reads a batch manifest and returns the latest entry per key.
+// A missing manifest is treated as empty, and a truncated final line left by
+// an interrupted run is ignored.
+func LoadManifest(manifestPath string) (map[string]ManifestEntry, error) {
+	entries := make(map[string]ManifestEntry)
+
+	file, err := os.Open(manifestPath)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest %q: %v", manifestPath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	var pending error
+	for scanner.Scan() {
+		lineNum++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		// Only the last line may be corrupt; anything earlier is a real error
+		if pending != nil {
+			return nil, pending
+		}
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			pending = fmt.Errorf("invalid manifest entry at %s:%d: %v", manifestPath, lineNum, err)
+			continue
+		}
+		entries[entry.Key] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest %q: %v", manifestPath, err)
+	}
+
+	return entries, nil
+}
+
+// BatchManifest converts the batch manifest at manifestPath into the
+// standard transfer manifest format, with one complete entry per uploaded
+// key in key order, e.g. to sign it or hand it to a report
+func BatchManifest(manifestPath string) (*manifest.Manifest, error) {
+	entries, err := LoadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	m := manifest.New("upload")
+	for _, key := range keys {
+		entry := entries[key]
+		m.Set(manifest.Entry{
+			Path:     filepath.ToSlash(entry.FilePath),
+			Key:      entry.Key,
+			Size:     entry.Size,
+			Checksum: "md5:" + entry.MD5,
+			Status:   manifest.StatusComplete,
+			Updated:  entry.UploadedAt,
+		})
+	}
+	return m, nil
+}
+
+// appendManifestEntry writes one JSON line and syncs it to disk
+func appendManifestEntry(file *os.File, entry ManifestEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// trimPartialLine truncates the manifest after its last complete line, so
+// an entry cut off by an interrupted run is not glued to the next one
+func trimPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	// Search backwards for the last newline
+	var keep int64
+	buf := make([]byte, 4096)
+	for end := size; end > 0; {
+		n := min(end, int64(len(buf)))
+		if _, err := file.ReadAt(buf[:n], end-n); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			keep = end - n + int64(i) + 1
+			break
+		}
+		end -= n
+	}
+	if keep == size {
+		return nil
+	}
+	return file.Truncate(keep)
+}
+
+// fileMD5 returns the hex MD5 digest and size of a local file
+func fileMD5(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file %q: %v", filePath, err)
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read file %q: %v", filePath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
@@ -0,0 +1,171 @@
+package s3helper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/romisugianto/go-utils/utils/dirwalk"
+)
+
+// FileFilter selects which files are uploaded by UploadDirectory.
+// Glob patterns use path.Match syntax and are matched against both the
+// slash-separated path relative to the upload root and the base name.
+type FileFilter struct {
+	// Include limits uploads to files matching at least one pattern (glob or regex)
+	Include      []string
+	IncludeRegex []*regexp.Regexp
+	// Exclude skips matching files and directories (e.g. "*.tmp", "*.lock")
+	Exclude      []string
+	ExcludeRegex []*regexp.Regexp
+	// MaxDepth limits recursion; 1 uploads only files directly in the root, 0 is unlimited
+	MaxDepth int
+	// IncludeHidden uploads dot-prefixed files and directories, which are skipped by default
+	IncludeHidden bool
+}
+
+// Validate checks that all glob patterns are well-formed
+func (f *FileFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if f.MaxDepth < 0 {
+		return fmt.Errorf("MaxDepth must be >= 0, got %d", f.MaxDepth)
+	}
+	return nil
+}
+
+// MatchDir reports whether a directory at relPath should be descended into
+func (f *FileFilter) MatchDir(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if !f.IncludeHidden && isHidden(relPath) {
+		return false
+	}
+	if f.MaxDepth > 0 && depth(relPath) >= f.MaxDepth {
+		return false
+	}
+	return !matchAny(relPath, f.Exclude, f.ExcludeRegex)
+}
+
+// MatchFile reports whether a file at relPath should be uploaded
+func (f *FileFilter) MatchFile(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if !f.IncludeHidden && isHidden(relPath) {
+		return false
+	}
+	if f.MaxDepth > 0 && depth(relPath) > f.MaxDepth {
+		return false
+	}
+	if matchAny(relPath, f.Exclude, f.ExcludeRegex) {
+		return false
+	}
+	if len(f.Include) == 0 && len(f.IncludeRegex) == 0 {
+		return true
+	}
+	return matchAny(relPath, f.Include, f.IncludeRegex)
+}
+
+// UploadDirectory uploads the files under localDir to s3Prefix, preserving the
+// relative directory layout, and returns the uploaded keys.
+// A nil filter uploads every non-hidden file recursively.
+func (u *S3Helper) UploadDirectory(localDir, s3Prefix string, filter *FileFilter) ([]string, error) {
+	files, err := collectFiles(localDir, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	s3Client := s3.New(sess)
+
+	var uploaded []string
+	for _, relPath := range files {
+		key := strings.TrimPrefix(path.Join(s3Prefix, relPath), "/")
+		if err := u.putFile(s3Client, filepath.Join(localDir, filepath.FromSlash(relPath)), key, nil); err != nil {
+			return uploaded, fmt.Errorf("failed to upload directory %q: %v", localDir, err)
+		}
+		uploaded = append(uploaded, key)
+	}
+
+	return uploaded, nil
+}
+
+// collectFiles walks localDir and returns the slash-separated relative paths
+// of the regular files accepted by the filter
+func collectFiles(localDir string, filter *FileFilter) ([]string, error) {
+	if filter == nil {
+		filter = &FileFilter{}
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access directory %q: %v", localDir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", localDir)
+	}
+
+	entries, err := dirwalk.Collect(context.Background(), localDir, &dirwalk.Options{
+		Filter: func(e dirwalk.Entry) bool {
+			if e.IsDir() {
+				return filter.MatchDir(e.RelPath)
+			}
+			return e.Mode().IsRegular() && filter.MatchFile(e.RelPath)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking directory %s: %v", localDir, err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		files = append(files, e.RelPath)
+	}
+	return files, nil
+}
+
+// isHidden reports whether any element of the slash-separated path starts with a dot
+func isHidden(relPath string) bool {
+	for _, part := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// depth returns the number of elements in a slash-separated relative path
+func depth(relPath string) int {
+	return strings.Count(relPath, "/") + 1
+}
+
+// matchAny reports whether relPath or its base name matches any glob or regex
+func matchAny(relPath string, globs []string, regexes []*regexp.Regexp) bool {
+	base := path.Base(relPath)
+	for _, pattern := range globs {
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	for _, re := range regexes {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package s3helper
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// deleteBatchSize is the maximum number of keys per DeleteObjects request
+const deleteBatchSize = 1000
+
+// ObjectFilter selects objects by tag and user metadata values.
+// An object matches when all listed tags and metadata entries are equal.
+type ObjectFilter struct {
+	Tags     map[string]string // matched against GetObjectTagging
+	Metadata map[string]string // matched against user metadata, keys case-insensitive
+}
+
+// ListFilesFiltered lists the keys under prefix whose tags and metadata match the filter
+func (u *S3Helper) ListFilesFiltered(prefix string, filter ObjectFilter) ([]string, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	s3Client := s3.New(sess)
+
+	return u.filterObjects(s3Client, prefix, filter)
+}
+
+// DeleteFilesFiltered deletes the objects under prefix matching the filter
+// (e.g. everything tagged temp=true) and returns the deleted keys
+func (u *S3Helper) DeleteFilesFiltered(prefix string, filter ObjectFilter) ([]string, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	s3Client := s3.New(sess)
+
+	keys, err := u.filterObjects(s3Client, prefix, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(keys))
+
+		var objects []*s3.ObjectIdentifier
+		for _, key := range keys[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		output, err := s3Client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(u.BucketName),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(false)},
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete files: %v", err)
+		}
+		for _, obj := range output.Deleted {
+			deleted = append(deleted, aws.StringValue(obj.Key))
+		}
+		if len(output.Errors) > 0 {
+			first := output.Errors[0]
+			return deleted, fmt.Errorf("failed to delete %d files (first: %q: %s)",
+				len(output.Errors), aws.StringValue(first.Key), aws.StringValue(first.Message))
+		}
+	}
+
+	log.Printf("Successfully deleted %d files from s3://%s/%s", len(deleted), u.BucketName, prefix)
+	return deleted, nil
+}
+
+// CopyFilesFiltered copies the objects under prefix matching the filter to dstPrefix
+// within the same bucket, keeping their metadata and tags, and returns the new keys
+func (u *S3Helper) CopyFilesFiltered(prefix string, filter ObjectFilter, dstPrefix string) ([]string, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	s3Client := s3.New(sess)
+
+	keys, err := u.filterObjects(s3Client, prefix, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var copied []string
+	for _, key := range keys {
+		dstKey := syncKey(prefix, dstPrefix, key)
+		_, err := s3Client.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(u.BucketName),
+			Key:        aws.String(dstKey),
+			CopySource: aws.String(url.PathEscape(u.BucketName + "/" + key)),
+		})
+		if err != nil {
+			return copied, fmt.Errorf("failed to copy %q to %q: %v", key, dstKey, err)
+		}
+		copied = append(copied, dstKey)
+	}
+
+	log.Printf("Successfully copied %d files to s3://%s/%s", len(copied), u.BucketName, dstPrefix)
+	return copied, nil
+}
+
+// filterObjects lists the keys under prefix and keeps those matching the filter
+func (u *S3Helper) filterObjects(s3Client *s3.S3, prefix string, filter ObjectFilter) ([]string, error) {
+	if len(filter.Tags) == 0 && len(filter.Metadata) == 0 {
+		return nil, fmt.Errorf("filter must specify at least one tag or metadata value")
+	}
+
+	objects, err := u.listObjects(s3Client, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, obj := range objects {
+		key := aws.StringValue(obj.Key)
+		ok, err := u.matchObject(s3Client, key, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// matchObject fetches the tags and metadata needed by the filter and compares them
+func (u *S3Helper) matchObject(s3Client *s3.S3, key string, filter ObjectFilter) (bool, error) {
+	if len(filter.Tags) > 0 {
+		output, err := s3Client.GetObjectTagging(&s3.GetObjectTaggingInput{
+			Bucket: aws.String(u.BucketName),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get tags for %q: %v", key, err)
+		}
+
+		tags := make(map[string]string, len(output.TagSet))
+		for _, tag := range output.TagSet {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		for k, v := range filter.Tags {
+			if value, ok := tags[k]; !ok || value != v {
+				return false, nil
+			}
+		}
+	}
+
+	if len(filter.Metadata) > 0 {
+		head, err := s3Client.HeadObject(&s3.HeadObjectInput{
+			Bucket: aws.String(u.BucketName),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get metadata for %q: %v", key, err)
+		}
+
+		metadata := make(map[string]string, len(head.Metadata))
+		for k, v := range head.Metadata {
+			metadata[strings.ToLower(k)] = aws.StringValue(v)
+		}
+		for k, v := range filter.Metadata {
+			if value, ok := metadata[strings.ToLower(k)]; !ok || value != v {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
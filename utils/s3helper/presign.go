@@ -0,0 +1,56 @@
+package s3helper
+
+import (
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ResponseOverrides sets the headers S3 returns when a presigned URL is fetched
+type ResponseOverrides struct {
+	ContentDisposition string // e.g. Attachment("report.csv") to force a download filename
+	ContentType        string // e.g. "text/csv"
+}
+
+// PresignGetURL returns a presigned GET URL for the object valid for the given duration.
+// Overrides, when non-nil, are signed into the URL as response-* query parameters.
+func (u *S3Helper) PresignGetURL(s3Path string, expires time.Duration, overrides *ResponseOverrides) (string, error) {
+	if expires <= 0 {
+		return "", fmt.Errorf("expires must be positive, got %v", expires)
+	}
+
+	sess, err := u.newSession()
+	if err != nil {
+		return "", err
+	}
+	s3Client := s3.New(sess)
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    aws.String(cleanKey(s3Path)),
+	}
+	if overrides != nil {
+		if overrides.ContentDisposition != "" {
+			input.ResponseContentDisposition = aws.String(overrides.ContentDisposition)
+		}
+		if overrides.ContentType != "" {
+			input.ResponseContentType = aws.String(overrides.ContentType)
+		}
+	}
+
+	req, _ := s3Client.GetObjectRequest(input)
+	presigned, err := req.Presign(expires)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign %q: %v", s3Path, err)
+	}
+	return presigned, nil
+}
+
+// Attachment builds a Content-Disposition value that makes browsers download
+// the object under the given filename, including non-ASCII names
+func Attachment(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
@@ -1,43 +1,145 @@
 package s3helper
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
-	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/romisugianto/go-utils/utils/awssession"
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/iocopy"
+	"github.com/romisugianto/go-utils/utils/mimetype"
+	"github.com/romisugianto/go-utils/utils/pathutil"
+	"github.com/romisugianto/go-utils/utils/ratelimit"
 )
 
 // S3Helper holds the configuration for S3 operations.
 type S3Helper struct {
-	ProfileName	string
-	BucketName	string
-	EndpointURL	string
-	Region			string
+	ProfileName string
+	BucketName  string
+	EndpointURL string
+	Region      string
+	// ForcePathStyle uses path-style addressing (endpoint/bucket/key), required by MinIO and most on-prem stores
+	ForcePathStyle bool
+	// UseAccelerate sends requests to the bucket's S3 Transfer Acceleration endpoint.
+	// The bucket must have acceleration enabled; EndpointURL is ignored when set.
+	UseAccelerate bool
+	// ContentTypes overrides the detected content type by file extension (e.g. ".dat": "text/csv").
+	// Keys are matched case-insensitively, with or without the leading dot.
+	ContentTypes map[string]string
+
+	// CABundlePath is an optional PEM file with root CAs trusted for the endpoint,
+	// in addition to the system pool (e.g. an internal CA for on-prem object stores)
+	CABundlePath string
+	// ClientCertPath and ClientKeyPath enable mutual TLS with the endpoint
+	ClientCertPath string
+	ClientKeyPath  string
+	// InsecureSkipVerify disables certificate verification for this helper only
+	InsecureSkipVerify bool
+
+	// RoleARN assumes an IAM role through STS using the profile credentials as the source.
+	// Temporary credentials are refreshed automatically before they expire.
+	RoleARN string
+	// ExternalID is passed to AssumeRole when the role's trust policy requires it
+	ExternalID string
+	// RoleSessionName identifies the assumed-role session (defaults to an SDK-generated name)
+	RoleSessionName string
+	// RoleDuration is the lifetime of the assumed-role credentials (defaults to 15 minutes)
+	RoleDuration time.Duration
+
+	// DownloadLimiter throttles DownloadFile in bytes per second, e.g.
+	// ratelimit.NewLimiter(10<<20, 1<<20) for 10 MiB/s; nil is unlimited
+	DownloadLimiter *ratelimit.Limiter
+}
+
+// awsConfig returns the credential, endpoint and TLS settings of the helper
+func (u *S3Helper) awsConfig() awssession.Config {
+	return awssession.Config{
+		ProfileName:        u.ProfileName,
+		Region:             u.Region,
+		EndpointURL:        u.EndpointURL,
+		CABundlePath:       u.CABundlePath,
+		ClientCertPath:     u.ClientCertPath,
+		ClientKeyPath:      u.ClientKeyPath,
+		InsecureSkipVerify: u.InsecureSkipVerify,
+		RoleARN:            u.RoleARN,
+		ExternalID:         u.ExternalID,
+		RoleSessionName:    u.RoleSessionName,
+		RoleDuration:       u.RoleDuration,
+	}
+}
+
+// newSession creates an AWS session from the helper configuration
+func (u *S3Helper) newSession() (*session.Session, error) {
+	return u.awsConfig().NewSession(func(config *aws.Config) error {
+		if u.ForcePathStyle {
+			config.S3ForcePathStyle = aws.Bool(true)
+		}
+		if u.UseAccelerate {
+			if u.ForcePathStyle {
+				return fmt.Errorf("UseAccelerate cannot be combined with ForcePathStyle")
+			}
+			// Accelerated requests must go to the AWS accelerate endpoint
+			config.Endpoint = nil
+			config.S3UseAccelerate = aws.Bool(true)
+		}
+		return nil
+	})
+}
+
+// assumeRoleOptions applies the role settings to the STS credential provider
+func (u *S3Helper) assumeRoleOptions(p *stscreds.AssumeRoleProvider) {
+	u.awsConfig().AssumeRoleOptions(p)
+}
+
+// newHTTPClient builds an HTTP client with the configured TLS settings.
+// It returns nil when no TLS customization is configured so the SDK default is used.
+func (u *S3Helper) newHTTPClient() (*http.Client, error) {
+	return u.awsConfig().HTTPClient()
+}
+
+// UploadOptions holds optional object headers applied to an upload
+type UploadOptions struct {
+	ContentType        string    // explicit content type, overriding detection
+	CacheControl       string    // e.g. "public, max-age=86400"
+	Expires            time.Time // zero value leaves Expires unset
+	ContentDisposition string    // e.g. `attachment; filename="report.csv"`
+	ContentLanguage    string    // e.g. "en-US"
 }
 
+// UploadFile uploads a local file to the specified S3 path
 func (u *S3Helper) UploadFile(filePath, s3Path string) error {
+	return u.UploadFileWithOptions(filePath, s3Path, nil)
+}
+
+// UploadFileWithOptions uploads a local file to the specified S3 path with
+// additional object headers such as Cache-Control and Expires
+func (u *S3Helper) UploadFileWithOptions(filePath, s3Path string, opts *UploadOptions) error {
 	// Create a new AWS session with the specified profile and region
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region:      aws.String(u.Region),
-			Endpoint:    aws.String(u.EndpointURL),
-			Credentials: credentials.NewSharedCredentials("", u.ProfileName),
-		},
-	})
+	sess, err := u.newSession()
 	if err != nil {
-		return fmt.Errorf("failed to create AWS session: %v", err)
+		return err
 	}
 
 	// Create an S3 service client
 	s3Client := s3.New(sess)
 
+	return u.putFile(s3Client, filePath, s3Path, opts)
+}
+
+// putFile uploads a single local file using an existing S3 client
+func (u *S3Helper) putFile(s3Client *s3.S3, filePath, s3Path string, opts *UploadOptions) error {
 	// Open the file for reading
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -52,22 +154,31 @@ func (u *S3Helper) UploadFile(filePath, s3Path string) error {
 	}
 
 	// Clean the S3 path (remove leading/trailing slashes)
-	s3Path = strings.TrimPrefix(filepath.Clean(s3Path), "/")
+	s3Path = cleanKey(s3Path)
 
-	// Determine the content type based on the file extension
-	contentType := mime.TypeByExtension(filepath.Ext(filePath))
-	if contentType == "" {
-		contentType = "application/octet-stream" // Default content type
+	// Determine the content type from the options, the leading bytes or the
+	// file extension
+	head := make([]byte, mimetype.SniffLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read file %q: %v", filePath, err)
+	}
+	contentType := u.detectContentType(filePath, head[:n])
+	if opts != nil && opts.ContentType != "" {
+		contentType = opts.ContentType
 	}
 
-	// Upload the file to S3
-	_, err = s3Client.PutObject(&s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket:        aws.String(u.BucketName),
 		Key:           aws.String(s3Path),
 		Body:          file,
 		ContentLength: aws.Int64(fileInfo.Size()),
 		ContentType:   aws.String(contentType),
-	})
+	}
+	opts.apply(input)
+
+	// Upload the file to S3
+	_, err = s3Client.PutObject(input)
 	if err != nil {
 		return fmt.Errorf("failed to upload file to S3: %v", err)
 	}
@@ -76,29 +187,73 @@ func (u *S3Helper) UploadFile(filePath, s3Path string) error {
 	return nil
 }
 
+// detectContentType determines the content type of a file, preferring the
+// helper's ContentTypes overrides over sniffing the leading bytes in head
+// and the system MIME table
+func (u *S3Helper) detectContentType(filePath string, head []byte) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	for key, contentType := range u.ContentTypes {
+		key = strings.ToLower(key)
+		if !strings.HasPrefix(key, ".") {
+			key = "." + key
+		}
+		if key == ext {
+			return contentType
+		}
+	}
+
+	return mimetype.TypeOf(filePath, head)
+}
+
+// apply copies the configured headers onto a PutObject request
+func (o *UploadOptions) apply(input *s3.PutObjectInput) {
+	if o == nil {
+		return
+	}
+	if o.CacheControl != "" {
+		input.CacheControl = aws.String(o.CacheControl)
+	}
+	if !o.Expires.IsZero() {
+		input.Expires = aws.Time(o.Expires)
+	}
+	if o.ContentDisposition != "" {
+		input.ContentDisposition = aws.String(o.ContentDisposition)
+	}
+	if o.ContentLanguage != "" {
+		input.ContentLanguage = aws.String(o.ContentLanguage)
+	}
+}
+
 // ListFiles lists all files in the specified S3 path prefix
 func (u *S3Helper) ListFiles(prefix string) ([]string, error) {
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region:      aws.String(u.Region),
-			Endpoint:    aws.String(u.EndpointURL),
-			Credentials: credentials.NewSharedCredentials("", u.ProfileName),
-		},
-	})
+	sess, err := u.newSession()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AWS session: %v", err)
+		return nil, err
 	}
 
 	s3Client := s3.New(sess)
 
+	objects, err := u.listObjects(s3Client, prefix)
+	if err != nil {
+		return nil, err
+	}
+
 	var files []string
-	err = s3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+	for _, obj := range objects {
+		files = append(files, *obj.Key)
+	}
+
+	return files, nil
+}
+
+// listObjects returns all objects under prefix, including size and ETag
+func (u *S3Helper) listObjects(s3Client *s3.S3, prefix string) ([]*s3.Object, error) {
+	var objects []*s3.Object
+	err := s3Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
 		Bucket: aws.String(u.BucketName),
 		Prefix: aws.String(prefix),
 	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-		for _, obj := range page.Contents {
-			files = append(files, *obj.Key)
-		}
+		objects = append(objects, page.Contents...)
 		return !lastPage
 	})
 
@@ -106,20 +261,14 @@ func (u *S3Helper) ListFiles(prefix string) ([]string, error) {
 		return nil, fmt.Errorf("failed to list files: %v", err)
 	}
 
-	return files, nil
+	return objects, nil
 }
 
 // DeleteFile deletes a file from S3
 func (u *S3Helper) DeleteFile(s3Path string) error {
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region:      aws.String(u.Region),
-			Endpoint:    aws.String(u.EndpointURL),
-			Credentials: credentials.NewSharedCredentials("", u.ProfileName),
-		},
-	})
+	sess, err := u.newSession()
 	if err != nil {
-		return fmt.Errorf("failed to create AWS session: %v", err)
+		return err
 	}
 
 	s3Client := s3.New(sess)
@@ -138,15 +287,9 @@ func (u *S3Helper) DeleteFile(s3Path string) error {
 
 // DownloadFile downloads a file from S3 to the local filesystem
 func (u *S3Helper) DownloadFile(s3Path, localPath string) error {
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config: aws.Config{
-			Region:      aws.String(u.Region),
-			Endpoint:    aws.String(u.EndpointURL),
-			Credentials: credentials.NewSharedCredentials("", u.ProfileName),
-		},
-	})
+	sess, err := u.newSession()
 	if err != nil {
-		return fmt.Errorf("failed to create AWS session: %v", err)
+		return err
 	}
 
 	s3Client := s3.New(sess)
@@ -175,11 +318,35 @@ func (u *S3Helper) DownloadFile(s3Path, localPath string) error {
 	defer file.Close()
 
 	// Copy the S3 object content to the local file
-	_, err = file.ReadFrom(result.Body)
+	copied, err := iocopy.Copy(context.Background(), file, result.Body, &iocopy.Options{Limiter: u.DownloadLimiter})
 	if err != nil {
 		return fmt.Errorf("failed to write to local file %q: %v", localPath, err)
 	}
 
-	log.Printf("Successfully downloaded s3://%s/%s to %s", u.BucketName, s3Path, localPath)
+	log.Printf("Successfully downloaded s3://%s/%s to %s (%s at %s)", u.BucketName, s3Path, localPath, humanize.Bytes(copied.Bytes), copied.Rate())
 	return nil
 }
+
+// CheckBucket verifies that the bucket exists and the credentials can access it,
+// e.g. for readiness checks
+func (u *S3Helper) CheckBucket(ctx context.Context) error {
+	sess, err := u.newSession()
+	if err != nil {
+		return err
+	}
+
+	s3Client := s3.New(sess)
+
+	_, err = s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(u.BucketName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to access bucket %q: %v", u.BucketName, err)
+	}
+	return nil
+}
+
+// cleanKey normalizes an S3 path by removing leading and trailing slashes
+func cleanKey(s3Path string) string {
+	return pathutil.ToKey(s3Path)
+}
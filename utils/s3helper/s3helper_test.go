@@ -1,11 +1,33 @@
 package s3helper
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
 	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/romisugianto/go-utils/utils/manifest"
+	"github.com/romisugianto/go-utils/utils/storage"
 )
 
 func TestUploadFile(t *testing.T) {
@@ -200,3 +222,1082 @@ func TestDownloadFile(t *testing.T) {
 		t.Errorf("downloaded file is empty")
 	}
 }
+
+func TestNewHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Write the test server certificate as a CA bundle
+	tempDir := t.TempDir()
+	caPath := filepath.Join(tempDir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0644); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	t.Run("default client when no TLS options", func(t *testing.T) {
+		helper := S3Helper{}
+		client, err := helper.newHTTPClient()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client != nil {
+			t.Errorf("expected nil client when no TLS options are set")
+		}
+	})
+
+	t.Run("custom CA bundle is trusted", func(t *testing.T) {
+		helper := S3Helper{CABundlePath: caPath}
+		client, err := helper.newHTTPClient()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request with custom CA failed: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("untrusted without CA bundle", func(t *testing.T) {
+		client := &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+		if _, err := client.Get(server.URL); err == nil {
+			t.Errorf("expected certificate error without CA bundle")
+		}
+	})
+
+	t.Run("insecure skip verify", func(t *testing.T) {
+		helper := S3Helper{InsecureSkipVerify: true}
+		client, err := helper.newHTTPClient()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request with InsecureSkipVerify failed: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("invalid CA bundle", func(t *testing.T) {
+		badPath := filepath.Join(tempDir, "bad.pem")
+		if err := os.WriteFile(badPath, []byte("not a certificate"), 0644); err != nil {
+			t.Fatalf("failed to write bad bundle: %v", err)
+		}
+		helper := S3Helper{CABundlePath: badPath}
+		if _, err := helper.newHTTPClient(); err == nil {
+			t.Errorf("expected error for invalid CA bundle")
+		}
+	})
+
+	t.Run("missing CA bundle", func(t *testing.T) {
+		helper := S3Helper{CABundlePath: filepath.Join(tempDir, "missing.pem")}
+		if _, err := helper.newHTTPClient(); err == nil {
+			t.Errorf("expected error for missing CA bundle")
+		}
+	})
+
+	t.Run("client cert without key", func(t *testing.T) {
+		helper := S3Helper{ClientCertPath: caPath}
+		if _, err := helper.newHTTPClient(); err == nil {
+			t.Errorf("expected error when client key is missing")
+		}
+	})
+}
+
+func TestFileFilter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		filter  FileFilter
+		relPath string
+		isDir   bool
+		want    bool
+	}{
+		{"plain file", FileFilter{}, "data/file.csv", false, true},
+		{"hidden file skipped", FileFilter{}, ".env", false, false},
+		{"file in hidden dir skipped", FileFilter{}, ".git/config", false, false},
+		{"hidden dir skipped", FileFilter{}, ".git", true, false},
+		{"hidden allowed", FileFilter{IncludeHidden: true}, ".env", false, true},
+		{"exclude by extension", FileFilter{Exclude: []string{"*.tmp"}}, "data/part.tmp", false, false},
+		{"exclude lock file", FileFilter{Exclude: []string{"*.lock"}}, "job.lock", false, false},
+		{"exclude dir by name", FileFilter{Exclude: []string{"tmp"}}, "data/tmp", true, false},
+		{"exclude by relative path", FileFilter{Exclude: []string{"data/*.csv"}}, "data/file.csv", false, false},
+		{"include matches", FileFilter{Include: []string{"*.csv"}}, "data/file.csv", false, true},
+		{"include does not match", FileFilter{Include: []string{"*.csv"}}, "data/file.txt", false, false},
+		{"include does not affect dirs", FileFilter{Include: []string{"*.csv"}}, "data", true, true},
+		{"exclude wins over include", FileFilter{Include: []string{"*.csv"}, Exclude: []string{"bad_*"}}, "bad_file.csv", false, false},
+		{"include regex", FileFilter{IncludeRegex: []*regexp.Regexp{regexp.MustCompile(`^\d{8}/`)}}, "20240101/file.csv", false, true},
+		{"exclude regex", FileFilter{ExcludeRegex: []*regexp.Regexp{regexp.MustCompile(`\.part\d+$`)}}, "file.part3", false, false},
+		{"max depth root file", FileFilter{MaxDepth: 1}, "file.csv", false, true},
+		{"max depth nested file", FileFilter{MaxDepth: 1}, "sub/file.csv", false, false},
+		{"max depth dir at limit", FileFilter{MaxDepth: 1}, "sub", true, false},
+		{"max depth dir under limit", FileFilter{MaxDepth: 2}, "sub", true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got bool
+			if tc.isDir {
+				got = tc.filter.MatchDir(tc.relPath)
+			} else {
+				got = tc.filter.MatchFile(tc.relPath)
+			}
+			if got != tc.want {
+				t.Errorf("match(%q) = %v, expected %v", tc.relPath, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFileFilterValidate(t *testing.T) {
+	if err := (&FileFilter{Exclude: []string{"[bad"}}).Validate(); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+	if err := (&FileFilter{MaxDepth: -1}).Validate(); err == nil {
+		t.Error("expected error for negative MaxDepth")
+	}
+	if err := (&FileFilter{Include: []string{"*.csv"}, Exclude: []string{"*.tmp"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"a.csv",
+		"b.tmp",
+		"job.lock",
+		".hidden",
+		".cache/x.csv",
+		"sub/c.csv",
+		"sub/deep/d.csv",
+	}
+	for _, name := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("content"), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		filter   *FileFilter
+		expected []string
+	}{
+		{"nil filter", nil, []string{"a.csv", "b.tmp", "job.lock", "sub/c.csv", "sub/deep/d.csv"}},
+		{"exclude temp and lock", &FileFilter{Exclude: []string{"*.tmp", "*.lock"}}, []string{"a.csv", "sub/c.csv", "sub/deep/d.csv"}},
+		{"max depth 2", &FileFilter{Include: []string{"*.csv"}, MaxDepth: 2}, []string{"a.csv", "sub/c.csv"}},
+		{"include hidden", &FileFilter{Include: []string{"*.csv"}, IncludeHidden: true}, []string{".cache/x.csv", "a.csv", "sub/c.csv", "sub/deep/d.csv"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := collectFiles(root, tc.filter)
+			if err != nil {
+				t.Fatalf("collectFiles failed: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("collectFiles() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+
+	if _, err := collectFiles(filepath.Join(root, "missing"), nil); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestUploadOptionsApply(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := &UploadOptions{
+		CacheControl:       "public, max-age=86400",
+		Expires:            expires,
+		ContentDisposition: `attachment; filename="report.csv"`,
+		ContentLanguage:    "en-US",
+	}
+
+	input := &s3.PutObjectInput{}
+	opts.apply(input)
+
+	if aws.StringValue(input.CacheControl) != opts.CacheControl {
+		t.Errorf("CacheControl = %q, expected %q", aws.StringValue(input.CacheControl), opts.CacheControl)
+	}
+	if !aws.TimeValue(input.Expires).Equal(expires) {
+		t.Errorf("Expires = %v, expected %v", aws.TimeValue(input.Expires), expires)
+	}
+	if aws.StringValue(input.ContentDisposition) != opts.ContentDisposition {
+		t.Errorf("ContentDisposition = %q, expected %q", aws.StringValue(input.ContentDisposition), opts.ContentDisposition)
+	}
+	if aws.StringValue(input.ContentLanguage) != opts.ContentLanguage {
+		t.Errorf("ContentLanguage = %q, expected %q", aws.StringValue(input.ContentLanguage), opts.ContentLanguage)
+	}
+
+	// Nil and empty options leave the request untouched
+	empty := &s3.PutObjectInput{}
+	var nilOpts *UploadOptions
+	nilOpts.apply(empty)
+	(&UploadOptions{}).apply(empty)
+	if empty.CacheControl != nil || empty.Expires != nil || empty.ContentDisposition != nil || empty.ContentLanguage != nil {
+		t.Errorf("expected no headers to be set, got %+v", empty)
+	}
+}
+
+// fakeObject is an object stored by fakeS3
+type fakeObject struct {
+	data     []byte
+	header   http.Header
+	metadata map[string]string
+	tags     map[string]string
+}
+
+// fakeS3 is a minimal in-memory, path-style S3 endpoint for offline tests
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]*fakeObject // "bucket/key" -> object
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: make(map[string]*fakeObject)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	// Provide static credentials so requests can be signed without a real profile
+	credsPath := filepath.Join(t.TempDir(), "credentials")
+	creds := "[default]\naws_access_key_id = AKIATESTTESTTEST\naws_secret_access_key = secret\n"
+	if err := os.WriteFile(credsPath, []byte(creds), 0600); err != nil {
+		t.Fatalf("failed to write credentials: %v", err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsPath)
+	return fake, server
+}
+
+func newFakeHelper(server *httptest.Server, bucket string) *S3Helper {
+	return &S3Helper{
+		ProfileName:    "default",
+		BucketName:     bucket,
+		EndpointURL:    server.URL,
+		Region:         "us-east-1",
+		ForcePathStyle: true,
+	}
+}
+
+func (f *fakeS3) put(bucket, key, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[bucket+"/"+key] = &fakeObject{data: []byte(content), header: http.Header{}, metadata: map[string]string{}, tags: map[string]string{}}
+}
+
+func (f *fakeS3) putTagged(bucket, key, content string, tags, metadata map[string]string) {
+	f.put(bucket, key, content)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj := f.objects[bucket+"/"+key]
+	maps.Copy(obj.tags, tags)
+	maps.Copy(obj.metadata, metadata)
+}
+
+func (f *fakeS3) get(bucket, key string) (*fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[bucket+"/"+key]
+	return obj, ok
+}
+
+func (f *fakeS3) keys(bucket string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for name := range f.objects {
+		if strings.HasPrefix(name, bucket+"/") {
+			keys = append(keys, strings.TrimPrefix(name, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fakeETag(data []byte) string {
+	return fmt.Sprintf("%q", fmt.Sprintf("%x", md5.Sum(data)))
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket := parts[0]
+	key := ""
+	if len(parts) > 1 {
+		key = parts[1]
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	if _, ok := query["delete"]; ok && r.Method == http.MethodPost {
+		f.deleteObjects(w, r, bucket)
+		return
+	}
+	if key == "" && r.Method == http.MethodGet {
+		f.list(w, bucket, query.Get("prefix"))
+		return
+	}
+	// A bucket exists once it holds an object
+	if key == "" && r.Method == http.MethodHead {
+		for name := range f.objects {
+			if strings.HasPrefix(name, bucket+"/") {
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	name := bucket + "/" + key
+	if _, ok := query["tagging"]; ok && r.Method == http.MethodGet {
+		obj, ok := f.objects[name]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "<Tagging><TagSet>")
+		for k, v := range obj.tags {
+			fmt.Fprintf(w, "<Tag><Key>%s</Key><Value>%s</Value></Tag>", k, v)
+		}
+		fmt.Fprint(w, "</TagSet></Tagging>")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		obj := &fakeObject{header: http.Header{}, metadata: map[string]string{}, tags: map[string]string{}}
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			source, _ = url.PathUnescape(source)
+			src, ok := f.objects[strings.TrimPrefix(source, "/")]
+			if !ok {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+			obj.data = src.data
+			maps.Copy(obj.tags, src.tags)
+			if r.Header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+				maps.Copy(obj.metadata, src.metadata)
+			}
+		} else {
+			data, _ := io.ReadAll(r.Body)
+			obj.data = data
+		}
+		for header, values := range r.Header {
+			if strings.HasPrefix(header, "X-Amz-Meta-") {
+				obj.metadata[strings.TrimPrefix(header, "X-Amz-Meta-")] = values[0]
+			} else {
+				obj.header[header] = values
+			}
+		}
+		f.objects[name] = obj
+		w.Header().Set("ETag", fakeETag(obj.data))
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			fmt.Fprintf(w, "<CopyObjectResult><ETag>%s</ETag></CopyObjectResult>", fakeETag(obj.data))
+		}
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+			}
+			return
+		}
+		w.Header().Set("ETag", fakeETag(obj.data))
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.data)))
+		for _, header := range []string{"Content-Type", "Cache-Control", "Content-Disposition", "Content-Language", "Expires"} {
+			if value := obj.header.Get(header); value != "" {
+				w.Header().Set(header, value)
+			}
+		}
+		for k, v := range obj.metadata {
+			w.Header().Set("X-Amz-Meta-"+k, v)
+		}
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	var request struct {
+		Objects []struct {
+			Key string
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "<Error><Code>MalformedXML</Code></Error>", http.StatusBadRequest)
+		return
+	}
+
+	fmt.Fprint(w, "<DeleteResult>")
+	for _, obj := range request.Objects {
+		delete(f.objects, bucket+"/"+obj.Key)
+		fmt.Fprintf(w, "<Deleted><Key>%s</Key></Deleted>", obj.Key)
+	}
+	fmt.Fprint(w, "</DeleteResult>")
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket, prefix string) {
+	type content struct {
+		Key  string
+		ETag string
+		Size int
+	}
+	type listResult struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}
+
+	result := listResult{Name: bucket, Prefix: prefix}
+	var names []string
+	for name := range f.objects {
+		if strings.HasPrefix(name, bucket+"/"+prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		obj := f.objects[name]
+		result.Contents = append(result.Contents, content{
+			Key:  strings.TrimPrefix(name, bucket+"/"),
+			ETag: fakeETag(obj.data),
+			Size: len(obj.data),
+		})
+	}
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func TestSyncKey(t *testing.T) {
+	testCases := []struct {
+		srcPrefix string
+		dstPrefix string
+		srcKey    string
+		expected  string
+	}{
+		{"data/", "backup/", "data/a.csv", "backup/a.csv"},
+		{"data", "backup", "data/sub/a.csv", "backup/sub/a.csv"},
+		{"data/", "", "data/a.csv", "a.csv"},
+		{"", "mirror", "a.csv", "mirror/a.csv"},
+	}
+
+	for _, tc := range testCases {
+		if got := syncKey(tc.srcPrefix, tc.dstPrefix, tc.srcKey); got != tc.expected {
+			t.Errorf("syncKey(%q, %q, %q) = %q, expected %q", tc.srcPrefix, tc.dstPrefix, tc.srcKey, got, tc.expected)
+		}
+	}
+}
+
+// putWithHeaders stores an object with the headers and metadata a sync must keep
+func (f *fakeS3) putWithHeaders(bucket, key, content string) {
+	f.putTagged(bucket, key, content, nil, map[string]string{"Owner": "etl"})
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	header := f.objects[bucket+"/"+key].header
+	header.Set("Content-Type", "text/csv")
+	header.Set("Cache-Control", "max-age=60")
+	header.Set("Content-Disposition", `attachment; filename="a.csv"`)
+	header.Set("Expires", "Wed, 21 Oct 2037 07:28:00 GMT")
+}
+
+// checkSyncedHeaders reports headers or metadata of the source missing on a copy
+func checkSyncedHeaders(t *testing.T, obj *fakeObject) {
+	t.Helper()
+	for header, want := range map[string]string{
+		"Content-Type":        "text/csv",
+		"Cache-Control":       "max-age=60",
+		"Content-Disposition": `attachment; filename="a.csv"`,
+		"Expires":             "Wed, 21 Oct 2037 07:28:00 GMT",
+	} {
+		if got := obj.header.Get(header); got != want {
+			t.Errorf("%s = %q, expected %q", header, got, want)
+		}
+	}
+	if obj.metadata["Owner"] != "etl" || obj.metadata["Source-Etag"] == "" {
+		t.Errorf("expected the source metadata and ETag, got %v", obj.metadata)
+	}
+}
+
+func TestSyncRemote(t *testing.T) {
+	fake, server := newFakeS3(t)
+	src := newFakeHelper(server, "source")
+	// A different profile forces the streamed (cross-account) copy path
+	dst := newFakeHelper(server, "target")
+	dst.ProfileName = "other"
+	other := "[other]\naws_access_key_id = AKIAOTHEROTHER\naws_secret_access_key = secret\n"
+	credsPath := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	existing, _ := os.ReadFile(credsPath)
+	if err := os.WriteFile(credsPath, append(existing, []byte(other)...), 0600); err != nil {
+		t.Fatalf("failed to write credentials: %v", err)
+	}
+
+	fake.putWithHeaders("source", "data/a.csv", "alpha")
+	fake.put("source", "data/sub/b.csv", "bravo")
+	fake.put("source", "database/c.csv", "charlie")      // outside the data directory
+	fake.put("target", "mirror/sub/b.csv", "bravo")      // already in sync
+	fake.put("target", "mirror/orphan.csv", "leftover")  // not in source
+	fake.put("target", "mirrored/keep.csv", "unrelated") // outside the mirror directory
+
+	result, err := src.SyncRemote("data", dst, "mirror")
+	if err != nil {
+		t.Fatalf("SyncRemote failed: %v", err)
+	}
+	if strings.Join(result.Copied, ",") != "mirror/a.csv" {
+		t.Errorf("Copied = %v, expected [mirror/a.csv]", result.Copied)
+	}
+	if strings.Join(result.Skipped, ",") != "mirror/sub/b.csv" {
+		t.Errorf("Skipped = %v, expected [mirror/sub/b.csv]", result.Skipped)
+	}
+	if len(result.Deleted) != 0 {
+		t.Errorf("expected no deletions without deleteOrphans, got %v", result.Deleted)
+	}
+	obj, ok := fake.get("target", "mirror/a.csv")
+	if !ok || string(obj.data) != "alpha" {
+		t.Fatalf("expected mirror/a.csv to be copied")
+	}
+	checkSyncedHeaders(t, obj)
+
+	// Changed content is copied again and orphans are removed on request
+	fake.put("source", "data/sub/b.csv", "bravo-2")
+	result, err = src.SyncRemote("data/", dst, "mirror/", true)
+	if err != nil {
+		t.Fatalf("SyncRemote failed: %v", err)
+	}
+	if strings.Join(result.Copied, ",") != "mirror/sub/b.csv" {
+		t.Errorf("Copied = %v, expected [mirror/sub/b.csv]", result.Copied)
+	}
+	if strings.Join(result.Deleted, ",") != "mirror/orphan.csv" {
+		t.Errorf("Deleted = %v, expected [mirror/orphan.csv]", result.Deleted)
+	}
+	if got := strings.Join(fake.keys("target"), ","); got != "mirror/a.csv,mirror/sub/b.csv,mirrored/keep.csv" {
+		t.Errorf("target keys = %s", got)
+	}
+}
+
+func TestSyncRemoteServerSide(t *testing.T) {
+	fake, server := newFakeS3(t)
+	src := newFakeHelper(server, "source")
+	dst := newFakeHelper(server, "target")
+
+	fake.putWithHeaders("source", "a.csv", "alpha")
+
+	result, err := src.SyncRemote("", dst, "copy")
+	if err != nil {
+		t.Fatalf("SyncRemote failed: %v", err)
+	}
+	if strings.Join(result.Copied, ",") != "copy/a.csv" {
+		t.Errorf("Copied = %v, expected [copy/a.csv]", result.Copied)
+	}
+	obj, ok := fake.get("target", "copy/a.csv")
+	if !ok || string(obj.data) != "alpha" {
+		t.Fatalf("expected copy/a.csv to be copied server-side")
+	}
+	if obj.header.Get("X-Amz-Copy-Source") == "" {
+		t.Errorf("expected a server-side copy")
+	}
+	checkSyncedHeaders(t, obj)
+
+	// Objects too large for CopyObject are streamed
+	defer func(size int64) { maxCopySize = size }(maxCopySize)
+	maxCopySize = 3
+	fake.putWithHeaders("source", "a.csv", "alpha-2")
+	if _, err := src.SyncRemote("", dst, "copy"); err != nil {
+		t.Fatalf("SyncRemote failed: %v", err)
+	}
+	obj, _ = fake.get("target", "copy/a.csv")
+	if string(obj.data) != "alpha-2" || obj.header.Get("X-Amz-Copy-Source") != "" {
+		t.Errorf("expected a streamed copy, got %q", obj.data)
+	}
+	checkSyncedHeaders(t, obj)
+}
+
+func TestUploadBatch(t *testing.T) {
+	fake, server := newFakeS3(t)
+	helper := newFakeHelper(server, "bucket")
+
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "state", "manifest.jsonl")
+	var items []BatchItem
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		items = append(items, BatchItem{FilePath: filepath.Join(dir, name), S3Path: "batch/" + name})
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("content "+name), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	// First run: c.txt is missing and fails, the rest are recorded
+	result, err := helper.UploadBatch(items, manifestPath)
+	if err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	if len(result.Uploaded) != 2 || len(result.Failed) != 1 || result.Failed["batch/c.txt"] == nil {
+		t.Fatalf("unexpected first run result: %+v", result)
+	}
+
+	entries, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(entries) != 2 || entries["batch/a.txt"].MD5 == "" {
+		t.Fatalf("unexpected manifest entries: %+v", entries)
+	}
+
+	// Second run resumes: only the previously failed file is uploaded
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("content c.txt"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	result, err = helper.UploadBatch(items, manifestPath)
+	if err != nil {
+		t.Fatalf("UploadBatch failed: %v", err)
+	}
+	if strings.Join(result.Uploaded, ",") != "batch/c.txt" || len(result.Skipped) != 2 {
+		t.Errorf("unexpected resume result: %+v", result)
+	}
+
+	// A modified file no longer matches its manifest checksum and is uploaded again
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	result, err = helper.UploadBatch(items, manifestPath)
+	if err != nil {
+		t.Fatalf("UploadBatch failed: %v", err)
+	}
+	if strings.Join(result.Uploaded, ",") != "batch/a.txt" {
+		t.Errorf("expected only batch/a.txt to be re-uploaded, got %v", result.Uploaded)
+	}
+	if obj, ok := fake.get("bucket", "batch/a.txt"); !ok || string(obj.data) != "changed" {
+		t.Errorf("expected updated content for batch/a.txt")
+	}
+}
+
+func TestUploadBatchTruncatedManifest(t *testing.T) {
+	_, server := newFakeS3(t)
+	helper := newFakeHelper(server, "bucket")
+
+	// An interrupted run left half an entry without a newline
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.jsonl")
+	content := `{"key":"batch/a.txt","md5":"1"}` + "\n" + `{"key":"batch/b.txt","md`
+	if err := os.WriteFile(manifestPath, []byte(content), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("content c.txt"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	items := []BatchItem{{FilePath: filepath.Join(dir, "c.txt"), S3Path: "batch/c.txt"}}
+	if _, err := helper.UploadBatch(items, manifestPath); err != nil {
+		t.Fatalf("UploadBatch failed: %v", err)
+	}
+	entries, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(entries) != 2 || entries["batch/a.txt"].MD5 != "1" || entries["batch/c.txt"].MD5 == "" {
+		t.Errorf("expected the partial entry to be dropped and the new one kept, got %+v", entries)
+	}
+	data, _ := os.ReadFile(manifestPath)
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); len(lines) != 2 {
+		t.Errorf("expected 2 complete lines, got %q", data)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	entries, err := LoadManifest(filepath.Join(dir, "missing.jsonl"))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected empty manifest for missing file, got %v, %v", entries, err)
+	}
+
+	// A truncated last line from a crash is ignored
+	truncated := filepath.Join(dir, "truncated.jsonl")
+	content := `{"key":"a","md5":"1"}` + "\n" + `{"key":"b","md5":"2"}` + "\n" + `{"key":"c","md`
+	if err := os.WriteFile(truncated, []byte(content), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	entries, err = LoadManifest(truncated)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+
+	// Corruption before the last line is reported
+	corrupt := filepath.Join(dir, "corrupt.jsonl")
+	content = `{"key":"a","md5":"1"}` + "\n" + `garbage` + "\n" + `{"key":"b","md5":"2"}` + "\n"
+	if err := os.WriteFile(corrupt, []byte(content), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := LoadManifest(corrupt); err == nil {
+		t.Error("expected error for corrupt manifest")
+	}
+}
+
+func TestBatchManifest(t *testing.T) {
+	journal := filepath.Join(t.TempDir(), "batch.jsonl")
+	content := `{"key":"b/two.csv","file_path":"data/two.csv","size":20,"md5":"bbbb"}` + "\n" +
+		`{"key":"a/one.csv","file_path":"data/one.csv","size":10,"md5":"aaaa"}` + "\n"
+	if err := os.WriteFile(journal, []byte(content), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	m, err := BatchManifest(journal)
+	if err != nil {
+		t.Fatalf("BatchManifest failed: %v", err)
+	}
+	if m.Kind != "upload" || len(m.Entries) != 2 || m.Entries[0].Key != "a/one.csv" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	if e := m.Entries[1]; e.Path != "data/two.csv" || e.Size != 20 || e.Checksum != "md5:bbbb" || e.Status != manifest.StatusComplete {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+func TestContentTypeOverrides(t *testing.T) {
+	helper := S3Helper{
+		ContentTypes: map[string]string{
+			".dat": "text/csv",
+			"TXT":  "text/x-fixed-width",
+		},
+	}
+
+	testCases := []struct {
+		filename string
+		expected string
+	}{
+		{"export.dat", "text/csv"},
+		{"EXPORT.DAT", "text/csv"},
+		{"report.txt", "text/x-fixed-width"},
+		{"image.png", "image/png"},
+		{"file", "application/octet-stream"},
+	}
+
+	// Overrides win over sniffed content
+	if got := helper.detectContentType("export.dat", []byte("\x1f\x8b\x08")); got != "text/csv" {
+		t.Errorf("expected override to win over content, got %q", got)
+	}
+
+	for _, tc := range testCases {
+		if got := helper.detectContentType(tc.filename, nil); got != tc.expected {
+			t.Errorf("detectContentType(%q) = %q, expected %q", tc.filename, got, tc.expected)
+		}
+	}
+}
+
+func TestUploadFileContentType(t *testing.T) {
+	fake, server := newFakeS3(t)
+	helper := newFakeHelper(server, "bucket")
+	helper.ContentTypes = map[string]string{".dat": "text/csv"}
+
+	filePath := filepath.Join(t.TempDir(), "export.dat")
+	if err := os.WriteFile(filePath, []byte("a,b\n"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if err := helper.UploadFile(filePath, "override/export.dat"); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if err := helper.UploadFileWithOptions(filePath, "explicit/export.dat", &UploadOptions{ContentType: "application/x-custom"}); err != nil {
+		t.Fatalf("UploadFileWithOptions failed: %v", err)
+	}
+
+	if obj, _ := fake.get("bucket", "override/export.dat"); obj == nil || obj.header.Get("Content-Type") != "text/csv" {
+		t.Errorf("expected override content type text/csv")
+	}
+	if obj, _ := fake.get("bucket", "explicit/export.dat"); obj == nil || obj.header.Get("Content-Type") != "application/x-custom" {
+		t.Errorf("expected explicit content type application/x-custom")
+	}
+
+	// Extension-less files are sniffed from their content
+	gzPath := filepath.Join(t.TempDir(), "ORDERS_20240601")
+	if err := os.WriteFile(gzPath, []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := helper.UploadFile(gzPath, "sniffed/ORDERS_20240601"); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if obj, _ := fake.get("bucket", "sniffed/ORDERS_20240601"); obj == nil || obj.header.Get("Content-Type") != "application/x-gzip" {
+		t.Errorf("expected sniffed content type application/x-gzip")
+	}
+	if err := helper.Put(context.Background(), "sniffed/stream", strings.NewReader(`{"id": 1}`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if obj, _ := fake.get("bucket", "sniffed/stream"); obj == nil || obj.header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected sniffed content type application/json for Put")
+	}
+}
+
+func TestAssumeRoleOptions(t *testing.T) {
+	helper := S3Helper{
+		RoleARN:         "arn:aws:iam::123456789012:role/delivery",
+		ExternalID:      "partner-42",
+		RoleSessionName: "nightly-delivery",
+		RoleDuration:    time.Hour,
+	}
+
+	provider := &stscreds.AssumeRoleProvider{}
+	helper.assumeRoleOptions(provider)
+
+	if aws.StringValue(provider.ExternalID) != "partner-42" {
+		t.Errorf("ExternalID = %q, expected %q", aws.StringValue(provider.ExternalID), "partner-42")
+	}
+	if provider.RoleSessionName != "nightly-delivery" {
+		t.Errorf("RoleSessionName = %q, expected %q", provider.RoleSessionName, "nightly-delivery")
+	}
+	if provider.Duration != time.Hour {
+		t.Errorf("Duration = %v, expected %v", provider.Duration, time.Hour)
+	}
+	if provider.ExpiryWindow <= 0 {
+		t.Errorf("expected a positive ExpiryWindow for early refresh")
+	}
+
+	// Unset fields keep the SDK defaults
+	defaults := &stscreds.AssumeRoleProvider{}
+	(&S3Helper{RoleARN: helper.RoleARN}).assumeRoleOptions(defaults)
+	if defaults.ExternalID != nil || defaults.RoleSessionName != "" || defaults.Duration != 0 {
+		t.Errorf("expected SDK defaults, got %+v", defaults)
+	}
+}
+
+func TestNewSessionWithRole(t *testing.T) {
+	helper := S3Helper{
+		ProfileName: "default",
+		EndpointURL: "https://minio.internal:9000",
+		Region:      "us-east-1",
+		RoleARN:     "arn:aws:iam::123456789012:role/delivery",
+	}
+
+	sess, err := helper.newSession()
+	if err != nil {
+		t.Fatalf("newSession failed: %v", err)
+	}
+	if aws.StringValue(sess.Config.Endpoint) != helper.EndpointURL {
+		t.Errorf("Endpoint = %q, expected %q", aws.StringValue(sess.Config.Endpoint), helper.EndpointURL)
+	}
+	if sess.Config.Credentials == nil {
+		t.Error("expected assumed-role credentials to be configured")
+	}
+}
+
+func TestNewSessionAccelerate(t *testing.T) {
+	helper := S3Helper{
+		ProfileName:   "default",
+		EndpointURL:   "https://s3.amazonaws.com",
+		Region:        "us-east-1",
+		UseAccelerate: true,
+	}
+
+	sess, err := helper.newSession()
+	if err != nil {
+		t.Fatalf("newSession failed: %v", err)
+	}
+	if !aws.BoolValue(sess.Config.S3UseAccelerate) {
+		t.Error("expected S3UseAccelerate to be enabled")
+	}
+	if sess.Config.Endpoint != nil {
+		t.Errorf("expected custom endpoint to be dropped, got %q", aws.StringValue(sess.Config.Endpoint))
+	}
+
+	// Acceleration requires virtual-hosted addressing
+	helper.ForcePathStyle = true
+	if _, err := helper.newSession(); err == nil {
+		t.Error("expected error when combining UseAccelerate with ForcePathStyle")
+	}
+}
+
+func TestPresignGetURL(t *testing.T) {
+	_, server := newFakeS3(t)
+	helper := newFakeHelper(server, "bucket")
+
+	presigned, err := helper.PresignGetURL("/reports/2024-01.csv", 15*time.Minute, &ResponseOverrides{
+		ContentDisposition: Attachment("January report.csv"),
+		ContentType:        "text/csv",
+	})
+	if err != nil {
+		t.Fatalf("PresignGetURL failed: %v", err)
+	}
+
+	parsed, err := url.Parse(presigned)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", presigned, err)
+	}
+	if parsed.Path != "/bucket/reports/2024-01.csv" {
+		t.Errorf("path = %q, expected %q", parsed.Path, "/bucket/reports/2024-01.csv")
+	}
+	query := parsed.Query()
+	if got := query.Get("response-content-disposition"); got != `attachment; filename="January report.csv"` {
+		t.Errorf("response-content-disposition = %q", got)
+	}
+	if got := query.Get("response-content-type"); got != "text/csv" {
+		t.Errorf("response-content-type = %q", got)
+	}
+	if query.Get("X-Amz-Signature") == "" || query.Get("X-Amz-Expires") != "900" {
+		t.Errorf("expected a signed URL expiring in 900 seconds, got %q", presigned)
+	}
+
+	// Without overrides no response-* parameters are added
+	plain, err := helper.PresignGetURL("reports/2024-01.csv", time.Minute, nil)
+	if err != nil {
+		t.Fatalf("PresignGetURL failed: %v", err)
+	}
+	if strings.Contains(plain, "response-content") {
+		t.Errorf("unexpected response overrides in %q", plain)
+	}
+
+	if _, err := helper.PresignGetURL("reports/2024-01.csv", 0, nil); err == nil {
+		t.Error("expected error for non-positive expiry")
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	if got := Attachment("report.csv"); got != "attachment; filename=report.csv" {
+		t.Errorf("Attachment(ascii) = %q", got)
+	}
+	if got := Attachment("résumé.pdf"); !strings.Contains(got, "filename*=utf-8''r%C3%A9sum%C3%A9.pdf") {
+		t.Errorf("Attachment(non-ascii) = %q", got)
+	}
+}
+
+func TestFilteredOperations(t *testing.T) {
+	fake, server := newFakeS3(t)
+	helper := newFakeHelper(server, "bucket")
+
+	fake.putTagged("bucket", "work/a.csv", "a", map[string]string{"temp": "true"}, nil)
+	fake.putTagged("bucket", "work/b.csv", "b", map[string]string{"temp": "false"}, nil)
+	fake.putTagged("bucket", "work/c.csv", "c", map[string]string{"temp": "true", "owner": "etl"}, map[string]string{"Source": "splitter"})
+	fake.putTagged("bucket", "other/d.csv", "d", map[string]string{"temp": "true"}, nil)
+
+	keys, err := helper.ListFilesFiltered("work/", ObjectFilter{Tags: map[string]string{"temp": "true"}})
+	if err != nil {
+		t.Fatalf("ListFilesFiltered failed: %v", err)
+	}
+	if strings.Join(keys, ",") != "work/a.csv,work/c.csv" {
+		t.Errorf("ListFilesFiltered by tag = %v", keys)
+	}
+
+	keys, err = helper.ListFilesFiltered("work/", ObjectFilter{Metadata: map[string]string{"source": "splitter"}})
+	if err != nil {
+		t.Fatalf("ListFilesFiltered failed: %v", err)
+	}
+	if strings.Join(keys, ",") != "work/c.csv" {
+		t.Errorf("ListFilesFiltered by metadata = %v", keys)
+	}
+
+	copied, err := helper.CopyFilesFiltered("work/", ObjectFilter{Tags: map[string]string{"owner": "etl"}}, "archive/")
+	if err != nil {
+		t.Fatalf("CopyFilesFiltered failed: %v", err)
+	}
+	if strings.Join(copied, ",") != "archive/c.csv" {
+		t.Errorf("CopyFilesFiltered = %v", copied)
+	}
+
+	deleted, err := helper.DeleteFilesFiltered("work/", ObjectFilter{Tags: map[string]string{"temp": "true"}})
+	if err != nil {
+		t.Fatalf("DeleteFilesFiltered failed: %v", err)
+	}
+	if strings.Join(deleted, ",") != "work/a.csv,work/c.csv" {
+		t.Errorf("DeleteFilesFiltered = %v", deleted)
+	}
+	if got := strings.Join(fake.keys("bucket"), ","); got != "archive/c.csv,other/d.csv,work/b.csv" {
+		t.Errorf("remaining keys = %s", got)
+	}
+
+	// An empty filter is rejected rather than matching everything
+	if _, err := helper.DeleteFilesFiltered("work/", ObjectFilter{}); err == nil {
+		t.Error("expected error for empty filter")
+	}
+}
+
+func TestCheckBucket(t *testing.T) {
+	fake, server := newFakeS3(t)
+	fake.put("reports", "daily/report.csv", "a,b")
+
+	if err := newFakeHelper(server, "reports").CheckBucket(context.Background()); err != nil {
+		t.Errorf("CheckBucket failed: %v", err)
+	}
+	if err := newFakeHelper(server, "missing").CheckBucket(context.Background()); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+}
+
+func TestBackend(t *testing.T) {
+	fake, server := newFakeS3(t)
+	helper := newFakeHelper(server, "deliveries")
+	ctx := context.Background()
+
+	if err := helper.Put(ctx, "/acme/export.csv", strings.NewReader("id,name\n1,acme\n")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	obj, ok := fake.get("deliveries", "acme/export.csv")
+	if !ok {
+		t.Fatalf("object not stored, have %v", fake.keys("deliveries"))
+	}
+	if obj.header.Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", obj.header.Get("Content-Type"))
+	}
+	fake.put("deliveries", "acme/b.csv", "b")
+	fake.put("deliveries", "other/c.csv", "c")
+
+	r, err := helper.Get(ctx, "acme/export.csv")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "id,name\n1,acme\n" {
+		t.Errorf("Get content = %q", data)
+	}
+
+	objects, err := helper.List(ctx, "acme/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != "acme/b.csv" || objects[1].Key != "acme/export.csv" || objects[1].Size != 15 || objects[1].ETag == "" {
+		t.Errorf("List = %+v", objects)
+	}
+
+	info, err := helper.Stat(ctx, "acme/export.csv")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Key != "acme/export.csv" || info.Size != 15 || info.ETag != objects[1].ETag {
+		t.Errorf("Stat = %+v", info)
+	}
+
+	if err := helper.Delete(ctx, "acme/export.csv"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := helper.Stat(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Stat, got %v", err)
+	}
+	if _, err := helper.Get(ctx, "acme/export.csv"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Get, got %v", err)
+	}
+}
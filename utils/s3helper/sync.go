@@ -0,0 +1,279 @@
+package s3helper
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// sourceETagKey is the metadata key recording the source ETag on synced objects,
+// so streamed (possibly multipart) copies can still be compared on later runs
+const sourceETagKey = "Source-Etag"
+
+// maxCopySize is the largest object CopyObject accepts; larger objects are
+// streamed through a multipart upload instead
+var maxCopySize int64 = 5 << 30
+
+// SyncResult summarizes the work done by SyncRemote
+type SyncResult struct {
+	Copied  []string // destination keys written
+	Skipped []string // destination keys already up to date
+	Deleted []string // orphaned destination keys removed
+}
+
+// SyncRemote mirrors the objects under srcPrefix to dstPrefix on the destination helper,
+// which may use a different bucket, endpoint, or account. Prefixes are directories, so
+// "data" mirrors "data/..." but not "database/...". Objects whose size and ETag
+// already match are skipped. Copies keep the content type and other headers and the
+// user metadata of the source. When deleteOrphans is true, destination objects with no
+// matching source object are deleted.
+func (u *S3Helper) SyncRemote(srcPrefix string, dst *S3Helper, dstPrefix string, deleteOrphans ...bool) (*SyncResult, error) {
+	if dst == nil {
+		return nil, fmt.Errorf("destination helper cannot be nil")
+	}
+
+	deleteFlag := false
+	if len(deleteOrphans) > 0 {
+		deleteFlag = deleteOrphans[0]
+	}
+
+	srcSess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	srcClient := s3.New(srcSess)
+
+	dstSess, err := dst.newSession()
+	if err != nil {
+		return nil, err
+	}
+	dstClient := s3.New(dstSess)
+
+	srcPrefix, dstPrefix = dirPrefix(srcPrefix), dirPrefix(dstPrefix)
+	srcObjects, err := u.listObjects(srcClient, srcPrefix)
+	if err != nil {
+		return nil, err
+	}
+	dstObjects, err := dst.listObjects(dstClient, dstPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]*s3.Object, len(dstObjects))
+	for _, obj := range dstObjects {
+		existing[*obj.Key] = obj
+	}
+
+	serverSide := u.sameEndpoint(dst)
+	uploader := s3manager.NewUploaderWithClient(dstClient)
+	result := &SyncResult{}
+	wanted := make(map[string]bool, len(srcObjects))
+
+	for _, src := range srcObjects {
+		srcKey := aws.StringValue(src.Key)
+		if strings.HasSuffix(srcKey, "/") {
+			continue // skip directory placeholder objects
+		}
+		dstKey := syncKey(srcPrefix, dstPrefix, srcKey)
+		wanted[dstKey] = true
+
+		if current, ok := existing[dstKey]; ok {
+			upToDate, err := dst.inSync(dstClient, src, current)
+			if err != nil {
+				return result, err
+			}
+			if upToDate {
+				result.Skipped = append(result.Skipped, dstKey)
+				continue
+			}
+		}
+
+		if serverSide && aws.Int64Value(src.Size) <= maxCopySize {
+			err = u.copyObject(srcClient, srcKey, dst.BucketName, dstKey, aws.StringValue(src.ETag))
+		} else {
+			err = u.streamObject(srcClient, uploader, srcKey, dst.BucketName, dstKey, aws.StringValue(src.ETag))
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Copied = append(result.Copied, dstKey)
+	}
+
+	if deleteFlag {
+		var orphans []string
+		for key := range existing {
+			if !wanted[key] && !strings.HasSuffix(key, "/") {
+				orphans = append(orphans, key)
+			}
+		}
+		sort.Strings(orphans)
+		for _, key := range orphans {
+			if _, err := dstClient.DeleteObject(&s3.DeleteObjectInput{
+				Bucket: aws.String(dst.BucketName),
+				Key:    aws.String(key),
+			}); err != nil {
+				return result, fmt.Errorf("failed to delete orphan %q: %v", key, err)
+			}
+			result.Deleted = append(result.Deleted, key)
+		}
+	}
+
+	log.Printf("Synced s3://%s/%s to s3://%s/%s (copied: %d, skipped: %d, deleted: %d)",
+		u.BucketName, srcPrefix, dst.BucketName, dstPrefix, len(result.Copied), len(result.Skipped), len(result.Deleted))
+	return result, nil
+}
+
+// inSync reports whether the destination object already matches the source object.
+// Sizes must match, and the ETags must match either directly or via the
+// source ETag recorded in the destination metadata by a previous sync.
+func (u *S3Helper) inSync(s3Client *s3.S3, src, current *s3.Object) (bool, error) {
+	if aws.Int64Value(src.Size) != aws.Int64Value(current.Size) {
+		return false, nil
+	}
+	srcETag := normalizeETag(aws.StringValue(src.ETag))
+	if srcETag == normalizeETag(aws.StringValue(current.ETag)) {
+		return true, nil
+	}
+
+	head, err := s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    current.Key,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get metadata for %q: %v", aws.StringValue(current.Key), err)
+	}
+	for key, value := range head.Metadata {
+		if strings.EqualFold(key, sourceETagKey) {
+			return normalizeETag(aws.StringValue(value)) == srcETag, nil
+		}
+	}
+	return false, nil
+}
+
+// copyObject performs a server-side copy within the same endpoint. Replacing
+// the metadata to add the source ETag drops everything else, so the headers
+// and metadata of the source are sent again.
+func (u *S3Helper) copyObject(s3Client *s3.S3, srcKey, dstBucket, dstKey, srcETag string) error {
+	head, err := s3Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get metadata for %q: %v", srcKey, err)
+	}
+
+	_, err = s3Client.CopyObject(&s3.CopyObjectInput{
+		Bucket:             aws.String(dstBucket),
+		Key:                aws.String(dstKey),
+		CopySource:         aws.String(url.PathEscape(u.BucketName + "/" + srcKey)),
+		MetadataDirective:  aws.String(s3.MetadataDirectiveReplace),
+		Metadata:           syncMetadata(head.Metadata, srcETag),
+		CacheControl:       head.CacheControl,
+		ContentDisposition: head.ContentDisposition,
+		ContentEncoding:    head.ContentEncoding,
+		ContentLanguage:    head.ContentLanguage,
+		ContentType:        head.ContentType,
+		Expires:            parseExpires(head.Expires),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy %q to %q: %v", srcKey, dstKey, err)
+	}
+	return nil
+}
+
+// streamObject downloads the source object and uploads it to the destination client,
+// keeping its headers and metadata
+func (u *S3Helper) streamObject(srcClient *s3.S3, uploader *s3manager.Uploader, srcKey, dstBucket, dstKey, srcETag string) error {
+	result, err := srcClient.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(u.BucketName),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object %q from S3: %v", srcKey, err)
+	}
+	defer result.Body.Close()
+
+	_, err = uploader.Upload(&s3manager.UploadInput{
+		Bucket:             aws.String(dstBucket),
+		Key:                aws.String(dstKey),
+		Body:               result.Body,
+		Metadata:           syncMetadata(result.Metadata, srcETag),
+		CacheControl:       result.CacheControl,
+		ContentDisposition: result.ContentDisposition,
+		ContentEncoding:    result.ContentEncoding,
+		ContentLanguage:    result.ContentLanguage,
+		ContentType:        result.ContentType,
+		Expires:            parseExpires(result.Expires),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %q to destination: %v", dstKey, err)
+	}
+	return nil
+}
+
+// syncMetadata returns the user metadata of a source object with its ETag
+// recorded under sourceETagKey
+func syncMetadata(metadata map[string]*string, srcETag string) map[string]*string {
+	result := make(map[string]*string, len(metadata)+1)
+	for key, value := range metadata {
+		if !strings.EqualFold(key, sourceETagKey) {
+			result[key] = value
+		}
+	}
+	result[sourceETagKey] = aws.String(normalizeETag(srcETag))
+	return result
+}
+
+// parseExpires parses the Expires header of a source object, ignoring
+// values that are not valid HTTP dates
+func parseExpires(expires *string) *time.Time {
+	if expires == nil {
+		return nil
+	}
+	t, err := http.ParseTime(*expires)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// sameEndpoint reports whether both helpers talk to the same endpoint with the same
+// credentials, in which case objects can be copied server-side
+func (u *S3Helper) sameEndpoint(other *S3Helper) bool {
+	return u.EndpointURL == other.EndpointURL &&
+		u.Region == other.Region &&
+		u.ProfileName == other.ProfileName &&
+		u.RoleARN == other.RoleARN
+}
+
+// dirPrefix returns prefix ending with "/", so it only matches keys in that
+// directory; an empty prefix matches the whole bucket
+func dirPrefix(prefix string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix + "/"
+}
+
+// syncKey maps a source key under srcPrefix to the corresponding destination key
+func syncKey(srcPrefix, dstPrefix, srcKey string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(srcKey, srcPrefix), "/")
+	if dstPrefix == "" {
+		return rel
+	}
+	return strings.TrimPrefix(path.Join(dstPrefix, rel), "/")
+}
+
+// normalizeETag strips the surrounding quotes S3 returns on ETag values
+func normalizeETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
@@ -0,0 +1,228 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Job is a scheduled function. The context is cancelled when the scheduler
+// stops or the job's Timeout elapses.
+type Job func(ctx context.Context) error
+
+// JobOptions configures how a job runs
+type JobOptions struct {
+	// Jitter delays each run by a random duration up to this value, spreading
+	// load when many jobs share a schedule
+	Jitter time.Duration
+	// AllowOverlap starts a new run even if the previous one is still running;
+	// by default the new run is skipped
+	AllowOverlap bool
+	// Timeout cancels the job's context after this duration (0 for no limit)
+	Timeout time.Duration
+}
+
+// Scheduler runs registered jobs on cron schedules or fixed intervals
+type Scheduler struct {
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	jobs    []*entry
+	cancel  context.CancelFunc
+	loops   sync.WaitGroup
+	running sync.WaitGroup
+}
+
+// entry is a registered job and its run state
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+	opts     JobOptions
+
+	mu     sync.Mutex
+	active bool
+}
+
+// NewScheduler creates a new Scheduler instance
+func NewScheduler(log *logger.Logger) (*Scheduler, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	return &Scheduler{logger: log}, nil
+}
+
+// AddCron registers a job using a cron expression or descriptor (see ParseCron).
+// A nil opts uses the defaults.
+func (s *Scheduler) AddCron(name, expr string, job Job, opts *JobOptions) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return fmt.Errorf("failed to add job %s: %w", name, err)
+	}
+	return s.Add(name, schedule, job, opts)
+}
+
+// AddInterval registers a job that runs every interval, starting one interval after Start
+func (s *Scheduler) AddInterval(name string, interval time.Duration, job Job, opts *JobOptions) error {
+	if interval <= 0 {
+		return fmt.Errorf("failed to add job %s: interval must be positive, got %s", name, interval)
+	}
+	return s.Add(name, everySchedule{interval: interval}, job, opts)
+}
+
+// Add registers a job with a custom Schedule
+func (s *Scheduler) Add(name string, schedule Schedule, job Job, opts *JobOptions) error {
+	if job == nil {
+		return fmt.Errorf("failed to add job %s: job cannot be nil", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.jobs {
+		if existing.name == name {
+			return fmt.Errorf("failed to add job %s: a job with this name already exists", name)
+		}
+	}
+
+	e := &entry{name: name, schedule: schedule, job: job}
+	if opts != nil {
+		e.opts = *opts
+	}
+	s.jobs = append(s.jobs, e)
+	return nil
+}
+
+// Start runs the registered jobs in the background until ctx is done or Stop is called
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return fmt.Errorf("scheduler is already running")
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, e := range s.jobs {
+		s.loops.Add(1)
+		go s.loop(ctx, e)
+	}
+	s.logger.Info("Scheduler started with %d jobs", len(s.jobs))
+	return nil
+}
+
+// Stop stops scheduling new runs, cancels running jobs' contexts and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	s.loops.Wait()
+	s.running.Wait()
+	s.logger.Info("Scheduler stopped")
+}
+
+// RunNow runs a registered job immediately, respecting its overlap setting
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	s.mu.Lock()
+	var target *entry
+	for _, e := range s.jobs {
+		if e.name == name {
+			target = e
+		}
+	}
+	s.mu.Unlock()
+	if target == nil {
+		return fmt.Errorf("job %s not found", name)
+	}
+
+	if !s.begin(target) {
+		return fmt.Errorf("job %s is already running", name)
+	}
+	return s.run(ctx, target)
+}
+
+// loop waits for each activation of the job until ctx is done
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.loops.Done()
+
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Warning("Job %s has no future runs, stopping its schedule", e.name)
+			return
+		}
+		if e.opts.Jitter > 0 {
+			next = next.Add(rand.N(e.opts.Jitter))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.begin(e) {
+			s.logger.Warning("Skipping job %s: previous run is still in progress", e.name)
+			continue
+		}
+		go s.run(ctx, e)
+	}
+}
+
+// begin marks the job as running, returning false if it is running and overlap is not allowed
+func (s *Scheduler) begin(e *entry) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.active && !e.opts.AllowOverlap {
+		return false
+	}
+	e.active = true
+	s.running.Add(1)
+	return true
+}
+
+// run executes the job with timing, error logging and panic recovery
+func (s *Scheduler) run(ctx context.Context, e *entry) (err error) {
+	defer s.running.Done()
+	defer func() {
+		e.mu.Lock()
+		e.active = false
+		e.mu.Unlock()
+	}()
+
+	if e.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.opts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	s.logger.Info("Job %s started", e.name)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v", e.name, r)
+			s.logger.Error("%v\n%s", err, debug.Stack())
+			return
+		}
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			s.logger.Error("Job %s failed after %s: %v", e.name, elapsed, err)
+			return
+		}
+		s.logger.Info("Job %s completed in %s", e.name, elapsed)
+	}()
+
+	return e.job(ctx)
+}
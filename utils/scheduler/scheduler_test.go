@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func TestParseCronNext(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC) // Monday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 1, 16, 2, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2024, 1, 15, 13, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * sat,sun", time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2024, 1, 21, 8, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		// Restricted day-of-month and day-of-week match either
+		{"0 0 20 * mon", time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron failed: %v", err)
+			}
+			if got := schedule.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next = %s, expected %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@every -1m", "@every soon"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+
+	// Valid but impossible dates never fire
+	schedule, _ := ParseCron("0 0 30 2 *")
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected zero time for impossible schedule, got %s", next)
+	}
+}
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("scheduler_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	s, err := NewScheduler(testLogger)
+	if err != nil {
+		t.Fatalf("NewScheduler failed: %v", err)
+	}
+	return s
+}
+
+func TestSchedulerRunsIntervalJobs(t *testing.T) {
+	s := newTestScheduler(t)
+
+	var runs atomic.Int32
+	var panics atomic.Int32
+	s.AddInterval("count", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}, nil)
+	s.AddInterval("panicky", 10*time.Millisecond, func(ctx context.Context) error {
+		panics.Add(1)
+		panic("boom")
+	}, nil)
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := s.Start(context.Background()); err == nil {
+		t.Error("expected error starting twice")
+	}
+	time.Sleep(100 * time.Millisecond)
+	s.Stop()
+
+	if runs.Load() < 3 {
+		t.Errorf("expected at least 3 runs, got %d", runs.Load())
+	}
+	// A panicking job keeps being scheduled
+	if panics.Load() < 3 {
+		t.Errorf("expected panicking job to keep running, got %d runs", panics.Load())
+	}
+
+	after := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != after {
+		t.Error("jobs ran after Stop")
+	}
+}
+
+func TestSchedulerPreventsOverlap(t *testing.T) {
+	for _, allowOverlap := range []bool{false, true} {
+		s := newTestScheduler(t)
+
+		var concurrent, maxConcurrent atomic.Int32
+		s.AddInterval("slow", 5*time.Millisecond, func(ctx context.Context) error {
+			n := concurrent.Add(1)
+			defer concurrent.Add(-1)
+			if n > maxConcurrent.Load() {
+				maxConcurrent.Store(n)
+			}
+			select {
+			case <-time.After(40 * time.Millisecond):
+			case <-ctx.Done():
+			}
+			return nil
+		}, &JobOptions{AllowOverlap: allowOverlap})
+
+		s.Start(context.Background())
+		time.Sleep(80 * time.Millisecond)
+		s.Stop()
+
+		if !allowOverlap && maxConcurrent.Load() != 1 {
+			t.Errorf("overlap prevented: max concurrent runs = %d, expected 1", maxConcurrent.Load())
+		}
+		if allowOverlap && maxConcurrent.Load() < 2 {
+			t.Errorf("overlap allowed: max concurrent runs = %d, expected at least 2", maxConcurrent.Load())
+		}
+	}
+}
+
+func TestRunNow(t *testing.T) {
+	s := newTestScheduler(t)
+
+	s.AddCron("nightly", "0 2 * * *", func(ctx context.Context) error {
+		return errors.New("upload failed")
+	}, nil)
+	s.AddCron("timeout", "@daily", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, &JobOptions{Timeout: 10 * time.Millisecond})
+
+	if err := s.RunNow(context.Background(), "nightly"); err == nil || err.Error() != "upload failed" {
+		t.Errorf("RunNow error = %v", err)
+	}
+	if err := s.RunNow(context.Background(), "timeout"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if err := s.RunNow(context.Background(), "missing"); err == nil {
+		t.Error("expected error for unknown job")
+	}
+}
+
+func TestAddValidation(t *testing.T) {
+	s := newTestScheduler(t)
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := s.AddCron("bad", "not a cron", noop, nil); err == nil {
+		t.Error("expected error for invalid expression")
+	}
+	if err := s.AddInterval("zero", 0, noop, nil); err == nil {
+		t.Error("expected error for zero interval")
+	}
+	if err := s.AddCron("nil", "@daily", nil, nil); err == nil {
+		t.Error("expected error for nil job")
+	}
+	s.AddCron("dup", "@daily", noop, nil)
+	if err := s.AddCron("dup", "@hourly", noop, nil); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected duplicate name error, got %v", err)
+	}
+	if _, err := NewScheduler(nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+}
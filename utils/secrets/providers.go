@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+
+	"github.com/romisugianto/go-utils/utils/pathutil"
+)
+
+// AWSConfig selects the AWS account and endpoint used by the AWS providers
+type AWSConfig struct {
+	ProfileName string
+	Region      string
+	// EndpointURL overrides the service endpoint, e.g. for LocalStack
+	EndpointURL string
+}
+
+// newSession creates an AWS session from the configuration
+func (c AWSConfig) newSession() (*session.Session, error) {
+	config := aws.Config{
+		Region:      aws.String(c.Region),
+		Credentials: credentials.NewSharedCredentials("", c.ProfileName),
+	}
+	if c.EndpointURL != "" {
+		config.Endpoint = aws.String(c.EndpointURL)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{Config: config})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %v", err)
+	}
+	return sess, nil
+}
+
+// SecretsManager reads secrets from AWS Secrets Manager by name or ARN
+type SecretsManager struct {
+	AWSConfig
+	// VersionStage selects the version to read (default AWSCURRENT)
+	VersionStage string
+
+	mu     sync.Mutex
+	client *secretsmanager.SecretsManager
+}
+
+// Name identifies the provider in logs
+func (p *SecretsManager) Name() string { return "secretsmanager" }
+
+// Get returns the secret string, or the binary value for binary secrets
+func (p *SecretsManager) Get(ctx context.Context, name string) (string, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)}
+	if p.VersionStage != "" {
+		input.VersionStage = aws.String(p.VersionStage)
+	}
+	output, err := client.GetSecretValueWithContext(ctx, input)
+	if err != nil {
+		return "", awsError(err, secretsmanager.ErrCodeResourceNotFoundException)
+	}
+	if output.SecretString != nil {
+		return *output.SecretString, nil
+	}
+	return string(output.SecretBinary), nil
+}
+
+// getClient creates the service client on first use
+func (p *SecretsManager) getClient() (*secretsmanager.SecretsManager, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		sess, err := p.newSession()
+		if err != nil {
+			return nil, err
+		}
+		p.client = secretsmanager.New(sess)
+	}
+	return p.client, nil
+}
+
+// ParameterStore reads parameters from AWS Systems Manager Parameter Store.
+// SecureString parameters are decrypted.
+type ParameterStore struct {
+	AWSConfig
+	// Prefix is prepended to names, e.g. "/prod/etl/"
+	Prefix string
+
+	mu     sync.Mutex
+	client *ssm.SSM
+}
+
+// Name identifies the provider in logs
+func (p *ParameterStore) Name() string { return "ssm" }
+
+// Get returns the parameter value
+func (p *ParameterStore) Get(ctx context.Context, name string) (string, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(p.Prefix + name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", awsError(err, ssm.ErrCodeParameterNotFound)
+	}
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+// getClient creates the service client on first use
+func (p *ParameterStore) getClient() (*ssm.SSM, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		sess, err := p.newSession()
+		if err != nil {
+			return nil, err
+		}
+		p.client = ssm.New(sess)
+	}
+	return p.client, nil
+}
+
+// awsError maps the service's not-found error code to ErrNotFound
+func awsError(err error, notFoundCode string) error {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == notFoundCode {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Env reads secrets from environment variables. A name such as "db/password"
+// becomes PREFIX_DB_PASSWORD.
+type Env struct {
+	Prefix string
+}
+
+// Name identifies the provider in logs
+func (p Env) Name() string { return "env" }
+
+// Get returns the environment variable for name
+func (p Env) Get(ctx context.Context, name string) (string, error) {
+	key := strings.ToUpper(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.Trim(name, "/")))
+	if p.Prefix != "" {
+		key = strings.TrimSuffix(p.Prefix, "_") + "_" + key
+	}
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Files reads secrets from files in a directory, such as Docker or Kubernetes
+// secret mounts. Trailing newlines are removed.
+type Files struct {
+	Dir string
+}
+
+// Name identifies the provider in logs
+func (p Files) Name() string { return "files" }
+
+// Get returns the content of Dir/name
+func (p Files) Get(ctx context.Context, name string) (string, error) {
+	path, err := pathutil.SafeJoin(p.Dir, name)
+	if err != nil {
+		return "", fmt.Errorf("secret name %q escapes %s", name, p.Dir)
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
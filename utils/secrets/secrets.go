@@ -0,0 +1,167 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// ErrNotFound is returned by a provider that does not hold the secret, so the
+// store tries the next provider
+var ErrNotFound = errors.New("secret not found")
+
+// Provider fetches secret values by name
+type Provider interface {
+	Name() string
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Options configures a Store
+type Options struct {
+	// TTL is how long a value is cached before it is fetched again (default 5m)
+	TTL time.Duration
+	// ServeStale returns the last known value when a refresh fails, e.g.
+	// during a provider outage
+	ServeStale bool
+}
+
+// Store resolves secrets from an ordered list of providers and caches them
+type Store struct {
+	logger    *logger.Logger
+	opts      Options
+	providers []Provider
+
+	mu       sync.Mutex
+	cache    map[string]cached
+	onChange map[string][]func(value string)
+}
+
+// cached is a fetched secret value
+type cached struct {
+	value    string
+	provider string
+	fetched  time.Time
+}
+
+// NewStore creates a store that tries providers in order, e.g. Secrets Manager,
+// then environment variables. A nil opts uses the defaults.
+func NewStore(log *logger.Logger, opts *Options, providers ...Provider) (*Store, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("at least one provider is required")
+	}
+	s := &Store{
+		logger:    log,
+		providers: providers,
+		cache:     map[string]cached{},
+		onChange:  map[string][]func(string){},
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.TTL <= 0 {
+		s.opts.TTL = 5 * time.Minute
+	}
+	return s, nil
+}
+
+// Get returns the secret, fetching it when it is not cached or the TTL expired
+func (s *Store) Get(ctx context.Context, name string) (string, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && time.Since(entry.fetched) < s.opts.TTL {
+		return entry.value, nil
+	}
+	return s.fetch(ctx, name, entry, ok)
+}
+
+// Refresh fetches the secret again, bypassing the cache. Call it after a
+// credential is rejected, since the secret may have been rotated.
+func (s *Store) Refresh(ctx context.Context, name string) (string, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[name]
+	s.mu.Unlock()
+	return s.fetch(ctx, name, entry, ok)
+}
+
+// GetJSON decodes a JSON secret, such as a Secrets Manager credential, into dst
+func (s *Store) GetJSON(ctx context.Context, name string, dst any) error {
+	value, err := s.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(value), dst); err != nil {
+		return fmt.Errorf("failed to decode secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// OnChange registers fn to be called when a refresh returns a value that
+// differs from the cached one, e.g. to reconnect with rotated credentials
+func (s *Store) OnChange(name string, fn func(value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange[name] = append(s.onChange[name], fn)
+}
+
+// Invalidate drops a cached secret
+func (s *Store) Invalidate(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, name)
+}
+
+// fetch resolves the secret from the providers and updates the cache
+func (s *Store) fetch(ctx context.Context, name string, previous cached, hadPrevious bool) (string, error) {
+	value, provider, err := s.resolve(ctx, name)
+	if err != nil {
+		if hadPrevious && s.opts.ServeStale {
+			s.logger.Warning("Failed to refresh secret %s, using cached value from %s: %v", name, previous.provider, err)
+			return previous.value, nil
+		}
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cached{value: value, provider: provider, fetched: time.Now()}
+	var callbacks []func(string)
+	if hadPrevious && previous.value != value {
+		callbacks = append(callbacks, s.onChange[name]...)
+	}
+	s.mu.Unlock()
+
+	if len(callbacks) > 0 {
+		s.logger.Info("Secret %s changed, notifying %d listeners", name, len(callbacks))
+		for _, fn := range callbacks {
+			fn(value)
+		}
+	}
+	return value, nil
+}
+
+// resolve asks each provider in turn, skipping those that do not hold the secret
+func (s *Store) resolve(ctx context.Context, name string) (string, string, error) {
+	var errs []error
+	for _, provider := range s.providers {
+		value, err := provider.Get(ctx, name)
+		if err == nil {
+			return value, provider.Name(), nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return "", "", fmt.Errorf("failed to get secret %s: %w", name, errors.Join(errs...))
+	}
+	return "", "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
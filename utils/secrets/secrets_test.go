@@ -0,0 +1,236 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// fakeProvider serves values from a map and counts lookups
+type fakeProvider struct {
+	mu     sync.Mutex
+	name   string
+	values map[string]string
+	err    error
+	calls  int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Get(ctx context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+	value, ok := f.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *fakeProvider) set(name, value string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[name] = value
+	f.err = err
+}
+
+func newTestStore(t *testing.T, opts *Options, providers ...Provider) *Store {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("secrets_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	s, err := NewStore(testLogger, opts, providers...)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	return s
+}
+
+func TestStoreFallbackAndCache(t *testing.T) {
+	primary := &fakeProvider{name: "primary", values: map[string]string{"sftp/password": "from-primary"}}
+	fallback := &fakeProvider{name: "fallback", values: map[string]string{"db/password": "from-fallback"}}
+	s := newTestStore(t, &Options{TTL: time.Hour}, primary, fallback)
+	ctx := context.Background()
+
+	if value, err := s.Get(ctx, "sftp/password"); err != nil || value != "from-primary" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+	if value, err := s.Get(ctx, "db/password"); err != nil || value != "from-fallback" {
+		t.Errorf("Get = %q, %v", value, err)
+	}
+	s.Get(ctx, "db/password")
+	if fallback.calls != 1 {
+		t.Errorf("expected cached value, fallback called %d times", fallback.calls)
+	}
+
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// Provider failures are reported instead of falling through silently
+	primary.set("other", "", errors.New("access denied"))
+	if _, err := s.Get(ctx, "other"); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("expected provider error, got %v", err)
+	}
+}
+
+func TestStoreRotation(t *testing.T) {
+	provider := &fakeProvider{name: "fake", values: map[string]string{"db": "v1"}}
+	s := newTestStore(t, &Options{TTL: time.Hour, ServeStale: true}, provider)
+	ctx := context.Background()
+
+	var changes []string
+	s.OnChange("db", func(value string) { changes = append(changes, value) })
+
+	s.Get(ctx, "db")
+	provider.set("db", "v2", nil)
+	if value, _ := s.Get(ctx, "db"); value != "v1" {
+		t.Errorf("expected cached v1 before refresh, got %s", value)
+	}
+	if value, err := s.Refresh(ctx, "db"); err != nil || value != "v2" {
+		t.Errorf("Refresh = %q, %v", value, err)
+	}
+	if strings.Join(changes, ",") != "v2" {
+		t.Errorf("expected one change notification, got %v", changes)
+	}
+
+	// A failed refresh keeps serving the last known value
+	provider.set("db", "v3", errors.New("throttled"))
+	if value, err := s.Refresh(ctx, "db"); err != nil || value != "v2" {
+		t.Errorf("expected stale v2, got %q, %v", value, err)
+	}
+
+	s.Invalidate("db")
+	if _, err := s.Get(ctx, "db"); err == nil {
+		t.Error("expected error after invalidation while provider fails")
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	provider := &fakeProvider{name: "fake", values: map[string]string{
+		"db":  `{"username":"etl","password":"s3cret"}`,
+		"bad": "not json",
+	}}
+	s := newTestStore(t, nil, provider)
+
+	var creds struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := s.GetJSON(context.Background(), "db", &creds); err != nil || creds.Password != "s3cret" {
+		t.Errorf("GetJSON = %+v, %v", creds, err)
+	}
+	if err := s.GetJSON(context.Background(), "bad", &creds); err == nil {
+		t.Error("expected decode error")
+	}
+}
+
+func TestEnvAndFiles(t *testing.T) {
+	t.Setenv("ETL_DB_PASSWORD", "env-secret")
+	if value, err := (Env{Prefix: "ETL"}).Get(context.Background(), "db/password"); err != nil || value != "env-secret" {
+		t.Errorf("Env.Get = %q, %v", value, err)
+	}
+	if _, err := (Env{}).Get(context.Background(), "not-set-anywhere"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sftp"), 0755)
+	os.WriteFile(filepath.Join(dir, "sftp", "key"), []byte("file-secret\n"), 0600)
+	files := Files{Dir: dir}
+	if value, err := files.Get(context.Background(), "sftp/key"); err != nil || value != "file-secret" {
+		t.Errorf("Files.Get = %q, %v", value, err)
+	}
+	if _, err := files.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := files.Get(context.Background(), "../etc/passwd"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error for path escaping the directory, got %v", err)
+	}
+}
+
+// newFakeAWS serves GetSecretValue and GetParameter from a map
+func newFakeAWS(t *testing.T, values map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			SecretId string
+			Name     string
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			value, ok := values[request.SecretId]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type":"ResourceNotFoundException","message":"not found"}`)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"Name": request.SecretId, "SecretString": value})
+		case "AmazonSSM.GetParameter":
+			value, ok := values[request.Name]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"__type":"ParameterNotFound","message":"not found"}`)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"Parameter": map[string]string{"Name": request.Name, "Value": value}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"AccessDeniedException","message":"denied"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	credsPath := filepath.Join(t.TempDir(), "credentials")
+	os.WriteFile(credsPath, []byte("[default]\naws_access_key_id = AKIATESTTESTTEST\naws_secret_access_key = secret\n"), 0600)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsPath)
+	return server
+}
+
+func TestAWSProviders(t *testing.T) {
+	server := newFakeAWS(t, map[string]string{
+		"prod/db":           `{"password":"sm-secret"}`,
+		"/prod/etl/sftp_pw": "ssm-secret",
+	})
+	config := AWSConfig{ProfileName: "default", Region: "us-east-1", EndpointURL: server.URL}
+	ctx := context.Background()
+
+	sm := &SecretsManager{AWSConfig: config}
+	if value, err := sm.Get(ctx, "prod/db"); err != nil || value != `{"password":"sm-secret"}` {
+		t.Errorf("SecretsManager.Get = %q, %v", value, err)
+	}
+	if _, err := sm.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	ps := &ParameterStore{AWSConfig: config, Prefix: "/prod/etl/"}
+	if value, err := ps.Get(ctx, "sftp_pw"); err != nil || value != "ssm-secret" {
+		t.Errorf("ParameterStore.Get = %q, %v", value, err)
+	}
+	if _, err := ps.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// Parameters not in Secrets Manager fall back to Parameter Store
+	s := newTestStore(t, nil, sm, ps)
+	if value, err := s.Get(ctx, "sftp_pw"); err != nil || value != "ssm-secret" {
+		t.Errorf("Store.Get = %q, %v", value, err)
+	}
+}
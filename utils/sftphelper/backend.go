@@ -0,0 +1,135 @@
+package sftphelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+var _ storage.Backend = (*SFTPHelper)(nil)
+
+// Put streams r to the remote file at key, creating parent directories. The
+// content is written to a temporary name first and renamed into place, so
+// readers never see a partial file.
+func (u *SFTPHelper) Put(ctx context.Context, key string, r io.Reader) error {
+	client, err := u.client()
+	if err != nil {
+		return err
+	}
+
+	key = cleanPath(key)
+	if err := client.MkdirAll(path.Dir(key)); err != nil {
+		return fmt.Errorf("failed to create remote directory for %q: %v", key, err)
+	}
+	tmp := path.Join(path.Dir(key), "."+path.Base(key)+".part")
+	remote, err := client.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %q: %v", tmp, err)
+	}
+	if _, err := remote.ReadFrom(r); err != nil {
+		remote.Close()
+		client.Remove(tmp)
+		return fmt.Errorf("failed to upload %q: %v", key, err)
+	}
+	if err := remote.Close(); err != nil {
+		client.Remove(tmp)
+		return fmt.Errorf("failed to upload %q: %v", key, err)
+	}
+	if err := client.PosixRename(tmp, key); err != nil {
+		client.Remove(tmp)
+		return fmt.Errorf("failed to rename %q into place: %v", key, err)
+	}
+	return nil
+}
+
+// Get opens the remote file at key for reading
+func (u *SFTPHelper) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	client, err := u.client()
+	if err != nil {
+		return nil, err
+	}
+
+	key = cleanPath(key)
+	remote, err := client.Open(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.NotFound(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remote file %q: %v", key, err)
+	}
+	return remote, nil
+}
+
+// List returns the remote files whose path starts with prefix. Only the
+// directory containing the prefix is walked.
+func (u *SFTPHelper) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	client, err := u.client()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := "."
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = cleanPath(prefix[:i+1])
+	}
+	infos, err := listRemote(client, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.TrimPrefix(prefix, "./")
+	objects := make([]storage.ObjectInfo, 0, len(infos))
+	for remotePath, info := range infos {
+		if !strings.HasPrefix(remotePath, prefix) {
+			continue
+		}
+		if strings.HasPrefix(info.Name(), ".") && strings.HasSuffix(info.Name(), ".part") {
+			continue // an unfinished Put
+		}
+		objects = append(objects, storage.ObjectInfo{Key: remotePath, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete removes the remote file at key
+func (u *SFTPHelper) Delete(ctx context.Context, key string) error {
+	client, err := u.client()
+	if err != nil {
+		return err
+	}
+
+	key = cleanPath(key)
+	if err := client.Remove(key); errors.Is(err, fs.ErrNotExist) {
+		return storage.NotFound(key)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", key, err)
+	}
+	return nil
+}
+
+// Stat returns the size and modification time of the remote file at key
+func (u *SFTPHelper) Stat(ctx context.Context, key string) (storage.ObjectInfo, error) {
+	client, err := u.client()
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+
+	key = cleanPath(key)
+	info, err := client.Stat(key)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return storage.ObjectInfo{}, storage.NotFound(key)
+	}
+	if err != nil {
+		return storage.ObjectInfo{}, fmt.Errorf("failed to stat %q: %v", key, err)
+	}
+	return storage.ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
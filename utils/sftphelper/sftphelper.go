@@ -0,0 +1,410 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package sftphelper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/romisugianto/go-utils/utils/humanize"
+	"github.com/romisugianto/go-utils/utils/iocopy"
+	"github.com/romisugianto/go-utils/utils/ratelimit"
+)
+
+// SFTPHelper holds the configuration for SFTP operations. The underlying
+// connection is opened on first use and reused until Close is called.
+type SFTPHelper struct {
+	Host string
+	Port int // defaults to 22
+	User string
+
+	// Password enables password authentication
+	Password string
+	// PrivateKeyPath enables public key authentication with a PEM or OpenSSH key
+	PrivateKeyPath string
+	// PrivateKeyPassphrase decrypts an encrypted private key
+	PrivateKeyPassphrase string
+
+	// KnownHostsPath verifies the server against an OpenSSH known_hosts file
+	KnownHostsPath string
+	// HostKeyFingerprint verifies the server against a SHA256 fingerprint
+	// as printed by ssh-keygen -l (e.g. "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8")
+	HostKeyFingerprint string
+	// InsecureIgnoreHostKey skips host key verification; use only for testing
+	InsecureIgnoreHostKey bool
+
+	// Timeout bounds the TCP connect and SSH handshake (defaults to 30 seconds)
+	Timeout time.Duration
+
+	// DownloadLimiter throttles DownloadFile in bytes per second, e.g.
+	// ratelimit.NewLimiter(10<<20, 1<<20) for 10 MiB/s; nil is unlimited
+	DownloadLimiter *ratelimit.Limiter
+
+	mu         sync.Mutex
+	sshClient  *ssh.Client
+	sftpClient *sftp.Client
+}
+
+// SyncResult reports the outcome of a SyncDirectory run
+type SyncResult struct {
+	Uploaded []string
+	Skipped  []string
+	Deleted  []string
+}
+
+// client returns the shared SFTP client, reconnecting if the connection was lost
+func (u *SFTPHelper) client() (*sftp.Client, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.sftpClient != nil {
+		if _, err := u.sftpClient.Getwd(); err == nil {
+			return u.sftpClient, nil
+		}
+		log.Printf("SFTP connection to %s lost, reconnecting", u.Host)
+		u.closeLocked()
+	}
+
+	config, err := u.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	port := u.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(u.Host, strconv.Itoa(port))
+
+	sshClient, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to start SFTP session on %s: %v", addr, err)
+	}
+
+	u.sshClient = sshClient
+	u.sftpClient = sftpClient
+	return sftpClient, nil
+}
+
+// clientConfig builds the SSH client configuration from the helper settings
+func (u *SFTPHelper) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if u.PrivateKeyPath != "" {
+		signer, err := u.loadSigner()
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if u.Password != "" {
+		auth = append(auth, ssh.Password(u.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("either Password or PrivateKeyPath must be set")
+	}
+
+	hostKeyCallback, err := u.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := u.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &ssh.ClientConfig{
+		User:            u.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}, nil
+}
+
+// loadSigner reads and parses the configured private key
+func (u *SFTPHelper) loadSigner() (ssh.Signer, error) {
+	keyData, err := os.ReadFile(u.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key %q: %v", u.PrivateKeyPath, err)
+	}
+
+	var signer ssh.Signer
+	if u.PrivateKeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyData, []byte(u.PrivateKeyPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(keyData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %q: %v", u.PrivateKeyPath, err)
+	}
+	return signer, nil
+}
+
+// hostKeyCallback selects the host key verification strategy
+func (u *SFTPHelper) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	switch {
+	case u.KnownHostsPath != "":
+		callback, err := knownhosts.New(u.KnownHostsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known hosts %q: %v", u.KnownHostsPath, err)
+		}
+		return callback, nil
+	case u.HostKeyFingerprint != "":
+		expected := u.HostKeyFingerprint
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if actual := ssh.FingerprintSHA256(key); actual != expected {
+				return fmt.Errorf("host key fingerprint mismatch for %s: expected %s, got %s", hostname, expected, actual)
+			}
+			return nil
+		}, nil
+	case u.InsecureIgnoreHostKey:
+		return ssh.InsecureIgnoreHostKey(), nil
+	default:
+		return nil, fmt.Errorf("host key verification requires KnownHostsPath or HostKeyFingerprint")
+	}
+}
+
+// Close closes the shared connection. The helper reconnects on next use.
+func (u *SFTPHelper) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.closeLocked()
+}
+
+// closeLocked closes the connection; the caller must hold u.mu
+func (u *SFTPHelper) closeLocked() error {
+	var err error
+	if u.sftpClient != nil {
+		u.sftpClient.Close()
+		u.sftpClient = nil
+	}
+	if u.sshClient != nil {
+		err = u.sshClient.Close()
+		u.sshClient = nil
+	}
+	return err
+}
+
+// UploadFile uploads a local file to the specified remote path, creating parent directories
+func (u *SFTPHelper) UploadFile(filePath, remotePath string) error {
+	client, err := u.client()
+	if err != nil {
+		return err
+	}
+	return u.putFile(client, filePath, remotePath)
+}
+
+// putFile uploads a single local file using an existing client and preserves its modification time
+func (u *SFTPHelper) putFile(client *sftp.Client, filePath, remotePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %v", filePath, err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info for %q: %v", filePath, err)
+	}
+
+	remotePath = cleanPath(remotePath)
+	if err := client.MkdirAll(path.Dir(remotePath)); err != nil {
+		return fmt.Errorf("failed to create remote directory for %q: %v", remotePath, err)
+	}
+
+	remote, err := client.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %q: %v", remotePath, err)
+	}
+	if _, err := remote.ReadFrom(file); err != nil {
+		remote.Close()
+		return fmt.Errorf("failed to upload file to %q: %v", remotePath, err)
+	}
+	if err := remote.Close(); err != nil {
+		return fmt.Errorf("failed to upload file to %q: %v", remotePath, err)
+	}
+
+	// Keep the local modification time so SyncDirectory can detect changes
+	if err := client.Chtimes(remotePath, time.Now(), fileInfo.ModTime()); err != nil {
+		log.Printf("Failed to set modification time on %s: %v", remotePath, err)
+	}
+
+	log.Printf("Successfully uploaded %q to sftp://%s%s", filePath, u.Host, remotePath)
+	return nil
+}
+
+// DownloadFile downloads a remote file to the local filesystem
+func (u *SFTPHelper) DownloadFile(remotePath, localPath string) error {
+	client, err := u.client()
+	if err != nil {
+		return err
+	}
+
+	remote, err := client.Open(cleanPath(remotePath))
+	if err != nil {
+		return fmt.Errorf("failed to open remote file %q: %v", remotePath, err)
+	}
+	defer remote.Close()
+
+	// Create the directory for the local file if it doesn't exist
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %q: %v", dir, err)
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file %q: %v", localPath, err)
+	}
+	defer file.Close()
+
+	copied, err := iocopy.Copy(context.Background(), file, remote, &iocopy.Options{Limiter: u.DownloadLimiter})
+	if err != nil {
+		return fmt.Errorf("failed to write to local file %q: %v", localPath, err)
+	}
+
+	log.Printf("Successfully downloaded sftp://%s%s to %s (%s at %s)", u.Host, remotePath, localPath, humanize.Bytes(copied.Bytes), copied.Rate())
+	return nil
+}
+
+// ListFiles recursively lists all files under the remote directory
+func (u *SFTPHelper) ListFiles(remoteDir string) ([]string, error) {
+	client, err := u.client()
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := listRemote(client, cleanPath(remoteDir))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(infos))
+	for filePath := range infos {
+		files = append(files, filePath)
+	}
+	return files, nil
+}
+
+// listRemote walks remoteDir and returns file info keyed by remote path.
+// A missing directory yields an empty result.
+func listRemote(client *sftp.Client, remoteDir string) (map[string]os.FileInfo, error) {
+	infos := make(map[string]os.FileInfo)
+	walker := client.Walk(remoteDir)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			if os.IsNotExist(err) && walker.Path() == remoteDir {
+				return infos, nil
+			}
+			return nil, fmt.Errorf("failed to list files: %v", err)
+		}
+		if walker.Stat().Mode().IsRegular() {
+			infos[walker.Path()] = walker.Stat()
+		}
+	}
+	return infos, nil
+}
+
+// DeleteFile deletes a remote file
+func (u *SFTPHelper) DeleteFile(remotePath string) error {
+	client, err := u.client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.Remove(cleanPath(remotePath)); err != nil {
+		return fmt.Errorf("failed to delete file %q: %v", remotePath, err)
+	}
+
+	log.Printf("Successfully deleted sftp://%s%s", u.Host, remotePath)
+	return nil
+}
+
+// SyncDirectory uploads files from localDir that are missing or differ in size or
+// modification time under remoteDir. When deleteOrphans is true, remote files
+// with no local counterpart are deleted.
+func (u *SFTPHelper) SyncDirectory(localDir, remoteDir string, deleteOrphans ...bool) (*SyncResult, error) {
+	client, err := u.client()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteDir = cleanPath(remoteDir)
+	remoteFiles, err := listRemote(client, remoteDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{}
+	seen := make(map[string]bool)
+	err = filepath.Walk(localDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(localDir, filePath)
+		if err != nil {
+			return err
+		}
+		remotePath := path.Join(remoteDir, filepath.ToSlash(relPath))
+		seen[remotePath] = true
+
+		if remote, ok := remoteFiles[remotePath]; ok && inSync(info, remote) {
+			result.Skipped = append(result.Skipped, remotePath)
+			return nil
+		}
+		if err := u.putFile(client, filePath, remotePath); err != nil {
+			return err
+		}
+		result.Uploaded = append(result.Uploaded, remotePath)
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to sync directory %q: %v", localDir, err)
+	}
+
+	if len(deleteOrphans) > 0 && deleteOrphans[0] {
+		for remotePath := range remoteFiles {
+			if seen[remotePath] {
+				continue
+			}
+			if err := client.Remove(remotePath); err != nil {
+				return result, fmt.Errorf("failed to delete orphan %q: %v", remotePath, err)
+			}
+			log.Printf("Deleted orphan sftp://%s%s", u.Host, remotePath)
+			result.Deleted = append(result.Deleted, remotePath)
+		}
+	}
+
+	return result, nil
+}
+
+// inSync reports whether a remote file matches the local file's size and modification time
+func inSync(local, remote os.FileInfo) bool {
+	return local.Size() == remote.Size() && local.ModTime().Unix() == remote.ModTime().Unix()
+}
+
+// cleanPath normalizes a remote path to a slash-separated absolute or relative path
+func cleanPath(remotePath string) string {
+	return path.Clean(filepath.ToSlash(remotePath))
+}
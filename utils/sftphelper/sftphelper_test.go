@@ -0,0 +1,371 @@
+package sftphelper
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/romisugianto/go-utils/utils/storage"
+)
+
+const (
+	testUser     = "partner"
+	testPassword = "s3cret"
+)
+
+// testServer is an in-process SSH server exposing the sftp subsystem over the local filesystem
+type testServer struct {
+	listener  net.Listener
+	hostKey   ssh.Signer
+	clientKey string // path of an authorized client private key
+	connCount atomic.Int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("failed to create host key: %v", err)
+	}
+
+	clientPub, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+	clientKey := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(clientKey, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("failed to write client key: %v", err)
+	}
+	authorized, _ := ssh.NewPublicKey(clientPub)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == testUser && string(password) == testPassword {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &testServer{listener: listener, hostKey: hostKey, clientKey: clientKey}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.connCount.Add(1)
+			go serveConn(conn, config)
+		}
+	}()
+	return s
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					server, err := sftp.NewServer(channel)
+					if err == nil {
+						server.Serve()
+						server.Close()
+					}
+					channel.Close()
+				}
+			}
+		}()
+	}
+}
+
+func (s *testServer) helper() *SFTPHelper {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &SFTPHelper{
+		Host:               "127.0.0.1",
+		Port:               addr.Port,
+		User:               testUser,
+		Password:           testPassword,
+		HostKeyFingerprint: ssh.FingerprintSHA256(s.hostKey.PublicKey()),
+		Timeout:            5 * time.Second,
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+}
+
+func TestUploadDownloadListDelete(t *testing.T) {
+	server := newTestServer(t)
+	helper := server.helper()
+	defer helper.Close()
+
+	localDir := t.TempDir()
+	remoteDir := filepath.ToSlash(t.TempDir())
+	localFile := filepath.Join(localDir, "report.csv")
+	writeFile(t, localFile, "id,name\n1,alpha\n")
+
+	remotePath := remoteDir + "/outbound/2024/report.csv"
+	if err := helper.UploadFile(localFile, remotePath); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+
+	files, err := helper.ListFiles(remoteDir)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != remotePath {
+		t.Errorf("ListFiles = %v, expected [%s]", files, remotePath)
+	}
+
+	downloaded := filepath.Join(localDir, "copy", "report.csv")
+	if err := helper.DownloadFile(remotePath, downloaded); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	content, _ := os.ReadFile(downloaded)
+	if string(content) != "id,name\n1,alpha\n" {
+		t.Errorf("downloaded content = %q", content)
+	}
+
+	if err := helper.DeleteFile(remotePath); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if files, _ := helper.ListFiles(remoteDir); len(files) != 0 {
+		t.Errorf("expected no files after delete, got %v", files)
+	}
+
+	// All operations share one connection
+	if server.connCount.Load() != 1 {
+		t.Errorf("expected 1 connection, got %d", server.connCount.Load())
+	}
+}
+
+func TestListFilesMissingDirectory(t *testing.T) {
+	helper := newTestServer(t).helper()
+	defer helper.Close()
+
+	files, err := helper.ListFiles(filepath.ToSlash(t.TempDir()) + "/missing")
+	if err != nil || len(files) != 0 {
+		t.Errorf("ListFiles on missing dir = %v, %v", files, err)
+	}
+}
+
+func TestReconnectAfterClose(t *testing.T) {
+	server := newTestServer(t)
+	helper := server.helper()
+	defer helper.Close()
+
+	remoteDir := filepath.ToSlash(t.TempDir())
+	if _, err := helper.ListFiles(remoteDir); err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+	helper.Close()
+	if _, err := helper.ListFiles(remoteDir); err != nil {
+		t.Fatalf("ListFiles after Close failed: %v", err)
+	}
+	if server.connCount.Load() != 2 {
+		t.Errorf("expected 2 connections, got %d", server.connCount.Load())
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		modify  func(h *SFTPHelper)
+		wantErr string
+	}{
+		{"password", func(h *SFTPHelper) {}, ""},
+		{"private key", func(h *SFTPHelper) {
+			h.Password = ""
+			h.PrivateKeyPath = server.clientKey
+		}, ""},
+		{"wrong password", func(h *SFTPHelper) { h.Password = "wrong" }, "unable to authenticate"},
+		{"no credentials", func(h *SFTPHelper) { h.Password = "" }, "either Password or PrivateKeyPath"},
+		{"fingerprint mismatch", func(h *SFTPHelper) {
+			h.HostKeyFingerprint = "SHA256:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+		}, "fingerprint mismatch"},
+		{"no host key verification", func(h *SFTPHelper) { h.HostKeyFingerprint = "" }, "host key verification"},
+		{"insecure", func(h *SFTPHelper) {
+			h.HostKeyFingerprint = ""
+			h.InsecureIgnoreHostKey = true
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper := server.helper()
+			tt.modify(helper)
+			defer helper.Close()
+
+			_, err := helper.ListFiles(filepath.ToSlash(t.TempDir()))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, expected it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKnownHosts(t *testing.T) {
+	server := newTestServer(t)
+	helper := server.helper()
+	helper.HostKeyFingerprint = ""
+	defer helper.Close()
+
+	hostLine := "[127.0.0.1]:" + strconv.Itoa(helper.Port) + " " + string(ssh.MarshalAuthorizedKey(server.hostKey.PublicKey()))
+	helper.KnownHostsPath = filepath.Join(t.TempDir(), "known_hosts")
+	writeFile(t, helper.KnownHostsPath, hostLine)
+
+	if _, err := helper.ListFiles(filepath.ToSlash(t.TempDir())); err != nil {
+		t.Errorf("ListFiles with known_hosts failed: %v", err)
+	}
+}
+
+func TestSyncDirectory(t *testing.T) {
+	helper := newTestServer(t).helper()
+	defer helper.Close()
+
+	localDir := t.TempDir()
+	remoteDir := filepath.ToSlash(t.TempDir())
+	writeFile(t, filepath.Join(localDir, "a.csv"), "a")
+	writeFile(t, filepath.Join(localDir, "sub", "b.csv"), "b")
+	writeFile(t, filepath.Join(remoteDir, "orphan.csv"), "stale")
+
+	result, err := helper.SyncDirectory(localDir, remoteDir)
+	if err != nil {
+		t.Fatalf("SyncDirectory failed: %v", err)
+	}
+	if len(result.Uploaded) != 2 || len(result.Skipped) != 0 || len(result.Deleted) != 0 {
+		t.Errorf("first sync = %+v", result)
+	}
+
+	// Unchanged files are skipped, modified files are uploaded, orphans are deleted
+	writeFile(t, filepath.Join(localDir, "a.csv"), "changed")
+	result, err = helper.SyncDirectory(localDir, remoteDir, true)
+	if err != nil {
+		t.Fatalf("SyncDirectory failed: %v", err)
+	}
+	if len(result.Uploaded) != 1 || result.Uploaded[0] != remoteDir+"/a.csv" {
+		t.Errorf("uploaded = %v", result.Uploaded)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != remoteDir+"/sub/b.csv" {
+		t.Errorf("skipped = %v", result.Skipped)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != remoteDir+"/orphan.csv" {
+		t.Errorf("deleted = %v", result.Deleted)
+	}
+
+	files, _ := helper.ListFiles(remoteDir)
+	sort.Strings(files)
+	if strings.Join(files, ",") != remoteDir+"/a.csv,"+remoteDir+"/sub/b.csv" {
+		t.Errorf("remote files = %v", files)
+	}
+}
+
+func TestBackend(t *testing.T) {
+	helper := newTestServer(t).helper()
+	defer helper.Close()
+	ctx := context.Background()
+
+	var backend storage.Backend = helper
+	root := filepath.ToSlash(t.TempDir())
+	if err := backend.Put(ctx, root+"/backups/2024/a.tar", strings.NewReader("alpha")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := backend.Put(ctx, root+"/backups/2024/b.tar", strings.NewReader("bravo!")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	writeFile(t, filepath.Join(root, "backups", "2024", ".c.tar.part"), "partial")
+	writeFile(t, filepath.Join(root, "other", "d.tar"), "delta")
+
+	objects, err := backend.List(ctx, root+"/backups/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Key != root+"/backups/2024/a.tar" || objects[1].Size != 6 {
+		t.Errorf("List = %+v", objects)
+	}
+
+	r, err := backend.Get(ctx, root+"/backups/2024/a.tar")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	if string(content) != "alpha" {
+		t.Errorf("Get = %q", content)
+	}
+
+	if info, err := backend.Stat(ctx, root+"/backups/2024/b.tar"); err != nil || info.Size != 6 {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+	if err := backend.Delete(ctx, root+"/backups/2024/a.tar"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := backend.Get(ctx, root+"/backups/2024/a.tar"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if _, err := backend.Stat(ctx, root+"/backups"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a directory, got %v", err)
+	}
+	if err := backend.Delete(ctx, root+"/missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing file, got %v", err)
+	}
+}
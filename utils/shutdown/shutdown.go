@@ -0,0 +1,216 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Hook is a cleanup step run during shutdown. It should return promptly once
+// ctx is done.
+type Hook func(ctx context.Context) error
+
+// Func adapts a cleanup function without a context, such as lock.Release or
+// log.Close, to a Hook
+func Func(fn func() error) Hook {
+	return func(ctx context.Context) error { return fn() }
+}
+
+// Options configures a Manager
+type Options struct {
+	// Timeout bounds the time spent running all hooks (default 30s)
+	Timeout time.Duration
+	// Signals that trigger shutdown (default SIGINT and SIGTERM)
+	Signals []os.Signal
+	// ForceExit exits with status 1 when a second signal arrives during shutdown
+	ForceExit bool
+}
+
+// Report describes what happened during shutdown
+type Report struct {
+	// Reason is the signal name, or "requested" when Shutdown was called
+	Reason    string
+	Completed []string
+	Failed    map[string]error
+	// Skipped lists hooks that did not run because the timeout expired
+	Skipped  []string
+	Duration time.Duration
+}
+
+// Clean reports whether every hook ran and succeeded
+func (r *Report) Clean() bool {
+	return len(r.Failed) == 0 && len(r.Skipped) == 0
+}
+
+// Manager turns termination signals into context cancellation and runs the
+// registered cleanup hooks
+type Manager struct {
+	logger *logger.Logger
+	opts   Options
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	signals chan os.Signal
+
+	mu     sync.Mutex
+	hooks  []namedHook
+	reason string
+
+	once   sync.Once
+	report *Report
+	done   chan struct{}
+}
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// New starts listening for the configured signals. The returned manager's
+// Context is cancelled when one arrives. A nil opts uses the defaults.
+func New(log *logger.Logger, opts *Options) (*Manager, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	m := &Manager{logger: log, done: make(chan struct{})}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.Timeout <= 0 {
+		m.opts.Timeout = 30 * time.Second
+	}
+	if len(m.opts.Signals) == 0 {
+		m.opts.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.signals = make(chan os.Signal, 2)
+	signal.Notify(m.signals, m.opts.Signals...)
+	go m.watch()
+	return m, nil
+}
+
+// Context returns a context that is cancelled when shutdown begins
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Register adds a cleanup hook. Hooks run in reverse order of registration,
+// like deferred calls, so register the logger first to close it last.
+func (m *Manager) Register(name string, hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, namedHook{name: name, hook: hook})
+}
+
+// Wait blocks until a signal arrives or Shutdown is called, and returns the
+// report once the hooks have run
+func (m *Manager) Wait() *Report {
+	<-m.ctx.Done()
+	return m.Shutdown()
+}
+
+// Shutdown cancels the context and runs the hooks. Later calls wait for the
+// first one and return the same report.
+func (m *Manager) Shutdown() *Report {
+	m.once.Do(func() {
+		m.mu.Lock()
+		if m.reason == "" {
+			m.reason = "requested"
+		}
+		m.mu.Unlock()
+		m.cancel()
+		m.report = m.run()
+		close(m.done)
+	})
+	<-m.done
+	return m.report
+}
+
+// watch cancels the context on the first signal and, with ForceExit, exits
+// on the second
+func (m *Manager) watch() {
+	select {
+	case sig := <-m.signals:
+		m.mu.Lock()
+		if m.reason == "" {
+			m.reason = sig.String()
+		}
+		m.mu.Unlock()
+		m.logger.Warning("Received %s, shutting down", sig)
+		m.cancel()
+	case <-m.ctx.Done():
+	}
+
+	select {
+	case sig := <-m.signals:
+		if m.opts.ForceExit {
+			m.logger.Error("Received %s during shutdown, exiting immediately", sig)
+			os.Exit(1)
+		}
+	case <-m.done:
+	}
+	signal.Stop(m.signals)
+}
+
+// run executes the hooks in reverse order within the timeout
+func (m *Manager) run() *Report {
+	m.mu.Lock()
+	hooks := append([]namedHook(nil), m.hooks...)
+	report := &Report{Reason: m.reason, Failed: map[string]error{}}
+	m.mu.Unlock()
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.Timeout)
+	defer cancel()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if ctx.Err() != nil {
+			report.Skipped = append(report.Skipped, h.name)
+			continue
+		}
+		if err := runHook(ctx, h.hook); err != nil {
+			report.Failed[h.name] = err
+			m.logger.Error("Shutdown hook %s failed: %v", h.name, err)
+			continue
+		}
+		report.Completed = append(report.Completed, h.name)
+	}
+	report.Duration = time.Since(start)
+
+	m.logger.Summary("Shutdown (%s) finished in %.2f seconds", report.Reason, report.Duration.Seconds())
+	m.logger.Summary("  - Completed: %d, failed: %d, skipped: %d", len(report.Completed), len(report.Failed), len(report.Skipped))
+	for _, name := range report.Skipped {
+		m.logger.Summary("  - Skipped: %s", name)
+	}
+	return report
+}
+
+// runHook runs a hook, converting a panic into an error and giving up on a
+// hook that ignores its context once the timeout expires
+func runHook(ctx context.Context, hook Hook) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("hook panicked: %v", r)
+			}
+		}()
+		done <- hook(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("hook did not complete: %w", ctx.Err())
+	}
+}
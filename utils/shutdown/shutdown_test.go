@@ -0,0 +1,115 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func newTestManager(t *testing.T, opts *Options) *Manager {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("shutdown_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	m, err := New(testLogger, opts)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { m.Shutdown() })
+	return m
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(nil, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+}
+
+func TestShutdownRunsHooksInReverse(t *testing.T) {
+	m := newTestManager(t, nil)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) Hook {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	m.Register("flush logger", record("logger"))
+	m.Register("release lock", record("lock"))
+	m.Register("broken", Func(func() error { return errors.New("already released") }))
+	m.Register("panics", func(ctx context.Context) error { panic("nil client") })
+	m.Register("abort uploads", record("uploads"))
+
+	report := m.Shutdown()
+	if strings.Join(order, ",") != "uploads,lock,logger" {
+		t.Errorf("hooks ran in order %v", order)
+	}
+	if report.Reason != "requested" || report.Clean() {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Failed) != 2 || !strings.Contains(report.Failed["panics"].Error(), "panicked") {
+		t.Errorf("unexpected failures %v", report.Failed)
+	}
+	if m.Context().Err() == nil {
+		t.Error("expected context to be cancelled")
+	}
+
+	// Later calls return the same report without running hooks again
+	if m.Shutdown() != report || len(order) != 3 {
+		t.Error("expected Shutdown to run only once")
+	}
+}
+
+func TestSignalTriggersShutdown(t *testing.T) {
+	m := newTestManager(t, nil)
+	ran := make(chan struct{})
+	m.Register("cleanup", func(ctx context.Context) error {
+		close(ran)
+		return nil
+	})
+
+	m.signals <- syscall.SIGTERM
+	select {
+	case <-m.Context().Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled by the signal")
+	}
+
+	report := m.Wait()
+	<-ran
+	if report.Reason != syscall.SIGTERM.String() || !report.Clean() {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestTimeoutSkipsRemainingHooks(t *testing.T) {
+	m := newTestManager(t, &Options{Timeout: 100 * time.Millisecond})
+
+	m.Register("never runs", func(ctx context.Context) error { return nil })
+	m.Register("hangs", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := m.Shutdown()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("shutdown took %s, expected the timeout to apply", elapsed)
+	}
+	if !errors.Is(report.Failed["hangs"], context.DeadlineExceeded) {
+		t.Errorf("expected hung hook to time out, got %v", report.Failed["hangs"])
+	}
+	if strings.Join(report.Skipped, ",") != "never runs" {
+		t.Errorf("skipped = %v", report.Skipped)
+	}
+}
@@ -0,0 +1,152 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package snshelper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+
+	"github.com/romisugianto/go-utils/utils/awssession"
+	"github.com/romisugianto/go-utils/utils/pipeline"
+)
+
+// SNSHelper publishes messages to an SNS topic
+type SNSHelper struct {
+	awssession.Config
+	// TopicARN is the topic to publish to, e.g.
+	// "arn:aws:sns:us-east-1:123456789012:pipeline-events"
+	TopicARN string
+
+	mu     sync.Mutex
+	client snsiface.SNSAPI
+}
+
+// PublishOptions holds optional settings for Publish
+type PublishOptions struct {
+	// Subject is used by email subscriptions (up to 100 characters)
+	Subject string
+	// Attributes are sent as message attributes, which subscription filter
+	// policies can match. Values may be a string, an integer or float, a
+	// []string or a []byte.
+	Attributes map[string]any
+	// GroupID and DeduplicationID are used by FIFO topics
+	GroupID         string
+	DeduplicationID string
+}
+
+// getClient creates the service client on first use
+func (p *SNSHelper) getClient() (snsiface.SNSAPI, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		if p.TopicARN == "" {
+			return nil, fmt.Errorf("TopicARN cannot be empty")
+		}
+		sess, err := p.NewSession(nil)
+		if err != nil {
+			return nil, err
+		}
+		p.client = sns.New(sess)
+	}
+	return p.client, nil
+}
+
+// Publish sends a message to the topic and returns its message ID
+func (p *SNSHelper) Publish(ctx context.Context, message string, opts *PublishOptions) (string, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	input := &sns.PublishInput{
+		TopicArn: aws.String(p.TopicARN),
+		Message:  aws.String(message),
+	}
+	if opts != nil {
+		if opts.Subject != "" {
+			input.Subject = aws.String(opts.Subject)
+		}
+		if len(opts.Attributes) > 0 {
+			attributes, err := messageAttributes(opts.Attributes)
+			if err != nil {
+				return "", err
+			}
+			input.MessageAttributes = attributes
+		}
+		if opts.GroupID != "" {
+			input.MessageGroupId = aws.String(opts.GroupID)
+		}
+		if opts.DeduplicationID != "" {
+			input.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+		}
+	}
+
+	output, err := client.PublishWithContext(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to publish to %s: %v", p.TopicARN, err)
+	}
+	return aws.StringValue(output.MessageId), nil
+}
+
+// PublishJSON publishes v encoded as JSON
+func (p *SNSHelper) PublishJSON(ctx context.Context, v any, opts *PublishOptions) (string, error) {
+	message, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode message: %v", err)
+	}
+	return p.Publish(ctx, string(message), opts)
+}
+
+// PipelineHook returns a pipeline OnFinish hook that publishes every run
+// report as JSON, with "pipeline", "run_id" and "status" message attributes
+// so subscribers can filter, e.g. on failed runs only. Publish failures are
+// logged.
+func (p *SNSHelper) PipelineHook() func(ctx context.Context, report *pipeline.Report) {
+	return func(ctx context.Context, report *pipeline.Report) {
+		_, err := p.PublishJSON(ctx, report, &PublishOptions{
+			Subject: fmt.Sprintf("[%s] %s run %s", report.Status, report.Pipeline, report.RunID),
+			Attributes: map[string]any{
+				"pipeline": report.Pipeline,
+				"run_id":   report.RunID,
+				"status":   string(report.Status),
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to publish report for run %s: %v", report.RunID, err)
+		}
+	}
+}
+
+// messageAttributes converts attribute values to SNS message attributes
+func messageAttributes(values map[string]any) (map[string]*sns.MessageAttributeValue, error) {
+	attributes := make(map[string]*sns.MessageAttributeValue, len(values))
+	for name, value := range values {
+		var attr sns.MessageAttributeValue
+		switch v := value.(type) {
+		case string:
+			attr.SetDataType("String").SetStringValue(v)
+		case int:
+			attr.SetDataType("Number").SetStringValue(strconv.Itoa(v))
+		case int64:
+			attr.SetDataType("Number").SetStringValue(strconv.FormatInt(v, 10))
+		case float64:
+			attr.SetDataType("Number").SetStringValue(strconv.FormatFloat(v, 'f', -1, 64))
+		case []string:
+			encoded, _ := json.Marshal(v)
+			attr.SetDataType("String.Array").SetStringValue(string(encoded))
+		case []byte:
+			attr.SetDataType("Binary").SetBinaryValue(v)
+		default:
+			return nil, fmt.Errorf("failed to add attribute %s: unsupported type %T", name, value)
+		}
+		attributes[name] = &attr
+	}
+	return attributes, nil
+}
@@ -0,0 +1,121 @@
+package snshelper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+
+	"github.com/romisugianto/go-utils/utils/pipeline"
+)
+
+// fakeSNS records published messages
+type fakeSNS struct {
+	snsiface.SNSAPI
+	published []*sns.PublishInput
+	err       error
+}
+
+func (f *fakeSNS) PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.published = append(f.published, input)
+	return &sns.PublishOutput{MessageId: aws.String("msg-1")}, nil
+}
+
+func newFakeHelper() (*SNSHelper, *fakeSNS) {
+	fake := &fakeSNS{}
+	return &SNSHelper{TopicARN: "arn:aws:sns:us-east-1:123456789012:pipeline-events", client: fake}, fake
+}
+
+func TestPublish(t *testing.T) {
+	helper, fake := newFakeHelper()
+
+	id, err := helper.Publish(context.Background(), "orders delivered", &PublishOptions{
+		Subject: "Delivery",
+		Attributes: map[string]any{
+			"customer": "acme",
+			"files":    3,
+			"bytes":    int64(2048),
+			"ratio":    0.5,
+			"regions":  []string{"us", "eu"},
+			"checksum": []byte{0xca, 0xfe},
+		},
+		GroupID: "orders",
+	})
+	if err != nil || id != "msg-1" {
+		t.Fatalf("Publish = %q, %v", id, err)
+	}
+
+	input := fake.published[0]
+	if aws.StringValue(input.TopicArn) != helper.TopicARN || aws.StringValue(input.Subject) != "Delivery" || aws.StringValue(input.MessageGroupId) != "orders" {
+		t.Errorf("unexpected publish input %v", input)
+	}
+	expected := map[string][2]string{
+		"customer": {"String", "acme"},
+		"files":    {"Number", "3"},
+		"bytes":    {"Number", "2048"},
+		"ratio":    {"Number", "0.5"},
+		"regions":  {"String.Array", `["us","eu"]`},
+	}
+	for name, want := range expected {
+		attr := input.MessageAttributes[name]
+		if attr == nil || aws.StringValue(attr.DataType) != want[0] || aws.StringValue(attr.StringValue) != want[1] {
+			t.Errorf("attribute %s = %v, expected %v", name, attr, want)
+		}
+	}
+	if attr := input.MessageAttributes["checksum"]; aws.StringValue(attr.DataType) != "Binary" || len(attr.BinaryValue) != 2 {
+		t.Errorf("unexpected binary attribute %v", attr)
+	}
+
+	if _, err := helper.Publish(context.Background(), "x", &PublishOptions{Attributes: map[string]any{"when": time.Now()}}); err == nil {
+		t.Error("expected error for unsupported attribute type")
+	}
+
+	fake.err = errors.New("AuthorizationError")
+	if _, err := helper.Publish(context.Background(), "x", nil); err == nil {
+		t.Error("expected publish error")
+	}
+
+	if _, err := (&SNSHelper{}).Publish(context.Background(), "x", nil); err == nil {
+		t.Error("expected error for empty TopicARN")
+	}
+}
+
+func TestPipelineHook(t *testing.T) {
+	helper, fake := newFakeHelper()
+	report := &pipeline.Report{
+		Pipeline: "orders",
+		RunID:    "orders.csv-1700000000",
+		Status:   pipeline.StatusFailed,
+		Error:    "step upload: access denied",
+	}
+
+	helper.PipelineHook()(context.Background(), report)
+	if len(fake.published) != 1 {
+		t.Fatalf("expected 1 published message, got %d", len(fake.published))
+	}
+	input := fake.published[0]
+	if aws.StringValue(input.MessageAttributes["status"].StringValue) != "failed" || aws.StringValue(input.MessageAttributes["pipeline"].StringValue) != "orders" {
+		t.Errorf("unexpected attributes %v", input.MessageAttributes)
+	}
+	if aws.StringValue(input.Subject) != "[failed] orders run orders.csv-1700000000" {
+		t.Errorf("subject = %q", aws.StringValue(input.Subject))
+	}
+
+	var decoded pipeline.Report
+	if err := json.Unmarshal([]byte(aws.StringValue(input.Message)), &decoded); err != nil || decoded.Error != report.Error {
+		t.Errorf("unexpected message %s: %v", aws.StringValue(input.Message), err)
+	}
+
+	// Publish failures are logged, not returned
+	fake.err = errors.New("throttled")
+	helper.PipelineHook()(context.Background(), report)
+}
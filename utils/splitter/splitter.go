@@ -4,17 +4,30 @@ package splitter
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/checksum"
+	"github.com/romisugianto/go-utils/utils/humanize"
 	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/manifest"
 )
 
 // Splitter handles file splitting operations
 type Splitter struct {
 	logger *logger.Logger
+	// Preprocess, when set, wraps the source file before it is split, e.g.
+	// textnorm.Preprocessor to convert it to UTF-8 with uniform line endings
+	Preprocess func(r io.Reader) (io.Reader, error)
+	// Manifest, when set, writes <name>_manifest.json to the output
+	// directory, a transfer manifest listing the parts with their sizes and
+	// SHA-256 checksums
+	Manifest bool
 }
 
 // NewSplitter creates a new splitter instance
@@ -60,19 +73,33 @@ func (s *Splitter) SplitFileByLines(filePath string, linesPerFile int, outputDir
 	}
 	fileSize := fileInfo.Size()
 
-	s.logger.Info("Starting to process file: %s (size: %.2f MB)", filePath, float64(fileSize)/1024/1024)
+	s.logger.Info("Starting to process file: %s (size: %s)", filePath, humanize.Bytes(fileSize))
 
 	// Get base filename without extension
 	fileName := filepath.Base(filePath)
 	fileExt := filepath.Ext(filePath)
 	baseName := strings.TrimSuffix(fileName, fileExt)
 
+	var input io.Reader = file
+	if s.Preprocess != nil {
+		if input, err = s.Preprocess(file); err != nil {
+			return fmt.Errorf("failed to preprocess file %s: %w", filePath, err)
+		}
+	}
+
 	// Create a scanner to read the file line by line
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(input)
 	linesCount := 0
 	fileCount := 1
-	var outputFile *os.File
+	var parts []string
+	var outputFile *atomicfile.Writer
 	var writer *bufio.Writer
+	// Discard a part that is still being written when splitting fails
+	defer func() {
+		if outputFile != nil {
+			outputFile.Abort()
+		}
+	}()
 
 	// Process each line in the file
 	for scanner.Scan() {
@@ -80,17 +107,19 @@ func (s *Splitter) SplitFileByLines(filePath string, linesPerFile int, outputDir
 		if linesCount%linesPerFile == 0 {
 			// Close the previous file if it exists
 			if outputFile != nil {
-				writer.Flush()
-				outputFile.Close()
+				if err := closePart(writer, outputFile); err != nil {
+					return err
+				}
 				s.logger.Info("Created output file part %d", fileCount-1)
 			}
 
-			// Create a new output file
+			// Create a new output file, published atomically once complete
 			outputPath := filepath.Join(outputDir, fmt.Sprintf("%s_part%d%s", baseName, fileCount, fileExt))
-			outputFile, err = os.Create(outputPath)
+			outputFile, err = atomicfile.Create(outputPath)
 			if err != nil {
 				return fmt.Errorf("failed to create output file %s: %w", outputPath, err)
 			}
+			parts = append(parts, outputPath)
 			writer = bufio.NewWriter(outputFile)
 			fileCount++
 		}
@@ -109,17 +138,24 @@ func (s *Splitter) SplitFileByLines(filePath string, linesPerFile int, outputDir
 	}
 
 	// Make sure to flush and close the last file
-	if writer != nil {
-		writer.Flush()
-	}
 	if outputFile != nil {
-		outputFile.Close()
+		if err := closePart(writer, outputFile); err != nil {
+			return err
+		}
 		s.logger.Info("Created final output file part %d", fileCount-1)
 	}
 
 	// Calculate actual number of files created (could be one less if file ended exactly on a boundary)
 	actualFileCount := fileCount - 1
 
+	if s.Manifest {
+		manifestPath := filepath.Join(outputDir, baseName+"_manifest.json")
+		if err := writeManifest(manifestPath, fileName, linesPerFile, parts); err != nil {
+			return err
+		}
+		s.logger.Info("Wrote manifest %s", manifestPath)
+	}
+
 		// Close any possible open handles to ensure we can move the file
 	file.Close()
 
@@ -134,10 +170,36 @@ func (s *Splitter) SplitFileByLines(filePath string, linesPerFile int, outputDir
 
 	// Log processing summary
 	s.logger.Summary("Processed file: %s", fileName)
-	s.logger.Summary("  - Original size: %.2f MB", float64(fileSize)/1024/1024)
+	s.logger.Summary("  - Original size: %s", humanize.Bytes(fileSize))
 	s.logger.Summary("  - Files created: %d", actualFileCount)
 	s.logger.Summary("  - Processing time: %.2f seconds", duration.Seconds())
-	s.logger.Summary("  - Processing rate: %.2f MB/sec", (float64(fileSize)/1024/1024)/duration.Seconds())
+	s.logger.Summary("  - Processing rate: %s", humanize.Rate(fileSize, duration))
 	s.logger.Summary("  - Processed file moved to: %s", processedPath)
 	return nil
-}
\ No newline at end of file
+}
+
+// writeManifest records the parts of a split, named relative to the output
+// directory, in a transfer manifest
+func writeManifest(manifestPath, source string, linesPerFile int, parts []string) error {
+	m := manifest.New("split")
+	m.Metadata = map[string]string{"source": source, "lines_per_file": strconv.Itoa(linesPerFile)}
+	for _, part := range parts {
+		if _, err := m.AddFile(part, filepath.Base(part), checksum.SHA256); err != nil {
+			return fmt.Errorf("failed to add %s to manifest: %w", part, err)
+		}
+		m.SetStatus(filepath.Base(part), manifest.StatusComplete, nil)
+	}
+	return m.Save(manifestPath)
+}
+
+// closePart flushes a part and renames it into place
+func closePart(writer *bufio.Writer, outputFile *atomicfile.Writer) error {
+	if err := writer.Flush(); err != nil {
+		outputFile.Abort()
+		return fmt.Errorf("failed to write to output file: %w", err)
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+	return nil
+}
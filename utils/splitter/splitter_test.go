@@ -1,12 +1,15 @@
 package splitter
 
 import (
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/manifest"
 )
 
 func createTestFile(t *testing.T, dir string) string {
@@ -140,4 +143,68 @@ func TestSplitFileByLines_ErrorCases(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestSplitFileByLines_Preprocess(t *testing.T) {
+	testLogger, _ := logger.NewLogger("splitter_test")
+	defer testLogger.Close()
+	sp, err := NewSplitter(testLogger)
+	if err != nil {
+		t.Fatalf("failed to create splitter: %v", err)
+	}
+
+	testDir := t.TempDir()
+	testFile := filepath.Join(testDir, "crlf.csv")
+	os.WriteFile(testFile, []byte("line1\r\nline2\r\nline3\r\n"), 0644)
+	sp.Preprocess = func(r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(r)
+		return strings.NewReader(strings.ReplaceAll(string(data), "\r\n", "\n")), err
+	}
+
+	outputDir := filepath.Join(testDir, "output")
+	if err := sp.SplitFileByLines(testFile, 2, outputDir, filepath.Join(testDir, "processed")); err != nil {
+		t.Fatalf("SplitFileByLines failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outputDir, "crlf_part1.csv")); string(data) != "line1\nline2\n" {
+		t.Errorf("unexpected first part %q", data)
+	}
+
+	sp.Preprocess = func(r io.Reader) (io.Reader, error) {
+		return nil, errors.New("unsupported encoding")
+	}
+	testFile = createTestFile(t, testDir)
+	err = sp.SplitFileByLines(testFile, 2, outputDir, filepath.Join(testDir, "processed"))
+	if err == nil || !strings.Contains(err.Error(), "failed to preprocess file") {
+		t.Errorf("expected preprocess error, got %v", err)
+	}
+}
+
+func TestSplitFileByLines_Manifest(t *testing.T) {
+	testLogger, _ := logger.NewLogger("splitter_test")
+	defer testLogger.Close()
+	sp, err := NewSplitter(testLogger)
+	if err != nil {
+		t.Fatalf("failed to create splitter: %v", err)
+	}
+	sp.Manifest = true
+
+	testDir := t.TempDir()
+	testFile := createTestFile(t, testDir)
+	outputDir := filepath.Join(testDir, "output")
+	if err := sp.SplitFileByLines(testFile, 2, outputDir, filepath.Join(testDir, "processed")); err != nil {
+		t.Fatalf("SplitFileByLines failed: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(outputDir, "testfile_manifest.json"))
+	if err != nil {
+		t.Fatalf("failed to load manifest: %v", err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("invalid manifest: %v", err)
+	}
+	if m.Kind != "split" || len(m.Entries) != 3 || m.Entries[0].Path != "testfile_part1.csv" || m.Metadata["source"] != "testfile.csv" {
+		t.Errorf("unexpected manifest %+v", m)
+	}
+	if failed, err := m.VerifyFiles(outputDir); err != nil {
+		t.Errorf("VerifyFiles reported %v: %v", failed, err)
+	}
+}
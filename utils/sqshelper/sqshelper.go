@@ -0,0 +1,322 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package sqshelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+
+	"github.com/romisugianto/go-utils/utils/awssession"
+)
+
+// maxBatchSize is the number of entries SQS accepts in one batch request
+const maxBatchSize = 10
+
+// SQSHelper sends, receives and deletes messages on an SQS queue
+type SQSHelper struct {
+	awssession.Config
+	// QueueURL is the queue to operate on, e.g.
+	// "https://sqs.us-east-1.amazonaws.com/123456789012/inbound-events"
+	QueueURL string
+
+	mu     sync.Mutex
+	client sqsiface.SQSAPI
+}
+
+// Message is a received SQS message
+type Message struct {
+	ID string
+	// ReceiptHandle identifies this receipt for deletion and visibility changes
+	ReceiptHandle string
+	Body          string
+	// Attributes holds the string and number message attributes
+	Attributes map[string]string
+	// ReceiveCount is the number of times the message has been received
+	ReceiveCount int
+	SentAt       time.Time
+}
+
+// SendOptions holds optional settings for Send
+type SendOptions struct {
+	// Delay postpones delivery of the message (up to 15 minutes)
+	Delay time.Duration
+	// Attributes are sent as string message attributes
+	Attributes map[string]string
+	// GroupID and DeduplicationID are used by FIFO queues
+	GroupID         string
+	DeduplicationID string
+}
+
+// ReceiveOptions holds optional settings for Receive and Poll
+type ReceiveOptions struct {
+	// MaxMessages is the most messages returned per call, 1 to 10 (default 10)
+	MaxMessages int
+	// WaitTime is the long-poll duration, up to 20s (default 20s)
+	WaitTime time.Duration
+	// VisibilityTimeout hides received messages from other consumers for this
+	// long (default: the queue setting, or 30s for Poll)
+	VisibilityTimeout time.Duration
+}
+
+// getClient creates the service client on first use
+func (q *SQSHelper) getClient() (sqsiface.SQSAPI, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.client == nil {
+		if q.QueueURL == "" {
+			return nil, fmt.Errorf("QueueURL cannot be empty")
+		}
+		sess, err := q.NewSession(nil)
+		if err != nil {
+			return nil, err
+		}
+		q.client = sqs.New(sess)
+	}
+	return q.client, nil
+}
+
+// Send sends a message to the queue and returns its message ID
+func (q *SQSHelper) Send(ctx context.Context, body string, opts *SendOptions) (string, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.QueueURL),
+		MessageBody: aws.String(body),
+	}
+	if opts != nil {
+		if opts.Delay > 0 {
+			input.DelaySeconds = aws.Int64(int64(opts.Delay / time.Second))
+		}
+		if len(opts.Attributes) > 0 {
+			input.MessageAttributes = make(map[string]*sqs.MessageAttributeValue, len(opts.Attributes))
+			for name, value := range opts.Attributes {
+				input.MessageAttributes[name] = &sqs.MessageAttributeValue{
+					DataType:    aws.String("String"),
+					StringValue: aws.String(value),
+				}
+			}
+		}
+		if opts.GroupID != "" {
+			input.MessageGroupId = aws.String(opts.GroupID)
+		}
+		if opts.DeduplicationID != "" {
+			input.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+		}
+	}
+
+	output, err := client.SendMessageWithContext(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to send message to %s: %v", q.QueueURL, err)
+	}
+	return aws.StringValue(output.MessageId), nil
+}
+
+// Receive long-polls the queue and returns up to MaxMessages messages. An
+// empty slice means no message arrived within WaitTime.
+func (q *SQSHelper) Receive(ctx context.Context, opts *ReceiveOptions) ([]Message, error) {
+	client, err := q.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var o ReceiveOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxMessages <= 0 || o.MaxMessages > maxBatchSize {
+		o.MaxMessages = maxBatchSize
+	}
+	if o.WaitTime <= 0 || o.WaitTime > 20*time.Second {
+		o.WaitTime = 20 * time.Second
+	}
+
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(q.QueueURL),
+		MaxNumberOfMessages:   aws.Int64(int64(o.MaxMessages)),
+		WaitTimeSeconds:       aws.Int64(int64(o.WaitTime / time.Second)),
+		AttributeNames:        aws.StringSlice([]string{sqs.MessageSystemAttributeNameApproximateReceiveCount, sqs.MessageSystemAttributeNameSentTimestamp}),
+		MessageAttributeNames: aws.StringSlice([]string{"All"}),
+	}
+	if o.VisibilityTimeout > 0 {
+		input.VisibilityTimeout = aws.Int64(int64(o.VisibilityTimeout / time.Second))
+	}
+
+	output, err := client.ReceiveMessageWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive messages from %s: %v", q.QueueURL, err)
+	}
+
+	messages := make([]Message, 0, len(output.Messages))
+	for _, m := range output.Messages {
+		messages = append(messages, toMessage(m))
+	}
+	return messages, nil
+}
+
+// toMessage converts an SDK message
+func toMessage(m *sqs.Message) Message {
+	msg := Message{
+		ID:            aws.StringValue(m.MessageId),
+		ReceiptHandle: aws.StringValue(m.ReceiptHandle),
+		Body:          aws.StringValue(m.Body),
+		Attributes:    map[string]string{},
+	}
+	for name, value := range m.MessageAttributes {
+		if value.StringValue != nil {
+			msg.Attributes[name] = *value.StringValue
+		}
+	}
+	if count, err := strconv.Atoi(aws.StringValue(m.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount])); err == nil {
+		msg.ReceiveCount = count
+	}
+	if sent, err := strconv.ParseInt(aws.StringValue(m.Attributes[sqs.MessageSystemAttributeNameSentTimestamp]), 10, 64); err == nil {
+		msg.SentAt = time.UnixMilli(sent)
+	}
+	return msg
+}
+
+// ExtendVisibility hides a received message from other consumers for timeout
+// from now, e.g. while a long download is still running. A zero timeout makes
+// the message visible again immediately.
+func (q *SQSHelper) ExtendVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	client, err := q.getClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(q.QueueURL),
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: aws.Int64(int64(timeout / time.Second)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to change message visibility on %s: %v", q.QueueURL, err)
+	}
+	return nil
+}
+
+// Delete removes a processed message from the queue
+func (q *SQSHelper) Delete(ctx context.Context, receiptHandle string) error {
+	return q.DeleteBatch(ctx, []string{receiptHandle})
+}
+
+// DeleteBatch removes processed messages from the queue in batches of 10.
+// Entries that fail are reported together in the returned error.
+func (q *SQSHelper) DeleteBatch(ctx context.Context, receiptHandles []string) error {
+	if len(receiptHandles) == 0 {
+		return nil
+	}
+	client, err := q.getClient()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for start := 0; start < len(receiptHandles); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(receiptHandles))
+		entries := make([]*sqs.DeleteMessageBatchRequestEntry, 0, end-start)
+		for i, handle := range receiptHandles[start:end] {
+			entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(start + i)),
+				ReceiptHandle: aws.String(handle),
+			})
+		}
+
+		output, err := client.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(q.QueueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete messages from %s: %v", q.QueueURL, err))
+			continue
+		}
+		for _, failed := range output.Failed {
+			errs = append(errs, fmt.Errorf("failed to delete message %s: %s: %s",
+				aws.StringValue(failed.Id), aws.StringValue(failed.Code), aws.StringValue(failed.Message)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Poll receives messages until ctx is done and calls handler for each one.
+// While a handler runs, the message's visibility is extended every half
+// VisibilityTimeout so slow work is not redelivered. Messages are deleted
+// when their handler succeeds; failed messages become visible again after
+// the timeout and are retried. Poll returns nil when ctx is canceled.
+func (q *SQSHelper) Poll(ctx context.Context, opts *ReceiveOptions, handler func(ctx context.Context, msg Message) error) error {
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+	var o ReceiveOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.VisibilityTimeout < time.Second {
+		o.VisibilityTimeout = 30 * time.Second
+	}
+
+	for ctx.Err() == nil {
+		messages, err := q.Receive(ctx, &o)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+
+		var done []string
+		for _, msg := range messages {
+			if err := q.handle(ctx, msg, o.VisibilityTimeout, handler); err != nil {
+				log.Printf("Failed to process message %s from %s: %v", msg.ID, q.QueueURL, err)
+				continue
+			}
+			done = append(done, msg.ReceiptHandle)
+		}
+		// Use a fresh context so finished work is not redelivered after cancellation
+		if err := q.DeleteBatch(context.WithoutCancel(ctx), done); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	return nil
+}
+
+// handle runs handler for one message while keeping the message invisible
+func (q *SQSHelper) handle(ctx context.Context, msg Message, visibility time.Duration, handler func(ctx context.Context, msg Message) error) (err error) {
+	heartbeatCtx, stop := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(visibility / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case <-ticker.C:
+				if err := q.ExtendVisibility(heartbeatCtx, msg.ReceiptHandle, visibility); err != nil && heartbeatCtx.Err() == nil {
+					log.Printf("Failed to extend visibility of message %s: %v", msg.ID, err)
+				}
+			}
+		}
+	}()
+	defer func() {
+		stop()
+		wg.Wait()
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("handler panicked: %v", rec)
+		}
+	}()
+	return handler(ctx, msg)
+}
@@ -0,0 +1,244 @@
+package sqshelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// fakeSQS is an in-memory queue implementing the calls used by SQSHelper
+type fakeSQS struct {
+	sqsiface.SQSAPI
+
+	mu       sync.Mutex
+	queue    []*sqs.Message
+	sent     []*sqs.SendMessageInput
+	received []*sqs.ReceiveMessageInput
+	extended map[string]int
+	deleted  []string
+	batches  int
+	// failDelete makes deletion of these receipt handles fail
+	failDelete map[string]bool
+}
+
+func newFakeHelper() (*SQSHelper, *fakeSQS) {
+	fake := &fakeSQS{extended: map[string]int{}, failDelete: map[string]bool{}}
+	return &SQSHelper{QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/inbound", client: fake}, fake
+}
+
+func (f *fakeSQS) SendMessageWithContext(ctx aws.Context, input *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, input)
+	id := fmt.Sprintf("msg-%d", len(f.sent))
+	attrs := map[string]*sqs.MessageAttributeValue{}
+	for name, value := range input.MessageAttributes {
+		attrs[name] = value
+	}
+	f.queue = append(f.queue, &sqs.Message{
+		MessageId:         aws.String(id),
+		ReceiptHandle:     aws.String("rh-" + id),
+		Body:              input.MessageBody,
+		MessageAttributes: attrs,
+		Attributes: map[string]*string{
+			sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String("1"),
+			sqs.MessageSystemAttributeNameSentTimestamp:           aws.String("1717207200000"),
+		},
+	})
+	return &sqs.SendMessageOutput{MessageId: aws.String(id)}, nil
+}
+
+func (f *fakeSQS) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	f.received = append(f.received, input)
+	n := min(int(aws.Int64Value(input.MaxNumberOfMessages)), len(f.queue))
+	messages := f.queue[:n]
+	f.queue = f.queue[n:]
+	f.mu.Unlock()
+
+	if len(messages) == 0 {
+		// Simulate a long poll that ends with the context
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibilityWithContext(ctx aws.Context, input *sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.extended[aws.StringValue(input.ReceiptHandle)]++
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessageBatchWithContext(ctx aws.Context, input *sqs.DeleteMessageBatchInput, opts ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches++
+	if len(input.Entries) > maxBatchSize {
+		return nil, errors.New("AWS.SimpleQueueService.TooManyEntriesInBatchRequest")
+	}
+	output := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		handle := aws.StringValue(entry.ReceiptHandle)
+		if f.failDelete[handle] {
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+				Id: entry.Id, Code: aws.String("ReceiptHandleIsInvalid"), Message: aws.String("invalid"),
+			})
+			continue
+		}
+		f.deleted = append(f.deleted, handle)
+		output.Successful = append(output.Successful, &sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+func TestSendReceive(t *testing.T) {
+	helper, fake := newFakeHelper()
+	ctx := context.Background()
+
+	id, err := helper.Send(ctx, `{"bucket":"inbound"}`, &SendOptions{
+		Delay:      30 * time.Second,
+		Attributes: map[string]string{"source": "s3"},
+		GroupID:    "orders",
+	})
+	if err != nil || id != "msg-1" {
+		t.Fatalf("Send = %q, %v", id, err)
+	}
+	sent := fake.sent[0]
+	if aws.Int64Value(sent.DelaySeconds) != 30 || aws.StringValue(sent.MessageGroupId) != "orders" || sent.MessageDeduplicationId != nil {
+		t.Errorf("unexpected send input %v", sent)
+	}
+
+	messages, err := helper.Receive(ctx, &ReceiveOptions{MaxMessages: 50, VisibilityTimeout: time.Minute})
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	input := fake.received[0]
+	if aws.Int64Value(input.MaxNumberOfMessages) != 10 || aws.Int64Value(input.WaitTimeSeconds) != 20 || aws.Int64Value(input.VisibilityTimeout) != 60 {
+		t.Errorf("unexpected receive input %v", input)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	msg := messages[0]
+	if msg.Body != `{"bucket":"inbound"}` || msg.Attributes["source"] != "s3" || msg.ReceiveCount != 1 || msg.ReceiptHandle != "rh-msg-1" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if !msg.SentAt.Equal(time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("SentAt = %v", msg.SentAt)
+	}
+
+	if err := helper.ExtendVisibility(ctx, msg.ReceiptHandle, 5*time.Minute); err != nil || fake.extended["rh-msg-1"] != 1 {
+		t.Errorf("ExtendVisibility = %v, calls %d", err, fake.extended["rh-msg-1"])
+	}
+}
+
+func TestDeleteBatch(t *testing.T) {
+	helper, fake := newFakeHelper()
+	var handles []string
+	for i := range 23 {
+		handles = append(handles, "rh-"+strconv.Itoa(i))
+	}
+	fake.failDelete["rh-4"] = true
+	fake.failDelete["rh-21"] = true
+
+	err := helper.DeleteBatch(context.Background(), handles)
+	if err == nil {
+		t.Fatal("expected error for failed entries")
+	}
+	if fake.batches != 3 || len(fake.deleted) != 21 {
+		t.Errorf("expected 3 batches and 21 deletions, got %d and %d", fake.batches, len(fake.deleted))
+	}
+	for _, id := range []string{"message 4:", "message 21:"} {
+		if !strings.Contains(err.Error(), id) {
+			t.Errorf("error %q does not mention %s", err, id)
+		}
+	}
+
+	if err := helper.DeleteBatch(context.Background(), nil); err != nil || fake.batches != 3 {
+		t.Errorf("expected no request for an empty batch, got %v", err)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	helper, fake := newFakeHelper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, body := range []string{"ok", "fail", "panic", "ok"} {
+		helper.Send(ctx, body, nil)
+	}
+
+	var mu sync.Mutex
+	var handled []string
+	err := helper.Poll(ctx, &ReceiveOptions{MaxMessages: 2}, func(ctx context.Context, msg Message) error {
+		mu.Lock()
+		handled = append(handled, msg.ID)
+		if len(handled) == 4 {
+			cancel()
+		}
+		mu.Unlock()
+		switch msg.Body {
+		case "fail":
+			return errors.New("download failed")
+		case "panic":
+			panic("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Poll returned %v", err)
+	}
+	if len(handled) != 4 {
+		t.Errorf("expected 4 handled messages, got %v", handled)
+	}
+	// Only successful messages are deleted, including the one handled after cancel
+	if len(fake.deleted) != 2 || fake.deleted[0] != "rh-msg-1" || fake.deleted[1] != "rh-msg-4" {
+		t.Errorf("unexpected deletions %v", fake.deleted)
+	}
+	if aws.Int64Value(fake.received[0].VisibilityTimeout) != 30 {
+		t.Errorf("expected default visibility of 30s, got %v", fake.received[0].VisibilityTimeout)
+	}
+
+	if err := helper.Poll(context.Background(), nil, nil); err == nil {
+		t.Error("expected error for nil handler")
+	}
+}
+
+func TestHandleExtendsVisibility(t *testing.T) {
+	helper, fake := newFakeHelper()
+	msg := Message{ID: "slow", ReceiptHandle: "rh-slow"}
+
+	err := helper.handle(context.Background(), msg, 40*time.Millisecond, func(ctx context.Context, msg Message) error {
+		time.Sleep(110 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.extended["rh-slow"] < 2 {
+		t.Errorf("expected visibility to be extended during processing, got %d calls", fake.extended["rh-slow"])
+	}
+}
+
+func TestQueueURLRequired(t *testing.T) {
+	helper := &SQSHelper{}
+	if _, err := helper.Send(context.Background(), "body", nil); err == nil {
+		t.Error("expected error for empty QueueURL")
+	}
+}
@@ -0,0 +1,342 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package sshrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/romisugianto/go-utils/utils/internal/capture"
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// Config holds the connection settings
+type Config struct {
+	Host string
+	Port int // defaults to 22
+	User string
+
+	// Password enables password authentication
+	Password string
+	// PrivateKeyPath enables public key authentication with a PEM or OpenSSH key
+	PrivateKeyPath string
+	// PrivateKeyPassphrase decrypts an encrypted private key
+	PrivateKeyPassphrase string
+
+	// KnownHostsPath verifies the server against an OpenSSH known_hosts file
+	KnownHostsPath string
+	// HostKeyFingerprint verifies the server against a SHA256 fingerprint
+	// as printed by ssh-keygen -l
+	HostKeyFingerprint string
+	// InsecureIgnoreHostKey skips host key verification; use only for testing
+	InsecureIgnoreHostKey bool
+
+	// Timeout bounds the TCP connect and SSH handshake (defaults to 30 seconds)
+	Timeout time.Duration
+}
+
+// Options configures a single command
+type Options struct {
+	// Timeout bounds the command; the remote process is killed when it
+	// expires (0 relies on the context only)
+	Timeout time.Duration
+	// Env sets environment variables; the server must accept them (AcceptEnv)
+	Env map[string]string
+	// Stdin is sent to the command's standard input
+	Stdin io.Reader
+	// Stdout and Stderr receive a copy of the output, e.g. a file
+	Stdout io.Writer
+	Stderr io.Writer
+	// Quiet stops output lines from being logged
+	Quiet bool
+}
+
+// Result describes a finished command
+type Result struct {
+	Command  string
+	ExitCode int
+	// Stdout and Stderr hold the last 64 KiB of each stream
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+}
+
+// ExitError is returned when a command exits with a non-zero status
+type ExitError struct {
+	Host     string
+	Command  string
+	ExitCode int
+	// Stderr is the end of the standard error output
+	Stderr string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("command %q on %s exited with status %d", e.Command, e.Host, e.ExitCode)
+	if stderr := capture.LastLine(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Runner executes commands on a remote host over SSH. The connection is
+// opened on first use and shared by concurrent commands until Close.
+type Runner struct {
+	config Config
+	addr   string
+	logger *logger.Logger
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// NewRunner validates cfg and returns a runner for the host. It does not
+// connect until the first command.
+func NewRunner(log *logger.Logger, cfg Config) (*Runner, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if cfg.Host == "" || cfg.User == "" {
+		return nil, fmt.Errorf("Host and User cannot be empty")
+	}
+	if cfg.Password == "" && cfg.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("either Password or PrivateKeyPath must be set")
+	}
+	if cfg.KnownHostsPath == "" && cfg.HostKeyFingerprint == "" && !cfg.InsecureIgnoreHostKey {
+		return nil, fmt.Errorf("host key verification requires KnownHostsPath or HostKeyFingerprint")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 22
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	return &Runner{config: cfg, addr: addr, logger: log}, nil
+}
+
+// Run executes command through the remote user's shell, logging each output
+// line with the host name: stdout as info and stderr as warnings. A
+// non-zero exit status returns the result together with an *ExitError.
+func (r *Runner) Run(ctx context.Context, command string, opts *Options) (*Result, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	session, err := r.session()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	for key, value := range o.Env {
+		if err := session.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("failed to set %s on %s (check AcceptEnv): %v", key, r.config.Host, err)
+		}
+	}
+
+	stdout := &capture.TailBuffer{}
+	stderr := &capture.TailBuffer{}
+	stdoutLines := r.lineLogger(o.Quiet, r.logger.Info)
+	stderrLines := r.lineLogger(o.Quiet, r.logger.Warning)
+	session.Stdout = capture.Writers(stdout, stdoutLines, o.Stdout)
+	session.Stderr = capture.Writers(stderr, stderrLines, o.Stderr)
+	session.Stdin = o.Stdin
+
+	r.logger.Info("[%s] Running %s", r.config.Host, command)
+	start := time.Now()
+	if err := session.Start(command); err != nil {
+		return nil, fmt.Errorf("failed to start %q on %s: %v", command, r.config.Host, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+
+	var waitErr error
+	select {
+	case waitErr = <-done:
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		<-done
+		return nil, fmt.Errorf("command %q on %s cancelled after %s: %w", command, r.config.Host, time.Since(start).Round(time.Millisecond), ctx.Err())
+	}
+	stdoutLines.Flush()
+	stderrLines.Flush()
+
+	result := &Result{
+		Command:  command,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+
+	var exitErr *ssh.ExitError
+	switch {
+	case waitErr == nil:
+		r.logger.Info("[%s] Finished %s in %s", r.config.Host, command, result.Duration.Round(time.Millisecond))
+		return result, nil
+	case errors.As(waitErr, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+		err := &ExitError{Host: r.config.Host, Command: command, ExitCode: result.ExitCode, Stderr: result.Stderr}
+		r.logger.Error("[%s] %v", r.config.Host, err)
+		return result, err
+	default:
+		return result, fmt.Errorf("command %q on %s failed: %v", command, r.config.Host, waitErr)
+	}
+}
+
+// Output runs command quietly and returns its standard output without the
+// trailing newline
+func (r *Runner) Output(ctx context.Context, command string) (string, error) {
+	result, err := r.Run(ctx, command, &Options{Quiet: true})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(result.Stdout, "\r\n"), nil
+}
+
+// Close closes the connection. The runner reconnects on next use.
+func (r *Runner) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client == nil {
+		return nil
+	}
+	err := r.client.Close()
+	r.client = nil
+	return err
+}
+
+// session opens a session on the shared connection, reconnecting once if the
+// connection was lost
+func (r *Runner) session() (*ssh.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		if session, err := r.client.NewSession(); err == nil {
+			return session, nil
+		}
+		r.logger.Warning("SSH connection to %s lost, reconnecting", r.addr)
+		r.client.Close()
+		r.client = nil
+	}
+
+	config, err := r.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", r.addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", r.addr, err)
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to open session on %s: %v", r.addr, err)
+	}
+	r.client = client
+	return session, nil
+}
+
+// clientConfig builds the SSH client configuration
+func (r *Runner) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if r.config.PrivateKeyPath != "" {
+		signer, err := r.loadSigner()
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if r.config.Password != "" {
+		auth = append(auth, ssh.Password(r.config.Password))
+	}
+
+	hostKeyCallback, err := r.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.ClientConfig{
+		User:            r.config.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         r.config.Timeout,
+	}, nil
+}
+
+// loadSigner reads and parses the configured private key
+func (r *Runner) loadSigner() (ssh.Signer, error) {
+	keyData, err := os.ReadFile(r.config.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key %q: %v", r.config.PrivateKeyPath, err)
+	}
+
+	var signer ssh.Signer
+	if r.config.PrivateKeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyData, []byte(r.config.PrivateKeyPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(keyData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %q: %v", r.config.PrivateKeyPath, err)
+	}
+	return signer, nil
+}
+
+// hostKeyCallback selects the host key verification strategy
+func (r *Runner) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	switch {
+	case r.config.KnownHostsPath != "":
+		callback, err := knownhosts.New(r.config.KnownHostsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known hosts %q: %v", r.config.KnownHostsPath, err)
+		}
+		return callback, nil
+	case r.config.HostKeyFingerprint != "":
+		expected := r.config.HostKeyFingerprint
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if actual := ssh.FingerprintSHA256(key); actual != expected {
+				return fmt.Errorf("host key fingerprint mismatch for %s: expected %s, got %s", hostname, expected, actual)
+			}
+			return nil
+		}, nil
+	default:
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+}
+
+// lineLogger returns a writer logging each complete line with the host name
+func (r *Runner) lineLogger(quiet bool, logf func(format string, args ...any)) *capture.LineWriter {
+	if quiet {
+		return capture.NewLineWriter(nil)
+	}
+	host := r.config.Host
+	return capture.NewLineWriter(func(line string) { logf("[%s] %s", host, line) })
+}
+
+// Quote returns s quoted for a POSIX shell, e.g. for file paths in commands
+func Quote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(c rune) bool {
+		return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./=:,+@%", c))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
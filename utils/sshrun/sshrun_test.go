@@ -0,0 +1,304 @@
+package sshrun
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+const testUser = "deploy"
+
+// testServer is an in-process SSH server with a few fake commands
+type testServer struct {
+	listener  net.Listener
+	hostKey   ssh.Signer
+	clientKey string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("failed to create host key: %v", err)
+	}
+	clientPub, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+	clientKey := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(clientKey, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("failed to write client key: %v", err)
+	}
+	authorized, _ := ssh.NewPublicKey(clientPub)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == testUser && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	return &testServer{listener: listener, hostKey: hostKey, clientKey: clientKey}
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(channel, requests)
+	}
+}
+
+// serveSession runs the fake commands: "echo <text>", "cat", "env <name>",
+// "fail" and "sleep"
+func serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	env := map[string]string{}
+	for req := range requests {
+		switch req.Type {
+		case "env":
+			var kv struct{ Name, Value string }
+			ssh.Unmarshal(req.Payload, &kv)
+			env[kv.Name] = kv.Value
+			req.Reply(true, nil)
+		case "exec":
+			var cmd struct{ Command string }
+			ssh.Unmarshal(req.Payload, &cmd)
+			req.Reply(true, nil)
+			go func() {
+				status := execute(channel, cmd.Command, env)
+				if status < 0 {
+					return
+				}
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+				channel.Close()
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func execute(channel ssh.Channel, command string, env map[string]string) int {
+	name, arg, _ := strings.Cut(command, " ")
+	switch name {
+	case "echo":
+		fmt.Fprintf(channel, "%s\n", arg)
+	case "cat":
+		io.Copy(channel, channel)
+	case "env":
+		fmt.Fprint(channel, env[arg])
+	case "fail":
+		fmt.Fprint(channel, "checking files\n")
+		fmt.Fprint(channel.Stderr(), "warning: slow disk\nerror: archive corrupt\n")
+		return 3
+	case "sleep":
+		// Run until the client closes the channel
+		io.Copy(io.Discard, channel)
+		return -1
+	default:
+		fmt.Fprintf(channel.Stderr(), "%s: command not found\n", name)
+		return 127
+	}
+	return 0
+}
+
+func (s *testServer) runner(t *testing.T) *Runner {
+	t.Helper()
+	log, err := logger.NewLogger("sshrun_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	addr := s.listener.Addr().(*net.TCPAddr)
+	r, err := NewRunner(log, Config{
+		Host:               "127.0.0.1",
+		Port:               addr.Port,
+		User:               testUser,
+		PrivateKeyPath:     s.clientKey,
+		HostKeyFingerprint: ssh.FingerprintSHA256(s.hostKey.PublicKey()),
+		Timeout:            5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRunner failed: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestNewRunnerValidation(t *testing.T) {
+	log, err := logger.NewLogger("sshrun_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	configs := []Config{
+		{User: "deploy", Password: "x", InsecureIgnoreHostKey: true},
+		{Host: "h", User: "deploy", InsecureIgnoreHostKey: true},
+		{Host: "h", User: "deploy", Password: "x"},
+	}
+	for _, cfg := range configs {
+		if _, err := NewRunner(log, cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	if _, err := NewRunner(nil, Config{}); err == nil {
+		t.Error("expected error for nil logger")
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := newTestServer(t).runner(t)
+	ctx := context.Background()
+
+	var copied bytes.Buffer
+	result, err := r.Run(ctx, "echo processed 42 files", &Options{Stdout: &copied})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Stdout != "processed 42 files\n" || result.ExitCode != 0 || copied.String() != result.Stdout {
+		t.Errorf("unexpected result %+v, copy %q", result, copied.String())
+	}
+
+	content, _ := os.ReadFile(r.logger.GetLogFilePath())
+	if !strings.Contains(string(content), "[127.0.0.1] processed 42 files") {
+		t.Errorf("expected output line in log, got:\n%s", content)
+	}
+
+	out, err := r.Output(ctx, "cat")
+	if err != nil || out != "" {
+		t.Errorf("Output(cat) = %q, %v", out, err)
+	}
+	result, err = r.Run(ctx, "cat", &Options{Stdin: strings.NewReader("line 1\nline 2"), Quiet: true})
+	if err != nil || result.Stdout != "line 1\nline 2" {
+		t.Errorf("Run(cat) = %+v, %v", result, err)
+	}
+
+	out, err = r.Output(ctx, "env BATCH_DATE")
+	if err != nil || out != "" {
+		t.Errorf("Output(env) = %q, %v", out, err)
+	}
+	result, err = r.Run(ctx, "env BATCH_DATE", &Options{Env: map[string]string{"BATCH_DATE": "2024-06-01"}})
+	if err != nil || result.Stdout != "2024-06-01" {
+		t.Errorf("Run(env) = %+v, %v", result, err)
+	}
+}
+
+func TestRunExitStatus(t *testing.T) {
+	r := newTestServer(t).runner(t)
+
+	result, err := r.Run(context.Background(), "fail", nil)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 3 {
+		t.Fatalf("expected ExitError with status 3, got %v", err)
+	}
+	if result == nil || result.ExitCode != 3 || !strings.Contains(result.Stderr, "slow disk") || result.Stdout != "checking files\n" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if !strings.HasSuffix(err.Error(), "error: archive corrupt") {
+		t.Errorf("expected last stderr line in error, got %q", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	r := newTestServer(t).runner(t)
+
+	start := time.Now()
+	_, err := r.Run(context.Background(), "sleep", &Options{Timeout: 100 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("command was not stopped at the timeout")
+	}
+
+	// The connection is still usable afterwards
+	if out, err := r.Output(context.Background(), "echo ok"); err != nil || out != "ok" {
+		t.Errorf("Output after timeout = %q, %v", out, err)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	r := newTestServer(t).runner(t)
+	ctx := context.Background()
+
+	if _, err := r.Output(ctx, "echo one"); err != nil {
+		t.Fatal(err)
+	}
+	// Drop the connection behind the runner's back
+	r.client.Conn.Close()
+	if out, err := r.Output(ctx, "echo two"); err != nil || out != "two" {
+		t.Errorf("Output after lost connection = %q, %v", out, err)
+	}
+}
+
+func TestHostKeyMismatch(t *testing.T) {
+	server := newTestServer(t)
+	r := server.runner(t)
+	r.config.HostKeyFingerprint = "SHA256:invalid"
+	if _, err := r.Output(context.Background(), "echo hi"); err == nil || !strings.Contains(err.Error(), "fingerprint mismatch") {
+		t.Errorf("expected fingerprint mismatch, got %v", err)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"orders.csv":            "orders.csv",
+		"/data/in/orders-1.csv": "/data/in/orders-1.csv",
+		"":                      "''",
+		"my file.csv":           "'my file.csv'",
+		"it's.csv":              `'it'\''s.csv'`,
+		"$(rm -rf /)":           "'$(rm -rf /)'",
+		"a;b":                   "'a;b'",
+	}
+	for input, expected := range tests {
+		if got := Quote(input); got != expected {
+			t.Errorf("Quote(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
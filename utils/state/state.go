@@ -0,0 +1,337 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names of the database layout
+var (
+	processedBucket = []byte("processed")
+	runsBucket      = []byte("runs")
+)
+
+// ErrNotFound is returned when a record or run does not exist
+var ErrNotFound = errors.New("not found")
+
+// RunStatus is the outcome of a run
+type RunStatus string
+
+// Run statuses
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+)
+
+// Options configures a Store
+type Options struct {
+	// Timeout bounds the wait for another process holding the database
+	// (default 5s)
+	Timeout time.Duration
+	// ReadOnly opens the database for queries only, e.g. from a reporting
+	// tool while the daemon is running
+	ReadOnly bool
+}
+
+// Record describes a processed file or object
+type Record struct {
+	Path        string            `json:"path"`
+	Checksum    string            `json:"checksum,omitempty"`
+	Size        int64             `json:"size,omitempty"`
+	RunID       string            `json:"run_id,omitempty"`
+	ProcessedAt time.Time         `json:"processed_at"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+// Run describes one execution of a job or pipeline
+type Run struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Status   RunStatus `json:"status"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished,omitzero"`
+	Files    int       `json:"files"`
+	Bytes    int64     `json:"bytes"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Duration returns how long a finished run took, or how long a running one
+// has been running
+func (r *Run) Duration() time.Duration {
+	if r.Finished.IsZero() {
+		return time.Since(r.Started)
+	}
+	return r.Finished.Sub(r.Started)
+}
+
+// Store keeps processed files and run history in an embedded bbolt database,
+// so pipelines can skip work already done before a restart. Only one process
+// at a time can open a database for writing.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database at path. A nil opts uses the defaults.
+func Open(path string, opts *Options) (*Store, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if !o.ReadOnly {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create state directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: o.Timeout, ReadOnly: o.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database %s: %w", path, err)
+	}
+	if !o.ReadOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{processedBucket, runsBucket} {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialize state database %s: %w", path, err)
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Path returns the database file path
+func (s *Store) Path() string {
+	return s.db.Path()
+}
+
+// MarkProcessed records a processed file, replacing an earlier record for
+// the same path. ProcessedAt defaults to now.
+func (s *Store) MarkProcessed(rec Record) error {
+	if rec.Path == "" {
+		return fmt.Errorf("record path cannot be empty")
+	}
+	if rec.ProcessedAt.IsZero() {
+		rec.ProcessedAt = time.Now()
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode record for %s: %w", rec.Path, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(processedBucket).Put([]byte(rec.Path), data)
+	})
+}
+
+// AlreadyProcessed reports whether path was processed with the given
+// checksum. A file that changed since, i.e. has a different checksum, was
+// not. An empty checksum matches any record for the path.
+func (s *Store) AlreadyProcessed(path, checksum string) (bool, error) {
+	rec, err := s.Get(path)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return checksum == "" || strings.EqualFold(rec.Checksum, checksum), nil
+}
+
+// Get returns the record for path, or an error wrapping ErrNotFound
+func (s *Store) Get(path string) (*Record, error) {
+	var rec *Record
+	err := s.view(processedBucket, func(b *bolt.Bucket) error {
+		data := b.Get([]byte(path))
+		if data == nil {
+			return nil
+		}
+		rec = &Record{}
+		return json.Unmarshal(data, rec)
+	})
+	if err == nil && rec == nil {
+		err = fmt.Errorf("record for %s: %w", path, ErrNotFound)
+	}
+	return rec, err
+}
+
+// Forget removes the record for path, so it is processed again
+func (s *Store) Forget(path string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(processedBucket).Delete([]byte(path))
+	})
+}
+
+// Processed returns the records whose path starts with prefix, sorted by path
+func (s *Store) Processed(prefix string) ([]Record, error) {
+	var records []Record
+	err := s.view(processedBucket, func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("failed to decode record for %s: %w", k, err)
+			}
+			records = append(records, rec)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Prune removes records processed before cutoff and returns how many were
+// removed, keeping the database small for long-running pipelines
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(processedBucket)
+		var stale [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("failed to decode record for %s: %w", k, err)
+			}
+			if rec.ProcessedAt.Before(cutoff) {
+				stale = append(stale, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(stale)
+		return nil
+	})
+	return removed, err
+}
+
+// StartRun records the start of a run of the named job. Update the returned
+// run's counters and pass it to FinishRun when done.
+func (s *Store) StartRun(name, id string) (*Run, error) {
+	if name == "" || id == "" {
+		return nil, fmt.Errorf("run name and ID cannot be empty")
+	}
+	run := &Run{ID: id, Name: name, Status: RunRunning, Started: time.Now()}
+	if err := s.putRun(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// FinishRun records the end of a run, failed when err is not nil
+func (s *Store) FinishRun(run *Run, err error) error {
+	run.Finished = time.Now()
+	run.Status = RunSucceeded
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+	}
+	return s.putRun(run)
+}
+
+// Runs returns the runs of the named job, newest first. A limit of 0
+// returns all runs.
+func (s *Store) Runs(name string, limit int) ([]Run, error) {
+	var runs []Run
+	err := s.view(runsBucket, func(b *bolt.Bucket) error {
+		jobs := b.Bucket([]byte(name))
+		if jobs == nil {
+			return nil
+		}
+		c := jobs.Cursor()
+		for k, v := c.Last(); k != nil && (limit <= 0 || len(runs) < limit); k, v = c.Prev() {
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return fmt.Errorf("failed to decode run %s: %w", k, err)
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	return runs, err
+}
+
+// LastRun returns the newest run of the named job, or with statuses the
+// newest run with one of them, e.g. the last successful run for incremental
+// processing. It returns an error wrapping ErrNotFound when there is none.
+func (s *Store) LastRun(name string, statuses ...RunStatus) (*Run, error) {
+	var found *Run
+	err := s.view(runsBucket, func(b *bolt.Bucket) error {
+		jobs := b.Bucket([]byte(name))
+		if jobs == nil {
+			return nil
+		}
+		c := jobs.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return fmt.Errorf("failed to decode run %s: %w", k, err)
+			}
+			if len(statuses) == 0 || slices.Contains(statuses, run.Status) {
+				found = &run
+				return nil
+			}
+		}
+		return nil
+	})
+	if err == nil && found == nil {
+		err = fmt.Errorf("run of %s: %w", name, ErrNotFound)
+	}
+	return found, err
+}
+
+// putRun stores run under its job, keyed by start time so runs sort
+// chronologically
+func (s *Store) putRun(run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run %s: %w", run.ID, err)
+	}
+	key := []byte(run.Started.UTC().Format("20060102T150405.000000000") + "/" + run.ID)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		jobs, err := tx.Bucket(runsBucket).CreateBucketIfNotExists([]byte(run.Name))
+		if err != nil {
+			return err
+		}
+		return jobs.Put(key, data)
+	})
+}
+
+// view runs fn with the named top-level bucket in a read-only transaction
+func (s *Store) view(name []byte, fn func(b *bolt.Bucket) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(name)
+		if b == nil {
+			// A read-only store opened on a database that was never written
+			return nil
+		}
+		return fn(b)
+	})
+}
@@ -0,0 +1,141 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state", "pipeline.db")
+	store, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, path
+}
+
+func TestAlreadyProcessed(t *testing.T) {
+	store, _ := openTestStore(t)
+
+	if done, err := store.AlreadyProcessed("inbound/orders.csv", "abc123"); err != nil || done {
+		t.Fatalf("AlreadyProcessed on empty store = %t, %v", done, err)
+	}
+	if err := store.MarkProcessed(Record{Path: "inbound/orders.csv", Checksum: "abc123", Size: 42, RunID: "run-1"}); err != nil {
+		t.Fatalf("MarkProcessed failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		checksum string
+		expected bool
+	}{
+		{"inbound/orders.csv", "abc123", true},
+		{"inbound/orders.csv", "ABC123", true},
+		{"inbound/orders.csv", "", true},
+		{"inbound/orders.csv", "def456", false},
+		{"inbound/other.csv", "abc123", false},
+	}
+	for _, test := range tests {
+		if done, err := store.AlreadyProcessed(test.path, test.checksum); err != nil || done != test.expected {
+			t.Errorf("AlreadyProcessed(%q, %q) = %t, %v, expected %t", test.path, test.checksum, done, err, test.expected)
+		}
+	}
+
+	rec, err := store.Get("inbound/orders.csv")
+	if err != nil || rec.Size != 42 || rec.RunID != "run-1" || rec.ProcessedAt.IsZero() {
+		t.Errorf("Get = %+v, %v", rec, err)
+	}
+	if err := store.Forget("inbound/orders.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("inbound/orders.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after Forget, got %v", err)
+	}
+	if err := store.MarkProcessed(Record{}); err == nil {
+		t.Error("expected error for empty path")
+	}
+}
+
+func TestProcessedAndPrune(t *testing.T) {
+	store, _ := openTestStore(t)
+	old := time.Now().Add(-48 * time.Hour)
+	for _, rec := range []Record{
+		{Path: "a/1.csv", ProcessedAt: old},
+		{Path: "a/2.csv"},
+		{Path: "b/1.csv", ProcessedAt: old},
+	} {
+		if err := store.MarkProcessed(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := store.Processed("a/")
+	if err != nil || len(records) != 2 || records[0].Path != "a/1.csv" || records[1].Path != "a/2.csv" {
+		t.Fatalf("Processed = %+v, %v", records, err)
+	}
+
+	removed, err := store.Prune(time.Now().Add(-24 * time.Hour))
+	if err != nil || removed != 2 {
+		t.Fatalf("Prune = %d, %v", removed, err)
+	}
+	if records, _ := store.Processed(""); len(records) != 1 || records[0].Path != "a/2.csv" {
+		t.Errorf("unexpected records after prune: %+v", records)
+	}
+}
+
+func TestRuns(t *testing.T) {
+	store, path := openTestStore(t)
+
+	first, err := store.StartRun("orders", "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Files, first.Bytes = 3, 1024
+	if err := store.FinishRun(first, nil); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := store.StartRun("orders", "run-2")
+	store.FinishRun(second, errors.New("upload failed"))
+	store.StartRun("orders", "run-3")
+	store.StartRun("invoices", "run-4")
+
+	runs, err := store.Runs("orders", 2)
+	if err != nil || len(runs) != 2 || runs[0].ID != "run-3" || runs[1].ID != "run-2" {
+		t.Fatalf("Runs = %+v, %v", runs, err)
+	}
+	if runs[1].Status != RunFailed || runs[1].Error != "upload failed" || runs[0].Status != RunRunning {
+		t.Errorf("unexpected statuses %+v", runs)
+	}
+	if all, _ := store.Runs("orders", 0); len(all) != 3 {
+		t.Errorf("expected 3 runs, got %d", len(all))
+	}
+
+	last, err := store.LastRun("orders", RunSucceeded)
+	if err != nil || last.ID != "run-1" || last.Files != 3 || last.Duration() < 0 {
+		t.Errorf("LastRun(succeeded) = %+v, %v", last, err)
+	}
+	if last, err := store.LastRun("orders"); err != nil || last.ID != "run-3" {
+		t.Errorf("LastRun = %+v, %v", last, err)
+	}
+	if _, err := store.LastRun("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.StartRun("", "run-5"); err == nil {
+		t.Error("expected error for empty run name")
+	}
+
+	// The history survives a restart
+	store.Close()
+	reopened, err := Open(path, &Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if last, err := reopened.LastRun("orders", RunSucceeded); err != nil || last.ID != "run-1" {
+		t.Errorf("LastRun after reopen = %+v, %v", last, err)
+	}
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+	"github.com/romisugianto/go-utils/utils/dirwalk"
+)
+
+// Local is a Backend storing objects as files under Root. Keys map to
+// slash-separated paths relative to Root and cannot escape it.
+type Local struct {
+	Root string
+}
+
+// path returns the file path for key
+func (l *Local) path(key string) (string, error) {
+	if l.Root == "" {
+		return "", fmt.Errorf("Root cannot be empty")
+	}
+	clean := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if clean == "" {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes r to the file for key. The content is written to a temporary
+// file first, so readers never see a partial object.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	file, err := atomicfile.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to store %q: %v", key, err)
+	}
+	defer file.Abort()
+	if _, err := io.Copy(file, r); err != nil {
+		return fmt.Errorf("failed to write %q: %v", key, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to store %q: %v", key, err)
+	}
+	return nil
+}
+
+// Get opens the file for key
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, NotFound(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %v", key, err)
+	}
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		file.Close()
+		return nil, NotFound(key)
+	}
+	return file, nil
+}
+
+// List returns the files whose key starts with prefix. Only the directory
+// containing the prefix is walked.
+func (l *Local) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	if l.Root == "" {
+		return nil, fmt.Errorf("Root cannot be empty")
+	}
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	// Walk from the deepest directory named by the prefix
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i]
+	}
+	root := filepath.Join(l.Root, filepath.FromSlash(dir))
+
+	entries, err := dirwalk.Collect(ctx, root, &dirwalk.Options{
+		Filter: func(e dirwalk.Entry) bool {
+			key := path.Join(dir, e.RelPath)
+			if e.IsDir() {
+				// Descend only into directories that can contain matching keys
+				return strings.HasPrefix(key+"/", prefix) || strings.HasPrefix(prefix, key+"/")
+			}
+			// Skip temporary files of unfinished Puts
+			return strings.HasPrefix(key, prefix) && !isTempFile(e.Name())
+		},
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %q: %v", prefix, err)
+	}
+
+	objects := make([]ObjectInfo, 0, len(entries))
+	for _, e := range entries {
+		objects = append(objects, ObjectInfo{Key: path.Join(dir, e.RelPath), Size: e.Size(), ModTime: e.ModTime()})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete removes the file for key
+func (l *Local) Delete(ctx context.Context, key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); errors.Is(err, fs.ErrNotExist) {
+		return NotFound(key)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete %q: %v", key, err)
+	}
+	return nil
+}
+
+// Stat returns the size and modification time of the file for key
+func (l *Local) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return ObjectInfo{}, NotFound(key)
+	}
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat %q: %v", key, err)
+	}
+	return ObjectInfo{Key: strings.TrimPrefix(path.Clean("/"+key), "/"), Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// isTempFile reports whether name is a temporary file created by Put
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-")
+}
@@ -0,0 +1,91 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotFound is wrapped by the errors Backend methods return for keys that
+// do not exist
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	// Key is the slash-separated object name
+	Key     string
+	Size    int64
+	ModTime time.Time
+	// ETag is the backend's content identifier, when it provides one
+	ETag string
+}
+
+// Backend is an object store that files can be delivered to and fetched
+// from. It is implemented by Local, s3helper.S3Helper, gcshelper.GCSHelper
+// and azblobhelper.AzBlobHelper, so code written against it works with any
+// of them.
+type Backend interface {
+	// Put stores the content of r under key, replacing an existing object
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the object for reading. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects whose key starts with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Delete removes the object. S3 does not report missing keys, so a
+	// missing object is not guaranteed to return ErrNotFound.
+	Delete(ctx context.Context, key string) error
+	// Stat returns the object's metadata
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+}
+
+// NotFound returns an error wrapping ErrNotFound for key
+func NotFound(key string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, key)
+}
+
+// PutFile uploads a local file to key
+func PutFile(ctx context.Context, b Backend, filePath, key string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %v", filePath, err)
+	}
+	defer file.Close()
+	return b.Put(ctx, key, file)
+}
+
+// GetFile downloads key to a local file, creating its directory
+func GetFile(ctx context.Context, b Backend, key, filePath string) error {
+	r, err := b.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %q: %v", filePath, err)
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file %q: %v", filePath, err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write to local file %q: %v", filePath, err)
+	}
+	return file.Close()
+}
+
+// Copy copies an object between backends, e.g. from S3 to GCS
+func Copy(ctx context.Context, dst Backend, dstKey string, src Backend, srcKey string) error {
+	r, err := src.Get(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return dst.Put(ctx, dstKey, r)
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	root := t.TempDir()
+	local := &Local{Root: root}
+	ctx := context.Background()
+
+	for key, content := range map[string]string{
+		"/acme/2024-06-01/export.csv": "id,name\n1,acme\n",
+		"acme/2024-06-02/export.csv":  "id,name\n",
+		"acme.csv":                    "acme",
+		"other/d.csv":                 "d",
+	} {
+		if err := local.Put(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatalf("Put(%q) failed: %v", key, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "acme", "2024-06-01", "export.csv")); string(data) != "id,name\n1,acme\n" {
+		t.Errorf("stored content = %q", data)
+	}
+	// A leftover temporary file must not be listed
+	os.WriteFile(filepath.Join(root, "acme", ".export.csv.tmp-123"), []byte("partial"), 0644)
+
+	r, err := local.Get(ctx, "acme/2024-06-01/export.csv")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "id,name\n1,acme\n" {
+		t.Errorf("Get content = %q", data)
+	}
+
+	tests := map[string]string{
+		"":                "acme.csv,acme/2024-06-01/export.csv,acme/2024-06-02/export.csv,other/d.csv",
+		"acme":            "acme.csv,acme/2024-06-01/export.csv,acme/2024-06-02/export.csv",
+		"acme/":           "acme/2024-06-01/export.csv,acme/2024-06-02/export.csv",
+		"acme/2024-06-01": "acme/2024-06-01/export.csv",
+		"missing/":        "",
+	}
+	for prefix, expected := range tests {
+		objects, err := local.List(ctx, prefix)
+		if err != nil {
+			t.Fatalf("List(%q) failed: %v", prefix, err)
+		}
+		var keys []string
+		for _, obj := range objects {
+			keys = append(keys, obj.Key)
+		}
+		if strings.Join(keys, ",") != expected {
+			t.Errorf("List(%q) = %v, expected %s", prefix, keys, expected)
+		}
+	}
+
+	info, err := local.Stat(ctx, "/acme/2024-06-01/export.csv")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Key != "acme/2024-06-01/export.csv" || info.Size != 15 || info.ModTime.IsZero() {
+		t.Errorf("Stat = %+v", info)
+	}
+	if _, err := local.Stat(ctx, "acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a directory, got %v", err)
+	}
+
+	if err := local.Delete(ctx, "acme/2024-06-01/export.csv"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := local.Delete(ctx, "acme/2024-06-01/export.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Delete, got %v", err)
+	}
+	if _, err := local.Get(ctx, "acme/2024-06-01/export.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound from Get, got %v", err)
+	}
+}
+
+func TestLocalKeysStayInRoot(t *testing.T) {
+	root := t.TempDir()
+	local := &Local{Root: filepath.Join(root, "store")}
+
+	if err := local.Put(context.Background(), "../../escape.csv", strings.NewReader("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "store", "escape.csv")); err != nil {
+		t.Errorf("expected key to be stored under Root: %v", err)
+	}
+	if err := local.Put(context.Background(), "/", strings.NewReader("x")); err == nil {
+		t.Error("expected error for empty key")
+	}
+	if _, err := (&Local{}).List(context.Background(), ""); err == nil {
+		t.Error("expected error for empty Root")
+	}
+}
+
+func TestCopyAndFiles(t *testing.T) {
+	ctx := context.Background()
+	src := &Local{Root: t.TempDir()}
+	dst := &Local{Root: t.TempDir()}
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "export.csv")
+	os.WriteFile(file, []byte("id,name\n"), 0644)
+	if err := PutFile(ctx, src, file, "in/export.csv"); err != nil {
+		t.Fatalf("PutFile failed: %v", err)
+	}
+	if err := Copy(ctx, dst, "out/export.csv", src, "in/export.csv"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	out := filepath.Join(dir, "downloads", "export.csv")
+	if err := GetFile(ctx, dst, "out/export.csv", out); err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "id,name\n" {
+		t.Errorf("copied content = %q", data)
+	}
+
+	if err := Copy(ctx, dst, "x", src, "missing.csv"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound copying a missing object, got %v", err)
+	}
+	if err := PutFile(ctx, src, filepath.Join(dir, "missing.csv"), "x"); err == nil {
+		t.Error("expected error for missing local file")
+	}
+}
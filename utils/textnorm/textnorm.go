@@ -0,0 +1,382 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package textnorm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+
+	"github.com/romisugianto/go-utils/utils/atomicfile"
+)
+
+// Encoding names a text encoding
+type Encoding string
+
+// Supported source encodings. Output is always UTF-8 without a BOM.
+const (
+	UTF8    Encoding = "utf-8"
+	UTF16LE Encoding = "utf-16le"
+	UTF16BE Encoding = "utf-16be"
+	// Latin1 is ISO-8859-1
+	Latin1 Encoding = "iso-8859-1"
+	// Windows1252 is the Western European code page used by Excel and most
+	// Windows exports; it is assumed for input that is not valid UTF-8
+	Windows1252 Encoding = "windows-1252"
+)
+
+// LineEnding is the line terminator written by a normalizer
+type LineEnding string
+
+// Supported line endings
+const (
+	// Keep leaves line endings unchanged
+	Keep LineEnding = ""
+	LF   LineEnding = "\n"
+	CRLF LineEnding = "\r\n"
+)
+
+// sampleSize is how much input is inspected for encoding detection
+const sampleSize = 64 << 10
+
+// Options configures normalization
+type Options struct {
+	// Encoding of the input; empty detects it from a BOM or the content
+	Encoding Encoding
+	// LineEnding converts CRLF, CR and LF line endings; Keep leaves them as is
+	LineEnding LineEnding
+	// FinalNewline adds a line ending after the last line if it is missing
+	FinalNewline bool
+}
+
+// Info describes the encoding and line endings of a text
+type Info struct {
+	Encoding Encoding
+	// BOM reports whether the text starts with a byte order mark
+	BOM bool
+	// CRLF, LF and CR count the line endings of each kind
+	CRLF int
+	LF   int
+	CR   int
+}
+
+// LineEnding returns the line ending used by the text, Keep when it has no
+// line endings or mixes them
+func (i Info) LineEnding() LineEnding {
+	switch {
+	case i.CRLF > 0 && i.LF == 0 && i.CR == 0:
+		return CRLF
+	case i.LF > 0 && i.CRLF == 0 && i.CR == 0:
+		return LF
+	}
+	return Keep
+}
+
+// Mixed reports whether the text uses more than one kind of line ending
+func (i Info) Mixed() bool {
+	kinds := 0
+	for _, n := range []int{i.CRLF, i.LF, i.CR} {
+		if n > 0 {
+			kinds++
+		}
+	}
+	return kinds > 1
+}
+
+// ParseEncoding parses an encoding name such as "utf-16le", "latin1" or "cp1252"
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "", "auto":
+		return "", nil
+	case "utf-8", "utf8":
+		return UTF8, nil
+	case "utf-16le", "utf16le", "utf-16", "utf16":
+		return UTF16LE, nil
+	case "utf-16be", "utf16be":
+		return UTF16BE, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		return Latin1, nil
+	case "windows-1252", "cp1252", "win1252":
+		return Windows1252, nil
+	}
+	return "", fmt.Errorf("unsupported encoding %q", name)
+}
+
+// ParseLineEnding parses "lf", "crlf" or "keep"
+func ParseLineEnding(name string) (LineEnding, error) {
+	switch strings.ToLower(name) {
+	case "", "keep":
+		return Keep, nil
+	case "lf", "unix":
+		return LF, nil
+	case "crlf", "windows":
+		return CRLF, nil
+	}
+	return Keep, fmt.Errorf("unsupported line ending %q", name)
+}
+
+// Detect returns the encoding of sample and the length of its BOM. Without
+// a BOM, UTF-16 is recognized by its zero bytes, valid UTF-8 is UTF8 and
+// anything else is assumed to be Windows1252.
+func Detect(sample []byte) (Encoding, int) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xef, 0xbb, 0xbf}):
+		return UTF8, 3
+	case bytes.HasPrefix(sample, []byte{0xff, 0xfe}):
+		return UTF16LE, 2
+	case bytes.HasPrefix(sample, []byte{0xfe, 0xff}):
+		return UTF16BE, 2
+	}
+
+	// ASCII text in UTF-16 has a zero in every other byte
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b == 0 {
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case pairs > 0 && oddZeros > pairs/3 && evenZeros == 0:
+		return UTF16LE, 0
+	case pairs > 0 && evenZeros > pairs/3 && oddZeros == 0:
+		return UTF16BE, 0
+	}
+
+	if utf8.Valid(trimPartialRune(sample)) {
+		return UTF8, 0
+	}
+	return Windows1252, 0
+}
+
+// trimPartialRune drops a multi-byte rune cut off at the end of a sample
+func trimPartialRune(sample []byte) []byte {
+	for i := 1; i <= 3 && i <= len(sample); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				return sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	return sample
+}
+
+// decoder returns the decoder for e
+func (e Encoding) decoder() (*encoding.Decoder, error) {
+	switch e {
+	case UTF8:
+		return unicode.UTF8.NewDecoder(), nil
+	case UTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder(), nil
+	case UTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder(), nil
+	case Latin1:
+		return charmap.ISO8859_1.NewDecoder(), nil
+	case Windows1252:
+		return charmap.Windows1252.NewDecoder(), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", e)
+}
+
+// NewReader returns a reader that decodes r to UTF-8, drops a BOM and
+// converts line endings as configured. A nil opts detects the encoding and
+// keeps line endings.
+func NewReader(r io.Reader, opts *Options) (io.Reader, error) {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+
+	buffered := bufio.NewReaderSize(r, sampleSize)
+	sample, err := buffered.Peek(sampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("failed to read sample: %w", err)
+	}
+	detected, bomLen := Detect(sample)
+	if o.Encoding == "" {
+		o.Encoding = detected
+	} else if o.Encoding != detected {
+		// A BOM only belongs to the detected encoding
+		bomLen = 0
+	}
+	buffered.Discard(bomLen)
+
+	decoder, err := o.Encoding.decoder()
+	if err != nil {
+		return nil, err
+	}
+	transformers := []transform.Transformer{decoder}
+	if o.LineEnding != Keep || o.FinalNewline {
+		transformers = append(transformers, &lineEndings{eol: []byte(o.LineEnding), final: o.FinalNewline})
+	}
+	return transform.NewReader(buffered, transform.Chain(transformers...)), nil
+}
+
+// Preprocessor returns a function that normalizes a reader with opts, for
+// use as a splitter.Splitter Preprocess step
+func Preprocessor(opts *Options) func(r io.Reader) (io.Reader, error) {
+	return func(r io.Reader) (io.Reader, error) {
+		return NewReader(r, opts)
+	}
+}
+
+// DetectFile reports the encoding, BOM and line endings of a file. Line
+// endings are counted after decoding, so UTF-16 files are counted correctly.
+func DetectFile(path string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReaderSize(file, sampleSize)
+	sample, err := buffered.Peek(sampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info := &Info{}
+	var bomLen int
+	info.Encoding, bomLen = Detect(sample)
+	info.BOM = bomLen > 0
+	buffered.Discard(bomLen)
+
+	decoder, _ := info.Encoding.decoder()
+	text := bufio.NewReader(transform.NewReader(buffered, decoder))
+	for {
+		b, err := text.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		switch b {
+		case '\n':
+			info.LF++
+		case '\r':
+			if next, err := text.Peek(1); err == nil && next[0] == '\n' {
+				text.Discard(1)
+				info.CRLF++
+			} else {
+				info.CR++
+			}
+		}
+	}
+	return info, nil
+}
+
+// NormalizeFile writes the normalized content of src to dst and returns what
+// was detected in src. An empty dst, or dst equal to src, rewrites src in
+// place. The output is written to a temporary file first and keeps the
+// permissions of src.
+func NormalizeFile(src, dst string, opts *Options) (*Info, error) {
+	info, err := DetectFile(src)
+	if err != nil {
+		return nil, err
+	}
+	if dst == "" {
+		dst = src
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", src, err)
+	}
+	defer in.Close()
+	stat, err := in.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", src, err)
+	}
+	reader, err := NewReader(in, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", src, err)
+	}
+
+	out, err := atomicfile.Create(dst, stat.Mode().Perm())
+	if err != nil {
+		return nil, err
+	}
+	defer out.Abort()
+	if _, err := io.Copy(out, reader); err != nil {
+		return nil, fmt.Errorf("failed to normalize %s: %w", src, err)
+	}
+	in.Close()
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// lineEndings is a transformer converting CRLF, CR and LF to eol, or only
+// adding a final line ending when eol is empty
+type lineEndings struct {
+	eol   []byte
+	final bool
+	// last is the last byte written, to detect a missing final newline
+	last byte
+}
+
+// Transform implements transform.Transformer
+func (t *lineEndings) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		c := src[nSrc]
+		if len(t.eol) == 0 || (c != '\r' && c != '\n') {
+			if nDst == len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = c
+			nDst++
+			nSrc++
+			t.last = c
+			continue
+		}
+
+		consumed := 1
+		if c == '\r' {
+			if nSrc+1 == len(src) && !atEOF {
+				// Wait for the next byte to tell CR from CRLF
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if nSrc+1 < len(src) && src[nSrc+1] == '\n' {
+				consumed = 2
+			}
+		}
+		if nDst+len(t.eol) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], t.eol)
+		nSrc += consumed
+		t.last = '\n'
+	}
+
+	if atEOF && t.final && t.last != 0 && t.last != '\n' && t.last != '\r' {
+		eol := t.eol
+		if len(eol) == 0 {
+			eol = []byte(LF)
+		}
+		if nDst+len(eol) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], eol)
+		t.last = '\n'
+	}
+	return nDst, nSrc, nil
+}
+
+// Reset implements transform.Transformer
+func (t *lineEndings) Reset() {
+	t.last = 0
+}
@@ -0,0 +1,163 @@
+package textnorm
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes s as UTF-16 with an optional BOM
+func encodeUTF16(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xfeff}, units...)
+	}
+	var buf bytes.Buffer
+	for _, u := range units {
+		if bigEndian {
+			buf.Write([]byte{byte(u >> 8), byte(u)})
+		} else {
+			buf.Write([]byte{byte(u), byte(u >> 8)})
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	text := "id,name\r\n1,Café\r\n"
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding Encoding
+		bom      int
+	}{
+		{"utf-8", []byte(text), UTF8, 0},
+		{"utf-8 bom", append([]byte{0xef, 0xbb, 0xbf}, text...), UTF8, 3},
+		{"utf-16le bom", encodeUTF16(text, false, true), UTF16LE, 2},
+		{"utf-16be bom", encodeUTF16(text, true, true), UTF16BE, 2},
+		{"utf-16le", encodeUTF16(text, false, false), UTF16LE, 0},
+		{"utf-16be", encodeUTF16(text, true, false), UTF16BE, 0},
+		{"windows-1252", []byte("id,name\n1,Caf\xe9 \x80\n"), Windows1252, 0},
+		// A multi-byte rune cut off at the end of the sample is still UTF-8
+		{"truncated utf-8", []byte("Caf\xc3"), UTF8, 0},
+		{"empty", nil, UTF8, 0},
+	}
+	for _, tt := range tests {
+		encoding, bom := Detect(tt.data)
+		if encoding != tt.encoding || bom != tt.bom {
+			t.Errorf("%s: Detect = %s, %d, expected %s, %d", tt.name, encoding, bom, tt.encoding, tt.bom)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		opts     *Options
+		expected string
+	}{
+		{"utf-16le to lf", encodeUTF16("a,b\r\n1,Café\r\n", false, true), &Options{LineEnding: LF}, "a,b\n1,Café\n"},
+		{"utf-8 bom kept endings", append([]byte{0xef, 0xbb, 0xbf}, "a\r\nb\n"...), nil, "a\r\nb\n"},
+		{"windows-1252 to crlf", []byte("a\nCaf\xe9 \x80\rend"), &Options{LineEnding: CRLF}, "a\r\nCafé €\r\nend"},
+		{"latin1", []byte("Caf\xe9"), &Options{Encoding: Latin1}, "Café"},
+		{"final newline", []byte("a\r\nb"), &Options{LineEnding: LF, FinalNewline: true}, "a\nb\n"},
+		{"final newline kept endings", []byte("a\r\nb"), &Options{FinalNewline: true}, "a\r\nb\n"},
+		{"final newline present", []byte("a\n"), &Options{FinalNewline: true}, "a\n"},
+		{"trailing cr", []byte("a\r"), &Options{LineEnding: LF}, "a\n"},
+		{"empty", nil, &Options{LineEnding: LF, FinalNewline: true}, ""},
+	}
+	for _, tt := range tests {
+		reader, err := NewReader(bytes.NewReader(tt.data), tt.opts)
+		if err != nil {
+			t.Fatalf("%s: NewReader failed: %v", tt.name, err)
+		}
+		out, err := io.ReadAll(reader)
+		if err != nil || string(out) != tt.expected {
+			t.Errorf("%s: got %q, %v, expected %q", tt.name, out, err, tt.expected)
+		}
+	}
+
+	// CRLF split across reads is converted once
+	long := strings.Repeat("x", sampleSize-1) + "\r\n" + strings.Repeat("y", 10) + "\r\n"
+	reader, _ := NewReader(iotest.HalfReader(strings.NewReader(long)), &Options{LineEnding: LF})
+	out, _ := io.ReadAll(reader)
+	if strings.Count(string(out), "\n") != 2 || strings.Contains(string(out), "\r") {
+		t.Errorf("unexpected line endings in %d bytes", len(out))
+	}
+
+	if _, err := NewReader(strings.NewReader("a"), &Options{Encoding: "ebcdic"}); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "export.csv")
+	os.WriteFile(src, encodeUTF16("id,name\r\n1,Café\r\n2,Zoë\n", false, true), 0600)
+
+	info, err := DetectFile(src)
+	if err != nil {
+		t.Fatalf("DetectFile failed: %v", err)
+	}
+	if info.Encoding != UTF16LE || !info.BOM || info.CRLF != 2 || info.LF != 1 || !info.Mixed() || info.LineEnding() != Keep {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	dst := filepath.Join(dir, "normalized", "export.csv")
+	if _, err := NormalizeFile(src, dst, &Options{LineEnding: LF}); err != nil {
+		t.Fatalf("NormalizeFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "id,name\n1,Café\n2,Zoë\n" {
+		t.Errorf("normalized content = %q", data)
+	}
+
+	// In place, keeping the permissions
+	info, err = NormalizeFile(src, "", &Options{LineEnding: CRLF})
+	if err != nil {
+		t.Fatalf("NormalizeFile in place failed: %v", err)
+	}
+	if info.Encoding != UTF16LE {
+		t.Errorf("expected the detected source encoding, got %s", info.Encoding)
+	}
+	if data, _ := os.ReadFile(src); string(data) != "id,name\r\n1,Café\r\n2,Zoë\r\n" {
+		t.Errorf("normalized content = %q", data)
+	}
+	if stat, _ := os.Stat(src); stat.Mode().Perm() != 0600 {
+		t.Errorf("permissions = %v, expected 0600", stat.Mode().Perm())
+	}
+	if info, _ := DetectFile(src); info.Encoding != UTF8 || info.BOM || info.LineEnding() != CRLF {
+		t.Errorf("unexpected info after normalizing %+v", info)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected no temporary files, found %v", entries)
+	}
+
+	if _, err := NormalizeFile(filepath.Join(dir, "missing.csv"), "", nil); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for name, expected := range map[string]Encoding{"": "", "UTF8": UTF8, "utf_16le": UTF16LE, "latin1": Latin1, "cp1252": Windows1252} {
+		if got, err := ParseEncoding(name); err != nil || got != expected {
+			t.Errorf("ParseEncoding(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseEncoding("shift-jis"); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+	for name, expected := range map[string]LineEnding{"": Keep, "LF": LF, "crlf": CRLF, "windows": CRLF} {
+		if got, err := ParseLineEnding(name); err != nil || got != expected {
+			t.Errorf("ParseLineEnding(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseLineEnding("cr"); err == nil {
+		t.Error("expected error for unsupported line ending")
+	}
+}
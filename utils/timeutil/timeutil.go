@@ -0,0 +1,240 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package timeutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/scheduler"
+)
+
+// Common stamp layouts for file names and object keys
+const (
+	DateStamp     = "20060102"
+	DateTimeStamp = "20060102T150405"
+	MonthStamp    = "200601"
+	ISODate       = "2006-01-02"
+)
+
+// LoadLocation returns the named time zone, e.g. "Asia/Jakarta". An empty
+// name or "Local" returns the local zone and "UTC" returns UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// In returns t in loc, or t unchanged when loc is nil
+func In(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+// StartOfDay returns midnight of t's day in t's location
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfDay returns the last nanosecond of t's day
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// StartOfWeek returns midnight of the first day of t's week, with weeks
+// starting on firstDay, e.g. time.Monday
+func StartOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(firstDay) + 7) % 7
+	return StartOfDay(t).AddDate(0, 0, -offset)
+}
+
+// EndOfWeek returns the last nanosecond of t's week, with weeks starting on
+// firstDay
+func EndOfWeek(t time.Time, firstDay time.Weekday) time.Time {
+	return StartOfWeek(t, firstDay).AddDate(0, 0, 7).Add(-time.Nanosecond)
+}
+
+// StartOfMonth returns midnight of the first day of t's month
+func StartOfMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfMonth returns the last nanosecond of t's month
+func EndOfMonth(t time.Time) time.Time {
+	return StartOfMonth(t).AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+// Calendar knows weekends and holidays for business-day arithmetic. Dates
+// are compared by calendar day in the location of the times passed in.
+type Calendar struct {
+	weekend  [7]bool
+	holidays map[string]bool
+}
+
+// defaultCalendar has Saturday and Sunday weekends and no holidays
+var defaultCalendar = NewCalendar(nil)
+
+// NewCalendar creates a calendar with the given holidays. Weekend days
+// default to Saturday and Sunday.
+func NewCalendar(holidays []time.Time, weekend ...time.Weekday) *Calendar {
+	if len(weekend) == 0 {
+		weekend = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	c := &Calendar{holidays: make(map[string]bool, len(holidays))}
+	for _, day := range weekend {
+		c.weekend[day] = true
+	}
+	for _, holiday := range holidays {
+		c.holidays[holiday.Format(ISODate)] = true
+	}
+	return c
+}
+
+// ParseHolidays parses dates in "2006-01-02" form, e.g. from a configuration
+// file, for NewCalendar
+func ParseHolidays(dates ...string) ([]time.Time, error) {
+	holidays := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		t, err := time.Parse(ISODate, strings.TrimSpace(date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: %w", date, err)
+		}
+		holidays = append(holidays, t)
+	}
+	return holidays, nil
+}
+
+// IsBusinessDay reports whether t falls on neither a weekend nor a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return !c.weekend[t.Weekday()] && !c.holidays[t.Format(ISODate)]
+}
+
+// AddBusinessDays moves t by n business days, backwards for a negative n,
+// keeping the time of day. With n of 0, t is returned unchanged.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// NextBusinessDay returns t if it is a business day, or the next one
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// PreviousBusinessDay returns the last business day before t's day, e.g. the
+// date of the data a Monday morning batch should process
+func (c *Calendar) PreviousBusinessDay(t time.Time) time.Time {
+	return c.AddBusinessDays(t, -1)
+}
+
+// BusinessDaysBetween counts the business days from from's day up to but
+// not including to's day. It is negative when to is before from.
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	count := 0
+	end := StartOfDay(to)
+	for day := StartOfDay(from); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(day) {
+			count++
+		}
+	}
+	return sign * count
+}
+
+// IsBusinessDay reports whether t is a weekday
+func IsBusinessDay(t time.Time) bool {
+	return defaultCalendar.IsBusinessDay(t)
+}
+
+// AddBusinessDays moves t by n weekdays
+func AddBusinessDays(t time.Time, n int) time.Time {
+	return defaultCalendar.AddBusinessDays(t, n)
+}
+
+// NextRun returns the first time after after matching the cron expression,
+// evaluated in after's location. Expressions use the syntax of
+// scheduler.ParseCron, including descriptors such as "@daily".
+func NextRun(expr string, after time.Time) (time.Time, error) {
+	runs, err := NextRuns(expr, after, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return runs[0], nil
+}
+
+// NextRuns returns the next n times after after matching the cron
+// expression, e.g. to show upcoming batch windows
+func NextRuns(expr string, after time.Time, n int) ([]time.Time, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("run count must be positive, got %d", n)
+	}
+	schedule, err := scheduler.ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		after = schedule.Next(after)
+		if after.IsZero() {
+			return nil, fmt.Errorf("cron expression %q never matches", expr)
+		}
+		runs = append(runs, after)
+	}
+	return runs, nil
+}
+
+// Stamp formats t with layout in loc, e.g. Stamp(t, DateStamp, jakarta)
+// returns "20240601" for a file name. A nil loc keeps t's location.
+func Stamp(t time.Time, layout string, loc *time.Location) string {
+	return In(t, loc).Format(layout)
+}
+
+// ParseStamp parses a stamp written by Stamp, interpreting it in loc (UTC
+// when nil)
+func ParseStamp(s, layout string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid stamp %q for layout %q: %w", s, layout, err)
+	}
+	return t, nil
+}
+
+// StampFromName finds the first stamp with a fixed-width layout in a file
+// name, e.g. the date of "orders_20240601.csv" with DateStamp, so retention
+// policies can use the date in the name rather than the modification time
+func StampFromName(name, layout string, loc *time.Location) (time.Time, bool) {
+	width := len(layout)
+	for i := 0; i+width <= len(name); i++ {
+		if t, err := ParseStamp(name[i:i+width], layout, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
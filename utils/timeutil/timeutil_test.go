@@ -0,0 +1,163 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, time.UTC)
+}
+
+func TestDayWeekMonthBoundaries(t *testing.T) {
+	// Wednesday
+	ts := date(2024, time.February, 14, 15, 30)
+
+	tests := []struct {
+		name     string
+		got      time.Time
+		expected time.Time
+	}{
+		{"StartOfDay", StartOfDay(ts), date(2024, time.February, 14, 0, 0)},
+		{"EndOfDay", EndOfDay(ts), date(2024, time.February, 15, 0, 0).Add(-time.Nanosecond)},
+		{"StartOfWeek Monday", StartOfWeek(ts, time.Monday), date(2024, time.February, 12, 0, 0)},
+		{"StartOfWeek Sunday", StartOfWeek(ts, time.Sunday), date(2024, time.February, 11, 0, 0)},
+		{"EndOfWeek Monday", EndOfWeek(ts, time.Monday), date(2024, time.February, 19, 0, 0).Add(-time.Nanosecond)},
+		{"StartOfMonth", StartOfMonth(ts), date(2024, time.February, 1, 0, 0)},
+		{"EndOfMonth leap year", EndOfMonth(ts), date(2024, time.March, 1, 0, 0).Add(-time.Nanosecond)},
+		{"StartOfWeek on first day", StartOfWeek(date(2024, time.February, 12, 8, 0), time.Monday), date(2024, time.February, 12, 0, 0)},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(tt.expected) {
+			t.Errorf("%s = %v, expected %v", tt.name, tt.got, tt.expected)
+		}
+	}
+
+	jakarta, err := LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := StartOfDay(ts.In(jakarta))
+	if local.Location() != jakarta || local.Hour() != 0 || local.Day() != 14 {
+		t.Errorf("expected midnight in Jakarta, got %v", local)
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	friday := date(2024, time.May, 31, 9, 0)
+	if !IsBusinessDay(friday) || IsBusinessDay(friday.AddDate(0, 0, 1)) {
+		t.Error("unexpected weekday classification")
+	}
+	if got := AddBusinessDays(friday, 1); !got.Equal(date(2024, time.June, 3, 9, 0)) {
+		t.Errorf("AddBusinessDays(friday, 1) = %v", got)
+	}
+	if got := AddBusinessDays(date(2024, time.June, 3, 9, 0), -1); !got.Equal(friday) {
+		t.Errorf("AddBusinessDays(monday, -1) = %v", got)
+	}
+
+	holidays, err := ParseHolidays("2024-06-03", " 2024-06-17 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := NewCalendar(holidays)
+	if got := cal.AddBusinessDays(friday, 1); !got.Equal(date(2024, time.June, 4, 9, 0)) {
+		t.Errorf("expected holiday to be skipped, got %v", got)
+	}
+	if got := cal.PreviousBusinessDay(date(2024, time.June, 4, 6, 0)); got.Day() != 31 {
+		t.Errorf("PreviousBusinessDay = %v", got)
+	}
+	if got := cal.NextBusinessDay(date(2024, time.June, 1, 0, 0)); got.Day() != 4 {
+		t.Errorf("NextBusinessDay = %v", got)
+	}
+	if got := cal.NextBusinessDay(friday); !got.Equal(friday) {
+		t.Errorf("NextBusinessDay of a business day = %v", got)
+	}
+	// June 2024 has 20 weekdays, two of which are holidays here
+	june, july := date(2024, time.June, 1, 0, 0), date(2024, time.July, 1, 0, 0)
+	if got := cal.BusinessDaysBetween(june, july); got != 18 {
+		t.Errorf("BusinessDaysBetween = %d, expected 18", got)
+	}
+	if got := cal.BusinessDaysBetween(july, june); got != -18 {
+		t.Errorf("reversed BusinessDaysBetween = %d, expected -18", got)
+	}
+
+	// Friday and Saturday weekends
+	gulf := NewCalendar(nil, time.Friday, time.Saturday)
+	if gulf.IsBusinessDay(friday) || !gulf.IsBusinessDay(date(2024, time.June, 2, 0, 0)) {
+		t.Error("unexpected custom weekend classification")
+	}
+
+	if _, err := ParseHolidays("2024-13-01"); err == nil {
+		t.Error("expected error for invalid holiday")
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	after := date(2024, time.June, 1, 10, 0) // Saturday
+	next, err := NextRun("30 2 * * mon-fri", after)
+	if err != nil || !next.Equal(date(2024, time.June, 3, 2, 30)) {
+		t.Errorf("NextRun = %v, %v", next, err)
+	}
+
+	runs, err := NextRuns("@daily", after, 3)
+	if err != nil || len(runs) != 3 || !runs[2].Equal(date(2024, time.June, 4, 0, 0)) {
+		t.Errorf("NextRuns = %v, %v", runs, err)
+	}
+
+	jakarta, _ := LoadLocation("Asia/Jakarta")
+	next, err = NextRun("0 1 * * *", after.In(jakarta))
+	if err != nil || next.Hour() != 1 || next.Location() != jakarta {
+		t.Errorf("expected run at 01:00 Jakarta time, got %v, %v", next, err)
+	}
+
+	for _, expr := range []string{"bad", "0 0 30 2 *"} {
+		if _, err := NextRun(expr, after); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+	if _, err := NextRuns("@daily", after, 0); err == nil {
+		t.Error("expected error for zero count")
+	}
+}
+
+func TestStamps(t *testing.T) {
+	ts := date(2024, time.June, 1, 20, 15)
+	jakarta, err := LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Stamp(ts, DateStamp, nil); got != "20240601" {
+		t.Errorf("Stamp UTC = %q", got)
+	}
+	// 20:15 UTC is already the next day in Jakarta (UTC+7)
+	if got := Stamp(ts, DateTimeStamp, jakarta); got != "20240602T031500" {
+		t.Errorf("Stamp Jakarta = %q", got)
+	}
+
+	parsed, err := ParseStamp("20240602T031500", DateTimeStamp, jakarta)
+	if err != nil || !parsed.Equal(ts) {
+		t.Errorf("ParseStamp = %v, %v", parsed, err)
+	}
+	if _, err := ParseStamp("2024-06", MonthStamp, nil); err == nil {
+		t.Error("expected error for malformed stamp")
+	}
+
+	found, ok := StampFromName("orders_v2_20240601.csv", DateStamp, nil)
+	if !ok || !found.Equal(date(2024, time.June, 1, 0, 0)) {
+		t.Errorf("StampFromName = %v, %t", found, ok)
+	}
+	found, ok = StampFromName("export-2024-05-31.tar.gz", ISODate, jakarta)
+	if !ok || found.Day() != 31 || found.Location() != jakarta {
+		t.Errorf("StampFromName ISO = %v, %t", found, ok)
+	}
+	if _, ok := StampFromName("orders.csv", DateStamp, nil); ok {
+		t.Error("expected no stamp")
+	}
+
+	if loc, err := LoadLocation(""); err != nil || loc != time.Local {
+		t.Errorf("LoadLocation(\"\") = %v, %v", loc, err)
+	}
+	if _, err := LoadLocation("Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown zone")
+	}
+}
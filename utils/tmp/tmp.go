@@ -0,0 +1,285 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package tmp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/romisugianto/go-utils/utils/lockfile"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/pathutil"
+)
+
+// ErrQuotaExceeded is returned when a write would exceed the manager's quota
+var ErrQuotaExceeded = errors.New("temp quota exceeded")
+
+// lockName is the lock file marking a manager directory as in use
+const lockName = ".owner.lock"
+
+// Options configures a TempManager
+type Options struct {
+	// BaseDir is where the manager's directory is created (default os.TempDir())
+	BaseDir string
+	// Quota limits the bytes written through the manager's files (0 for no limit)
+	Quota int64
+	// HandleSignals removes the directory and exits when the process receives
+	// SIGINT or SIGTERM. Leave it off when the application handles shutdown
+	// itself and calls Close.
+	HandleSignals bool
+}
+
+// TempManager owns a private temp directory and everything created in it
+type TempManager struct {
+	logger *logger.Logger
+	root   string
+	quota  int64
+	lock   *lockfile.Lock
+
+	mu     sync.Mutex
+	used   int64
+	files  map[string]int64 // tracked file -> bytes written through it
+	closed bool
+
+	signals chan os.Signal
+	stop    chan struct{}
+}
+
+// NewTempManager creates <BaseDir>/<namespace>-<random>. Directories of the
+// same namespace left behind by processes that are no longer running are
+// removed first. A nil opts uses the defaults.
+func NewTempManager(log *logger.Logger, namespace string, opts *Options) (*TempManager, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	if namespace == "" || strings.ContainsAny(namespace, `/\*`) {
+		return nil, fmt.Errorf("invalid namespace %q", namespace)
+	}
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.BaseDir == "" {
+		o.BaseDir = os.TempDir()
+	}
+	if err := os.MkdirAll(o.BaseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create base directory: %w", err)
+	}
+
+	m := &TempManager{logger: log, quota: o.Quota, files: map[string]int64{}}
+	m.removeStale(o.BaseDir, namespace)
+
+	root, err := os.MkdirTemp(o.BaseDir, namespace+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	lock, err := lockfile.Acquire(filepath.Join(root, lockName))
+	if err != nil {
+		os.RemoveAll(root)
+		return nil, err
+	}
+	m.root, m.lock = root, lock
+
+	if o.HandleSignals {
+		m.signals = make(chan os.Signal, 1)
+		m.stop = make(chan struct{})
+		signal.Notify(m.signals, os.Interrupt, syscall.SIGTERM)
+		go m.watchSignals()
+	}
+	return m, nil
+}
+
+// Dir returns the manager's directory
+func (m *TempManager) Dir() string {
+	return m.root
+}
+
+// CreateFile creates a tracked file using an os.CreateTemp pattern (e.g.
+// "part-*.csv"). Bytes written through the returned File count against the quota.
+func (m *TempManager) CreateFile(pattern string) (*File, error) {
+	if err := m.checkOpen(); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(m.root, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	m.mu.Lock()
+	m.files[file.Name()] = 0
+	m.mu.Unlock()
+	return &File{File: file, manager: m}, nil
+}
+
+// CreateDir creates a directory using an os.MkdirTemp pattern. Files written
+// into it directly are removed on Close and counted by Usage, but are not
+// limited by the quota as they are written.
+func (m *TempManager) CreateDir(pattern string) (string, error) {
+	if err := m.checkOpen(); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(m.root, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Remove deletes a file or directory inside the manager's directory and
+// releases its quota
+func (m *TempManager) Remove(path string) error {
+	if filepath.Clean(path) == filepath.Clean(m.root) || !pathutil.IsSubpath(m.root, path) {
+		return fmt.Errorf("%s is not inside %s", path, m.root)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, size := range m.files {
+		if name == path || strings.HasPrefix(name, path+string(filepath.Separator)) {
+			m.used -= size
+			delete(m.files, name)
+		}
+	}
+	return nil
+}
+
+// Usage returns the bytes currently stored in the manager's directory
+func (m *TempManager) Usage() (int64, error) {
+	var total int64
+	err := filepath.WalkDir(m.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure %s: %w", m.root, err)
+	}
+	return total, nil
+}
+
+// Close removes the manager's directory and everything in it. It is safe to
+// call more than once.
+func (m *TempManager) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	if m.signals != nil {
+		signal.Stop(m.signals)
+		close(m.stop)
+	}
+	m.lock.Release()
+	if err := os.RemoveAll(m.root); err != nil {
+		return fmt.Errorf("failed to remove temp directory %s: %w", m.root, err)
+	}
+	return nil
+}
+
+// reserve accounts n bytes written to name against the quota
+func (m *TempManager) reserve(name string, n int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.quota > 0 && m.used+n > m.quota {
+		return fmt.Errorf("%w: %d of %d bytes used", ErrQuotaExceeded, m.used, m.quota)
+	}
+	m.used += n
+	m.files[name] += n
+	return nil
+}
+
+func (m *TempManager) checkOpen() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return fmt.Errorf("temp manager is closed")
+	}
+	return nil
+}
+
+// watchSignals cleans up and exits when the process is interrupted
+func (m *TempManager) watchSignals() {
+	select {
+	case sig := <-m.signals:
+		m.logger.Warning("Received %s, removing temp directory %s", sig, m.root)
+		if err := m.Close(); err != nil {
+			m.logger.Error("%v", err)
+		}
+		os.Exit(1)
+	case <-m.stop:
+	}
+}
+
+// removeStale deletes namespace directories whose owning process has exited
+func (m *TempManager) removeStale(baseDir, namespace string) {
+	matches, _ := filepath.Glob(filepath.Join(baseDir, namespace+"-*"))
+	for _, dir := range matches {
+		lockPath := filepath.Join(dir, lockName)
+		if _, err := os.Stat(lockPath); err != nil {
+			continue
+		}
+		lock, err := lockfile.Acquire(lockPath)
+		if err != nil {
+			continue
+		}
+		lock.Release()
+		if err := os.RemoveAll(dir); err != nil {
+			m.logger.Warning("Failed to remove stale temp directory %s: %v", dir, err)
+			continue
+		}
+		m.logger.Info("Removed stale temp directory %s", dir)
+	}
+}
+
+// File is a temp file whose writes count against the manager's quota
+type File struct {
+	*os.File
+	manager *TempManager
+}
+
+// Write writes p if the quota allows it
+func (f *File) Write(p []byte) (int, error) {
+	if err := f.manager.reserve(f.Name(), int64(len(p))); err != nil {
+		return 0, err
+	}
+	return f.File.Write(p)
+}
+
+// WriteString writes s if the quota allows it
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// WriteAt writes p at off if the quota allows it
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.manager.reserve(f.Name(), int64(len(p))); err != nil {
+		return 0, err
+	}
+	return f.File.WriteAt(p, off)
+}
+
+// ReadFrom copies r into the file through Write so the quota applies
+func (f *File) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{f}, r)
+}
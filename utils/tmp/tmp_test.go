@@ -0,0 +1,150 @@
+package tmp
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func newTestManager(t *testing.T, opts *Options) *TempManager {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("tmp_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.BaseDir == "" {
+		opts.BaseDir = t.TempDir()
+	}
+	m, err := NewTempManager(testLogger, "etl", opts)
+	if err != nil {
+		t.Fatalf("NewTempManager failed: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestNewTempManagerValidation(t *testing.T) {
+	testLogger, _ := logger.NewLogger("tmp_test")
+	defer testLogger.Close()
+
+	if _, err := NewTempManager(nil, "etl", nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+	for _, namespace := range []string{"", "a/b", "etl*"} {
+		if _, err := NewTempManager(testLogger, namespace, &Options{BaseDir: t.TempDir()}); err == nil {
+			t.Errorf("expected error for namespace %q", namespace)
+		}
+	}
+}
+
+func TestCreateAndClose(t *testing.T) {
+	base := t.TempDir()
+	m := newTestManager(t, &Options{BaseDir: base})
+
+	if !strings.HasPrefix(filepath.Base(m.Dir()), "etl-") || filepath.Dir(m.Dir()) != base {
+		t.Errorf("unexpected directory %s", m.Dir())
+	}
+
+	file, err := m.CreateFile("part-*.csv")
+	if err != nil {
+		t.Fatalf("CreateFile failed: %v", err)
+	}
+	file.WriteString("a,b,c\n")
+	file.Close()
+
+	dir, err := m.CreateDir("unpacked-*")
+	if err != nil {
+		t.Fatalf("CreateDir failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "data.bin"), make([]byte, 100), 0644)
+
+	usage, err := m.Usage()
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	// The lock file holds the PID
+	if usage < 106 {
+		t.Errorf("usage = %d, expected at least 106", usage)
+	}
+
+	if err := m.Remove(dir); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if err := m.Remove(base); err == nil {
+		t.Error("expected error removing a path outside the manager")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(m.Dir()); !os.IsNotExist(err) {
+		t.Error("expected directory to be removed")
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+	if _, err := m.CreateFile("late-*"); err == nil {
+		t.Error("expected error creating a file after Close")
+	}
+}
+
+func TestQuota(t *testing.T) {
+	m := newTestManager(t, &Options{Quota: 10})
+
+	file, _ := m.CreateFile("quota-*")
+	defer file.Close()
+	if _, err := file.Write([]byte("12345678")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := file.Write([]byte("abc")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	other, _ := m.CreateFile("copy-*")
+	defer other.Close()
+	if _, err := io.Copy(other, strings.NewReader("more than two bytes")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded from io.Copy, got %v", err)
+	}
+
+	// Removing a file releases its quota
+	file.Close()
+	if err := m.Remove(file.Name()); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := other.Write([]byte("12345678")); err != nil {
+		t.Errorf("Write after Remove failed: %v", err)
+	}
+}
+
+func TestRemovesStaleDirectories(t *testing.T) {
+	base := t.TempDir()
+
+	// A directory left by a crashed process: its lock names a PID that is not running
+	stale := filepath.Join(base, "etl-123")
+	os.MkdirAll(stale, 0755)
+	os.WriteFile(filepath.Join(stale, lockName), []byte("999999999\n"), 0644)
+	os.WriteFile(filepath.Join(stale, "leftover.csv"), []byte("x"), 0644)
+
+	// Directories without a lock or owned by a live process are kept
+	unrelated := filepath.Join(base, "etl-manual")
+	os.MkdirAll(unrelated, 0755)
+	live := newTestManager(t, &Options{BaseDir: base})
+
+	newTestManager(t, &Options{BaseDir: base})
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("expected stale directory to be removed")
+	}
+	for _, dir := range []string{unrelated, live.Dir()} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected %s to be kept: %v", dir, err)
+		}
+	}
+}
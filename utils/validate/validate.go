@@ -0,0 +1,259 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrEmpty is returned for empty required values
+var ErrEmpty = errors.New("cannot be empty")
+
+// FieldError is a validation failure of a named argument or setting
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Validator collects the failures of several checks so they can be reported
+// together, e.g. all invalid settings of a job at startup
+type Validator struct {
+	errs []error
+}
+
+// New returns an empty Validator
+func New() *Validator {
+	return &Validator{}
+}
+
+// Err returns all failures joined with errors.Join, or nil when every check
+// passed. Each failure is a *FieldError.
+func (v *Validator) Err() error {
+	return errors.Join(v.errs...)
+}
+
+// Valid reports whether every check so far passed
+func (v *Validator) Valid() bool {
+	return len(v.errs) == 0
+}
+
+// add records err for field when it is not nil and reports whether it was nil
+func (v *Validator) add(field string, err error) bool {
+	if err == nil {
+		return true
+	}
+	v.errs = append(v.errs, &FieldError{Field: field, Err: err})
+	return false
+}
+
+// Check records a failure for field with the formatted message when ok is
+// false, for rules without a dedicated validator
+func (v *Validator) Check(ok bool, field, format string, args ...any) bool {
+	if ok {
+		return true
+	}
+	return v.add(field, fmt.Errorf(format, args...))
+}
+
+// NonEmpty checks that value is not empty or only whitespace
+func (v *Validator) NonEmpty(field, value string) bool {
+	return v.add(field, NonEmpty(value))
+}
+
+// Path checks that value is a usable path
+func (v *Validator) Path(field, value string) bool {
+	return v.add(field, Path(value))
+}
+
+// Dir checks that value is an existing directory
+func (v *Validator) Dir(field, value string) bool {
+	return v.add(field, Dir(value))
+}
+
+// File checks that value is an existing regular file
+func (v *Validator) File(field, value string) bool {
+	return v.add(field, File(value))
+}
+
+// Port checks that value is a TCP or UDP port number
+func (v *Validator) Port(field string, value int) bool {
+	return v.add(field, Port(value))
+}
+
+// Range checks that min <= value <= max
+func (v *Validator) Range(field string, value, min, max int) bool {
+	return v.add(field, Range(value, min, max))
+}
+
+// URL checks that value is an absolute URL with one of schemes and returns
+// it parsed, or nil on failure
+func (v *Validator) URL(field, value string, schemes ...string) *url.URL {
+	u, err := URL(value, schemes...)
+	v.add(field, err)
+	return u
+}
+
+// S3URI checks that value is an S3 URI and returns it parsed, or the zero
+// S3URI on failure
+func (v *Validator) S3URI(field, value string) S3URI {
+	uri, err := ParseS3URI(value)
+	v.add(field, err)
+	return uri
+}
+
+// NonEmpty returns ErrEmpty when value is empty or only whitespace
+func NonEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return ErrEmpty
+	}
+	return nil
+}
+
+// Path checks that p is not empty and contains no NUL byte
+func Path(p string) error {
+	if err := NonEmpty(p); err != nil {
+		return err
+	}
+	if strings.ContainsRune(p, 0) {
+		return fmt.Errorf("path %q contains a NUL byte", p)
+	}
+	return nil
+}
+
+// Dir checks that p is an existing directory. A missing directory returns
+// an error wrapping fs.ErrNotExist.
+func Dir(p string) error {
+	info, err := stat(p, "directory")
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", p)
+	}
+	return nil
+}
+
+// File checks that p is an existing regular file. A missing file returns an
+// error wrapping fs.ErrNotExist.
+func File(p string) error {
+	info, err := stat(p, "file")
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", p)
+	}
+	return nil
+}
+
+// stat validates p and returns its file info
+func stat(p, kind string) (fs.FileInfo, error) {
+	if err := Path(p); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &notExistError{msg: fmt.Sprintf("%s does not exist: %s", kind, p)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", p, err)
+	}
+	return info, nil
+}
+
+// notExistError reports a missing path and matches fs.ErrNotExist
+type notExistError struct {
+	msg string
+}
+
+func (e *notExistError) Error() string {
+	return e.msg
+}
+
+func (e *notExistError) Unwrap() error {
+	return fs.ErrNotExist
+}
+
+// Port checks that port is between 1 and 65535
+func Port(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", port)
+	}
+	return nil
+}
+
+// Range checks that min <= value <= max
+func Range(value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("must be between %d and %d, got %d", min, max, value)
+	}
+	return nil
+}
+
+// URL parses raw and checks that it is absolute with a host. With schemes,
+// e.g. "http", "https", the scheme must be one of them.
+func URL(raw string, schemes ...string) (*url.URL, error) {
+	if err := NonEmpty(raw); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("URL %q must be absolute, e.g. https://host/path", raw)
+	}
+	if len(schemes) > 0 && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+		return nil, fmt.Errorf("URL %q must use %s", u.Redacted(), strings.Join(schemes, " or "))
+	}
+	return u, nil
+}
+
+// bucketName matches S3 bucket naming rules: 3 to 63 lowercase letters,
+// digits, dots and hyphens, starting and ending with a letter or digit
+var bucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// S3URI is a parsed s3://bucket/key URI
+type S3URI struct {
+	Bucket string
+	// Key is the object key or prefix without a leading slash; empty for the
+	// whole bucket
+	Key string
+}
+
+// String returns the URI in s3://bucket/key form
+func (u S3URI) String() string {
+	return "s3://" + u.Bucket + "/" + u.Key
+}
+
+// ParseS3URI parses a URI such as "s3://bucket/path/to/key". The key may
+// be empty, as in "s3://bucket" or "s3://bucket/".
+func ParseS3URI(raw string) (S3URI, error) {
+	if err := NonEmpty(raw); err != nil {
+		return S3URI{}, err
+	}
+	rest, ok := strings.CutPrefix(raw, "s3://")
+	if !ok {
+		return S3URI{}, fmt.Errorf("S3 URI %q must start with s3://", raw)
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if !bucketName.MatchString(bucket) || strings.Contains(bucket, "..") {
+		return S3URI{}, fmt.Errorf("S3 URI %q has an invalid bucket name %q", raw, bucket)
+	}
+	return S3URI{Bucket: bucket, Key: strings.TrimLeft(key, "/")}, nil
+}
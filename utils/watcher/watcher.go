@@ -0,0 +1,429 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/romisugianto/go-utils/utils/logger"
+	"github.com/romisugianto/go-utils/utils/pathutil"
+)
+
+// Handler processes a file once it is stable. The context is cancelled when
+// the watcher stops.
+type Handler func(ctx context.Context, path string) error
+
+// Options configures a Watcher
+type Options struct {
+	// StableFor is how long a file's size and modification time must stay
+	// unchanged before it is handled (default 5s)
+	StableFor time.Duration
+	// PollInterval is how often stability is checked and, when polling,
+	// how often directories are listed (default 1s)
+	PollInterval time.Duration
+	// Pattern filters files by base name, e.g. "*.csv" (default all files)
+	Pattern string
+	// Recursive also watches subdirectories
+	Recursive bool
+	// ForcePolling lists directories instead of using filesystem events, for
+	// network mounts where events are not delivered
+	ForcePolling bool
+	// IncludeExisting handles files already present when the watcher starts
+	IncludeExisting bool
+}
+
+// Watcher triggers handlers for files that land in watched directories
+type Watcher struct {
+	logger *logger.Logger
+	opts   Options
+
+	mu     sync.Mutex
+	dirs   []*watchedDir
+	cancel context.CancelFunc
+	loop   sync.WaitGroup
+
+	// state owned by the run loop
+	polling    bool
+	fsw        *fsnotify.Watcher
+	candidates map[string]*candidate
+	handled    map[string]fileState
+	processing map[string]bool
+	done       chan string
+	handlers   sync.WaitGroup
+}
+
+// watchedDir is a registered directory and its handler
+type watchedDir struct {
+	path    string
+	handler Handler
+}
+
+// fileState identifies a version of a file
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// candidate is a file waiting to become stable
+type candidate struct {
+	dir     *watchedDir
+	state   fileState
+	changed time.Time
+}
+
+// NewWatcher creates a new Watcher instance. A nil opts uses the defaults.
+func NewWatcher(log *logger.Logger, opts *Options) (*Watcher, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	w := &Watcher{logger: log}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.StableFor <= 0 {
+		w.opts.StableFor = 5 * time.Second
+	}
+	if w.opts.PollInterval <= 0 {
+		w.opts.PollInterval = time.Second
+	}
+	if w.opts.Pattern != "" {
+		if _, err := filepath.Match(w.opts.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", w.opts.Pattern, err)
+		}
+	}
+	return w, nil
+}
+
+// Add registers a directory and the handler for its files. Directories must
+// be added before Start.
+func (w *Watcher) Add(dir string, handler Handler) error {
+	if handler == nil {
+		return fmt.Errorf("failed to watch %s: handler cannot be nil", dir)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("failed to watch %s: not a directory", dir)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return fmt.Errorf("failed to watch %s: watcher is already running", dir)
+	}
+	w.dirs = append(w.dirs, &watchedDir{path: filepath.Clean(dir), handler: handler})
+	return nil
+}
+
+// Start watches the registered directories in the background until ctx is
+// done or Stop is called. It falls back to polling when filesystem events are
+// unavailable.
+func (w *Watcher) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return fmt.Errorf("watcher is already running")
+	}
+	if len(w.dirs) == 0 {
+		return fmt.Errorf("no directories to watch")
+	}
+
+	w.candidates = map[string]*candidate{}
+	w.handled = map[string]fileState{}
+	w.processing = map[string]bool{}
+	w.done = make(chan string)
+	w.polling = w.opts.ForcePolling
+	if !w.polling {
+		if err := w.startEvents(); err != nil {
+			w.logger.Warning("Filesystem events unavailable, falling back to polling: %v", err)
+			w.polling = true
+		}
+	}
+
+	// Files present at startup are either handled or treated as already seen
+	for _, dir := range w.dirs {
+		w.scan(dir, !w.opts.IncludeExisting)
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.loop.Add(1)
+	go w.run(ctx)
+
+	mode := "filesystem events"
+	if w.polling {
+		mode = fmt.Sprintf("polling every %s", w.opts.PollInterval)
+	}
+	for _, dir := range w.dirs {
+		w.logger.Info("Watching %s using %s", dir.path, mode)
+	}
+	return nil
+}
+
+// Stop stops watching and waits for running handlers to return
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	w.loop.Wait()
+	w.logger.Info("Watcher stopped")
+}
+
+// startEvents creates the fsnotify watcher and registers every directory
+func (w *Watcher) startEvents() error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for _, dir := range w.dirs {
+		for _, path := range w.directories(dir.path) {
+			if err := fsw.Add(path); err != nil {
+				fsw.Close()
+				return fmt.Errorf("failed to watch %s: %w", path, err)
+			}
+		}
+	}
+	w.fsw = fsw
+	return nil
+}
+
+// directories returns root and, when recursive, its subdirectories
+func (w *Watcher) directories(root string) []string {
+	if !w.opts.Recursive {
+		return []string{root}
+	}
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// run is the event loop; it owns the candidate state
+func (w *Watcher) run(ctx context.Context) {
+	defer w.loop.Done()
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w.fsw != nil {
+		defer w.fsw.Close()
+		events, errs = w.fsw.Events, w.fsw.Errors
+	}
+
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Drain completions so running handlers can finish
+			go func() {
+				for range w.done {
+				}
+			}()
+			w.handlers.Wait()
+			close(w.done)
+			return
+		case event := <-events:
+			w.handleEvent(event)
+		case err := <-errs:
+			w.logger.Warning("Watcher error: %v", err)
+		case path := <-w.done:
+			delete(w.processing, path)
+		case <-ticker.C:
+			if w.polling {
+				for _, dir := range w.dirs {
+					w.scan(dir, false)
+				}
+			}
+			w.checkCandidates(ctx)
+		}
+	}
+}
+
+// handleEvent updates candidates from a filesystem event
+func (w *Watcher) handleEvent(event fsnotify.Event) {
+	path := filepath.Clean(event.Name)
+	switch {
+	case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		dir := w.owner(path)
+		if dir == nil {
+			return
+		}
+		if info.IsDir() {
+			if w.opts.Recursive {
+				if err := w.fsw.Add(path); err != nil {
+					w.logger.Warning("Failed to watch new directory %s: %v", path, err)
+				}
+				// Files may have landed before the watch was added
+				w.scanDir(dir, path, false)
+			}
+			return
+		}
+		w.track(dir, path, info)
+	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+		delete(w.candidates, path)
+		delete(w.handled, path)
+	}
+}
+
+// scan lists a watched directory, tracking new or changed files. With
+// baseline set, files are marked as handled instead.
+func (w *Watcher) scan(dir *watchedDir, baseline bool) {
+	w.scanDir(dir, dir.path, baseline)
+}
+
+// scanDir lists root (recursively if configured) on behalf of dir
+func (w *Watcher) scanDir(dir *watchedDir, root string, baseline bool) {
+	seen := map[string]bool{}
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && !w.opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || w.owner(path) != dir {
+			return nil
+		}
+		seen[path] = true
+		if baseline {
+			if w.matches(path) {
+				w.handled[path] = stateOf(info)
+			}
+			return nil
+		}
+		w.track(dir, path, info)
+		return nil
+	})
+
+	// Forget handled files that have been moved away so a new file with the
+	// same name is picked up
+	if w.polling && !baseline && root == dir.path {
+		for path := range w.handled {
+			if !seen[path] && w.owner(path) == dir {
+				delete(w.handled, path)
+			}
+		}
+	}
+}
+
+// track adds or refreshes a candidate for a matching file
+func (w *Watcher) track(dir *watchedDir, path string, info fs.FileInfo) {
+	if !info.Mode().IsRegular() || !w.matches(path) {
+		return
+	}
+	state := stateOf(info)
+	if handled, ok := w.handled[path]; ok && handled == state {
+		return
+	}
+	if c, ok := w.candidates[path]; ok {
+		if c.state != state {
+			c.state, c.changed = state, time.Now()
+		}
+		return
+	}
+	w.candidates[path] = &candidate{dir: dir, state: state, changed: time.Now()}
+}
+
+// checkCandidates dispatches files whose size and modification time have not
+// changed for StableFor
+func (w *Watcher) checkCandidates(ctx context.Context) {
+	now := time.Now()
+	for path, c := range w.candidates {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			delete(w.candidates, path)
+			continue
+		}
+		if state := stateOf(info); state != c.state {
+			c.state, c.changed = state, now
+			continue
+		}
+		if now.Sub(c.changed) < w.opts.StableFor || w.processing[path] {
+			continue
+		}
+
+		delete(w.candidates, path)
+		w.handled[path] = c.state
+		w.processing[path] = true
+		w.handlers.Add(1)
+		go w.dispatch(ctx, c.dir, path)
+	}
+}
+
+// dispatch runs the handler with timing, error logging and panic recovery
+func (w *Watcher) dispatch(ctx context.Context, dir *watchedDir, path string) {
+	defer w.handlers.Done()
+	defer func() { w.done <- path }()
+
+	start := time.Now()
+	w.logger.Info("File %s is stable, processing", path)
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error("Handler for %s panicked: %v\n%s", path, r, debug.Stack())
+		}
+	}()
+
+	if err := dir.handler(ctx, path); err != nil {
+		w.logger.Error("Failed to process %s after %s: %v", path, time.Since(start).Round(time.Millisecond), err)
+		return
+	}
+	w.logger.Info("Processed %s in %s", path, time.Since(start).Round(time.Millisecond))
+}
+
+// owner returns the watched directory containing path
+func (w *Watcher) owner(path string) *watchedDir {
+	var best *watchedDir
+	for _, dir := range w.dirs {
+		if !pathutil.IsSubpath(dir.path, path) {
+			continue
+		}
+		if !w.opts.Recursive && filepath.Dir(path) != dir.path {
+			continue
+		}
+		// Prefer the most specific directory when watched directories nest
+		if best == nil || len(dir.path) > len(best.path) {
+			best = dir
+		}
+	}
+	return best
+}
+
+// matches reports whether the file's base name matches Pattern
+func (w *Watcher) matches(path string) bool {
+	if w.opts.Pattern == "" {
+		return true
+	}
+	ok, _ := filepath.Match(w.opts.Pattern, filepath.Base(path))
+	return ok
+}
+
+func stateOf(info fs.FileInfo) fileState {
+	return fileState{size: info.Size(), modTime: info.ModTime()}
+}
@@ -0,0 +1,206 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// recorder collects handled paths
+type recorder struct {
+	mu    sync.Mutex
+	paths []string
+	ch    chan string
+}
+
+func newRecorder() *recorder {
+	return &recorder{ch: make(chan string, 16)}
+}
+
+func (r *recorder) handle(ctx context.Context, path string) error {
+	r.mu.Lock()
+	r.paths = append(r.paths, path)
+	r.mu.Unlock()
+	r.ch <- path
+	return nil
+}
+
+func (r *recorder) wait(t *testing.T, timeout time.Duration) string {
+	t.Helper()
+	select {
+	case path := <-r.ch:
+		return path
+	case <-time.After(timeout):
+		t.Fatalf("timed out waiting for handler")
+		return ""
+	}
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.paths)
+}
+
+func newTestWatcher(t *testing.T, opts *Options) *Watcher {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("watcher_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	w, err := NewWatcher(testLogger, opts)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	return w
+}
+
+func TestNewWatcherValidation(t *testing.T) {
+	if _, err := NewWatcher(nil, nil); err == nil {
+		t.Error("expected error for nil logger")
+	}
+
+	testLogger, _ := logger.NewLogger("watcher_test")
+	defer testLogger.Close()
+	if _, err := NewWatcher(testLogger, &Options{Pattern: "[a-"}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+
+	w, _ := NewWatcher(testLogger, nil)
+	if err := w.Add(filepath.Join(t.TempDir(), "missing"), func(context.Context, string) error { return nil }); err == nil {
+		t.Error("expected error for missing directory")
+	}
+	if err := w.Add(t.TempDir(), nil); err == nil {
+		t.Error("expected error for nil handler")
+	}
+	if err := w.Start(context.Background()); err == nil {
+		t.Error("expected error when no directories are added")
+	}
+}
+
+func testWatcherHandlesStableFiles(t *testing.T, polling bool) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "existing.csv"), []byte("old"), 0644)
+
+	w := newTestWatcher(t, &Options{
+		StableFor:    200 * time.Millisecond,
+		PollInterval: 50 * time.Millisecond,
+		Pattern:      "*.csv",
+		ForcePolling: polling,
+	})
+	rec := newRecorder()
+	if err := w.Add(dir, rec.handle); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer w.Stop()
+
+	// Grow the file while the watcher is running; it must not fire early
+	path := filepath.Join(dir, "inbound.csv")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	start := time.Now()
+	var lastWrite time.Time
+	for i := 0; i < 5; i++ {
+		file.WriteString("a,b,c\n")
+		file.Sync()
+		lastWrite = time.Now()
+		time.Sleep(60 * time.Millisecond)
+	}
+	file.Close()
+	os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("x"), 0644)
+
+	if got := rec.wait(t, 5*time.Second); got != path {
+		t.Errorf("handled %s, expected %s", got, path)
+	}
+	if elapsed := time.Since(lastWrite); elapsed < 150*time.Millisecond {
+		t.Errorf("handler fired %s after the last write, expected at least StableFor", elapsed)
+	}
+	if time.Since(start) < 300*time.Millisecond {
+		t.Error("handler fired before the file stopped growing")
+	}
+
+	// Unchanged files are not handled again; existing and filtered files never are
+	time.Sleep(400 * time.Millisecond)
+	if n := rec.count(); n != 1 {
+		t.Errorf("expected 1 handled file, got %d", n)
+	}
+}
+
+func TestWatcherEvents(t *testing.T) {
+	testWatcherHandlesStableFiles(t, false)
+}
+
+func TestWatcherPolling(t *testing.T) {
+	testWatcherHandlesStableFiles(t, true)
+}
+
+func TestWatcherIncludeExistingAndRecursive(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.dat")
+	os.WriteFile(existing, []byte("old"), 0644)
+
+	w := newTestWatcher(t, &Options{
+		StableFor:       100 * time.Millisecond,
+		PollInterval:    50 * time.Millisecond,
+		Recursive:       true,
+		IncludeExisting: true,
+	})
+	rec := newRecorder()
+	w.Add(dir, rec.handle)
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer w.Stop()
+
+	if got := rec.wait(t, 5*time.Second); got != existing {
+		t.Errorf("handled %s, expected %s", got, existing)
+	}
+
+	nested := filepath.Join(dir, "2024", "01")
+	os.MkdirAll(nested, 0755)
+	path := filepath.Join(nested, "new.dat")
+	os.WriteFile(path, []byte("new"), 0644)
+	if got := rec.wait(t, 5*time.Second); got != path {
+		t.Errorf("handled %s, expected %s", got, path)
+	}
+}
+
+func TestWatcherStopCancelsHandlers(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWatcher(t, &Options{StableFor: 50 * time.Millisecond, PollInterval: 20 * time.Millisecond})
+
+	started := make(chan struct{})
+	var cancelled bool
+	w.Add(dir, func(ctx context.Context, path string) error {
+		close(started)
+		<-ctx.Done()
+		cancelled = true
+		return ctx.Err()
+	})
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "slow.dat"), []byte("x"), 0644)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not start")
+	}
+	w.Stop()
+	if !cancelled {
+		t.Error("expected Stop to cancel and wait for the running handler")
+	}
+	if err := w.Add(dir, func(context.Context, string) error { return nil }); err != nil {
+		t.Errorf("Add after Stop failed: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// S3Record is one object event from an S3 event notification
+type S3Record struct {
+	// EventName is e.g. "ObjectCreated:Put" (the "s3:" prefix is not included)
+	EventName string
+	EventTime time.Time
+	Bucket    string
+	// Key is the object key, already URL-decoded
+	Key  string
+	Size int64
+	ETag string
+}
+
+// s3Notification is the JSON document S3 sends for object events
+type s3Notification struct {
+	Records []struct {
+		EventName string    `json:"eventName"`
+		EventTime time.Time `json:"eventTime"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key  string `json:"key"`
+				Size int64  `json:"size"`
+				ETag string `json:"eTag"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+	Event string `json:"Event"`
+}
+
+// S3Records parses the body as an S3 event notification, as delivered
+// directly or through HandleSNS. The s3:TestEvent sent when notifications are
+// configured yields no records.
+func (e *Event) S3Records() ([]S3Record, error) {
+	var n s3Notification
+	if err := json.Unmarshal(e.Body, &n); err != nil {
+		return nil, fmt.Errorf("failed to parse S3 event notification: %w", err)
+	}
+	if len(n.Records) == 0 && n.Event == "" {
+		return nil, fmt.Errorf("body is not an S3 event notification")
+	}
+
+	records := make([]S3Record, 0, len(n.Records))
+	for _, r := range n.Records {
+		// Keys are URL-encoded with spaces as '+'
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object key %q: %w", r.S3.Object.Key, err)
+		}
+		records = append(records, S3Record{
+			EventName: r.EventName,
+			EventTime: r.EventTime,
+			Bucket:    r.S3.Bucket.Name,
+			Key:       key,
+			Size:      r.S3.Object.Size,
+			ETag:      r.S3.Object.ETag,
+		})
+	}
+	return records, nil
+}
+
+// JSON decodes the body into v
+func (e *Event) JSON(v any) error {
+	if err := json.Unmarshal(e.Body, v); err != nil {
+		return fmt.Errorf("failed to decode webhook body: %w", err)
+	}
+	return nil
+}
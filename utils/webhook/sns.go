@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// snsHostPattern matches the hosts SNS signing certificates and subscription
+// URLs are served from
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsMessage is the JSON body SNS posts to HTTP(S) subscribers
+type snsMessage struct {
+	Type             string
+	MessageId        string
+	Token            string
+	TopicArn         string
+	Subject          string
+	Message          string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// HandleSNS registers an endpoint for an SNS HTTP(S) subscription to
+// topicARN. Message signatures are verified against the SNS signing
+// certificate, subscription confirmations for the topic are accepted
+// automatically, and each notification is passed to handler with the SNS
+// message as the event body. Messages from other topics are rejected.
+func (s *Server) HandleSNS(path, topicARN string, handler Handler) error {
+	if handler == nil {
+		return fmt.Errorf("failed to add route %s: handler cannot be nil", path)
+	}
+	if topicARN == "" {
+		return fmt.Errorf("failed to add route %s: topic ARN cannot be empty", path)
+	}
+	return s.addRoute(path, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := s.readBody(w, r)
+		if !ok {
+			return
+		}
+
+		var msg snsMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			http.Error(w, "invalid SNS message", http.StatusBadRequest)
+			return
+		}
+		if msg.TopicArn != topicARN {
+			s.logger.Warning("Rejected SNS message on %s for unexpected topic %s", path, msg.TopicArn)
+			http.Error(w, "unexpected topic", http.StatusForbidden)
+			return
+		}
+		if err := s.verifySNS(r.Context(), &msg); err != nil {
+			s.logger.Warning("Rejected SNS message on %s: %v", path, err)
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		switch msg.Type {
+		case "SubscriptionConfirmation":
+			if err := s.confirmSubscription(r.Context(), msg.SubscribeURL); err != nil {
+				s.logger.Error("Failed to confirm SNS subscription to %s: %v", topicARN, err)
+				http.Error(w, "confirmation failed", http.StatusBadGateway)
+				return
+			}
+			s.logger.Info("Confirmed SNS subscription to %s on %s", topicARN, path)
+			w.WriteHeader(http.StatusNoContent)
+		case "Notification":
+			s.dispatch(w, r, handler, &Event{
+				Route:      path,
+				Header:     r.Header,
+				Body:       []byte(msg.Message),
+				ReceivedAt: time.Now(),
+				MessageID:  msg.MessageId,
+				Subject:    msg.Subject,
+			})
+		default:
+			// UnsubscribeConfirmation and future types need no action
+			s.logger.Info("Ignored SNS %s message on %s", msg.Type, path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+// verifySNS checks the message signature with the SNS signing certificate
+func (s *Server) verifySNS(ctx context.Context, msg *snsMessage) error {
+	var hashType crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hashType = crypto.SHA1
+	case "2":
+		hashType = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSignature, msg.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	key, err := s.signingKey(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	canonical := []byte(snsCanonical(msg))
+	var digest []byte
+	if hashType == crypto.SHA1 {
+		sum := sha1.Sum(canonical)
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256(canonical)
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hashType, digest, signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// snsCanonical builds the string SNS signs for a message type
+func snsCanonical(msg *snsMessage) string {
+	fields := [][2]string{{"Message", msg.Message}, {"MessageId", msg.MessageId}}
+	if msg.Type == "Notification" {
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", msg.SubscribeURL})
+	}
+	fields = append(fields, [2]string{"Timestamp", msg.Timestamp})
+	if msg.Type != "Notification" {
+		fields = append(fields, [2]string{"Token", msg.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", msg.TopicArn}, [2]string{"Type", msg.Type})
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String()
+}
+
+// signingKey downloads and caches the RSA key of an SNS signing certificate
+func (s *Server) signingKey(ctx context.Context, certURL string) (*rsa.PublicKey, error) {
+	if err := checkSNSURL(certURL); err != nil {
+		return nil, fmt.Errorf("%w: signing certificate %v", ErrInvalidSignature, err)
+	}
+
+	s.certMu.Lock()
+	cached, ok := s.certs[certURL]
+	s.certMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	body, err := s.get(ctx, certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("signing certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("signing certificate does not contain an RSA key")
+	}
+
+	s.certMu.Lock()
+	s.certs[certURL] = key
+	s.certMu.Unlock()
+	return key, nil
+}
+
+// confirmSubscription visits the SubscribeURL of a confirmation message
+func (s *Server) confirmSubscription(ctx context.Context, subscribeURL string) error {
+	if err := checkSNSURL(subscribeURL); err != nil {
+		return fmt.Errorf("subscribe URL %v", err)
+	}
+	_, err := s.get(ctx, subscribeURL)
+	return err
+}
+
+func (s *Server) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return body, nil
+}
+
+// checkSNSURL rejects URLs that are not served over HTTPS by SNS, so a forged
+// message cannot point the server at an attacker's certificate
+func checkSNSURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || !snsHostPattern.MatchString(u.Hostname()) {
+		return fmt.Errorf("%q is not an SNS HTTPS URL", rawURL)
+	}
+	return nil
+}
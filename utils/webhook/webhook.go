@@ -0,0 +1,274 @@
+// Created by Romi Sugianto - https://romisugi.dev
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+// ErrInvalidSignature is returned by a Verifier when a request is not authentic
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Event is a received webhook payload
+type Event struct {
+	// Route is the path the event was received on
+	Route      string
+	Header     http.Header
+	Body       []byte
+	ReceivedAt time.Time
+	// MessageID and Subject are set for SNS notifications
+	MessageID string
+	Subject   string
+}
+
+// Handler processes an event. Returning an error responds with 500 so the
+// sender retries the delivery.
+type Handler func(ctx context.Context, event *Event) error
+
+// Verifier checks the authenticity of a request given its raw body
+type Verifier func(r *http.Request, body []byte) error
+
+// Options configures a Server
+type Options struct {
+	// MaxBodySize limits request bodies (default 1 MB)
+	MaxBodySize int64
+	// HandlerTimeout bounds each handler call (default 30s)
+	HandlerTimeout time.Duration
+	// ShutdownTimeout bounds the wait for in-flight requests on shutdown (default 10s)
+	ShutdownTimeout time.Duration
+}
+
+// Server receives webhooks over HTTP and dispatches them to handlers
+type Server struct {
+	logger *logger.Logger
+	opts   Options
+	// client fetches SNS signing certificates and confirms subscriptions
+	client *http.Client
+
+	mu     sync.RWMutex
+	routes map[string]http.HandlerFunc
+
+	certMu sync.Mutex
+	certs  map[string]*rsa.PublicKey
+}
+
+// NewServer creates a webhook server. A nil opts uses the defaults.
+func NewServer(log *logger.Logger, opts *Options) (*Server, error) {
+	if log == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+	s := &Server{
+		logger: log,
+		client: &http.Client{Timeout: 10 * time.Second},
+		routes: map[string]http.HandlerFunc{},
+		certs:  map[string]*rsa.PublicKey{},
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MaxBodySize <= 0 {
+		s.opts.MaxBodySize = 1 << 20
+	}
+	if s.opts.HandlerTimeout <= 0 {
+		s.opts.HandlerTimeout = 30 * time.Second
+	}
+	if s.opts.ShutdownTimeout <= 0 {
+		s.opts.ShutdownTimeout = 10 * time.Second
+	}
+	return s, nil
+}
+
+// Handle registers a handler for POST requests to path. A nil verifier
+// accepts unsigned requests; use it only behind another form of authentication.
+func (s *Server) Handle(path string, verifier Verifier, handler Handler) error {
+	if handler == nil {
+		return fmt.Errorf("failed to add route %s: handler cannot be nil", path)
+	}
+	if verifier == nil {
+		s.logger.Warning("Webhook route %s accepts unsigned requests", path)
+	}
+	return s.addRoute(path, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := s.readBody(w, r)
+		if !ok {
+			return
+		}
+		if verifier != nil {
+			if err := verifier(r, body); err != nil {
+				s.logger.Warning("Rejected webhook on %s from %s: %v", path, r.RemoteAddr, err)
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+		}
+		s.dispatch(w, r, handler, &Event{Route: path, Header: r.Header, Body: body, ReceivedAt: time.Now()})
+	})
+}
+
+// Handler returns the HTTP handler serving the registered routes
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		route, ok := s.routes[r.URL.Path]
+		s.mu.RUnlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		route(w, r)
+	})
+}
+
+// ListenAndServe serves webhooks on addr (e.g. ":8080") until ctx is done,
+// then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve is like ListenAndServe but uses an existing listener
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+	s.logger.Info("Webhook server listening on %s", listener.Addr())
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("webhook server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down webhook server: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	s.logger.Info("Webhook server stopped")
+	return nil
+}
+
+func (s *Server) addRoute(path string, route http.HandlerFunc) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("failed to add route %s: path must start with /", path)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.routes[path]; ok {
+		return fmt.Errorf("failed to add route %s: a route with this path already exists", path)
+	}
+	s.routes[path] = route
+	return nil
+}
+
+// readBody reads the request body within MaxBodySize, responding with an
+// error when it cannot
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.opts.MaxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+		}
+		s.logger.Warning("Failed to read webhook body on %s: %v", r.URL.Path, err)
+		return nil, false
+	}
+	return body, true
+}
+
+// dispatch runs the handler within the timeout and writes the response
+func (s *Server) dispatch(w http.ResponseWriter, r *http.Request, handler Handler, event *Event) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.opts.HandlerTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- fmt.Errorf("handler panicked: %v", rec)
+			}
+		}()
+		done <- handler(ctx, event)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("handler did not complete: %w", ctx.Err())
+	}
+	if err != nil {
+		s.logger.Error("Webhook handler for %s failed: %v", event.Route, err)
+		http.Error(w, "handler failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HMACSHA256 verifies a hex HMAC-SHA256 of the body in header. An optional
+// "sha256=" prefix, as sent by GitHub and others, is accepted.
+func HMACSHA256(secret, header string) Verifier {
+	return hmacVerifier(sha256.New, "sha256=", secret, header)
+}
+
+// HMACSHA1 verifies a hex HMAC-SHA1 of the body in header, for senders that
+// do not support SHA-256. An optional "sha1=" prefix is accepted.
+func HMACSHA1(secret, header string) Verifier {
+	return hmacVerifier(sha1.New, "sha1=", secret, header)
+}
+
+func hmacVerifier(newHash func() hash.Hash, prefix, secret, header string) Verifier {
+	return func(r *http.Request, body []byte) error {
+		if secret == "" {
+			return fmt.Errorf("%w: no secret configured", ErrInvalidSignature)
+		}
+		signature := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(header)), prefix)
+		if signature == "" {
+			return fmt.Errorf("%w: missing %s header", ErrInvalidSignature, header)
+		}
+		received, err := hex.DecodeString(signature)
+		if err != nil {
+			return fmt.Errorf("%w: malformed %s header", ErrInvalidSignature, header)
+		}
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write(body)
+		if !hmac.Equal(received, mac.Sum(nil)) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body, for senders and tests
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,267 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/romisugianto/go-utils/utils/logger"
+)
+
+func newTestServer(t *testing.T, opts *Options) *Server {
+	t.Helper()
+	testLogger, _ := logger.NewLogger("webhook_test")
+	t.Cleanup(func() { testLogger.Close() })
+
+	s, err := NewServer(testLogger, opts)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	return s
+}
+
+func post(t *testing.T, url string, body []byte, header map[string]string) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+const s3Event = `{"Records":[{"eventName":"ObjectCreated:Put","eventTime":"2024-06-01T02:00:00.000Z",
+"s3":{"bucket":{"name":"inbound"},"object":{"key":"orders/june+orders%282%29.csv","size":1024,"eTag":"abc"}}}]}`
+
+func TestHandleHMAC(t *testing.T) {
+	s := newTestServer(t, &Options{MaxBodySize: 1024, HandlerTimeout: 100 * time.Millisecond})
+	var received []S3Record
+	err := s.Handle("/hooks/s3", HMACSHA256("s3cret", "X-Signature"), func(ctx context.Context, event *Event) error {
+		records, err := event.S3Records()
+		received = append(received, records...)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	s.Handle("/hooks/slow", nil, func(ctx context.Context, event *Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := s.Handle("/hooks/s3", nil, func(ctx context.Context, event *Event) error { return nil }); err == nil {
+		t.Error("expected error for duplicate route")
+	}
+	if err := s.Handle("hooks", nil, func(ctx context.Context, event *Event) error { return nil }); err == nil {
+		t.Error("expected error for relative path")
+	}
+
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+	body := []byte(s3Event)
+
+	if code := post(t, server.URL+"/hooks/s3", body, map[string]string{"X-Signature": "sha256=" + Sign("s3cret", body)}); code != http.StatusNoContent {
+		t.Errorf("valid request returned %d", code)
+	}
+	if len(received) != 1 || received[0].Key != "orders/june orders(2).csv" || received[0].Bucket != "inbound" || received[0].Size != 1024 {
+		t.Errorf("unexpected records %+v", received)
+	}
+
+	cases := []struct {
+		path   string
+		body   []byte
+		header map[string]string
+		code   int
+	}{
+		{"/hooks/s3", body, map[string]string{"X-Signature": Sign("wrong", body)}, http.StatusUnauthorized},
+		{"/hooks/s3", body, nil, http.StatusUnauthorized},
+		{"/hooks/s3", []byte("{}"), map[string]string{"X-Signature": Sign("s3cret", []byte("{}"))}, http.StatusInternalServerError},
+		{"/hooks/s3", bytes.Repeat([]byte("x"), 2048), nil, http.StatusRequestEntityTooLarge},
+		{"/hooks/slow", body, nil, http.StatusInternalServerError},
+		{"/hooks/missing", body, nil, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		if code := post(t, server.URL+c.path, c.body, c.header); code != c.code {
+			t.Errorf("POST %s returned %d, expected %d", c.path, code, c.code)
+		}
+	}
+
+	resp, _ := http.Get(server.URL + "/hooks/s3")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET returned %d", resp.StatusCode)
+	}
+}
+
+// fakeSNS signs messages like SNS and serves the signing certificate and
+// subscription confirmation URL
+type fakeSNS struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	confirmed atomic.Bool
+}
+
+func newFakeSNS(t *testing.T, s *Server) *fakeSNS {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	f := &fakeSNS{key: key}
+	f.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cert.pem":
+			w.Write(certPEM)
+		case "/confirm":
+			f.confirmed.Store(true)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.server.Close)
+
+	// Accept the test server as an SNS host
+	original := snsHostPattern
+	snsHostPattern = regexp.MustCompile(`^127\.0\.0\.1$`)
+	t.Cleanup(func() { snsHostPattern = original })
+	s.client = f.server.Client()
+	return f
+}
+
+func (f *fakeSNS) message(msgType, topic, message string) []byte {
+	msg := &snsMessage{
+		Type:             msgType,
+		MessageId:        "6a9b2c1e",
+		TopicArn:         topic,
+		Message:          message,
+		Timestamp:        "2024-06-01T02:00:00.000Z",
+		SignatureVersion: "2",
+		SigningCertURL:   f.server.URL + "/cert.pem",
+	}
+	if msgType == "SubscriptionConfirmation" {
+		msg.Token = "token"
+		msg.SubscribeURL = f.server.URL + "/confirm"
+	} else {
+		msg.Subject = "Amazon S3 Notification"
+	}
+	digest := sha256.Sum256([]byte(snsCanonical(msg)))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	msg.Signature = base64.StdEncoding.EncodeToString(signature)
+	body, _ := json.Marshal(msg)
+	return body
+}
+
+func TestHandleSNS(t *testing.T) {
+	s := newTestServer(t, nil)
+	sns := newFakeSNS(t, s)
+	const topic = "arn:aws:sns:us-east-1:123456789012:inbound-events"
+
+	var events []*Event
+	if err := s.HandleSNS("/hooks/sns", topic, func(ctx context.Context, event *Event) error {
+		events = append(events, event)
+		return nil
+	}); err != nil {
+		t.Fatalf("HandleSNS failed: %v", err)
+	}
+	if err := s.HandleSNS("/hooks/other", "", func(ctx context.Context, event *Event) error { return nil }); err == nil {
+		t.Error("expected error for empty topic")
+	}
+
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+	url := server.URL + "/hooks/sns"
+
+	if code := post(t, url, sns.message("SubscriptionConfirmation", topic, "confirm"), nil); code != http.StatusNoContent || !sns.confirmed.Load() {
+		t.Errorf("subscription confirmation returned %d, confirmed %v", code, sns.confirmed.Load())
+	}
+
+	if code := post(t, url, sns.message("Notification", topic, s3Event), nil); code != http.StatusNoContent {
+		t.Fatalf("notification returned %d", code)
+	}
+	if len(events) != 1 || events[0].MessageID != "6a9b2c1e" || events[0].Subject != "Amazon S3 Notification" {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if records, err := events[0].S3Records(); err != nil || len(records) != 1 {
+		t.Errorf("S3Records = %+v, %v", records, err)
+	}
+
+	tampered := bytes.Replace(sns.message("Notification", topic, s3Event), []byte("june"), []byte("july"), 1)
+	if code := post(t, url, tampered, nil); code != http.StatusUnauthorized {
+		t.Errorf("tampered message returned %d", code)
+	}
+	if code := post(t, url, sns.message("Notification", topic+"-other", s3Event), nil); code != http.StatusForbidden {
+		t.Errorf("message for another topic returned %d", code)
+	}
+
+	// Certificates outside the SNS hosts are never fetched
+	forged := strings.Replace(string(sns.message("Notification", topic, s3Event)), sns.server.URL, "https://attacker.example.com", 1)
+	if code := post(t, url, []byte(forged), nil); code != http.StatusUnauthorized {
+		t.Errorf("forged certificate URL returned %d", code)
+	}
+	if len(events) != 1 {
+		t.Errorf("rejected messages reached the handler")
+	}
+}
+
+func TestS3RecordsTestEvent(t *testing.T) {
+	event := &Event{Body: []byte(`{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"inbound"}`)}
+	if records, err := event.S3Records(); err != nil || len(records) != 0 {
+		t.Errorf("S3Records = %v, %v", records, err)
+	}
+	if _, err := (&Event{Body: []byte(`{"hello":"world"}`)}).S3Records(); err == nil {
+		t.Error("expected error for a body that is not an S3 notification")
+	}
+}
+
+func TestServe(t *testing.T) {
+	s := newTestServer(t, nil)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, listener) }()
+
+	s.Handle("/hooks/ping", nil, func(ctx context.Context, event *Event) error { return nil })
+	if code := post(t, "http://"+listener.Addr().String()+"/hooks/ping", []byte("{}"), nil); code != http.StatusNoContent {
+		t.Errorf("ping returned %d", code)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after cancel")
+	}
+}